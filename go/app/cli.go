@@ -17,6 +17,8 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
@@ -28,6 +30,7 @@ import (
 
 	"github.com/github/orchestrator/go/agent"
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/events"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/kv"
 	"github.com/github/orchestrator/go/logic"
@@ -39,6 +42,84 @@ import (
 var thisInstanceKey *inst.InstanceKey
 var knownCommands []CliCommand
 
+// mutatingCliCommands lists the commands which change topology, instance or recovery state. These
+// require operation attribution (see RequireOperationReason)
+var mutatingCliCommands = map[string]bool{
+	"relocate":                        true,
+	"relocate-below":                  true,
+	"relocate-replicas":               true,
+	"take-siblings":                   true,
+	"regroup-replicas":                true,
+	"topology-apply":                  true,
+	"move-up":                         true,
+	"move-up-replicas":                true,
+	"move-below":                      true,
+	"move-equivalent":                 true,
+	"repoint":                         true,
+	"undo-last-relocation":            true,
+	"repoint-replicas":                true,
+	"take-master":                     true,
+	"make-co-master":                  true,
+	"regroup-replicas-bls":            true,
+	"retire-binlog-server-family":     true,
+	"move-gtid":                       true,
+	"move-replicas-gtid":              true,
+	"regroup-replicas-gtid":           true,
+	"match":                           true,
+	"match-up":                        true,
+	"rematch":                         true,
+	"match-replicas":                  true,
+	"match-up-replicas":               true,
+	"regroup-replicas-pgtid":          true,
+	"enable-gtid":                     true,
+	"disable-gtid":                    true,
+	"enable-cluster-replication-ssl":  true,
+	"set-replica-concurrency":         true,
+	"reset-replica-concurrency":       true,
+	"gtid-errant-reset-master":        true,
+	"gtid-rollback":                   true,
+	"skip-query":                      true,
+	"stop-slave":                      true,
+	"start-slave":                     true,
+	"restart-slave":                   true,
+	"reset-slave":                     true,
+	"detach-replica-master-host":      true,
+	"reattach-replica-master-host":    true,
+	"set-master-delay":                true,
+	"clear-master-delay":              true,
+	"enable-semi-sync-master":         true,
+	"disable-semi-sync-master":        true,
+	"enable-semi-sync-replica":        true,
+	"disable-semi-sync-replica":       true,
+	"enforce-semi-sync":               true,
+	"forget":                          true,
+	"begin-maintenance":               true,
+	"end-maintenance":                 true,
+	"begin-downtime":                  true,
+	"end-downtime":                    true,
+	"recover":                         true,
+	"recover-lite":                    true,
+	"force-master-failover":           true,
+	"force-master-takeover":           true,
+	"force-master-failover-to":        true,
+	"graceful-master-takeover":        true,
+	"graceful-master-takeover-auto":   true,
+	"recover-undo":                    true,
+	"ack-all-recoveries":              true,
+	"ack-cluster-recoveries":          true,
+	"ack-instance-recoveries":         true,
+	"relax-lag-postponement":          true,
+	"end-lag-postponement-relaxation": true,
+	"begin-cluster-focus":             true,
+	"end-cluster-focus":               true,
+	"begin-write-freeze":              true,
+	"end-write-freeze":                true,
+	"reset-recovery-rate-limit":       true,
+	"enable-feature":                  true,
+	"disable-feature":                 true,
+	"reset-feature":                   true,
+}
+
 type CliCommand struct {
 	Command     string
 	Section     string
@@ -80,7 +161,6 @@ func registerCliCommand(command string, section string, description string) stri
 		command = synonym
 	}
 	knownCommands = append(knownCommands, CliCommand{Command: command, Section: section, Description: description})
-
 	return command
 }
 
@@ -115,6 +195,23 @@ func getClusterName(clusterAlias string, instanceKey *inst.InstanceKey) (cluster
 	return clusterName
 }
 
+// writeFeatureFlag enables or disables the --feature feature on given cluster, or on all clusters when neither
+// alias nor instance are given
+func writeFeatureFlag(clusterAlias string, instanceKey *inst.InstanceKey, reason string, enabled bool) *inst.FeatureFlag {
+	clusterName := ""
+	if clusterAlias != "" || instanceKey != nil {
+		clusterName = getClusterName(clusterAlias, instanceKey)
+	}
+	if reason == "" {
+		log.Fatal("--reason option required")
+	}
+	flag := inst.NewFeatureFlag(*config.RuntimeCLIFlags.Feature, clusterName, enabled, inst.GetMaintenanceOwner(), reason)
+	if err := inst.WriteFeatureFlag(flag); err != nil {
+		log.Fatale(err)
+	}
+	return flag
+}
+
 func assignThisInstanceKey() *inst.InstanceKey {
 	log.Debugf("Assuming instance is this machine, %+v", thisInstanceKey)
 	return thisInstanceKey
@@ -131,12 +228,66 @@ func validateInstanceIsFound(instanceKey *inst.InstanceKey) (instance *inst.Inst
 	return instance
 }
 
+// printRelocationPlan prints the plan of a relocation dry run
+func printRelocationPlan(plan *inst.RelocationPlan, err error) {
+	if err != nil {
+		log.Fatale(err)
+	}
+	fmt.Println(plan.String())
+	if plan.TopologyDiff != nil {
+		fmt.Println(plan.TopologyDiff.String())
+	}
+}
+
+// printCampaign prints a campaign's state and the outcome on each of its instances
+func printCampaign(campaign *inst.Campaign) {
+	fmt.Println(fmt.Sprintf("%s\t%s\t%s=%s\t%s\t%s", campaign.UID, campaign.Name, campaign.Variable, campaign.Value, campaign.State, campaign.Message))
+	for _, campaignInstance := range campaign.Instances {
+		canary := ""
+		if campaignInstance.IsCanary {
+			canary = "canary"
+		}
+		fmt.Println(fmt.Sprintf("%s\t%s\t%s\t%s\t%s", campaignInstance.Key.DisplayString(), canary, campaignInstance.State, campaignInstance.PreviousValue, campaignInstance.Error))
+	}
+}
+
+// cliTopologyFilter builds a topology filter out of given --filter and --tag
+func cliTopologyFilter() *inst.TopologyFilter {
+	return &inst.TopologyFilter{HostnamePattern: *config.RuntimeCLIFlags.Filter, Tags: *config.RuntimeCLIFlags.Tag}
+}
+
+// cliPollOverride builds a poll override for given --tag, or else for given instance
+func cliPollOverride(instanceKey *inst.InstanceKey, thisInstanceKey *inst.InstanceKey) (*inst.PollOverride, error) {
+	probeSet, err := inst.ParseProbeSet(*config.RuntimeCLIFlags.ProbeSet)
+	if err != nil {
+		return nil, err
+	}
+	if *config.RuntimeCLIFlags.Tag != "" {
+		tag, err := inst.ParseTag(*config.RuntimeCLIFlags.Tag)
+		if err != nil {
+			return nil, err
+		}
+		return inst.NewTagPollOverride(tag, *config.RuntimeCLIFlags.PollSeconds, probeSet), nil
+	}
+	instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+	if instanceKey == nil {
+		return nil, fmt.Errorf("Cannot deduce instance")
+	}
+	return inst.NewInstancePollOverride(instanceKey, *config.RuntimeCLIFlags.PollSeconds, probeSet), nil
+}
+
 // CliWrapper is called from main and allows for the instance parameter
 // to take multiple instance names separated by a comma or whitespace.
 func CliWrapper(command string, strict bool, instances string, destination string, owner string, reason string, duration string, pattern string, clusterAlias string, pool string, hostnameFlag string) {
 	if config.Config.RaftEnabled && !*config.RuntimeCLIFlags.IgnoreRaftSetup {
 		log.Fatalf(`Orchestrator configured to run raft ("RaftEnabled": true). All access must go through the web API of the active raft node. You may use the orchestrator-client script which has a similar interface to the command line invocation. You may override this with --ignore-raft-setup`)
 	}
+	if *config.RuntimeCLIFlags.Follow {
+		events.AddHandler(func(event *events.Event) error {
+			fmt.Fprintln(os.Stderr, event.String())
+			return nil
+		})
+	}
 	r := regexp.MustCompile(`[ ,\r\n\t]+`)
 	tokens := r.Split(instances, -1)
 	switch command {
@@ -203,6 +354,12 @@ func Cli(command string, strict bool, instance string, destination string, owner
 		owner = usr.Username
 	}
 	inst.SetMaintenanceOwner(owner)
+	if mutatingCliCommands[command] {
+		if err := inst.ValidateOperationAttribution(owner, reason); err != nil {
+			log.Fatalf("%s: %+v (use -reason)", command, err)
+		}
+	}
+	ctx := inst.WithOperationAttribution(context.Background(), owner, reason)
 
 	if !skipDatabaseCommands && !*config.RuntimeCLIFlags.SkipContinuousRegistration {
 		process.ContinuousRegistration(string(process.OrchestratorExecutionCliMode), command)
@@ -218,7 +375,11 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			_, err := inst.RelocateBelow(instanceKey, destinationKey)
+			if *config.RuntimeCLIFlags.DryRun {
+				printRelocationPlan(inst.PlanRelocateBelow(instanceKey, destinationKey, *config.RuntimeCLIFlags.AllowWANRelocation))
+				break
+			}
+			_, err := inst.RelocateBelowContext(ctx, instanceKey, destinationKey, *config.RuntimeCLIFlags.AllowWANRelocation)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -230,7 +391,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			replicas, _, err, errs := inst.RelocateReplicas(instanceKey, destinationKey, pattern)
+			replicas, _, err, errs := inst.RelocateReplicasContext(ctx, instanceKey, destinationKey, pattern)
 			if err != nil {
 				log.Fatale(err)
 			} else {
@@ -262,7 +423,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			validateInstanceIsFound(instanceKey)
 
-			lostReplicas, equalReplicas, aheadReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicas(instanceKey, false, func(candidateReplica *inst.Instance) { fmt.Println(candidateReplica.Key.DisplayString()) }, postponedFunctionsContainer)
+			lostReplicas, equalReplicas, aheadReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasContext(ctx, instanceKey, false, func(candidateReplica *inst.Instance) { fmt.Println(candidateReplica.Key.DisplayString()) }, postponedFunctionsContainer)
 			lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
 
 			postponedFunctionsContainer.Wait()
@@ -275,12 +436,37 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				log.Fatale(err)
 			}
 		}
+	case registerCliCommand("topology-apply", "Smart relocation", `Relocate replicas to converge onto a desired topology, read from standard input as JSON or YAML mapping of instance to intended master`):
+		{
+			desired, err := inst.ReadDesiredTopology(os.Stdin)
+			if err != nil {
+				log.Fatale(err)
+			}
+			plan, err := inst.PlanTopology(desired)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if *config.RuntimeCLIFlags.DryRun {
+				fmt.Println(plan.String())
+				fmt.Println(plan.TopologyDiff.String())
+				break
+			}
+			plan, err = inst.ApplyTopologyPlan(plan, *config.RuntimeCLIFlags.AllowWANRelocation)
+			fmt.Println(plan.String())
+			if err != nil {
+				log.Fatale(err)
+			}
+		}
 		// General replication commands
 		// move, binlog file:pos
-	case registerCliCommand("move-up", "Classic file:pos relocation", `Move a replica one level up the topology`):
+	case registerCliCommand("move-up", "Classic file:pos relocation", `Move a replica one level up the topology; uses Oracle GTID where possible, and otherwise stops replication on its master`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			instance, err := inst.MoveUp(instanceKey)
+			if *config.RuntimeCLIFlags.DryRun {
+				printRelocationPlan(inst.PlanMoveUp(instanceKey))
+				break
+			}
+			instance, err := inst.MoveUpContext(ctx, instanceKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -293,7 +479,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				log.Fatal("Cannot deduce instance:", instance)
 			}
 
-			movedReplicas, _, err, errs := inst.MoveUpReplicas(instanceKey, pattern)
+			movedReplicas, _, err, errs := inst.MoveUpReplicasContext(ctx, instanceKey, pattern)
 			if err != nil {
 				log.Fatale(err)
 			} else {
@@ -311,7 +497,11 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination/sibling:", destination)
 			}
-			_, err := inst.MoveBelow(instanceKey, destinationKey)
+			if *config.RuntimeCLIFlags.DryRun {
+				printRelocationPlan(inst.PlanMoveBelow(instanceKey, destinationKey))
+				break
+			}
+			_, err := inst.MoveBelowContext(ctx, instanceKey, destinationKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -323,6 +513,10 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
+			if *config.RuntimeCLIFlags.DryRun {
+				printRelocationPlan(inst.PlanMoveEquivalent(instanceKey, destinationKey))
+				break
+			}
 			_, err := inst.MoveEquivalent(instanceKey, destinationKey)
 			if err != nil {
 				log.Fatale(err)
@@ -333,7 +527,20 @@ func Cli(command string, strict bool, instance string, destination string, owner
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
 			// destinationKey can be null, in which case the instance repoints to its existing master
-			instance, err := inst.Repoint(instanceKey, destinationKey, inst.GTIDHintNeutral)
+			if *config.RuntimeCLIFlags.DryRun {
+				printRelocationPlan(inst.PlanRepoint(instanceKey, destinationKey, inst.GTIDHintNeutral))
+				break
+			}
+			instance, err := inst.RepointContext(ctx, instanceKey, destinationKey, inst.GTIDHintNeutral)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(fmt.Sprintf("%s<%s", instanceKey.DisplayString(), instance.MasterKey.DisplayString()))
+		}
+	case registerCliCommand("undo-last-relocation", "Classic file:pos relocation", `Move the given instance back below the master it replicated from before its most recent repoint or GTID move, if within RelocationUndoWindowSeconds and the instance has not moved since`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			instance, _, err := inst.UndoLastRelocationContext(ctx, instanceKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -360,7 +567,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if instanceKey == nil {
 				log.Fatal("Cannot deduce instance:", instance)
 			}
-			_, err := inst.TakeMaster(instanceKey, false)
+			_, err := inst.TakeMasterContext(ctx, instanceKey, false)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -369,7 +576,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("make-co-master", "Classic file:pos relocation", `Create a master-master replication. Given instance is a replica which replicates directly from a master.`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			_, err := inst.MakeCoMaster(instanceKey)
+			_, err := inst.MakeCoMasterContext(ctx, instanceKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -397,7 +604,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			validateInstanceIsFound(instanceKey)
 
-			_, promotedBinlogServer, err := inst.RegroupReplicasBinlogServers(instanceKey, false)
+			_, promotedBinlogServer, err := inst.RegroupReplicasBinlogServersContext(ctx, instanceKey, false)
 			if promotedBinlogServer == nil {
 				log.Fatalf("Could not regroup binlog server replicas of %+v; error: %+v", *instanceKey, err)
 			}
@@ -406,6 +613,27 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				log.Fatale(err)
 			}
 		}
+	case registerCliCommand("retire-binlog-server-family", "Binlog server relocation", `Migrate all replicas of the binlog server family of a given instance (the master, or any of its binlog servers) onto direct replication from the master, furthest behind first, validating each hop. With -d, relocate them further below a designated intermediate master. Binlog servers are left with no replicas`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatal("Cannot deduce instance:", instance)
+			}
+			validateInstanceIsFound(instanceKey)
+
+			retirement, err := inst.RetireBinlogServerFamilyContext(ctx, instanceKey, destinationKey, *config.RuntimeCLIFlags.AllowWANRelocation)
+			if retirement != nil {
+				for _, key := range retirement.MovedReplicas {
+					fmt.Println(fmt.Sprintf("%s\tmoved", key.DisplayString()))
+				}
+				for _, key := range retirement.PendingReplicas {
+					fmt.Println(fmt.Sprintf("%s\tpending", key.DisplayString()))
+				}
+			}
+			if err != nil {
+				log.Fatale(err)
+			}
+		}
 	// move, GTID
 	case registerCliCommand("move-gtid", "GTID relocation", `Move a replica beneath another instance.`):
 		{
@@ -413,7 +641,11 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			_, err := inst.MoveBelowGTID(instanceKey, destinationKey)
+			if *config.RuntimeCLIFlags.DryRun {
+				printRelocationPlan(inst.PlanMoveBelowGTID(instanceKey, destinationKey))
+				break
+			}
+			_, err := inst.MoveBelowGTIDContext(ctx, instanceKey, destinationKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -445,7 +677,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			validateInstanceIsFound(instanceKey)
 
-			lostReplicas, movedReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasGTID(instanceKey, false, func(candidateReplica *inst.Instance) { fmt.Println(candidateReplica.Key.DisplayString()) }, postponedFunctionsContainer, nil)
+			lostReplicas, movedReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasGTIDContext(ctx, instanceKey, false, func(candidateReplica *inst.Instance) { fmt.Println(candidateReplica.Key.DisplayString()) }, postponedFunctionsContainer, nil)
 			lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
 
 			if promotedReplica == nil {
@@ -464,7 +696,11 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			_, _, err := inst.MatchBelow(instanceKey, destinationKey, true)
+			if *config.RuntimeCLIFlags.DryRun {
+				printRelocationPlan(inst.PlanMatchBelow(instanceKey, destinationKey, true))
+				break
+			}
+			_, _, err := inst.MatchBelowContext(ctx, instanceKey, destinationKey, true)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -539,7 +775,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			validateInstanceIsFound(instanceKey)
 
 			onCandidateReplicaChosen := func(candidateReplica *inst.Instance) { fmt.Println(candidateReplica.Key.DisplayString()) }
-			lostReplicas, equalReplicas, aheadReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasPseudoGTID(instanceKey, false, onCandidateReplicaChosen, postponedFunctionsContainer, nil)
+			lostReplicas, equalReplicas, aheadReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasPseudoGTIDContext(ctx, instanceKey, false, onCandidateReplicaChosen, postponedFunctionsContainer, nil)
 			lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
 			postponedFunctionsContainer.Wait()
 			if promotedReplica == nil {
@@ -570,6 +806,66 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("enable-cluster-replication-ssl", "Replication, general", `Enable MASTER_SSL on all replicas of a cluster, in stages of ReplicationSSLRolloutConcurrency replicas; then list replicas still replicating in plaintext`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			statuses, err := inst.EnableClusterReplicationSSL(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			plaintext := 0
+			for _, status := range statuses {
+				if status.IsPlaintext() {
+					plaintext++
+					fmt.Println(fmt.Sprintf("%s\t%s", status.Key.DisplayString(), status.Error))
+				}
+			}
+			if plaintext > 0 {
+				log.Fatalf("%d replicas of %s not verified as replicating via SSL", plaintext, clusterName)
+			}
+		}
+	case registerCliCommand("set-replica-concurrency", "Replication, general", `Set the number of replicas of a cluster (--concurrency) concurrently moved by mass replica operations, overriding configuration`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if clusterName == "" {
+				log.Fatalf("Unable to determine cluster name")
+			}
+			override := inst.NewReplicaConcurrencyOverride(clusterName, *config.RuntimeCLIFlags.Concurrency)
+			if err := inst.WriteReplicaConcurrencyOverride(override); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(override.String())
+		}
+	case registerCliCommand("reset-replica-concurrency", "Replication, general", `Remove the replica concurrency override of a cluster, reverting to configuration`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if clusterName == "" {
+				log.Fatalf("Unable to determine cluster name")
+			}
+			if err := inst.DeleteReplicaConcurrencyOverride(clusterName); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(clusterName)
+		}
+	case registerCliCommand("replica-concurrency", "Replication, general", `Show the number of replicas of a cluster concurrently moved by mass replica operations`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if clusterName == "" {
+				log.Fatalf("Unable to determine cluster name")
+			}
+			fmt.Println(inst.MaxConcurrentReplicaOperations(clusterName))
+		}
+	case registerCliCommand("verify-cluster-replication-ssl", "Replication, general", `List replicas of a cluster, and whether each replicates via an encrypted connection`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			statuses, err := inst.VerifyClusterReplicationSSL(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, status := range statuses {
+				fmt.Println(fmt.Sprintf("%s\tencrypted=%t\t%s", status.Key.DisplayString(), status.Verified && status.Encrypted, status.Error))
+			}
+		}
 	case registerCliCommand("which-gtid-errant", "Replication, general", `Get errant GTID set (empty results if no errant GTID)`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -586,12 +882,33 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("gtid-errant-reset-master", "Replication, general", `Reset master on instance, remove GTID errant transactions`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			_, err := inst.ErrantGTIDResetMaster(instanceKey)
+			_, err := inst.ErrantGTIDResetMasterContext(ctx, instanceKey)
 			if err != nil {
 				log.Fatale(err)
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("gtid-rollback-plan", "Replication, general", `Plan the rollback of transactions a replica executed and its cluster's master did not: divergent GTID set, binary logs, method and steps`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			plan, err := inst.GetGTIDRollbackPlan(instanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(plan.String())
+		}
+	case registerCliCommand("gtid-rollback", "Replication, general", `Execute a single --step of a replica's GTID rollback plan. Destructive steps require --confirm with the step's token, as listed by gtid-rollback-plan`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if *config.RuntimeCLIFlags.Step == "" {
+				log.Fatal("--step expected")
+			}
+			plan, err := inst.ExecuteGTIDRollbackStepContext(ctx, instanceKey, *config.RuntimeCLIFlags.Step, *config.RuntimeCLIFlags.Confirm)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(plan.String())
+		}
 	case registerCliCommand("skip-query", "Replication, general", `Skip a single statement on a replica; either when running with GTID or without`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -631,7 +948,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("reset-slave", "Replication, general", `Issues a RESET SLAVE command; use with care`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			_, err := inst.ResetSlaveOperation(instanceKey)
+			_, err := inst.ResetSlaveOperationContext(ctx, instanceKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -643,7 +960,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if instanceKey == nil {
 				log.Fatal("Cannot deduce instance:", instance)
 			}
-			_, err := inst.DetachReplicaMasterHost(instanceKey)
+			_, err := inst.DetachReplicaMasterHostContext(ctx, instanceKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -655,7 +972,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if instanceKey == nil {
 				log.Fatal("Cannot deduce instance:", instance)
 			}
-			_, err := inst.ReattachReplicaMasterHost(instanceKey)
+			_, err := inst.ReattachReplicaMasterHostContext(ctx, instanceKey)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -685,6 +1002,33 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("set-master-delay", "Replication, general", `Set MASTER_DELAY on a replica, making it an intentionally delayed replica (--delay-seconds)`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatalf("Unresolved instance")
+			}
+			if *config.RuntimeCLIFlags.DelaySeconds == 0 {
+				log.Fatal("--delay-seconds must be provided; use clear-master-delay to remove the delay")
+			}
+			_, err := inst.SetMasterDelay(instanceKey, *config.RuntimeCLIFlags.DelaySeconds)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("clear-master-delay", "Replication, general", `Remove MASTER_DELAY from a replica`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatalf("Unresolved instance")
+			}
+			_, err := inst.ClearMasterDelay(instanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
 	case registerCliCommand("enable-semi-sync-master", "Replication, general", `Enable semi-sync replication (master-side)`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -721,6 +1065,18 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("enforce-semi-sync", "Replication, general", `Enforce SemiSyncReplicasPerMaster semi-sync replicas on given master, and set its semi-sync wait count to match`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if config.Config.SemiSyncReplicasPerMaster == 0 {
+				log.Fatalf("SemiSyncReplicasPerMaster is 0")
+			}
+			instance, err := inst.EnforceMasterSemiSync(instanceKey, config.Config.SemiSyncReplicasPerMaster)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(fmt.Sprintf("%s %d", instanceKey.DisplayString(), instance.SemiSyncMasterWaitForReplicaCount))
+		}
 	case registerCliCommand("restart-slave-statements", "Replication, general", `Get a list of statements to execute to stop then restore replica to same execution state. Provide --statement for injected statement`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -752,6 +1108,30 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				fmt.Println(destinationKey.DisplayString())
 			}
 		}
+	case registerCliCommand("replication-compatibility", "Replication information", `List all checks of whether an instance (-i) can replicate from another (-d) right now, and whether each has passed`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatalf("Unresolved instance")
+			}
+			if destinationKey == nil {
+				log.Fatal("Cannot deduce target instance:", destination)
+			}
+			compatibility, err := inst.CheckReplicationCompatibility(instanceKey, destinationKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, check := range append(compatibility.Checks, compatibility.GTIDChecks...) {
+				status := "passed"
+				if !check.Passed {
+					status = "failed"
+				}
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s", check.Name, status, check.Message))
+			}
+			if !compatibility.CanReplicate {
+				log.Fatalf("%+v cannot replicate from %+v", *instanceKey, *destinationKey)
+			}
+		}
 	case registerCliCommand("is-replicating", "Replication information", `Is an instance (-i) actively replicating right now`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -822,6 +1202,40 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("binlog-purge-advice", "Binary logs", `Advise how far to purge binary logs on a master, given BinlogVolumeCapacityMB and its replicas' progress`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatalf("Unresolved instance")
+			}
+			advice, err := inst.ReadBinlogPurgeAdvice(instanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(advice.PurgeToLogFile)
+		}
+	case registerCliCommand("gtid-purge-forecast", "Binary logs", `Forecast, per replica of a GTID master, when its master's purging of binary logs leaves it unable to reconnect, as per GTIDPurgeWarningHours; of all clusters, or of a given cluster (via -i or -alias)`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			forecasts, err := inst.ReadGTIDPurgeForecasts(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, forecast := range forecasts {
+				for _, replica := range forecast.Replicas {
+					untilPurged := "-"
+					if replica.AlreadyPurged {
+						untilPurged = "0"
+					} else if replica.ForecastKnown {
+						untilPurged = fmt.Sprintf("%d", replica.SecondsUntilPurged)
+					}
+					fmt.Println(fmt.Sprintf("%s\t%s\t%d\t%s\t%s", forecast.MasterKey.DisplayString(), replica.Key.DisplayString(), replica.TransactionsAheadOfPurge, untilPurged, replica.Warning))
+				}
+			}
+		}
 	case registerCliCommand("last-pseudo-gtid", "Binary logs", `Find latest Pseudo-GTID entry in instance's binary logs`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
@@ -1058,6 +1472,45 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				fmt.Println(fmt.Sprintf("%s\t%s", cluster.ClusterName, cluster.ClusterAlias))
 			}
 		}
+	case registerCliCommand("reconcile-inventory", "Information", `Reconcile known instances against the external inventory (InventoryURL), listing per cluster instances never discovered and instances the inventory says are decommissioned`):
+		{
+			reconciliation, err := inst.ReconcileInventory()
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, cluster := range reconciliation.Clusters {
+				for _, key := range cluster.Undiscovered {
+					fmt.Println(fmt.Sprintf("%s\tundiscovered\t%s", cluster.Cluster, key.DisplayString()))
+				}
+				for _, key := range cluster.Decommissioned {
+					fmt.Println(fmt.Sprintf("%s\tdecommissioned\t%s", cluster.Cluster, key.DisplayString()))
+				}
+			}
+		}
+	case registerCliCommand("replication-security-report", "Information", `Inventory per replica the replication user, whether the channel is encrypted and verifies its master's certificate, whether the user requires SSL, and the master's certificate expiry; of all clusters, or of a given cluster (via -i or -alias)`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			report, err := inst.ReportReplicationSecurity(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, cluster := range report.Clusters {
+				for _, channel := range cluster.ReplicationChannels {
+					findings := strings.Join(channel.Findings, "; ")
+					if channel.Error != "" {
+						findings = channel.Error
+					}
+					expiry := "-"
+					if channel.MasterCertificateExpiryKnown {
+						expiry = channel.MasterCertificateExpiry.Format(time.RFC3339)
+					}
+					fmt.Println(fmt.Sprintf("%s\t%s\t%s\t%s\tssl=%t\tverify=%t\t%s\t%s", cluster.ClusterAlias, channel.Key.DisplayString(), channel.MasterKey.DisplayString(), channel.ReplicationUser, channel.SSLAllowed, channel.SSLVerifyServerCert, expiry, findings))
+				}
+			}
+		}
 	case registerCliCommand("all-clusters-masters", "Information", `List of writeable masters, one per cluster`):
 		{
 			instances, err := inst.ReadWriteableClustersMasters()
@@ -1069,10 +1522,10 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				}
 			}
 		}
-	case registerCliCommand("topology", "Information", `Show an ascii-graph of a replication topology, given a member of that topology`):
+	case registerCliCommand("topology", "Information", `Show an ascii-graph of a replication topology, given a member of that topology. Use --filter (hostname regular expression) and/or --tag to only show matching instances, their replicas and masters. Use --color to highlight broken (red), lagging (yellow) and downtimed (dim) instances`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
-			output, err := inst.ASCIITopology(clusterName, pattern, false)
+			output, err := inst.ASCIITopology(clusterName, pattern, false, *config.RuntimeCLIFlags.Color, cliTopologyFilter())
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -1081,12 +1534,73 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("topology-tabulated", "Information", `Show an ascii-graph of a replication topology, given a member of that topology`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
-			output, err := inst.ASCIITopology(clusterName, pattern, true)
+			output, err := inst.ASCIITopology(clusterName, pattern, true, *config.RuntimeCLIFlags.Color, cliTopologyFilter())
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(output)
+		}
+	case registerCliCommand("topology-json", "Information", `Show a replication topology as a JSON tree, given a member of that topology`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			roots, err := inst.TopologyJSON(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			output, err := json.MarshalIndent(roots, "", "  ")
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(string(output))
+		}
+	case registerCliCommand("topology-mermaid", "Information", `Show a replication topology as a mermaid flowchart, given a member of that topology`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			output, err := inst.TopologyMermaid(clusterName)
 			if err != nil {
 				log.Fatale(err)
 			}
 			fmt.Println(output)
 		}
+	case registerCliCommand("topology-diff", "Information", `Compare the topology of a cluster at --since with --until (default: now), listing master change, moved, lost and new instances. Uses topology snapshots`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			since, err := inst.ParseTopologyHistoryTime(*config.RuntimeCLIFlags.Since)
+			if err != nil {
+				log.Fatale(err)
+			}
+			until, err := inst.ParseTopologyHistoryTime(*config.RuntimeCLIFlags.Until)
+			if err != nil {
+				log.Fatale(err)
+			}
+			diff, err := inst.TopologyHistoryDiffOf(clusterName, since, until)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(diff.String())
+		}
+	case registerCliCommand("export-cluster-snapshot", "Information", `Export the backend data of a cluster (instances, tags, downtime, promotion rules, attributes, audit and recoveries) as JSON, for loading into another orchestrator via load-cluster-snapshot`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			snapshot, err := logic.ExportClusterSnapshot(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := json.NewEncoder(os.Stdout).Encode(snapshot); err != nil {
+				log.Fatale(err)
+			}
+		}
+	case registerCliCommand("load-cluster-snapshot", "Information", `Load a cluster snapshot, read from standard input as exported by export-cluster-snapshot. Requires AnalysisMode`):
+		{
+			snapshot, err := logic.ReadClusterSnapshot(os.Stdin)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := logic.LoadClusterSnapshot(snapshot); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(snapshot.ClusterName)
+		}
 	case registerCliCommand("all-instances", "Information", `The complete list of known instances`):
 		{
 			instances, err := inst.SearchInstances("")
@@ -1220,6 +1734,35 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				fmt.Println(replica.Key.DisplayString())
 			}
 		}
+	case registerCliCommand("master-history", "Information", `Show the recorded master changes of a given instance, oldest first: time, previous and new master, method, cause, owner and correlation ID`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatalf("Unable to get master history: unresolved instance")
+			}
+			history, err := inst.ReadMasterHistory(instanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, change := range history {
+				fmt.Println(change.String())
+			}
+		}
+	case registerCliCommand("last-relocation", "Information", `Show the most recent repoint or GTID move of a given instance, if it may still be undone via undo-last-relocation`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatalf("Unresolved instance")
+			}
+			relocation, found, err := inst.ReadLastRelocation(instanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if !found {
+				log.Fatalf("No relocation of %+v to undo", *instanceKey)
+			}
+			fmt.Println(relocation.String())
+		}
 	case registerCliCommand("which-lost-in-recovery", "Information", `List instances marked as downtimed for being lost in a recovery process`):
 		{
 			instances, err := inst.ReadLostInRecoveryInstances("")
@@ -1239,6 +1782,29 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			instance := validateInstanceIsFound(instanceKey)
 			fmt.Println(instance.HumanReadableDescription())
 		}
+	case registerCliCommand("binlog-server-conformance", "Information", `Probe a binlog server (-i) for the expectations orchestrator has of binlog servers, listing each check and whether it has passed`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatalf("Unresolved instance")
+			}
+			checks, err := inst.ProbeBinlogServerConformance(instanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			conforms := true
+			for _, check := range checks {
+				status := "passed"
+				if !check.Passed {
+					status = "failed"
+					conforms = false
+				}
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s", check.Name, status, check.Message))
+			}
+			if !conforms {
+				log.Fatalf("%+v does not conform to binlog server expectations", *instanceKey)
+			}
+		}
 	case registerCliCommand("get-cluster-heuristic-lag", "Information", `For a given cluster (indicated by an instance or alias), output a heuristic "representative" lag of that cluster`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
@@ -1345,6 +1911,39 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 		}
 
+	case registerCliCommand("set-poll-override", "tags", `Override polling interval (--poll-seconds) and probe set (--probe-set) of an instance, or of instances having given --tag`):
+		{
+			override, err := cliPollOverride(instanceKey, thisInstanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := inst.WritePollOverride(override); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(override.String())
+		}
+	case registerCliCommand("remove-poll-override", "tags", `Remove poll override of an instance, or of given --tag`):
+		{
+			override, err := cliPollOverride(instanceKey, thisInstanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := inst.DeletePollOverride(override); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(override.String())
+		}
+	case registerCliCommand("poll-overrides", "tags", `List poll overrides`):
+		{
+			overrides, err := inst.ReadPollOverrides()
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, override := range overrides {
+				fmt.Println(override.String())
+			}
+		}
+
 		// Instance management
 	case registerCliCommand("discover", "Instance management", `Lookup an instance, investigate it`):
 		{
@@ -1452,6 +2051,51 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+		// Campaigns
+	case registerCliCommand("start-campaign", "Campaigns", `Run a configuration campaign, read from standard input as JSON, setting a global variable across targeted instances; then print the outcome per instance`):
+		{
+			campaign, err := inst.ReadCampaignDefinition(os.Stdin)
+			if err != nil {
+				log.Fatale(err)
+			}
+			campaign.Owner = inst.GetMaintenanceOwner()
+			campaign, err = inst.StartCampaign(campaign)
+			printCampaign(campaign)
+			if err != nil {
+				log.Fatale(err)
+			}
+		}
+	case registerCliCommand("halt-campaign", "Campaigns", `Request a running campaign (--campaign) to halt before its next batch`):
+		{
+			if *config.RuntimeCLIFlags.Campaign == "" {
+				log.Fatal("--campaign must be provided")
+			}
+			if err := inst.HaltCampaign(*config.RuntimeCLIFlags.Campaign); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(*config.RuntimeCLIFlags.Campaign)
+		}
+	case registerCliCommand("campaigns", "Campaigns", `List configuration campaigns, most recent first, with their progress`):
+		{
+			campaigns, err := inst.ReadCampaigns()
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, campaign := range campaigns {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s=%s\t%s\t%s\t%+v", campaign.UID, campaign.Name, campaign.Variable, campaign.Value, campaign.State, campaign.StartedTimestamp, campaign.Progress))
+			}
+		}
+	case registerCliCommand("campaign", "Campaigns", `Show a configuration campaign (--campaign) and its outcome per instance`):
+		{
+			if *config.RuntimeCLIFlags.Campaign == "" {
+				log.Fatal("--campaign must be provided")
+			}
+			campaign, err := inst.ReadCampaign(*config.RuntimeCLIFlags.Campaign)
+			if err != nil {
+				log.Fatale(err)
+			}
+			printCampaign(campaign)
+		}
 		// Recovery & analysis
 	case registerCliCommand("recover", "Recovery", `Do auto-recovery given a dead instance`), registerCliCommand("recover-lite", "Recovery", `Do auto-recovery given a dead instance. Orchestrator chooses the best course of actionwithout executing external processes`):
 		{
@@ -1493,13 +2137,48 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(topologyRecovery.SuccessorKey.DisplayString())
 		}
+	case registerCliCommand("force-master-failover-to", "Recovery", `Forcibly discard master and promote another (direct child) instance instead, having verified by GTID sets that it is not missing transactions its siblings executed`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if destinationKey == nil {
+				log.Fatal("Cannot deduce destination, the instance to promote in place of the master. Please provide with -d")
+			}
+			topologyRecovery, _, err := logic.ForceMasterFailoverTo(clusterName, destinationKey, *config.RuntimeCLIFlags.IgnoreUnreachableReplicas)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(topologyRecovery.SuccessorKey.DisplayString())
+		}
+	case registerCliCommand("what-if-master-fails", "Recovery", `Show the replica which would be promoted were the master to fail now, what would become of its siblings, and why. Changes nothing`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			preview, err := inst.PreviewClusterCandidateReplica(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(preview.String())
+		}
 	case registerCliCommand("graceful-master-takeover", "Recovery", `Gracefully promote a new master. Either indicate identity of new master via '-d designated.instance.com' or setup replication tree to have a single direct replica to the master.`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
 			if destinationKey != nil {
 				validateInstanceIsFound(destinationKey)
 			}
-			topologyRecovery, promotedMasterCoordinates, err := logic.GracefulMasterTakeover(clusterName, destinationKey)
+			topologyRecovery, promotedMasterCoordinates, err := logic.GracefulMasterTakeover(clusterName, destinationKey, false)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(topologyRecovery.SuccessorKey.DisplayString())
+			fmt.Println(*promotedMasterCoordinates)
+			log.Debugf("Promoted %+v as new master. Binlog coordinates at time of promotion: %+v", topologyRecovery.SuccessorKey, *promotedMasterCoordinates)
+		}
+	case registerCliCommand("graceful-master-takeover-auto", "Recovery", `Gracefully promote a new master, and demote the old master as its replica: super_read_only, replicating, and with semi-sync roles swapped. Either indicate identity of new master via '-d designated.instance.com' or setup replication tree to have a single direct replica to the master.`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if destinationKey != nil {
+				validateInstanceIsFound(destinationKey)
+			}
+			topologyRecovery, promotedMasterCoordinates, err := logic.GracefulMasterTakeover(clusterName, destinationKey, true)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -1507,6 +2186,17 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			fmt.Println(*promotedMasterCoordinates)
 			log.Debugf("Promoted %+v as new master. Binlog coordinates at time of promotion: %+v", topologyRecovery.SuccessorKey, *promotedMasterCoordinates)
 		}
+	case registerCliCommand("recover-undo", "Recovery", `Revert a master recovery (--recovery) which turned out to be unnecessary: given the failed master is back, demote the promoted replica below it and restore the pre-recovery topology. Requires GTID`):
+		{
+			if *config.RuntimeCLIFlags.Recovery == "" {
+				log.Fatal("--recovery must be provided")
+			}
+			failedMaster, err := logic.UndoRecovery(*config.RuntimeCLIFlags.Recovery)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(failedMaster.Key.DisplayString())
+		}
 	case registerCliCommand("replication-analysis", "Recovery", `Request an analysis of potential crash incidents in all known topologies`):
 		{
 			analysis, err := inst.GetReplicationAnalysis("", &inst.ReplicationAnalysisHints{})
@@ -1553,15 +2243,205 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(fmt.Sprintf("%d recoveries acknowldged", countRecoveries))
 		}
+	case registerCliCommand("relax-lag-postponement", "Recovery", `Have recoveries of the cluster relocate a critical replica right away, regardless of PostponeReplicaRecoveryOnLagMinutes, for --duration (default 1h)`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if reason == "" {
+				log.Fatal("--reason option required")
+			}
+			instance := validateInstanceIsFound(instanceKey)
+			var durationSeconds int = 0
+			if duration != "" {
+				durationSeconds, err = util.SimpleTimeToSeconds(duration)
+				if err != nil {
+					log.Fatale(err)
+				}
+				if durationSeconds < 0 {
+					log.Fatalf("Duration value must be non-negative. Given value: %d", durationSeconds)
+				}
+			}
+			relaxation := inst.NewPostponementRelaxation(instanceKey, instance.ClusterName, inst.GetMaintenanceOwner(), reason, time.Duration(durationSeconds)*time.Second)
+			if err := inst.WritePostponementRelaxation(relaxation); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("end-lag-postponement-relaxation", "Recovery", `Have recoveries postpone relocating a replica per PostponeReplicaRecoveryOnLagMinutes again`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if err := inst.DeletePostponementRelaxation(instanceKey); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("lag-postponement-relaxations", "Recovery", `List replicas which recoveries relocate right away regardless of lag, optionally only of given cluster`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			relaxations, err := inst.ReadPostponementRelaxations(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, relaxation := range relaxations {
+				fmt.Println(relaxation.String())
+			}
+		}
+	case registerCliCommand("begin-cluster-focus", "Recovery", `Put a cluster in focus mode for --duration (default FocusModeDefaultMinutes): poll its instances every FocusModePollSeconds, sample them, and publish its topology changes in finer detail`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if reason == "" {
+				log.Fatal("--reason option required")
+			}
+			var durationSeconds int = 0
+			if duration != "" {
+				durationSeconds, err = util.SimpleTimeToSeconds(duration)
+				if err != nil {
+					log.Fatale(err)
+				}
+				if durationSeconds < 0 {
+					log.Fatalf("Duration value must be non-negative. Given value: %d", durationSeconds)
+				}
+			}
+			focus := inst.NewClusterFocus(clusterName, inst.GetMaintenanceOwner(), reason, time.Duration(durationSeconds)*time.Second)
+			if err := inst.WriteClusterFocus(focus); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(clusterName)
+		}
+	case registerCliCommand("end-cluster-focus", "Recovery", `Have a cluster's instances polled by their normal interval and probe set again`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if err := inst.DeleteClusterFocus(clusterName); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(clusterName)
+		}
+	case registerCliCommand("cluster-focuses", "Recovery", `List clusters in focus mode, optionally only given cluster`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			focuses, err := inst.ReadClusterFocuses(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, focus := range focuses {
+				fmt.Println(focus.String())
+			}
+		}
+	case registerCliCommand("begin-write-freeze", "Recovery", `Freeze writes on a cluster for --duration (default WriteFreezeDefaultMinutes): set its writeable instances read_only, and refuse automated failovers until the freeze expires`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if reason == "" {
+				log.Fatal("--reason option required")
+			}
+			var durationSeconds int = 0
+			if duration != "" {
+				durationSeconds, err = util.SimpleTimeToSeconds(duration)
+				if err != nil {
+					log.Fatale(err)
+				}
+				if durationSeconds < 0 {
+					log.Fatalf("Duration value must be non-negative. Given value: %d", durationSeconds)
+				}
+			}
+			freeze, err := logic.FreezeClusterWrites(inst.NewClusterWriteFreeze(clusterName, inst.GetMaintenanceOwner(), reason, time.Duration(durationSeconds)*time.Second))
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(freeze.FrozenInstances.ToCommaDelimitedList())
+		}
+	case registerCliCommand("end-write-freeze", "Recovery", `End the write freeze of a cluster, allowing automated failovers again. Frozen instances remain read_only`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if err := logic.UnfreezeClusterWrites(clusterName); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(clusterName)
+		}
+	case registerCliCommand("write-freezes", "Recovery", `List clusters whose writes are frozen, optionally only given cluster`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			freezes, err := inst.ReadClusterWriteFreezes(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, freeze := range freezes {
+				fmt.Println(freeze.String())
+			}
+		}
+	case registerCliCommand("recovery-rate-limits", "Recovery", `List the automated recovery counters of clusters, with their RecoveryRateLimitPerHour and backoff, optionally only given cluster`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			rateLimits, err := inst.ReadRecoveryRateLimits(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, rateLimit := range rateLimits {
+				fmt.Println(rateLimit.String())
+			}
+		}
+	case registerCliCommand("reset-recovery-rate-limit", "Recovery", `Reset the automated recovery counters, and any backoff, of a cluster; or of all clusters when no cluster is given`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			if err := logic.ResetRecoveryRateLimit(clusterName); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(clusterName)
+		}
+	case registerCliCommand("enable-feature", "Recovery", `Enable a gated feature, given by --feature, on a cluster; or on all clusters when no cluster is given`):
+		{
+			fmt.Println(writeFeatureFlag(clusterAlias, instanceKey, reason, true).String())
+		}
+	case registerCliCommand("disable-feature", "Recovery", `Disable a gated feature, given by --feature, on a cluster; or on all clusters when no cluster is given`):
+		{
+			fmt.Println(writeFeatureFlag(clusterAlias, instanceKey, reason, false).String())
+		}
+	case registerCliCommand("reset-feature", "Recovery", `Remove the flag of a feature, given by --feature, on a cluster, which then follows the all-clusters flag; or remove the all-clusters flag when no cluster is given`):
+		{
+			clusterName := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterName = getClusterName(clusterAlias, instanceKey)
+			}
+			if *config.RuntimeCLIFlags.Feature == "" {
+				log.Fatal("--feature option required")
+			}
+			if err := inst.DeleteFeatureFlag(*config.RuntimeCLIFlags.Feature, clusterName); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(*config.RuntimeCLIFlags.Feature)
+		}
+	case registerCliCommand("feature-flags", "Recovery", `List feature flags, optionally only those of given --feature`):
+		{
+			flags, err := inst.ReadFeatureFlags(*config.RuntimeCLIFlags.Feature)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, flag := range flags {
+				fmt.Println(flag.String())
+			}
+		}
 	// Instance meta
 	case registerCliCommand("register-candidate", "Instance, meta", `Indicate that a specific instance is a preferred candidate for master promotion`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			promotionRule, err := inst.ParseCandidatePromotionRule(*config.RuntimeCLIFlags.PromotionRule)
+			promotionRule, promotionPriority, err := inst.ParseCandidatePromotionRuleAndPriority(*config.RuntimeCLIFlags.PromotionRule)
 			if err != nil {
 				log.Fatale(err)
 			}
-			err = inst.RegisterCandidateInstance(inst.NewCandidateDatabaseInstance(instanceKey, promotionRule).WithCurrentTime())
+			err = inst.RegisterCandidateInstance(inst.NewCandidateDatabaseInstance(instanceKey, promotionRule).WithPromotionPriority(promotionPriority).WithCurrentTime())
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -1595,7 +2475,66 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			fmt.Println(instanceKey.DisplayString())
 		}
 
+		// DR pairs
+	case registerCliCommand("register-dr-pair", "DR pairs", `Register given instance (-i) as the main of a warm standby cluster, aliased by -alias. Instance must replicate directly from its cluster's master`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			pair, err := logic.RegisterDRPair(clusterAlias, instanceKey)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(pair.StandbyMasterKey.DisplayString())
+		}
+	case registerCliCommand("split-dr-pair", "DR pairs", `Detach the warm standby cluster given by -alias from its primary, and make its main writable`):
+		{
+			instance, err := logic.SplitDRPair(clusterAlias)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instance.Key.DisplayString())
+		}
+	case registerCliCommand("remove-dr-pair", "DR pairs", `Forget the DR pair given by -alias. Replication is unaffected`):
+		{
+			if err := logic.RemoveDRPair(clusterAlias); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(clusterAlias)
+		}
+	case registerCliCommand("dr-pairs", "DR pairs", `List DR pairs: standby cluster alias, standby main, state`):
+		{
+			pairs, err := inst.ReadDRPairs()
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, pair := range pairs {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s", pair.StandbyClusterAlias, pair.StandbyMasterKey.DisplayString(), pair.State))
+			}
+		}
+
 		// meta
+	case registerCliCommand("export-cluster", "Meta", `Write a portable archive of a cluster's orchestrator state (instances, tags, candidates, downtime, alias, recovery history) to standard output`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			archive, err := logic.ExportClusterArchive(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := logic.WriteClusterArchive(archive, os.Stdout); err != nil {
+				log.Fatale(err)
+			}
+		}
+	case registerCliCommand("import-cluster", "Meta", `Import a cluster archive, as written by export-cluster, read from standard input`):
+		{
+			archive, err := logic.ReadClusterArchive(os.Stdin)
+			if err != nil {
+				log.Fatale(err)
+			}
+			summary, err := logic.ImportClusterArchive(archive)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(fmt.Sprintf("%s: imported %+v, skipped %+v", summary.ClusterName, summary.RowsImported, summary.RowsSkipped))
+		}
 	case registerCliCommand("snapshot-topologies", "Meta", `Take a snapshot of existing topologies.`):
 		{
 			err := inst.SnapshotTopologies()
@@ -34,3 +34,19 @@ func TestKnownCommands(t *testing.T) {
 		test.S(t).ExpectNotEquals(commandsMap[synonym], "")
 	}
 }
+
+func TestMutatingCommandsAreKnown(t *testing.T) {
+	Cli("help", false, "localhost:9999", "localhost:9999", "orc", "no-reason", "1m", ".", "no-alias", "no-pool", "")
+
+	commandsMap := make(map[string]string)
+	for _, command := range knownCommands {
+		commandsMap[command.Command] = command.Section
+	}
+	for command := range mutatingCliCommands {
+		test.S(t).ExpectNotEquals(commandsMap[command], "")
+	}
+	test.S(t).ExpectTrue(mutatingCliCommands["relocate"])
+	test.S(t).ExpectTrue(mutatingCliCommands["begin-downtime"])
+	test.S(t).ExpectFalse(mutatingCliCommands["topology"])
+	test.S(t).ExpectFalse(mutatingCliCommands["replication-analysis"])
+}
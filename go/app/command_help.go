@@ -748,6 +748,21 @@ func init() {
 			Indicate cluster by an instance. You don't structly need to specify the master, orchestrator
 			will infer the master's identify.
 	`
+	CommandHelp["force-master-failover-to"] = `
+	Forcibly discard master and promote another (direct child) instance instead, having first verified, by GTID sets,
+	that the instance to promote is not missing transactions executed by any of its siblings.
+	NOTE:
+	- You must specify the instance to promote via "-d"
+	- Promoted instance must be a direct child of the existing master, and all must use Oracle GTID
+	- Should verification fail, no failover takes place, and the siblings whose transactions may be missing are reported
+	- Unreachable siblings cannot be verified, and fail verification, unless --ignore-unreachable-replicas is given
+	- Verification is repeated upon promotion, once replication is stopped; should it fail then, the promotion is aborted
+	- Errant transactions of a sibling, not originating from the master, are not expected on the promoted instance
+	- Otherwise, this works as "force-master-takeover"
+	Examples:
+
+	orchestrator -c force-master-failover-to -alias mycluster -d immediate.child.of.master.com
+	`
 	CommandHelp["graceful-master-takeover"] = `
 	Gracefully discard master and promote another (direct child) instance instead, even if everything is running well.
 	This allows for planned switchover.
@@ -768,6 +783,19 @@ func init() {
 		Indicate cluster by an instance. You don't structly need to specify the master, orchestrator
 		will infer the master's identify.
 	`
+	CommandHelp["graceful-master-takeover-auto"] = `
+	Gracefully discard master and promote another (direct child) instance instead, as graceful-master-takeover does,
+	then demote the old master as a replica of the promoted master:
+	- The old master is set super_read_only
+	- Where the old master was semi-sync master, the promoted master becomes semi-sync master instead
+	- Where the promoted master was semi-sync replica, the old master becomes semi-sync replica instead
+	- Replication is started on the old master
+	The demotion is audited as a single graceful-master-takeover-demote operation.
+	Examples:
+
+	orchestrator -c graceful-master-takeover-auto -alias mycluster -d immediate.child.of.master.com
+		Indicate cluster by alias and designated master to promote
+	`
 	CommandHelp["replication-analysis"] = `
   Request an analysis of potential crash incidents in all known topologies.
   Output format is not yet stabilized and may change in the future. Do not trust the output
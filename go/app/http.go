@@ -106,20 +106,30 @@ func standardHttp(continuousDiscovery bool) {
 		}
 	}
 
+	m.Use(http.TraceRequest)
+	m.Use(http.ConsistentReads)
+	m.Use(http.UncompressedEventsStream)
 	m.Use(gzip.All())
+	m.Use(martini.Static("resources/public", martini.StaticOptions{Prefix: config.Config.URLPrefix}))
+	if config.Config.UseMutualTLS {
+		m.Use(ssl.VerifyOUs(config.Config.SSLValidOUs))
+	}
+	// Replays responses only to authenticated and verified clients, and precedes the renderer, which writes
+	// to the response writer it recorded with
+	m.Use(http.IdempotentRequest)
 	// Render html templates from templates directory
 	m.Use(render.Renderer(render.Options{
 		Directory:       "resources",
 		Layout:          "templates/layout",
 		HTMLContentType: "text/html",
 	}))
-	m.Use(martini.Static("resources/public", martini.StaticOptions{Prefix: config.Config.URLPrefix}))
-	if config.Config.UseMutualTLS {
-		m.Use(ssl.VerifyOUs(config.Config.SSLValidOUs))
-	}
 
 	inst.SetMaintenanceOwner(process.ThisHostname)
 
+	if continuousDiscovery && config.Config.AnalysisMode {
+		log.Info("AnalysisMode: not starting discovery")
+		continuousDiscovery = false
+	}
 	if continuousDiscovery {
 		// start to expire metric collection info
 		discoveryMetrics = collection.CreateOrReturnCollection(discoveryMetricsName)
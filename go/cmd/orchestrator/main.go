@@ -56,26 +56,37 @@ func main() {
 	config.RuntimeCLIFlags.BinlogFile = flag.String("binlog", "", "Binary log file name")
 	config.RuntimeCLIFlags.Statement = flag.String("statement", "", "Statement/hint")
 	config.RuntimeCLIFlags.GrabElection = flag.Bool("grab-election", false, "Grab leadership (only applies to continuous mode)")
-	config.RuntimeCLIFlags.PromotionRule = flag.String("promotion-rule", "prefer", "Promotion rule for register-andidate (prefer|neutral|prefer_not|must_not)")
+	config.RuntimeCLIFlags.PromotionRule = flag.String("promotion-rule", "prefer", "Promotion rule for register-andidate (prefer|neutral|prefer_not|must_not), optionally followed by :<priority>, e.g. prefer:10. Among candidates of the same rule, higher priority is preferred")
 	config.RuntimeCLIFlags.Version = flag.Bool("version", false, "Print version and exit")
 	config.RuntimeCLIFlags.SkipContinuousRegistration = flag.Bool("skip-continuous-registration", false, "Skip cli commands performaing continuous registration (to reduce orchestratrator backend db load")
 	config.RuntimeCLIFlags.EnableDatabaseUpdate = flag.Bool("enable-database-update", false, "Enable database update, overrides SkipOrchestratorDatabaseUpdate")
 	config.RuntimeCLIFlags.IgnoreRaftSetup = flag.Bool("ignore-raft-setup", false, "Override RaftEnabled for CLI invocation (CLI by default not allowed for raft setups). NOTE: operations by CLI invocation may not reflect in all raft nodes.")
 	config.RuntimeCLIFlags.Tag = flag.String("tag", "", "tag to add ('tagname' or 'tagname=tagvalue') or to search ('tagname' or 'tagname=tagvalue' or comma separated 'tag0,tag1=val1,tag2' for intersection of all)")
+	config.RuntimeCLIFlags.AllowWANRelocation = flag.Bool("allow-wan", false, "Confirm a relocation which creates a new WAN-crossing replication edge (see RequireWANRelocationConfirmation)")
+	config.RuntimeCLIFlags.IgnoreVersionCompatibility = flag.Bool("ignore-version-compatibility", false, "For relocation commands: emergency override; move a replica onto a master of an unsupported version, e.g. from 8.0 to 5.7")
+	config.RuntimeCLIFlags.IgnoreUnreachableReplicas = flag.Bool("ignore-unreachable-replicas", false, "For force-master-failover-to: do not verify the transactions of unreachable replicas, rather than refuse to fail over")
+	config.RuntimeCLIFlags.Follow = flag.Bool("follow", false, "Print progress and outcome events (audit, recovery steps) to stderr as the command executes")
+	config.RuntimeCLIFlags.DryRun = flag.Bool("dry-run", false, "For relocation commands (relocate, move-up, move-below, move-equivalent, repoint, move-gtid, match): print the plan without executing it")
+	config.RuntimeCLIFlags.PollSeconds = flag.Uint("poll-seconds", 0, "For set-poll-override: polling interval of the instance or tag; 0 keeps InstancePollSeconds")
+	config.RuntimeCLIFlags.ProbeSet = flag.String("probe-set", "", "For set-poll-override: probe query set, 'full' (default) or 'light'")
+	config.RuntimeCLIFlags.Concurrency = flag.Uint("concurrency", 0, "For set-replica-concurrency: number of replicas of the cluster concurrently moved by mass replica operations")
+	config.RuntimeCLIFlags.DelaySeconds = flag.Uint("delay-seconds", 0, "For set-master-delay: MASTER_DELAY, in seconds, of the replica")
+	config.RuntimeCLIFlags.Campaign = flag.String("campaign", "", "For campaign, halt-campaign: campaign UID")
+	config.RuntimeCLIFlags.Filter = flag.String("filter", "", "For topology, topology-tabulated: regular expression; only show instances whose hostname matches, along with their replicas and masters")
+	config.RuntimeCLIFlags.Since = flag.String("since", "", "For topology-diff: point in time to compare from; unix timestamp, 'YYYY-MM-DD hh:mm:ss' or 'YYYY-MM-DD'")
+	config.RuntimeCLIFlags.Until = flag.String("until", "", "For topology-diff: point in time to compare to; unix timestamp, 'YYYY-MM-DD hh:mm:ss' or 'YYYY-MM-DD'. Default: now")
+	config.RuntimeCLIFlags.Step = flag.String("step", "", "For gtid-rollback: name of the plan step to execute")
+	config.RuntimeCLIFlags.Confirm = flag.String("confirm", "", "For gtid-rollback: confirmation token of a destructive step, as listed by gtid-rollback-plan")
+	config.RuntimeCLIFlags.Color = flag.Bool("color", false, "For topology, topology-tabulated: colorize instances by health: red for broken replication, yellow for lag, dim for downtimed")
+	config.RuntimeCLIFlags.Recovery = flag.String("recovery", "", "For recover-undo: recovery UID")
+	config.RuntimeCLIFlags.Feature = flag.String("feature", "", "For enable-feature, disable-feature, reset-feature, feature-flags: feature flag name")
 	flag.Parse()
 
 	if *destination != "" && *sibling != "" {
 		log.Fatalf("-s and -d are synonyms, yet both were specified. You're probably doing the wrong thing.")
 	}
-	switch *config.RuntimeCLIFlags.PromotionRule {
-	case "prefer", "neutral", "prefer_not", "must_not":
-		{
-			// OK
-		}
-	default:
-		{
-			log.Fatalf("-promotion-rule only supports prefer|neutral|prefer_not|must_not")
-		}
+	if _, _, err := inst.ParseCandidatePromotionRuleAndPriority(*config.RuntimeCLIFlags.PromotionRule); err != nil {
+		log.Fatalf("-promotion-rule only supports prefer|neutral|prefer_not|must_not, optionally followed by :<priority>")
 	}
 	if *destination == "" {
 		*destination = *sibling
@@ -32,6 +32,24 @@ type CLIFlags struct {
 	EnableDatabaseUpdate       *bool
 	IgnoreRaftSetup            *bool
 	Tag                        *string
+	AllowWANRelocation         *bool
+	IgnoreVersionCompatibility *bool
+	IgnoreUnreachableReplicas  *bool
+	DryRun                     *bool
+	Follow                     *bool
+	PollSeconds                *uint
+	ProbeSet                   *string
+	Concurrency                *uint
+	DelaySeconds               *uint
+	Campaign                   *string
+	Filter                     *string
+	Since                      *string
+	Until                      *string
+	Step                       *string
+	Confirm                    *string
+	Color                      *bool
+	Recovery                   *string
+	Feature                    *string
 }
 
 var RuntimeCLIFlags CLIFlags
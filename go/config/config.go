@@ -95,6 +95,9 @@ type Configuration struct {
 	MySQLTopologyUser                          string
 	MySQLTopologyPassword                      string // my.cnf style configuration file from where to pick credentials. Expecting `user`, `password` under `[client]` section
 	MySQLTopologyCredentialsConfigFile         string
+	MySQLTopologySuperUser                     string // optional elevated (SUPER) user, only used for `RESET MASTER` and `SET GLOBAL gtid_purged`; never used for polling
+	MySQLTopologySuperPassword                 string
+	MySQLTopologySuperCredentialsConfigFile    string // my.cnf style configuration file from where to pick elevated credentials. Expecting `user`, `password` under `[client]` section
 	MySQLTopologySSLPrivateKeyFile             string // Private key file used to authenticate with a Topology mysql instance with TLS
 	MySQLTopologySSLCertFile                   string // Certificate PEM file used to authenticate with a Topology mysql instance with TLS
 	MySQLTopologySSLCAFile                     string // Certificate Authority PEM file used to authenticate with a Topology mysql instance with TLS
@@ -106,12 +109,15 @@ type Configuration struct {
 	SQLite3DataFile                            string // when BackendDB == "sqlite3", full path to sqlite3 datafile
 	SkipOrchestratorDatabaseUpdate             bool   // When true, do not check backend database schema nor attempt to update it. Useful when you may be running multiple versions of orchestrator, and you only wish certain boxes to dictate the db structure (or else any time a different orchestrator version runs it will rebuild database schema)
 	PanicIfDifferentDatabaseDeploy             bool   // When true, and this process finds the orchestrator backend DB was provisioned by a different version, panic
+	DenySchemaDeployOnVersionSkew              bool   // When true, this process refuses to update the backend database schema while active nodes sharing the backend run a different version
+	RestartForUpgradeCommand                   string // Command invoked by the restart-for-upgrade API, expected to replace the binary and restart this orchestrator service. Runs after this node gives up leadership
 	RaftEnabled                                bool   // When true, setup orchestrator in a raft consensus layout. When false (default) all Raft* variables are ignored
 	RaftBind                                   string
 	RaftAdvertise                              string
 	RaftDataDir                                string
 	DefaultRaftPort                            int      // if a RaftNodes entry does not specify port, use this one
 	RaftNodes                                  []string // Raft nodes to make initial connection with
+	RaftMaxClusterArchiveBytes                 int      // Maximum compressed size of a cluster archive imported via the API with raft, as it is written to the raft log. Larger archives are to be imported onto each raft member via the command line
 	ExpectFailureAnalysisConcensus             bool
 	MySQLOrchestratorHost                      string
 	MySQLOrchestratorMaxPoolConnections        int // The maximum size of the connection pool to the Orchestrator backend.
@@ -123,6 +129,9 @@ type Configuration struct {
 	MySQLOrchestratorSSLPrivateKeyFile         string   // Private key file used to authenticate with the Orchestrator mysql instance with TLS
 	MySQLOrchestratorSSLCertFile               string   // Certificate PEM file used to authenticate with the Orchestrator mysql instance with TLS
 	MySQLOrchestratorSSLCAFile                 string   // Certificate Authority PEM file used to authenticate with the Orchestrator mysql instance with TLS
+	BackendOverloadLatencyMilliseconds         uint     // When > 0, the backend is deemed overloaded when backend queries average more than this many milliseconds over BackendOverloadWindowSeconds. While overloaded, non-essential work is skipped and non-recovery operations are rejected
+	BackendOverloadErrorPercent                uint     // When > 0, the backend is deemed overloaded when more than this percent of backend queries fail over BackendOverloadWindowSeconds
+	BackendOverloadWindowSeconds               uint     // Window over which backend query latency and errors are evaluated
 	MySQLOrchestratorSSLSkipVerify             bool     // If true, do not strictly validate mutual TLS certs for the Orchestrator mysql instances
 	MySQLOrchestratorUseMutualTLS              bool     // Turn on TLS authentication with the Orchestrator MySQL instance
 	MySQLConnectTimeoutSeconds                 int      // Number of seconds before connection is aborted (driver-side)
@@ -134,6 +143,8 @@ type Configuration struct {
 	SlaveLagQuery                              string   // Synonym to ReplicationLagQuery
 	ReplicationLagQuery                        string   // custom query to check on replica lg (e.g. heartbeat table). Must return a single row with a single numeric column, which is the lag.
 	ReplicationCredentialsQuery                string   // custom query to get replication credentials. Must return a single row, with two text columns: 1st is username, 2nd is password. This is optional, and can be used by orchestrator to configure replication after master takeover or setup of co-masters. You need to ensure the orchestrator user has the privileges to run this query
+	ReplicationSSLRolloutConcurrency           uint     // Number of replicas concurrently reconfigured with MASTER_SSL=1 in each stage of enable-cluster-replication-ssl
+	MaxConcurrentReplicaOperations             uint     // Number of replicas concurrently moved by mass replica operations such as move-replicas-gtid, move-up-replicas, repoint-replicas and regroup-replicas
 	DiscoverByShowSlaveHosts                   bool     // Attempt SHOW SLAVE HOSTS before PROCESSLIST
 	UseSuperReadOnly                           bool     // Should orchestrator super_read_only any time it sets read_only
 	InstancePollSeconds                        uint     // Number of seconds between instance reads
@@ -142,12 +153,34 @@ type Configuration struct {
 	InstanceFlushIntervalMilliseconds          int      // Max interval between instance write buffer flushes
 	SkipMaxScaleCheck                          bool     // If you don't ever have MaxScale BinlogServer in your topology (and most people don't), set this to 'true' to save some pointless queries
 	UnseenInstanceForgetHours                  uint     // Number of hours after which an unseen instance is forgotten
+	ReverifyUnreachableInstancesSeconds        uint     // When > 0, instances unseen for this many seconds are no longer polled each InstancePollSeconds, but re-probed in the background once per this many seconds, and reinstated once reachable. Forgotten instances are re-probed likewise. Default: 0 (disabled)
+	ReverifyInstancesBatchSize                 uint     // Max number of instances re-probed per re-verification round (once per minute)
+	ReverifyForgottenInstancesHours            uint     // Number of hours during which forgotten (long unseen) instances keep being re-probed
 	SnapshotTopologiesIntervalHours            uint     // Interval in hour between snapshot-topologies invocation. Default: 0 (disabled)
+	InventoryURL                               string   // Optional URL of an external inventory (CMDB/asset API), responding with a JSON list of {"Hostname", "Port", "Cluster", "Decommissioned"} entries, against which known instances are reconciled
+	InventoryReconciliationIntervalMinutes     uint     // Interval in minutes between reconciliations of known instances against InventoryURL. Default: 0 (only on demand)
 	DiscoveryMaxConcurrency                    uint     // Number of goroutines doing hosts discovery
 	DiscoveryQueueCapacity                     uint     // Buffer size of the discovery queue. Should be greater than the number of DB instances being discovered
 	DiscoveryQueueMaxStatisticsSize            int      // The maximum number of individual secondly statistics taken of the discovery queue
 	DiscoveryCollectionRetentionSeconds        uint     // Number of seconds to retain the discovery collection information
+	RapidRediscoverySeeds                      []string // Instances (hostname:port) from which to crawl the fleet in rapid rediscovery mode upon startup, when the backend knows no instances (fresh install, or backend loss). Default: none
+	RapidRediscoveryConcurrency                uint     // Discovery goroutines added to DiscoveryMaxConcurrency while in rapid rediscovery mode
+	RapidRediscoveryMaxSeconds                 uint     // Rapid rediscovery mode ends once the fleet is crawled, or after this many seconds at most
+	FocusModePollSeconds                       uint     // Polling interval of instances of a cluster in focus mode. Focus mode also polls by the light probe set, records a sample of each instance per interval, and publishes topology changes at this interval
+	FocusModeDefaultMinutes                    uint     // Duration of focus mode when begun without an explicit duration
+	FocusModeOnRecoveryMinutes                 uint     // When > 0, a cluster enters focus mode for this many minutes upon a recovery beginning on it. Default: 10
+	FocusModeSampleRetentionHours              uint     // Number of hours to retain instance samples recorded in focus mode
+	WriteFreezeDefaultMinutes                  uint     // Duration of a cluster write freeze when begun without an explicit duration
 	InstanceBulkOperationsWaitTimeoutSeconds   uint     // Time to wait on a single instance when doing bulk (many instances) operation
+	TopologyOperationTimeoutSeconds            uint     // When > 0, topology operations (move, match, relocate, regroup) not completing within this many seconds are aborted and replication restarted. API requests may override with ?timeout=. Default: 0 (unbounded)
+	OperationIPChangePolicy                    string   // When set, the IPs an instance's hostname resolves to are pinned as a topology operation first operates on it, and re-resolved on each further step. Upon change: "abort" aborts the operation; "revalidate" continues if the same server (by server UUID, or else server ID) answers at the new IP, and aborts otherwise. Default: "" (not pinned)
+	ReplicaMoveRetries                         uint     // Number of times a single replica is retried by mass replica moves (move-up-replicas, move-replicas-gtid, regroup-replicas-gtid) when failing with a retryable error. Default: 0 (single attempt)
+	ReplicaMoveRetryBackoffMilliseconds        uint     // Wait time before first retry of a replica move; doubled on each subsequent retry
+	ReplicaMoveRetryableErrors                 []string // Regular expressions; a failed replica move is retried only when its error matches any. Defaults to transient connection errors
+	SerializeClusterOperationsFilters          []string // Clusters (same syntax as RecoverMasterClusterFilters) on which only one mutating topology operation, be it an API operation or a recovery, runs at a time. Others queue. Default: none
+	ClusterOperationQueueTimeoutSeconds        uint     // Time a serialized cluster operation waits in queue for its turn before failing
+	RelocationUndoWindowSeconds                uint     // Time during which the most recent planned repoint or GTID move of an instance may be undone via undo-last-relocation. 0 disables
+	IOThreadReconnectsWindowSeconds            uint     // Replication IO thread reconnects observed within this many seconds are counted in an instance's IOThreadReconnects, e.g. by the "reconnects" candidate scorer
 	HostnameResolveMethod                      string   // Method by which to "normalize" hostname ("none"/"default"/"cname")
 	MySQLHostnameResolveMethod                 string   // Method by which to "normalize" hostname via MySQL server. ("none"/"@@hostname"/"@@report_host"; default "@@hostname")
 	SkipBinlogServerUnresolveCheck             bool     // Skip the double-check that an unresolved hostname resolves back to same hostname for binlog servers
@@ -156,6 +189,7 @@ type Configuration struct {
 	ReasonableReplicationLagSeconds            int      // Above this value is considered a problem
 	ProblemIgnoreHostnameFilters               []string // Will minimize problem visualization for hostnames matching given regexp filters
 	VerifyReplicationFilters                   bool     // Include replication filters check before approving topology refactoring
+	VerifyReplicationSettings                  bool     // Before CHANGE MASTER TO, verify binlog_row_image, lower_case_table_names, character_set_server and replication filters agree between replica and master
 	ReasonableMaintenanceReplicationLagSeconds int      // Above this value move-up and move-below are blocked
 	CandidateInstanceExpireMinutes             uint     // Minutes after which a suggestion to use an instance as a candidate replica (to be preferably promoted on master failover) is expired.
 	AuditLogFile                               string   // Name of log file for audit operations. Disabled when empty.
@@ -163,6 +197,7 @@ type Configuration struct {
 	AuditToBackendDB                           bool     // If true, audit messages are written to the backend DB's `audit` table (default: true)
 	RemoveTextFromHostnameDisplay              string   // Text to strip off the hostname on cluster/clusters pages
 	ReadOnly                                   bool
+	AnalysisMode                               bool   // When true, orchestrator neither probes instances nor runs failure detection and recoveries, and its API is read-only. For investigating cluster snapshots loaded via load-cluster-snapshot
 	AuthenticationMethod                       string // Type of autherntication to use, if any. "" for none, "basic" for BasicAuth, "multi" for advanced BasicAuth, "proxy" for forwarded credentials via reverse proxy, "token" for token based access
 	OAuthClientId                              string
 	OAuthClientSecret                          string
@@ -174,7 +209,19 @@ type Configuration struct {
 	PowerAuthGroups                            []string          // list of unix groups the authenticated user must be a member of to make changes.
 	AccessTokenUseExpirySeconds                uint              // Time by which an issued token must be used
 	AccessTokenExpiryMinutes                   uint              // Time after which HTTP access token expires
+	RequireOperationReason                     bool              // When true, mutating API requests and CLI commands must provide a reason, and an acting user must be known. Both are recorded in audit
+	OperationIntentMaxAttempts                 uint              // Max number of times a relocation/regroup intent is attempted, including resumption by newly elected leaders, before being abandoned
+	OperationIntentLeaseSeconds                uint              // Time for which a running relocation/regroup intent is leased to the node running it, which renews the lease while running. Another node only takes over the intent once its lease expires
+	IdempotencyKeyExpirySeconds                uint              // Time for which a response to an API request carrying an `Idempotency-Key` header is retained and replayed to repeated requests by same user with same key: in full by the node which served it, and by status by any other node
+	ConsistentReadWaitSeconds                  uint              // With raft, time an API request carrying a minimum raft index waits for the serving node to apply the raft log up to that index, before failing with 503
 	ClusterNameToAlias                         map[string]string // map between regex matching cluster name to a human friendly alias
+	BinlogServerDetectionQueries               map[string]string // map between binlog server implementation name (e.g. "ripple") and a query detecting it: returning a single row and column, its version, only when executed on such binlog server
+	ReplicationLagWindowSize                   uint              // Number of most recent lag samples kept per replica. Lag thresholds are evaluated on a percentile of this window rather than on the latest sample alone. 1 evaluates the latest sample
+	ReplicationLagPercentile                   float64           // Percentile of a replica's lag window compared with ReasonableReplicationLagSeconds. Lag above threshold at this percentile is "sustained"; lag above threshold only in the latest sample is a "spike" and not reported as a problem
+	ClusterReplicationLagPolicies              LagPolicies       // Per cluster (by cluster alias or cluster name) overrides of ReplicationLagWindowSize, ReplicationLagPercentile and ReasonableReplicationLagSeconds
+	ClockSkewThresholdSeconds                  uint              // An instance whose clock differs from orchestrator's by more than this many seconds, or a replica whose time zone differs from its master's, is flagged as clock skewed, and its replication lag as unreliable. 0 disables
+	StopReplicationPolicies                    StopSlavePolicies // Per operation type ("regroup", "make-master") overrides of how replication is stopped on replicas
+	ClusterMaxConcurrentReplicaOperations      map[string]uint   // Per cluster (by cluster alias or cluster name) overrides of MaxConcurrentReplicaOperations. Runtime overrides, set via API, take precedence
 	DetectClusterAliasQuery                    string            // Optional query (executed on topology instance) that returns the alias of a cluster. Query will only be executed on cluster master (though until the topology's master is resovled it may execute on other/all replicas). If provided, must return one row, one column
 	DetectClusterDomainQuery                   string            // Optional query (executed on topology instance) that returns the VIP/CNAME/Alias/whatever domain name for the master of this cluster. Query will only be executed on cluster master (though until the topology's master is resovled it may execute on other/all replicas). If provided, must return one row, one column
 	DetectInstanceAliasQuery                   string            // Optional query (executed on topology instance) that returns the alias of an instance. If provided, must return one row, one column
@@ -182,13 +229,27 @@ type Configuration struct {
 	DataCenterPattern                          string            // Regexp pattern with one group, extracting the datacenter name from the hostname
 	RegionPattern                              string            // Regexp pattern with one group, extracting the region name from the hostname
 	PhysicalEnvironmentPattern                 string            // Regexp pattern with one group, extracting physical environment info from hostname (e.g. combination of datacenter & prod/dev env)
+	NamespacePattern                           string            // Regexp pattern with one group, extracting the environment namespace (e.g. prod, staging, dev) from the hostname
 	DetectDataCenterQuery                      string            // Optional query (executed on topology instance) that returns the data center of an instance. If provided, must return one row, one column. Overrides DataCenterPattern and useful for installments where DC cannot be inferred by hostname
 	DetectRegionQuery                          string            // Optional query (executed on topology instance) that returns the region of an instance. If provided, must return one row, one column. Overrides RegionPattern and useful for installments where Region cannot be inferred by hostname
 	DetectPhysicalEnvironmentQuery             string            // Optional query (executed on topology instance) that returns the physical environment of an instance. If provided, must return one row, one column. Overrides PhysicalEnvironmentPattern and useful for installments where env cannot be inferred by hostname
+	DetectNamespaceQuery                       string            // Optional query (executed on topology instance) that returns the environment namespace of an instance. If provided, must return one row, one column. Overrides NamespacePattern
+	NamespacePolicies                          NamespacePolicies // Per environment namespace overrides of recovery configuration and API access, e.g. {"staging": {"RecoverMasterClusterFilters": ["*"]}}
 	DetectSemiSyncEnforcedQuery                string            // Optional query (executed on topology instance) to determine whether semi-sync is fully enforced for master writes (async fallback is not allowed under any circumstance). If provided, must return one row, one column, value 0 or 1.
+	SemiSyncReplicasPerMaster                  uint              // When > 0, number of semi-sync replicas enforced per semi-sync master (rpl_semi_sync_master_wait_for_slave_count is set accordingly), and semi-sync is re-enabled on masters promoted by recovery. Default: 0 (disabled)
 	SupportFuzzyPoolHostnames                  bool              // Should "submit-pool-instances" command be able to pass list of fuzzy instances (fuzzy means non-fqdn, but unique enough to recognize). Defaults 'true', implies more queries on backend db
 	InstancePoolExpiryMinutes                  uint              // Time after which entries in database_instance_pool are expired (resubmit via `submit-pool-instances`)
 	PromotionIgnoreHostnameFilters             []string          // Orchestrator will not promote replicas with hostname matching pattern (via -c recovery; for example, avoid promoting dev-dedicated machines)
+	PreventErrantGTIDPromotion                 bool              // When true, replicas with errant GTID are banned from promotion, so that a failover does not spread errant transactions to the whole cluster
+	ErrantGTIDPromotionClusterFilters          []string          // Clusters (same syntax as RecoverMasterClusterFilters) exempt from PreventErrantGTIDPromotion, e.g. while errant transactions are known and accepted
+	RemediateErrantGTIDAfterMasterFailover     bool              // When true, after promoting a master, errant GTID found on its replicas is removed by injecting empty transactions on the promoted master, as gtid-errant-inject-empty does
+	PromotionCooldownSeconds                   uint              // Instances which failed as, or were promoted to, master within this many seconds are only promoted when no other candidate is valid; avoids ping-pong between two flaky hosts. 0 disables
+	ClusterPromotionCooldownSeconds            map[string]uint   // Per cluster (by cluster alias or cluster name) overrides of PromotionCooldownSeconds. 0 disables cool-down for the cluster
+	PromotionMaxLagSeconds                     uint              // Replicas lagging (beyond their intended delay) more than this many seconds are only promoted when no other candidate is valid. 0 disables
+	ClusterPromotionMaxLagSeconds              map[string]uint   // Per cluster (by cluster alias or cluster name) overrides of PromotionMaxLagSeconds. 0 disables the lag gate for the cluster
+	PromotionTagConstraint                     string            // Tags (comma separated, as with the tagged command, e.g. "role=failover-target") which replicas must all have to be promoted. Default: none
+	ClusterPromotionTagConstraints             map[string]string // Per cluster (by cluster alias or cluster name) overrides of PromotionTagConstraint. Empty for no constraint in the cluster
+	PromotionPlanTTLSeconds                    uint              // A master promotion prepared via prepare-master-promotion must be confirmed within this many seconds
 	ServeAgentsHttp                            bool              // Spawn another HTTP interface dedicated for orchestrator-agent
 	AgentsUseSSL                               bool              // When "true" orchestrator will listen on agents port with SSL as well as connect to agents via SSL
 	AgentsUseMutualTLS                         bool              // When "true" Use mutual TLS for the server to agent communication
@@ -218,13 +279,23 @@ type Configuration struct {
 	DetectPseudoGTIDQuery                      string            // Optional query which is used to authoritatively decide whether pseudo gtid is enabled on instance
 	BinlogEventsChunkSize                      int               // Chunk size (X) for SHOW BINLOG|RELAYLOG EVENTS LIMIT ?,X statements. Smaller means less locking and mroe work to be done
 	SkipBinlogEventsContaining                 []string          // When scanning/comparing binlogs for Pseudo-GTID, skip entries containing given texts. These are NOT regular expressions (would consume too much CPU while scanning binlogs), just substrings to find.
+	BinlogVolumeCapacityMB                     uint              // When > 0, size of the volume holding binary logs on masters. Binary logs sizes are then probed (SHOW BINARY LOGS) and compared against it. Default: 0 (disabled)
+	BinlogVolumeWarningPercent                 uint              // Percent of BinlogVolumeCapacityMB used by binary logs at which a master's binlog volume is considered at risk. Default: 80
+	GTIDPurgeWarningHours                      uint              // When > 0, gtid_purged of GTID masters and the progress of their replicas are sampled each minute, and a warning is audited for a replica forecast to be unable to reconnect, its master having purged transactions it needs, within this many hours. Default: 0 (disabled)
+	GTIDPurgeRateWindowMinutes                 uint              // Period over which purge and replication rates are measured for GTIDPurgeWarningHours. Default: 360
 	ReduceReplicationAnalysisCount             bool              // When true, replication analysis will only report instances where possibility of handled problems is possible in the first place (e.g. will not report most leaf nodes, that are mostly uninteresting). When false, provides an entry for every known instance
 	FailureDetectionPeriodBlockMinutes         int               // The time for which an instance's failure discovery is kept "active", so as to avoid concurrent "discoveries" of the instance's failure; this preceeds any recovery process, if any.
 	RecoveryPeriodBlockMinutes                 int               // (supported for backwards compatibility but please use newer `RecoveryPeriodBlockSeconds` instead) The time for which an instance's recovery is kept "active", so as to avoid concurrent recoveries on smae instance as well as flapping
 	RecoveryPeriodBlockSeconds                 int               // (overrides `RecoveryPeriodBlockMinutes`) The time for which an instance's recovery is kept "active", so as to avoid concurrent recoveries on smae instance as well as flapping
+	RecoveryRateLimitPerHour                   uint              // Maximum number of automated recoveries per cluster per hour; once reached, automated recoveries on the cluster back off. 0 (default) for no limit
+	ClusterRecoveryRateLimitsPerHour           map[string]uint   // Per cluster (by cluster alias or cluster name) overrides of RecoveryRateLimitPerHour
+	RecoveryRateLimitBackoffMinutes            uint              // Minutes for which automated recoveries on a rate limited cluster are refused, doubling with each consecutive time the cluster hits its limit. Default: 10
+	RecoveryRateLimitMaxBackoffMinutes         uint              // Upper bound to the doubling RecoveryRateLimitBackoffMinutes. Default: 240
 	RecoveryIgnoreHostnameFilters              []string          // Recovery analysis will completely ignore hosts matching given patterns
 	RecoverMasterClusterFilters                []string          // Only do master recovery on clusters matching these regexp patterns (of course the ".*" pattern matches everything)
 	RecoverIntermediateMasterClusterFilters    []string          // Only do IM recovery on clusters matching these regexp patterns (of course the ".*" pattern matches everything)
+	IntermediateMasterRecoveryStrategy         string            // How replicas of a dead intermediate master are recovered: "auto" (default; weighted siblings, candidate sibling, regroup, then match up), "match-up" (below the grandparent), "regroup" (below the most up to date replica, itself matched up), or "designated:<host>:<port>" (below given server)
+	ClusterIntermediateMasterStrategies        map[string]string // Per cluster (by cluster alias or cluster name) overrides of IntermediateMasterRecoveryStrategy
 	ProcessesShellCommand                      string            // Shell that executes command scripts
 	OnFailureDetectionProcesses                []string          // Processes to execute when detecting a failover scenario (before making a decision whether to failover or not). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {successorHost}, {successorPort}, {successorAlias}, {countReplicas}, {replicaHosts}, {isDowntimed}, {autoMasterRecovery}, {autoIntermediateMasterRecovery}
 	PreGracefulTakeoverProcesses               []string          // Processes to execute before doing a failover (aborting operation should any once of them exits with non-zero code; order of execution undefined). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {countReplicas}, {replicaHosts}, {isDowntimed}
@@ -234,25 +305,70 @@ type Configuration struct {
 	PostMasterFailoverProcesses                []string          // Processes to execute after doing a master failover (order of execution undefined). Uses same placeholders as PostFailoverProcesses
 	PostIntermediateMasterFailoverProcesses    []string          // Processes to execute after doing a master failover (order of execution undefined). Uses same placeholders as PostFailoverProcesses
 	PostGracefulTakeoverProcesses              []string          // Processes to execute after runnign a graceful master takeover. Uses same placeholders as PostFailoverProcesses
+	GracefulTakeoverPauseTrafficProcesses      []string          // Processes to execute on graceful master takeover, having a proxy pause traffic to the cluster before the master goes read-only. Failure of any of these aborts the takeover. Uses same placeholders as PreGracefulTakeoverProcesses
+	GracefulTakeoverResumeTrafficProcesses     []string          // Processes to execute once a graceful master takeover, which paused traffic, completes or fails, having the proxy resume traffic. Uses same placeholders as PostFailoverProcesses
+	GracefulTakeoverTrafficHookTimeoutSeconds  uint              // Time given to all GracefulTakeoverPauseTrafficProcesses, and separately to all GracefulTakeoverResumeTrafficProcesses, to complete; a hook still running is killed. 0 for no limit
+	GracefulTakeoverCatchUpTimeoutSeconds      uint              // Time given to the designated replica in a graceful master takeover to catch up with the read-only master, after which the takeover is rolled back. 0 for no limit
+	TakeoverMustWaitForReplicas                TakeoverWaits     // Per cluster (by cluster alias or cluster name) replicas, by tags or by key, which a graceful master takeover must confirm caught up before promoting, e.g. {"mycluster": {"Tags": "role=dr", "MaxLagSeconds": 10, "TimeoutSeconds": 30}}
 	PostTakeMasterProcesses                    []string          // Processes to execute after a successful Take-Master event has taken place
 	CoMasterRecoveryMustPromoteOtherCoMaster   bool              // When 'false', anything can get promoted (and candidates are prefered over others). When 'true', orchestrator will promote the other co-master or else fail
+	CoMasterArbiter                            string            // Optional; arbiter consulted before changing writability of co-masters during recovery. "kv": claim a lock in KV stores; "witness": approval by CoMasterArbiterWitnessURL. Default: "" (none)
+	CoMasterArbiterKVPrefix                    string            // Prefix of co-master arbiter lock entries in KV stores, per cluster alias
+	CoMasterArbiterWitnessURL                  string            // URL of a third-node witness, queried with cluster, failed and promoted arguments. An HTTP 200 response approves the change
 	DetachLostSlavesAfterMasterFailover        bool              // synonym to DetachLostReplicasAfterMasterFailover
 	DetachLostReplicasAfterMasterFailover      bool              // Should replicas that are not to be lost in master recovery (i.e. were more up-to-date than promoted replica) be forcibly detached
 	ApplyMySQLPromotionAfterMasterFailover     bool              // Should orchestrator take upon itself to apply MySQL master promotion: set read_only=0, detach replication, etc.
 	PreventCrossDataCenterMasterFailover       bool              // When true (default: false), cross-DC master failover are not allowed, orchestrator will do all it can to only fail over within same DC, or else not fail over at all.
+	ClusterPromotionDataCenterPolicies         DCPolicies        // Per cluster (by cluster alias or cluster name) ranking of data centers to promote in, among equally up-to-date candidates, e.g. {"mycluster": {"DataCenters": ["dc2", "dc3"]}}. The failed master's data center ranks first unless IgnoreFailedMasterDataCenter
+	CandidateGracePeriodSeconds                uint              // When > 0, and the chosen promotion candidate is behind a more up-to-date replica which is not valid as candidate (e.g. banned, lagging, or its last check invalid), candidate selection is retried for up to this many seconds, hoping the more up-to-date replica becomes valid. Default: 0 (proceed right away)
+	ClusterCandidateGracePeriodSeconds         GracePeriods      // Per cluster (by cluster alias or cluster name) overrides of CandidateGracePeriodSeconds, e.g. {"mycluster": 30}
+	CandidateScoring                           CandidateScorers  // Optional; weights of candidate scorers which pick the promotion candidate among equally up-to-date replicas, e.g. {"lag": 1, "command": 10}. Built-in scorers: "lag", "command", "reconnects". Default: none
+	CandidateScoringCommand                    string            // Command run by the "command" candidate scorer. Candidates are listed in ORC_CANDIDATES (comma separated host:port); it prints a "host:port score" line per candidate
+	CandidateHealthCheckCommand                string            // Optional command run per candidate replica, with ORC_CANDIDATE_HOST and ORC_CANDIDATE_PORT, which prints a health score or "veto". Scores are weighted by the "health" candidate scorer; vetoed replicas are not promoted
+	CandidateHealthCheckURL                    string            // Optional HTTP endpoint checked per candidate replica when CandidateHealthCheckCommand is empty, e.g. "http://{host}:9000/health?port={port}". A 2xx response body is a health score or "veto"
+	CandidateHealthCheckTimeoutSeconds         uint              // Timeout of a candidate health check. Default: 5
+	CandidateHealthCheckFailurePolicy          string            // Outcome of a failing or timed out candidate health check: "ignore" (score 0, default) or "veto"
+	MinPromotionCandidates                     uint              // Minimum number of replicas of a cluster's master currently eligible for promotion, evaluated every minute; below it, the master is analyzed with PromotionCandidatesBelowMinimumStructureWarning. 0 (default) for no minimum
+	ClusterMinPromotionCandidates              map[string]uint   // Per cluster (by cluster alias or cluster name) overrides of MinPromotionCandidates
 	PreventCrossRegionMasterFailover           bool              // When true (default: false), cross-region master failover are not allowed, orchestrator will do all it can to only fail over within same region, or else not fail over at all.
+	WANLinks                                   WANLinkCosts      // Declared WAN links between data centers, along with their link cost, e.g. {"dc1": {"dc2": 10}}. Links are symmetric; undeclared data center pairs are considered LAN connected
+	RequireWANRelocationConfirmation           bool              // When true, a relocation which creates a new WAN-crossing replication edge is refused unless explicitly confirmed
+	OperationPolicyURL                         string            // Optional OpenPolicyAgent decision URL, e.g. "http://localhost:8181/v1/data/orchestrator/decision", queried before promotions, relocations and destructive operations. A decision is either a boolean or {"allow": <bool>, "reason": <string>}
+	OperationPolicyTimeoutSeconds              uint              // Timeout of an OperationPolicyURL query. Default: 5
+	OperationPolicyFailurePolicy               string            // Outcome of a failing, timed out or undefined OperationPolicyURL query: "deny" (default) or "allow"
 	MasterFailoverLostInstancesDowntimeMinutes uint              // Number of minutes to downtime any server that was lost after a master failover (including failed master & lost replicas). 0 to disable
 	MasterFailoverDetachSlaveMasterHost        bool              // synonym to MasterFailoverDetachReplicaMasterHost
 	MasterFailoverDetachReplicaMasterHost      bool              // Should orchestrator issue a detach-replica-master-host on newly promoted master (this makes sure the new master will not attempt to replicate old master if that comes back to life). Defaults 'false'. Meaningless if ApplyMySQLPromotionAfterMasterFailover is 'true'.
+	PromotedMasterResetSlaveAllWhenHealthy     bool              // When true, and ApplyMySQLPromotionAfterMasterFailover is false, issue RESET SLAVE ALL on a promoted master once its replicas replicate from it, clearing its stale CHANGE MASTER settings
+	PromotedMasterResetSlaveAllWaitSeconds     uint              // Time to wait for the promoted master's replicas to replicate from it before giving up on PromotedMasterResetSlaveAllWhenHealthy
 	FailMasterPromotionIfSQLThreadNotUpToDate  bool              // when true, and a master failover takes place, if candidate master has not consumed all relay logs, promotion is aborted with error
 	DelayMasterPromotionIfSQLThreadNotUpToDate bool              // when true, and a master failover takes place, if candidate master has not consumed all relay logs, delay promotion until the sql thread has caught up
+	MasterDeathQuorumConfirmation              bool              // When true, before an automated master recovery, the other raft nodes and the DeadMasterDataCenterProbeURLs in other data centers independently check whether the master is reachable. The recovery is aborted when a quorum of them, this node included, sees the master alive
+	MasterDeathConfirmationTimeoutSeconds      uint              // Time to wait for raft nodes and DeadMasterDataCenterProbeURLs to confirm master death. Those not responding in time abstain
+	DeadMasterReplicaFailureFraction           float64           // When non-zero, an unreachable master is only analyzed as dead when at least this fraction (0-1] of its reachable replicas fail to connect to it; otherwise it is analyzed as UnreachableMaster
+	DeadMasterDataCenterProbeURLs              DCProbeURLs       // Probes by the data center they run in, with {host} and {port} substituted by the master's. A probe responds with "alive" or "dead"; any other response abstains. When non-empty, an automated master recovery requires at least one probe, in a data center other than OrchestratorDataCenter, to respond "dead". The same probes vote in MasterDeathQuorumConfirmation
+	OrchestratorDataCenter                     string            // The data center orchestrator runs in. DeadMasterDataCenterProbeURLs in this data center are not asked
+	PromotedMasterWriteValidationQuery         string            // When non empty, a promoted master must execute this write, e.g. to a table in a schema of orchestrator's own, and advance its binary log position, before the master failover is deemed successful. Default: empty (disabled)
+	PromotedMasterWriteTimeoutSeconds          uint              // A write validation not completing within this many seconds, e.g. on a full disk, fails the master failover
+	ReplicationGroupElectionWaitSeconds        uint              // Upon failure of a replication group primary, time to wait for the group to elect a new primary before the recovery is deemed failed
 	PostponeSlaveRecoveryOnLagMinutes          uint              // Synonym to PostponeReplicaRecoveryOnLagMinutes
 	PostponeReplicaRecoveryOnLagMinutes        uint              // On crash recovery, replicas that are lagging more than given minutes are only resurrected late in the recovery process, after master/IM has been elected and processes executed. Value of 0 disables this feature
+	PostponedFunctionsMaxConcurrency           uint              // Maximum number of postponed recovery functions (e.g. relocation of lagging replicas) running at once, higher priorities first. 0 runs them all at once
+	PostponedFunctionDeadlineSeconds           uint              // Postponed recovery functions which have not completed this many seconds after being postponed are given up on. 0 for no deadline
 	OSCIgnoreHostnameFilters                   []string          // OSC replicas recommendation will ignore replica hostnames matching given patterns
 	GraphiteAddr                               string            // Optional; address of graphite port. If supplied, metrics will be written here
 	GraphitePath                               string            // Prefix for graphite path. May include {hostname} magic placeholder
 	GraphiteConvertHostnameDotsToUnderscores   bool              // If true, then hostname's dots are converted to underscores before being used in graphite path
 	GraphitePollSeconds                        int               // Graphite writes interval. 0 disables.
+	TracingOTLPEndpoint                        string            // Optional; base URL of an OTLP/HTTP collector, e.g. http://otel-collector:4318. If supplied, traces are exported there
+	TracingServiceName                         string            // Service name by which traces are reported
+	TracingSampleRatio                         float64           // Ratio (0..1) of traces to sample. Traces propagated from callers follow the caller's sampling decision
+	EventWebhookURLs                           []string          // Optional; URLs to which outcome events (see AuditOperation) are POSTed as JSON
+	EventWebhookIncludeProgress                bool              // When 'true', progress events (e.g. recovery steps) are POSTed to EventWebhookURLs as well
+	HookRetryMaxAttempts                       uint              // Failed webhook POSTs and non-aborting recovery hooks are queued and retried, up to this many attempts overall, after which they are dead-lettered. 0 disables the retry queue
+	HookRetryInitialBackoffSeconds             uint              // Delay before the first retry of a failed hook delivery; doubles with each further attempt
+	HookRetryMaxBackoffSeconds                 uint              // Maximum delay between retries of a failed hook delivery
+	HookRetryMaxDeliveriesPerSecond            uint              // Maximum number of queued hook deliveries retried per second
 	URLPrefix                                  string            // URL prefix to run orchestrator on non-root web path, e.g. /orchestrator to put it behind nginx.
 	DiscoveryIgnoreReplicaHostnameFilters      []string          // Regexp filters to apply to prevent auto-discovering new replicas. Usage: unreachable servers due to firewalls, applications which trigger binlog dumps
 	ConsulAddress                              string            // Address where Consul HTTP api is found. Example: 127.0.0.1:8500
@@ -263,6 +379,75 @@ type Configuration struct {
 	WebMessage                                 string            // If provided, will be shown on all web pages below the title bar
 }
 
+// LagPolicy overrides replication lag evaluation for a specific cluster. Zero values fall back to
+// the global configuration.
+type LagPolicy struct {
+	WindowSize                      uint
+	Percentile                      float64
+	ReasonableReplicationLagSeconds int
+}
+
+// LagPolicies maps a cluster alias or cluster name onto its replication lag evaluation policy
+type LagPolicies map[string]LagPolicy
+
+// StopSlavePolicy is how an operation stops replication on replicas: "StopReplicationNicely" waits, up to
+// TimeoutSeconds, for the SQL thread to catch up with the IO thread and then escalates to a normal stop;
+// "StopReplicationNormal" stops right away; "NoStopReplication" does not stop replication.
+type StopSlavePolicy struct {
+	Method         string
+	TimeoutSeconds uint
+}
+
+// StopSlavePolicies maps an operation type onto its stop replication policy
+type StopSlavePolicies map[string]StopSlavePolicy
+
+// DCPolicy ranks the data centers in which a cluster's master may be promoted: the failed master's data center
+// first, unless IgnoreFailedMasterDataCenter, then DataCenters in order, then any other data center.
+type DCPolicy struct {
+	IgnoreFailedMasterDataCenter bool
+	DataCenters                  []string
+}
+
+// DCPolicies maps a cluster alias or cluster name onto its promotion data center policy
+type DCPolicies map[string]DCPolicy
+
+// NamespacePolicy overrides configuration for the clusters of an environment namespace. Nil fields fall back to
+// the global configuration. When PowerAuthUsers is non-empty, only listed users may operate on the namespace's
+// instances via the API ("*" for any user).
+type NamespacePolicy struct {
+	RecoverMasterClusterFilters             []string
+	RecoverIntermediateMasterClusterFilters []string
+	PowerAuthUsers                          []string
+}
+
+// NamespacePolicies maps an environment namespace onto its policy
+type NamespacePolicies map[string]NamespacePolicy
+
+// GracePeriods maps a cluster alias or cluster name onto a grace period, in seconds
+type GracePeriods map[string]uint
+
+// CandidateScorers maps a candidate scorer name onto the weight of its scores
+type CandidateScorers map[string]float64
+
+// DCProbeURLs maps a data center onto the URLs of probes running in it
+type DCProbeURLs map[string][]string
+
+// TakeoverWait declares replicas which a graceful master takeover must wait for: those having all of Tags (as with
+// the tagged command, e.g. "role=dr"), and those listed in Keys (as hostname:port). With the master read-only, each
+// must replicate within MaxLagSeconds of it before TimeoutSeconds pass, or else the takeover is rolled back.
+type TakeoverWait struct {
+	Tags           string
+	Keys           []string
+	MaxLagSeconds  uint
+	TimeoutSeconds uint
+}
+
+// TakeoverWaits maps a cluster alias or cluster name onto the replicas its graceful master takeovers must wait for
+type TakeoverWaits map[string]TakeoverWait
+
+// WANLinkCosts maps pairs of data centers onto the cost of the WAN link between them
+type WANLinkCosts map[string]map[string]int
+
 // ToJSONString will marshal this configuration as JSON
 func (this *Configuration) ToJSONString() string {
 	b, _ := json.Marshal(this)
@@ -287,14 +472,20 @@ func newConfiguration() *Configuration {
 		SQLite3DataFile:                            "",
 		SkipOrchestratorDatabaseUpdate:             false,
 		PanicIfDifferentDatabaseDeploy:             false,
+		DenySchemaDeployOnVersionSkew:              false,
+		RestartForUpgradeCommand:                   "",
 		RaftBind:                                   "127.0.0.1:10008",
 		RaftAdvertise:                              "",
 		RaftDataDir:                                "",
 		DefaultRaftPort:                            10008,
 		RaftNodes:                                  []string{},
+		RaftMaxClusterArchiveBytes:                 1024 * 1024,
 		ExpectFailureAnalysisConcensus:             true,
 		MySQLOrchestratorMaxPoolConnections:        128, // limit concurrent conns to backend DB
 		MySQLOrchestratorPort:                      3306,
+		BackendOverloadLatencyMilliseconds:         0,
+		BackendOverloadErrorPercent:                0,
+		BackendOverloadWindowSeconds:               10,
 		MySQLTopologyUseMutualTLS:                  false,
 		MySQLTopologyUseMixedTLS:                   true,
 		MySQLOrchestratorUseMutualTLS:              false,
@@ -311,14 +502,38 @@ func newConfiguration() *Configuration {
 		InstanceFlushIntervalMilliseconds:          100,
 		SkipMaxScaleCheck:                          false,
 		UnseenInstanceForgetHours:                  240,
+		ReverifyUnreachableInstancesSeconds:        0,
+		ReverifyInstancesBatchSize:                 10,
+		ReverifyForgottenInstancesHours:            168,
 		SnapshotTopologiesIntervalHours:            0,
+		InventoryURL:                               "",
+		InventoryReconciliationIntervalMinutes:     0,
 		DiscoverByShowSlaveHosts:                   false,
 		UseSuperReadOnly:                           false,
 		DiscoveryMaxConcurrency:                    300,
+		ReplicationSSLRolloutConcurrency:           1,
+		MaxConcurrentReplicaOperations:             5,
 		DiscoveryQueueCapacity:                     100000,
 		DiscoveryQueueMaxStatisticsSize:            120,
 		DiscoveryCollectionRetentionSeconds:        120,
+		RapidRediscoverySeeds:                      []string{},
+		RapidRediscoveryConcurrency:                1000,
+		RapidRediscoveryMaxSeconds:                 600,
+		FocusModePollSeconds:                       1,
+		FocusModeDefaultMinutes:                    10,
+		FocusModeOnRecoveryMinutes:                 10,
+		FocusModeSampleRetentionHours:              24,
+		WriteFreezeDefaultMinutes:                  60,
 		InstanceBulkOperationsWaitTimeoutSeconds:   10,
+		TopologyOperationTimeoutSeconds:            0,
+		OperationIPChangePolicy:                    "",
+		ReplicaMoveRetries:                         0,
+		ReplicaMoveRetryBackoffMilliseconds:        1000,
+		ReplicaMoveRetryableErrors:                 []string{"connection refused", "i/o timeout", "bad connection", "invalid connection", "broken pipe", "Lost connection to MySQL server", "MySQL server has gone away", "Too many connections"},
+		SerializeClusterOperationsFilters:          []string{},
+		ClusterOperationQueueTimeoutSeconds:        60,
+		RelocationUndoWindowSeconds:                600,
+		IOThreadReconnectsWindowSeconds:            3600,
 		HostnameResolveMethod:                      "default",
 		MySQLHostnameResolveMethod:                 "@@hostname",
 		SkipBinlogServerUnresolveCheck:             true,
@@ -327,6 +542,7 @@ func newConfiguration() *Configuration {
 		ReasonableReplicationLagSeconds:            10,
 		ProblemIgnoreHostnameFilters:               []string{},
 		VerifyReplicationFilters:                   false,
+		VerifyReplicationSettings:                  true,
 		ReasonableMaintenanceReplicationLagSeconds: 20,
 		CandidateInstanceExpireMinutes:             60,
 		AuditLogFile:                               "",
@@ -334,6 +550,7 @@ func newConfiguration() *Configuration {
 		AuditToBackendDB:                           false,
 		RemoveTextFromHostnameDisplay:              "",
 		ReadOnly:                                   false,
+		AnalysisMode:                               false,
 		AuthenticationMethod:                       "",
 		HTTPAuthUser:                               "",
 		HTTPAuthPassword:                           "",
@@ -342,7 +559,21 @@ func newConfiguration() *Configuration {
 		PowerAuthGroups:                            []string{},
 		AccessTokenUseExpirySeconds:                60,
 		AccessTokenExpiryMinutes:                   1440,
+		IdempotencyKeyExpirySeconds:                3600,
+		ConsistentReadWaitSeconds:                  5,
+		OperationIntentMaxAttempts:                 3,
+		OperationIntentLeaseSeconds:                60,
+		RequireOperationReason:                     false,
 		ClusterNameToAlias:                         make(map[string]string),
+		BinlogServerDetectionQueries:               make(map[string]string),
+		ReplicationLagWindowSize:                   1,
+		ClockSkewThresholdSeconds:                  5,
+		ReplicationLagPercentile:                   50,
+		ClusterReplicationLagPolicies:              make(LagPolicies),
+		ClusterMaxConcurrentReplicaOperations:      make(map[string]uint),
+		StopReplicationPolicies:                    make(StopSlavePolicies),
+		WANLinks:                                   make(WANLinkCosts),
+		RequireWANRelocationConfirmation:           false,
 		DetectClusterAliasQuery:                    "",
 		DetectClusterDomainQuery:                   "",
 		DetectInstanceAliasQuery:                   "",
@@ -351,10 +582,24 @@ func newConfiguration() *Configuration {
 		PhysicalEnvironmentPattern:                 "",
 		DetectDataCenterQuery:                      "",
 		DetectPhysicalEnvironmentQuery:             "",
+		NamespacePattern:                           "",
+		DetectNamespaceQuery:                       "",
+		NamespacePolicies:                          NamespacePolicies{},
 		DetectSemiSyncEnforcedQuery:                "",
+		SemiSyncReplicasPerMaster:                  0,
 		SupportFuzzyPoolHostnames:                  true,
 		InstancePoolExpiryMinutes:                  60,
 		PromotionIgnoreHostnameFilters:             []string{},
+		PreventErrantGTIDPromotion:                 false,
+		ErrantGTIDPromotionClusterFilters:          []string{},
+		RemediateErrantGTIDAfterMasterFailover:     false,
+		PromotionCooldownSeconds:                   0,
+		ClusterPromotionCooldownSeconds:            make(map[string]uint),
+		PromotionMaxLagSeconds:                     0,
+		ClusterPromotionMaxLagSeconds:              make(map[string]uint),
+		PromotionTagConstraint:                     "",
+		ClusterPromotionTagConstraints:             make(map[string]string),
+		PromotionPlanTTLSeconds:                    300,
 		ServeAgentsHttp:                            false,
 		AgentsUseSSL:                               false,
 		AgentsUseMutualTLS:                         false,
@@ -382,13 +627,23 @@ func newConfiguration() *Configuration {
 		DetectPseudoGTIDQuery:                      "",
 		BinlogEventsChunkSize:                      10000,
 		SkipBinlogEventsContaining:                 []string{},
+		BinlogVolumeCapacityMB:                     0,
+		BinlogVolumeWarningPercent:                 80,
+		GTIDPurgeWarningHours:                      0,
+		GTIDPurgeRateWindowMinutes:                 360,
 		ReduceReplicationAnalysisCount:             true,
 		FailureDetectionPeriodBlockMinutes:         60,
 		RecoveryPeriodBlockMinutes:                 60,
 		RecoveryPeriodBlockSeconds:                 3600,
+		RecoveryRateLimitPerHour:                   0,
+		ClusterRecoveryRateLimitsPerHour:           make(map[string]uint),
+		RecoveryRateLimitBackoffMinutes:            10,
+		RecoveryRateLimitMaxBackoffMinutes:         240,
 		RecoveryIgnoreHostnameFilters:              []string{},
 		RecoverMasterClusterFilters:                []string{},
 		RecoverIntermediateMasterClusterFilters:    []string{},
+		IntermediateMasterRecoveryStrategy:         "auto",
+		ClusterIntermediateMasterStrategies:        make(map[string]string),
 		ProcessesShellCommand:                      "bash",
 		OnFailureDetectionProcesses:                []string{},
 		PreGracefulTakeoverProcesses:               []string{},
@@ -398,22 +653,65 @@ func newConfiguration() *Configuration {
 		PostFailoverProcesses:                      []string{},
 		PostUnsuccessfulFailoverProcesses:          []string{},
 		PostGracefulTakeoverProcesses:              []string{},
+		GracefulTakeoverPauseTrafficProcesses:      []string{},
+		GracefulTakeoverResumeTrafficProcesses:     []string{},
+		GracefulTakeoverTrafficHookTimeoutSeconds:  30,
+		GracefulTakeoverCatchUpTimeoutSeconds:      0,
+		TakeoverMustWaitForReplicas:                TakeoverWaits{},
 		PostTakeMasterProcesses:                    []string{},
 		CoMasterRecoveryMustPromoteOtherCoMaster:   true,
+		CoMasterArbiter:                            "",
+		CoMasterArbiterKVPrefix:                    "mysql/co-master-arbiter",
+		CoMasterArbiterWitnessURL:                  "",
 		DetachLostSlavesAfterMasterFailover:        true,
 		ApplyMySQLPromotionAfterMasterFailover:     true,
 		PreventCrossDataCenterMasterFailover:       false,
+		ClusterPromotionDataCenterPolicies:         DCPolicies{},
+		CandidateGracePeriodSeconds:                0,
+		ClusterCandidateGracePeriodSeconds:         GracePeriods{},
+		CandidateScoring:                           CandidateScorers{},
+		CandidateScoringCommand:                    "",
+		CandidateHealthCheckCommand:                "",
+		CandidateHealthCheckURL:                    "",
+		CandidateHealthCheckTimeoutSeconds:         5,
+		CandidateHealthCheckFailurePolicy:          "ignore",
+		MinPromotionCandidates:                     0,
+		ClusterMinPromotionCandidates:              make(map[string]uint),
+		OperationPolicyURL:                         "",
+		OperationPolicyTimeoutSeconds:              5,
+		OperationPolicyFailurePolicy:               "deny",
 		PreventCrossRegionMasterFailover:           false,
 		MasterFailoverLostInstancesDowntimeMinutes: 0,
 		MasterFailoverDetachSlaveMasterHost:        false,
 		FailMasterPromotionIfSQLThreadNotUpToDate:  false,
 		DelayMasterPromotionIfSQLThreadNotUpToDate: false,
+		MasterDeathQuorumConfirmation:              false,
+		MasterDeathConfirmationTimeoutSeconds:      5,
+		DeadMasterReplicaFailureFraction:           0,
+		DeadMasterDataCenterProbeURLs:              DCProbeURLs{},
+		OrchestratorDataCenter:                     "",
+		PromotedMasterWriteValidationQuery:         "",
+		PromotedMasterWriteTimeoutSeconds:          10,
+		PromotedMasterResetSlaveAllWhenHealthy:     false,
+		PromotedMasterResetSlaveAllWaitSeconds:     300,
+		ReplicationGroupElectionWaitSeconds:        30,
 		PostponeSlaveRecoveryOnLagMinutes:          0,
+		PostponedFunctionsMaxConcurrency:           0,
+		PostponedFunctionDeadlineSeconds:           0,
 		OSCIgnoreHostnameFilters:                   []string{},
 		GraphiteAddr:                               "",
 		GraphitePath:                               "",
 		GraphiteConvertHostnameDotsToUnderscores:   true,
 		GraphitePollSeconds:                        60,
+		TracingOTLPEndpoint:                        "",
+		TracingServiceName:                         "orchestrator",
+		TracingSampleRatio:                         1,
+		EventWebhookURLs:                           []string{},
+		EventWebhookIncludeProgress:                false,
+		HookRetryMaxAttempts:                       0,
+		HookRetryInitialBackoffSeconds:             5,
+		HookRetryMaxBackoffSeconds:                 600,
+		HookRetryMaxDeliveriesPerSecond:            5,
 		URLPrefix:                                  "",
 		DiscoveryIgnoreReplicaHostnameFilters:      []string{},
 		ConsulAddress:                              "",
@@ -426,6 +724,9 @@ func newConfiguration() *Configuration {
 }
 
 func (this *Configuration) postReadAdjustments() error {
+	if this.AnalysisMode {
+		this.ReadOnly = true
+	}
 	if this.MySQLOrchestratorCredentialsConfigFile != "" {
 		mySQLConfig := struct {
 			Client struct {
@@ -475,6 +776,31 @@ func (this *Configuration) postReadAdjustments() error {
 		}
 	}
 
+	if this.MySQLTopologySuperCredentialsConfigFile != "" {
+		mySQLConfig := struct {
+			Client struct {
+				User     string
+				Password string
+			}
+		}{}
+		err := gcfg.ReadFileInto(&mySQLConfig, this.MySQLTopologySuperCredentialsConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to parse gcfg data from file: %+v", err)
+		} else {
+			log.Debugf("Parsed topology super credentials from %s", this.MySQLTopologySuperCredentialsConfigFile)
+			this.MySQLTopologySuperUser = mySQLConfig.Client.User
+			this.MySQLTopologySuperPassword = mySQLConfig.Client.Password
+		}
+	}
+	{
+		// We accept password in the form "${SOME_ENV_VARIABLE}" in which case we pull
+		// the given variable from os env
+		submatch := envVariableRegexp.FindStringSubmatch(this.MySQLTopologySuperPassword)
+		if len(submatch) > 1 {
+			this.MySQLTopologySuperPassword = os.Getenv(submatch[1])
+		}
+	}
+
 	if this.RecoveryPeriodBlockSeconds == 0 && this.RecoveryPeriodBlockMinutes > 0 {
 		// RecoveryPeriodBlockSeconds is a newer addition that overrides RecoveryPeriodBlockMinutes
 		// The code does not consider RecoveryPeriodBlockMinutes anymore, but RecoveryPeriodBlockMinutes
@@ -505,6 +831,20 @@ func (this *Configuration) postReadAdjustments() error {
 			this.MasterFailoverDetachReplicaMasterHost = true
 		}
 	}
+	for cluster, wait := range this.TakeoverMustWaitForReplicas {
+		if wait.Tags == "" && len(wait.Keys) == 0 {
+			return fmt.Errorf("TakeoverMustWaitForReplicas: %s must declare Tags or Keys", cluster)
+		}
+	}
+	if this.DeadMasterReplicaFailureFraction < 0 || this.DeadMasterReplicaFailureFraction > 1 {
+		return fmt.Errorf("DeadMasterReplicaFailureFraction must be within [0, 1]")
+	}
+	if len(this.DeadMasterDataCenterProbeURLs) > 0 && this.MasterDeathConfirmationTimeoutSeconds == 0 {
+		return fmt.Errorf("MasterDeathConfirmationTimeoutSeconds must be positive when DeadMasterDataCenterProbeURLs are configured")
+	}
+	if this.MasterDeathQuorumConfirmation && this.MasterDeathConfirmationTimeoutSeconds == 0 {
+		return fmt.Errorf("MasterDeathConfirmationTimeoutSeconds must be positive when MasterDeathQuorumConfirmation is enabled")
+	}
 	if this.FailMasterPromotionIfSQLThreadNotUpToDate && this.DelayMasterPromotionIfSQLThreadNotUpToDate {
 		return fmt.Errorf("Cannot have both FailMasterPromotionIfSQLThreadNotUpToDate and DelayMasterPromotionIfSQLThreadNotUpToDate enabled")
 	}
@@ -517,6 +857,92 @@ func (this *Configuration) postReadAdjustments() error {
 			this.PostponeReplicaRecoveryOnLagMinutes = this.PostponeSlaveRecoveryOnLagMinutes
 		}
 	}
+	if this.ReplicationLagWindowSize == 0 {
+		this.ReplicationLagWindowSize = 1
+	}
+	if this.ReplicationLagPercentile <= 0 || this.ReplicationLagPercentile > 100 {
+		return fmt.Errorf("ReplicationLagPercentile must be in the range (0, 100]")
+	}
+	for cluster, policy := range this.ClusterReplicationLagPolicies {
+		if policy.Percentile < 0 || policy.Percentile > 100 {
+			return fmt.Errorf("ClusterReplicationLagPolicies: Percentile for %s must be in the range (0, 100]", cluster)
+		}
+	}
+	for operation, policy := range this.StopReplicationPolicies {
+		switch operation {
+		case "regroup", "make-master":
+		default:
+			return fmt.Errorf("StopReplicationPolicies: unknown operation type %s. Expected regroup or make-master", operation)
+		}
+		switch policy.Method {
+		case "", "NoStopReplication", "StopReplicationNormal", "StopReplicationNicely":
+		default:
+			return fmt.Errorf("StopReplicationPolicies: unknown method %s for %s", policy.Method, operation)
+		}
+	}
+	for _, pattern := range this.ReplicaMoveRetryableErrors {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("ReplicaMoveRetryableErrors: invalid regular expression %s: %+v", pattern, err)
+		}
+	}
+	if this.MaxConcurrentReplicaOperations == 0 {
+		this.MaxConcurrentReplicaOperations = 1
+	}
+	for cluster, concurrency := range this.ClusterMaxConcurrentReplicaOperations {
+		if concurrency == 0 {
+			return fmt.Errorf("ClusterMaxConcurrentReplicaOperations: value for %s must be positive", cluster)
+		}
+	}
+	if this.BinlogVolumeWarningPercent == 0 || this.BinlogVolumeWarningPercent > 100 {
+		return fmt.Errorf("BinlogVolumeWarningPercent must be in the range [1, 100]")
+	}
+	if this.TracingSampleRatio < 0 || this.TracingSampleRatio > 1 {
+		return fmt.Errorf("TracingSampleRatio must be in the range [0, 1]")
+	}
+	switch this.CandidateHealthCheckFailurePolicy {
+	case "ignore", "veto":
+	default:
+		return fmt.Errorf("CandidateHealthCheckFailurePolicy must be one of: ignore, veto. Got: %s", this.CandidateHealthCheckFailurePolicy)
+	}
+	if this.CandidateHealthCheckTimeoutSeconds == 0 {
+		this.CandidateHealthCheckTimeoutSeconds = 5
+	}
+	switch this.OperationPolicyFailurePolicy {
+	case "deny", "allow":
+	default:
+		return fmt.Errorf("OperationPolicyFailurePolicy must be one of: deny, allow. Got: %s", this.OperationPolicyFailurePolicy)
+	}
+	if this.OperationPolicyTimeoutSeconds == 0 {
+		this.OperationPolicyTimeoutSeconds = 5
+	}
+	switch this.CoMasterArbiter {
+	case "", "kv":
+	case "witness":
+		if this.CoMasterArbiterWitnessURL == "" {
+			return fmt.Errorf("CoMasterArbiterWitnessURL must be defined since CoMasterArbiter is witness")
+		}
+	default:
+		return fmt.Errorf("CoMasterArbiter must be one of: \"\", kv, witness. Got: %s", this.CoMasterArbiter)
+	}
+	if this.ReverifyUnreachableInstancesSeconds > 0 && this.ReverifyUnreachableInstancesSeconds < 2*this.InstancePollSeconds {
+		return fmt.Errorf("ReverifyUnreachableInstancesSeconds must be at least twice InstancePollSeconds")
+	}
+	if this.ReverifyInstancesBatchSize == 0 {
+		this.ReverifyInstancesBatchSize = 1
+	}
+	if this.OperationIntentLeaseSeconds < 3 {
+		// Lease timestamps are of second resolution; the lease is renewed at a third of its duration
+		this.OperationIntentLeaseSeconds = 3
+	}
+	if this.FocusModePollSeconds == 0 {
+		this.FocusModePollSeconds = 1
+	}
+	if this.PromotedMasterWriteTimeoutSeconds == 0 {
+		this.PromotedMasterWriteTimeoutSeconds = 1
+	}
+	if this.PromotedMasterResetSlaveAllWaitSeconds == 0 {
+		this.PromotedMasterResetSlaveAllWaitSeconds = 1
+	}
 
 	if this.URLPrefix != "" {
 		// Ensure the prefix starts with "/" and has no trailing one.
@@ -540,6 +966,9 @@ func (this *Configuration) postReadAdjustments() error {
 	if this.RaftAdvertise == "" {
 		this.RaftAdvertise = this.RaftBind
 	}
+	if this.RaftMaxClusterArchiveBytes == 0 {
+		this.RaftMaxClusterArchiveBytes = 1024 * 1024
+	}
 	if this.KVClusterMasterPrefix != "/" {
 		// "/" remains "/"
 		// "prefix" turns to "prefix/"
@@ -547,6 +976,42 @@ func (this *Configuration) postReadAdjustments() error {
 		this.KVClusterMasterPrefix = strings.TrimRight(this.KVClusterMasterPrefix, "/")
 		this.KVClusterMasterPrefix = fmt.Sprintf("%s/", this.KVClusterMasterPrefix)
 	}
+	if !isValidIntermediateMasterRecoveryStrategy(this.IntermediateMasterRecoveryStrategy) {
+		return fmt.Errorf("Unknown IntermediateMasterRecoveryStrategy: %s. Expected auto, match-up, regroup or designated:<host>:<port>", this.IntermediateMasterRecoveryStrategy)
+	}
+	for cluster, strategy := range this.ClusterIntermediateMasterStrategies {
+		if !isValidIntermediateMasterRecoveryStrategy(strategy) {
+			return fmt.Errorf("Unknown ClusterIntermediateMasterStrategies strategy for %s: %s. Expected auto, match-up, regroup or designated:<host>:<port>", cluster, strategy)
+		}
+	}
+	switch this.OperationIPChangePolicy {
+	case "", "abort", "revalidate":
+	default:
+		return fmt.Errorf("Unknown OperationIPChangePolicy: %s. Expected abort or revalidate", this.OperationIPChangePolicy)
+	}
+	if this.RecoveryRateLimitBackoffMinutes == 0 {
+		this.RecoveryRateLimitBackoffMinutes = 1
+	}
+	if this.RecoveryRateLimitMaxBackoffMinutes < this.RecoveryRateLimitBackoffMinutes {
+		this.RecoveryRateLimitMaxBackoffMinutes = this.RecoveryRateLimitBackoffMinutes
+	}
+	if len(this.GracefulTakeoverPauseTrafficProcesses) > 0 && len(this.GracefulTakeoverResumeTrafficProcesses) == 0 {
+		return fmt.Errorf("GracefulTakeoverPauseTrafficProcesses requires GracefulTakeoverResumeTrafficProcesses, lest traffic remain paused")
+	}
+	if this.GTIDPurgeRateWindowMinutes == 0 {
+		this.GTIDPurgeRateWindowMinutes = 1
+	}
+	if this.HookRetryMaxAttempts > 0 {
+		if this.HookRetryInitialBackoffSeconds == 0 {
+			this.HookRetryInitialBackoffSeconds = 1
+		}
+		if this.HookRetryMaxBackoffSeconds < this.HookRetryInitialBackoffSeconds {
+			this.HookRetryMaxBackoffSeconds = this.HookRetryInitialBackoffSeconds
+		}
+		if this.HookRetryMaxDeliveriesPerSecond == 0 {
+			this.HookRetryMaxDeliveriesPerSecond = 1
+		}
+	}
 	if this.AutoPseudoGTID {
 		this.PseudoGTIDPattern = "drop view if exists `_pseudo_gtid_`"
 		this.PseudoGTIDPatternIsFixedSubstring = true
@@ -574,6 +1039,16 @@ func (this *Configuration) postReadAdjustments() error {
 	return nil
 }
 
+// isValidIntermediateMasterRecoveryStrategy checks the syntax of an intermediate master recovery strategy; the
+// designated server's key is parsed once the strategy applies
+func isValidIntermediateMasterRecoveryStrategy(strategy string) bool {
+	switch strategy = strings.TrimSpace(strategy); strategy {
+	case "", "auto", "match-up", "regroup":
+		return true
+	}
+	return strings.HasPrefix(strategy, "designated:") && len(strategy) > len("designated:")
+}
+
 func (this *Configuration) IsSQLite() bool {
 	return strings.Contains(this.BackendDB, "sqlite")
 }
@@ -215,3 +215,79 @@ func TestHttpAdvertise(t *testing.T) {
 		test.S(t).ExpectNotNil(err)
 	}
 }
+
+func TestCoMasterArbiter(t *testing.T) {
+	{
+		c := newConfiguration()
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.CoMasterArbiter = "kv"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.CoMasterArbiter = "witness"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.CoMasterArbiter = "witness"
+		c.CoMasterArbiterWitnessURL = "http://witness:8080/approve"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.CoMasterArbiter = "quorum"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestPromotedMasterWriteTimeoutSeconds(t *testing.T) {
+	{
+		c := newConfiguration()
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.PromotedMasterWriteTimeoutSeconds, uint(10))
+	}
+	{
+		c := newConfiguration()
+		c.PromotedMasterWriteTimeoutSeconds = 0
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(c.PromotedMasterWriteTimeoutSeconds, uint(1))
+	}
+}
+
+func TestIntermediateMasterRecoveryStrategy(t *testing.T) {
+	{
+		c := newConfiguration()
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.IntermediateMasterRecoveryStrategy = "designated:relay.example.com:3306"
+		c.ClusterIntermediateMasterStrategies = map[string]string{"mycluster": "regroup"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.IntermediateMasterRecoveryStrategy = "designated:"
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		c := newConfiguration()
+		c.ClusterIntermediateMasterStrategies = map[string]string{"mycluster": "promote-sibling"}
+		err := c.postReadAdjustments()
+		test.S(t).ExpectNotNil(err)
+	}
+}
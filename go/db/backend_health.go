@@ -0,0 +1,138 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// backendHealthMinQueries is the number of backend queries within the window, below which the backend is never deemed
+// overloaded: a handful of slow or failed queries is no evidence of overload
+const backendHealthMinQueries = 10
+
+// backendHealthBucket aggregates the backend queries of a single second
+type backendHealthBucket struct {
+	unixSecond int64
+	queries    int64
+	errors     int64
+	latency    time.Duration
+}
+
+var backendHealthMutex sync.Mutex
+var backendHealthBuckets = make(map[int64]*backendHealthBucket)
+
+// BackendHealth summarizes the backend queries of the last BackendOverloadWindowSeconds
+type BackendHealth struct {
+	Queries              int64
+	Errors               int64
+	AverageLatencyMillis float64
+	Overloaded           bool
+	Reason               string
+}
+
+func backendHealthWindowSeconds() int64 {
+	if config.Config.BackendOverloadWindowSeconds == 0 {
+		return 1
+	}
+	return int64(config.Config.BackendOverloadWindowSeconds)
+}
+
+// recordBackendQuery accounts for a backend query which started at given time and completed with given error
+func recordBackendQuery(startTime time.Time, err error) {
+	now := time.Now()
+	unixSecond := now.Unix()
+
+	backendHealthMutex.Lock()
+	defer backendHealthMutex.Unlock()
+
+	bucket, found := backendHealthBuckets[unixSecond]
+	if !found {
+		bucket = &backendHealthBucket{unixSecond: unixSecond}
+		backendHealthBuckets[unixSecond] = bucket
+		for bucketSecond := range backendHealthBuckets {
+			if bucketSecond <= unixSecond-backendHealthWindowSeconds() {
+				delete(backendHealthBuckets, bucketSecond)
+			}
+		}
+	}
+	bucket.queries++
+	bucket.latency += now.Sub(startTime)
+	if err != nil {
+		bucket.errors++
+	}
+}
+
+// evaluateBackendHealth decides whether given summary depicts an overloaded backend, by configured thresholds
+func evaluateBackendHealth(health *BackendHealth) {
+	if health.Queries < backendHealthMinQueries {
+		return
+	}
+	if threshold := config.Config.BackendOverloadLatencyMilliseconds; threshold > 0 && health.AverageLatencyMillis > float64(threshold) {
+		health.Overloaded = true
+		health.Reason = fmt.Sprintf("average backend query latency is %.0fms, exceeding %dms", health.AverageLatencyMillis, threshold)
+		return
+	}
+	errorPercent := float64(100*health.Errors) / float64(health.Queries)
+	if threshold := config.Config.BackendOverloadErrorPercent; threshold > 0 && errorPercent > float64(threshold) {
+		health.Overloaded = true
+		health.Reason = fmt.Sprintf("%.0f%% of backend queries fail, exceeding %d%%", errorPercent, threshold)
+	}
+}
+
+// GetBackendHealth summarizes the backend queries of the last BackendOverloadWindowSeconds, and whether
+// the backend is overloaded
+func GetBackendHealth() BackendHealth {
+	health := BackendHealth{}
+	var latency time.Duration
+	minUnixSecond := time.Now().Unix() - backendHealthWindowSeconds()
+
+	backendHealthMutex.Lock()
+	for _, bucket := range backendHealthBuckets {
+		if bucket.unixSecond > minUnixSecond {
+			health.Queries += bucket.queries
+			health.Errors += bucket.errors
+			latency += bucket.latency
+		}
+	}
+	backendHealthMutex.Unlock()
+
+	if health.Queries > 0 {
+		health.AverageLatencyMillis = float64(latency) / float64(health.Queries) / float64(time.Millisecond)
+	}
+	evaluateBackendHealth(&health)
+	return health
+}
+
+// BackendOverloadError returns an error describing the backend overload, or nil when the backend is not overloaded
+func BackendOverloadError() error {
+	if config.Config.BackendOverloadLatencyMilliseconds == 0 && config.Config.BackendOverloadErrorPercent == 0 {
+		return nil
+	}
+	if health := GetBackendHealth(); health.Overloaded {
+		return fmt.Errorf("backend overloaded: %s", health.Reason)
+	}
+	return nil
+}
+
+// IsBackendOverloaded returns true when the backend is overloaded; non-essential work is then skipped
+func IsBackendOverloaded() bool {
+	return BackendOverloadError() != nil
+}
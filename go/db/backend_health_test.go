@@ -0,0 +1,56 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestBackendOverloadError(t *testing.T) {
+	defer func(latency, errorPercent uint) {
+		config.Config.BackendOverloadLatencyMilliseconds = latency
+		config.Config.BackendOverloadErrorPercent = errorPercent
+		backendHealthBuckets = make(map[int64]*backendHealthBucket)
+	}(config.Config.BackendOverloadLatencyMilliseconds, config.Config.BackendOverloadErrorPercent)
+
+	backendHealthBuckets = make(map[int64]*backendHealthBucket)
+	for i := 0; i < backendHealthMinQueries; i++ {
+		recordBackendQuery(time.Now().Add(-200*time.Millisecond), nil)
+	}
+	for i := 0; i < backendHealthMinQueries; i++ {
+		recordBackendQuery(time.Now(), fmt.Errorf("too many connections"))
+	}
+	health := GetBackendHealth()
+	test.S(t).ExpectEquals(health.Queries, int64(2*backendHealthMinQueries))
+	test.S(t).ExpectEquals(health.Errors, int64(backendHealthMinQueries))
+	test.S(t).ExpectTrue(health.AverageLatencyMillis >= 100)
+
+	// disabled by default
+	config.Config.BackendOverloadLatencyMilliseconds = 0
+	config.Config.BackendOverloadErrorPercent = 0
+	test.S(t).ExpectNil(BackendOverloadError())
+
+	config.Config.BackendOverloadLatencyMilliseconds = 50
+	test.S(t).ExpectNotNil(BackendOverloadError())
+	test.S(t).ExpectTrue(IsBackendOverloaded())
+
+	config.Config.BackendOverloadLatencyMilliseconds = 1000
+	test.S(t).ExpectNil(BackendOverloadError())
+
+	config.Config.BackendOverloadErrorPercent = 40
+	test.S(t).ExpectNotNil(BackendOverloadError())
+
+	config.Config.BackendOverloadErrorPercent = 60
+	test.S(t).ExpectNil(BackendOverloadError())
+}
+
+func TestBackendHealthMinQueries(t *testing.T) {
+	health := &BackendHealth{Queries: backendHealthMinQueries - 1, Errors: backendHealthMinQueries - 1}
+	defer func(errorPercent uint) { config.Config.BackendOverloadErrorPercent = errorPercent }(config.Config.BackendOverloadErrorPercent)
+	config.Config.BackendOverloadErrorPercent = 1
+	evaluateBackendHealth(health)
+	test.S(t).ExpectFalse(health.Overloaded)
+}
@@ -17,13 +17,16 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/tracing"
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/sqlutils"
 )
@@ -105,6 +108,39 @@ func openTopology(host string, port int, readTimeout int) (db *sql.DB, err error
 	return db, err
 }
 
+// HasTopologySuperCredentials returns true when elevated topology credentials are configured
+func HasTopologySuperCredentials() bool {
+	return config.Config.MySQLTopologySuperUser != ""
+}
+
+// OpenTopologySuper returns a fresh, uncached DB instance to access a topology instance
+// using the elevated (SUPER) credentials. These are requested just in time for a specific
+// operation and are never pooled; the caller is expected to Close() the returned DB.
+func OpenTopologySuper(host string, port int) (db *sql.DB, err error) {
+	if !HasTopologySuperCredentials() {
+		return nil, fmt.Errorf("OpenTopologySuper: no MySQLTopologySuperUser configured")
+	}
+	mysql_uri := fmt.Sprintf("%s:%s@tcp(%s:%d)/?timeout=%ds&readTimeout=%ds&interpolateParams=true",
+		config.Config.MySQLTopologySuperUser,
+		config.Config.MySQLTopologySuperPassword,
+		host, port,
+		config.Config.MySQLConnectTimeoutSeconds,
+		config.Config.MySQLTopologyReadTimeoutSeconds,
+	)
+	if config.Config.MySQLTopologyUseMutualTLS ||
+		(config.Config.MySQLTopologyUseMixedTLS && requiresTLS(host, port, mysql_uri)) {
+		if mysql_uri, err = SetupMySQLTopologyTLS(mysql_uri); err != nil {
+			return nil, err
+		}
+	}
+	if db, err = sql.Open("mysql", mysql_uri); err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(0)
+	return db, nil
+}
+
 func openOrchestratorMySQLGeneric() (db *sql.DB, fromCache bool, err error) {
 	uri := fmt.Sprintf("%s:%s@tcp(%s:%d)/?timeout=%ds&readTimeout=%ds&interpolateParams=true",
 		config.Config.MySQLOrchestratorUser,
@@ -214,6 +250,30 @@ func versionIsDeployed(db *sql.DB) (result bool, err error) {
 	return result, err
 }
 
+// readSkewedNodes returns the hostnames of other nodes, active on this backend, which run a different version.
+// On first deployment node_health does not exist yet, and an error is returned.
+func readSkewedNodes(db *sql.DB) (hostnames []string, err error) {
+	thisHostname, err := os.Hostname()
+	if err != nil {
+		return hostnames, err
+	}
+	query := `
+		select
+			hostname, app_version
+		from
+			node_health
+		where
+			last_seen_active > now() - interval ? second
+			and app_version not in ('', ?)
+			and hostname != ?
+		`
+	err = sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		hostnames = append(hostnames, fmt.Sprintf("%s (%s)", m.GetString("hostname"), m.GetString("app_version")))
+		return nil
+	}, config.HealthPollSeconds*2, config.RuntimeCLIFlags.ConfiguredVersion, thisHostname)
+	return hostnames, err
+}
+
 // registerOrchestratorDeployment updates the orchestrator_metadata table upon successful deployment
 func registerOrchestratorDeployment(db *sql.DB) error {
 	query := `
@@ -304,6 +364,11 @@ func initOrchestratorDB(db *sql.DB) error {
 	if config.Config.PanicIfDifferentDatabaseDeploy && config.RuntimeCLIFlags.ConfiguredVersion != "" && !versionAlreadyDeployed {
 		log.Fatalf("PanicIfDifferentDatabaseDeploy is set. Configured version %s is not the version found in the database", config.RuntimeCLIFlags.ConfiguredVersion)
 	}
+	if config.Config.DenySchemaDeployOnVersionSkew && !IsSQLite() {
+		if skewedNodes, err := readSkewedNodes(db); err == nil && len(skewedNodes) > 0 {
+			log.Fatalf("DenySchemaDeployOnVersionSkew is set. Will not migrate schema to version %s while these nodes are active: %s", config.RuntimeCLIFlags.ConfiguredVersion, strings.Join(skewedNodes, ", "))
+		}
+	}
 	log.Debugf("Migrating database schema")
 	deployStatements(db, generateSQLBase)
 	deployStatements(db, generateSQLPatches)
@@ -328,8 +393,25 @@ func execInternal(db *sql.DB, query string, args ...interface{}) (sql.Result, er
 	return res, err
 }
 
+// startBackendQuerySpan starts a span of a backend query, as a child of the span carried by given context, if any
+func startBackendQuerySpan(ctx context.Context, query string) *tracing.Span {
+	operation := tracing.StatementOperation(query)
+	span := tracing.StartClientSpanFromContext(ctx, fmt.Sprintf("backend %s", operation))
+	span.SetAttribute("db.system", "mysql")
+	if IsSQLite() {
+		span.SetAttribute("db.system", "sqlite")
+	}
+	span.SetAttribute("db.operation", operation)
+	return span
+}
+
 // ExecOrchestrator will execute given query on the orchestrator backend database.
 func ExecOrchestrator(query string, args ...interface{}) (sql.Result, error) {
+	return ExecOrchestratorContext(context.Background(), query, args...)
+}
+
+// ExecOrchestratorContext is ExecOrchestrator, where the query is traced as a child of the span carried by given context
+func ExecOrchestratorContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	var err error
 	query, err = translateStatement(query)
 	if err != nil {
@@ -339,7 +421,12 @@ func ExecOrchestrator(query string, args ...interface{}) (sql.Result, error) {
 	if err != nil {
 		return nil, err
 	}
+	span := startBackendQuerySpan(ctx, query)
+	startTime := time.Now()
 	res, err := sqlutils.ExecNoPrepare(db, query, args...)
+	recordBackendQuery(startTime, err)
+	span.SetError(err)
+	span.End()
 	return res, err
 }
 
@@ -354,11 +441,19 @@ func QueryOrchestratorRowsMap(query string, on_row func(sqlutils.RowMap) error)
 		return err
 	}
 
-	return sqlutils.QueryRowsMap(db, query, on_row)
+	startTime := time.Now()
+	err = sqlutils.QueryRowsMap(db, query, on_row)
+	recordBackendQuery(startTime, err)
+	return err
 }
 
 // QueryOrchestrator
 func QueryOrchestrator(query string, argsArray []interface{}, on_row func(sqlutils.RowMap) error) error {
+	return QueryOrchestratorContext(context.Background(), query, argsArray, on_row)
+}
+
+// QueryOrchestratorContext is QueryOrchestrator, where the query is traced as a child of the span carried by given context
+func QueryOrchestratorContext(ctx context.Context, query string, argsArray []interface{}, on_row func(sqlutils.RowMap) error) error {
 	query, err := translateStatement(query)
 	if err != nil {
 		return log.Fatalf("Cannot query orchestrator: %+v; query=%+v", err, query)
@@ -368,7 +463,13 @@ func QueryOrchestrator(query string, argsArray []interface{}, on_row func(sqluti
 		return err
 	}
 
-	return log.Criticale(sqlutils.QueryRowsMap(db, query, on_row, argsArray...))
+	span := startBackendQuerySpan(ctx, query)
+	startTime := time.Now()
+	err = sqlutils.QueryRowsMap(db, query, on_row, argsArray...)
+	recordBackendQuery(startTime, err)
+	span.SetError(err)
+	span.End()
+	return log.Criticale(err)
 }
 
 // QueryOrchestratorRowsMapBuffered
@@ -382,7 +483,10 @@ func QueryOrchestratorRowsMapBuffered(query string, on_row func(sqlutils.RowMap)
 		return err
 	}
 
-	return sqlutils.QueryRowsMapBuffered(db, query, on_row)
+	startTime := time.Now()
+	err = sqlutils.QueryRowsMapBuffered(db, query, on_row)
+	recordBackendQuery(startTime, err)
+	return err
 }
 
 // QueryOrchestratorBuffered
@@ -399,7 +503,10 @@ func QueryOrchestratorBuffered(query string, argsArray []interface{}, on_row fun
 	if argsArray == nil {
 		argsArray = EmptyArgs
 	}
-	return log.Criticale(sqlutils.QueryRowsMapBuffered(db, query, on_row, argsArray...))
+	startTime := time.Now()
+	err = sqlutils.QueryRowsMapBuffered(db, query, on_row, argsArray...)
+	recordBackendQuery(startTime, err)
+	return log.Criticale(err)
 }
 
 // ReadTimeNow reads and returns the current timestamp as string. This is an unfortunate workaround
@@ -838,4 +838,339 @@ var generateSQLBase = []string{
 	`
 		CREATE INDEX tag_name_idx_database_instance_tags ON database_instance_tags (tag_name)
 	`,
+	`
+		CREATE TABLE IF NOT EXISTS operation_intent (
+			uid varchar(128) CHARACTER SET ascii NOT NULL,
+			operation varchar(128) CHARACTER SET ascii NOT NULL,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			target_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			target_port smallint(5) unsigned NOT NULL,
+			pattern varchar(255) CHARACTER SET utf8 NOT NULL,
+			allow_wan tinyint unsigned NOT NULL DEFAULT 0,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			state varchar(32) CHARACTER SET ascii NOT NULL,
+			attempts int unsigned NOT NULL DEFAULT 0,
+			message text CHARACTER SET utf8 NOT NULL,
+			submitted_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_attempt_timestamp timestamp NULL DEFAULT NULL,
+			completed_timestamp timestamp NULL DEFAULT NULL,
+			PRIMARY KEY (uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX state_idx_operation_intent ON operation_intent (state, submitted_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS dr_pair (
+			standby_cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			standby_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			standby_port smallint(5) unsigned NOT NULL,
+			state varchar(16) CHARACTER SET ascii NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (standby_cluster_alias)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_instance_reverification (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			state varchar(16) CHARACTER SET ascii NOT NULL,
+			state_changed timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_attempted timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			attempts int unsigned NOT NULL DEFAULT 0,
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX last_attempted_idx_database_instance_reverification ON database_instance_reverification (last_attempted)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS poll_override (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			tag_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			tag_value varchar(128) CHARACTER SET utf8 NOT NULL,
+			poll_seconds int unsigned NOT NULL,
+			probe_set varchar(32) CHARACTER SET ascii NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hostname, port, tag_name, tag_value)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_instance_master_history (
+			history_id bigint unsigned not null auto_increment,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			master_host varchar(128) CHARACTER SET ascii NOT NULL,
+			master_port smallint(5) unsigned NOT NULL,
+			previous_master_host varchar(128) CHARACTER SET ascii NOT NULL,
+			previous_master_port smallint(5) unsigned NOT NULL,
+			master_log_file varchar(128) CHARACTER SET ascii NOT NULL,
+			master_log_pos bigint(20) unsigned NOT NULL,
+			method varchar(32) CHARACTER SET ascii NOT NULL,
+			cause varchar(32) CHARACTER SET ascii NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			correlation_id varchar(128) CHARACTER SET ascii NOT NULL,
+			changed_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (history_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX hostname_port_idx_database_instance_master_history ON database_instance_master_history (hostname, port, history_id)
+	`,
+	`
+		CREATE INDEX changed_timestamp_idx_database_instance_master_history ON database_instance_master_history (changed_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS replica_concurrency_override (
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			max_concurrent_operations int unsigned NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cluster_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS campaign (
+			uid varchar(128) CHARACTER SET ascii NOT NULL,
+			name varchar(128) CHARACTER SET utf8 NOT NULL,
+			variable_name varchar(128) CHARACTER SET ascii NOT NULL,
+			variable_value varchar(255) CHARACTER SET utf8 NOT NULL,
+			persist tinyint unsigned NOT NULL DEFAULT 0,
+			target_cluster varchar(128) CHARACTER SET utf8 NOT NULL,
+			target_tags varchar(255) CHARACTER SET utf8 NOT NULL,
+			concurrency int unsigned NOT NULL,
+			canary_count int unsigned NOT NULL,
+			max_error_percent int unsigned NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			state varchar(32) CHARACTER SET ascii NOT NULL,
+			message text CHARACTER SET utf8 NOT NULL,
+			started_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			completed_timestamp timestamp NULL DEFAULT NULL,
+			PRIMARY KEY (uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX started_timestamp_idx_campaign ON campaign (started_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS campaign_instance (
+			uid varchar(128) CHARACTER SET ascii NOT NULL,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			position int unsigned NOT NULL,
+			is_canary tinyint unsigned NOT NULL DEFAULT 0,
+			state varchar(32) CHARACTER SET ascii NOT NULL,
+			previous_value varchar(255) CHARACTER SET utf8 NOT NULL,
+			error text CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (uid, hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS postponement_relaxation (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			end_timestamp timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX cluster_name_idx_postponement_relaxation ON postponement_relaxation (cluster_name)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_instance_last_relocation (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			operation varchar(32) CHARACTER SET ascii NOT NULL,
+			master_host varchar(128) CHARACTER SET ascii NOT NULL,
+			master_port smallint(5) unsigned NOT NULL,
+			previous_master_host varchar(128) CHARACTER SET ascii NOT NULL,
+			previous_master_port smallint(5) unsigned NOT NULL,
+			previous_exec_master_log_file varchar(128) CHARACTER SET ascii NOT NULL,
+			previous_exec_master_log_pos bigint(20) unsigned NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			relocated_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX relocated_timestamp_idx_database_instance_last_relocation ON database_instance_last_relocation (relocated_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_instance_replication_reconnect (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			error_timestamp varchar(32) CHARACTER SET ascii NOT NULL,
+			error_number int unsigned NOT NULL,
+			observed_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hostname, port, error_timestamp)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX observed_timestamp_idx_database_instance_replication_reconnect ON database_instance_replication_reconnect (observed_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS master_promotion_plan (
+			token varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			master_host varchar(128) CHARACTER SET ascii NOT NULL,
+			master_port smallint(5) unsigned NOT NULL,
+			candidate_host varchar(128) CHARACTER SET ascii NOT NULL,
+			candidate_port smallint(5) unsigned NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			prepared_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			expires_timestamp timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			PRIMARY KEY (token)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX expires_timestamp_idx_master_promotion_plan ON master_promotion_plan (expires_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_focus (
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			end_timestamp timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			PRIMARY KEY (cluster_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX end_timestamp_idx_cluster_focus ON cluster_focus (end_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_instance_focus_sample (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			sampled_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			last_checked timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			is_last_check_valid tinyint unsigned NOT NULL,
+			master_host varchar(128) CHARACTER SET ascii NOT NULL,
+			master_port smallint(5) unsigned NOT NULL,
+			slave_io_running tinyint unsigned NOT NULL,
+			slave_sql_running tinyint unsigned NOT NULL,
+			slave_lag_seconds bigint(20) unsigned DEFAULT NULL,
+			binary_log_file varchar(128) CHARACTER SET ascii NOT NULL,
+			binary_log_pos bigint(20) unsigned NOT NULL,
+			relay_master_log_file varchar(128) CHARACTER SET ascii NOT NULL,
+			exec_master_log_pos bigint(20) unsigned NOT NULL,
+			last_io_error text CHARACTER SET utf8 NOT NULL,
+			last_sql_error text CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (hostname, port, sampled_timestamp)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX cluster_name_sampled_idx_database_instance_focus_sample ON database_instance_focus_sample (cluster_name, sampled_timestamp)
+	`,
+	`
+		CREATE INDEX sampled_timestamp_idx_database_instance_focus_sample ON database_instance_focus_sample (sampled_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_write_freeze (
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			frozen_instances text CHARACTER SET ascii NOT NULL,
+			begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			end_timestamp timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			PRIMARY KEY (cluster_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX end_timestamp_idx_cluster_write_freeze ON cluster_write_freeze (end_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS feature_flag (
+			feature_name varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			enabled tinyint unsigned NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (feature_name, cluster_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS topology_recovery_topology (
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			hostname varchar(128) NOT NULL,
+			port smallint unsigned NOT NULL,
+			master_host varchar(128) NOT NULL,
+			master_port smallint unsigned NOT NULL,
+			read_only tinyint unsigned NOT NULL,
+			recorded_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (recovery_uid, hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX recorded_timestamp_idx_topology_recovery_topology ON topology_recovery_topology (recorded_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS hook_delivery (
+			delivery_id bigint unsigned not null auto_increment,
+			hook_kind varchar(32) CHARACTER SET ascii NOT NULL,
+			description varchar(512) CHARACTER SET utf8 NOT NULL,
+			target text CHARACTER SET utf8 NOT NULL,
+			payload mediumtext CHARACTER SET utf8 NOT NULL,
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			attempts int unsigned NOT NULL,
+			last_error text CHARACTER SET utf8 NOT NULL,
+			is_dead tinyint unsigned NOT NULL,
+			first_failure_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_attempt_timestamp timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			next_attempt_timestamp timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			PRIMARY KEY (delivery_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX next_attempt_timestamp_idx_hook_delivery ON hook_delivery (is_dead, next_attempt_timestamp)
+	`,
+	`
+		CREATE INDEX last_attempt_timestamp_idx_hook_delivery ON hook_delivery (last_attempt_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS gtid_purge_history (
+			hostname varchar(128) NOT NULL,
+			port smallint unsigned NOT NULL,
+			source_uuid varchar(64) CHARACTER SET ascii NOT NULL,
+			purged_transaction bigint unsigned NOT NULL,
+			executed_transaction bigint unsigned NOT NULL,
+			recorded_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hostname, port, recorded_timestamp)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX recorded_timestamp_idx_gtid_purge_history ON gtid_purge_history (recorded_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_recovery_rate_limit (
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			window_start_unixtime bigint unsigned NOT NULL,
+			recoveries_count int unsigned NOT NULL,
+			backoff_count int unsigned NOT NULL,
+			backoff_until_unixtime bigint unsigned NOT NULL,
+			PRIMARY KEY (cluster_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS api_idempotent_response (
+			request_hash varchar(64) CHARACTER SET ascii NOT NULL,
+			in_progress tinyint unsigned NOT NULL,
+			status int unsigned NOT NULL,
+			header text CHARACTER SET utf8 NOT NULL,
+			body mediumtext CHARACTER SET utf8 NOT NULL,
+			expires_unixtime bigint unsigned NOT NULL,
+			PRIMARY KEY (request_hash)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX expires_unixtime_idx_api_idempotent_response ON api_idempotent_response (expires_unixtime)
+	`,
 }
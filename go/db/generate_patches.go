@@ -546,4 +546,185 @@ var generateSQLPatches = []string{
 			database_instance
 			ADD COLUMN region varchar(32) CHARACTER SET ascii NOT NULL AFTER data_center
 	`,
+	`
+		ALTER TABLE
+			audit
+			ADD COLUMN owner varchar(128) CHARACTER SET utf8 NOT NULL DEFAULT ''
+	`,
+	`
+		ALTER TABLE
+			audit
+			ADD COLUMN reason text CHARACTER SET utf8 NOT NULL
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN slave_lag_percentile_seconds bigint(20) unsigned DEFAULT NULL AFTER slave_lag_seconds
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_lag_pattern varchar(16) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER slave_lag_percentile_seconds
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN binlog_encryption TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER log_slave_updates
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN relay_log_encryption TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER binlog_encryption
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN has_binlog_filters TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER has_replication_filters
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN pseudo_gtid_unsafe TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER pseudo_gtid
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_name VARCHAR(64) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER semi_sync_replica_enabled
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_is_single_primary_mode TINYINT UNSIGNED NOT NULL DEFAULT 1 AFTER replication_group_name
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_member_state VARCHAR(16) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_group_is_single_primary_mode
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_member_role VARCHAR(16) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_group_member_state
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_members text CHARACTER SET ascii NOT NULL AFTER replication_group_member_role
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_primary_host varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_group_members
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_group_primary_port smallint(5) unsigned NOT NULL DEFAULT 0 AFTER replication_group_primary_host
+	`,
+	`
+		CREATE INDEX replication_group_name_idx_database_instance ON database_instance(replication_group_name)
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_wait_for_replica_count INT UNSIGNED NOT NULL DEFAULT 0 AFTER semi_sync_replica_enabled
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_clients INT UNSIGNED NOT NULL DEFAULT 0 AFTER semi_sync_master_wait_for_replica_count
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN binary_logs_size BIGINT UNSIGNED NOT NULL DEFAULT 0 AFTER binary_log_pos
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN binlog_expire_seconds INT UNSIGNED NOT NULL DEFAULT 0 AFTER binary_logs_size
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN super_read_only TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER read_only
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN capabilities text CHARACTER SET ascii NOT NULL AFTER instance_alias
+	`,
+	`
+		ALTER TABLE
+			candidate_database_instance
+			ADD COLUMN promotion_priority INT NOT NULL DEFAULT 0 AFTER promotion_rule
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN io_thread_connection_state varchar(16) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER replication_io_thread_state
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN last_heartbeat_timestamp varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER io_thread_connection_state
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN received_heartbeats bigint unsigned NOT NULL DEFAULT 0 AFTER last_heartbeat_timestamp
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN io_thread_connect_latency bigint unsigned NOT NULL DEFAULT 0 AFTER received_heartbeats
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN io_thread_reconnects int unsigned NOT NULL DEFAULT 0 AFTER io_thread_connect_latency
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN namespace varchar(32) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER physical_environment
+	`,
+	`
+		CREATE INDEX namespace_idx_database_instance ON database_instance(namespace)
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN clock_skew_seconds int NOT NULL DEFAULT 0 AFTER replication_lag_pattern
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN time_zone_offset_seconds int NOT NULL DEFAULT 0 AFTER clock_skew_seconds
+	`,
+	`
+		ALTER TABLE
+			master_promotion_plan
+			ADD COLUMN moved_replicas text CHARACTER SET ascii NOT NULL AFTER candidate_port
+	`,
+	`
+		ALTER TABLE
+			master_promotion_plan
+			ADD COLUMN lost_replicas text CHARACTER SET ascii NOT NULL AFTER moved_replicas
+	`,
+	`
+		ALTER TABLE
+			api_idempotent_response
+			ADD COLUMN body_digest varchar(64) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER body
+	`,
+	`
+		ALTER TABLE
+			operation_intent
+			ADD COLUMN claimed_by varchar(128) CHARACTER SET ascii NOT NULL DEFAULT '' AFTER completed_timestamp
+	`,
+	`
+		ALTER TABLE
+			operation_intent
+			ADD COLUMN lease_expiry_timestamp timestamp NULL DEFAULT NULL AFTER claimed_by
+	`,
 }
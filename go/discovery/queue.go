@@ -132,6 +132,14 @@ func (q *Queue) QueueLen() int {
 	return len(q.queue) + len(q.queuedKeys)
 }
 
+// ActiveLen returns the number of keys consumed and not yet released
+func (q *Queue) ActiveLen() int {
+	q.Lock()
+	defer q.Unlock()
+
+	return len(q.consumedKeys)
+}
+
 // Push enqueues a key if it is not on a queue and is not being
 // processed; silently returns otherwise.
 func (q *Queue) Push(key inst.InstanceKey) {
@@ -0,0 +1,145 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+/*
+Package events is an in-process bus of progress, outcome and topology events. Subsystems publish events; consumers are
+either synchronous handlers (e.g. the audit log, the CLI's --follow mode) or asynchronous subscriptions
+(e.g. the API event stream, webhooks).
+*/
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+type EventKind string
+
+const (
+	// ProgressEvent depicts an intermediate step of an operation, e.g. a recovery step
+	ProgressEvent EventKind = "progress"
+	// OutcomeEvent depicts the result of an operation. Outcome events are audited.
+	OutcomeEvent = "outcome"
+	// TopologyEvent depicts a change in a topology, as observed by orchestrator, e.g. a replica lost, or
+	// a recovery started
+	TopologyEvent = "topology"
+)
+
+// Event is a single progress or outcome event
+type Event struct {
+	Id          int64
+	Timestamp   time.Time
+	Kind        EventKind
+	Type        string
+	Hostname    string
+	Port        int
+	ClusterName string
+	Message     string
+	Owner       string
+	Reason      string
+}
+
+func (this *Event) String() string {
+	return fmt.Sprintf("%s [%s] %s %s:%d [%s] %s", this.Timestamp.Format(time.RFC3339), this.Kind, this.Type, this.Hostname, this.Port, this.ClusterName, this.Message)
+}
+
+// Handler synchronously consumes published events. Handlers are expected to return quickly.
+type Handler func(event *Event) error
+
+// Subscription asynchronously consumes published events. Events are dropped, rather than blocking the
+// publisher, when the subscription's buffer is full.
+type Subscription struct {
+	Events chan *Event
+}
+
+var eventsPublishedCounter = metrics.NewCounter()
+var eventsDroppedCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("events.published", eventsPublishedCounter)
+	metrics.Register("events.dropped", eventsDroppedCounter)
+}
+
+var busMutex sync.RWMutex
+var lastEventId int64
+var handlers = []Handler{}
+var subscriptions = map[*Subscription]bool{}
+
+// AddHandler registers a synchronous consumer of all events
+func AddHandler(handler Handler) {
+	busMutex.Lock()
+	defer busMutex.Unlock()
+
+	handlers = append(handlers, handler)
+}
+
+// Subscribe registers an asynchronous consumer of all events, buffering up to given number of events
+func Subscribe(bufferSize int) *Subscription {
+	busMutex.Lock()
+	defer busMutex.Unlock()
+
+	subscription := &Subscription{Events: make(chan *Event, bufferSize)}
+	subscriptions[subscription] = true
+	return subscription
+}
+
+// Close unregisters this subscription. Its events channel is closed.
+func (this *Subscription) Close() {
+	busMutex.Lock()
+	defer busMutex.Unlock()
+
+	if subscriptions[this] {
+		delete(subscriptions, this)
+		close(this.Events)
+	}
+}
+
+// Publish assigns given event an id and timestamp, and hands it to all consumers: handlers are invoked in
+// order of registration, and the first handler error, if any, is returned.
+func Publish(event *Event) (err error) {
+	busMutex.Lock()
+	lastEventId++
+	event.Id = lastEventId
+	busMutex.Unlock()
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+	eventsPublishedCounter.Inc(1)
+
+	busMutex.RLock()
+	defer busMutex.RUnlock()
+	for _, handler := range handlers {
+		if handlerErr := handler(event); handlerErr != nil && err == nil {
+			err = handlerErr
+		}
+	}
+	for subscription := range subscriptions {
+		select {
+		case subscription.Events <- event:
+		default:
+			eventsDroppedCounter.Inc(1)
+		}
+	}
+	return err
+}
+
+// PublishProgress publishes a progress event
+func PublishProgress(eventType string, clusterName string, message string) error {
+	return Publish(&Event{Kind: ProgressEvent, Type: eventType, ClusterName: clusterName, Message: message})
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestPublishToHandlersAndSubscriptions(t *testing.T) {
+	handled := []*Event{}
+	AddHandler(func(event *Event) error {
+		handled = append(handled, event)
+		return nil
+	})
+	subscription := Subscribe(1)
+	defer subscription.Close()
+
+	err := Publish(&Event{Kind: OutcomeEvent, Type: "move-up", Hostname: "h1", Port: 3306})
+	test.S(t).ExpectNil(err)
+	err = PublishProgress("recovery-step", "c1", "will recover")
+	test.S(t).ExpectNil(err)
+
+	test.S(t).ExpectEquals(len(handled), 2)
+	test.S(t).ExpectEquals(handled[0].Type, "move-up")
+	test.S(t).ExpectTrue(handled[1].Id > handled[0].Id)
+	test.S(t).ExpectFalse(handled[1].Timestamp.IsZero())
+
+	// buffer of 1: the second event is dropped rather than blocking
+	test.S(t).ExpectEquals(len(subscription.Events), 1)
+	event := <-subscription.Events
+	test.S(t).ExpectEquals(event.Type, "move-up")
+}
+
+func TestPublishHandlerError(t *testing.T) {
+	AddHandler(func(event *Event) error {
+		if event.Type == "fail" {
+			return fmt.Errorf("handler failed")
+		}
+		return nil
+	})
+	test.S(t).ExpectNotNil(Publish(&Event{Kind: OutcomeEvent, Type: "fail"}))
+	test.S(t).ExpectNil(Publish(&Event{Kind: OutcomeEvent, Type: "pass"}))
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	subscription := Subscribe(10)
+	subscription.Close()
+	subscription.Close()
+	_, ok := <-subscription.Events
+	test.S(t).ExpectFalse(ok)
+	test.S(t).ExpectNil(Publish(&Event{Kind: OutcomeEvent, Type: "after-close"}))
+}
+
+func TestShouldPostEvent(t *testing.T) {
+	defer func() { config.Config.EventWebhookIncludeProgress = false }()
+	test.S(t).ExpectTrue(shouldPostEvent(&Event{Kind: OutcomeEvent}))
+	test.S(t).ExpectTrue(shouldPostEvent(&Event{Kind: TopologyEvent}))
+	test.S(t).ExpectFalse(shouldPostEvent(&Event{Kind: ProgressEvent}))
+	config.Config.EventWebhookIncludeProgress = true
+	test.S(t).ExpectTrue(shouldPostEvent(&Event{Kind: ProgressEvent}))
+}
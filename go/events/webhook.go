@@ -0,0 +1,89 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/openark/golib/log"
+)
+
+const webhookQueueCapacity = 1024
+const webhookTimeout = 10 * time.Second
+
+var initWebhooksOnce sync.Once
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// WebhookFailureHandler is handed events which failed posting to a webhook, such that they may be retried
+type WebhookFailureHandler func(url string, body []byte, err error)
+
+// InitWebhooks is called once in the lifetime of the app, after config has been loaded. It subscribes a
+// consumer per configured EventWebhookURLs entry. Failed posts are handed to onFailure, if given.
+func InitWebhooks(onFailure WebhookFailureHandler) {
+	if len(config.Config.EventWebhookURLs) == 0 {
+		return
+	}
+	initWebhooksOnce.Do(func() {
+		for _, url := range config.Config.EventWebhookURLs {
+			go postEvents(url, Subscribe(webhookQueueCapacity), onFailure)
+		}
+	})
+}
+
+// shouldPostEvent returns true when given event is to be posted to webhooks
+func shouldPostEvent(event *Event) bool {
+	return event.Kind != ProgressEvent || config.Config.EventWebhookIncludeProgress
+}
+
+func postEvents(url string, subscription *Subscription, onFailure WebhookFailureHandler) {
+	for event := range subscription.Events {
+		if !shouldPostEvent(event) {
+			continue
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		if err := PostWebhook(url, body); err != nil {
+			log.Errorf("events: failed posting event %d to %s: %+v", event.Id, url, err)
+			if onFailure != nil {
+				onFailure(url, body, err)
+			}
+		}
+	}
+}
+
+// PostWebhook posts given JSON body to given URL, failing on a non 2xx response
+func PostWebhook(url string, body []byte) error {
+	response, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned %s", response.Status)
+	}
+	return nil
+}
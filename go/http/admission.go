@@ -0,0 +1,235 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+)
+
+// operationAPIRequests are the API requests which operate, rather than read, and which are therefore subject to
+// admission (see admitAPIOperation)
+var operationAPIRequests = map[string]bool{
+	"relocate":                         true,
+	"relocate-below":                   true,
+	"relocate-slaves":                  true,
+	"regroup-slaves":                   true,
+	"move-up":                          true,
+	"move-up-slaves":                   true,
+	"move-below":                       true,
+	"move-equivalent":                  true,
+	"repoint":                          true,
+	"undo-last-relocation":             true,
+	"repoint-slaves":                   true,
+	"make-co-master":                   true,
+	"enslave-siblings":                 true,
+	"enslave-master":                   true,
+	"master-equivalent":                true,
+	"regroup-slaves-bls":               true,
+	"retire-binlog-server-family":      true,
+	"move-below-gtid":                  true,
+	"move-slaves-gtid":                 true,
+	"regroup-slaves-gtid":              true,
+	"match":                            true,
+	"match-below":                      true,
+	"match-up":                         true,
+	"match-slaves":                     true,
+	"match-up-slaves":                  true,
+	"regroup-slaves-pgtid":             true,
+	"make-master":                      true,
+	"make-local-master":                true,
+	"enable-gtid":                      true,
+	"disable-gtid":                     true,
+	"gtid-errant-reset-master":         true,
+	"gtid-errant-inject-empty":         true,
+	"gtid-rollback":                    true,
+	"enable-cluster-replication-ssl":   true,
+	"set-replica-concurrency":          true,
+	"reset-replica-concurrency":        true,
+	"halt-campaign":                    true,
+	"skip-query":                       true,
+	"start-slave":                      true,
+	"restart-slave":                    true,
+	"stop-slave":                       true,
+	"stop-slave-nice":                  true,
+	"reset-slave":                      true,
+	"detach-slave":                     true,
+	"reattach-slave":                   true,
+	"detach-slave-master-host":         true,
+	"reattach-slave-master-host":       true,
+	"flush-binary-logs":                true,
+	"purge-binary-logs":                true,
+	"restart-slave-statements":         true,
+	"enable-semi-sync-master":          true,
+	"disable-semi-sync-master":         true,
+	"enable-semi-sync-replica":         true,
+	"disable-semi-sync-replica":        true,
+	"set-semi-sync-wait-count":         true,
+	"enforce-semi-sync":                true,
+	"set-master-delay":                 true,
+	"clear-master-delay":               true,
+	"set-read-only":                    true,
+	"set-writeable":                    true,
+	"kill-query":                       true,
+	"last-pseudo-gtid":                 true,
+	"submit-pool-instances":            true,
+	"cluster-pool-instances":           true,
+	"heuristic-cluster-pool-instances": true,
+	"heuristic-cluster-pool-lag":       true,
+	"set-cluster-alias":                true,
+	"set-poll-override":                true,
+	"remove-poll-override":             true,
+	"binlog-server-conformance":        true,
+	"discover":                         true,
+	"async-discover":                   true,
+	"rapid-rediscovery":                true,
+	"refresh":                          true,
+	"forget":                           true,
+	"forget-cluster":                   true,
+	"begin-maintenance":                true,
+	"end-maintenance":                  true,
+	"begin-downtime":                   true,
+	"end-downtime":                     true,
+	"recover":                          true,
+	"recover-lite":                     true,
+	"graceful-master-takeover":         true,
+	"graceful-master-takeover-auto":    true,
+	"recover-undo":                     true,
+	"retry-hook-delivery":              true,
+	"force-master-failover":            true,
+	"force-master-takeover":            true,
+	"force-master-failover-to":         true,
+	"prepare-master-promotion":         true,
+	"confirm-master-promotion":         true,
+	"register-candidate":               true,
+	"ack-recovery":                     true,
+	"ack-all-recoveries":               true,
+	"disable-global-recoveries":        true,
+	"enable-global-recoveries":         true,
+	"relax-lag-postponement":           true,
+	"end-lag-postponement-relaxation":  true,
+	"begin-cluster-focus":              true,
+	"end-cluster-focus":                true,
+	"begin-write-freeze":               true,
+	"end-write-freeze":                 true,
+	"reset-recovery-rate-limit":        true,
+	"enable-feature":                   true,
+	"disable-feature":                  true,
+	"reset-feature":                    true,
+	"register-dr-pair":                 true,
+	"split-dr-pair":                    true,
+	"remove-dr-pair":                   true,
+	"grab-election":                    true,
+	"raft-yield":                       true,
+	"raft-yield-hint":                  true,
+	"restart-for-upgrade":              true,
+	"reload-configuration":             true,
+	"reset-hostname-resolve-cache":     true,
+	"reelect":                          true,
+	"reload-cluster-alias":             true,
+	"deregister-hostname-unresolve":    true,
+	"register-hostname-unresolve":      true,
+	"bulk-instances":                   true,
+	"bulk-promotion-rules":             true,
+	"agents":                           true,
+	"agent":                            true,
+	"agent-umount":                     true,
+	"agent-mount":                      true,
+	"agent-create-snapshot":            true,
+	"agent-removelv":                   true,
+	"agent-mysql-stop":                 true,
+	"agent-mysql-start":                true,
+	"agent-seed":                       true,
+	"agent-active-seeds":               true,
+	"agent-recent-seeds":               true,
+	"agent-seed-details":               true,
+	"agent-seed-states":                true,
+	"agent-abort-seed":                 true,
+	"agent-custom-command":             true,
+	"seeds":                            true,
+	"topology-apply":                   true,
+	"start-campaign":                   true,
+	"import-cluster":                   true,
+}
+
+// overloadExemptAPIRequests are the operations admitted even while the backend is overloaded: recoveries,
+// and the means to control them
+var overloadExemptAPIRequests = map[string]bool{
+	"recover":                         true,
+	"recover-lite":                    true,
+	"graceful-master-takeover":        true,
+	"graceful-master-takeover-auto":   true,
+	"force-master-failover":           true,
+	"force-master-takeover":           true,
+	"force-master-failover-to":        true,
+	"confirm-master-promotion":        true,
+	"ack-recovery":                    true,
+	"ack-all-recoveries":              true,
+	"disable-global-recoveries":       true,
+	"enable-global-recoveries":        true,
+	"begin-downtime":                  true,
+	"end-downtime":                    true,
+	"relax-lag-postponement":          true,
+	"end-lag-postponement-relaxation": true,
+}
+
+// apiRequestName returns the name of the API request, e.g. "relocate" for /api/relocate/:host/:port/:belowHost/:belowPort
+func apiRequestName(req *http.Request) string {
+	path := strings.TrimPrefix(req.URL.Path, fmt.Sprintf("%s/api/", config.Config.URLPrefix))
+	return strings.SplitN(path, "/", 2)[0]
+}
+
+// admitOperation returns an error when the operation requested by req is not to be executed, because the backend
+// is overloaded. Recoveries are always admitted.
+func admitOperation(req *http.Request) error {
+	if overloadExemptAPIRequests[apiRequestName(req)] {
+		return nil
+	}
+	return db.BackendOverloadError()
+}
+
+// overloadRetryAfterSeconds is the Retry-After advised on rejected operations: the window over which backend
+// overload is evaluated, and no less than a second
+func overloadRetryAfterSeconds() uint {
+	if config.Config.BackendOverloadWindowSeconds < 1 {
+		return 1
+	}
+	return config.Config.BackendOverloadWindowSeconds
+}
+
+// admitAPIOperation precedes the handler of an operation API request (see operationAPIRequests). An authorized
+// operation which is not admitted is responded with a retryable OVERLOADED (503) response, along with Retry-After,
+// and does not reach its handler.
+func admitAPIOperation(r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedByAuthenticationMethod(req, user) {
+		// The handler rejects the request
+		return
+	}
+	if err := admitOperation(req); err != nil {
+		log.Warningf("%s: rejected: %+v", req.URL.Path, err)
+		r.Header().Set("Retry-After", fmt.Sprintf("%d", overloadRetryAfterSeconds()))
+		Respond(r, &APIResponse{Code: OVERLOADED, Message: err.Error()})
+	}
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestOverloadRetryAfterSeconds(t *testing.T) {
+	defer func(windowSeconds uint) { config.Config.BackendOverloadWindowSeconds = windowSeconds }(config.Config.BackendOverloadWindowSeconds)
+
+	config.Config.BackendOverloadWindowSeconds = 10
+	test.S(t).ExpectEquals(overloadRetryAfterSeconds(), uint(10))
+	config.Config.BackendOverloadWindowSeconds = 0
+	test.S(t).ExpectEquals(overloadRetryAfterSeconds(), uint(1))
+}
+
+func TestAdmitAPIOperation(t *testing.T) {
+	m := martini.Classic()
+	m.Use(render.Renderer())
+	m.Map(auth.User(""))
+	m.Get("/api/relocate/:host/:port/:belowHost/:belowPort", admitAPIOperation, func(r render.Render) {
+		Respond(r, &APIResponse{Code: OK})
+	})
+	// Overload detection is not configured: all operations are admitted
+	req, _ := http.NewRequest("GET", "/api/relocate/host1/3306/host2/3306", nil)
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, req)
+	test.S(t).ExpectEquals(recorder.Code, http.StatusOK)
+	test.S(t).ExpectEquals(recorder.Header().Get("Retry-After"), "")
+}
+
+func TestOverloadExemptAPIRequestsAreOperations(t *testing.T) {
+	for requestName := range overloadExemptAPIRequests {
+		test.S(t).ExpectTrue(operationAPIRequests[requestName])
+	}
+	test.S(t).ExpectTrue(operationAPIRequests["relocate"])
+	test.S(t).ExpectFalse(operationAPIRequests["instance"])
+}
@@ -17,6 +17,7 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -43,12 +44,14 @@ import (
 	"github.com/github/orchestrator/go/raft"
 )
 
-// APIResponseCode is an OK/ERROR response code
+// APIResponseCode is an OK/ERROR/OVERLOADED response code
 type APIResponseCode int
 
 const (
 	ERROR APIResponseCode = iota
 	OK
+	// OVERLOADED is a retryable rejection of an operation, due to backend overload
+	OVERLOADED
 )
 
 var apiSynonyms = map[string]string{
@@ -90,6 +93,8 @@ func (this *APIResponseCode) String() string {
 		return "ERROR"
 	case OK:
 		return "OK"
+	case OVERLOADED:
+		return "OVERLOADED"
 	}
 	return "unknown"
 }
@@ -101,6 +106,8 @@ func (this *APIResponseCode) HttpStatus() int {
 		return http.StatusInternalServerError
 	case OK:
 		return http.StatusOK
+	case OVERLOADED:
+		return http.StatusServiceUnavailable
 	}
 	return http.StatusNotImplemented
 }
@@ -188,6 +195,23 @@ func (this *HttpAPI) InstanceReplicas(params martini.Params, r render.Render, re
 	r.JSON(http.StatusOK, replicas)
 }
 
+// MasterHistory returns the recorded master changes of an instance, oldest first
+func (this *HttpAPI) MasterHistory(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	history, err := inst.ReadMasterHistory(&instanceKey)
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, history)
+}
+
 // Instance reads and returns an instance's details.
 func (this *HttpAPI) Instance(params martini.Params, r render.Render, req *http.Request) {
 	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
@@ -204,6 +228,63 @@ func (this *HttpAPI) Instance(params martini.Params, r render.Render, req *http.
 	r.JSON(http.StatusOK, instance)
 }
 
+// InstanceCapabilities returns the capabilities matrix of an instance, as last probed
+func (this *HttpAPI) InstanceCapabilities(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	capabilities, err := inst.ReadInstanceCapabilities(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Capabilities of %+v", instanceKey), Details: capabilities})
+}
+
+// ClusterCapabilities returns the capabilities matrix of a cluster's instances, as last probed
+func (this *HttpAPI) ClusterCapabilities(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	instances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	capabilities := make(map[string]inst.InstanceCapabilities)
+	for _, instance := range instances {
+		capabilities[instance.Key.StringCode()] = instance.Capabilities
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Capabilities of %s instances", clusterName), Details: capabilities})
+}
+
+// BinlogServerConformance probes a binlog server for the expectations orchestrator has of binlog servers
+func (this *HttpAPI) BinlogServerConformance(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	checks, err := inst.ProbeBinlogServerConformance(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	conforms := true
+	for _, check := range checks {
+		conforms = conforms && check.Passed
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%t", conforms), Details: checks})
+}
+
 // AsyncDiscover issues an asynchronous read on an instance. This is
 // useful for bulk loads of a new set of instances and will not block
 // if the instance is slow to respond or not reachable.
@@ -248,6 +329,24 @@ func (this *HttpAPI) Discover(params martini.Params, r render.Render, req *http.
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance discovered: %+v", instance.Key), Details: instance})
 }
 
+// RapidRediscovery crawls the fleet from given seed instance in rapid rediscovery mode
+func (this *HttpAPI) RapidRediscovery(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if err := logic.RapidRediscovery([]inst.InstanceKey{instanceKey}); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Rapid rediscovery crawling from %+v", instanceKey), Details: instanceKey})
+}
+
 // Refresh synchronuously re-reads a topology instance
 func (this *HttpAPI) Refresh(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -345,7 +444,7 @@ func (this *HttpAPI) BeginMaintenance(params martini.Params, r render.Render, re
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	key, err := inst.BeginBoundedMaintenance(&instanceKey, params["owner"], params["reason"], 0, true)
+	key, err := inst.BeginBoundedMaintenance(&instanceKey, getActingUserOnBehalfOf(req, user, params["owner"]), params["reason"], 0, true)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: key})
 		return
@@ -451,7 +550,7 @@ func (this *HttpAPI) BeginDowntime(params martini.Params, r render.Render, req *
 		}
 	}
 	duration := time.Duration(durationSeconds) * time.Second
-	downtime := inst.NewDowntime(&instanceKey, params["owner"], params["reason"], duration)
+	downtime := inst.NewDowntime(&instanceKey, getActingUserOnBehalfOf(req, user, params["owner"]), params["reason"], duration)
 	if orcraft.IsRaftEnabled() {
 		_, err = orcraft.PublishCommand("begin-downtime", downtime)
 	} else {
@@ -491,6 +590,47 @@ func (this *HttpAPI) EndDowntime(params martini.Params, r render.Render, req *ht
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime ended: %+v", instanceKey), Details: instanceKey})
 }
 
+// isDryRun returns true when the request asks for the plan of an operation rather than for its execution
+func isDryRun(req *http.Request) bool {
+	return req.URL.Query().Get("dryRun") == "true"
+}
+
+// getOperationContext returns a context bounding a topology operation to the lifetime of given request,
+// and to the request's `timeout` (a duration such as "90s"), if given, or else to TopologyOperationTimeoutSeconds.
+// The operation, and the maintenance it begins, are attributed to the acting user and the request's reason. Master
+// changes are correlated by the request's in-flight operation, if any, or else by its idempotency key, if any.
+// With `ignore-version-compatibility=true`, the operation does not enforce version compatibility.
+func getOperationContext(req *http.Request, user auth.User) (context.Context, context.CancelFunc, error) {
+	var timeout time.Duration
+	if timeoutParam := req.URL.Query().Get("timeout"); timeoutParam != "" {
+		var err error
+		if timeout, err = time.ParseDuration(timeoutParam); err != nil || timeout <= 0 {
+			return nil, nil, fmt.Errorf("Invalid timeout: %s", timeoutParam)
+		}
+	}
+	correlationID := inst.InFlightOperationFromContext(req.Context())
+	if correlationID == "" {
+		correlationID = req.Header.Get(IdempotencyKeyHeader)
+	}
+	origin := inst.NewMasterChangeOrigin(inst.MasterChangeCausePlanned, getActingUser(req, user), correlationID)
+	parent := inst.WithMasterChangeOrigin(req.Context(), origin)
+	parent = inst.WithOperationAttribution(parent, getActingUser(req, user), getOperationReason(req))
+	if req.URL.Query().Get("ignore-version-compatibility") == "true" {
+		parent = inst.WithVersionCompatibilityOverride(parent)
+	}
+	ctx, cancel := inst.NewOperationContext(parent, timeout)
+	return ctx, cancel, nil
+}
+
+// respondRelocationPlan responds with the plan of a relocation dry run
+func respondRelocationPlan(r render.Render, plan *inst.RelocationPlan, err error) {
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Dry run: %s %+v below %+v via %s", plan.Operation, plan.Key, plan.MasterKey, plan.Strategy), Details: plan})
+}
+
 // MoveUp attempts to move an instance up the topology
 func (this *HttpAPI) MoveUp(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -503,7 +643,18 @@ func (this *HttpAPI) MoveUp(params martini.Params, r render.Render, req *http.Re
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, err := inst.MoveUp(&instanceKey)
+	if isDryRun(req) {
+		plan, err := inst.PlanMoveUp(&instanceKey)
+		respondRelocationPlan(r, plan, err)
+		return
+	}
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.MoveUpContext(ctx, &instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -524,7 +675,13 @@ func (this *HttpAPI) MoveUpReplicas(params martini.Params, r render.Render, req
 		return
 	}
 
-	replicas, newMaster, err, errs := inst.MoveUpReplicas(&instanceKey, req.URL.Query().Get("pattern"))
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	replicas, newMaster, err, errs := inst.MoveUpReplicasContext(ctx, &instanceKey, req.URL.Query().Get("pattern"))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -551,7 +708,18 @@ func (this *HttpAPI) Repoint(params martini.Params, r render.Render, req *http.R
 		return
 	}
 
-	instance, err := inst.Repoint(&instanceKey, &belowKey, inst.GTIDHintNeutral)
+	if isDryRun(req) {
+		plan, err := inst.PlanRepoint(&instanceKey, &belowKey, inst.GTIDHintNeutral)
+		respondRelocationPlan(r, plan, err)
+		return
+	}
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.RepointContext(ctx, &instanceKey, &belowKey, inst.GTIDHintNeutral)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -560,6 +728,52 @@ func (this *HttpAPI) Repoint(params martini.Params, r render.Render, req *http.R
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v repointed below %+v", instanceKey, belowKey), Details: instance})
 }
 
+// UndoLastRelocation moves an instance back below the master it replicated from before its most recent
+// repoint or GTID move, within RelocationUndoWindowSeconds
+func (this *HttpAPI) UndoLastRelocation(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, relocation, err := inst.UndoLastRelocationContext(ctx, &instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Undid %s: instance %+v moved back below %+v", relocation.Operation, instanceKey, relocation.PreviousMasterKey), Details: instance})
+}
+
+// LastRelocation returns the most recent relocation of an instance, if it may still be undone
+func (this *HttpAPI) LastRelocation(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	relocation, found, err := inst.ReadLastRelocation(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if !found {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("No relocation of %+v to undo", instanceKey)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: relocation.String(), Details: relocation})
+}
+
 // MoveUpReplicas attempts to move up all replicas of an instance
 func (this *HttpAPI) RepointReplicas(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -593,7 +807,13 @@ func (this *HttpAPI) MakeCoMaster(params martini.Params, r render.Render, req *h
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, err := inst.MakeCoMaster(&instanceKey)
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.MakeCoMasterContext(ctx, &instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -614,7 +834,13 @@ func (this *HttpAPI) ResetSlave(params martini.Params, r render.Render, req *htt
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, err := inst.ResetSlaveOperation(&instanceKey)
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.ResetSlaveOperationContext(ctx, &instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -636,7 +862,13 @@ func (this *HttpAPI) DetachReplicaMasterHost(params martini.Params, r render.Ren
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, err := inst.DetachReplicaMasterHost(&instanceKey)
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.DetachReplicaMasterHostContext(ctx, &instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -658,7 +890,13 @@ func (this *HttpAPI) ReattachReplicaMasterHost(params martini.Params, r render.R
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, err := inst.ReattachReplicaMasterHost(&instanceKey)
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.ReattachReplicaMasterHostContext(ctx, &instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -737,7 +975,13 @@ func (this *HttpAPI) ErrantGTIDResetMaster(params martini.Params, r render.Rende
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, err := inst.ErrantGTIDResetMaster(&instanceKey)
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.ErrantGTIDResetMasterContext(ctx, &instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -746,29 +990,25 @@ func (this *HttpAPI) ErrantGTIDResetMaster(params martini.Params, r render.Rende
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Removed errant GTID on %+v and issued a RESET MASTER", instance.Key), Details: instance})
 }
 
-// ErrantGTIDInjectEmpty removes errant transactions by injecting and empty transaction on the cluster's master
-func (this *HttpAPI) ErrantGTIDInjectEmpty(params martini.Params, r render.Render, req *http.Request, user auth.User) {
-	if !isAuthorizedForAction(req, user) {
-		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
-		return
-	}
+// GTIDRollbackPlan returns the plan for rolling back transactions a replica executed and its cluster's master did not
+func (this *HttpAPI) GTIDRollbackPlan(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
-
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	instance, clusterMaster, countInjectedTransactions, err := inst.ErrantGTIDInjectEmpty(&instanceKey)
+	plan, err := inst.GetGTIDRollbackPlan(&instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Have injected %+v transactions on cluster master %+v", countInjectedTransactions, clusterMaster.Key), Details: instance})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("GTID rollback plan for %+v: %s", instanceKey, plan.Method), Details: plan})
 }
 
-// MoveBelow attempts to move an instance below its supposed sibling
-func (this *HttpAPI) MoveBelow(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// GTIDRollbackStep executes a single step of a replica's GTID rollback plan. Destructive steps require the
+// step's token in the "confirm" query parameter.
+func (this *HttpAPI) GTIDRollbackStep(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
@@ -778,118 +1018,148 @@ func (this *HttpAPI) MoveBelow(params martini.Params, r render.Render, req *http
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	siblingKey, err := this.getInstanceKey(params["siblingHost"], params["siblingPort"])
+	ctx, cancel, err := getOperationContext(req, user)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-
-	instance, err := inst.MoveBelow(&instanceKey, &siblingKey)
+	defer cancel()
+	plan, err := inst.ExecuteGTIDRollbackStepContext(ctx, &instanceKey, params["step"], req.URL.Query().Get("confirm"))
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: plan})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v moved below %+v", instanceKey, siblingKey), Details: instance})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Executed GTID rollback step %s on %+v", params["step"], instanceKey), Details: plan})
 }
 
-// MoveBelowGTID attempts to move an instance below another, via GTID
-func (this *HttpAPI) MoveBelowGTID(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// EnableClusterReplicationSSL enables MASTER_SSL on all replicas of a cluster in stages, then verifies
+// their replication connections are encrypted
+func (this *HttpAPI) EnableClusterReplicationSSL(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
-	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	clusterName, err := figureClusterName(getClusterHint(params))
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-	belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+	statuses, err := inst.EnableClusterReplicationSSL(clusterName)
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	plaintext := 0
+	for _, status := range statuses {
+		if status.IsPlaintext() {
+			plaintext++
+		}
+	}
+	if plaintext > 0 {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%d replicas of %s not verified as replicating via SSL", plaintext, clusterName), Details: statuses})
 		return
 	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("All %d replicas of %s replicate via SSL", len(statuses), clusterName), Details: statuses})
+}
 
-	instance, err := inst.MoveBelowGTID(&instanceKey, &belowKey)
+// ClusterReplicationSSL reports, per replica of a cluster, whether its replication connection is encrypted
+func (this *HttpAPI) ClusterReplicationSSL(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	statuses, err := inst.VerifyClusterReplicationSSL(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v moved below %+v via GTID", instanceKey, belowKey), Details: instance})
+	r.JSON(http.StatusOK, statuses)
 }
 
-// MoveReplicasGTID attempts to move an instance below another, via GTID
-func (this *HttpAPI) MoveReplicasGTID(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// StartCampaign runs a configuration campaign, given as a JSON definition in the request body.
+// The request returns when the campaign completes or halts.
+func (this *HttpAPI) StartCampaign(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
-	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
-	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
-		return
-	}
-	belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+	campaign, err := inst.ReadCampaignDefinition(req.Body)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-
-	movedReplicas, _, err, errs := inst.MoveReplicasGTID(&instanceKey, &belowKey, req.URL.Query().Get("pattern"))
+	campaign.Owner = getActingUser(req, user)
+	campaign, err = inst.StartCampaign(campaign)
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: campaign})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Moved %d replicas of %+v below %+v via GTID; %d errors: %+v", len(movedReplicas), instanceKey, belowKey, len(errs), errs), Details: belowKey})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Campaign %s completed: %+v", campaign.Name, campaign.Progress), Details: campaign})
 }
 
-// TakeSiblings
-func (this *HttpAPI) TakeSiblings(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// HaltCampaign requests a running campaign to halt before its next batch
+func (this *HttpAPI) HaltCampaign(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
-	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
-	if err != nil {
+	if err := inst.HaltCampaign(params["uid"]); err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	instance, count, err := inst.TakeSiblings(&instanceKey)
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Requested campaign %s to halt", params["uid"]), Details: params["uid"]})
+}
+
+// Campaigns lists configuration campaigns, most recent first, along with their progress
+func (this *HttpAPI) Campaigns(params martini.Params, r render.Render, req *http.Request) {
+	campaigns, err := inst.ReadCampaigns()
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Took %d siblings of %+v", count, instanceKey), Details: instance})
+	r.JSON(http.StatusOK, campaigns)
 }
 
-// TakeMaster
-func (this *HttpAPI) TakeMaster(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// Campaign reports a configuration campaign, along with the state of each of its instances
+func (this *HttpAPI) Campaign(params martini.Params, r render.Render, req *http.Request) {
+	campaign, err := inst.ReadCampaign(params["uid"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, campaign)
+}
+
+// ErrantGTIDInjectEmpty removes errant transactions by injecting and empty transaction on the cluster's master
+func (this *HttpAPI) ErrantGTIDInjectEmpty(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
 	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-
-	instance, err := inst.TakeMaster(&instanceKey, false)
+	instance, clusterMaster, countInjectedTransactions, err := inst.ErrantGTIDInjectEmpty(&instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%+v took its master", instanceKey), Details: instance})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Have injected %+v transactions on cluster master %+v", countInjectedTransactions, clusterMaster.Key), Details: instance})
 }
 
-// RelocateBelow attempts to move an instance below another, orchestrator choosing the best (potentially multi-step)
-// relocation method
-func (this *HttpAPI) RelocateBelow(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// MoveBelow attempts to move an instance below its supposed sibling
+func (this *HttpAPI) MoveBelow(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
@@ -899,23 +1169,34 @@ func (this *HttpAPI) RelocateBelow(params martini.Params, r render.Render, req *
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+	siblingKey, err := this.getInstanceKey(params["siblingHost"], params["siblingPort"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	instance, err := inst.RelocateBelow(&instanceKey, &belowKey)
+	if isDryRun(req) {
+		plan, err := inst.PlanMoveBelow(&instanceKey, &siblingKey)
+		respondRelocationPlan(r, plan, err)
+		return
+	}
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.MoveBelowContext(ctx, &instanceKey, &siblingKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v relocated below %+v", instanceKey, belowKey), Details: instance})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v moved below %+v", instanceKey, siblingKey), Details: instance})
 }
 
-// Relocates attempts to smartly relocate replicas of a given instance below another
-func (this *HttpAPI) RelocateReplicas(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// MoveBelowGTID attempts to move an instance below another, via GTID
+func (this *HttpAPI) MoveBelowGTID(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
@@ -931,17 +1212,28 @@ func (this *HttpAPI) RelocateReplicas(params martini.Params, r render.Render, re
 		return
 	}
 
-	replicas, _, err, errs := inst.RelocateReplicas(&instanceKey, &belowKey, req.URL.Query().Get("pattern"))
+	if isDryRun(req) {
+		plan, err := inst.PlanMoveBelowGTID(&instanceKey, &belowKey)
+		respondRelocationPlan(r, plan, err)
+		return
+	}
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.MoveBelowGTIDContext(ctx, &instanceKey, &belowKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Relocated %d replicas of %+v below %+v; %d errors: %+v", len(replicas), instanceKey, belowKey, len(errs), errs), Details: replicas})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v moved below %+v via GTID", instanceKey, belowKey), Details: instance})
 }
 
-// MoveEquivalent attempts to move an instance below another, baseed on known equivalence master coordinates
-func (this *HttpAPI) MoveEquivalent(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// MoveReplicasGTID attempts to move an instance below another, via GTID
+func (this *HttpAPI) MoveReplicasGTID(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
@@ -957,6 +1249,184 @@ func (this *HttpAPI) MoveEquivalent(params martini.Params, r render.Render, req
 		return
 	}
 
+	movedReplicas, _, err, errs := inst.MoveReplicasGTID(&instanceKey, &belowKey, req.URL.Query().Get("pattern"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Moved %d replicas of %+v below %+v via GTID; %d errors: %+v", len(movedReplicas), instanceKey, belowKey, len(errs), errs), Details: belowKey})
+}
+
+// TakeSiblings
+func (this *HttpAPI) TakeSiblings(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	instance, count, err := inst.TakeSiblings(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Took %d siblings of %+v", count, instanceKey), Details: instance})
+}
+
+// TakeMaster
+func (this *HttpAPI) TakeMaster(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	instance, err := inst.TakeMaster(&instanceKey, false)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%+v took its master", instanceKey), Details: instance})
+}
+
+// RelocateBelow attempts to move an instance below another, orchestrator choosing the best (potentially multi-step)
+// relocation method
+func (this *HttpAPI) RelocateBelow(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	if isDryRun(req) {
+		plan, err := inst.PlanRelocateBelow(&instanceKey, &belowKey, req.URL.Query().Get("allow-wan") == "true")
+		respondRelocationPlan(r, plan, err)
+		return
+	}
+	intent := logic.NewOperationIntent(logic.RelocateBelowIntent, &instanceKey, &belowKey, getActingUser(req, user), getOperationReason(req))
+	intent.AllowWAN = (req.URL.Query().Get("allow-wan") == "true")
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := logic.SubmitOperationIntent(ctx, intent)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Instance %+v relocated below %+v", instanceKey, belowKey), Details: instance})
+}
+
+// TopologyApply converges topologies onto a desired topology, given as request body: a JSON object (or flat
+// YAML mapping) of instance to intended master. With dryRun=true, only the plan is computed.
+func (this *HttpAPI) TopologyApply(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	desired, err := inst.ReadDesiredTopology(req.Body)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot read desired topology: %+v", err)})
+		return
+	}
+	plan, err := inst.PlanTopology(desired)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if isDryRun(req) {
+		Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Dry run: %d steps, %d instances unchanged", len(plan.Steps), len(plan.Unchanged)), Details: plan})
+		return
+	}
+	plan, err = inst.ApplyTopologyPlan(plan, req.URL.Query().Get("allow-wan") == "true")
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: plan})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Applied %d steps, %d instances unchanged", len(plan.Steps), len(plan.Unchanged)), Details: plan})
+}
+
+// Relocates attempts to smartly relocate replicas of a given instance below another
+func (this *HttpAPI) RelocateReplicas(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	intent := logic.NewOperationIntent(logic.RelocateReplicasIntent, &instanceKey, &belowKey, getActingUser(req, user), getOperationReason(req))
+	intent.Pattern = req.URL.Query().Get("pattern")
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	result, err := logic.SubmitOperationIntent(ctx, intent)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	replicas := result.([](*inst.Instance))
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Relocated %d replicas of %+v below %+v; %s", len(replicas), instanceKey, belowKey, intent.Message), Details: replicas})
+}
+
+// MoveEquivalent attempts to move an instance below another, baseed on known equivalence master coordinates
+func (this *HttpAPI) MoveEquivalent(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	if isDryRun(req) {
+		plan, err := inst.PlanMoveEquivalent(&instanceKey, &belowKey)
+		respondRelocationPlan(r, plan, err)
+		return
+	}
 	instance, err := inst.MoveEquivalent(&instanceKey, &belowKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
@@ -1018,7 +1488,18 @@ func (this *HttpAPI) MatchBelow(params martini.Params, r render.Render, req *htt
 		return
 	}
 
-	instance, matchedCoordinates, err := inst.MatchBelow(&instanceKey, &belowKey, true)
+	if isDryRun(req) {
+		plan, err := inst.PlanMatchBelow(&instanceKey, &belowKey, true)
+		respondRelocationPlan(r, plan, err)
+		return
+	}
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, matchedCoordinates, err := inst.MatchBelowContext(ctx, &instanceKey, &belowKey, true)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1108,15 +1589,25 @@ func (this *HttpAPI) RegroupReplicas(params martini.Params, r render.Render, req
 		return
 	}
 
-	lostReplicas, equalReplicas, aheadReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicas(&instanceKey, false, nil, nil)
-	lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
+	intent := logic.NewOperationIntent(logic.RegroupReplicasIntent, &instanceKey, nil, getActingUser(req, user), getOperationReason(req))
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	result, err := logic.SubmitOperationIntent(ctx, intent)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
+	promotedReplica, _ := result.(*inst.Instance)
+	if promotedReplica == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Could not regroup replicas of %+v; no replica promoted", instanceKey)})
+		return
+	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("promoted replica: %s, lost: %d, trivial: %d, pseudo-gtid: %d",
-		promotedReplica.Key.DisplayString(), len(lostReplicas), len(equalReplicas), len(aheadReplicas)), Details: promotedReplica.Key})
+	Respond(r, &APIResponse{Code: OK, Message: intent.Message, Details: promotedReplica.Key})
 }
 
 // RegroupReplicas attempts to pick a replica of a given instance and make it take its siblings, efficiently,
@@ -1132,7 +1623,13 @@ func (this *HttpAPI) RegroupReplicasPseudoGTID(params martini.Params, r render.R
 		return
 	}
 
-	lostReplicas, equalReplicas, aheadReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasPseudoGTID(&instanceKey, false, nil, nil, nil)
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	lostReplicas, equalReplicas, aheadReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasPseudoGTIDContext(ctx, &instanceKey, false, nil, nil, nil)
 	lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
 
 	if err != nil {
@@ -1156,7 +1653,13 @@ func (this *HttpAPI) RegroupReplicasGTID(params martini.Params, r render.Render,
 		return
 	}
 
-	lostReplicas, movedReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasGTID(&instanceKey, false, nil, nil, nil)
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	lostReplicas, movedReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasGTIDContext(ctx, &instanceKey, false, nil, nil, nil)
 	lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
 
 	if err != nil {
@@ -1191,6 +1694,43 @@ func (this *HttpAPI) RegroupReplicasBinlogServers(params martini.Params, r rende
 		promotedBinlogServer.Key.DisplayString()), Details: promotedBinlogServer.Key})
 }
 
+// RetireBinlogServerFamily migrates all replicas of a binlog server family onto direct replication from the master,
+// or below a designated intermediate master
+func (this *HttpAPI) RetireBinlogServerFamily(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var designatedKey *inst.InstanceKey
+	if params["belowHost"] != "" {
+		belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+		designatedKey = &belowKey
+	}
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+
+	retirement, err := inst.RetireBinlogServerFamilyContext(ctx, &instanceKey, designatedKey, req.URL.Query().Get("allow-wan") == "true")
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: retirement})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("migrated %d replicas off %d binlog servers", len(retirement.MovedReplicas), len(retirement.BinlogServers)), Details: retirement})
+}
+
 // MakeMaster attempts to make the given instance a master, and match its siblings to be its replicas
 func (this *HttpAPI) MakeMaster(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -1203,7 +1743,13 @@ func (this *HttpAPI) MakeMaster(params martini.Params, r render.Render, req *htt
 		return
 	}
 
-	instance, err := inst.MakeMaster(&instanceKey)
+	ctx, cancel, err := getOperationContext(req, user)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer cancel()
+	instance, err := inst.MakeMasterContext(ctx, &instanceKey)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1360,6 +1906,64 @@ func (this *HttpAPI) FlushBinaryLogs(params martini.Params, r render.Render, req
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Binary logs flushed on: %+v", instance.Key), Details: instance})
 }
 
+// BinlogPurgeAdvice advises on purging binary logs on a master, based on its binlog volume usage
+func (this *HttpAPI) BinlogPurgeAdvice(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	advice, err := inst.ReadBinlogPurgeAdvice(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	message := "No purge advised"
+	if advice.PurgeToLogFile != "" {
+		message = fmt.Sprintf("Purge binary logs up to %s to reclaim %d bytes", advice.PurgeToLogFile, advice.ReclaimableBytes)
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: message, Details: advice})
+}
+
+// GTIDPurgeForecast forecasts, per replica of GTID masters, when its master's purging leaves it unable to reconnect
+func (this *HttpAPI) GTIDPurgeForecast(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		if clusterName, err = figureClusterName(getClusterHint(params)); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	forecasts, err := inst.ReadGTIDPurgeForecasts(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, forecasts)
+}
+
+// PromotionCandidateQuorum reports, per cluster having a minimum of promotion candidates, its latest evaluation.
+// Given a cluster, evaluates it now, whether or not it has a minimum.
+func (this *HttpAPI) PromotionCandidateQuorum(params martini.Params, r render.Render, req *http.Request) {
+	if getClusterHint(params) == "" {
+		r.JSON(http.StatusOK, inst.ReadPromotionCandidateQuorums())
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	quorum, err := inst.EvaluatePromotionCandidateQuorum(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, quorum)
+}
+
 // PurgeBinaryLogs purges binary logs up to given binlog file
 func (this *HttpAPI) PurgeBinaryLogs(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -1477,6 +2081,27 @@ func (this *HttpAPI) CanReplicateFrom(params martini.Params, r render.Render, re
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%t", canReplicate), Details: belowKey})
 }
 
+// ReplicationCompatibility freshly reads both instances and lists all checks of whether the first can replicate from the second
+func (this *HttpAPI) ReplicationCompatibility(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	belowKey, err := this.getInstanceKey(params["belowHost"], params["belowPort"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	compatibility, err := inst.CheckReplicationCompatibility(&instanceKey, &belowKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%t", compatibility.CanReplicate), Details: compatibility})
+}
+
 // CanReplicateFromGTID attempts to move an instance below another via GTID.
 func (this *HttpAPI) CanReplicateFromGTID(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
@@ -1576,14 +2201,118 @@ func (this *HttpAPI) DisableSemiSyncReplica(params martini.Params, r render.Rend
 	this.setSemiSyncReplica(params, r, req, user, false)
 }
 
-// SetReadOnly sets the global read_only variable
-func (this *HttpAPI) SetReadOnly(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// SetSemiSyncWaitCount sets rpl_semi_sync_master_wait_for_slave_count on given master
+func (this *HttpAPI) SetSemiSyncWaitCount(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
 	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
-
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	count, err := strconv.ParseUint(params["count"], 10, 0)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid count: %s", params["count"])})
+		return
+	}
+	instance, err := inst.SetSemiSyncMasterWaitForReplicaCount(&instanceKey, uint(count))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("master semi-sync wait count set to %d", count), Details: instance})
+}
+
+// EnforceSemiSync enforces a number of semi-sync replicas on given master; SemiSyncReplicasPerMaster unless
+// explicitly given
+func (this *HttpAPI) EnforceSemiSync(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	count := config.Config.SemiSyncReplicasPerMaster
+	if params["count"] != "" {
+		parsedCount, err := strconv.ParseUint(params["count"], 10, 0)
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid count: %s", params["count"])})
+			return
+		}
+		count = uint(parsedCount)
+	}
+	if count == 0 {
+		Respond(r, &APIResponse{Code: ERROR, Message: "No semi-sync replica count given, and SemiSyncReplicasPerMaster is 0"})
+		return
+	}
+	instance, err := inst.EnforceMasterSemiSync(&instanceKey, count)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("semi-sync enforced: %d replicas", instance.SemiSyncMasterWaitForReplicaCount), Details: instance})
+}
+
+// SetMasterDelay sets MASTER_DELAY on a replica
+func (this *HttpAPI) SetMasterDelay(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	delaySeconds, err := strconv.ParseUint(params["seconds"], 10, 0)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid delay seconds: %s", params["seconds"])})
+		return
+	}
+	instance, err := inst.SetMasterDelay(&instanceKey, uint(delaySeconds))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Replica delayed by %d seconds", instance.SQLDelay), Details: instance})
+}
+
+// ClearMasterDelay removes MASTER_DELAY from a replica
+func (this *HttpAPI) ClearMasterDelay(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	instance, err := inst.ClearMasterDelay(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: "Replica delay cleared", Details: instance})
+}
+
+// SetReadOnly sets the global read_only variable
+func (this *HttpAPI) SetReadOnly(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1652,7 +2381,9 @@ func (this *HttpAPI) asciiTopology(params martini.Params, r render.Render, req *
 		return
 	}
 
-	asciiOutput, err := inst.ASCIITopology(clusterName, "", tabulated)
+	filter := &inst.TopologyFilter{HostnamePattern: req.URL.Query().Get("filter"), Tags: req.URL.Query().Get("tag")}
+	colorize := (req.URL.Query().Get("color") == "true")
+	asciiOutput, err := inst.ASCIITopology(clusterName, "", tabulated, colorize, filter)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
@@ -1661,6 +2392,83 @@ func (this *HttpAPI) asciiTopology(params martini.Params, r render.Render, req *
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Topology for cluster %s", clusterName), Details: asciiOutput})
 }
 
+// TopologyJSON returns the tree of cluster's instances, as structured JSON
+func (this *HttpAPI) TopologyJSON(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	roots, err := inst.TopologyJSON(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Topology for cluster %s", clusterName), Details: roots})
+}
+
+// TopologyMermaid returns the tree of cluster's instances as a mermaid flowchart
+func (this *HttpAPI) TopologyMermaid(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	flowchart, err := inst.TopologyMermaid(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Topology for cluster %s", clusterName), Details: flowchart})
+}
+
+// ExportClusterSnapshot returns the backend data of a cluster, as loaded by load-cluster-snapshot
+func (this *HttpAPI) ExportClusterSnapshot(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	snapshot, err := logic.ExportClusterSnapshot(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, snapshot)
+}
+
+// TopologyDiff compares the topology of a cluster between two points in time, given by the "since" and (optional) "until" query params
+func (this *HttpAPI) TopologyDiff(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	since, err := inst.ParseTopologyHistoryTime(req.URL.Query().Get("since"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	until, err := inst.ParseTopologyHistoryTime(req.URL.Query().Get("until"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	diff, err := inst.TopologyHistoryDiffOf(clusterName, since, until)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Topology diff for cluster %s", clusterName), Details: diff})
+}
+
 // SnapshotTopologies triggers orchestrator to record a snapshot of host/master for all known hosts.
 func (this *HttpAPI) SnapshotTopologies(params martini.Params, r render.Render, req *http.Request) {
 	start := time.Now()
@@ -1700,6 +2508,24 @@ func (this *HttpAPI) Cluster(params martini.Params, r render.Render, req *http.R
 	r.JSON(http.StatusOK, instances)
 }
 
+// ClusterTree provides the instances of given cluster as a nested tree, annotated with lag, validity and
+// downtime status, so that the cluster may be rendered in a single request
+func (this *HttpAPI) ClusterTree(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	tree, err := inst.ReadClusterTree(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, tree)
+}
+
 // ClusterByAlias provides list of instances in given cluster
 func (this *HttpAPI) ClusterByAlias(params martini.Params, r render.Render, req *http.Request) {
 	clusterName, err := inst.GetClusterByAlias(params["clusterAlias"])
@@ -1746,6 +2572,50 @@ func (this *HttpAPI) ClusterInfo(params martini.Params, r render.Render, req *ht
 	r.JSON(http.StatusOK, clusterInfo)
 }
 
+// ExportCluster returns a portable archive of a cluster's orchestrator state: instances, tags, candidates,
+// downtime, alias and recovery history
+func (this *HttpAPI) ExportCluster(params martini.Params, r render.Render, req *http.Request, w http.ResponseWriter) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	archive, err := logic.ExportClusterArchive(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	b, err := logic.ClusterArchiveBytes(archive)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.orchestrator-cluster.json.gz"`, strings.Replace(clusterName, ":", "_", -1)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// ImportCluster imports a cluster archive, as produced by ExportCluster, given as request body
+func (this *HttpAPI) ImportCluster(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	archive, err := logic.ReadClusterArchive(req.Body)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot read cluster archive: %+v", err)})
+		return
+	}
+	summary, err := logic.SubmitClusterArchiveImport(archive)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Imported cluster %s", archive.ClusterName), Details: summary})
+}
+
 // Cluster provides list of instances in given cluster
 func (this *HttpAPI) ClusterInfoByAlias(params martini.Params, r render.Render, req *http.Request) {
 	clusterName, err := inst.GetClusterByAlias(params["clusterAlias"])
@@ -1800,6 +2670,19 @@ func (this *HttpAPI) SetClusterAliasManualOverride(params martini.Params, r rend
 
 // Clusters provides list of known clusters
 func (this *HttpAPI) Clusters(params martini.Params, r render.Render, req *http.Request) {
+	if namespace := req.URL.Query().Get("namespace"); namespace != "" {
+		clustersInfo, err := inst.ReadClustersInfo("")
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+		clusterNames := []string{}
+		for _, clusterInfo := range filterClustersInfoByNamespace(clustersInfo, namespace) {
+			clusterNames = append(clusterNames, clusterInfo.ClusterName)
+		}
+		r.JSON(http.StatusOK, clusterNames)
+		return
+	}
 	clusterNames, err := inst.ReadClusters()
 
 	if err != nil {
@@ -1819,7 +2702,55 @@ func (this *HttpAPI) ClustersInfo(params martini.Params, r render.Render, req *h
 		return
 	}
 
-	r.JSON(http.StatusOK, clustersInfo)
+	r.JSON(http.StatusOK, filterClustersInfoByNamespace(clustersInfo, req.URL.Query().Get("namespace")))
+}
+
+// InventoryReconciliation reconciles known instances against the external inventory, optionally reporting on
+// given cluster only. With cached=true, the latest reconciliation run by this node is returned instead.
+func (this *HttpAPI) InventoryReconciliation(params martini.Params, r render.Render, req *http.Request) {
+	reconciliation := inst.ReadLastInventoryReconciliation()
+	if reconciliation == nil || req.URL.Query().Get("cached") != "true" {
+		var err error
+		if reconciliation, err = inst.ReconcileInventory(); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	if cluster := req.URL.Query().Get("cluster"); cluster != "" {
+		reconciliation = reconciliation.FilterCluster(cluster)
+	}
+	r.JSON(http.StatusOK, reconciliation)
+}
+
+// ReplicationSecurityReport inventories the replication users and TLS status of replication channels, aggregated
+// per cluster, optionally of a given cluster only
+func (this *HttpAPI) ReplicationSecurityReport(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		if clusterName, err = figureClusterName(getClusterHint(params)); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	report, err := inst.ReportReplicationSecurity(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, report)
+}
+
+// Namespaces lists the environment namespaces of known instances
+func (this *HttpAPI) Namespaces(params martini.Params, r render.Render, req *http.Request) {
+	namespaces, err := inst.ReadNamespaces()
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, namespaces)
 }
 
 // Tags lists existing tags for a given instance
@@ -1886,60 +2817,688 @@ func (this *HttpAPI) Tag(params martini.Params, r render.Render, req *http.Reque
 		return
 	}
 
-	tag, err := getTag(params, req)
-	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+	tag, err := getTag(params, req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("put-instance-tag", inst.InstanceTag{Key: instanceKey, T: *tag})
+	} else {
+		err = inst.PutInstanceTag(&instanceKey, tag)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%+v tagged with %s", instanceKey, tag.String()), Details: instanceKey})
+}
+
+// Untag removes a tag from an instance
+func (this *HttpAPI) Untag(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	tag, err := getTag(params, req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	untagged, err := inst.Untag(&instanceKey, tag)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%s removed from %+v instances", tag.TagName, len(*untagged)), Details: untagged.GetInstanceKeys()})
+}
+
+// UntagAll removes a tag from all matching instances
+func (this *HttpAPI) UntagAll(params martini.Params, r render.Render, req *http.Request) {
+	tag, err := getTag(params, req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	untagged, err := inst.Untag(nil, tag)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%s removed from %+v instances", tag.TagName, len(*untagged)), Details: untagged.GetInstanceKeys()})
+}
+
+// getPollOverride builds a poll override from either host/port params or a `tag` query param, and
+// `poll-seconds` and `probe-set` query params
+func (this *HttpAPI) getPollOverride(params martini.Params, req *http.Request) (*inst.PollOverride, error) {
+	var pollSeconds uint
+	if pollSecondsParam := req.URL.Query().Get("poll-seconds"); pollSecondsParam != "" {
+		value, err := strconv.ParseUint(pollSecondsParam, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid poll-seconds: %s", pollSecondsParam)
+		}
+		pollSeconds = uint(value)
+	}
+	probeSet, err := inst.ParseProbeSet(req.URL.Query().Get("probe-set"))
+	if err != nil {
+		return nil, err
+	}
+	if params["host"] == "" {
+		tag, err := inst.ParseTag(req.URL.Query().Get("tag"))
+		if err != nil {
+			return nil, err
+		}
+		return inst.NewTagPollOverride(tag, pollSeconds, probeSet), nil
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		return nil, err
+	}
+	return inst.NewInstancePollOverride(&instanceKey, pollSeconds, probeSet), nil
+}
+
+// SetPollOverride overrides the polling interval and probe set of an instance, or of instances having a tag
+func (this *HttpAPI) SetPollOverride(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	override, err := this.getPollOverride(params, req)
+	if err == nil {
+		err = override.Validate()
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-poll-override", override)
+	} else {
+		err = inst.WritePollOverride(override)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Poll override set: %s", override.String()), Details: override})
+}
+
+// RemovePollOverride removes the poll override of an instance, or of a tag
+func (this *HttpAPI) RemovePollOverride(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	override, err := this.getPollOverride(params, req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-poll-override", override)
+	} else {
+		err = inst.DeletePollOverride(override)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: "Poll override removed", Details: override})
+}
+
+// PollOverrides lists all poll overrides, or the effective poll override of a given instance
+func (this *HttpAPI) PollOverrides(params martini.Params, r render.Render, req *http.Request) {
+	if params["host"] != "" {
+		instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+		r.JSON(http.StatusOK, inst.ReadEffectivePollOverride(&instanceKey))
+		return
+	}
+	overrides, err := inst.ReadPollOverrides()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, overrides)
+}
+
+// SetReplicaConcurrency overrides, at runtime, the number of replicas of a cluster concurrently moved by mass replica operations
+func (this *HttpAPI) SetReplicaConcurrency(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	concurrency, err := strconv.ParseUint(params["concurrency"], 10, 32)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid concurrency: %s", params["concurrency"])})
+		return
+	}
+	override := inst.NewReplicaConcurrencyOverride(clusterName, uint(concurrency))
+	if err := override.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-replica-concurrency-override", override)
+	} else {
+		err = inst.WriteReplicaConcurrencyOverride(override)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Replica concurrency set: %s", override.String()), Details: override})
+}
+
+// ResetReplicaConcurrency removes the runtime replica concurrency override of a cluster
+func (this *HttpAPI) ResetReplicaConcurrency(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-replica-concurrency-override", clusterName)
+	} else {
+		err = inst.DeleteReplicaConcurrencyOverride(clusterName)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Replica concurrency reset: %s", clusterName), Details: inst.MaxConcurrentReplicaOperations(clusterName)})
+}
+
+// ReplicaConcurrency returns the effective replica concurrency of a cluster, or lists all runtime overrides
+func (this *HttpAPI) ReplicaConcurrency(params martini.Params, r render.Render, req *http.Request) {
+	if getClusterHint(params) != "" {
+		clusterName, err := figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+		r.JSON(http.StatusOK, inst.NewReplicaConcurrencyOverride(clusterName, inst.MaxConcurrentReplicaOperations(clusterName)))
+		return
+	}
+	overrides, err := inst.ReadReplicaConcurrencyOverrides()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, overrides)
+}
+
+// RelaxLagPostponement has recoveries of an instance's cluster relocate the instance right away, regardless of
+// PostponeReplicaRecoveryOnLagMinutes
+func (this *HttpAPI) RelaxLagPostponement(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	instance, found, err := inst.ReadInstance(&instanceKey)
+	if (!found) || (err != nil) {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot read instance: %+v", instanceKey)})
+		return
+	}
+	var durationSeconds int = 0
+	if params["duration"] != "" {
+		durationSeconds, err = util.SimpleTimeToSeconds(params["duration"])
+		if durationSeconds < 0 {
+			err = fmt.Errorf("Duration value must be non-negative. Given value: %d", durationSeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	relaxation := inst.NewPostponementRelaxation(&instanceKey, instance.ClusterName, userId, params["reason"], time.Duration(durationSeconds)*time.Second)
+	if err := relaxation.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-postponement-relaxation", relaxation)
+	} else {
+		err = inst.WritePostponementRelaxation(relaxation)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Lag postponement relaxed: %+v", instanceKey), Details: relaxation})
+}
+
+// EndLagPostponementRelaxation has recoveries postpone relocating an instance per PostponeReplicaRecoveryOnLagMinutes again
+func (this *HttpAPI) EndLagPostponementRelaxation(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-postponement-relaxation", instanceKey)
+	} else {
+		err = inst.DeletePostponementRelaxation(&instanceKey)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Lag postponement relaxation ended: %+v", instanceKey), Details: instanceKey})
+}
+
+// LagPostponementRelaxations lists the unexpired lag postponement relaxations of a cluster, or of all clusters
+func (this *HttpAPI) LagPostponementRelaxations(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	relaxations, err := inst.ReadPostponementRelaxations(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, relaxations)
+}
+
+// BeginClusterFocus puts a cluster in focus mode for a duration (default FocusModeDefaultMinutes): its instances are
+// polled every FocusModePollSeconds, sampled, and its topology changes published in finer detail
+func (this *HttpAPI) BeginClusterFocus(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	var durationSeconds int = 0
+	if params["duration"] != "" {
+		durationSeconds, err = util.SimpleTimeToSeconds(params["duration"])
+		if durationSeconds < 0 {
+			err = fmt.Errorf("Duration value must be non-negative. Given value: %d", durationSeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	focus := inst.NewClusterFocus(clusterName, userId, params["reason"], time.Duration(durationSeconds)*time.Second)
+	if err := focus.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-cluster-focus", focus)
+	} else {
+		err = inst.WriteClusterFocus(focus)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: clusterName})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster in focus: %s, for %+v", clusterName, focus.Duration), Details: focus})
+}
+
+// EndClusterFocus has a cluster's instances polled by their normal interval and probe set again
+func (this *HttpAPI) EndClusterFocus(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-cluster-focus", clusterName)
+	} else {
+		err = inst.DeleteClusterFocus(clusterName)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: clusterName})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster focus ended: %s", clusterName), Details: clusterName})
+}
+
+// ClusterFocuses lists the clusters in focus mode, or the focus of a given cluster
+func (this *HttpAPI) ClusterFocuses(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	focuses, err := inst.ReadClusterFocuses(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, focuses)
+}
+
+// BeginWriteFreeze freezes writes on a cluster for a duration (default WriteFreezeDefaultMinutes): its writeable
+// instances are set read_only, and automated failovers are refused
+func (this *HttpAPI) BeginWriteFreeze(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	var durationSeconds int = 0
+	if params["duration"] != "" {
+		durationSeconds, err = util.SimpleTimeToSeconds(params["duration"])
+		if durationSeconds < 0 {
+			err = fmt.Errorf("Duration value must be non-negative. Given value: %d", durationSeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	freeze, err := logic.FreezeClusterWrites(inst.NewClusterWriteFreeze(clusterName, userId, params["reason"], time.Duration(durationSeconds)*time.Second))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: clusterName})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster writes frozen: %s, for %+v", clusterName, freeze.Duration), Details: freeze})
+}
+
+// EndWriteFreeze ends the write freeze of a cluster, allowing automated failovers again. Frozen instances remain
+// read_only.
+func (this *HttpAPI) EndWriteFreeze(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if err := logic.UnfreezeClusterWrites(clusterName); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: clusterName})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster write freeze ended: %s", clusterName), Details: clusterName})
+}
+
+// WriteFreezes lists the clusters whose writes are frozen, or the write freeze of a given cluster
+func (this *HttpAPI) WriteFreezes(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	freezes, err := inst.ReadClusterWriteFreezes(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, freezes)
+}
+
+// RecoveryRateLimits lists the automated recovery counters of clusters, or of a given cluster, along with their
+// limits and backoff
+func (this *HttpAPI) RecoveryRateLimits(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	rateLimits, err := inst.ReadRecoveryRateLimits(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, rateLimits)
+}
+
+// ResetRecoveryRateLimit resets the automated recovery counters, and any backoff, of a cluster, or of all clusters
+// when no cluster is given
+func (this *HttpAPI) ResetRecoveryRateLimit(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	if err := logic.ResetRecoveryRateLimit(clusterName); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: clusterName})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Recovery rate limit reset: %s", clusterName), Details: clusterName})
+}
+
+// setFeatureFlag enables or disables a feature on a cluster, or on all clusters when no cluster is given
+func (this *HttpAPI) setFeatureFlag(params martini.Params, r render.Render, req *http.Request, user auth.User, enabled bool) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	flag := inst.NewFeatureFlag(params["feature"], clusterName, enabled, userId, params["reason"])
+	if err := flag.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-feature-flag", flag)
+	} else {
+		err = inst.WriteFeatureFlag(flag)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: flag})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Feature flag set: %s", flag.String()), Details: flag})
+}
+
+// EnableFeature enables a gated feature on a cluster, or on all clusters when no cluster is given
+func (this *HttpAPI) EnableFeature(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.setFeatureFlag(params, r, req, user, true)
+}
+
+// DisableFeature disables a gated feature on a cluster, or on all clusters when no cluster is given
+func (this *HttpAPI) DisableFeature(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.setFeatureFlag(params, r, req, user, false)
+}
+
+// ResetFeature removes the flag of a feature on a cluster, which then follows the all-clusters flag; or removes the
+// all-clusters flag when no cluster is given
+func (this *HttpAPI) ResetFeature(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	flag := inst.NewFeatureFlag(params["feature"], clusterName, false, "", "")
+	var err error
 	if orcraft.IsRaftEnabled() {
-		_, err = orcraft.PublishCommand("put-instance-tag", inst.InstanceTag{Key: instanceKey, T: *tag})
+		_, err = orcraft.PublishCommand("delete-feature-flag", flag)
 	} else {
-		err = inst.PutInstanceTag(&instanceKey, tag)
+		err = inst.DeleteFeatureFlag(flag.FeatureName, flag.ClusterName)
 	}
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: flag})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%+v tagged with %s", instanceKey, tag.String()), Details: instanceKey})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Feature flag reset: %s on %s", flag.FeatureName, flag.Scope()), Details: flag})
 }
 
-// Untag removes a tag from an instance
-func (this *HttpAPI) Untag(params martini.Params, r render.Render, req *http.Request) {
-	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+// FeatureFlags lists feature flags, optionally only those of a given feature
+func (this *HttpAPI) FeatureFlags(params martini.Params, r render.Render, req *http.Request) {
+	flags, err := inst.ReadFeatureFlags(params["feature"])
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
 
-	tag, err := getTag(params, req)
+	r.JSON(http.StatusOK, flags)
+}
+
+// ClusterFocusSamples lists the samples recorded of a cluster's instances while in focus mode, within the last
+// `seconds` query param seconds (default: FocusModeDefaultMinutes)
+func (this *HttpAPI) ClusterFocusSamples(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-	untagged, err := inst.Untag(&instanceKey, tag)
+	seconds := 60 * config.Config.FocusModeDefaultMinutes
+	if secondsParam := req.URL.Query().Get("seconds"); secondsParam != "" {
+		value, err := strconv.ParseUint(secondsParam, 10, 32)
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid seconds: %s", secondsParam)})
+			return
+		}
+		seconds = uint(value)
+	}
+	samples, err := inst.ReadClusterFocusSamples(clusterName, seconds)
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%s removed from %+v instances", tag.TagName, len(*untagged)), Details: untagged.GetInstanceKeys()})
+	r.JSON(http.StatusOK, samples)
 }
 
-// UntagAll removes a tag from all matching instances
-func (this *HttpAPI) UntagAll(params martini.Params, r render.Render, req *http.Request) {
-	tag, err := getTag(params, req)
-	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
-		return
+// ClusterOperations lists the running and queued serialized operations of a cluster, or of all clusters
+func (this *HttpAPI) ClusterOperations(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
 	}
-	untagged, err := inst.Untag(nil, tag)
-	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
-		return
+
+	r.JSON(http.StatusOK, inst.ReadClusterOperations(clusterName))
+}
+
+// InFlightOperations lists the multi-step operations running right now, of a cluster or of all clusters
+func (this *HttpAPI) InFlightOperations(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if getClusterHint(params) != "" {
+		var err error
+		clusterName, err = figureClusterName(getClusterHint(params))
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%s removed from %+v instances", tag.TagName, len(*untagged)), Details: untagged.GetInstanceKeys()})
+	r.JSON(http.StatusOK, inst.ReadInFlightOperations(clusterName))
 }
 
 // Write a cluster's master (or all clusters masters) to kv stores.
@@ -2071,6 +3630,88 @@ func (this *HttpAPI) Audit(params martini.Params, r render.Render, req *http.Req
 	r.JSON(http.StatusOK, audits)
 }
 
+// OperationIntents returns recently submitted relocation/regroup intents and their state
+func (this *HttpAPI) OperationIntents(params martini.Params, r render.Render, req *http.Request) {
+	intents, err := logic.ReadRecentOperationIntents()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, intents)
+}
+
+// DRPairs returns all known DR pairs
+func (this *HttpAPI) DRPairs(params martini.Params, r render.Render, req *http.Request) {
+	pairs, err := inst.ReadDRPairs()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, pairs)
+}
+
+// InstanceReverifications returns the instances handled by the background re-verifier
+func (this *HttpAPI) InstanceReverifications(params martini.Params, r render.Render, req *http.Request) {
+	reverifications, err := inst.ReadInstanceReverifications()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, reverifications)
+}
+
+// RegisterDRPair registers given instance as the main of a warm standby cluster
+func (this *HttpAPI) RegisterDRPair(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	pair, err := logic.RegisterDRPair(params["standbyAlias"], &instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("DR pair registered: %s", pair.StandbyClusterAlias), Details: pair})
+}
+
+// SplitDRPair detaches a warm standby cluster from its primary and makes its main writable
+func (this *HttpAPI) SplitDRPair(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instance, err := logic.SplitDRPair(params["standbyAlias"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("DR pair split: %+v", instance.Key), Details: instance})
+}
+
+// RemoveDRPair forgets a DR pair, without changing replication
+func (this *HttpAPI) RemoveDRPair(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	if err := logic.RemoveDRPair(params["standbyAlias"]); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("DR pair removed: %s", params["standbyAlias"])})
+}
+
 // HostnameResolveCache shows content of in-memory hostname cache
 func (this *HttpAPI) HostnameResolveCache(params martini.Params, r render.Render, req *http.Request) {
 	content, err := inst.HostnameResolveCache()
@@ -2741,6 +4382,17 @@ func (this *HttpAPI) Health(params martini.Params, r render.Render, req *http.Re
 
 }
 
+// HealthReport returns the structured, versioned health of this node's components. It responds with
+// 503 when some component is critical, and is thus suitable for load balancer health decisions.
+func (this *HttpAPI) HealthReport(params martini.Params, r render.Render, req *http.Request) {
+	report := logic.ReadHealthReport()
+	if !report.Healthy {
+		r.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	r.JSON(http.StatusOK, report)
+}
+
 // LBCheck returns a constant respnse, and this can be used by load balancers that expect a given string.
 func (this *HttpAPI) LBCheck(params martini.Params, r render.Render, req *http.Request) {
 	r.JSON(http.StatusOK, "OK")
@@ -2890,7 +4542,7 @@ func (this *HttpAPI) RaftFollowerHealthReport(params martini.Params, r render.Re
 		Respond(r, &APIResponse{Code: ERROR, Message: "raft-state: not running with raft setup"})
 		return
 	}
-	err := orcraft.OnHealthReport(params["authenticationToken"], params["raftBind"], params["raftAdvertise"])
+	err := orcraft.OnHealthReport(params["authenticationToken"], params["raftBind"], params["raftAdvertise"], req.URL.Query().Get("version"), req.URL.Query().Get("uri"))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot create snapshot: %+v", err)})
 		return
@@ -2898,6 +4550,45 @@ func (this *HttpAPI) RaftFollowerHealthReport(params martini.Params, r render.Re
 	r.JSON(http.StatusOK, "health reported")
 }
 
+// InstanceReachability checks whether this node can reach an instance, regardless of the instance's state as last
+// polled. The raft leader asks other nodes so as to confirm master death. Only known instances are checked.
+func (this *HttpAPI) InstanceReachability(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if _, found, err := inst.ReadInstance(&instanceKey); err != nil || !found {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot read instance: %+v", instanceKey)})
+		return
+	}
+	r.JSON(http.StatusOK, inst.CheckInstanceReachability(&instanceKey))
+}
+
+// RollingUpgradePlan lists the orchestrator nodes in the order by which they should be restarted in a rolling upgrade
+func (this *HttpAPI) RollingUpgradePlan(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	steps, err := logic.ReadRollingUpgradePlan(req.URL.Query().Get("version"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, steps)
+}
+
+// RestartForUpgrade makes this node give up leadership, if held, and run the configured RestartForUpgradeCommand
+func (this *HttpAPI) RestartForUpgrade(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	targetVersion := req.URL.Query().Get("version")
+	if err := logic.RestartForUpgrade(targetVersion); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Restarting %s for upgrade", process.ThisHostname), Details: targetVersion})
+}
+
 // RaftSnapshot instructs raft to take a snapshot
 func (this *HttpAPI) RaftSnapshot(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !orcraft.IsRaftEnabled() {
@@ -3019,8 +4710,9 @@ func (this *HttpAPI) Recover(params martini.Params, r render.Render, req *http.R
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Recovery executed on %+v", instanceKey), Details: *promotedInstanceKey})
 }
 
-// GracefulMasterTakeover gracefully fails over a master onto its single replica.
-func (this *HttpAPI) GracefulMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// gracefulMasterTakeover gracefully fails over a master onto its single replica, or onto a designated replica.
+// With auto, the demoted master is further set to replicate from the promoted master.
+func (this *HttpAPI) gracefulMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User, auto bool) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
@@ -3032,7 +4724,8 @@ func (this *HttpAPI) GracefulMasterTakeover(params martini.Params, r render.Rend
 	}
 	designatedKey, _ := this.getInstanceKey(params["designatedHost"], params["designatedPort"])
 	// designatedKey may be empty/invalid
-	topologyRecovery, _, err := logic.GracefulMasterTakeover(clusterName, &designatedKey)
+	topologyRecovery, _, err := logic.GracefulMasterTakeoverContext(req.Context(), clusterName, &designatedKey, auto)
+	traceRecovery(req, topologyRecovery)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: topologyRecovery})
 		return
@@ -3045,6 +4738,16 @@ func (this *HttpAPI) GracefulMasterTakeover(params martini.Params, r render.Rend
 	Respond(r, &APIResponse{Code: OK, Message: "graceful-master-takeover: successor promoted", Details: topologyRecovery})
 }
 
+// GracefulMasterTakeover gracefully fails over a master, leaving the demoted master with replication stopped.
+func (this *HttpAPI) GracefulMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.gracefulMasterTakeover(params, r, req, user, false)
+}
+
+// GracefulMasterTakeoverAuto gracefully fails over a master, and demotes it as a replica of the promoted master.
+func (this *HttpAPI) GracefulMasterTakeoverAuto(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.gracefulMasterTakeover(params, r, req, user, true)
+}
+
 // ForceMasterFailover fails over a master (even if there's no particular problem with the master)
 func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3056,7 +4759,8 @@ func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render,
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	topologyRecovery, err := logic.ForceMasterFailover(clusterName)
+	topologyRecovery, err := logic.ForceMasterFailoverContext(req.Context(), clusterName)
+	traceRecovery(req, topologyRecovery)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -3068,6 +4772,64 @@ func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render,
 	}
 }
 
+// WhatIfMasterFails shows the replica which would be promoted were a cluster's master to fail now, changing nothing
+func (this *HttpAPI) WhatIfMasterFails(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	preview, err := inst.PreviewClusterCandidateReplica(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Candidate replica: %+v", preview.CandidateKey), Details: preview})
+}
+
+// PrepareMasterPromotion runs candidate selection for a cluster's master, changing nothing, and returns a promotion
+// plan whose token is to be confirmed via ConfirmMasterPromotion
+func (this *HttpAPI) PrepareMasterPromotion(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	plan, err := logic.PrepareMasterPromotion(clusterName, userId)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Promotion prepared: %s. Confirm with token %s", plan.String(), plan.Token), Details: plan})
+}
+
+// ConfirmMasterPromotion executes a promotion plan prepared via PrepareMasterPromotion
+func (this *HttpAPI) ConfirmMasterPromotion(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	_, topologyRecovery, err := logic.ConfirmMasterPromotionContext(req.Context(), params["token"])
+	traceRecovery(req, topologyRecovery)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: topologyRecovery})
+		return
+	}
+	if topologyRecovery.SuccessorKey != nil {
+		Respond(r, &APIResponse{Code: OK, Message: "Master promoted", Details: topologyRecovery})
+	} else {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Master not promoted", Details: topologyRecovery})
+	}
+}
+
 // ForceMasterTakeover fails over a master (even if there's no particular problem with the master)
 func (this *HttpAPI) ForceMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3090,7 +4852,8 @@ func (this *HttpAPI) ForceMasterTakeover(params martini.Params, r render.Render,
 		return
 	}
 
-	topologyRecovery, err := logic.ForceMasterTakeover(clusterName, designatedInstance)
+	topologyRecovery, err := logic.ForceMasterTakeoverContext(req.Context(), clusterName, designatedInstance)
+	traceRecovery(req, topologyRecovery)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -3102,6 +4865,38 @@ func (this *HttpAPI) ForceMasterTakeover(params martini.Params, r render.Render,
 	}
 }
 
+// ForceMasterFailoverTo fails over a master to a designated replica, having verified by GTID sets that the replica
+// is not missing transactions its siblings executed. With `ignore-unreachable-replicas=true`, unreachable siblings
+// are not verified, rather than fail verification.
+func (this *HttpAPI) ForceMasterFailoverTo(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	designatedKey, err := this.getInstanceKey(params["designatedHost"], params["designatedPort"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	topologyRecovery, missingTransactions, err := logic.ForceMasterFailoverToContext(req.Context(), clusterName, &designatedKey, req.URL.Query().Get("ignore-unreachable-replicas") == "true")
+	traceRecovery(req, topologyRecovery)
+	if err != nil {
+		if len(missingTransactions) > 0 {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: missingTransactions})
+		} else {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: topologyRecovery})
+		}
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: "Master failed over", Details: topologyRecovery})
+}
+
 // Registers promotion preference for given instance
 func (this *HttpAPI) RegisterCandidate(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3113,13 +4908,13 @@ func (this *HttpAPI) RegisterCandidate(params martini.Params, r render.Render, r
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	promotionRule, err := inst.ParseCandidatePromotionRule(params["promotionRule"])
+	promotionRule, promotionPriority, err := inst.ParseCandidatePromotionRuleAndPriority(params["promotionRule"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	candidate := inst.NewCandidateDatabaseInstance(&instanceKey, promotionRule).WithCurrentTime()
+	candidate := inst.NewCandidateDatabaseInstance(&instanceKey, promotionRule).WithPromotionPriority(promotionPriority).WithCurrentTime()
 
 	if orcraft.IsRaftEnabled() {
 		_, err = orcraft.PublishCommand("register-candidate", candidate)
@@ -3399,6 +5194,60 @@ func (this *HttpAPI) AcknowledgeRecovery(params martini.Params, r render.Render,
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Acknowledged recovery"), Details: idParam})
 }
 
+// UndoRecovery reverts a master recovery, given its failed master is back: the promoted replica is demoted and the
+// pre-recovery topology is restored
+func (this *HttpAPI) UndoRecovery(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	failedMaster, err := logic.UndoRecovery(params["uid"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err), Details: failedMaster})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Reverted recovery %s; master is %+v", params["uid"], failedMaster.Key), Details: failedMaster})
+}
+
+// HookDeliveries lists failed hook deliveries queued for retry, including dead ones
+func (this *HttpAPI) HookDeliveries(params martini.Params, r render.Render, req *http.Request) {
+	deliveries, err := logic.ReadHookDeliveries(false)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, deliveries)
+}
+
+// DeadHookDeliveries lists hook deliveries which failed all their attempts
+func (this *HttpAPI) DeadHookDeliveries(params martini.Params, r render.Render, req *http.Request) {
+	deliveries, err := logic.ReadHookDeliveries(true)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, deliveries)
+}
+
+// RetryHookDelivery makes a queued hook delivery, dead or pending, due for retry at once
+func (this *HttpAPI) RetryHookDelivery(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	deliveryId, err := strconv.ParseInt(params["deliveryId"], 10, 0)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if err := logic.RetryHookDelivery(deliveryId); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Hook delivery %d is due for retry", deliveryId), Details: deliveryId})
+}
+
 // ClusterInfo provides details of a given cluster
 func (this *HttpAPI) AcknowledgeAllRecoveries(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3509,22 +5358,32 @@ func (this *HttpAPI) getSynonymPath(path string) (synonymPath string) {
 	return synonymPath
 }
 
-func (this *HttpAPI) registerSingleAPIRequest(m *martini.ClassicMartini, path string, handler martini.Handler, allowProxy bool) {
+func (this *HttpAPI) registerSingleAPIRequest(m *martini.ClassicMartini, path string, handler martini.Handler, allowProxy bool, admitted bool, serialized bool) {
 	registeredPaths = append(registeredPaths, path)
 	fullPath := fmt.Sprintf("%s/api/%s", this.URLPrefix, path)
 
+	handlers := []martini.Handler{}
 	if allowProxy && config.Config.RaftEnabled {
-		m.Get(fullPath, raftReverseProxy, handler)
-	} else {
-		m.Get(fullPath, handler)
+		handlers = append(handlers, raftReverseProxy)
+	}
+	handlers = append(handlers, scopeNamespace, auditAPIOperation)
+	if admitted {
+		handlers = append(handlers, admitAPIOperation)
 	}
+	if serialized {
+		handlers = append(handlers, this.serializeClusterOperation)
+	}
+	handlers = append(handlers, handler)
+	m.Get(fullPath, handlers...)
 }
 
 func (this *HttpAPI) registerAPIRequestInternal(m *martini.ClassicMartini, path string, handler martini.Handler, allowProxy bool) {
-	this.registerSingleAPIRequest(m, path, handler, allowProxy)
+	admitted := operationAPIRequests[strings.Split(path, "/")[0]]
+	serialized := serializedAPIRequests[strings.Split(path, "/")[0]]
+	this.registerSingleAPIRequest(m, path, handler, allowProxy, admitted, serialized)
 
 	if synonym := this.getSynonymPath(path); synonym != "" {
-		this.registerSingleAPIRequest(m, synonym, handler, allowProxy)
+		this.registerSingleAPIRequest(m, synonym, handler, allowProxy, admitted, serialized)
 	}
 }
 
@@ -3543,6 +5402,7 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "relocate-below/:host/:port/:belowHost/:belowPort", this.RelocateBelow)
 	this.registerAPIRequest(m, "relocate-slaves/:host/:port/:belowHost/:belowPort", this.RelocateReplicas)
 	this.registerAPIRequest(m, "regroup-slaves/:host/:port", this.RegroupReplicas)
+	m.Post(this.URLPrefix+"/api/topology-apply", raftReverseProxy, auditAPIOperation, admitAPIOperation, this.TopologyApply)
 
 	// Classic file:pos relocation:
 	this.registerAPIRequest(m, "move-up/:host/:port", this.MoveUp)
@@ -3550,6 +5410,8 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "move-below/:host/:port/:siblingHost/:siblingPort", this.MoveBelow)
 	this.registerAPIRequest(m, "move-equivalent/:host/:port/:belowHost/:belowPort", this.MoveEquivalent)
 	this.registerAPIRequest(m, "repoint/:host/:port/:belowHost/:belowPort", this.Repoint)
+	this.registerAPIRequest(m, "undo-last-relocation/:host/:port", this.UndoLastRelocation)
+	this.registerAPIRequest(m, "last-relocation/:host/:port", this.LastRelocation)
 	this.registerAPIRequest(m, "repoint-slaves/:host/:port", this.RepointReplicas)
 	this.registerAPIRequest(m, "make-co-master/:host/:port", this.MakeCoMaster)
 	this.registerAPIRequest(m, "enslave-siblings/:host/:port", this.TakeSiblings)
@@ -3558,6 +5420,8 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 
 	// Binlog server relocation:
 	this.registerAPIRequest(m, "regroup-slaves-bls/:host/:port", this.RegroupReplicasBinlogServers)
+	this.registerAPIRequest(m, "retire-binlog-server-family/:host/:port", this.RetireBinlogServerFamily)
+	this.registerAPIRequest(m, "retire-binlog-server-family/:host/:port/:belowHost/:belowPort", this.RetireBinlogServerFamily)
 
 	// GTID relocation:
 	this.registerAPIRequest(m, "move-below-gtid/:host/:port/:belowHost/:belowPort", this.MoveBelowGTID)
@@ -3581,6 +5445,22 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "locate-gtid-errant/:host/:port", this.LocateErrantGTID)
 	this.registerAPIRequest(m, "gtid-errant-reset-master/:host/:port", this.ErrantGTIDResetMaster)
 	this.registerAPIRequest(m, "gtid-errant-inject-empty/:host/:port", this.ErrantGTIDInjectEmpty)
+	this.registerAPIRequest(m, "gtid-rollback-plan/:host/:port", this.GTIDRollbackPlan)
+	this.registerAPIRequest(m, "gtid-rollback/:host/:port/:step", this.GTIDRollbackStep)
+	this.registerAPIRequest(m, "enable-cluster-replication-ssl/:clusterHint", this.EnableClusterReplicationSSL)
+	this.registerAPIRequest(m, "cluster-replication-ssl/:clusterHint", this.ClusterReplicationSSL)
+	this.registerAPIRequest(m, "set-replica-concurrency/:clusterHint/:concurrency", this.SetReplicaConcurrency)
+	this.registerAPIRequest(m, "reset-replica-concurrency/:clusterHint", this.ResetReplicaConcurrency)
+	this.registerAPIRequest(m, "replica-concurrency", this.ReplicaConcurrency)
+	this.registerAPIRequest(m, "replica-concurrency/:clusterHint", this.ReplicaConcurrency)
+	this.registerAPIRequest(m, "cluster-operations", this.ClusterOperations)
+	this.registerAPIRequest(m, "cluster-operations/:clusterHint", this.ClusterOperations)
+	this.registerAPIRequest(m, "operations", this.InFlightOperations)
+	this.registerAPIRequest(m, "operations/:clusterHint", this.InFlightOperations)
+	m.Post(this.URLPrefix+"/api/start-campaign", raftReverseProxy, auditAPIOperation, admitAPIOperation, this.StartCampaign)
+	this.registerAPIRequest(m, "halt-campaign/:uid", this.HaltCampaign)
+	this.registerAPIRequest(m, "campaigns", this.Campaigns)
+	this.registerAPIRequest(m, "campaign/:uid", this.Campaign)
 	this.registerAPIRequest(m, "skip-query/:host/:port", this.SkipQuery)
 	this.registerAPIRequest(m, "start-slave/:host/:port", this.StartSlave)
 	this.registerAPIRequest(m, "restart-slave/:host/:port", this.RestartSlave)
@@ -3593,15 +5473,24 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "reattach-slave-master-host/:host/:port", this.ReattachReplicaMasterHost)
 	this.registerAPIRequest(m, "flush-binary-logs/:host/:port", this.FlushBinaryLogs)
 	this.registerAPIRequest(m, "purge-binary-logs/:host/:port/:logFile", this.PurgeBinaryLogs)
+	this.registerAPIRequest(m, "binlog-purge-advice/:host/:port", this.BinlogPurgeAdvice)
+	this.registerAPIRequest(m, "gtid-purge-forecast", this.GTIDPurgeForecast)
+	this.registerAPIRequest(m, "gtid-purge-forecast/:clusterHint", this.GTIDPurgeForecast)
 	this.registerAPIRequest(m, "restart-slave-statements/:host/:port", this.RestartSlaveStatements)
 	this.registerAPIRequest(m, "enable-semi-sync-master/:host/:port", this.EnableSemiSyncMaster)
 	this.registerAPIRequest(m, "disable-semi-sync-master/:host/:port", this.DisableSemiSyncMaster)
 	this.registerAPIRequest(m, "enable-semi-sync-replica/:host/:port", this.EnableSemiSyncReplica)
 	this.registerAPIRequest(m, "disable-semi-sync-replica/:host/:port", this.DisableSemiSyncReplica)
+	this.registerAPIRequest(m, "set-semi-sync-wait-count/:host/:port/:count", this.SetSemiSyncWaitCount)
+	this.registerAPIRequest(m, "enforce-semi-sync/:host/:port", this.EnforceSemiSync)
+	this.registerAPIRequest(m, "enforce-semi-sync/:host/:port/:count", this.EnforceSemiSync)
+	this.registerAPIRequest(m, "set-master-delay/:host/:port/:seconds", this.SetMasterDelay)
+	this.registerAPIRequest(m, "clear-master-delay/:host/:port", this.ClearMasterDelay)
 
 	// Replication information:
 	this.registerAPIRequest(m, "can-replicate-from/:host/:port/:belowHost/:belowPort", this.CanReplicateFrom)
 	this.registerAPIRequest(m, "can-replicate-from-gtid/:host/:port/:belowHost/:belowPort", this.CanReplicateFromGTID)
+	this.registerAPIRequest(m, "replication-compatibility/:host/:port/:belowHost/:belowPort", this.ReplicationCompatibility)
 
 	// Instance:
 	this.registerAPIRequest(m, "set-read-only/:host/:port", this.SetReadOnly)
@@ -3628,16 +5517,25 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "cluster/:clusterHint", this.Cluster)
 	this.registerAPIRequest(m, "cluster/alias/:clusterAlias", this.ClusterByAlias)
 	this.registerAPIRequest(m, "cluster/instance/:host/:port", this.ClusterByInstance)
+	this.registerAPIRequest(m, "cluster-tree/:clusterHint", this.ClusterTree)
+	this.registerAPIRequest(m, "cluster-tree/:host/:port", this.ClusterTree)
 	this.registerAPIRequest(m, "cluster-info/:clusterHint", this.ClusterInfo)
 	this.registerAPIRequest(m, "cluster-info/alias/:clusterAlias", this.ClusterInfoByAlias)
+	this.registerAPIRequest(m, "export-cluster/:clusterHint", this.ExportCluster)
+	m.Post(this.URLPrefix+"/api/import-cluster", raftReverseProxy, auditAPIOperation, admitAPIOperation, this.ImportCluster)
 	this.registerAPIRequest(m, "cluster-osc-slaves/:clusterHint", this.ClusterOSCReplicas)
 	this.registerAPIRequest(m, "set-cluster-alias/:clusterName", this.SetClusterAliasManualOverride)
 	this.registerAPIRequest(m, "clusters", this.Clusters)
 	this.registerAPIRequest(m, "clusters-info", this.ClustersInfo)
+	this.registerAPIRequest(m, "namespaces", this.Namespaces)
+	this.registerAPIRequestNoProxy(m, "inventory-reconciliation", this.InventoryReconciliation)
+	this.registerAPIRequest(m, "replication-security-report", this.ReplicationSecurityReport)
+	this.registerAPIRequest(m, "replication-security-report/:clusterHint", this.ReplicationSecurityReport)
 
 	this.registerAPIRequest(m, "masters", this.Masters)
 	this.registerAPIRequest(m, "master/:clusterHint", this.ClusterMaster)
 	this.registerAPIRequest(m, "instance-replicas/:host/:port", this.InstanceReplicas)
+	this.registerAPIRequest(m, "master-history/:host/:port", this.MasterHistory)
 	this.registerAPIRequest(m, "all-instances", this.AllInstances)
 	this.registerAPIRequest(m, "downtimed", this.Downtimed)
 	this.registerAPIRequest(m, "downtimed/:clusterHint", this.Downtimed)
@@ -3645,6 +5543,13 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "topology/:host/:port", this.AsciiTopology)
 	this.registerAPIRequest(m, "topology-tabulated/:clusterHint", this.AsciiTopologyTabulated)
 	this.registerAPIRequest(m, "topology-tabulated/:host/:port", this.AsciiTopologyTabulated)
+	this.registerAPIRequest(m, "topology-json/:clusterHint", this.TopologyJSON)
+	this.registerAPIRequest(m, "topology-json/:host/:port", this.TopologyJSON)
+	this.registerAPIRequest(m, "topology-mermaid/:clusterHint", this.TopologyMermaid)
+	this.registerAPIRequest(m, "topology-mermaid/:host/:port", this.TopologyMermaid)
+	this.registerAPIRequest(m, "topology-diff/:clusterHint", this.TopologyDiff)
+	this.registerAPIRequest(m, "topology-diff/:host/:port", this.TopologyDiff)
+	this.registerAPIRequest(m, "export-cluster-snapshot/:clusterHint", this.ExportClusterSnapshot)
 	this.registerAPIRequest(m, "snapshot-topologies", this.SnapshotTopologies)
 
 	// Key-value:
@@ -3663,13 +5568,26 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "untag-all", this.UntagAll)
 	this.registerAPIRequest(m, "untag-all/:tagName/:tagValue", this.UntagAll)
 
+	// Poll overrides:
+	this.registerAPIRequest(m, "set-poll-override", this.SetPollOverride)
+	this.registerAPIRequest(m, "set-poll-override/:host/:port", this.SetPollOverride)
+	this.registerAPIRequest(m, "remove-poll-override", this.RemovePollOverride)
+	this.registerAPIRequest(m, "remove-poll-override/:host/:port", this.RemovePollOverride)
+	this.registerAPIRequest(m, "poll-overrides", this.PollOverrides)
+	this.registerAPIRequest(m, "poll-override/:host/:port", this.PollOverrides)
+
 	// Instance management:
 	this.registerAPIRequest(m, "instance/:host/:port", this.Instance)
+	this.registerAPIRequest(m, "instance-capabilities/:host/:port", this.InstanceCapabilities)
+	this.registerAPIRequest(m, "cluster-capabilities/:clusterHint", this.ClusterCapabilities)
+	this.registerAPIRequest(m, "binlog-server-conformance/:host/:port", this.BinlogServerConformance)
 	this.registerAPIRequest(m, "discover/:host/:port", this.Discover)
 	this.registerAPIRequest(m, "async-discover/:host/:port", this.AsyncDiscover)
+	this.registerAPIRequest(m, "rapid-rediscovery/:host/:port", this.RapidRediscovery)
 	this.registerAPIRequest(m, "refresh/:host/:port", this.Refresh)
 	this.registerAPIRequest(m, "forget/:host/:port", this.Forget)
 	this.registerAPIRequest(m, "forget-cluster/:clusterHint", this.ForgetCluster)
+	this.registerAPIRequest(m, "instance-reverifications", this.InstanceReverifications)
 	this.registerAPIRequest(m, "begin-maintenance/:host/:port/:owner/:reason", this.BeginMaintenance)
 	this.registerAPIRequest(m, "end-maintenance/:host/:port", this.EndMaintenanceByInstanceKey)
 	this.registerAPIRequest(m, "in-maintenance/:host/:port", this.InMaintenance)
@@ -3691,10 +5609,27 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "graceful-master-takeover/:host/:port/:designatedHost/:designatedPort", this.GracefulMasterTakeover)
 	this.registerAPIRequest(m, "graceful-master-takeover/:clusterHint", this.GracefulMasterTakeover)
 	this.registerAPIRequest(m, "graceful-master-takeover/:clusterHint/:designatedHost/:designatedPort", this.GracefulMasterTakeover)
+	this.registerAPIRequest(m, "graceful-master-takeover-auto/:host/:port", this.GracefulMasterTakeoverAuto)
+	this.registerAPIRequest(m, "graceful-master-takeover-auto/:host/:port/:designatedHost/:designatedPort", this.GracefulMasterTakeoverAuto)
+	this.registerAPIRequest(m, "graceful-master-takeover-auto/:clusterHint", this.GracefulMasterTakeoverAuto)
+	this.registerAPIRequest(m, "graceful-master-takeover-auto/:clusterHint/:designatedHost/:designatedPort", this.GracefulMasterTakeoverAuto)
+	this.registerAPIRequest(m, "recover-undo/:uid", this.UndoRecovery)
+	this.registerAPIRequest(m, "hook-deliveries", this.HookDeliveries)
+	this.registerAPIRequest(m, "hook-deliveries-dead", this.DeadHookDeliveries)
+	this.registerAPIRequest(m, "retry-hook-delivery/:deliveryId", this.RetryHookDelivery)
 	this.registerAPIRequest(m, "force-master-failover/:host/:port", this.ForceMasterFailover)
 	this.registerAPIRequest(m, "force-master-failover/:clusterHint", this.ForceMasterFailover)
 	this.registerAPIRequest(m, "force-master-takeover/:clusterHint/:designatedHost/:designatedPort", this.ForceMasterTakeover)
 	this.registerAPIRequest(m, "force-master-takeover/:host/:port/:designatedHost/:designatedPort", this.ForceMasterTakeover)
+	this.registerAPIRequest(m, "force-master-failover-to/:clusterHint/:designatedHost/:designatedPort", this.ForceMasterFailoverTo)
+	this.registerAPIRequest(m, "force-master-failover-to/:host/:port/:designatedHost/:designatedPort", this.ForceMasterFailoverTo)
+	this.registerAPIRequest(m, "what-if-master-fails/:host/:port", this.WhatIfMasterFails)
+	this.registerAPIRequest(m, "what-if-master-fails/:clusterHint", this.WhatIfMasterFails)
+	this.registerAPIRequest(m, "promotion-candidate-quorum", this.PromotionCandidateQuorum)
+	this.registerAPIRequest(m, "promotion-candidate-quorum/:clusterHint", this.PromotionCandidateQuorum)
+	this.registerAPIRequest(m, "prepare-master-promotion/:host/:port", this.PrepareMasterPromotion)
+	this.registerAPIRequest(m, "prepare-master-promotion/:clusterHint", this.PrepareMasterPromotion)
+	this.registerAPIRequest(m, "confirm-master-promotion/:token", this.ConfirmMasterPromotion)
 	this.registerAPIRequest(m, "register-candidate/:host/:port/:promotionRule", this.RegisterCandidate)
 	this.registerAPIRequest(m, "automated-recovery-filters", this.AutomatedRecoveryFilters)
 	this.registerAPIRequest(m, "audit-failure-detection", this.AuditFailureDetection)
@@ -3724,6 +5659,34 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "disable-global-recoveries", this.DisableGlobalRecoveries)
 	this.registerAPIRequest(m, "enable-global-recoveries", this.EnableGlobalRecoveries)
 	this.registerAPIRequest(m, "check-global-recoveries", this.CheckGlobalRecoveries)
+	this.registerAPIRequest(m, "relax-lag-postponement/:host/:port/:reason", this.RelaxLagPostponement)
+	this.registerAPIRequest(m, "relax-lag-postponement/:host/:port/:reason/:duration", this.RelaxLagPostponement)
+	this.registerAPIRequest(m, "end-lag-postponement-relaxation/:host/:port", this.EndLagPostponementRelaxation)
+	this.registerAPIRequest(m, "lag-postponement-relaxations", this.LagPostponementRelaxations)
+	this.registerAPIRequest(m, "lag-postponement-relaxations/:clusterHint", this.LagPostponementRelaxations)
+	this.registerAPIRequest(m, "begin-cluster-focus/:clusterHint/:reason", this.BeginClusterFocus)
+	this.registerAPIRequest(m, "begin-cluster-focus/:clusterHint/:reason/:duration", this.BeginClusterFocus)
+	this.registerAPIRequest(m, "end-cluster-focus/:clusterHint", this.EndClusterFocus)
+	this.registerAPIRequest(m, "cluster-focuses", this.ClusterFocuses)
+	this.registerAPIRequest(m, "cluster-focuses/:clusterHint", this.ClusterFocuses)
+	this.registerAPIRequest(m, "cluster-focus-samples/:clusterHint", this.ClusterFocusSamples)
+	this.registerAPIRequest(m, "begin-write-freeze/:clusterHint/:reason", this.BeginWriteFreeze)
+	this.registerAPIRequest(m, "begin-write-freeze/:clusterHint/:reason/:duration", this.BeginWriteFreeze)
+	this.registerAPIRequest(m, "end-write-freeze/:clusterHint", this.EndWriteFreeze)
+	this.registerAPIRequest(m, "write-freezes", this.WriteFreezes)
+	this.registerAPIRequest(m, "write-freezes/:clusterHint", this.WriteFreezes)
+	this.registerAPIRequest(m, "recovery-rate-limits", this.RecoveryRateLimits)
+	this.registerAPIRequest(m, "recovery-rate-limits/:clusterHint", this.RecoveryRateLimits)
+	this.registerAPIRequest(m, "reset-recovery-rate-limit", this.ResetRecoveryRateLimit)
+	this.registerAPIRequest(m, "reset-recovery-rate-limit/:clusterHint", this.ResetRecoveryRateLimit)
+	this.registerAPIRequest(m, "enable-feature/:feature/:reason", this.EnableFeature)
+	this.registerAPIRequest(m, "enable-feature/:feature/:reason/:clusterHint", this.EnableFeature)
+	this.registerAPIRequest(m, "disable-feature/:feature/:reason", this.DisableFeature)
+	this.registerAPIRequest(m, "disable-feature/:feature/:reason/:clusterHint", this.DisableFeature)
+	this.registerAPIRequest(m, "reset-feature/:feature", this.ResetFeature)
+	this.registerAPIRequest(m, "reset-feature/:feature/:clusterHint", this.ResetFeature)
+	this.registerAPIRequest(m, "feature-flags", this.FeatureFlags)
+	this.registerAPIRequest(m, "feature-flags/:feature", this.FeatureFlags)
 
 	// General
 	this.registerAPIRequest(m, "problems", this.Problems)
@@ -3732,11 +5695,22 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "audit/:page", this.Audit)
 	this.registerAPIRequest(m, "audit/instance/:host/:port", this.Audit)
 	this.registerAPIRequest(m, "audit/instance/:host/:port/:page", this.Audit)
+	this.registerAPIRequestNoProxy(m, "events-stream", this.EventsStream)
+	this.registerAPIRequestNoProxy(m, "topology-stream", this.TopologyStream)
+	this.registerAPIRequestNoProxy(m, "topology-stream/:clusterHint", this.TopologyStream)
+	this.registerAPIRequest(m, "operation-intents", this.OperationIntents)
+
+	// DR pairs:
+	this.registerAPIRequest(m, "dr-pairs", this.DRPairs)
+	this.registerAPIRequest(m, "register-dr-pair/:host/:port/:standbyAlias", this.RegisterDRPair)
+	this.registerAPIRequest(m, "split-dr-pair/:standbyAlias", this.SplitDRPair)
+	this.registerAPIRequest(m, "remove-dr-pair/:standbyAlias", this.RemoveDRPair)
 	this.registerAPIRequest(m, "resolve/:host/:port", this.Resolve)
 
 	// Meta, no proxy
 	this.registerAPIRequestNoProxy(m, "headers", this.Headers)
 	this.registerAPIRequestNoProxy(m, "health", this.Health)
+	this.registerAPIRequestNoProxy(m, "health/v1", this.HealthReport)
 	this.registerAPIRequestNoProxy(m, "lb-check", this.LBCheck)
 	this.registerAPIRequestNoProxy(m, "_ping", this.LBCheck)
 	this.registerAPIRequestNoProxy(m, "leader-check", this.LeaderCheck)
@@ -3749,12 +5723,15 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequestNoProxy(m, "raft-leader", this.RaftLeader)
 	this.registerAPIRequestNoProxy(m, "raft-health", this.RaftHealth)
 	this.registerAPIRequestNoProxy(m, "raft-snapshot", this.RaftSnapshot)
+	this.registerAPIRequestNoProxy(m, "instance-reachability/:host/:port", this.InstanceReachability)
 	this.registerAPIRequestNoProxy(m, "raft-follower-health-report/:authenticationToken/:raftBind/:raftAdvertise", this.RaftFollowerHealthReport)
+	this.registerAPIRequestNoProxy(m, "restart-for-upgrade", this.RestartForUpgrade)
 	this.registerAPIRequestNoProxy(m, "reload-configuration", this.ReloadConfiguration)
 	this.registerAPIRequestNoProxy(m, "hostname-resolve-cache", this.HostnameResolveCache)
 	this.registerAPIRequestNoProxy(m, "reset-hostname-resolve-cache", this.ResetHostnameResolveCache)
 	// Meta
 	this.registerAPIRequest(m, "reelect", this.Reelect)
+	this.registerAPIRequest(m, "rolling-upgrade-plan", this.RollingUpgradePlan)
 	this.registerAPIRequest(m, "reload-cluster-alias", this.ReloadClusterAlias)
 	this.registerAPIRequest(m, "deregister-hostname-unresolve/:host/:port", this.DeregisterHostnameUnresolve)
 	this.registerAPIRequest(m, "register-hostname-unresolve/:host/:port/:virtualname", this.RegisterHostnameUnresolve)
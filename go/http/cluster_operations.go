@@ -0,0 +1,103 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// serializedAPIRequests are the mutating topology operations which run as cluster operations, serialized with
+// other operations and recoveries on the same cluster per SerializeClusterOperationsFilters
+var serializedAPIRequests = map[string]bool{
+	"relocate":                    true,
+	"relocate-below":              true,
+	"relocate-slaves":             true,
+	"regroup-slaves":              true,
+	"move-up":                     true,
+	"move-up-slaves":              true,
+	"move-below":                  true,
+	"move-equivalent":             true,
+	"repoint":                     true,
+	"repoint-slaves":              true,
+	"undo-last-relocation":        true,
+	"make-co-master":              true,
+	"enslave-siblings":            true,
+	"enslave-master":              true,
+	"regroup-slaves-bls":          true,
+	"retire-binlog-server-family": true,
+	"move-below-gtid":             true,
+	"move-slaves-gtid":            true,
+	"regroup-slaves-gtid":         true,
+	"match":                       true,
+	"match-below":                 true,
+	"match-up":                    true,
+	"match-slaves":                true,
+	"match-up-slaves":             true,
+	"regroup-slaves-pgtid":        true,
+	"make-master":                 true,
+	"make-local-master":           true,
+	"detach-slave":                true,
+	"reattach-slave":              true,
+	"detach-slave-master-host":    true,
+	"reattach-slave-master-host":  true,
+	"begin-write-freeze":          true,
+}
+
+// operationInstanceKeys returns the instances a request's params name: the instance operated on, and any instance
+// it is relocated below
+func operationInstanceKeys(params martini.Params) (instanceKeys []inst.InstanceKey) {
+	for _, hostPort := range [][2]string{{"host", "port"}, {"belowHost", "belowPort"}, {"siblingHost", "siblingPort"}} {
+		if params[hostPort[0]] == "" {
+			continue
+		}
+		if instanceKey, err := inst.NewRawInstanceKeyStrings(params[hostPort[0]], params[hostPort[1]]); err == nil {
+			instanceKeys = append(instanceKeys, *instanceKey)
+		}
+	}
+	return instanceKeys
+}
+
+// serializeClusterOperation precedes the handler of a serialized API request. It waits for its turn to operate on
+// the cluster, runs the handler as an in-flight operation, and lets the next operation queued on the cluster run.
+func (this *HttpAPI) serializeClusterOperation(params martini.Params, r render.Render, req *http.Request, user auth.User, c martini.Context) {
+	if !isAuthorizedByAuthenticationMethod(req, user) {
+		// The handler rejects the request
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		// The handler reports the unknown instance
+		return
+	}
+	clusterOperation, err := inst.BeginClusterOperation(clusterName, req.URL.Path, getActingUser(req, user))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	defer inst.EndClusterOperation(clusterOperation)
+
+	correlationID := inst.BeginInFlightOperation(req.Header.Get(IdempotencyKeyHeader), apiCommand(req.URL.Path), clusterName, getActingUser(req, user), false, operationInstanceKeys(params)...)
+	defer inst.EndInFlightOperation(correlationID)
+	c.Map(req.WithContext(inst.WithInFlightOperation(req.Context(), correlationID)))
+	c.Next()
+}
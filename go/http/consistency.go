@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/raft"
+)
+
+// RaftIndexHeader is set on API responses, in raft setups, to the raft log index applied by the serving node
+const RaftIndexHeader = "X-Orchestrator-Raft-Index"
+
+// MinRaftIndexHeader is the request header by which a client requires data at least as new as a raft index
+// it has previously been handed via RaftIndexHeader
+const MinRaftIndexHeader = "X-Orchestrator-Min-Raft-Index"
+
+// ConsistentReads is a middleware which marks API responses with the raft log index applied by the serving node.
+// Following a mutation, a client which passes this index back via MinRaftIndexHeader reads its own writes,
+// whichever node serves its subsequent requests.
+func ConsistentReads(w http.ResponseWriter, req *http.Request) {
+	if !orcraft.IsRaftEnabled() {
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, fmt.Sprintf("%s/api/", config.Config.URLPrefix)) {
+		return
+	}
+	rw, ok := w.(martini.ResponseWriter)
+	if !ok {
+		return
+	}
+	rw.Before(func(rw martini.ResponseWriter) {
+		// A response proxied from the leader already carries the leader's index
+		if rw.Header().Get(RaftIndexHeader) == "" {
+			rw.Header().Set(RaftIndexHeader, fmt.Sprintf("%d", orcraft.AppliedIndex()))
+		}
+	})
+}
+
+// requestMinRaftIndex returns the raft index required by given request, or 0 when the request requires none
+func requestMinRaftIndex(req *http.Request) (uint64, error) {
+	minRaftIndex := req.Header.Get(MinRaftIndexHeader)
+	if minRaftIndex == "" {
+		return 0, nil
+	}
+	index, err := strconv.ParseUint(minRaftIndex, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid %s: %s", MinRaftIndexHeader, minRaftIndex)
+	}
+	return index, nil
+}
+
+// serveConsistently is called when this node serves an API request by itself, rather than proxying it to the leader.
+// Should the request require a minimum raft index, this waits up to ConsistentReadWaitSeconds for this node to apply
+// the raft log up to that index. It returns false, having responded with an error, when the request may not be served.
+func serveConsistently(w http.ResponseWriter, req *http.Request) bool {
+	index, err := requestMinRaftIndex(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if index == 0 {
+		return true
+	}
+	if !orcraft.WaitForAppliedIndex(index, time.Duration(config.Config.ConsistentReadWaitSeconds)*time.Second) {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, fmt.Sprintf("This node has applied raft index %d, behind requested %s %d", orcraft.AppliedIndex(), MinRaftIndexHeader, index), http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestServeConsistently(t *testing.T) {
+	defer func(seconds uint) { config.Config.ConsistentReadWaitSeconds = seconds }(config.Config.ConsistentReadWaitSeconds)
+	config.Config.ConsistentReadWaitSeconds = 0
+
+	request := func(minRaftIndex string) (*httptest.ResponseRecorder, bool) {
+		req, _ := http.NewRequest("GET", "/api/cluster/mycluster", nil)
+		if minRaftIndex != "" {
+			req.Header.Set(MinRaftIndexHeader, minRaftIndex)
+		}
+		w := httptest.NewRecorder()
+		return w, serveConsistently(w, req)
+	}
+
+	{
+		_, ok := request("")
+		test.S(t).ExpectTrue(ok)
+	}
+	{
+		_, ok := request("0")
+		test.S(t).ExpectTrue(ok)
+	}
+	{
+		w, ok := request("not-a-number")
+		test.S(t).ExpectFalse(ok)
+		test.S(t).ExpectEquals(w.Code, http.StatusBadRequest)
+	}
+	{
+		// raft is not running in this test; this node's applied index is 0
+		w, ok := request("17")
+		test.S(t).ExpectFalse(ok)
+		test.S(t).ExpectEquals(w.Code, http.StatusServiceUnavailable)
+		test.S(t).ExpectEquals(w.Header().Get("Retry-After"), "1")
+	}
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-martini/martini"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/events"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// eventsStreamBufferSize is the number of events buffered per streaming client; events are dropped for
+// clients which do not keep up
+const eventsStreamBufferSize = 256
+
+func isEventsStreamRequest(req *http.Request) bool {
+	for _, path := range []string{"events-stream", "topology-stream"} {
+		if strings.HasPrefix(req.URL.Path, fmt.Sprintf("%s/api/%s", config.Config.URLPrefix, path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// UncompressedEventsStream is a middleware which disables response compression for the events stream:
+// compression buffers the response, which defeats streaming. It must precede the gzip middleware.
+func UncompressedEventsStream(req *http.Request) {
+	if isEventsStreamRequest(req) {
+		req.Header.Del("Accept-Encoding")
+	}
+}
+
+// EventsStream streams this node's events as Server-Sent Events, until the client disconnects
+func (this *HttpAPI) EventsStream(params martini.Params, w http.ResponseWriter, req *http.Request) {
+	streamEvents(w, req, func(event *events.Event) bool { return true })
+}
+
+// TopologyStream streams this node's topology events as Server-Sent Events, optionally of a single cluster,
+// until the client disconnects. The stream follows the cluster past a change of its master, and thereby of its name.
+func (this *HttpAPI) TopologyStream(params martini.Params, w http.ResponseWriter, req *http.Request) {
+	clusterNames := map[string]bool{}
+	if clusterHint := getClusterHint(params); clusterHint != "" {
+		clusterName, err := figureClusterName(clusterHint)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		clusterNames[clusterName] = true
+	}
+	streamEvents(w, req, func(event *events.Event) bool {
+		if event.Kind != events.TopologyEvent {
+			return false
+		}
+		if len(clusterNames) == 0 {
+			return true
+		}
+		if !clusterNames[event.ClusterName] {
+			return false
+		}
+		if event.Type == inst.TopologyMasterChangedEvent {
+			if master, _, err := inst.ReadInstance(&inst.InstanceKey{Hostname: event.Hostname, Port: event.Port}); err == nil && master != nil {
+				clusterNames[master.ClusterName] = true
+			}
+		}
+		return true
+	})
+}
+
+// streamEvents streams this node's events, as accepted by given filter, as Server-Sent Events, until the client disconnects
+func streamEvents(w http.ResponseWriter, req *http.Request, accept func(event *events.Event) bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	subscription := events.Subscribe(eventsStreamBufferSize)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			if !accept(event) {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", event.Id, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
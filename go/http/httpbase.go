@@ -28,6 +28,7 @@ import (
 	"github.com/github/orchestrator/go/os"
 	"github.com/github/orchestrator/go/process"
 	"github.com/github/orchestrator/go/raft"
+	"github.com/openark/golib/log"
 )
 
 func getProxyAuthUser(req *http.Request) string {
@@ -39,7 +40,32 @@ func getProxyAuthUser(req *http.Request) string {
 
 // isAuthorizedForAction checks req to see whether authenticated user has write-privileges.
 // This depends on configured authentication method.
+// For API requests, the acting user and reason are also validated (see RequireOperationReason); the operation
+// is audited once it completes (see auditAPIOperation)
 func isAuthorizedForAction(req *http.Request, user auth.User) bool {
+	if !isAuthorizedByAuthenticationMethod(req, user) {
+		return false
+	}
+	if !strings.HasPrefix(req.URL.Path, fmt.Sprintf("%s/api/", config.Config.URLPrefix)) {
+		return true
+	}
+	actingUser := getActingUser(req, user)
+	reason := getOperationReason(req)
+	if err := inst.ValidateOperationAttribution(actingUser, reason); err != nil {
+		log.Errorf("%s: %+v", req.URL.Path, err)
+		return false
+	}
+	if namespace := getRequestNamespace(req); !inst.IsNamespaceOperator(namespace, getUserId(req, user)) {
+		log.Errorf("%s: user %s may not operate on namespace %s", req.URL.Path, getUserId(req, user), namespace)
+		return false
+	}
+	authorizeAPIOperation(req, actingUser, reason)
+	return true
+}
+
+// isAuthorizedByAuthenticationMethod checks req to see whether authenticated user has write-privileges,
+// by configured authentication method
+func isAuthorizedByAuthenticationMethod(req *http.Request, user auth.User) bool {
 	if config.Config.ReadOnly {
 		return false
 	}
@@ -143,6 +169,31 @@ func getUserId(req *http.Request, user auth.User) string {
 	}
 }
 
+// getActingUser returns the identity of the user on behalf of whom an operation is requested.
+// An authenticated (e.g. automation) user may act on behalf of an operator via the `owner` param,
+// in which case both identities are returned.
+func getActingUser(req *http.Request, user auth.User) string {
+	return getActingUserOnBehalfOf(req, user, req.URL.Query().Get("owner"))
+}
+
+// getActingUserOnBehalfOf returns the identity of the authenticated user, acting on behalf of given owner, if any
+func getActingUserOnBehalfOf(req *http.Request, user auth.User, owner string) string {
+	userId := getUserId(req, user)
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return userId
+	}
+	if userId == "" || userId == owner {
+		return owner
+	}
+	return fmt.Sprintf("%s (via %s)", owner, userId)
+}
+
+// getOperationReason returns the reason given for a requested operation, if any
+func getOperationReason(req *http.Request) string {
+	return strings.TrimSpace(req.URL.Query().Get("reason"))
+}
+
 func getClusterHint(params map[string]string) string {
 	if params["clusterHint"] != "" {
 		return params["clusterHint"]
@@ -0,0 +1,221 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/openark/golib/log"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/process"
+	orcraft "github.com/github/orchestrator/go/raft"
+)
+
+// IdempotencyKeyHeader is the request header by which a client marks an API request as safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses which are replayed rather than executed
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// idempotentResponse is a recorded response to an API request carrying an idempotency key.
+// An entry with inProgress set is a placeholder for a request still being executed. A response read from the
+// backend has no body, but only its digest.
+type idempotentResponse struct {
+	inProgress bool
+	status     int
+	header     http.Header
+	body       []byte
+	bodyDigest string
+}
+
+// idempotentResponses caches responses, along with their bodies, in front of the backend, where their status
+// and body digest are persisted
+var idempotentResponses = cache.New(cache.NoExpiration, time.Minute)
+
+// responseRecorder passes through all writes to the underlying writer, while recording them.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   []byte
+}
+
+func (this *responseRecorder) WriteHeader(status int) {
+	this.status = status
+	this.ResponseWriter.WriteHeader(status)
+}
+
+func (this *responseRecorder) Write(b []byte) (int, error) {
+	if this.status == 0 {
+		this.status = http.StatusOK
+	}
+	this.body = append(this.body, b...)
+	return this.ResponseWriter.Write(b)
+}
+
+func (this *responseRecorder) toIdempotentResponse() *idempotentResponse {
+	header := http.Header{}
+	for k, v := range this.ResponseWriter.Header() {
+		header[k] = append([]string{}, v...)
+	}
+	hash := sha256.Sum256(this.body)
+	return &idempotentResponse{
+		status:     this.status,
+		header:     header,
+		body:       append([]byte{}, this.body...),
+		bodyDigest: hex.EncodeToString(hash[:]),
+	}
+}
+
+// toPersisted returns this response as persisted for given request, until given expiry. The body is not persisted,
+// and is therefore neither written to the raft log nor replicated; only its digest is.
+func (this *idempotentResponse) toPersisted(requestHash string, expiresUnixtime int64) *process.IdempotentResponse {
+	return &process.IdempotentResponse{
+		RequestHash:     requestHash,
+		InProgress:      this.inProgress,
+		Status:          this.status,
+		Header:          this.header,
+		BodyDigest:      this.bodyDigest,
+		ExpiresUnixtime: expiresUnixtime,
+	}
+}
+
+func newIdempotentResponse(persisted *process.IdempotentResponse) *idempotentResponse {
+	return &idempotentResponse{
+		inProgress: persisted.InProgress,
+		status:     persisted.Status,
+		header:     persisted.Header,
+		bodyDigest: persisted.BodyDigest,
+	}
+}
+
+// idempotentRequestHash identifies a request by its idempotency key, user, method and URI
+func idempotentRequestHash(cacheKey string) string {
+	hash := sha256.Sum256([]byte(cacheKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// persistIdempotentResponse writes given response to the backend. With raft, the leader shares it with all nodes,
+// such that a request retried against a new leader is still replayed.
+func persistIdempotentResponse(response *process.IdempotentResponse) {
+	var err error
+	if orcraft.IsRaftEnabled() && orcraft.IsLeader() {
+		_, err = orcraft.PublishCommand("write-idempotent-response", response)
+	} else {
+		err = process.WriteIdempotentResponse(response)
+	}
+	log.Errore(err)
+}
+
+// forgetIdempotentResponse deletes the response, or placeholder, of given request from the backend
+func forgetIdempotentResponse(requestHash string) {
+	var err error
+	if orcraft.IsRaftEnabled() && orcraft.IsLeader() {
+		_, err = orcraft.PublishCommand("delete-idempotent-response", requestHash)
+	} else {
+		err = process.DeleteIdempotentResponse(requestHash)
+	}
+	log.Errore(err)
+}
+
+func (this *idempotentResponse) replay(w http.ResponseWriter) {
+	if this.inProgress {
+		http.Error(w, fmt.Sprintf("A request with same %s is still in progress", IdempotencyKeyHeader), http.StatusConflict)
+		return
+	}
+	for k, v := range this.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	if this.body == nil {
+		// Read from the backend: the request was served, by another node or before a restart, and its body is
+		// not retained
+		apiResponse := &APIResponse{Code: OK, Message: "Request already served; response not retained", Details: this.bodyDigest}
+		if this.status >= http.StatusBadRequest {
+			apiResponse.Code = ERROR
+		}
+		body, _ := json.Marshal(apiResponse)
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(this.status)
+		w.Write(body)
+		return
+	}
+	w.WriteHeader(this.status)
+	w.Write(this.body)
+}
+
+// IdempotentRequest is a middleware which serves API requests carrying an `Idempotency-Key` header at most once:
+// a repeated request by same user, with same key, method and URI gets the original response, without the operation
+// being re-executed. A response's status and body digest are persisted in the backend, and so are replayed by other
+// nodes and after a restart; its body is only replayed by the node which served it.
+// Requests without such header are unaffected.
+func IdempotentRequest(w http.ResponseWriter, req *http.Request, user auth.User, c martini.Context) {
+	idempotencyKey := req.Header.Get(IdempotencyKeyHeader)
+	if idempotencyKey == "" {
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, fmt.Sprintf("%s/api/", config.Config.URLPrefix)) {
+		return
+	}
+	expiry := time.Duration(config.Config.IdempotencyKeyExpirySeconds) * time.Second
+	cacheKey := fmt.Sprintf("%s %s %s %s", idempotencyKey, getUserId(req, user), req.Method, req.URL.RequestURI())
+	placeholder := &idempotentResponse{inProgress: true}
+	if err := idempotentResponses.Add(cacheKey, placeholder, expiry); err != nil {
+		// Key already exists: this is a repeated request
+		if response, found := idempotentResponses.Get(cacheKey); found {
+			response.(*idempotentResponse).replay(w)
+			return
+		}
+	}
+	// Unknown to this node's cache, the request may yet be known to the backend: from another node, or from
+	// before a restart. Should the backend be unavailable, we make do with the cache.
+	requestHash := idempotentRequestHash(cacheKey)
+	expiresUnixtime := time.Now().Add(expiry).Unix()
+	if claimed, err := process.ClaimIdempotentRequest(requestHash, expiresUnixtime); err == nil {
+		if !claimed {
+			if persisted, err := process.ReadIdempotentResponse(requestHash); err == nil && persisted != nil {
+				idempotentResponses.Delete(cacheKey)
+				newIdempotentResponse(persisted).replay(w)
+				return
+			}
+		}
+		persistIdempotentResponse(placeholder.toPersisted(requestHash, expiresUnixtime))
+	}
+	recorder := &responseRecorder{ResponseWriter: w}
+	c.MapTo(recorder, (*http.ResponseWriter)(nil))
+	defer func() {
+		if recorder.status == 0 {
+			// Nothing was written; do not hold on to this key
+			idempotentResponses.Delete(cacheKey)
+			forgetIdempotentResponse(requestHash)
+			return
+		}
+		response := recorder.toIdempotentResponse()
+		idempotentResponses.Set(cacheKey, response, expiry)
+		persistIdempotentResponse(response.toPersisted(requestHash, expiresUnixtime))
+	}()
+	c.Next()
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestIdempotentRequest(t *testing.T) {
+	countExecutions := 0
+	m := martini.New()
+	m.Use(func(req *http.Request, c martini.Context) {
+		c.Map(auth.User(req.Header.Get("X-Test-User")))
+	})
+	m.Use(IdempotentRequest)
+	m.Action(func(w http.ResponseWriter) {
+		countExecutions++
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "execution %d", countExecutions)
+	})
+
+	defer func(authenticationMethod string) { config.Config.AuthenticationMethod = authenticationMethod }(config.Config.AuthenticationMethod)
+	config.Config.AuthenticationMethod = "basic"
+
+	requestBy := func(user string, idempotencyKey string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/relocate/host1/3306/host2/3306", nil)
+		req.Header.Set("X-Test-User", user)
+		if idempotencyKey != "" {
+			req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+		}
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, req)
+		return w
+	}
+	request := func(idempotencyKey string) *httptest.ResponseRecorder {
+		return requestBy("operator", idempotencyKey)
+	}
+
+	{
+		w := request("key-1")
+		test.S(t).ExpectEquals(w.Body.String(), "execution 1")
+		test.S(t).ExpectEquals(w.Header().Get(IdempotentReplayedHeader), "")
+	}
+	{
+		w := request("key-1")
+		test.S(t).ExpectEquals(w.Body.String(), "execution 1")
+		test.S(t).ExpectEquals(w.Header().Get(IdempotentReplayedHeader), "true")
+		test.S(t).ExpectEquals(countExecutions, 1)
+	}
+	{
+		w := request("key-2")
+		test.S(t).ExpectEquals(w.Body.String(), "execution 2")
+	}
+	{
+		w := request("")
+		test.S(t).ExpectEquals(w.Body.String(), "execution 3")
+		w = request("")
+		test.S(t).ExpectEquals(w.Body.String(), "execution 4")
+	}
+	{
+		// Another user's request with same key is not replayed the first user's response
+		w := requestBy("intruder", "key-1")
+		test.S(t).ExpectEquals(w.Body.String(), "execution 5")
+		test.S(t).ExpectEquals(w.Header().Get(IdempotentReplayedHeader), "")
+	}
+}
+
+func TestIdempotentResponsePersistence(t *testing.T) {
+	recorder := &responseRecorder{ResponseWriter: httptest.NewRecorder()}
+	recorder.Header().Set("Content-Type", "application/json")
+	recorder.Write([]byte(`{"Code":"OK"}`))
+	response := recorder.toIdempotentResponse()
+	test.S(t).ExpectEquals(len(response.bodyDigest), 64)
+
+	w := httptest.NewRecorder()
+	response.replay(w)
+	test.S(t).ExpectEquals(w.Code, http.StatusOK)
+	test.S(t).ExpectEquals(w.Body.String(), `{"Code":"OK"}`)
+	test.S(t).ExpectEquals(w.Header().Get(IdempotentReplayedHeader), "true")
+
+	persisted := response.toPersisted("hash", 12345)
+	test.S(t).ExpectEquals(persisted.RequestHash, "hash")
+	test.S(t).ExpectEquals(persisted.ExpiresUnixtime, int64(12345))
+	test.S(t).ExpectEquals(persisted.BodyDigest, response.bodyDigest)
+	test.S(t).ExpectFalse(persisted.InProgress)
+
+	// The body is not persisted: a response read from the backend is replayed by its status and digest
+	restored := newIdempotentResponse(persisted)
+	w = httptest.NewRecorder()
+	restored.replay(w)
+	test.S(t).ExpectEquals(w.Code, http.StatusOK)
+	test.S(t).ExpectEquals(w.Header().Get("Content-Type"), "application/json; charset=UTF-8")
+	test.S(t).ExpectEquals(w.Header().Get(IdempotentReplayedHeader), "true")
+	apiResponse := map[string]interface{}{}
+	test.S(t).ExpectNil(json.Unmarshal(w.Body.Bytes(), &apiResponse))
+	test.S(t).ExpectEquals(apiResponse["Code"], "OK")
+	test.S(t).ExpectEquals(apiResponse["Details"], response.bodyDigest)
+
+	w = httptest.NewRecorder()
+	newIdempotentResponse((&idempotentResponse{status: http.StatusInternalServerError, bodyDigest: "digest"}).toPersisted("hash", 12345)).replay(w)
+	test.S(t).ExpectEquals(w.Code, http.StatusInternalServerError)
+	test.S(t).ExpectNil(json.Unmarshal(w.Body.Bytes(), &apiResponse))
+	test.S(t).ExpectEquals(apiResponse["Code"], "ERROR")
+
+	w = httptest.NewRecorder()
+	newIdempotentResponse((&idempotentResponse{inProgress: true}).toPersisted("hash", 12345)).replay(w)
+	test.S(t).ExpectEquals(w.Code, http.StatusConflict)
+
+	test.S(t).ExpectEquals(idempotentRequestHash("key-1 GET /api/relocate"), idempotentRequestHash("key-1 GET /api/relocate"))
+	test.S(t).ExpectNotEquals(idempotentRequestHash("key-1 GET /api/relocate"), idempotentRequestHash("key-2 GET /api/relocate"))
+	test.S(t).ExpectEquals(len(idempotentRequestHash("key-1 GET /api/relocate")), 64)
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-martini/martini"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+type namespaceContextKey struct{}
+
+// getRequestNamespace returns the environment namespace of the cluster a request operates on, as resolved by
+// scopeNamespace
+func getRequestNamespace(req *http.Request) string {
+	namespace, _ := req.Context().Value(namespaceContextKey{}).(string)
+	return namespace
+}
+
+// scopeNamespace precedes the handler of an API request. It resolves the environment namespace of the cluster the
+// request operates on, by which isAuthorizedForAction applies the namespace's PowerAuthUsers.
+func scopeNamespace(params martini.Params, req *http.Request, c martini.Context) {
+	if len(config.Config.NamespacePolicies) == 0 {
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		// Not a cluster operation, or the handler reports the unknown instance
+		return
+	}
+	namespace, err := inst.ReadClusterNamespace(clusterName)
+	if err != nil || namespace == "" {
+		return
+	}
+	c.Map(req.WithContext(context.WithValue(req.Context(), namespaceContextKey{}, namespace)))
+}
+
+// filterClustersInfoByNamespace returns those of given clusters which are in given environment namespace. An empty
+// namespace filters nothing.
+func filterClustersInfoByNamespace(clustersInfo []inst.ClusterInfo, namespace string) []inst.ClusterInfo {
+	if namespace == "" {
+		return clustersInfo
+	}
+	filtered := []inst.ClusterInfo{}
+	for _, clusterInfo := range clustersInfo {
+		if clusterInfo.Namespace == namespace {
+			filtered = append(filtered, clusterInfo)
+		}
+	}
+	return filtered
+}
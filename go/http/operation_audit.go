@@ -0,0 +1,116 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// apiOperation is what an API request's authorization tells of the operation it requests: whether it was
+// authorized, and by whom and why
+type apiOperation struct {
+	authorized bool
+	actingUser string
+	reason     string
+}
+
+type apiOperationContextKey struct{}
+
+// auditOperationBy audits a completed API operation
+var auditOperationBy = inst.AuditOperationBy
+
+// authorizeAPIOperation marks the operation of given request as authorized, by given acting user and reason
+func authorizeAPIOperation(req *http.Request, actingUser string, reason string) {
+	if operation, ok := req.Context().Value(apiOperationContextKey{}).(*apiOperation); ok {
+		operation.authorized = true
+		operation.actingUser = actingUser
+		operation.reason = reason
+	}
+}
+
+// statusRender is a render.Render which remembers the status it responded with
+type statusRender struct {
+	render.Render
+	status int
+}
+
+func (this *statusRender) JSON(status int, v interface{}) {
+	this.status = status
+	this.Render.JSON(status, v)
+}
+
+func (this *statusRender) HTML(status int, name string, v interface{}, htmlOpt ...render.HTMLOptions) {
+	this.status = status
+	this.Render.HTML(status, name, v, htmlOpt...)
+}
+
+func (this *statusRender) XML(status int, v interface{}) {
+	this.status = status
+	this.Render.XML(status, v)
+}
+
+func (this *statusRender) Data(status int, v []byte) {
+	this.status = status
+	this.Render.Data(status, v)
+}
+
+func (this *statusRender) Text(status int, v string) {
+	this.status = status
+	this.Render.Text(status, v)
+}
+
+func (this *statusRender) Error(status int) {
+	this.status = status
+	this.Render.Error(status)
+}
+
+func (this *statusRender) Status(status int) {
+	this.status = status
+	this.Render.Status(status)
+}
+
+// isAuditedAPIOperation returns true when a request, with given authorization and response status, is an
+// operation which completed successfully
+func isAuditedAPIOperation(operation *apiOperation, status int) bool {
+	return operation.authorized && status >= http.StatusOK && status < http.StatusMultipleChoices
+}
+
+// auditAPIOperation precedes the handler of an API request. Once the handler completes, a request which it
+// authorized as an operation (see isAuthorizedForAction) and which succeeded is audited, once, on the instance
+// it operated on.
+func auditAPIOperation(params martini.Params, r render.Render, req *http.Request, c martini.Context) {
+	operation := &apiOperation{}
+	c.Map(req.WithContext(context.WithValue(req.Context(), apiOperationContextKey{}, operation)))
+	statusRender := &statusRender{Render: r}
+	c.MapTo(statusRender, (*render.Render)(nil))
+	c.Next()
+
+	if !isAuditedAPIOperation(operation, statusRender.status) {
+		return
+	}
+	var instanceKey *inst.InstanceKey
+	if instanceKeys := operationInstanceKeys(params); len(instanceKeys) > 0 {
+		instanceKey = &instanceKeys[0]
+	}
+	auditOperationBy("api-operation", instanceKey, req.URL.Path, operation.actingUser, operation.reason)
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestAuditAPIOperation(t *testing.T) {
+	defer func(f func(string, *inst.InstanceKey, string, string, string) error) { auditOperationBy = f }(auditOperationBy)
+	audited := []*inst.InstanceKey{}
+	auditOperationBy = func(auditType string, instanceKey *inst.InstanceKey, message string, owner string, reason string) error {
+		audited = append(audited, instanceKey)
+		return nil
+	}
+
+	m := martini.Classic()
+	m.Use(render.Renderer())
+	m.Get("/api/relocate/:host/:port/:belowHost/:belowPort", auditAPIOperation, func(r render.Render, req *http.Request) {
+		// a handler may check authorization more than once
+		authorizeAPIOperation(req, "someone", "")
+		authorizeAPIOperation(req, "someone", "")
+		Respond(r, &APIResponse{Code: OK})
+	})
+	m.Get("/api/move-up/:host/:port", auditAPIOperation, func(r render.Render, req *http.Request) {
+		authorizeAPIOperation(req, "someone", "")
+		Respond(r, &APIResponse{Code: ERROR, Message: "failed"})
+	})
+	m.Get("/api/repoint/:host/:port", auditAPIOperation, func(r render.Render, req *http.Request) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+	})
+	request := func(path string) {
+		req, _ := http.NewRequest("GET", path, nil)
+		m.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	request("/api/relocate/host1/3306/host2/3306")
+	test.S(t).ExpectEquals(len(audited), 1)
+	test.S(t).ExpectEquals(audited[0].Hostname, "host1")
+	test.S(t).ExpectEquals(audited[0].Port, 3306)
+
+	request("/api/move-up/host1/3306")
+	request("/api/repoint/host1/3306")
+	test.S(t).ExpectEquals(len(audited), 1)
+}
@@ -20,9 +20,11 @@ func raftReverseProxy(w http.ResponseWriter, r *http.Request, c martini.Context)
 	}
 	if orcraft.IsLeader() {
 		// I am the leader. I will handle the request directly.
+		serveConsistently(w, r)
 		return
 	}
 	if orcraft.GetLeader() == "" {
+		serveConsistently(w, r)
 		return
 	}
 	if orcraft.LeaderURI.IsThisLeaderURI() {
@@ -31,6 +33,7 @@ func raftReverseProxy(w http.ResponseWriter, r *http.Request, c martini.Context)
 		// But anyway, obviously not going to redirect to myself.
 		// Gonna return: this isn't ideal, because I'm not really the leader. If the user tries to
 		// run an operation they'll fail.
+		serveConsistently(w, r)
 		return
 	}
 	url, err := url.Parse(orcraft.LeaderURI.Get())
@@ -0,0 +1,76 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-martini/martini"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/logic"
+	"github.com/github/orchestrator/go/tracing"
+)
+
+// apiCommand returns the API command of given request path, e.g. "relocate" for "/api/relocate/host/3306/..."
+func apiCommand(path string) string {
+	apiPrefix := fmt.Sprintf("%s/api/", config.Config.URLPrefix)
+	return strings.SplitN(strings.TrimPrefix(path, apiPrefix), "/", 2)[0]
+}
+
+// apiSpanName names a span by the API command, e.g. "GET /api/relocate", rather than by the full path,
+// which includes hostnames and would make for unbounded span names
+func apiSpanName(method string, path string) string {
+	return fmt.Sprintf("%s %s/api/%s", method, config.Config.URLPrefix, apiCommand(path))
+}
+
+// TraceRequest is a middleware which starts a server span per API request, continuing the trace of the
+// caller when a `traceparent` header is given. The span is carried by the request's context, and the
+// request's `traceparent` header is updated such that requests proxied to the raft leader continue the trace.
+func TraceRequest(w http.ResponseWriter, req *http.Request, c martini.Context) {
+	if !tracing.IsEnabled() {
+		return
+	}
+	if !strings.HasPrefix(req.URL.Path, fmt.Sprintf("%s/api/", config.Config.URLPrefix)) {
+		return
+	}
+	span := tracing.StartServerSpan(apiSpanName(req.Method, req.URL.Path), req.Header.Get(tracing.TraceparentHeader))
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.target", req.URL.RequestURI())
+	req.Header.Set(tracing.TraceparentHeader, span.Traceparent())
+	c.Map(req.WithContext(tracing.ContextWithSpan(req.Context(), span)))
+	defer span.End()
+
+	c.Next()
+
+	if rw, ok := w.(martini.ResponseWriter); ok {
+		span.SetAttribute("http.status_code", rw.Status())
+		if rw.Status() >= http.StatusInternalServerError {
+			span.SetError(fmt.Errorf("%s", http.StatusText(rw.Status())))
+		}
+	}
+}
+
+// traceRecovery marks the request's span with the UID of given recovery, which is traced as a child of the request
+func traceRecovery(req *http.Request, topologyRecovery *logic.TopologyRecovery) {
+	if topologyRecovery == nil {
+		return
+	}
+	tracing.SpanFromContext(req.Context()).SetAttribute("recovery_uid", topologyRecovery.UID)
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/tracing"
 )
 
 type AnalysisCode string
@@ -53,6 +54,9 @@ const (
 	AllIntermediateMasterSlavesNotReplicating                          = "AllIntermediateMasterSlavesNotReplicating"
 	FirstTierSlaveFailingToConnectToMaster                             = "FirstTierSlaveFailingToConnectToMaster"
 	BinlogServerFailingToConnectToMaster                               = "BinlogServerFailingToConnectToMaster"
+	ReplicaSQLThreadDataInconsistency                                  = "ReplicaSQLThreadDataInconsistency"
+	DeadBinlogServer                                                   = "DeadBinlogServer"
+	DeadReplicationGroupPrimary                                        = "DeadReplicationGroupPrimary"
 )
 
 const (
@@ -65,6 +69,9 @@ const (
 	ErrantGTIDStructureWarning                                               = "ErrantGTIDStructureWarning"
 	NoFailoverSupportStructureWarning                                        = "NoFailoverSupportStructureWarning"
 	NoWriteableMasterStructureWarning                                        = "NoWriteableMasterStructureWarning"
+	BinlogVolumeAtRiskStructureWarning                                       = "BinlogVolumeAtRiskStructureWarning"
+	ClockSkewStructureWarning                                                = "ClockSkewStructureWarning"
+	PromotionCandidatesBelowMinimumStructureWarning                          = "PromotionCandidatesBelowMinimumStructureWarning"
 )
 
 type InstanceAnalysis struct {
@@ -96,6 +103,7 @@ type ReplicationAnalysisHints struct {
 const (
 	ForceMasterFailoverCommandHint    string = "force-master-failover"
 	ForceMasterTakeoverCommandHint    string = "force-master-takeover"
+	ForceMasterFailoverToCommandHint  string = "force-master-failover-to"
 	GracefulMasterTakeoverCommandHint string = "graceful-master-takeover"
 )
 
@@ -109,6 +117,7 @@ type ReplicationAnalysis struct {
 	AnalyzedInstancePhysicalEnvironment       string
 	IsMaster                                  bool
 	IsCoMaster                                bool
+	IsReplicationGroupMember                  bool
 	LastCheckValid                            bool
 	LastCheckPartialSuccess                   bool
 	CountReplicas                             uint
@@ -138,6 +147,8 @@ type ReplicationAnalysis struct {
 	CountDistinctMajorVersionsLoggingReplicas uint
 	CountDelayedReplicas                      uint
 	CountLaggingReplicas                      uint
+	CountClockSkewedReplicas                  uint
+	IsClockSkewed                             bool
 	IsActionableRecovery                      bool
 	ProcessingNodeHostname                    string
 	ProcessingNodeToken                       string
@@ -150,6 +161,10 @@ type ReplicationAnalysis struct {
 	MaxReplicaGTIDErrant                      string
 	CommandHint                               string
 	IsReadOnly                                bool
+	BinaryLogsSize                            int64
+	ReplicationBreakage                       *ReplicationBreakageHint
+	TransactionVerifiedReplicas               InstanceKeyMap // force-master-failover-to: replicas whose transactions the promoted replica is verified to have
+	RequestSpan                               *tracing.Span  `json:"-"` // the span of the API request which forced this analysis' recovery, if any
 }
 
 type AnalysisMap map[string](*ReplicationAnalysis)
@@ -177,6 +192,23 @@ func (this *ReplicationAnalysis) AnalysisString() string {
 	return strings.Join(result, ", ")
 }
 
+// isMasterDeathConfirmedByReplicas returns true unless DeadMasterReplicaFailureFraction is set, and fewer than that
+// fraction of the master's reachable replicas fail to connect to it. Replicas which still connect to the master
+// suggest orchestrator, rather than the master, is cut off.
+func (this *ReplicationAnalysis) isMasterDeathConfirmedByReplicas() bool {
+	if config.Config.DeadMasterReplicaFailureFraction == 0 {
+		return true
+	}
+	return float64(this.CountReplicasFailingToConnectToMaster) >= config.Config.DeadMasterReplicaFailureFraction*float64(this.CountValidReplicas)
+}
+
+// isReplicationLagUnreliable returns true when the clock of the analyzed instance, or of any of its replicas, is
+// skewed, or when a replica's time zone differs from the analyzed instance's. Lag computed by comparing timestamps
+// across these servers, as by ReplicationLagQuery, is then off by the skew.
+func (this *ReplicationAnalysis) isReplicationLagUnreliable() bool {
+	return this.IsClockSkewed || this.CountClockSkewedReplicas > 0
+}
+
 // ValidSecondsFromSeenToLastAttemptedCheck returns the maximum allowed elapsed time
 // between last_attempted_check to last_checked before we consider the instance as invalid.
 func ValidSecondsFromSeenToLastAttemptedCheck() uint {
@@ -55,7 +55,7 @@ func initializeAnalysisDaoPostConfiguration() {
 func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
 	result := []ReplicationAnalysis{}
 
-	args := sqlutils.Args(ValidSecondsFromSeenToLastAttemptedCheck(), config.Config.ReasonableReplicationLagSeconds, clusterName)
+	args := sqlutils.Args(ValidSecondsFromSeenToLastAttemptedCheck(), clusterName)
 	analysisQueryReductionClause := ``
 
 	if config.Config.ReduceReplicationAnalysisCount {
@@ -81,6 +81,10 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		            AND master_instance.slave_io_running = 0
 		            AND master_instance.last_io_error like '%error %connecting to master%'
 		          ) /* AS is_failing_to_connect_to_master */)
+				OR (MIN(
+		            master_instance.slave_sql_running = 0
+		            AND master_instance.last_sql_error != ''
+		          ) /* AS is_sql_thread_broken */)
 				OR (COUNT(replica_instance.server_id) /* AS count_replicas */ > 0)
 			`
 		args = append(args, ValidSecondsFromSeenToLastAttemptedCheck())
@@ -95,6 +99,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			MIN(master_instance.data_center) AS data_center,
 			MIN(master_instance.region) AS region,
 			MIN(master_instance.physical_environment) AS physical_environment,
+			MIN(master_instance.namespace) AS namespace,
 		        MIN(master_instance.master_host) AS master_host,
 		        MIN(master_instance.master_port) AS master_port,
 		        MIN(master_instance.cluster_name) AS cluster_name,
@@ -108,10 +113,12 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		            OR master_instance.master_port = 0
 								OR substr(master_instance.master_host, 1, 2) = '//') AS is_master,
 		        MIN(master_instance.is_co_master) AS is_co_master,
+		        MIN(master_instance.replication_group_name) AS replication_group_name,
 		        MIN(CONCAT(master_instance.hostname,
 		                ':',
 		                master_instance.port) = master_instance.cluster_name) AS is_cluster_master,
 						MIN(master_instance.gtid_mode) AS gtid_mode,
+						MIN(master_instance.binary_logs_size) AS binary_logs_size,
 		        COUNT(replica_instance.server_id) AS count_replicas,
 		        IFNULL(SUM(replica_instance.last_checked <= replica_instance.last_seen),
 		                0) AS count_valid_slaves,
@@ -131,6 +138,13 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		            AND master_instance.slave_io_running = 0
 		            AND master_instance.last_io_error like '%%error %%connecting to master%%'
 		          ) AS is_failing_to_connect_to_master,
+		        MIN(
+		            master_instance.slave_sql_running = 0
+		            AND master_instance.last_sql_error != ''
+		          ) AS is_sql_thread_broken,
+		        MIN(master_instance.last_sql_error) AS last_sql_error,
+		        MIN(master_instance.relay_log_file) AS relay_log_file,
+		        MIN(master_instance.relay_log_pos) AS relay_log_pos,
 						MIN(
 								master_downtime.downtime_active is not null
 								and ifnull(master_downtime.end_timestamp, now()) > now()
@@ -188,8 +202,13 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
               0) AS count_row_based_loggin_slaves,
 						IFNULL(SUM(replica_instance.sql_delay > 0),
               0) AS count_delayed_replicas,
-						IFNULL(SUM(replica_instance.slave_lag_seconds > ?),
+						IFNULL(SUM(replica_instance.replication_lag_pattern = 'sustained'),
               0) AS count_lagging_replicas,
+						IFNULL(SUM(replica_instance.last_checked <= replica_instance.last_seen
+								AND (ABS(replica_instance.clock_skew_seconds) > %d
+									OR replica_instance.time_zone_offset_seconds != master_instance.time_zone_offset_seconds)),
+              0) AS count_clock_skewed_replicas,
+						MIN(ABS(master_instance.clock_skew_seconds) > %d) AS is_clock_skewed,
 						IFNULL(MIN(replica_instance.gtid_mode), '')
               AS min_replica_gtid_mode,
 						IFNULL(MAX(replica_instance.gtid_mode), '')
@@ -243,7 +262,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			    is_master DESC ,
 			    is_cluster_master DESC,
 			    count_replicas DESC
-	`, analysisQueryReductionClause)
+	`, config.Config.ClockSkewThresholdSeconds, config.Config.ClockSkewThresholdSeconds, analysisQueryReductionClause)
 
 	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		a := ReplicationAnalysis{
@@ -254,6 +273,11 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 
 		a.IsMaster = m.GetBool("is_master")
 		a.IsCoMaster = m.GetBool("is_co_master")
+		a.IsReplicationGroupMember = (m.GetString("replication_group_name") != "")
+		if a.IsReplicationGroupMember && !m.GetBool("is_cluster_master") {
+			// Group members other than the group's primary replicate within the group, and are not masters
+			a.IsMaster = false
+		}
 		a.AnalyzedInstanceKey = InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
 		a.AnalyzedInstanceMasterKey = InstanceKey{Hostname: m.GetString("master_host"), Port: m.GetInt("master_port")}
 		a.AnalyzedInstanceDataCenter = m.GetString("data_center")
@@ -261,7 +285,9 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		a.AnalyzedInstancePhysicalEnvironment = m.GetString("physical_environment")
 		a.ClusterDetails.ClusterName = m.GetString("cluster_name")
 		a.ClusterDetails.ClusterAlias = m.GetString("cluster_alias")
+		a.ClusterDetails.Namespace = m.GetString("namespace")
 		a.GTIDMode = m.GetString("gtid_mode")
+		a.BinaryLogsSize = m.GetInt64("binary_logs_size")
 		a.LastCheckValid = m.GetBool("is_last_check_valid")
 		a.LastCheckPartialSuccess = m.GetBool("last_check_partial_success")
 		a.CountReplicas = m.GetUint("count_replicas")
@@ -300,9 +326,18 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 
 		a.CountDelayedReplicas = m.GetUint("count_delayed_replicas")
 		a.CountLaggingReplicas = m.GetUint("count_lagging_replicas")
+		if config.Config.ClockSkewThresholdSeconds > 0 {
+			a.CountClockSkewedReplicas = m.GetUint("count_clock_skewed_replicas")
+			a.IsClockSkewed = m.GetBool("is_clock_skewed")
+		}
 
 		a.IsReadOnly = m.GetUint("read_only") == 1
 
+		if m.GetBool("is_sql_thread_broken") {
+			a.ReplicationBreakage = ParseReplicationBreakageHint(m.GetString("last_sql_error"))
+		}
+		relaylogCoordinates := BinlogCoordinates{LogFile: m.GetString("relay_log_file"), LogPos: m.GetInt64("relay_log_pos"), Type: RelayLog}
+
 		if !a.LastCheckValid {
 			analysisMessage := fmt.Sprintf("analysis: IsMaster: %+v, LastCheckValid: %+v, LastCheckPartialSuccess: %+v, CountReplicas: %+v, CountValidReplicatingReplicas: %+v, CountLaggingReplicas: %+v, CountDelayedReplicas: %+v, ",
 				a.IsMaster, a.LastCheckValid, a.LastCheckPartialSuccess, a.CountReplicas, a.CountValidReplicatingReplicas, a.CountLaggingReplicas, a.CountDelayedReplicas,
@@ -311,10 +346,18 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				log.Debugf(analysisMessage)
 			}
 		}
-		if a.IsMaster && !a.LastCheckValid && a.CountReplicas == 0 {
+		if a.IsMaster && a.IsReplicationGroupMember && !a.LastCheckValid {
+			a.Analysis = DeadReplicationGroupPrimary
+			a.Description = "Replication group primary cannot be reached by orchestrator; the group is expected to elect a new primary"
+			//
+		} else if a.IsMaster && !a.LastCheckValid && a.CountReplicas == 0 {
 			a.Analysis = DeadMasterWithoutSlaves
 			a.Description = "Master cannot be reached by orchestrator and has no slave"
 			//
+		} else if a.IsMaster && !a.LastCheckValid && a.CountValidReplicas > 0 && a.CountValidReplicatingReplicas == 0 && !a.isMasterDeathConfirmedByReplicas() {
+			a.Analysis = UnreachableMaster
+			a.Description = "Master cannot be reached by orchestrator and none of its replicas is replicating, yet too few of its replicas fail to connect to it; possibly a network issue on orchestrator's side"
+			//
 		} else if a.IsMaster && !a.LastCheckValid && a.CountValidReplicas == a.CountReplicas && a.CountValidReplicatingReplicas == 0 {
 			a.Analysis = DeadMaster
 			a.Description = "Master cannot be reached by orchestrator and none of its replicas is replicating"
@@ -330,6 +373,9 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		} else if a.IsMaster && !a.LastCheckValid && a.CountLaggingReplicas == a.CountReplicas && a.CountDelayedReplicas < a.CountReplicas && a.CountValidReplicatingReplicas > 0 {
 			a.Analysis = UnreachableMasterWithLaggingReplicas
 			a.Description = "Master cannot be reached by orchestrator and all of its replicas are lagging"
+			if a.isReplicationLagUnreliable() {
+				a.Description = fmt.Sprintf("%s; replication lag is unreliable due to clock skew", a.Description)
+			}
 			//
 		} else if a.IsMaster && !a.LastCheckValid && !a.LastCheckPartialSuccess && a.CountValidReplicas > 0 && a.CountValidReplicatingReplicas > 0 {
 			a.Analysis = UnreachableMaster
@@ -351,6 +397,10 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			a.Analysis = AllMasterSlavesNotReplicatingOrDead
 			a.Description = "Master is reachable but none of its replicas is replicating"
 			//
+		} else /* binlog server */ if a.IsBinlogServer && !a.LastCheckValid && a.CountReplicas > 0 && a.CountValidReplicatingReplicas == 0 {
+			a.Analysis = DeadBinlogServer
+			a.Description = "Binlog server cannot be reached by orchestrator and none of its replicas is replicating"
+			//
 		} else /* co-master */ if a.IsCoMaster && !a.LastCheckValid && a.CountReplicas > 0 && a.CountValidReplicas == a.CountReplicas && a.CountValidReplicatingReplicas == 0 {
 			a.Analysis = DeadCoMaster
 			a.Description = "Co-master cannot be reached by orchestrator and none of its replicas is replicating"
@@ -412,6 +462,11 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			a.Analysis = FirstTierSlaveFailingToConnectToMaster
 			a.Description = "1st tier slave (directly replicating from topology master) is unable to connect to the master"
 			//
+		} else if !a.IsMaster && a.LastCheckValid && a.ReplicationBreakage != nil {
+			a.Analysis = ReplicaSQLThreadDataInconsistency
+			a.ReplicationBreakage = ReadInspectedReplicationBreakage(&a.AnalyzedInstanceKey, &relaylogCoordinates, a.ReplicationBreakage)
+			a.Description = fmt.Sprintf("Replica's SQL thread stopped on a data inconsistency error: %s", a.ReplicationBreakage.String())
+			//
 		}
 		//		 else if a.IsMaster && a.CountReplicas == 0 {
 		//			a.Analysis = MasterWithoutSlaves
@@ -488,6 +543,15 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			if a.IsMaster && a.IsReadOnly {
 				a.StructureAnalysis = append(a.StructureAnalysis, NoWriteableMasterStructureWarning)
 			}
+			if a.IsMaster && IsBinlogVolumeAtRisk(a.BinaryLogsSize) {
+				a.StructureAnalysis = append(a.StructureAnalysis, BinlogVolumeAtRiskStructureWarning)
+			}
+			if a.isReplicationLagUnreliable() {
+				a.StructureAnalysis = append(a.StructureAnalysis, ClockSkewStructureWarning)
+			}
+			if a.IsMaster && IsBelowMinimumPromotionCandidates(a.ClusterDetails.ClusterName, &a.AnalyzedInstanceKey) {
+				a.StructureAnalysis = append(a.StructureAnalysis, PromotionCandidatesBelowMinimumStructureWarning)
+			}
 
 		}
 		appendAnalysis(&a)
@@ -0,0 +1,26 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestIsMasterDeathConfirmedByReplicas(t *testing.T) {
+	defer func(fraction float64) { config.Config.DeadMasterReplicaFailureFraction = fraction }(config.Config.DeadMasterReplicaFailureFraction)
+
+	analysis := &ReplicationAnalysis{CountValidReplicas: 4, CountReplicasFailingToConnectToMaster: 1}
+	config.Config.DeadMasterReplicaFailureFraction = 0
+	test.S(t).ExpectTrue(analysis.isMasterDeathConfirmedByReplicas())
+
+	config.Config.DeadMasterReplicaFailureFraction = 0.5
+	test.S(t).ExpectFalse(analysis.isMasterDeathConfirmedByReplicas())
+	analysis.CountReplicasFailingToConnectToMaster = 2
+	test.S(t).ExpectTrue(analysis.isMasterDeathConfirmedByReplicas())
+
+	config.Config.DeadMasterReplicaFailureFraction = 1
+	test.S(t).ExpectFalse(analysis.isMasterDeathConfirmedByReplicas())
+	analysis.CountReplicasFailingToConnectToMaster = 4
+	test.S(t).ExpectTrue(analysis.isMasterDeathConfirmedByReplicas())
+}
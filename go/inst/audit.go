@@ -23,4 +23,6 @@ type Audit struct {
 	AuditType        string
 	AuditInstanceKey InstanceKey
 	Message          string
+	Owner            string
+	Reason           string
 }
@@ -20,12 +20,12 @@ import (
 	"fmt"
 	"github.com/github/orchestrator/go/config"
 	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/events"
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/sqlutils"
 	"github.com/rcrowley/go-metrics"
 	"log/syslog"
 	"os"
-	"time"
 )
 
 // syslogWriter is optional, and defaults to nil (disabled)
@@ -35,6 +35,7 @@ var auditOperationCounter = metrics.NewCounter()
 
 func init() {
 	metrics.Register("audit.write", auditOperationCounter)
+	events.AddHandler(auditEvent)
 }
 
 // EnableSyslogWriter enables, if possible, writes to syslog. These will execute _in addition_ to normal logging
@@ -46,8 +47,13 @@ func EnableAuditSyslog() (err error) {
 	return err
 }
 
-// AuditOperation creates and writes a new audit entry by given params
+// AuditOperation creates and writes a new audit entry by given params, attributed to the maintenance owner
 func AuditOperation(auditType string, instanceKey *InstanceKey, message string) error {
+	return AuditOperationBy(auditType, instanceKey, message, GetMaintenanceOwner(), "")
+}
+
+// AuditOperationBy creates and publishes a new audit entry by given params, attributed to given acting user and reason
+func AuditOperationBy(auditType string, instanceKey *InstanceKey, message string, owner string, reason string) error {
 	if instanceKey == nil {
 		instanceKey = &InstanceKey{}
 	}
@@ -55,6 +61,25 @@ func AuditOperation(auditType string, instanceKey *InstanceKey, message string)
 	if instanceKey.Hostname != "" {
 		clusterName, _ = GetClusterName(instanceKey)
 	}
+	return events.Publish(&events.Event{
+		Kind:        events.OutcomeEvent,
+		Type:        auditType,
+		Hostname:    instanceKey.Hostname,
+		Port:        instanceKey.Port,
+		ClusterName: clusterName,
+		Message:     message,
+		Owner:       owner,
+		Reason:      reason,
+	})
+}
+
+// auditEvent is the audit log's consumer of published events: outcome events are written to the audit
+// log file, backend database and syslog, as configured
+func auditEvent(event *events.Event) error {
+	if event.Kind != events.OutcomeEvent {
+		return nil
+	}
+	instanceKey := &InstanceKey{Hostname: event.Hostname, Port: event.Port}
 
 	auditWrittenToFile := false
 	if config.Config.AuditLogFile != "" {
@@ -66,7 +91,7 @@ func AuditOperation(auditType string, instanceKey *InstanceKey, message string)
 			}
 
 			defer f.Close()
-			text := fmt.Sprintf("%s\t%s\t%s\t%d\t[%s]\t%s\t\n", time.Now().Format(log.TimeFormat), auditType, instanceKey.Hostname, instanceKey.Port, clusterName, message)
+			text := fmt.Sprintf("%s\t%s\t%s\t%d\t[%s]\t%s\t%s\t%s\t\n", event.Timestamp.Format(log.TimeFormat), event.Type, instanceKey.Hostname, instanceKey.Port, event.ClusterName, event.Message, event.Owner, event.Reason)
 			if _, err = f.WriteString(text); err != nil {
 				return log.Errore(err)
 			}
@@ -77,22 +102,24 @@ func AuditOperation(auditType string, instanceKey *InstanceKey, message string)
 		_, err := db.ExecOrchestrator(`
 			insert
 				into audit (
-					audit_timestamp, audit_type, hostname, port, cluster_name, message
+					audit_timestamp, audit_type, hostname, port, cluster_name, message, owner, reason
 				) VALUES (
-					NOW(), ?, ?, ?, ?, ?
+					NOW(), ?, ?, ?, ?, ?, ?, ?
 				)
 			`,
-			auditType,
+			event.Type,
 			instanceKey.Hostname,
 			instanceKey.Port,
-			clusterName,
-			message,
+			event.ClusterName,
+			event.Message,
+			event.Owner,
+			event.Reason,
 		)
 		if err != nil {
 			return log.Errore(err)
 		}
 	}
-	logMessage := fmt.Sprintf("auditType:%s instance:%s cluster:%s message:%s", auditType, instanceKey.DisplayString(), clusterName, message)
+	logMessage := fmt.Sprintf("auditType:%s instance:%s cluster:%s message:%s owner:%s reason:%s", event.Type, instanceKey.DisplayString(), event.ClusterName, event.Message, event.Owner, event.Reason)
 	if syslogWriter != nil {
 		auditWrittenToFile = true
 		go func() {
@@ -123,7 +150,9 @@ func ReadRecentAudit(instanceKey *InstanceKey, page int) ([]Audit, error) {
 			audit_type,
 			hostname,
 			port,
-			message
+			message,
+			owner,
+			reason
 		from
 			audit
 		%s
@@ -141,6 +170,8 @@ func ReadRecentAudit(instanceKey *InstanceKey, page int) ([]Audit, error) {
 		audit.AuditInstanceKey.Hostname = m.GetString("hostname")
 		audit.AuditInstanceKey.Port = m.GetInt("port")
 		audit.Message = m.GetString("message")
+		audit.Owner = m.GetString("owner")
+		audit.Reason = m.GetString("reason")
 
 		res = append(res, audit)
 		return nil
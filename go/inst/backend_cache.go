@@ -0,0 +1,33 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/patrickmn/go-cache"
+)
+
+// readThroughCache returns the value cached under given key, or otherwise loads, caches and returns it.
+// Loaders return whatever they managed to read even on backend error, and that, too, is cached: a failing
+// backend is then queried once per cache expiry rather than on each call.
+func readThroughCache(c *cache.Cache, key string, load func() interface{}) interface{} {
+	if cached, found := c.Get(key); found {
+		return cached
+	}
+	value := load()
+	c.Set(key, value, cache.DefaultExpiration)
+	return value
+}
@@ -0,0 +1,30 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+	"github.com/patrickmn/go-cache"
+)
+
+func TestReadThroughCache(t *testing.T) {
+	c := cache.New(time.Minute, time.Minute)
+	loads := 0
+	load := func() interface{} {
+		loads++
+		return loads
+	}
+	test.S(t).ExpectEquals(readThroughCache(c, "k", load).(int), 1)
+	test.S(t).ExpectEquals(readThroughCache(c, "k", load).(int), 1)
+	test.S(t).ExpectEquals(loads, 1)
+
+	// A failed load is cached as well
+	failedLoad := func() interface{} {
+		loads++
+		return map[string]bool(nil)
+	}
+	test.S(t).ExpectEquals(len(readThroughCache(c, "failed", failedLoad).(map[string]bool)), 0)
+	test.S(t).ExpectEquals(len(readThroughCache(c, "failed", failedLoad).(map[string]bool)), 0)
+	test.S(t).ExpectEquals(loads, 2)
+}
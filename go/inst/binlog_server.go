@@ -0,0 +1,208 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/sqlutils"
+)
+
+// BinlogServer is an implementation of a binlog server: a proxy which replicates binary logs from its master and
+// serves them to replicas of its own, holding no data. orchestrator expects a binlog server to:
+// - replicate, i.e. always have SHOW SLAVE STATUS output
+// - mirror its master's binary log coordinates: its binary logs carry the master's file names and positions
+// - list its replicas via SHOW SLAVE HOSTS; it is not expected to support PROCESSLIST
+// - not be asked for SHOW BINLOG EVENTS; Pseudo-GTID matching never reads its binary logs
+// - be read-only and log replica updates
+// A detected binlog server's version is suffixed by its implementation name, e.g. "2.1.5-maxscale", by which
+// IsBinlogServer() tells it apart everywhere else.
+type BinlogServer interface {
+	// Name is the implementation name, suffixed to versions of detected instances
+	Name() string
+	// Detect returns the version of the server behind given connection, when it is of this implementation
+	Detect(db *sql.DB) (version string, detected bool, err error)
+}
+
+var binlogServersMutex sync.Mutex
+var binlogServers = map[string]BinlogServer{
+	"maxscale": &maxScaleBinlogServer{},
+}
+
+// RegisterBinlogServer makes a binlog server implementation known to discovery and to IsBinlogServer()
+func RegisterBinlogServer(binlogServer BinlogServer) {
+	binlogServersMutex.Lock()
+	defer binlogServersMutex.Unlock()
+	binlogServers[binlogServer.Name()] = binlogServer
+}
+
+// getBinlogServers returns the registered implementations, and those configured in BinlogServerDetectionQueries,
+// sorted by name
+func getBinlogServers() (implementations []BinlogServer) {
+	binlogServersMutex.Lock()
+	defer binlogServersMutex.Unlock()
+
+	for _, binlogServer := range binlogServers {
+		implementations = append(implementations, binlogServer)
+	}
+	for name, query := range config.Config.BinlogServerDetectionQueries {
+		if _, found := binlogServers[name]; !found {
+			implementations = append(implementations, &queryBinlogServer{name: name, query: query})
+		}
+	}
+	sort.Slice(implementations, func(i, j int) bool { return implementations[i].Name() < implementations[j].Name() })
+	return implementations
+}
+
+// binlogServerNameByVersion returns the implementation name of a binlog server with given version, or an empty
+// string when given version is not that of a binlog server
+func binlogServerNameByVersion(version string) string {
+	for _, binlogServer := range getBinlogServers() {
+		if strings.HasSuffix(version, "-"+binlogServer.Name()) {
+			return binlogServer.Name()
+		}
+	}
+	return ""
+}
+
+// maxScaleBinlogServer is the MaxScale binlog router
+type maxScaleBinlogServer struct{}
+
+func (this *maxScaleBinlogServer) Name() string {
+	return "maxscale"
+}
+
+func (this *maxScaleBinlogServer) Detect(db *sql.DB) (version string, detected bool, err error) {
+	if config.Config.SkipMaxScaleCheck {
+		return version, detected, err
+	}
+	err = sqlutils.QueryRowsMap(db, "show variables like 'maxscale%'", func(m sqlutils.RowMap) error {
+		if m.GetString("Variable_name") == "MAXSCALE_VERSION" {
+			version = m.GetString("Value")
+			if version == "" {
+				version = m.GetString("value")
+			}
+			if version == "" {
+				version = "0.0.0"
+			}
+			detected = true
+		}
+		return nil
+	})
+	return version, detected, err
+}
+
+// queryBinlogServer is an implementation configured in BinlogServerDetectionQueries, detected by its query
+// returning a version
+type queryBinlogServer struct {
+	name  string
+	query string
+}
+
+func (this *queryBinlogServer) Name() string {
+	return this.name
+}
+
+func (this *queryBinlogServer) Detect(db *sql.DB) (version string, detected bool, err error) {
+	err = sqlutils.QueryRowsMap(db, this.query, func(m sqlutils.RowMap) error {
+		for _, column := range m {
+			version = column.String
+		}
+		detected = true
+		return nil
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if detected && version == "" {
+		version = "0.0.0"
+	}
+	return version, detected, nil
+}
+
+// detectBinlogServer checks whether the server behind given connection is a binlog server of any known
+// implementation, and returns its suffixed version if so
+func detectBinlogServer(db *sql.DB) (version string, detected bool, err error) {
+	for _, binlogServer := range getBinlogServers() {
+		implementationVersion, implementationDetected, implementationErr := binlogServer.Detect(db)
+		if implementationErr != nil {
+			err = implementationErr
+			continue
+		}
+		if implementationDetected {
+			return fmt.Sprintf("%s-%s", implementationVersion, binlogServer.Name()), true, nil
+		}
+	}
+	return version, false, err
+}
+
+// BinlogServerConformanceCheck is the result of probing a binlog server for a single expectation
+type BinlogServerConformanceCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// ProbeBinlogServerConformance probes given instance for the expectations orchestrator has of binlog servers
+func ProbeBinlogServerConformance(instanceKey *InstanceKey) (checks []BinlogServerConformanceCheck, err error) {
+	sqlDB, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return checks, err
+	}
+	addCheck := func(name string, passed bool, message string) {
+		checks = append(checks, BinlogServerConformanceCheck{Name: name, Passed: passed, Message: message})
+	}
+
+	version, detected, err := detectBinlogServer(sqlDB)
+	if detected {
+		addCheck("detected", true, fmt.Sprintf("version %s", version))
+	} else {
+		addCheck("detected", false, fmt.Sprintf("not detected as any of the known binlog server implementations; error: %+v", err))
+	}
+
+	var masterLogFile string
+	var readMasterLogPos int64
+	replicates := false
+	err = sqlutils.QueryRowsMap(sqlDB, "show slave status", func(m sqlutils.RowMap) error {
+		masterLogFile = m.GetString("Master_Log_File")
+		readMasterLogPos = m.GetInt64("Read_Master_Log_Pos")
+		replicates = true
+		return nil
+	})
+	addCheck("replicates", replicates, fmt.Sprintf("show slave status: found=%t, error=%+v", replicates, err))
+
+	var selfLogFile string
+	var selfLogPos int64
+	err = sqlutils.QueryRowsMap(sqlDB, "show master status", func(m sqlutils.RowMap) error {
+		selfLogFile = m.GetString("File")
+		selfLogPos = m.GetInt64("Position")
+		return nil
+	})
+	mirrors := replicates && err == nil && selfLogFile != "" && selfLogFile == masterLogFile
+	addCheck("mirrors-coordinates", mirrors, fmt.Sprintf("own coordinates %s:%d; master's coordinates %s:%d; error=%+v", selfLogFile, selfLogPos, masterLogFile, readMasterLogPos, err))
+
+	err = sqlutils.QueryRowsMap(sqlDB, "show slave hosts", func(m sqlutils.RowMap) error { return nil })
+	addCheck("show-slave-hosts", err == nil, fmt.Sprintf("error=%+v", err))
+
+	return checks, nil
+}
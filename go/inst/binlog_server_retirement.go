@@ -0,0 +1,172 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/openark/golib/log"
+)
+
+// BinlogServerFamilyRetirement is the outcome of migrating the replicas of a binlog server family off the family
+type BinlogServerFamilyRetirement struct {
+	MasterKey       InstanceKey
+	DesignatedKey   *InstanceKey
+	BinlogServers   []InstanceKey
+	MovedReplicas   []InstanceKey
+	PendingReplicas []InstanceKey
+}
+
+// binlogServerFamilyMigrationOrder sorts the replicas of a binlog server family such that replicas furthest behind
+// migrate first, before their master may purge the binary logs they yet need
+func binlogServerFamilyMigrationOrder(replicas [](*Instance)) [](*Instance) {
+	ordered := make([](*Instance), len(replicas))
+	copy(ordered, replicas)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].ExecBinlogCoordinates.Equals(&ordered[j].ExecBinlogCoordinates) {
+			return ordered[i].Key.SmallerThan(&ordered[j].Key)
+		}
+		return ordered[i].ExecBinlogCoordinates.SmallerThan(&ordered[j].ExecBinlogCoordinates)
+	})
+	return ordered
+}
+
+// validateMigratedReplica returns an error when a replica does not replicate from the expected master following a
+// migration hop
+func validateMigratedReplica(replica *Instance, masterKey *InstanceKey) error {
+	if !replica.MasterKey.Equals(masterKey) {
+		return fmt.Errorf("%+v replicates from %+v, expected %+v", replica.Key, replica.MasterKey, *masterKey)
+	}
+	if replica.LastIOError != "" {
+		return fmt.Errorf("%+v IO thread error: %s", replica.Key, replica.LastIOError)
+	}
+	if replica.LastSQLError != "" {
+		return fmt.Errorf("%+v SQL thread error: %s", replica.Key, replica.LastSQLError)
+	}
+	if replica.ReplicationSQLThreadState.IsStopped() || replica.ReplicationIOThreadState.IsStopped() {
+		return fmt.Errorf("%+v replication is not running", replica.Key)
+	}
+	return nil
+}
+
+// readBinlogServerFamily reads the master of the binlog server family of given instance, which is either the master
+// or any member of the family, along with the family's binlog servers and their non binlog server replicas
+func readBinlogServerFamily(instanceKey *InstanceKey) (master *Instance, binlogServers [](*Instance), replicas [](*Instance), err error) {
+	master, err = ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for master.IsBinlogServer() {
+		if master, err = ReadTopologyInstance(&master.MasterKey); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	pendingKeys := []InstanceKey{master.Key}
+	for len(pendingKeys) > 0 {
+		parentKey := pendingKeys[0]
+		pendingKeys = pendingKeys[1:]
+		children, err := ReadReplicaInstances(&parentKey)
+		if err != nil {
+			return master, binlogServers, replicas, err
+		}
+		for _, child := range children {
+			if child.IsBinlogServer() {
+				binlogServers = append(binlogServers, child)
+				pendingKeys = append(pendingKeys, child.Key)
+			} else if !parentKey.Equals(&master.Key) {
+				replicas = append(replicas, child)
+			}
+		}
+	}
+	if len(binlogServers) == 0 {
+		return master, binlogServers, replicas, fmt.Errorf("%+v has no binlog servers", master.Key)
+	}
+	return master, binlogServers, replicas, nil
+}
+
+// RetireBinlogServerFamily migrates all replicas of a binlog server family, as identified by its master or any of
+// its binlog servers, off the family and onto direct replication from the master. Since binlog servers serve the
+// master's own binary logs, each replica is repointed at the master at its very coordinates; replicas furthest
+// behind go first. When designatedKey is given, replicas are then relocated below the designated intermediate
+// master. Each hop is validated; the migration halts on the first failure, listing the replicas yet to migrate.
+// The binlog servers themselves are left as they are, with no replicas, to be decommissioned.
+func RetireBinlogServerFamily(instanceKey *InstanceKey, designatedKey *InstanceKey, allowWAN bool) (retirement *BinlogServerFamilyRetirement, err error) {
+	return RetireBinlogServerFamilyContext(context.Background(), instanceKey, designatedKey, allowWAN)
+}
+
+// RetireBinlogServerFamilyContext is RetireBinlogServerFamily, bounded by given context
+func RetireBinlogServerFamilyContext(ctx context.Context, instanceKey *InstanceKey, designatedKey *InstanceKey, allowWAN bool) (retirement *BinlogServerFamilyRetirement, err error) {
+	master, binlogServers, replicas, err := readBinlogServerFamily(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	retirement = &BinlogServerFamilyRetirement{MasterKey: master.Key, DesignatedKey: designatedKey, BinlogServers: []InstanceKey{}, MovedReplicas: []InstanceKey{}, PendingReplicas: []InstanceKey{}}
+	for _, binlogServer := range binlogServers {
+		retirement.BinlogServers = append(retirement.BinlogServers, binlogServer.Key)
+	}
+	if designatedKey != nil {
+		designated, err := ReadTopologyInstance(designatedKey)
+		if err != nil {
+			return retirement, err
+		}
+		if designated.IsBinlogServer() {
+			return retirement, fmt.Errorf("retire-binlog-server-family: designated %+v is itself a binlog server", *designatedKey)
+		}
+	}
+	ordered := binlogServerFamilyMigrationOrder(replicas)
+	for _, replica := range ordered {
+		if designatedKey != nil && replica.Key.Equals(designatedKey) {
+			// The designated intermediate master migrates first, so as to take the others
+			retirement.PendingReplicas = append([]InstanceKey{replica.Key}, retirement.PendingReplicas...)
+			continue
+		}
+		retirement.PendingReplicas = append(retirement.PendingReplicas, replica.Key)
+	}
+	log.Infof("retire-binlog-server-family: migrating %d replicas of %d binlog servers onto %+v", len(ordered), len(binlogServers), master.Key)
+
+	for len(retirement.PendingReplicas) > 0 {
+		replicaKey := retirement.PendingReplicas[0]
+		// Binlog servers serve the master's binary logs: the replica's coordinates are valid on the master as they are
+		if _, err := RepointContext(ctx, &replicaKey, &master.Key, GTIDHintDeny); err != nil {
+			return retirement, fmt.Errorf("retire-binlog-server-family: failed repointing %+v to %+v: %+v", replicaKey, master.Key, err)
+		}
+		replica, err := ReadTopologyInstance(&replicaKey)
+		if err != nil {
+			return retirement, err
+		}
+		if err := validateMigratedReplica(replica, &master.Key); err != nil {
+			return retirement, fmt.Errorf("retire-binlog-server-family: %+v", err)
+		}
+		if designatedKey != nil && !replicaKey.Equals(designatedKey) && !designatedKey.Equals(&master.Key) {
+			if _, err := RelocateBelowContext(ctx, &replicaKey, designatedKey, allowWAN); err != nil {
+				return retirement, fmt.Errorf("retire-binlog-server-family: %+v now replicates from %+v, but failed relocating below %+v: %+v", replicaKey, master.Key, *designatedKey, err)
+			}
+			if replica, err = ReadTopologyInstance(&replicaKey); err != nil {
+				return retirement, err
+			}
+			if err := validateMigratedReplica(replica, designatedKey); err != nil {
+				return retirement, fmt.Errorf("retire-binlog-server-family: %+v", err)
+			}
+		}
+		retirement.PendingReplicas = retirement.PendingReplicas[1:]
+		retirement.MovedReplicas = append(retirement.MovedReplicas, replicaKey)
+	}
+	AuditOperation("retire-binlog-server-family", &master.Key, fmt.Sprintf("migrated %d replicas off binlog servers %+v", len(retirement.MovedReplicas), retirement.BinlogServers))
+	return retirement, nil
+}
@@ -0,0 +1,59 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestBinlogServerFamilyMigrationOrder(t *testing.T) {
+	newReplica := func(hostname string, logFile string, logPos int64) *Instance {
+		instance := &Instance{Key: InstanceKey{Hostname: hostname, Port: 3306}}
+		instance.ExecBinlogCoordinates = BinlogCoordinates{LogFile: logFile, LogPos: logPos}
+		return instance
+	}
+	replicas := [](*Instance){
+		newReplica("host1", "mysql-bin.000012", 400),
+		newReplica("host2", "mysql-bin.000011", 800),
+		newReplica("host4", "mysql-bin.000012", 100),
+		newReplica("host3", "mysql-bin.000012", 100),
+	}
+	ordered := binlogServerFamilyMigrationOrder(replicas)
+	test.S(t).ExpectEquals(len(ordered), 4)
+	test.S(t).ExpectEquals(ordered[0].Key.Hostname, "host2")
+	test.S(t).ExpectEquals(ordered[1].Key.Hostname, "host3")
+	test.S(t).ExpectEquals(ordered[2].Key.Hostname, "host4")
+	test.S(t).ExpectEquals(ordered[3].Key.Hostname, "host1")
+	// given replicas are not reordered
+	test.S(t).ExpectEquals(replicas[0].Key.Hostname, "host1")
+}
+
+func TestValidateMigratedReplica(t *testing.T) {
+	masterKey := InstanceKey{Hostname: "master", Port: 3306}
+	newReplica := func() *Instance {
+		return &Instance{
+			Key:                       InstanceKey{Hostname: "replica", Port: 3306},
+			MasterKey:                 masterKey,
+			ReplicationSQLThreadState: ReplicationThreadStateRunning,
+			ReplicationIOThreadState:  ReplicationThreadStateRunning,
+		}
+	}
+	{
+		test.S(t).ExpectNil(validateMigratedReplica(newReplica(), &masterKey))
+	}
+	{
+		replica := newReplica()
+		replica.MasterKey = InstanceKey{Hostname: "binlog-server", Port: 3306}
+		test.S(t).ExpectNotNil(validateMigratedReplica(replica, &masterKey))
+	}
+	{
+		replica := newReplica()
+		replica.LastIOError = "Could not find first log file name in binary log index file"
+		test.S(t).ExpectNotNil(validateMigratedReplica(replica, &masterKey))
+	}
+	{
+		replica := newReplica()
+		replica.ReplicationSQLThreadState = ReplicationThreadStateStopped
+		test.S(t).ExpectNotNil(validateMigratedReplica(replica, &masterKey))
+	}
+}
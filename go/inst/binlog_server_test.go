@@ -0,0 +1,67 @@
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+// fakeBinlogServer is a binlog server implementation which is never detected
+type fakeBinlogServer struct {
+	name string
+}
+
+func (this *fakeBinlogServer) Name() string {
+	return this.name
+}
+
+func (this *fakeBinlogServer) Detect(db *sql.DB) (version string, detected bool, err error) {
+	return "", false, nil
+}
+
+func TestBinlogServerNameByVersion(t *testing.T) {
+	defer func(queries map[string]string) { config.Config.BinlogServerDetectionQueries = queries }(config.Config.BinlogServerDetectionQueries)
+	defer func() {
+		binlogServersMutex.Lock()
+		defer binlogServersMutex.Unlock()
+		delete(binlogServers, "ripple")
+	}()
+
+	test.S(t).ExpectEquals(binlogServerNameByVersion("2.1.5-maxscale"), "maxscale")
+	test.S(t).ExpectEquals(binlogServerNameByVersion("5.7.30-log"), "")
+	test.S(t).ExpectEquals(binlogServerNameByVersion("1.0.0-ripple"), "")
+
+	RegisterBinlogServer(&fakeBinlogServer{name: "ripple"})
+	test.S(t).ExpectEquals(binlogServerNameByVersion("1.0.0-ripple"), "ripple")
+
+	config.Config.BinlogServerDetectionQueries = map[string]string{"mybinlogd": "select @@mybinlogd_version"}
+	test.S(t).ExpectEquals(binlogServerNameByVersion("0.3-mybinlogd"), "mybinlogd")
+	test.S(t).ExpectEquals(binlogServerNameByVersion("5.7.30-log"), "")
+}
+
+func TestIsBinlogServer(t *testing.T) {
+	defer func() {
+		binlogServersMutex.Lock()
+		defer binlogServersMutex.Unlock()
+		delete(binlogServers, "ripple")
+	}()
+
+	maxscale := &Instance{Version: "2.1.5-maxscale"}
+	maxscale.applyFlavorName()
+	test.S(t).ExpectTrue(maxscale.IsBinlogServer())
+	test.S(t).ExpectEquals(maxscale.FlavorName, "MaxScale")
+
+	ripple := &Instance{Version: "1.0.0-ripple"}
+	test.S(t).ExpectFalse(ripple.IsBinlogServer())
+	RegisterBinlogServer(&fakeBinlogServer{name: "ripple"})
+	ripple.applyFlavorName()
+	test.S(t).ExpectTrue(ripple.IsBinlogServer())
+	test.S(t).ExpectEquals(ripple.BinlogServerName(), "ripple")
+	test.S(t).ExpectEquals(ripple.FlavorName, "ripple")
+
+	mysql := &Instance{Version: "5.7.30-log"}
+	test.S(t).ExpectFalse(mysql.IsBinlogServer())
+	test.S(t).ExpectEquals(mysql.BinlogServerName(), "")
+}
@@ -0,0 +1,140 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/sqlutils"
+)
+
+// BinaryLogFile is a binary log file on a server, as listed by SHOW BINARY LOGS
+type BinaryLogFile struct {
+	Name string
+	Size int64
+}
+
+// BinlogPurgeAdvice suggests how far binary logs may be purged on a master, and how far they should be
+// purged so as to get its binlog volume usage back below BinlogVolumeWarningPercent
+type BinlogPurgeAdvice struct {
+	Key                  InstanceKey
+	BinaryLogsSize       int64
+	CapacityBytes        int64
+	AtRisk               bool
+	ExpiryConfigured     bool
+	SafePurgeToLogFile   string
+	SafeReclaimableBytes int64
+	PurgeToLogFile       string
+	ReclaimableBytes     int64
+}
+
+func showBinaryLogsSizes(db *sql.DB) (binaryLogs []BinaryLogFile, err error) {
+	err = sqlutils.QueryRowsMap(db, "show binary logs", func(m sqlutils.RowMap) error {
+		binaryLogs = append(binaryLogs, BinaryLogFile{Name: m.GetString("Log_name"), Size: m.GetInt64("File_size")})
+		return nil
+	})
+	return binaryLogs, err
+}
+
+// ShowBinaryLogsSizes lists binary logs on given instance along with their sizes
+func ShowBinaryLogsSizes(instanceKey *InstanceKey) (binaryLogs []BinaryLogFile, err error) {
+	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return binaryLogs, err
+	}
+	return showBinaryLogsSizes(db)
+}
+
+// binlogVolumeCapacityBytes returns the configured capacity of binlog volumes, or 0 when not configured
+func binlogVolumeCapacityBytes() int64 {
+	return int64(config.Config.BinlogVolumeCapacityMB) * 1024 * 1024
+}
+
+// binlogVolumeWarningBytes returns the binary logs size at which a binlog volume is considered at risk
+func binlogVolumeWarningBytes() int64 {
+	return binlogVolumeCapacityBytes() * int64(config.Config.BinlogVolumeWarningPercent) / 100
+}
+
+// IsBinlogVolumeAtRisk returns true when given total size of binary logs is at or above
+// BinlogVolumeWarningPercent of BinlogVolumeCapacityMB
+func IsBinlogVolumeAtRisk(binaryLogsSize int64) bool {
+	if binlogVolumeCapacityBytes() == 0 {
+		return false
+	}
+	return binaryLogsSize >= binlogVolumeWarningBytes()
+}
+
+// computeBinlogPurgeAdvice advises purging of given binary logs (oldest first). Logs older than
+// oldestNeededLogFile are safe to purge. When at risk, the advice is to purge the fewest logs that get
+// the total size below the warning size, within the safe limit.
+func computeBinlogPurgeAdvice(advice *BinlogPurgeAdvice, binaryLogs []BinaryLogFile, oldestNeededLogFile string, warningBytes int64) {
+	for _, binaryLog := range binaryLogs {
+		advice.BinaryLogsSize += binaryLog.Size
+	}
+	advice.AtRisk = advice.CapacityBytes > 0 && advice.BinaryLogsSize >= warningBytes
+	remainingSize := advice.BinaryLogsSize
+	for i, binaryLog := range binaryLogs {
+		if i == len(binaryLogs)-1 || binaryLog.Name >= oldestNeededLogFile {
+			// current binary log, or still needed by some replica
+			break
+		}
+		advice.SafePurgeToLogFile = binaryLogs[i+1].Name
+		advice.SafeReclaimableBytes += binaryLog.Size
+		if advice.AtRisk && remainingSize >= warningBytes {
+			advice.PurgeToLogFile = binaryLogs[i+1].Name
+			advice.ReclaimableBytes += binaryLog.Size
+			remainingSize -= binaryLog.Size
+		}
+	}
+}
+
+// ReadBinlogPurgeAdvice advises on purging binary logs on given master, based on its binlog volume usage
+// and on its replicas' progress. Purging itself is left to the user, see PurgeBinaryLogsTo.
+func ReadBinlogPurgeAdvice(instanceKey *InstanceKey) (*BinlogPurgeAdvice, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	if !instance.LogBinEnabled {
+		return nil, fmt.Errorf("binary logs are not enabled on %+v", *instanceKey)
+	}
+	binaryLogs, err := ShowBinaryLogsSizes(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	replicas, err := ReadReplicaInstances(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	oldestNeededLogFile := instance.SelfBinlogCoordinates.LogFile
+	for _, replica := range replicas {
+		if replica.ExecBinlogCoordinates.LogFile < oldestNeededLogFile {
+			oldestNeededLogFile = replica.ExecBinlogCoordinates.LogFile
+		}
+	}
+	advice := &BinlogPurgeAdvice{
+		Key:              *instanceKey,
+		CapacityBytes:    binlogVolumeCapacityBytes(),
+		ExpiryConfigured: instance.BinlogExpireSeconds > 0,
+	}
+	computeBinlogPurgeAdvice(advice, binaryLogs, oldestNeededLogFile, binlogVolumeWarningBytes())
+	return advice, nil
+}
@@ -0,0 +1,50 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+var testBinaryLogs = []BinaryLogFile{
+	{Name: "mysql-bin.000101", Size: 400},
+	{Name: "mysql-bin.000102", Size: 300},
+	{Name: "mysql-bin.000103", Size: 200},
+	{Name: "mysql-bin.000104", Size: 100},
+}
+
+func TestComputeBinlogPurgeAdviceNotAtRisk(t *testing.T) {
+	advice := &BinlogPurgeAdvice{CapacityBytes: 2000}
+	computeBinlogPurgeAdvice(advice, testBinaryLogs, "mysql-bin.000103", 1600)
+	test.S(t).ExpectEquals(advice.BinaryLogsSize, int64(1000))
+	test.S(t).ExpectFalse(advice.AtRisk)
+	test.S(t).ExpectEquals(advice.SafePurgeToLogFile, "mysql-bin.000103")
+	test.S(t).ExpectEquals(advice.SafeReclaimableBytes, int64(700))
+	test.S(t).ExpectEquals(advice.PurgeToLogFile, "")
+	test.S(t).ExpectEquals(advice.ReclaimableBytes, int64(0))
+}
+
+func TestComputeBinlogPurgeAdviceAtRisk(t *testing.T) {
+	advice := &BinlogPurgeAdvice{CapacityBytes: 1000}
+	computeBinlogPurgeAdvice(advice, testBinaryLogs, "mysql-bin.000104", 800)
+	test.S(t).ExpectTrue(advice.AtRisk)
+	test.S(t).ExpectEquals(advice.SafePurgeToLogFile, "mysql-bin.000104")
+	test.S(t).ExpectEquals(advice.SafeReclaimableBytes, int64(900))
+	test.S(t).ExpectEquals(advice.PurgeToLogFile, "mysql-bin.000102")
+	test.S(t).ExpectEquals(advice.ReclaimableBytes, int64(400))
+}
+
+func TestComputeBinlogPurgeAdviceLimitedByReplicas(t *testing.T) {
+	advice := &BinlogPurgeAdvice{CapacityBytes: 1000}
+	computeBinlogPurgeAdvice(advice, testBinaryLogs, "mysql-bin.000101", 500)
+	test.S(t).ExpectTrue(advice.AtRisk)
+	test.S(t).ExpectEquals(advice.SafePurgeToLogFile, "")
+	test.S(t).ExpectEquals(advice.PurgeToLogFile, "")
+}
+
+func TestComputeBinlogPurgeAdviceKeepsCurrentLog(t *testing.T) {
+	advice := &BinlogPurgeAdvice{CapacityBytes: 1000}
+	computeBinlogPurgeAdvice(advice, testBinaryLogs, "mysql-bin.000104", 50)
+	test.S(t).ExpectEquals(advice.PurgeToLogFile, "mysql-bin.000104")
+	test.S(t).ExpectEquals(advice.ReclaimableBytes, int64(900))
+}
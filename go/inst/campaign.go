@@ -0,0 +1,397 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/events"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+)
+
+type CampaignState string
+
+const (
+	CampaignRunning   CampaignState = "running"
+	CampaignCompleted               = "completed"
+	CampaignHalted                  = "halted"
+)
+
+type CampaignInstanceState string
+
+const (
+	CampaignInstancePending   CampaignInstanceState = "pending"
+	CampaignInstanceApplied                         = "applied"
+	CampaignInstanceUnchanged                       = "unchanged"
+	CampaignInstanceFailed                          = "failed"
+	CampaignInstanceSkipped                         = "skipped"
+)
+
+var campaignVariableRegexp = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+// CampaignInstance is the progress of a campaign on a single instance
+type CampaignInstance struct {
+	Key           InstanceKey
+	IsCanary      bool
+	State         CampaignInstanceState
+	PreviousValue string
+	Error         string
+}
+
+// Campaign rolls a change of a global variable across all instances matching a target: a cluster, a tag
+// expression, or both. Canary instances are changed first, one at a time; the remaining instances are then
+// changed in batches of Concurrency instances. The campaign halts when a canary fails, or when the share of
+// failed instances exceeds MaxErrorPercent.
+type Campaign struct {
+	UID                string
+	Name               string
+	Variable           string
+	Value              string
+	Persist            bool
+	TargetCluster      string
+	TargetTags         string
+	Concurrency        uint
+	CanaryCount        uint
+	MaxErrorPercent    uint
+	Owner              string
+	State              CampaignState
+	Message            string
+	StartedTimestamp   string
+	CompletedTimestamp string
+	Progress           map[CampaignInstanceState]int
+	Instances          [](*CampaignInstance)
+}
+
+// ReadCampaignDefinition reads a campaign definition, given as JSON
+func ReadCampaignDefinition(r io.Reader) (*Campaign, error) {
+	campaign := &Campaign{}
+	if err := json.NewDecoder(r).Decode(campaign); err != nil {
+		return nil, fmt.Errorf("Cannot parse campaign definition: %+v", err)
+	}
+	if campaign.Concurrency == 0 {
+		campaign.Concurrency = 1
+	}
+	return campaign, campaign.Validate()
+}
+
+// Validate checks this campaign's definition
+func (this *Campaign) Validate() error {
+	if this.Name == "" {
+		return fmt.Errorf("Campaign requires a name")
+	}
+	if !campaignVariableRegexp.MatchString(this.Variable) {
+		return fmt.Errorf("Campaign %s: invalid variable name: %s", this.Name, this.Variable)
+	}
+	if this.Value == "" {
+		return fmt.Errorf("Campaign %s: value must be provided", this.Name)
+	}
+	if this.TargetCluster == "" && this.TargetTags == "" {
+		return fmt.Errorf("Campaign %s: either TargetCluster or TargetTags must be provided", this.Name)
+	}
+	if this.MaxErrorPercent > 100 {
+		return fmt.Errorf("Campaign %s: MaxErrorPercent must be in the range [0, 100]", this.Name)
+	}
+	return nil
+}
+
+// IsDone returns true when the campaign requires no further attention
+func (this *Campaign) IsDone() bool {
+	return this.State == CampaignCompleted || this.State == CampaignHalted
+}
+
+// updateProgress counts the campaign's instances by state
+func (this *Campaign) updateProgress() {
+	this.Progress = make(map[CampaignInstanceState]int)
+	for _, campaignInstance := range this.Instances {
+		this.Progress[campaignInstance.State]++
+	}
+}
+
+// valueArg returns the campaign's value as a query argument. Numeric variables do not accept string values.
+func (this *Campaign) valueArg() interface{} {
+	if value, err := strconv.ParseInt(this.Value, 10, 64); err == nil {
+		return value
+	}
+	return this.Value
+}
+
+// campaignValuesEqual compares variable values, where booleans may read as ON/OFF or as 1/0
+func campaignValuesEqual(value string, other string) bool {
+	normalize := func(value string) string {
+		value = strings.ToUpper(strings.TrimSpace(value))
+		switch value {
+		case "ON", "TRUE":
+			return "1"
+		case "OFF", "FALSE":
+			return "0"
+		}
+		return value
+	}
+	return normalize(value) == normalize(other)
+}
+
+// campaignBatches splits given instances into the batches executed by a campaign: each canary on its own,
+// followed by batches of at most given concurrency
+func campaignBatches(instances [](*CampaignInstance), concurrency uint) (batches [][](*CampaignInstance)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var batch [](*CampaignInstance)
+	for _, campaignInstance := range instances {
+		if campaignInstance.IsCanary {
+			batches = append(batches, [](*CampaignInstance){campaignInstance})
+			continue
+		}
+		batch = append(batch, campaignInstance)
+		if len(batch) == int(concurrency) {
+			batches = append(batches, batch)
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// campaignErrorRateExceeded returns true when the share of failed instances, out of those attempted,
+// exceeds given percent
+func campaignErrorRateExceeded(instances [](*CampaignInstance), maxErrorPercent uint) bool {
+	attempted := 0
+	failed := 0
+	for _, campaignInstance := range instances {
+		switch campaignInstance.State {
+		case CampaignInstanceApplied, CampaignInstanceUnchanged:
+			attempted++
+		case CampaignInstanceFailed:
+			attempted++
+			failed++
+		}
+	}
+	return failed*100 > int(maxErrorPercent)*attempted
+}
+
+// sortCampaignTargets orders target instances such that the deepest replicas are changed first and
+// masters last
+func sortCampaignTargets(instances [](*Instance)) {
+	sort.SliceStable(instances, func(i, j int) bool {
+		if instances[i].ReplicationDepth != instances[j].ReplicationDepth {
+			return instances[i].ReplicationDepth > instances[j].ReplicationDepth
+		}
+		return instances[i].Key.SmallerThan(&instances[j].Key)
+	})
+}
+
+// resolveCampaignTargets reads the instances matched by the campaign's target cluster and tags
+func resolveCampaignTargets(campaign *Campaign) (targets [](*Instance), err error) {
+	var tagged *InstanceKeyMap
+	if campaign.TargetTags != "" {
+		if tagged, err = GetInstanceKeysByTags(campaign.TargetTags); err != nil {
+			return targets, err
+		}
+	}
+	if campaign.TargetCluster != "" {
+		clusterName, err := FigureClusterName(campaign.TargetCluster, nil, nil)
+		if err != nil {
+			return targets, err
+		}
+		clusterInstances, err := ReadClusterInstances(clusterName)
+		if err != nil {
+			return targets, err
+		}
+		for _, instance := range clusterInstances {
+			if tagged == nil || tagged.HasKey(instance.Key) {
+				targets = append(targets, instance)
+			}
+		}
+	} else {
+		for _, key := range tagged.GetInstanceKeys() {
+			instance, found, err := ReadInstance(&key)
+			if err != nil {
+				return targets, err
+			}
+			if found {
+				targets = append(targets, instance)
+			}
+		}
+	}
+	sortCampaignTargets(targets)
+	return targets, nil
+}
+
+// readGlobalVariable reads the global value of given variable on given instance
+func readGlobalVariable(instanceKey *InstanceKey, variable string) (value string, err error) {
+	if !campaignVariableRegexp.MatchString(variable) {
+		return value, fmt.Errorf("invalid variable name: %s", variable)
+	}
+	sqlDB, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return value, err
+	}
+	err = sqlDB.QueryRow(fmt.Sprintf("select ifnull(@@global.%s, '')", variable)).Scan(&value)
+	return value, err
+}
+
+// applyCampaignToInstance changes the campaign's variable on a single instance, and verifies the change
+func applyCampaignToInstance(campaign *Campaign, campaignInstance *CampaignInstance) {
+	fail := func(err error) {
+		campaignInstance.State = CampaignInstanceFailed
+		campaignInstance.Error = err.Error()
+	}
+	previousValue, err := readGlobalVariable(&campaignInstance.Key, campaign.Variable)
+	if err != nil {
+		fail(err)
+		return
+	}
+	campaignInstance.PreviousValue = previousValue
+	if campaignValuesEqual(previousValue, campaign.Value) {
+		campaignInstance.State = CampaignInstanceUnchanged
+		return
+	}
+	if *config.RuntimeCLIFlags.Noop {
+		fail(fmt.Errorf("noop: aborting campaign %s on %+v; signalling error but nothing went wrong.", campaign.Name, campaignInstance.Key))
+		return
+	}
+	scope := "global"
+	if campaign.Persist {
+		scope = "persist"
+	}
+	if _, err := ExecInstance(&campaignInstance.Key, fmt.Sprintf("set %s %s = ?", scope, campaign.Variable), campaign.valueArg()); err != nil {
+		fail(err)
+		return
+	}
+	value, err := readGlobalVariable(&campaignInstance.Key, campaign.Variable)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if !campaignValuesEqual(value, campaign.Value) {
+		fail(fmt.Errorf("%s is %s after change", campaign.Variable, value))
+		return
+	}
+	campaignInstance.State = CampaignInstanceApplied
+	AuditOperationBy("campaign", &campaignInstance.Key, fmt.Sprintf("campaign %s: %s changed from %s to %s", campaign.Name, campaign.Variable, previousValue, campaign.Value), campaign.Owner, "")
+}
+
+// StartCampaign resolves the campaign's targets and persists the campaign, then runs it, synchronously.
+// The campaign may be halted meanwhile via HaltCampaign; progress is persisted after each batch.
+func StartCampaign(campaign *Campaign) (*Campaign, error) {
+	if err := campaign.Validate(); err != nil {
+		return campaign, err
+	}
+	targets, err := resolveCampaignTargets(campaign)
+	if err != nil {
+		return campaign, err
+	}
+	if len(targets) == 0 {
+		return campaign, fmt.Errorf("Campaign %s: no instances match the target", campaign.Name)
+	}
+	campaign.UID = util.PrettyUniqueToken()
+	campaign.State = CampaignRunning
+	campaign.StartedTimestamp = time.Now().Format("2006-01-02 15:04:05")
+	campaign.Instances = [](*CampaignInstance){}
+	for i, instance := range targets {
+		campaign.Instances = append(campaign.Instances, &CampaignInstance{Key: instance.Key, IsCanary: i < int(campaign.CanaryCount), State: CampaignInstancePending})
+	}
+	if err := WriteCampaign(campaign); err != nil {
+		return campaign, err
+	}
+	AuditOperationBy("campaign", nil, fmt.Sprintf("started campaign %s (%s): set %s=%s on %d instances", campaign.Name, campaign.UID, campaign.Variable, campaign.Value, len(campaign.Instances)), campaign.Owner, "")
+	return runCampaign(campaign)
+}
+
+// runCampaign executes the pending instances of given campaign, batch by batch
+func runCampaign(campaign *Campaign) (*Campaign, error) {
+	halt := func(message string) {
+		campaign.State = CampaignHalted
+		campaign.Message = message
+		log.Errorf("Campaign %s: %s", campaign.Name, message)
+	}
+	// A halt request is only persisted, and must not be overwritten by the campaign's own progress
+	checkHaltRequest := func() {
+		if campaign.State == CampaignHalted {
+			return
+		}
+		if state, err := ReadCampaignState(campaign.UID); err != nil {
+			halt(fmt.Sprintf("cannot read campaign state: %+v", err))
+		} else if state == CampaignHalted {
+			halt("halted by request")
+		}
+	}
+	batches := campaignBatches(campaign.Instances, campaign.Concurrency)
+	for i, batch := range batches {
+		checkHaltRequest()
+		if campaign.State == CampaignHalted {
+			break
+		}
+		events.PublishProgress("campaign", "", fmt.Sprintf("campaign %s: batch %d/%d, %d instances", campaign.Name, i+1, len(batches), len(batch)))
+		barrier := make(chan bool)
+		for _, campaignInstance := range batch {
+			campaignInstance := campaignInstance
+			go func() {
+				defer func() { barrier <- true }()
+				ExecuteOnTopology(func() {
+					applyCampaignToInstance(campaign, campaignInstance)
+				})
+			}()
+		}
+		for range batch {
+			<-barrier
+		}
+		for _, campaignInstance := range batch {
+			if campaignInstance.IsCanary && campaignInstance.State == CampaignInstanceFailed {
+				halt(fmt.Sprintf("canary %s failed: %s", campaignInstance.Key.DisplayString(), campaignInstance.Error))
+			}
+		}
+		if campaign.State != CampaignHalted && campaignErrorRateExceeded(campaign.Instances, campaign.MaxErrorPercent) {
+			halt(fmt.Sprintf("error rate exceeds %d%%", campaign.MaxErrorPercent))
+		}
+		checkHaltRequest()
+		if err := WriteCampaign(campaign); err != nil {
+			log.Errore(err)
+		}
+	}
+	for _, campaignInstance := range campaign.Instances {
+		if campaignInstance.State == CampaignInstancePending {
+			campaignInstance.State = CampaignInstanceSkipped
+		}
+	}
+	if campaign.State != CampaignHalted {
+		campaign.State = CampaignCompleted
+	}
+	campaign.CompletedTimestamp = time.Now().Format("2006-01-02 15:04:05")
+	campaign.updateProgress()
+	if err := WriteCampaign(campaign); err != nil {
+		log.Errore(err)
+	}
+	AuditOperationBy("campaign", nil, fmt.Sprintf("campaign %s (%s) %s: %+v", campaign.Name, campaign.UID, campaign.State, campaign.Progress), campaign.Owner, "")
+	if campaign.State == CampaignHalted {
+		return campaign, fmt.Errorf("Campaign %s halted: %s", campaign.Name, campaign.Message)
+	}
+	return campaign, nil
+}
@@ -0,0 +1,246 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteCampaign creates or updates a campaign, along with the progress of each of its instances
+func WriteCampaign(campaign *Campaign) error {
+	campaign.updateProgress()
+	writeFunc := func() error {
+		completedTimestamp := interface{}(nil)
+		if campaign.CompletedTimestamp != "" {
+			completedTimestamp = campaign.CompletedTimestamp
+		}
+		_, err := db.ExecOrchestrator(`
+			insert into campaign (
+				uid, name, variable_name, variable_value, persist, target_cluster, target_tags,
+				concurrency, canary_count, max_error_percent, owner, state, message, started_timestamp, completed_timestamp
+			) values (
+				?, ?, ?, ?, ?, ?, ?,
+				?, ?, ?, ?, ?, ?, ?, ?
+			) on duplicate key update
+				state=values(state),
+				message=values(message),
+				completed_timestamp=values(completed_timestamp)
+			`, campaign.UID, campaign.Name, campaign.Variable, campaign.Value, campaign.Persist, campaign.TargetCluster, campaign.TargetTags,
+			campaign.Concurrency, campaign.CanaryCount, campaign.MaxErrorPercent, campaign.Owner, string(campaign.State), campaign.Message,
+			campaign.StartedTimestamp, completedTimestamp,
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+		for i, campaignInstance := range campaign.Instances {
+			_, err := db.ExecOrchestrator(`
+				insert into campaign_instance (
+					uid, hostname, port, position, is_canary, state, previous_value, error
+				) values (
+					?, ?, ?, ?, ?, ?, ?, ?
+				) on duplicate key update
+					state=values(state),
+					previous_value=values(previous_value),
+					error=values(error)
+				`, campaign.UID, campaignInstance.Key.Hostname, campaignInstance.Key.Port, i, campaignInstance.IsCanary,
+				string(campaignInstance.State), campaignInstance.PreviousValue, campaignInstance.Error,
+			)
+			if err != nil {
+				return log.Errore(err)
+			}
+		}
+		return nil
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// readCampaigns reads campaigns matching given condition, most recent first, without their instances
+func readCampaigns(condition string, args []interface{}) ([](*Campaign), error) {
+	res := [](*Campaign){}
+	query := fmt.Sprintf(`
+		select
+			uid,
+			name,
+			variable_name,
+			variable_value,
+			persist,
+			target_cluster,
+			target_tags,
+			concurrency,
+			canary_count,
+			max_error_percent,
+			owner,
+			state,
+			message,
+			started_timestamp,
+			ifnull(completed_timestamp, '') as completed_timestamp
+		from
+			campaign
+		where
+			%s
+		order by
+			started_timestamp desc
+		`, condition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		campaign := &Campaign{}
+		campaign.UID = m.GetString("uid")
+		campaign.Name = m.GetString("name")
+		campaign.Variable = m.GetString("variable_name")
+		campaign.Value = m.GetString("variable_value")
+		campaign.Persist = m.GetBool("persist")
+		campaign.TargetCluster = m.GetString("target_cluster")
+		campaign.TargetTags = m.GetString("target_tags")
+		campaign.Concurrency = m.GetUint("concurrency")
+		campaign.CanaryCount = m.GetUint("canary_count")
+		campaign.MaxErrorPercent = m.GetUint("max_error_percent")
+		campaign.Owner = m.GetString("owner")
+		campaign.State = CampaignState(m.GetString("state"))
+		campaign.Message = m.GetString("message")
+		campaign.StartedTimestamp = m.GetString("started_timestamp")
+		campaign.CompletedTimestamp = m.GetString("completed_timestamp")
+		campaign.Instances = [](*CampaignInstance){}
+
+		res = append(res, campaign)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// readCampaignInstances reads the progress of each of a campaign's instances, in order of execution
+func readCampaignInstances(uid string) ([](*CampaignInstance), error) {
+	res := [](*CampaignInstance){}
+	query := `
+		select
+			hostname,
+			port,
+			is_canary,
+			state,
+			previous_value,
+			error
+		from
+			campaign_instance
+		where
+			uid = ?
+		order by
+			position
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(uid), func(m sqlutils.RowMap) error {
+		campaignInstance := &CampaignInstance{}
+		campaignInstance.Key.Hostname = m.GetString("hostname")
+		campaignInstance.Key.Port = m.GetInt("port")
+		campaignInstance.IsCanary = m.GetBool("is_canary")
+		campaignInstance.State = CampaignInstanceState(m.GetString("state"))
+		campaignInstance.PreviousValue = m.GetString("previous_value")
+		campaignInstance.Error = m.GetString("error")
+
+		res = append(res, campaignInstance)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadCampaigns reads all campaigns, most recent first. Progress is summarized; instances are not listed.
+func ReadCampaigns() ([](*Campaign), error) {
+	campaigns, err := readCampaigns("1=1", sqlutils.Args())
+	if err != nil {
+		return campaigns, err
+	}
+	for _, campaign := range campaigns {
+		instances, err := readCampaignInstances(campaign.UID)
+		if err != nil {
+			return campaigns, err
+		}
+		campaign.Instances = instances
+		campaign.updateProgress()
+		campaign.Instances = [](*CampaignInstance){}
+	}
+	return campaigns, nil
+}
+
+// ReadCampaign reads a campaign, along with the progress of each of its instances
+func ReadCampaign(uid string) (*Campaign, error) {
+	campaigns, err := readCampaigns("uid = ?", sqlutils.Args(uid))
+	if err != nil {
+		return nil, err
+	}
+	if len(campaigns) == 0 {
+		return nil, fmt.Errorf("Campaign not found: %s", uid)
+	}
+	campaign := campaigns[0]
+	if campaign.Instances, err = readCampaignInstances(uid); err != nil {
+		return nil, err
+	}
+	campaign.updateProgress()
+	return campaign, nil
+}
+
+// ReadCampaignState reads the current state of a campaign
+func ReadCampaignState(uid string) (state CampaignState, err error) {
+	campaigns, err := readCampaigns("uid = ?", sqlutils.Args(uid))
+	if err != nil {
+		return state, err
+	}
+	if len(campaigns) == 0 {
+		return state, fmt.Errorf("Campaign not found: %s", uid)
+	}
+	return campaigns[0].State, nil
+}
+
+// HaltCampaign requests a running campaign to halt. The campaign halts before its next batch.
+func HaltCampaign(uid string) error {
+	writeFunc := func() error {
+		sqlResult, err := db.ExecOrchestrator(`
+			update campaign set state = ? where uid = ? and state = ?
+			`, string(CampaignHalted), uid, string(CampaignRunning),
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+		if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			return fmt.Errorf("Campaign %s is not running", uid)
+		}
+		return nil
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	return AuditOperation("halt-campaign", nil, fmt.Sprintf("requested campaign %s to halt", uid))
+}
+
+// ExpireCampaigns removes old campaigns
+func ExpireCampaigns() error {
+	writeFunc := func() error {
+		if _, err := db.ExecOrchestrator(`
+			delete from campaign where completed_timestamp < NOW() - INTERVAL ? DAY
+			`, config.AuditPurgeDays,
+		); err != nil {
+			return log.Errore(err)
+		}
+		_, err := db.ExecOrchestrator(`
+			delete from campaign_instance where uid not in (select uid from campaign)
+			`,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
@@ -0,0 +1,141 @@
+package inst
+
+import (
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func newTestCampaignInstances(canaries int, count int) (instances [](*CampaignInstance)) {
+	for i := 0; i < count; i++ {
+		instances = append(instances, &CampaignInstance{Key: InstanceKey{Hostname: "host", Port: 3306 + i}, IsCanary: i < canaries, State: CampaignInstancePending})
+	}
+	return instances
+}
+
+func TestReadCampaignDefinition(t *testing.T) {
+	{
+		campaign, err := ReadCampaignDefinition(strings.NewReader(`{"Name": "net-timeout", "Variable": "slave_net_timeout", "Value": "8", "TargetTags": "env=prod"}`))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(campaign.Variable, "slave_net_timeout")
+		test.S(t).ExpectEquals(campaign.Concurrency, uint(1))
+	}
+	{
+		_, err := ReadCampaignDefinition(strings.NewReader(`{"Name": "net-timeout"`))
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestCampaignValidate(t *testing.T) {
+	valid := Campaign{Name: "net-timeout", Variable: "slave_net_timeout", Value: "8", TargetCluster: "mycluster"}
+	test.S(t).ExpectNil(valid.Validate())
+	{
+		campaign := valid
+		campaign.Name = ""
+		test.S(t).ExpectNotNil(campaign.Validate())
+	}
+	{
+		campaign := valid
+		campaign.Variable = "slave_net_timeout = 0; drop table t"
+		test.S(t).ExpectNotNil(campaign.Validate())
+	}
+	{
+		campaign := valid
+		campaign.Value = ""
+		test.S(t).ExpectNotNil(campaign.Validate())
+	}
+	{
+		campaign := valid
+		campaign.TargetCluster = ""
+		test.S(t).ExpectNotNil(campaign.Validate())
+		campaign.TargetTags = "env=prod"
+		test.S(t).ExpectNil(campaign.Validate())
+	}
+	{
+		campaign := valid
+		campaign.MaxErrorPercent = 101
+		test.S(t).ExpectNotNil(campaign.Validate())
+	}
+}
+
+func TestCampaignValueArg(t *testing.T) {
+	campaign := Campaign{Value: "8"}
+	test.S(t).ExpectEquals(campaign.valueArg(), int64(8))
+	campaign.Value = "ON_PERMISSIVE"
+	test.S(t).ExpectEquals(campaign.valueArg(), "ON_PERMISSIVE")
+}
+
+func TestCampaignValuesEqual(t *testing.T) {
+	test.S(t).ExpectTrue(campaignValuesEqual("8", "8"))
+	test.S(t).ExpectTrue(campaignValuesEqual("ON", "1"))
+	test.S(t).ExpectTrue(campaignValuesEqual("0", "off"))
+	test.S(t).ExpectTrue(campaignValuesEqual("on_permissive", "ON_PERMISSIVE"))
+	test.S(t).ExpectFalse(campaignValuesEqual("ON", "ON_PERMISSIVE"))
+	test.S(t).ExpectFalse(campaignValuesEqual("60", "8"))
+}
+
+func TestCampaignBatches(t *testing.T) {
+	{
+		batches := campaignBatches(newTestCampaignInstances(2, 7), 2)
+		test.S(t).ExpectEquals(len(batches), 5)
+		test.S(t).ExpectEquals(len(batches[0]), 1)
+		test.S(t).ExpectTrue(batches[0][0].IsCanary)
+		test.S(t).ExpectEquals(len(batches[1]), 1)
+		test.S(t).ExpectTrue(batches[1][0].IsCanary)
+		test.S(t).ExpectEquals(len(batches[2]), 2)
+		test.S(t).ExpectEquals(len(batches[3]), 2)
+		test.S(t).ExpectEquals(len(batches[4]), 1)
+		test.S(t).ExpectEquals(batches[4][0].Key.Port, 3312)
+	}
+	{
+		batches := campaignBatches(newTestCampaignInstances(0, 3), 0)
+		test.S(t).ExpectEquals(len(batches), 3)
+	}
+	{
+		batches := campaignBatches(newTestCampaignInstances(0, 3), 10)
+		test.S(t).ExpectEquals(len(batches), 1)
+		test.S(t).ExpectEquals(len(batches[0]), 3)
+	}
+}
+
+func TestCampaignErrorRateExceeded(t *testing.T) {
+	instances := newTestCampaignInstances(0, 10)
+	test.S(t).ExpectFalse(campaignErrorRateExceeded(instances, 0))
+
+	instances[0].State = CampaignInstanceApplied
+	instances[1].State = CampaignInstanceUnchanged
+	instances[2].State = CampaignInstanceApplied
+	instances[3].State = CampaignInstanceFailed
+	test.S(t).ExpectTrue(campaignErrorRateExceeded(instances, 0))
+	test.S(t).ExpectTrue(campaignErrorRateExceeded(instances, 24))
+	test.S(t).ExpectFalse(campaignErrorRateExceeded(instances, 25))
+	test.S(t).ExpectFalse(campaignErrorRateExceeded(instances, 100))
+}
+
+func TestSortCampaignTargets(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	instancesMap[i710Key.StringCode()].ReplicationDepth = 0
+	instancesMap[i720Key.StringCode()].ReplicationDepth = 1
+	instancesMap[i730Key.StringCode()].ReplicationDepth = 1
+	instancesMap[i810Key.StringCode()].ReplicationDepth = 2
+	instancesMap[i820Key.StringCode()].ReplicationDepth = 2
+	instancesMap[i830Key.StringCode()].ReplicationDepth = 1
+	sortCampaignTargets(instances)
+
+	test.S(t).ExpectEquals(instances[0].Key, i810Key)
+	test.S(t).ExpectEquals(instances[1].Key, i820Key)
+	test.S(t).ExpectEquals(instances[2].Key, i720Key)
+	test.S(t).ExpectEquals(instances[5].Key, i710Key)
+}
+
+func TestCampaignUpdateProgress(t *testing.T) {
+	campaign := Campaign{Instances: newTestCampaignInstances(1, 4)}
+	campaign.Instances[0].State = CampaignInstanceApplied
+	campaign.Instances[1].State = CampaignInstanceFailed
+	campaign.updateProgress()
+	test.S(t).ExpectEquals(campaign.Progress[CampaignInstanceApplied], 1)
+	test.S(t).ExpectEquals(campaign.Progress[CampaignInstanceFailed], 1)
+	test.S(t).ExpectEquals(campaign.Progress[CampaignInstancePending], 2)
+	test.S(t).ExpectFalse(campaign.IsDone())
+}
@@ -27,6 +27,7 @@ type CandidateDatabaseInstance struct {
 	Hostname            string
 	Port                int
 	PromotionRule       CandidatePromotionRule
+	PromotionPriority   int // among candidates of the same rule, higher priority is preferred
 	LastSuggestedString string
 	PromotionRuleExpiry string // generated when retrieved from database for consistency reasons
 }
@@ -44,8 +45,16 @@ func (cdi *CandidateDatabaseInstance) WithCurrentTime() *CandidateDatabaseInstan
 	return cdi
 }
 
+func (cdi *CandidateDatabaseInstance) WithPromotionPriority(promotionPriority int) *CandidateDatabaseInstance {
+	cdi.PromotionPriority = promotionPriority
+	return cdi
+}
+
 // String returns a string representation of the CandidateDatabaseInstance struct
 func (cdi *CandidateDatabaseInstance) String() string {
+	if cdi.PromotionPriority != 0 {
+		return fmt.Sprintf("%s:%d %s:%d", cdi.Hostname, cdi.Port, cdi.PromotionRule, cdi.PromotionPriority)
+	}
 	return fmt.Sprintf("%s:%d %s", cdi.Hostname, cdi.Port, cdi.PromotionRule)
 }
 
@@ -31,23 +31,25 @@ func RegisterCandidateInstance(candidate *CandidateDatabaseInstance) error {
 	if candidate.LastSuggestedString == "" {
 		candidate = candidate.WithCurrentTime()
 	}
-	args := sqlutils.Args(candidate.Hostname, candidate.Port, string(candidate.PromotionRule), candidate.LastSuggestedString)
+	args := sqlutils.Args(candidate.Hostname, candidate.Port, string(candidate.PromotionRule), candidate.PromotionPriority, candidate.LastSuggestedString)
 
 	query := fmt.Sprintf(`
 			insert into candidate_database_instance (
 					hostname,
 					port,
 					promotion_rule,
+					promotion_priority,
 					last_suggested
 				) values (
-					?, ?, ?, ?
+					?, ?, ?, ?, ?
 				) on duplicate key update
 					last_suggested=values(last_suggested),
-					promotion_rule=values(promotion_rule)
+					promotion_rule=values(promotion_rule),
+					promotion_priority=values(promotion_priority)
 			`)
 	writeFunc := func() error {
 		_, err := db.ExecOrchestrator(query, args...)
-		AuditOperation("register-candidate", candidate.Key(), string(candidate.PromotionRule))
+		AuditOperation("register-candidate", candidate.Key(), fmt.Sprintf("%s priority=%d", candidate.PromotionRule, candidate.PromotionPriority))
 		return log.Errore(err)
 	}
 	return ExecDBWriteFunc(writeFunc)
@@ -87,6 +89,7 @@ func BulkReadCandidateDatabaseInstance() ([]CandidateDatabaseInstance, error) {
 			hostname,
 			port,
 			promotion_rule,
+			promotion_priority,
 			last_suggested,
 			last_suggested + INTERVAL ? MINUTE AS promotion_rule_expiry
 		FROM
@@ -97,6 +100,7 @@ func BulkReadCandidateDatabaseInstance() ([]CandidateDatabaseInstance, error) {
 			Hostname:            m.GetString("hostname"),
 			Port:                m.GetInt("port"),
 			PromotionRule:       CandidatePromotionRule(m.GetString("promotion_rule")),
+			PromotionPriority:   m.GetInt("promotion_priority"),
 			LastSuggestedString: m.GetString("last_suggested"),
 			PromotionRuleExpiry: m.GetString("promotion_rule_expiry"),
 		}
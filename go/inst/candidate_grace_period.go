@@ -0,0 +1,48 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// candidateGracePeriodRetryInterval is the interval between candidate selection retries within the grace period
+var candidateGracePeriodRetryInterval = time.Second
+
+// candidateGracePeriod returns the time for which candidate selection on given cluster is retried, while the chosen
+// candidate is behind a more up-to-date replica, as per CandidateGracePeriodSeconds and its per cluster overrides
+func candidateGracePeriod(clusterName string, clusterAlias string) time.Duration {
+	seconds, found := config.Config.ClusterCandidateGracePeriodSeconds[clusterName]
+	if !found && clusterAlias != "" {
+		seconds, found = config.Config.ClusterCandidateGracePeriodSeconds[clusterAlias]
+	}
+	if !found {
+		seconds = config.Config.CandidateGracePeriodSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// isBehindMostUpToDateReplica returns true when given candidate is behind the most up-to-date of given replicas,
+// which are sorted by preference, most up-to-date first
+func isBehindMostUpToDateReplica(candidateReplica *Instance, replicas [](*Instance)) bool {
+	if candidateReplica == nil || len(replicas) == 0 {
+		return false
+	}
+	return candidateReplica.ExecBinlogCoordinates.SmallerThan(&replicas[0].ExecBinlogCoordinates)
+}
@@ -0,0 +1,35 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestCandidateGracePeriod(t *testing.T) {
+	defer func(seconds uint, clusterSeconds config.GracePeriods) {
+		config.Config.CandidateGracePeriodSeconds = seconds
+		config.Config.ClusterCandidateGracePeriodSeconds = clusterSeconds
+	}(config.Config.CandidateGracePeriodSeconds, config.Config.ClusterCandidateGracePeriodSeconds)
+
+	config.Config.CandidateGracePeriodSeconds = 10
+	config.Config.ClusterCandidateGracePeriodSeconds = config.GracePeriods{"db-1:3306": 30, "payments": 0}
+
+	test.S(t).ExpectEquals(candidateGracePeriod("db-1:3306", "orders"), 30*time.Second)
+	test.S(t).ExpectEquals(candidateGracePeriod("db-2:3306", "payments"), time.Duration(0))
+	test.S(t).ExpectEquals(candidateGracePeriod("db-3:3306", "users"), 10*time.Second)
+	test.S(t).ExpectEquals(candidateGracePeriod("db-3:3306", ""), 10*time.Second)
+}
+
+func TestIsBehindMostUpToDateReplica(t *testing.T) {
+	mostUpToDate := &Instance{ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000010", LogPos: 200}}
+	behind := &Instance{ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000010", LogPos: 100}}
+	replicas := [](*Instance){mostUpToDate, behind}
+
+	test.S(t).ExpectTrue(isBehindMostUpToDateReplica(behind, replicas))
+	test.S(t).ExpectFalse(isBehindMostUpToDateReplica(mostUpToDate, replicas))
+	test.S(t).ExpectFalse(isBehindMostUpToDateReplica(nil, replicas))
+	test.S(t).ExpectFalse(isBehindMostUpToDateReplica(behind, [](*Instance){}))
+}
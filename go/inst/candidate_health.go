@@ -0,0 +1,184 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	goos "os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/os"
+	"github.com/openark/golib/log"
+)
+
+// CandidateHealth is the outcome of an external health check of a candidate replica
+type CandidateHealth struct {
+	Score  float64
+	Vetoed bool
+	Reason string
+}
+
+// candidateHealthCache keeps health check outcomes such that a single candidate selection, along with its
+// scoring, checks each replica once
+var candidateHealthCache = cache.New(time.Duration(config.Config.InstancePollSeconds)*time.Second, time.Second)
+
+// IsCandidateHealthCheckConfigured returns true when either CandidateHealthCheckCommand or CandidateHealthCheckURL
+// is configured
+func IsCandidateHealthCheckConfigured() bool {
+	return config.Config.CandidateHealthCheckCommand != "" || config.Config.CandidateHealthCheckURL != ""
+}
+
+// parseCandidateHealth parses the output of a health check: a score, or "veto"
+func parseCandidateHealth(output string) (*CandidateHealth, error) {
+	output = strings.TrimSpace(output)
+	if strings.EqualFold(output, "veto") {
+		return &CandidateHealth{Vetoed: true, Reason: "vetoed by health check"}, nil
+	}
+	score, err := strconv.ParseFloat(output, 64)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse health check output: %s", output)
+	}
+	return &CandidateHealth{Score: score}, nil
+}
+
+// candidateHealthCheckURL returns CandidateHealthCheckURL, with {host} and {port} substituted by given replica's
+func candidateHealthCheckURL(instanceKey *InstanceKey) string {
+	url := strings.Replace(config.Config.CandidateHealthCheckURL, "{host}", instanceKey.Hostname, -1)
+	return strings.Replace(url, "{port}", fmt.Sprintf("%d", instanceKey.Port), -1)
+}
+
+// runCandidateHealthCheck runs CandidateHealthCheckCommand, or else requests CandidateHealthCheckURL, for given
+// replica and returns its output
+func runCandidateHealthCheck(ctx context.Context, instanceKey *InstanceKey) (string, error) {
+	if config.Config.CandidateHealthCheckCommand != "" {
+		env := append(goos.Environ(),
+			fmt.Sprintf("ORC_CANDIDATE_HOST=%s", instanceKey.Hostname),
+			fmt.Sprintf("ORC_CANDIDATE_PORT=%d", instanceKey.Port),
+		)
+		output, err := os.CommandOutputContext(ctx, config.Config.CandidateHealthCheckCommand, env)
+		return string(output), err
+	}
+	req, err := http.NewRequest("GET", candidateHealthCheckURL(instanceKey), nil)
+	if err != nil {
+		return "", err
+	}
+	response, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", fmt.Errorf("health check responded with status %d", response.StatusCode)
+	}
+	return string(body), nil
+}
+
+// checkCandidateHealth runs the health check of given replica, within CandidateHealthCheckTimeoutSeconds
+func checkCandidateHealth(instanceKey *InstanceKey) (*CandidateHealth, error) {
+	timeout := time.Duration(config.Config.CandidateHealthCheckTimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type checkResult struct {
+		output string
+		err    error
+	}
+	results := make(chan checkResult, 1)
+	go func() {
+		output, err := runCandidateHealthCheck(ctx, instanceKey)
+		results <- checkResult{output: output, err: err}
+	}()
+	select {
+	case result := <-results:
+		if result.err != nil {
+			return nil, result.err
+		}
+		return parseCandidateHealth(result.output)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("health check timed out after %+v", timeout)
+	}
+}
+
+// ReadCandidateHealth returns the health of given replica, as per the configured health check. A failing health
+// check results as per CandidateHealthCheckFailurePolicy.
+func ReadCandidateHealth(instanceKey *InstanceKey) CandidateHealth {
+	if !IsCandidateHealthCheckConfigured() {
+		return CandidateHealth{}
+	}
+	if health, found := candidateHealthCache.Get(instanceKey.StringCode()); found {
+		return health.(CandidateHealth)
+	}
+	health, err := checkCandidateHealth(instanceKey)
+	if err != nil {
+		log.Errorf("ReadCandidateHealth: %+v: %+v", *instanceKey, err)
+		health = &CandidateHealth{}
+		if config.Config.CandidateHealthCheckFailurePolicy == "veto" {
+			health = &CandidateHealth{Vetoed: true, Reason: fmt.Sprintf("health check failed: %+v", err)}
+		}
+	}
+	candidateHealthCache.Set(instanceKey.StringCode(), *health, cache.DefaultExpiration)
+	return *health
+}
+
+// readCandidateHealths checks the health of given replicas concurrently
+func readCandidateHealths(replicas [](*Instance)) map[InstanceKey]CandidateHealth {
+	healths := make(map[InstanceKey]CandidateHealth)
+	if !IsCandidateHealthCheckConfigured() {
+		return healths
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, replica := range replicas {
+		wg.Add(1)
+		go func(instanceKey InstanceKey) {
+			defer wg.Done()
+			health := ReadCandidateHealth(&instanceKey)
+			mutex.Lock()
+			defer mutex.Unlock()
+			healths[instanceKey] = health
+		}(replica.Key)
+	}
+	wg.Wait()
+	return healths
+}
+
+// healthCandidateScorer prefers replicas scoring higher by the configured candidate health check
+type healthCandidateScorer struct{}
+
+func (this *healthCandidateScorer) ScoreCandidates(candidates [](*Instance)) (map[InstanceKey]float64, error) {
+	if !IsCandidateHealthCheckConfigured() {
+		return nil, fmt.Errorf("Neither CandidateHealthCheckCommand nor CandidateHealthCheckURL is configured")
+	}
+	scores := make(map[InstanceKey]float64)
+	for key, health := range readCandidateHealths(candidates) {
+		scores[key] = health.Score
+	}
+	return scores, nil
+}
@@ -0,0 +1,95 @@
+package inst
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseCandidateHealth(t *testing.T) {
+	health, err := parseCandidateHealth("42.5\n")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(health.Score, 42.5)
+	test.S(t).ExpectFalse(health.Vetoed)
+
+	health, err = parseCandidateHealth("VETO")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(health.Vetoed)
+
+	_, err = parseCandidateHealth("raid degraded")
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestCandidateHealthCheckURL(t *testing.T) {
+	defer func(url string) { config.Config.CandidateHealthCheckURL = url }(config.Config.CandidateHealthCheckURL)
+	config.Config.CandidateHealthCheckURL = "http://{host}:9000/health?port={port}"
+	test.S(t).ExpectEquals(candidateHealthCheckURL(&InstanceKey{Hostname: "db-1", Port: 3306}), "http://db-1:9000/health?port=3306")
+}
+
+func TestReadCandidateHealths(t *testing.T) {
+	defer func(url string, policy string) {
+		config.Config.CandidateHealthCheckURL = url
+		config.Config.CandidateHealthCheckFailurePolicy = policy
+		candidateHealthCache.Flush()
+	}(config.Config.CandidateHealthCheckURL, config.Config.CandidateHealthCheckFailurePolicy)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("port") {
+		case "1":
+			fmt.Fprint(w, "10")
+		case "2":
+			fmt.Fprint(w, "veto")
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	config.Config.CandidateHealthCheckURL = server.URL + "/health?port={port}"
+
+	replicas := [](*Instance){
+		{Key: InstanceKey{Hostname: "db-health", Port: 1}},
+		{Key: InstanceKey{Hostname: "db-health", Port: 2}},
+		{Key: InstanceKey{Hostname: "db-health", Port: 3}},
+	}
+	config.Config.CandidateHealthCheckFailurePolicy = "ignore"
+	candidateHealthCache.Flush()
+	healths := readCandidateHealths(replicas)
+	test.S(t).ExpectEquals(healths[replicas[0].Key].Score, float64(10))
+	test.S(t).ExpectTrue(healths[replicas[1].Key].Vetoed)
+	test.S(t).ExpectFalse(healths[replicas[2].Key].Vetoed)
+
+	config.Config.CandidateHealthCheckFailurePolicy = "veto"
+	candidateHealthCache.Flush()
+	healths = readCandidateHealths(replicas)
+	test.S(t).ExpectTrue(healths[replicas[2].Key].Vetoed)
+}
+
+func TestChooseCandidateReplicaHealthVeto(t *testing.T) {
+	defer func(url string) {
+		config.Config.CandidateHealthCheckURL = url
+		candidateHealthCache.Flush()
+	}(config.Config.CandidateHealthCheckURL)
+
+	instances, _ := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("host") == i830Key.Hostname {
+			fmt.Fprint(w, "veto")
+			return
+		}
+		fmt.Fprint(w, "0")
+	}))
+	defer server.Close()
+	config.Config.CandidateHealthCheckURL = server.URL + "/health?host={host}"
+	candidateHealthCache.Flush()
+
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, aheadReplicas, _, _, _, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i820Key)
+	test.S(t).ExpectEquals(len(aheadReplicas), 1)
+}
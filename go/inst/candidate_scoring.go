@@ -0,0 +1,181 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	goos "os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/os"
+	"github.com/openark/golib/log"
+)
+
+// CandidateScorer scores replicas as promotion candidates. Higher scores are preferred. Scorers are consulted
+// by name and weight per CandidateScoring, and only to choose among replicas which are equally valid and equally
+// up to date as candidates: a scorer never makes a recovery lose data.
+type CandidateScorer interface {
+	ScoreCandidates(candidates [](*Instance)) (scores map[InstanceKey]float64, err error)
+}
+
+var candidateScorersMutex sync.Mutex
+var candidateScorers = map[string]CandidateScorer{
+	"lag":        &lagCandidateScorer{},
+	"command":    &commandCandidateScorer{},
+	"reconnects": &reconnectsCandidateScorer{},
+	"health":     &healthCandidateScorer{},
+}
+
+// RegisterCandidateScorer makes a scorer available to CandidateScoring by given name
+func RegisterCandidateScorer(name string, scorer CandidateScorer) {
+	candidateScorersMutex.Lock()
+	defer candidateScorersMutex.Unlock()
+	candidateScorers[name] = scorer
+}
+
+func getCandidateScorer(name string) (scorer CandidateScorer, found bool) {
+	candidateScorersMutex.Lock()
+	defer candidateScorersMutex.Unlock()
+	scorer, found = candidateScorers[name]
+	return scorer, found
+}
+
+// lagCandidateScorer prefers replicas with lower replication lag
+type lagCandidateScorer struct{}
+
+func (this *lagCandidateScorer) ScoreCandidates(candidates [](*Instance)) (map[InstanceKey]float64, error) {
+	scores := make(map[InstanceKey]float64)
+	for _, candidate := range candidates {
+		scores[candidate.Key] = -float64(candidate.SlaveLagSeconds.Int64)
+	}
+	return scores, nil
+}
+
+// reconnectsCandidateScorer prefers replicas whose IO thread reconnected less often to its master, within
+// IOThreadReconnectsWindowSeconds: repeated reconnects suggest a flaky host or network
+type reconnectsCandidateScorer struct{}
+
+func (this *reconnectsCandidateScorer) ScoreCandidates(candidates [](*Instance)) (map[InstanceKey]float64, error) {
+	scores := make(map[InstanceKey]float64)
+	for _, candidate := range candidates {
+		scores[candidate.Key] = -float64(candidate.IOThreadReconnects)
+	}
+	return scores, nil
+}
+
+// commandCandidateScorer runs CandidateScoringCommand, which gets the candidates in ORC_CANDIDATES and
+// prints a "host:port score" line per candidate. Candidates it does not print score 0.
+type commandCandidateScorer struct{}
+
+func (this *commandCandidateScorer) ScoreCandidates(candidates [](*Instance)) (map[InstanceKey]float64, error) {
+	if config.Config.CandidateScoringCommand == "" {
+		return nil, fmt.Errorf("CandidateScoringCommand is not configured")
+	}
+	keys := []string{}
+	for _, candidate := range candidates {
+		keys = append(keys, candidate.Key.StringCode())
+	}
+	env := append(goos.Environ(), fmt.Sprintf("ORC_CANDIDATES=%s", strings.Join(keys, ",")))
+	output, err := os.CommandOutput(config.Config.CandidateScoringCommand, env)
+	if err != nil {
+		return nil, err
+	}
+	return parseCandidateScores(output)
+}
+
+// parseCandidateScores parses "host:port score" lines
+func parseCandidateScores(output []byte) (map[InstanceKey]float64, error) {
+	scores := make(map[InstanceKey]float64)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		tokens := strings.Fields(scanner.Text())
+		if len(tokens) == 0 {
+			continue
+		}
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("cannot parse candidate score: %s", scanner.Text())
+		}
+		key, err := ParseRawInstanceKey(tokens[0])
+		if err != nil {
+			return nil, err
+		}
+		score, err := strconv.ParseFloat(tokens[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse candidate score: %s", scanner.Text())
+		}
+		scores[*key] = score
+	}
+	return scores, scanner.Err()
+}
+
+// scoreCandidateReplicas returns the weighted sum of the scores of given candidates, per CandidateScoring.
+// A failing scorer is logged and otherwise ignored.
+func scoreCandidateReplicas(candidates [](*Instance)) map[InstanceKey]float64 {
+	totalScores := make(map[InstanceKey]float64)
+	for name, weight := range config.Config.CandidateScoring {
+		scorer, found := getCandidateScorer(name)
+		if !found {
+			log.Errorf("scoreCandidateReplicas: unknown candidate scorer: %s", name)
+			continue
+		}
+		scores, err := scorer.ScoreCandidates(candidates)
+		if err != nil {
+			log.Errorf("scoreCandidateReplicas: %s: %+v", name, err)
+			continue
+		}
+		for _, candidate := range candidates {
+			totalScores[candidate.Key] += weight * scores[candidate.Key]
+		}
+	}
+	return totalScores
+}
+
+// chooseScoredCandidateReplica chooses among given valid candidates, sorted by preference, the one to promote.
+// Only candidates which given sorter deems as preferable as the first are considered: as up to date, and
+// indifferent by all of the sorter's criteria. Among those, the highest scoring one is chosen.
+// Without CandidateScoring, this is the first candidate.
+func chooseScoredCandidateReplica(candidates [](*Instance), sorter *InstancesSorterByExec) *Instance {
+	first := candidates[0]
+	if len(config.Config.CandidateScoring) == 0 {
+		return first
+	}
+	equalCandidates := [](*Instance){}
+	for _, candidate := range candidates {
+		if sorter.isEquallyPreferred(candidate, first) {
+			equalCandidates = append(equalCandidates, candidate)
+		}
+	}
+	if len(equalCandidates) == 1 {
+		return first
+	}
+	scores := scoreCandidateReplicas(equalCandidates)
+	chosen := first
+	for _, candidate := range equalCandidates {
+		if scores[candidate.Key] > scores[chosen.Key] {
+			chosen = candidate
+		}
+	}
+	if chosen != first {
+		log.Infof("chooseCandidateReplica: %+v scores %.2f by CandidateScoring, preferred over %+v which scores %.2f", chosen.Key, scores[chosen.Key], first.Key, scores[first.Key])
+	}
+	return chosen
+}
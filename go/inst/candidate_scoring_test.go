@@ -0,0 +1,113 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+// keyCandidateScorer scores given keys by their position in its list
+type keyCandidateScorer struct {
+	keys []InstanceKey
+}
+
+func (this *keyCandidateScorer) ScoreCandidates(candidates [](*Instance)) (map[InstanceKey]float64, error) {
+	scores := make(map[InstanceKey]float64)
+	for i, key := range this.keys {
+		scores[key] = float64(len(this.keys) - i)
+	}
+	return scores, nil
+}
+
+func TestParseCandidateScores(t *testing.T) {
+	scores, err := parseCandidateScores([]byte("i710:3306 10\n\ni720:3306 -2.5\n"))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(scores), 2)
+	test.S(t).ExpectEquals(scores[i710Key], float64(10))
+	test.S(t).ExpectEquals(scores[i720Key], -2.5)
+
+	_, err = parseCandidateScores([]byte("i710:3306\n"))
+	test.S(t).ExpectNotNil(err)
+	_, err = parseCandidateScores([]byte("i710:3306 high\n"))
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestChooseCandidateReplicaScored(t *testing.T) {
+	defer func(scoring config.CandidateScorers) { config.Config.CandidateScoring = scoring }(config.Config.CandidateScoring)
+	RegisterCandidateScorer("test", &keyCandidateScorer{keys: []InstanceKey{i830Key, i720Key, i810Key}})
+
+	// generateInstances generates equally up to date replicas, other than those at given positions
+	generateInstances := func(positions map[InstanceKey]int64, mustNotPromote *InstanceKey) [](*Instance) {
+		instances, _ := generateTestInstances()
+		applyGeneralGoodToGoReplicationParams(instances)
+		for _, instance := range instances {
+			instance.ExecBinlogCoordinates = BinlogCoordinates{LogFile: "mysql.000008", LogPos: 20}
+			if position, ok := positions[instance.Key]; ok {
+				instance.ExecBinlogCoordinates.LogPos = position
+			}
+			if mustNotPromote != nil && instance.Key.Equals(mustNotPromote) {
+				instance.PromotionRule = MustNotPromoteRule
+			}
+		}
+		return sortedReplicas(instances, NoStopReplication)
+	}
+	{
+		config.Config.CandidateScoring = config.CandidateScorers{}
+		candidate, _, equalReplicas, _, _, err := chooseCandidateReplica(generateInstances(nil, nil))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectNotEquals(candidate.Key, i830Key)
+		test.S(t).ExpectEquals(len(equalReplicas), 5)
+	}
+	config.Config.CandidateScoring = config.CandidateScorers{"test": 1}
+	{
+		candidate, aheadReplicas, equalReplicas, _, _, err := chooseCandidateReplica(generateInstances(nil, nil))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(candidate.Key, i830Key)
+		test.S(t).ExpectEquals(len(aheadReplicas), 0)
+		test.S(t).ExpectEquals(len(equalReplicas), 5)
+	}
+	{
+		// the preferred i830 is banned; among equally up to date replicas, i720 scores highest
+		candidate, aheadReplicas, _, _, _, err := chooseCandidateReplica(generateInstances(nil, &i830Key))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(candidate.Key, i720Key)
+		test.S(t).ExpectEquals(len(aheadReplicas), 0)
+	}
+	{
+		// i830 is as up to date, but not logging replica updates: the sorter prefers the others, hence scores
+		// only choose among those
+		instances := generateInstances(nil, nil)
+		for _, instance := range instances {
+			if instance.Key.Equals(&i830Key) {
+				instance.LogSlaveUpdatesEnabled = false
+			}
+		}
+		instances = sortedReplicas(instances, NoStopReplication)
+		candidate, aheadReplicas, equalReplicas, _, cannotReplicateReplicas, err := chooseCandidateReplica(instances)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(candidate.Key, i720Key)
+		test.S(t).ExpectEquals(len(aheadReplicas), 0)
+		test.S(t).ExpectEquals(len(equalReplicas), 5)
+		test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
+	}
+	{
+		// a more up to date replica is chosen regardless of scores
+		candidate, aheadReplicas, _, laterReplicas, _, err := chooseCandidateReplica(generateInstances(map[InstanceKey]int64{i810Key: 40}, nil))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(candidate.Key, i810Key)
+		test.S(t).ExpectEquals(len(aheadReplicas), 0)
+		test.S(t).ExpectEquals(len(laterReplicas), 5)
+	}
+}
+
+func TestReconnectsCandidateScorer(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	instancesMap[i720Key.StringCode()].IOThreadReconnects = 3
+	instancesMap[i810Key.StringCode()].IOThreadReconnects = 1
+	scores, err := (&reconnectsCandidateScorer{}).ScoreCandidates(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(scores[i710Key], float64(0))
+	test.S(t).ExpectEquals(scores[i720Key], float64(-3))
+	test.S(t).ExpectEquals(scores[i810Key], float64(-1))
+}
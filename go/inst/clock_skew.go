@@ -0,0 +1,88 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// clockSkewWarnings holds the instances recently audited as clock skewed, such that each is audited at most once an hour
+var clockSkewWarnings = cache.New(time.Hour, time.Minute)
+
+// unixSeconds returns given time as fractional seconds since the epoch
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+// clockSkewSeconds returns the skew of an instance's clock, in whole seconds, given the instance's unix time and
+// orchestrator's time just before and just after reading it. The instance's unix time is truncated to the second, and
+// was read at some point between before and after: a skew within these bounds is none at all.
+func clockSkewSeconds(instanceUnixtime int64, before time.Time, after time.Time) int64 {
+	lowest := float64(instanceUnixtime) - unixSeconds(after)
+	highest := float64(instanceUnixtime+1) - unixSeconds(before)
+	if lowest > 0 {
+		return int64(math.Floor(lowest))
+	}
+	if highest < 0 {
+		return int64(math.Ceil(highest))
+	}
+	return 0
+}
+
+// isClockSkewed returns true when given skew exceeds ClockSkewThresholdSeconds
+func isClockSkewed(skewSeconds int64) bool {
+	if config.Config.ClockSkewThresholdSeconds == 0 {
+		return false
+	}
+	if skewSeconds < 0 {
+		skewSeconds = -skewSeconds
+	}
+	return skewSeconds > int64(config.Config.ClockSkewThresholdSeconds)
+}
+
+// readClockSkew compares the instance's clock with orchestrator's, and reads the instance's time zone offset from UTC
+func readClockSkew(db *sql.DB, instance *Instance) error {
+	var instanceUnixtime int64
+	before := time.Now()
+	err := db.QueryRow("select unix_timestamp(), timestampdiff(second, utc_timestamp(), now())").Scan(&instanceUnixtime, &instance.TimeZoneOffsetSeconds)
+	if err != nil {
+		return err
+	}
+	instance.ClockSkewSeconds = clockSkewSeconds(instanceUnixtime, before, time.Now())
+	return nil
+}
+
+// evaluateClockSkew flags the instance as clock skewed as per ClockSkewThresholdSeconds. A skewed clock corrupts lag
+// computed by ReplicationLagQuery, as well as timestamps the instance reports; it is audited at most once an hour.
+func (this *Instance) evaluateClockSkew() {
+	this.IsClockSkewed = isClockSkewed(this.ClockSkewSeconds)
+	if !this.IsClockSkewed {
+		return
+	}
+	if err := clockSkewWarnings.Add(this.Key.StringCode(), true, cache.DefaultExpiration); err != nil {
+		// Recently audited
+		return
+	}
+	AuditOperation("clock-skew", &this.Key, fmt.Sprintf("clock differs from orchestrator's by %+ds, exceeding %ds; replication lag and timestamps are unreliable", this.ClockSkewSeconds, config.Config.ClockSkewThresholdSeconds))
+}
@@ -0,0 +1,33 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestClockSkewSeconds(t *testing.T) {
+	before := time.Unix(1000, 200*int64(time.Millisecond))
+	after := before.Add(300 * time.Millisecond)
+
+	test.S(t).ExpectEquals(clockSkewSeconds(1000, before, after), int64(0))
+	test.S(t).ExpectEquals(clockSkewSeconds(999, before, after), int64(0))
+	test.S(t).ExpectEquals(clockSkewSeconds(1001, before, after), int64(0))
+	test.S(t).ExpectEquals(clockSkewSeconds(1010, before, after), int64(9))
+	test.S(t).ExpectEquals(clockSkewSeconds(990, before, after), int64(-9))
+}
+
+func TestIsClockSkewed(t *testing.T) {
+	defer func(threshold uint) { config.Config.ClockSkewThresholdSeconds = threshold }(config.Config.ClockSkewThresholdSeconds)
+
+	config.Config.ClockSkewThresholdSeconds = 5
+	test.S(t).ExpectFalse(isClockSkewed(5))
+	test.S(t).ExpectFalse(isClockSkewed(-5))
+	test.S(t).ExpectTrue(isClockSkewed(6))
+	test.S(t).ExpectTrue(isClockSkewed(-6))
+
+	config.Config.ClockSkewThresholdSeconds = 0
+	test.S(t).ExpectFalse(isClockSkewed(3600))
+}
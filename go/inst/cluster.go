@@ -82,7 +82,9 @@ type ClusterInfo struct {
 	ClusterName                            string
 	ClusterAlias                           string // Human friendly alias
 	ClusterDomain                          string // CNAME/VIP/A-record/whatever of the master of this cluster
+	Namespace                              string // Environment namespace, e.g. prod, staging
 	CountInstances                         uint
+	CountBinlogEncryptedInstances          uint // Instances with binlog_encryption (or encrypt_binlog) enabled. Mixed when neither 0 nor CountInstances
 	HeuristicLag                           int64
 	HasAutomatedMasterRecovery             bool
 	HasAutomatedIntermediateMasterRecovery bool
@@ -90,8 +92,18 @@ type ClusterInfo struct {
 
 // ReadRecoveryInfo
 func (this *ClusterInfo) ReadRecoveryInfo() {
-	this.HasAutomatedMasterRecovery = this.filtersMatchCluster(config.Config.RecoverMasterClusterFilters)
-	this.HasAutomatedIntermediateMasterRecovery = this.filtersMatchCluster(config.Config.RecoverIntermediateMasterClusterFilters)
+	recoverMasterClusterFilters := config.Config.RecoverMasterClusterFilters
+	recoverIntermediateMasterClusterFilters := config.Config.RecoverIntermediateMasterClusterFilters
+	if policy, found := namespacePolicy(this.Namespace); found {
+		if policy.RecoverMasterClusterFilters != nil {
+			recoverMasterClusterFilters = policy.RecoverMasterClusterFilters
+		}
+		if policy.RecoverIntermediateMasterClusterFilters != nil {
+			recoverIntermediateMasterClusterFilters = policy.RecoverIntermediateMasterClusterFilters
+		}
+	}
+	this.HasAutomatedMasterRecovery = this.filtersMatchCluster(recoverMasterClusterFilters)
+	this.HasAutomatedIntermediateMasterRecovery = this.filtersMatchCluster(recoverIntermediateMasterClusterFilters)
 }
 
 // filtersMatchCluster will see whether the given filters match the given cluster details
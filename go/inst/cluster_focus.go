@@ -0,0 +1,93 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// ClusterFocus puts a cluster in focus mode, e.g. for the duration of an incident. Until it expires, the cluster's
+// instances are polled every FocusModePollSeconds by the light probe set, a sample of each instance is recorded
+// per interval, and the cluster's topology changes are published per interval and in finer detail.
+type ClusterFocus struct {
+	ClusterName    string
+	Owner          string
+	Reason         string
+	Duration       time.Duration
+	BeginTimestamp string
+	EndTimestamp   string
+}
+
+func NewClusterFocus(clusterName string, owner string, reason string, duration time.Duration) *ClusterFocus {
+	if duration == 0 {
+		duration = time.Duration(config.Config.FocusModeDefaultMinutes) * time.Minute
+	}
+	return &ClusterFocus{
+		ClusterName: clusterName,
+		Owner:       owner,
+		Reason:      reason,
+		Duration:    duration,
+	}
+}
+
+func (this *ClusterFocus) String() string {
+	return fmt.Sprintf("%s: in focus until %s; owner: %s, reason: %s", this.ClusterName, this.EndTimestamp, this.Owner, this.Reason)
+}
+
+// Validate checks this focus is applicable
+func (this *ClusterFocus) Validate() error {
+	if this.ClusterName == "" {
+		return fmt.Errorf("Cluster focus requires a cluster name")
+	}
+	if this.Duration <= 0 {
+		return fmt.Errorf("Cluster focus of %s: duration must be positive", this.ClusterName)
+	}
+	return nil
+}
+
+// FocusSample is the state of an instance of a cluster in focus mode, as recorded once per FocusModePollSeconds
+type FocusSample struct {
+	Key                   InstanceKey
+	ClusterName           string
+	SampledTimestamp      string
+	LastChecked           string
+	IsLastCheckValid      bool
+	MasterKey             InstanceKey
+	Slave_IO_Running      bool
+	Slave_SQL_Running     bool
+	SlaveLagSeconds       sql.NullInt64
+	SelfBinlogCoordinates BinlogCoordinates
+	ExecBinlogCoordinates BinlogCoordinates
+	LastIOError           string
+	LastSQLError          string
+}
+
+// applyClusterFocus has given instances, which belong to clusters in focus mode, polled every FocusModePollSeconds
+// by the light probe set, unless their own poll override has them polled at least as frequently
+func applyClusterFocus(effective map[InstanceKey]*PollOverride, focusedKeys []InstanceKey) map[InstanceKey]*PollOverride {
+	for _, key := range focusedKeys {
+		if current, found := effective[key]; found && effectivePollSeconds(current) <= config.Config.FocusModePollSeconds {
+			continue
+		}
+		effective[key] = NewInstancePollOverride(&key, config.Config.FocusModePollSeconds, ProbeSetLight)
+	}
+	return effective
+}
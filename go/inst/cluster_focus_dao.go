@@ -0,0 +1,293 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteClusterFocus puts a cluster in focus mode, or renews its focus, for the focus' duration as of now
+func WriteClusterFocus(focus *ClusterFocus) error {
+	if err := focus.Validate(); err != nil {
+		return err
+	}
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into cluster_focus (
+				cluster_name, owner, reason, begin_timestamp, end_timestamp
+			) values (
+				?, ?, ?, NOW(), NOW() + INTERVAL ? SECOND
+			) on duplicate key update
+				owner=values(owner),
+				reason=values(reason),
+				begin_timestamp=values(begin_timestamp),
+				end_timestamp=values(end_timestamp)
+			`, focus.ClusterName, focus.Owner, focus.Reason, int(focus.Duration.Seconds()),
+		)
+		pollOverridesCache.Flush()
+		return log.Errore(err)
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	AuditOperation("begin-cluster-focus", nil, fmt.Sprintf("%s in focus for %+v; owner: %s, reason: %s", focus.ClusterName, focus.Duration, focus.Owner, focus.Reason))
+	return nil
+}
+
+// DeleteClusterFocus ends the focus mode of a cluster
+func DeleteClusterFocus(clusterName string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from cluster_focus where cluster_name = ?
+			`, clusterName,
+		)
+		pollOverridesCache.Flush()
+		return log.Errore(err)
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	AuditOperation("end-cluster-focus", nil, fmt.Sprintf("%s no longer in focus", clusterName))
+	return nil
+}
+
+// ReadClusterFocuses reads the unexpired focus of given cluster, or of all clusters when clusterName is empty
+func ReadClusterFocuses(clusterName string) ([]*ClusterFocus, error) {
+	res := []*ClusterFocus{}
+	query := `
+		select
+			cluster_name,
+			owner,
+			reason,
+			begin_timestamp,
+			end_timestamp
+		from
+			cluster_focus
+		where
+			end_timestamp > NOW()
+			and (cluster_name = ? or ? = '')
+		order by
+			cluster_name
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName, clusterName), func(m sqlutils.RowMap) error {
+		focus := &ClusterFocus{}
+		focus.ClusterName = m.GetString("cluster_name")
+		focus.Owner = m.GetString("owner")
+		focus.Reason = m.GetString("reason")
+		focus.BeginTimestamp = m.GetString("begin_timestamp")
+		focus.EndTimestamp = m.GetString("end_timestamp")
+
+		res = append(res, focus)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ClusterFocusOutlasts returns true when given cluster is in focus mode for at least given duration as of now
+func ClusterFocusOutlasts(clusterName string, duration time.Duration) (outlasts bool, err error) {
+	query := `
+		select
+			count(*) as count_focus
+		from
+			cluster_focus
+		where
+			cluster_name = ?
+			and end_timestamp >= NOW() + INTERVAL ? SECOND
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(clusterName, int(duration.Seconds())), func(m sqlutils.RowMap) error {
+		outlasts = m.GetInt("count_focus") > 0
+		return nil
+	})
+	return outlasts, log.Errore(err)
+}
+
+// readFocusedClusterNames reads the names of clusters in focus mode
+func readFocusedClusterNames() (map[string]bool, error) {
+	clusterNames := make(map[string]bool)
+	query := `
+		select
+			cluster_name
+		from
+			cluster_focus
+		where
+			end_timestamp > NOW()
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		clusterNames[m.GetString("cluster_name")] = true
+		return nil
+	})
+	return clusterNames, log.Errore(err)
+}
+
+// focusedClusterNames returns the names of clusters in focus mode. These are cached briefly, as they are consulted
+// every FocusModePollSeconds.
+func focusedClusterNames() map[string]bool {
+	return readThroughCache(pollOverridesCache, "focused-clusters", func() interface{} {
+		clusterNames, _ := readFocusedClusterNames()
+		return clusterNames
+	}).(map[string]bool)
+}
+
+// HasFocusedClusters returns true when any cluster is in focus mode
+func HasFocusedClusters() bool {
+	return len(focusedClusterNames()) > 0
+}
+
+// readFocusedInstanceKeys reads the keys of instances of clusters in focus mode
+func readFocusedInstanceKeys() ([]InstanceKey, error) {
+	res := []InstanceKey{}
+	query := `
+		select
+			database_instance.hostname,
+			database_instance.port
+		from
+			database_instance
+			join cluster_focus on (database_instance.cluster_name = cluster_focus.cluster_name)
+		where
+			cluster_focus.end_timestamp > NOW()
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		res = append(res, InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")})
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// RecordClusterFocusSamples records the last polled state of each instance of clusters in focus mode
+func RecordClusterFocusSamples() error {
+	if !HasFocusedClusters() {
+		return nil
+	}
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert ignore into database_instance_focus_sample (
+				hostname, port, sampled_timestamp, cluster_name, last_checked, is_last_check_valid,
+				master_host, master_port, slave_io_running, slave_sql_running, slave_lag_seconds,
+				binary_log_file, binary_log_pos, relay_master_log_file, exec_master_log_pos,
+				last_io_error, last_sql_error
+			)
+			select
+				database_instance.hostname,
+				database_instance.port,
+				NOW(),
+				database_instance.cluster_name,
+				database_instance.last_checked,
+				ifnull(database_instance.last_checked <= database_instance.last_seen, 0),
+				database_instance.master_host,
+				database_instance.master_port,
+				database_instance.slave_io_running,
+				database_instance.slave_sql_running,
+				database_instance.slave_lag_seconds,
+				database_instance.binary_log_file,
+				database_instance.binary_log_pos,
+				database_instance.relay_master_log_file,
+				database_instance.exec_master_log_pos,
+				database_instance.last_io_error,
+				database_instance.last_sql_error
+			from
+				database_instance
+				join cluster_focus on (database_instance.cluster_name = cluster_focus.cluster_name)
+			where
+				cluster_focus.end_timestamp > NOW()
+			`,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadClusterFocusSamples reads the samples recorded of given cluster's instances within the last given number of
+// seconds, oldest first
+func ReadClusterFocusSamples(clusterName string, seconds uint) ([]*FocusSample, error) {
+	res := []*FocusSample{}
+	query := `
+		select
+			hostname,
+			port,
+			sampled_timestamp,
+			cluster_name,
+			last_checked,
+			is_last_check_valid,
+			master_host,
+			master_port,
+			slave_io_running,
+			slave_sql_running,
+			slave_lag_seconds,
+			binary_log_file,
+			binary_log_pos,
+			relay_master_log_file,
+			exec_master_log_pos,
+			last_io_error,
+			last_sql_error
+		from
+			database_instance_focus_sample
+		where
+			cluster_name = ?
+			and sampled_timestamp >= NOW() - INTERVAL ? SECOND
+		order by
+			sampled_timestamp, hostname, port
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName, seconds), func(m sqlutils.RowMap) error {
+		sample := &FocusSample{}
+		sample.Key.Hostname = m.GetString("hostname")
+		sample.Key.Port = m.GetInt("port")
+		sample.SampledTimestamp = m.GetString("sampled_timestamp")
+		sample.ClusterName = m.GetString("cluster_name")
+		sample.LastChecked = m.GetString("last_checked")
+		sample.IsLastCheckValid = m.GetBool("is_last_check_valid")
+		sample.MasterKey.Hostname = m.GetString("master_host")
+		sample.MasterKey.Port = m.GetInt("master_port")
+		sample.Slave_IO_Running = m.GetBool("slave_io_running")
+		sample.Slave_SQL_Running = m.GetBool("slave_sql_running")
+		sample.SlaveLagSeconds = m.GetNullInt64("slave_lag_seconds")
+		sample.SelfBinlogCoordinates.LogFile = m.GetString("binary_log_file")
+		sample.SelfBinlogCoordinates.LogPos = m.GetInt64("binary_log_pos")
+		sample.ExecBinlogCoordinates.LogFile = m.GetString("relay_master_log_file")
+		sample.ExecBinlogCoordinates.LogPos = m.GetInt64("exec_master_log_pos")
+		sample.LastIOError = m.GetString("last_io_error")
+		sample.LastSQLError = m.GetString("last_sql_error")
+
+		res = append(res, sample)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ExpireClusterFocuses removes expired cluster focuses, and samples older than FocusModeSampleRetentionHours
+func ExpireClusterFocuses() error {
+	{
+		_, err := db.ExecOrchestrator(`
+				delete from cluster_focus where end_timestamp < NOW()
+			`,
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+	}
+	_, err := db.ExecOrchestrator(`
+			delete from database_instance_focus_sample where sampled_timestamp < NOW() - INTERVAL ? HOUR
+		`, config.Config.FocusModeSampleRetentionHours,
+	)
+	return log.Errore(err)
+}
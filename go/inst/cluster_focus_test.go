@@ -0,0 +1,53 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewClusterFocus(t *testing.T) {
+	defer func(minutes uint) { config.Config.FocusModeDefaultMinutes = minutes }(config.Config.FocusModeDefaultMinutes)
+	config.Config.FocusModeDefaultMinutes = 10
+
+	focus := NewClusterFocus("c1", "owner", "incident", 0)
+	test.S(t).ExpectEquals(focus.Duration, 10*time.Minute)
+	test.S(t).ExpectNil(focus.Validate())
+
+	focus = NewClusterFocus("c1", "owner", "incident", time.Hour)
+	test.S(t).ExpectEquals(focus.Duration, time.Hour)
+
+	focus = NewClusterFocus("", "owner", "incident", time.Hour)
+	test.S(t).ExpectNotNil(focus.Validate())
+
+	config.Config.FocusModeDefaultMinutes = 0
+	focus = NewClusterFocus("c1", "owner", "incident", 0)
+	test.S(t).ExpectNotNil(focus.Validate())
+}
+
+func TestApplyClusterFocus(t *testing.T) {
+	defer func(pollSeconds uint) { config.Config.InstancePollSeconds = pollSeconds }(config.Config.InstancePollSeconds)
+	defer func(pollSeconds uint) { config.Config.FocusModePollSeconds = pollSeconds }(config.Config.FocusModePollSeconds)
+	config.Config.InstancePollSeconds = 5
+	config.Config.FocusModePollSeconds = 2
+
+	effective := map[InstanceKey]*PollOverride{
+		i710Key: NewInstancePollOverride(&i710Key, 1, ProbeSetFull),
+		i720Key: NewInstancePollOverride(&i720Key, 30, ProbeSetLight),
+		i810Key: NewInstancePollOverride(&i810Key, 1, ProbeSetFull),
+	}
+	effective = applyClusterFocus(effective, []InstanceKey{i710Key, i720Key, i730Key})
+
+	// already polled more frequently
+	test.S(t).ExpectEquals(effective[i710Key].PollSeconds, uint(1))
+	test.S(t).ExpectEquals(effective[i710Key].ProbeSet, ProbeSetFull)
+	test.S(t).ExpectEquals(effective[i720Key].PollSeconds, uint(2))
+	test.S(t).ExpectEquals(effective[i720Key].ProbeSet, ProbeSet(ProbeSetLight))
+	test.S(t).ExpectTrue(effective[i730Key].Key.Equals(&i730Key))
+	test.S(t).ExpectEquals(effective[i730Key].PollSeconds, uint(2))
+	// not in focus
+	test.S(t).ExpectEquals(effective[i810Key].PollSeconds, uint(1))
+	test.S(t).ExpectEquals(len(effective), 4)
+}
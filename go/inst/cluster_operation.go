@@ -0,0 +1,184 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+)
+
+// ClusterOperation is a mutating topology operation on a cluster which SerializeClusterOperationsFilters
+// serializes: it only runs once no other operation runs on the cluster, and otherwise waits in queue.
+type ClusterOperation struct {
+	ClusterName string
+	Description string
+	Owner       string
+	QueuedAt    time.Time
+	StartedAt   time.Time // zero while queued
+}
+
+func (this *ClusterOperation) IsRunning() bool {
+	return !this.StartedAt.IsZero()
+}
+
+func (this *ClusterOperation) String() string {
+	if this.IsRunning() {
+		return fmt.Sprintf("%s: running since %s; owner: %s", this.Description, this.StartedAt.Format(time.RFC3339), this.Owner)
+	}
+	return fmt.Sprintf("%s: queued since %s; owner: %s", this.Description, this.QueuedAt.Format(time.RFC3339), this.Owner)
+}
+
+// clusterOperationQueue serializes the operations of a single cluster. Holding the slot means running.
+type clusterOperationQueue struct {
+	slot       chan bool
+	operations []*ClusterOperation
+}
+
+var clusterOperationQueuesMutex sync.Mutex
+var clusterOperationQueues = make(map[string]*clusterOperationQueue)
+
+// IsClusterOperationSerialized returns true when operations on given cluster are serialized,
+// per SerializeClusterOperationsFilters
+func IsClusterOperationSerialized(clusterName string) bool {
+	if clusterName == "" || len(config.Config.SerializeClusterOperationsFilters) == 0 {
+		return false
+	}
+	clusterInfo := &ClusterInfo{ClusterName: clusterName}
+	if clusterInfo.filtersMatchCluster(config.Config.SerializeClusterOperationsFilters) {
+		return true
+	}
+	// Filters may also match by alias
+	if clusterInfo.ClusterAlias, _ = ReadAliasByClusterName(clusterName); clusterInfo.ClusterAlias == "" {
+		return false
+	}
+	return clusterInfo.filtersMatchCluster(config.Config.SerializeClusterOperationsFilters)
+}
+
+// enqueueClusterOperation lists given operation with its cluster's queue, and returns that queue
+func enqueueClusterOperation(operation *ClusterOperation) *clusterOperationQueue {
+	clusterOperationQueuesMutex.Lock()
+	defer clusterOperationQueuesMutex.Unlock()
+
+	queue, found := clusterOperationQueues[operation.ClusterName]
+	if !found {
+		queue = &clusterOperationQueue{slot: make(chan bool, 1)}
+		clusterOperationQueues[operation.ClusterName] = queue
+	}
+	queue.operations = append(queue.operations, operation)
+	return queue
+}
+
+// dequeueClusterOperation removes given operation from its cluster's queue
+func dequeueClusterOperation(operation *ClusterOperation) {
+	clusterOperationQueuesMutex.Lock()
+	defer clusterOperationQueuesMutex.Unlock()
+
+	queue, found := clusterOperationQueues[operation.ClusterName]
+	if !found {
+		return
+	}
+	for i, queued := range queue.operations {
+		if queued == operation {
+			queue.operations = append(queue.operations[:i], queue.operations[i+1:]...)
+			break
+		}
+	}
+}
+
+// BeginClusterOperation waits for its turn to run an operation on given cluster, up to
+// ClusterOperationQueueTimeoutSeconds. It returns nil when the cluster's operations are not serialized.
+// A non-nil operation must be ended via EndClusterOperation.
+func BeginClusterOperation(clusterName string, description string, owner string) (*ClusterOperation, error) {
+	if !IsClusterOperationSerialized(clusterName) {
+		return nil, nil
+	}
+	operation := &ClusterOperation{
+		ClusterName: clusterName,
+		Description: description,
+		Owner:       owner,
+		QueuedAt:    time.Now(),
+	}
+	queue := enqueueClusterOperation(operation)
+	timeout := time.Duration(config.Config.ClusterOperationQueueTimeoutSeconds) * time.Second
+	select {
+	case queue.slot <- true:
+		clusterOperationQueuesMutex.Lock()
+		operation.StartedAt = time.Now()
+		clusterOperationQueuesMutex.Unlock()
+		log.Debugf("BeginClusterOperation: %s on %s", description, clusterName)
+		return operation, nil
+	case <-time.After(timeout):
+		dequeueClusterOperation(operation)
+		others := []string{}
+		for _, other := range ReadClusterOperations(clusterName) {
+			others = append(others, other.String())
+		}
+		return nil, fmt.Errorf("%s: timed out after %+v waiting for other operations on %s: %s", description, timeout, clusterName, strings.Join(others, "; "))
+	}
+}
+
+// EndClusterOperation completes given operation, letting the next operation queued on its cluster run
+func EndClusterOperation(operation *ClusterOperation) {
+	if operation == nil {
+		return
+	}
+	dequeueClusterOperation(operation)
+	queue := getClusterOperationQueue(operation.ClusterName)
+	<-queue.slot
+	log.Debugf("EndClusterOperation: %s on %s", operation.Description, operation.ClusterName)
+}
+
+// getClusterOperationQueue returns the queue of given cluster
+func getClusterOperationQueue(clusterName string) *clusterOperationQueue {
+	clusterOperationQueuesMutex.Lock()
+	defer clusterOperationQueuesMutex.Unlock()
+
+	return clusterOperationQueues[clusterName]
+}
+
+// ReadClusterOperations returns the running and then queued operations of given cluster, or of all clusters
+// when clusterName is empty
+func ReadClusterOperations(clusterName string) (operations []ClusterOperation) {
+	clusterOperationQueuesMutex.Lock()
+	defer clusterOperationQueuesMutex.Unlock()
+
+	operations = []ClusterOperation{}
+	for queueClusterName, queue := range clusterOperationQueues {
+		if clusterName != "" && queueClusterName != clusterName {
+			continue
+		}
+		for _, operation := range queue.operations {
+			operations = append(operations, *operation)
+		}
+	}
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].ClusterName != operations[j].ClusterName {
+			return operations[i].ClusterName < operations[j].ClusterName
+		}
+		if operations[i].IsRunning() != operations[j].IsRunning() {
+			return operations[i].IsRunning()
+		}
+		return operations[i].QueuedAt.Before(operations[j].QueuedAt)
+	})
+	return operations
+}
@@ -0,0 +1,61 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestClusterOperationNotSerialized(t *testing.T) {
+	defer func(filters []string) { config.Config.SerializeClusterOperationsFilters = filters }(config.Config.SerializeClusterOperationsFilters)
+	config.Config.SerializeClusterOperationsFilters = []string{}
+
+	operation, err := BeginClusterOperation("c1:3306", "relocate", "ops")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(operation == nil)
+	EndClusterOperation(operation)
+	test.S(t).ExpectEquals(len(ReadClusterOperations("c1:3306")), 0)
+}
+
+func TestClusterOperationSerialized(t *testing.T) {
+	defer func(filters []string) { config.Config.SerializeClusterOperationsFilters = filters }(config.Config.SerializeClusterOperationsFilters)
+	defer func(timeout uint) { config.Config.ClusterOperationQueueTimeoutSeconds = timeout }(config.Config.ClusterOperationQueueTimeoutSeconds)
+	config.Config.SerializeClusterOperationsFilters = []string{"c2:3306", "c3:3306"}
+	config.Config.ClusterOperationQueueTimeoutSeconds = 1
+
+	regroup, err := BeginClusterOperation("c2:3306", "regroup", "orchestrator")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectNotNil(regroup)
+
+	// another cluster is unaffected
+	other, err := BeginClusterOperation("c3:3306", "move-up", "ops")
+	test.S(t).ExpectNil(err)
+	EndClusterOperation(other)
+
+	// times out while regroup runs
+	_, err = BeginClusterOperation("c2:3306", "relocate", "ops")
+	test.S(t).ExpectNotNil(err)
+
+	started := make(chan *ClusterOperation)
+	go func() {
+		relocate, _ := BeginClusterOperation("c2:3306", "relocate", "ops")
+		started <- relocate
+	}()
+	for len(ReadClusterOperations("c2:3306")) < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	operations := ReadClusterOperations("c2:3306")
+	test.S(t).ExpectEquals(operations[0].Description, "regroup")
+	test.S(t).ExpectTrue(operations[0].IsRunning())
+	test.S(t).ExpectEquals(operations[1].Description, "relocate")
+	test.S(t).ExpectFalse(operations[1].IsRunning())
+
+	EndClusterOperation(regroup)
+	relocate := <-started
+	test.S(t).ExpectNotNil(relocate)
+	test.S(t).ExpectTrue(relocate.IsRunning())
+	EndClusterOperation(relocate)
+	test.S(t).ExpectEquals(len(ReadClusterOperations("c2:3306")), 0)
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// ClusterWriteFreeze freezes writes on a cluster, e.g. for a coordinated cutover to another system: its writeable
+// instances are set read_only, and until the freeze expires, automated failovers, which would restore writability
+// on a promoted master, are refused.
+type ClusterWriteFreeze struct {
+	ClusterName     string
+	Owner           string
+	Reason          string
+	Duration        time.Duration
+	FrozenInstances InstanceKeyMap
+	BeginTimestamp  string
+	EndTimestamp    string
+}
+
+func NewClusterWriteFreeze(clusterName string, owner string, reason string, duration time.Duration) *ClusterWriteFreeze {
+	if duration == 0 {
+		duration = time.Duration(config.Config.WriteFreezeDefaultMinutes) * time.Minute
+	}
+	return &ClusterWriteFreeze{
+		ClusterName:     clusterName,
+		Owner:           owner,
+		Reason:          reason,
+		Duration:        duration,
+		FrozenInstances: *NewInstanceKeyMap(),
+	}
+}
+
+func (this *ClusterWriteFreeze) String() string {
+	return fmt.Sprintf("%s: writes frozen until %s on %s; owner: %s, reason: %s", this.ClusterName, this.EndTimestamp, this.FrozenInstances.ToCommaDelimitedList(), this.Owner, this.Reason)
+}
+
+// Validate checks this freeze is applicable
+func (this *ClusterWriteFreeze) Validate() error {
+	if this.ClusterName == "" {
+		return fmt.Errorf("Write freeze requires a cluster name")
+	}
+	if this.Reason == "" {
+		return fmt.Errorf("Write freeze of %s requires a reason", this.ClusterName)
+	}
+	if this.Duration <= 0 {
+		return fmt.Errorf("Write freeze of %s: duration must be positive", this.ClusterName)
+	}
+	return nil
+}
+
+// WriteableInstances returns those of given instances which accept writes, as last polled
+func WriteableInstances(instances [](*Instance)) [](*Instance) {
+	writeable := [](*Instance){}
+	for _, instance := range instances {
+		if instance.IsLastCheckValid && !instance.ReadOnly {
+			writeable = append(writeable, instance)
+		}
+	}
+	return writeable
+}
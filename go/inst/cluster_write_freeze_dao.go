@@ -0,0 +1,123 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteClusterWriteFreeze records a cluster write freeze, or renews it, for the freeze's duration as of now
+func WriteClusterWriteFreeze(freeze *ClusterWriteFreeze) error {
+	if err := freeze.Validate(); err != nil {
+		return err
+	}
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into cluster_write_freeze (
+				cluster_name, owner, reason, frozen_instances, begin_timestamp, end_timestamp
+			) values (
+				?, ?, ?, ?, NOW(), NOW() + INTERVAL ? SECOND
+			) on duplicate key update
+				owner=values(owner),
+				reason=values(reason),
+				frozen_instances=values(frozen_instances),
+				begin_timestamp=values(begin_timestamp),
+				end_timestamp=values(end_timestamp)
+			`, freeze.ClusterName, freeze.Owner, freeze.Reason, freeze.FrozenInstances.ToJSONString(), int(freeze.Duration.Seconds()),
+		)
+		return log.Errore(err)
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	AuditOperation("begin-write-freeze", nil, fmt.Sprintf("%s writes frozen for %+v on %s; owner: %s, reason: %s", freeze.ClusterName, freeze.Duration, freeze.FrozenInstances.ToCommaDelimitedList(), freeze.Owner, freeze.Reason))
+	return nil
+}
+
+// DeleteClusterWriteFreeze ends the write freeze of a cluster. The read_only state of its instances is unchanged.
+func DeleteClusterWriteFreeze(clusterName string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from cluster_write_freeze where cluster_name = ?
+			`, clusterName,
+		)
+		return log.Errore(err)
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	AuditOperation("end-write-freeze", nil, fmt.Sprintf("%s writes no longer frozen", clusterName))
+	return nil
+}
+
+// ReadClusterWriteFreezes reads the unexpired write freeze of given cluster, or of all clusters when clusterName is
+// empty
+func ReadClusterWriteFreezes(clusterName string) ([]*ClusterWriteFreeze, error) {
+	res := []*ClusterWriteFreeze{}
+	query := `
+		select
+			cluster_name,
+			owner,
+			reason,
+			frozen_instances,
+			begin_timestamp,
+			end_timestamp
+		from
+			cluster_write_freeze
+		where
+			end_timestamp > NOW()
+			and (cluster_name = ? or ? = '')
+		order by
+			cluster_name
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName, clusterName), func(m sqlutils.RowMap) error {
+		freeze := &ClusterWriteFreeze{FrozenInstances: *NewInstanceKeyMap()}
+		freeze.ClusterName = m.GetString("cluster_name")
+		freeze.Owner = m.GetString("owner")
+		freeze.Reason = m.GetString("reason")
+		freeze.FrozenInstances.ReadJson(m.GetString("frozen_instances"))
+		freeze.BeginTimestamp = m.GetString("begin_timestamp")
+		freeze.EndTimestamp = m.GetString("end_timestamp")
+
+		res = append(res, freeze)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// IsClusterWriteFrozen returns true when given cluster has an unexpired write freeze
+func IsClusterWriteFrozen(clusterName string) (bool, error) {
+	freezes, err := ReadClusterWriteFreezes(clusterName)
+	if err != nil {
+		return false, err
+	}
+	return len(freezes) > 0, nil
+}
+
+// ExpireClusterWriteFreezes removes expired write freezes
+func ExpireClusterWriteFreezes() error {
+	_, err := db.ExecOrchestrator(`
+			delete from cluster_write_freeze where end_timestamp < NOW()
+		`,
+	)
+	return log.Errore(err)
+}
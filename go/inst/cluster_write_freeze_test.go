@@ -0,0 +1,53 @@
+package inst
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewClusterWriteFreeze(t *testing.T) {
+	defer func(minutes uint) { config.Config.WriteFreezeDefaultMinutes = minutes }(config.Config.WriteFreezeDefaultMinutes)
+	config.Config.WriteFreezeDefaultMinutes = 60
+
+	freeze := NewClusterWriteFreeze("c1", "owner", "cutover", 0)
+	test.S(t).ExpectEquals(freeze.Duration, time.Hour)
+	test.S(t).ExpectNil(freeze.Validate())
+
+	freeze = NewClusterWriteFreeze("c1", "owner", "", time.Hour)
+	test.S(t).ExpectNotNil(freeze.Validate())
+
+	freeze = NewClusterWriteFreeze("", "owner", "cutover", time.Hour)
+	test.S(t).ExpectNotNil(freeze.Validate())
+}
+
+func TestClusterWriteFreezeJSON(t *testing.T) {
+	freeze := NewClusterWriteFreeze("c1", "owner", "cutover", time.Hour)
+	freeze.FrozenInstances.AddKey(i710Key)
+	b, err := json.Marshal(freeze)
+	test.S(t).ExpectNil(err)
+
+	decoded := ClusterWriteFreeze{}
+	test.S(t).ExpectNil(json.Unmarshal(b, &decoded))
+	test.S(t).ExpectEquals(decoded.ClusterName, "c1")
+	test.S(t).ExpectEquals(decoded.Duration, time.Hour)
+	test.S(t).ExpectTrue(decoded.FrozenInstances.HasKey(i710Key))
+}
+
+func TestWriteableInstances(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.ReadOnly = true
+		instance.IsLastCheckValid = true
+	}
+	instancesMap[i710Key.StringCode()].ReadOnly = false
+	instancesMap[i720Key.StringCode()].ReadOnly = false
+	instancesMap[i720Key.StringCode()].IsLastCheckValid = false
+
+	writeable := WriteableInstances(instances)
+	test.S(t).ExpectEquals(len(writeable), 1)
+	test.S(t).ExpectEquals(writeable[0].Key, i710Key)
+}
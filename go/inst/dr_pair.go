@@ -0,0 +1,78 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/patrickmn/go-cache"
+)
+
+type DRPairState string
+
+const (
+	DRPairActive DRPairState = "active"
+	DRPairSplit              = "split"
+)
+
+// DRPair is a warm standby relationship between two clusters: the master ("main") of the standby
+// cluster replicates directly from the master of the primary cluster. While active, orchestrator
+// considers the standby main as part of the primary cluster's topology, keeps it replicating
+// from the primary master across failovers of either side, and never promotes it in the primary
+// cluster.
+type DRPair struct {
+	StandbyClusterAlias string
+	StandbyMasterKey    InstanceKey
+	State               DRPairState
+	LastUpdated         string
+}
+
+func NewDRPair(standbyClusterAlias string, standbyMasterKey *InstanceKey) *DRPair {
+	return &DRPair{
+		StandbyClusterAlias: standbyClusterAlias,
+		StandbyMasterKey:    *standbyMasterKey,
+		State:               DRPairActive,
+	}
+}
+
+func (this *DRPair) IsActive() bool {
+	return this.State == DRPairActive
+}
+
+var activeDRStandbyKeysCache = cache.New(time.Duration(config.Config.InstancePollSeconds)*time.Second, time.Second)
+
+// IsActiveDRStandbyMaster returns true when given instance is the main of the standby side of an active DR pair.
+// The list of such instances is cached briefly, as this is checked repeatedly when evaluating promotion candidates.
+func IsActiveDRStandbyMaster(instanceKey *InstanceKey) bool {
+	var standbyKeys *InstanceKeyMap
+	if cached, found := activeDRStandbyKeysCache.Get("keys"); found {
+		standbyKeys = cached.(*InstanceKeyMap)
+	} else {
+		standbyKeys = NewInstanceKeyMap()
+		// On error we cache the empty list as well, so as not to hammer a failing backend
+		pairs, _ := ReadDRPairs()
+		for _, pair := range pairs {
+			if pair.IsActive() {
+				standbyKeys.AddKey(pair.StandbyMasterKey)
+			}
+		}
+		activeDRStandbyKeysCache.Set("keys", standbyKeys, cache.DefaultExpiration)
+	}
+	return standbyKeys.HasKey(*instanceKey)
+}
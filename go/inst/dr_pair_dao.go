@@ -0,0 +1,118 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteDRPair creates or updates a DR pair
+func WriteDRPair(pair *DRPair) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into dr_pair (
+				standby_cluster_alias, standby_hostname, standby_port, state, last_updated
+			) values (
+				?, ?, ?, ?, NOW()
+			) on duplicate key update
+				standby_hostname=values(standby_hostname),
+				standby_port=values(standby_port),
+				state=values(state),
+				last_updated=values(last_updated)
+			`, pair.StandbyClusterAlias, pair.StandbyMasterKey.Hostname, pair.StandbyMasterKey.Port, string(pair.State),
+		)
+		activeDRStandbyKeysCache.Flush()
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// DeleteDRPair removes a DR pair
+func DeleteDRPair(standbyClusterAlias string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from dr_pair where standby_cluster_alias = ?
+			`, standbyClusterAlias,
+		)
+		activeDRStandbyKeysCache.Flush()
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+func readDRPairs(whereCondition string, args []interface{}) ([]*DRPair, error) {
+	res := []*DRPair{}
+	query := fmt.Sprintf(`
+		select
+			standby_cluster_alias,
+			standby_hostname,
+			standby_port,
+			state,
+			last_updated
+		from
+			dr_pair
+		%s
+		order by
+			standby_cluster_alias
+		`, whereCondition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		pair := &DRPair{}
+		pair.StandbyClusterAlias = m.GetString("standby_cluster_alias")
+		pair.StandbyMasterKey.Hostname = m.GetString("standby_hostname")
+		pair.StandbyMasterKey.Port = m.GetInt("standby_port")
+		pair.State = DRPairState(m.GetString("state"))
+		pair.LastUpdated = m.GetString("last_updated")
+
+		res = append(res, pair)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadDRPairs reads all DR pairs
+func ReadDRPairs() ([]*DRPair, error) {
+	return readDRPairs(``, sqlutils.Args())
+}
+
+// ReadDRPair reads a DR pair by its standby cluster alias
+func ReadDRPair(standbyClusterAlias string) (*DRPair, error) {
+	pairs, err := readDRPairs(`where standby_cluster_alias = ?`, sqlutils.Args(standbyClusterAlias))
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("DR pair not found: %s", standbyClusterAlias)
+	}
+	return pairs[0], nil
+}
+
+// ReadDRPairByStandbyMaster reads the DR pair, if any, whose standby main is given instance
+func ReadDRPairByStandbyMaster(instanceKey *InstanceKey) (*DRPair, error) {
+	pairs, err := readDRPairs(`where standby_hostname = ? and standby_port = ?`, sqlutils.Args(instanceKey.Hostname, instanceKey.Port))
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	return pairs[0], nil
+}
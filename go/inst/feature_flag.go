@@ -0,0 +1,90 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// featureNameRegexp is the format of feature names, e.g. "errant-gtid-auto-fix"
+var featureNameRegexp = regexp.MustCompile("^[a-z0-9][a-z0-9_-]*$")
+
+// FeatureFlag enables or disables a gated feature on a cluster, or on all clusters when ClusterName is empty.
+// Features are disabled unless enabled by a flag; a cluster's own flag takes precedence over the all-clusters flag,
+// such that a feature may be enabled on canary clusters, or enabled fleet-wide and disabled on some clusters.
+type FeatureFlag struct {
+	FeatureName string
+	ClusterName string
+	Enabled     bool
+	Owner       string
+	Reason      string
+	LastUpdated string
+}
+
+// NewFeatureFlag creates a flag of given feature on given cluster; an empty cluster name stands for all clusters
+func NewFeatureFlag(featureName string, clusterName string, enabled bool, owner string, reason string) *FeatureFlag {
+	return &FeatureFlag{
+		FeatureName: featureName,
+		ClusterName: clusterName,
+		Enabled:     enabled,
+		Owner:       owner,
+		Reason:      reason,
+	}
+}
+
+// Validate checks this flag is applicable
+func (this *FeatureFlag) Validate() error {
+	if !featureNameRegexp.MatchString(this.FeatureName) {
+		return fmt.Errorf("Invalid feature name: %s. Expected lowercase letters, digits, dashes and underscores", this.FeatureName)
+	}
+	return nil
+}
+
+// Scope returns the cluster this flag applies to, or "*" for all clusters
+func (this *FeatureFlag) Scope() string {
+	if this.ClusterName == "" {
+		return "*"
+	}
+	return this.ClusterName
+}
+
+func (this *FeatureFlag) String() string {
+	state := "disabled"
+	if this.Enabled {
+		state = "enabled"
+	}
+	return fmt.Sprintf("%s on %s: %s; owner: %s, reason: %s", this.FeatureName, this.Scope(), state, this.Owner, this.Reason)
+}
+
+// isFeatureEnabledByFlags returns true when given feature is enabled on given cluster, as per given flags: by the
+// cluster's own flag, or else by the all-clusters flag
+func isFeatureEnabledByFlags(flags []*FeatureFlag, featureName string, clusterName string) bool {
+	var allClustersFlag *FeatureFlag
+	for _, flag := range flags {
+		if flag.FeatureName != featureName {
+			continue
+		}
+		if clusterName != "" && flag.ClusterName == clusterName {
+			return flag.Enabled
+		}
+		if flag.ClusterName == "" {
+			allClustersFlag = flag
+		}
+	}
+	return allClustersFlag != nil && allClustersFlag.Enabled
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// featureFlagsCache keeps all feature flags, such that checking a feature does not hit the backend. Flags written by
+// other orchestrator nodes apply once the cache expires.
+var featureFlagsCache = cache.New(time.Duration(config.Config.InstancePollSeconds)*time.Second, time.Second)
+
+// WriteFeatureFlag records a feature flag, or updates it
+func WriteFeatureFlag(flag *FeatureFlag) error {
+	if err := flag.Validate(); err != nil {
+		return err
+	}
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into feature_flag (
+				feature_name, cluster_name, enabled, owner, reason, last_updated
+			) values (
+				?, ?, ?, ?, ?, NOW()
+			) on duplicate key update
+				enabled=values(enabled),
+				owner=values(owner),
+				reason=values(reason),
+				last_updated=values(last_updated)
+			`, flag.FeatureName, flag.ClusterName, flag.Enabled, flag.Owner, flag.Reason,
+		)
+		return log.Errore(err)
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	featureFlagsCache.Flush()
+	AuditOperation("write-feature-flag", nil, flag.String())
+	return nil
+}
+
+// DeleteFeatureFlag removes the flag of given feature on given cluster (empty for the all-clusters flag), such that
+// the cluster follows the all-clusters flag
+func DeleteFeatureFlag(featureName string, clusterName string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from feature_flag where feature_name = ? and cluster_name = ?
+			`, featureName, clusterName,
+		)
+		return log.Errore(err)
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	featureFlagsCache.Flush()
+	AuditOperation("delete-feature-flag", nil, fmt.Sprintf("%s on %s", featureName, NewFeatureFlag(featureName, clusterName, false, "", "").Scope()))
+	return nil
+}
+
+// ReadFeatureFlags reads the flags of given feature, or of all features when featureName is empty
+func ReadFeatureFlags(featureName string) ([]*FeatureFlag, error) {
+	res := []*FeatureFlag{}
+	query := `
+		select
+			feature_name,
+			cluster_name,
+			enabled,
+			owner,
+			reason,
+			last_updated
+		from
+			feature_flag
+		where
+			feature_name = ? or ? = ''
+		order by
+			feature_name, cluster_name
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(featureName, featureName), func(m sqlutils.RowMap) error {
+		flag := &FeatureFlag{}
+		flag.FeatureName = m.GetString("feature_name")
+		flag.ClusterName = m.GetString("cluster_name")
+		flag.Enabled = m.GetBool("enabled")
+		flag.Owner = m.GetString("owner")
+		flag.Reason = m.GetString("reason")
+		flag.LastUpdated = m.GetString("last_updated")
+
+		res = append(res, flag)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// IsFeatureEnabled returns true when given feature is enabled on given cluster. Behaviors gated by a feature check
+// it before taking effect. Features are disabled when flags cannot be read.
+func IsFeatureEnabled(featureName string, clusterName string) bool {
+	if flags, found := featureFlagsCache.Get("flags"); found {
+		return isFeatureEnabledByFlags(flags.([]*FeatureFlag), featureName, clusterName)
+	}
+	flags, err := ReadFeatureFlags("")
+	if err != nil {
+		return false
+	}
+	featureFlagsCache.Set("flags", flags, cache.DefaultExpiration)
+	return isFeatureEnabledByFlags(flags, featureName, clusterName)
+}
@@ -0,0 +1,44 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestFeatureFlagValidate(t *testing.T) {
+	test.S(t).ExpectNil(NewFeatureFlag("errant-gtid-auto-fix", "", true, "", "").Validate())
+	test.S(t).ExpectNil(NewFeatureFlag("fast_probe", "mycluster:3306", true, "", "").Validate())
+	test.S(t).ExpectNotNil(NewFeatureFlag("", "", true, "", "").Validate())
+	test.S(t).ExpectNotNil(NewFeatureFlag("Errant GTID", "", true, "", "").Validate())
+	test.S(t).ExpectNotNil(NewFeatureFlag("-fix", "", true, "", "").Validate())
+}
+
+func TestFeatureFlagScope(t *testing.T) {
+	test.S(t).ExpectEquals(NewFeatureFlag("fix", "", true, "", "").Scope(), "*")
+	test.S(t).ExpectEquals(NewFeatureFlag("fix", "mycluster:3306", true, "", "").Scope(), "mycluster:3306")
+}
+
+func TestIsFeatureEnabledByFlags(t *testing.T) {
+	{
+		test.S(t).ExpectFalse(isFeatureEnabledByFlags([]*FeatureFlag{}, "fix", "canary:3306"))
+	}
+	{
+		// enabled on a canary cluster
+		flags := []*FeatureFlag{NewFeatureFlag("fix", "canary:3306", true, "", "")}
+		test.S(t).ExpectTrue(isFeatureEnabledByFlags(flags, "fix", "canary:3306"))
+		test.S(t).ExpectFalse(isFeatureEnabledByFlags(flags, "fix", "other:3306"))
+		test.S(t).ExpectFalse(isFeatureEnabledByFlags(flags, "fix", ""))
+		test.S(t).ExpectFalse(isFeatureEnabledByFlags(flags, "rebalance", "canary:3306"))
+	}
+	{
+		// enabled fleet-wide, disabled on a cluster
+		flags := []*FeatureFlag{
+			NewFeatureFlag("fix", "", true, "", ""),
+			NewFeatureFlag("fix", "fragile:3306", false, "", ""),
+		}
+		test.S(t).ExpectTrue(isFeatureEnabledByFlags(flags, "fix", "canary:3306"))
+		test.S(t).ExpectTrue(isFeatureEnabledByFlags(flags, "fix", ""))
+		test.S(t).ExpectFalse(isFeatureEnabledByFlags(flags, "fix", "fragile:3306"))
+	}
+}
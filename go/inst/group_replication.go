@@ -0,0 +1,208 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+const (
+	GroupReplicationMemberRolePrimary   = "PRIMARY"
+	GroupReplicationMemberRoleSecondary = "SECONDARY"
+)
+
+const (
+	GroupReplicationMemberStateOnline     = "ONLINE"
+	GroupReplicationMemberStateRecovering = "RECOVERING"
+	GroupReplicationMemberStateOffline    = "OFFLINE"
+)
+
+// IsReplicationGroupMember returns true when this instance is an active member of a Group Replication group
+func (this *Instance) IsReplicationGroupMember() bool {
+	return this.ReplicationGroupName != ""
+}
+
+// IsReplicationGroupPrimary returns true when this instance is a writable member of its replication group
+func (this *Instance) IsReplicationGroupPrimary() bool {
+	return this.IsReplicationGroupMember() && this.ReplicationGroupMemberRole == GroupReplicationMemberRolePrimary
+}
+
+// IsReplicationGroupPeerOf returns true when both instances are members of the same replication group
+func (this *Instance) IsReplicationGroupPeerOf(other *Instance) bool {
+	return this.IsReplicationGroupMember() && this.ReplicationGroupName == other.ReplicationGroupName
+}
+
+// replicationGroupUpstreamKey returns the group member from which this instance derives its cluster attributes:
+// the group primary in single-primary mode, or the smallest member in multi-primary mode. A group is thus
+// modeled as a unit headed by a single instance. nil is returned for that instance itself, as well as for
+// instances which are not group members.
+func (this *Instance) replicationGroupUpstreamKey() *InstanceKey {
+	if !this.IsReplicationGroupMember() {
+		return nil
+	}
+	var upstreamKey *InstanceKey
+	if this.ReplicationGroupIsSinglePrimary {
+		upstreamKey = &this.ReplicationGroupPrimaryInstanceKey
+	} else {
+		for _, key := range this.ReplicationGroupMembers.GetInstanceKeys() {
+			key := key
+			if upstreamKey == nil || key.SmallerThan(upstreamKey) {
+				upstreamKey = &key
+			}
+		}
+	}
+	if upstreamKey == nil || !upstreamKey.IsValid() || upstreamKey.Equals(&this.Key) {
+		return nil
+	}
+	return upstreamKey
+}
+
+// readReplicationGroupMembership reads, on a MySQL 5.7/8.0 server, whether the server is an active member
+// of a Group Replication group, its role and state, and the group's members.
+func readReplicationGroupMembership(db *sql.DB, instance *Instance) error {
+	groupName := ""
+	err := sqlutils.QueryRowsMap(db, "show global variables like 'group_replication_%'", func(m sqlutils.RowMap) error {
+		switch m.GetString("Variable_name") {
+		case "group_replication_group_name":
+			groupName = m.GetString("Value")
+		case "group_replication_single_primary_mode":
+			instance.ReplicationGroupIsSinglePrimary = (m.GetString("Value") == "ON")
+		}
+		return nil
+	})
+	if err != nil || groupName == "" {
+		return err
+	}
+	// member_role is only available as of MySQL 8.0. Without it, the primary is identified by status.
+	roleColumn := "member_role"
+	primaryMemberUUID := ""
+	if !instance.Capabilities.ReplicationGroupMemberRole {
+		roleColumn = "''"
+		_ = db.QueryRow("select variable_value from performance_schema.global_status where variable_name = 'group_replication_primary_member'").Scan(&primaryMemberUUID)
+	}
+	query := fmt.Sprintf(`
+		select
+			member_id,
+			member_host,
+			member_port,
+			member_state,
+			%s as member_role,
+			member_id = @@global.server_uuid as is_self
+		from
+			performance_schema.replication_group_members
+		`, roleColumn)
+	memberState := ""
+	memberRole := ""
+	members := NewInstanceKeyMap()
+	primaryKey := InstanceKey{}
+	err = sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		role := m.GetString("member_role")
+		if role == "" {
+			if !instance.ReplicationGroupIsSinglePrimary || m.GetString("member_id") == primaryMemberUUID {
+				role = GroupReplicationMemberRolePrimary
+			} else {
+				role = GroupReplicationMemberRoleSecondary
+			}
+		}
+		state := m.GetString("member_state")
+		if m.GetBool("is_self") {
+			memberState = state
+			memberRole = role
+		}
+		if state != GroupReplicationMemberStateOnline && state != GroupReplicationMemberStateRecovering {
+			return nil
+		}
+		memberKey, err := NewResolveInstanceKey(m.GetString("member_host"), m.GetInt("member_port"))
+		if err != nil {
+			return err
+		}
+		members.AddKey(*memberKey)
+		if role == GroupReplicationMemberRolePrimary && state == GroupReplicationMemberStateOnline {
+			primaryKey = *memberKey
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if memberState == "" || memberState == GroupReplicationMemberStateOffline {
+		// Group Replication is configured but not running on this server
+		return nil
+	}
+	instance.ReplicationGroupName = groupName
+	instance.ReplicationGroupMemberState = memberState
+	instance.ReplicationGroupMemberRole = memberRole
+	instance.ReplicationGroupMembers = *members
+	if instance.ReplicationGroupIsSinglePrimary {
+		instance.ReplicationGroupPrimaryInstanceKey = primaryKey
+	}
+	return nil
+}
+
+// checkReplicationGroupChangeMaster refuses replication between members of the same replication group:
+// a group replicates by itself, and CHANGE MASTER within the group would break it.
+func checkReplicationGroupChangeMaster(instance *Instance, master *Instance) error {
+	if instance.IsReplicationGroupPeerOf(master) {
+		return fmt.Errorf("%+v and %+v are members of the same replication group %s; will not CHANGE MASTER within the group", instance.Key, master.Key, instance.ReplicationGroupName)
+	}
+	return nil
+}
+
+// SetReplicationGroupPrimary promotes given instance to be its group's primary by means of a group primary
+// election (group_replication_set_as_primary, MySQL 8.0.13 and above). Replication is not otherwise changed.
+func SetReplicationGroupPrimary(instanceKey *InstanceKey) (*Instance, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if !instance.IsReplicationGroupMember() {
+		return instance, fmt.Errorf("SetReplicationGroupPrimary: %+v is not a replication group member", *instanceKey)
+	}
+	if !instance.ReplicationGroupIsSinglePrimary {
+		return instance, fmt.Errorf("SetReplicationGroupPrimary: replication group of %+v is in multi-primary mode", *instanceKey)
+	}
+	if instance.ReplicationGroupMemberState != GroupReplicationMemberStateOnline {
+		return instance, fmt.Errorf("SetReplicationGroupPrimary: %+v is %s", *instanceKey, instance.ReplicationGroupMemberState)
+	}
+	if instance.IsReplicationGroupPrimary() {
+		return instance, nil
+	}
+	if !instance.Capabilities.GroupReplicationSetAsPrimary {
+		return instance, fmt.Errorf("SetReplicationGroupPrimary: %+v does not support group_replication_set_as_primary", *instanceKey)
+	}
+	if _, err := ExecInstance(instanceKey, "select group_replication_set_as_primary(?)", instance.ServerUUID); err != nil {
+		return instance, log.Errore(err)
+	}
+	AuditOperation("set-replication-group-primary", instanceKey, fmt.Sprintf("elected primary of replication group %s; previous primary: %+v", instance.ReplicationGroupName, instance.ReplicationGroupPrimaryInstanceKey))
+	return ReadTopologyInstance(instanceKey)
+}
+
+// ReadReplicationGroupMembers reads from the backend the instances sharing given instance's replication group
+func ReadReplicationGroupMembers(instance *Instance) ([](*Instance), error) {
+	if !instance.IsReplicationGroupMember() {
+		return [](*Instance){}, nil
+	}
+	condition := `
+		replication_group_name = ?
+		and not (hostname = ? and port = ?)
+	`
+	return readInstancesByCondition(condition, sqlutils.Args(instance.ReplicationGroupName, instance.Key.Hostname, instance.Key.Port), "hostname, port")
+}
@@ -0,0 +1,82 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func mkReplicationGroupInstances(singlePrimary bool) []*Instance {
+	keys := []InstanceKey{
+		{Hostname: "gr1", Port: 3306},
+		{Hostname: "gr2", Port: 3306},
+		{Hostname: "gr3", Port: 3306},
+	}
+	instances := []*Instance{}
+	for i, key := range keys {
+		instance := NewInstance()
+		instance.Key = key
+		instance.ServerID = uint(i + 1)
+		instance.Version = "8.0.20"
+		instance.LogBinEnabled = true
+		instance.ReplicationGroupName = "8a94f357-aab4-11df-86ab-c80aa9429562"
+		instance.ReplicationGroupIsSinglePrimary = singlePrimary
+		instance.ReplicationGroupMemberState = GroupReplicationMemberStateOnline
+		instance.ReplicationGroupMemberRole = GroupReplicationMemberRolePrimary
+		instance.ReplicationGroupMembers.AddKeys(keys)
+		if singlePrimary {
+			instance.ReplicationGroupPrimaryInstanceKey = keys[1]
+			if i != 1 {
+				instance.ReplicationGroupMemberRole = GroupReplicationMemberRoleSecondary
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return instances
+}
+
+func TestReplicationGroupUpstreamKeySinglePrimary(t *testing.T) {
+	instances := mkReplicationGroupInstances(true)
+	test.S(t).ExpectTrue(instances[0].replicationGroupUpstreamKey().Equals(&instances[1].Key))
+	test.S(t).ExpectTrue(instances[1].replicationGroupUpstreamKey() == nil)
+	test.S(t).ExpectTrue(instances[2].replicationGroupUpstreamKey().Equals(&instances[1].Key))
+	test.S(t).ExpectTrue(instances[1].IsReplicationGroupPrimary())
+	test.S(t).ExpectFalse(instances[2].IsReplicationGroupPrimary())
+}
+
+func TestReplicationGroupUpstreamKeyMultiPrimary(t *testing.T) {
+	instances := mkReplicationGroupInstances(false)
+	test.S(t).ExpectTrue(instances[0].replicationGroupUpstreamKey() == nil)
+	test.S(t).ExpectTrue(instances[1].replicationGroupUpstreamKey().Equals(&instances[0].Key))
+	test.S(t).ExpectTrue(instances[2].replicationGroupUpstreamKey().Equals(&instances[0].Key))
+}
+
+func TestReplicationGroupUpstreamKeyNoPrimary(t *testing.T) {
+	instances := mkReplicationGroupInstances(true)
+	instances[0].ReplicationGroupPrimaryInstanceKey = InstanceKey{}
+	test.S(t).ExpectTrue(instances[0].replicationGroupUpstreamKey() == nil)
+
+	nonMember := NewInstance()
+	nonMember.Key = InstanceKey{Hostname: "async1", Port: 3306}
+	test.S(t).ExpectFalse(nonMember.IsReplicationGroupMember())
+	test.S(t).ExpectTrue(nonMember.replicationGroupUpstreamKey() == nil)
+}
+
+func TestCanReplicateFromWithinReplicationGroup(t *testing.T) {
+	instances := mkReplicationGroupInstances(true)
+	_, err := instances[0].CanReplicateFrom(instances[1])
+	test.S(t).ExpectNotNil(err)
+
+	replica := NewInstance()
+	replica.Key = InstanceKey{Hostname: "async1", Port: 3306}
+	replica.ServerID = 100
+	replica.Version = "8.0.20"
+	test.S(t).ExpectFalse(replica.IsReplicationGroupPeerOf(instances[1]))
+	canReplicate, err := replica.CanReplicateFrom(instances[1])
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(canReplicate)
+
+	otherGroup := mkReplicationGroupInstances(true)
+	otherGroup[1].ReplicationGroupName = "0e8b3c4f-aab4-11df-86ab-c80aa9429562"
+	test.S(t).ExpectFalse(otherGroup[1].IsReplicationGroupPeerOf(instances[1]))
+}
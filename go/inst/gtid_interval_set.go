@@ -0,0 +1,268 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GtidInterval is a closed interval of transaction numbers, e.g. 1-10539
+type GtidInterval struct {
+	Start int64
+	End   int64
+}
+
+// GtidIntervalSet is a native representation of an Oracle GTID set. Per UUID, it holds sorted,
+// non-overlapping and non-adjacent intervals, such that memory is proportional to the number of
+// intervals rather than to the number of transactions, and set operations do not require a server.
+type GtidIntervalSet struct {
+	intervals map[string][]GtidInterval
+}
+
+func NewGtidIntervalSet() *GtidIntervalSet {
+	return &GtidIntervalSet{intervals: make(map[string][]GtidInterval)}
+}
+
+// parseGtidInterval parses "7" or "1-10539"
+func parseGtidInterval(token string) (interval GtidInterval, err error) {
+	dash := strings.IndexByte(token, '-')
+	if dash < 0 {
+		if interval.Start, err = strconv.ParseInt(token, 10, 64); err != nil {
+			return interval, fmt.Errorf("Cannot parse GTID interval: %s", token)
+		}
+		interval.End = interval.Start
+	} else {
+		if interval.Start, err = strconv.ParseInt(token[:dash], 10, 64); err != nil {
+			return interval, fmt.Errorf("Cannot parse GTID interval: %s", token)
+		}
+		if interval.End, err = strconv.ParseInt(token[dash+1:], 10, 64); err != nil {
+			return interval, fmt.Errorf("Cannot parse GTID interval: %s", token)
+		}
+	}
+	if interval.Start < 1 || interval.End < interval.Start {
+		return interval, fmt.Errorf("Invalid GTID interval: %s", token)
+	}
+	return interval, nil
+}
+
+// ParseGtidIntervalSet parses a GTID set as depicted by Executed_Gtid_Set, @@gtid_purged etc.
+// Example input: `230ea8ea-81e3-11e4-972a-e25ec4bd140a:1-10539,
+// 316d193c-70e5-11e5-adb2-ecf4bb2262ff:1-8935:8984-6124596`
+func ParseGtidIntervalSet(gtidSet string) (*GtidIntervalSet, error) {
+	set := NewGtidIntervalSet()
+	for _, entry := range strings.Split(gtidSet, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tokens := strings.Split(entry, ":")
+		if len(tokens) < 2 || tokens[0] == "" {
+			return nil, fmt.Errorf("Cannot parse GTID set entry: %s", entry)
+		}
+		uuid := strings.ToLower(tokens[0])
+		for _, token := range tokens[1:] {
+			interval, err := parseGtidInterval(token)
+			if err != nil {
+				return nil, err
+			}
+			set.intervals[uuid] = append(set.intervals[uuid], interval)
+		}
+	}
+	for uuid, intervals := range set.intervals {
+		set.intervals[uuid] = normalizeGtidIntervals(intervals)
+	}
+	return set, nil
+}
+
+// normalizeGtidIntervals sorts given intervals and merges overlapping or adjacent ones, in place
+func normalizeGtidIntervals(intervals []GtidInterval) []GtidInterval {
+	if !sort.SliceIsSorted(intervals, func(i, j int) bool { return intervals[i].Start < intervals[j].Start }) {
+		sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start < intervals[j].Start })
+	}
+	merged := intervals[:0]
+	for _, interval := range intervals {
+		if last := len(merged) - 1; last >= 0 && interval.Start <= merged[last].End+1 {
+			if interval.End > merged[last].End {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// AddInterval adds an interval of transactions of given UUID, merging with existing intervals as needed
+func (this *GtidIntervalSet) AddInterval(uuid string, interval GtidInterval) {
+	intervals := this.intervals[uuid]
+	// first interval which ends at or after interval.Start-1, i.e. may merge with or follow the new interval
+	i := sort.Search(len(intervals), func(i int) bool { return intervals[i].End >= interval.Start-1 })
+	j := i
+	for j < len(intervals) && intervals[j].Start <= interval.End+1 {
+		if intervals[j].Start < interval.Start {
+			interval.Start = intervals[j].Start
+		}
+		if intervals[j].End > interval.End {
+			interval.End = intervals[j].End
+		}
+		j++
+	}
+	if j > i {
+		// replace merged intervals by the single new one
+		intervals[i] = interval
+		intervals = append(intervals[:i+1], intervals[j:]...)
+	} else {
+		intervals = append(intervals, GtidInterval{})
+		copy(intervals[i+1:], intervals[i:])
+		intervals[i] = interval
+	}
+	this.intervals[uuid] = intervals
+}
+
+// UUIDs returns the UUIDs in this set, sorted
+func (this *GtidIntervalSet) UUIDs() (uuids []string) {
+	for uuid := range this.intervals {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+	return uuids
+}
+
+// Intervals returns the intervals of given UUID
+func (this *GtidIntervalSet) Intervals(uuid string) []GtidInterval {
+	return this.intervals[uuid]
+}
+
+func (this *GtidIntervalSet) IsEmpty() bool {
+	return len(this.intervals) == 0
+}
+
+// Union returns a new set, with all transactions in this set or in the other
+func (this *GtidIntervalSet) Union(other *GtidIntervalSet) *GtidIntervalSet {
+	result := NewGtidIntervalSet()
+	for uuid, intervals := range this.intervals {
+		result.intervals[uuid] = append([]GtidInterval{}, intervals...)
+	}
+	for uuid, intervals := range other.intervals {
+		for _, interval := range intervals {
+			result.AddInterval(uuid, interval)
+		}
+	}
+	return result
+}
+
+// subtractIntervals subtracts sorted intervals b from sorted intervals a
+func subtractIntervals(a, b []GtidInterval) (result []GtidInterval) {
+	j := 0
+	for _, interval := range a {
+		start := interval.Start
+		for j < len(b) && b[j].End < start {
+			j++
+		}
+		k := j
+		for k < len(b) && b[k].Start <= interval.End {
+			if b[k].Start > start {
+				result = append(result, GtidInterval{Start: start, End: b[k].Start - 1})
+			}
+			start = b[k].End + 1
+			if start > interval.End {
+				break
+			}
+			k++
+		}
+		if start <= interval.End {
+			result = append(result, GtidInterval{Start: start, End: interval.End})
+		}
+	}
+	return result
+}
+
+// Subtract returns a new set, with transactions in this set which are not in the other.
+// This is the equivalent of GTID_SUBTRACT(this, other).
+func (this *GtidIntervalSet) Subtract(other *GtidIntervalSet) *GtidIntervalSet {
+	result := NewGtidIntervalSet()
+	for uuid, intervals := range this.intervals {
+		otherIntervals, found := other.intervals[uuid]
+		if !found {
+			result.intervals[uuid] = append([]GtidInterval{}, intervals...)
+			continue
+		}
+		if remaining := subtractIntervals(intervals, otherIntervals); len(remaining) > 0 {
+			result.intervals[uuid] = remaining
+		}
+	}
+	return result
+}
+
+// Contains returns true when all transactions in the other set are also in this set.
+// This is the equivalent of GTID_SUBSET(other, this).
+func (this *GtidIntervalSet) Contains(other *GtidIntervalSet) bool {
+	for uuid, otherIntervals := range other.intervals {
+		if len(subtractIntervals(otherIntervals, this.intervals[uuid])) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsTransaction returns true when given transaction is in this set
+func (this *GtidIntervalSet) ContainsTransaction(uuid string, transactionNumber int64) bool {
+	intervals := this.intervals[strings.ToLower(uuid)]
+	i := sort.Search(len(intervals), func(i int) bool { return intervals[i].End >= transactionNumber })
+	return i < len(intervals) && intervals[i].Start <= transactionNumber
+}
+
+// Equals returns true when both sets hold the exact same transactions
+func (this *GtidIntervalSet) Equals(other *GtidIntervalSet) bool {
+	return this.Contains(other) && other.Contains(this)
+}
+
+// String returns the set in MySQL notation, with UUIDs sorted
+func (this *GtidIntervalSet) String() string {
+	var builder strings.Builder
+	for i, uuid := range this.UUIDs() {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(uuid)
+		for _, interval := range this.intervals[uuid] {
+			builder.WriteByte(':')
+			builder.WriteString(strconv.FormatInt(interval.Start, 10))
+			if interval.End != interval.Start {
+				builder.WriteByte('-')
+				builder.WriteString(strconv.FormatInt(interval.End, 10))
+			}
+		}
+	}
+	return builder.String()
+}
+
+// subtractGtidSets natively computes GTID_SUBTRACT(gtidSet, gtidSubset), returning the result in MySQL notation
+func subtractGtidSets(gtidSet string, gtidSubset string) (string, error) {
+	set, err := ParseGtidIntervalSet(gtidSet)
+	if err != nil {
+		return "", err
+	}
+	subset, err := ParseGtidIntervalSet(gtidSubset)
+	if err != nil {
+		return "", err
+	}
+	return set.Subtract(subset).String(), nil
+}
@@ -0,0 +1,141 @@
+package inst
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+const (
+	gtidUUID1 = "00020194-3333-3333-3333-333333333333"
+	gtidUUID2 = "00020192-1111-1111-1111-111111111111"
+)
+
+func TestParseGtidIntervalSet(t *testing.T) {
+	{
+		set, err := ParseGtidIntervalSet("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(set.IsEmpty())
+		test.S(t).ExpectEquals(set.String(), "")
+	}
+	{
+		set, err := ParseGtidIntervalSet(gtidUUID1 + ":1-7:10-20,\n" + gtidUUID2 + ":5")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(set.String(), gtidUUID2+":5,"+gtidUUID1+":1-7:10-20")
+	}
+	{
+		// overlapping and adjacent intervals are merged
+		set, err := ParseGtidIntervalSet(gtidUUID1 + ":10-20:1-7:8-9:15-25")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(set.String(), gtidUUID1+":1-25")
+	}
+	{
+		set, err := ParseGtidIntervalSet(strings.ToUpper(gtidUUID1) + ":3")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(set.String(), gtidUUID1+":3")
+	}
+	{
+		_, err := ParseGtidIntervalSet(gtidUUID1)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseGtidIntervalSet(gtidUUID1 + ":7-3")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseGtidIntervalSet(gtidUUID1 + ":a-b")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestGtidIntervalSetSubtract(t *testing.T) {
+	{
+		set, _ := ParseGtidIntervalSet(gtidUUID1 + ":1-100," + gtidUUID2 + ":1-5")
+		subset, _ := ParseGtidIntervalSet(gtidUUID1 + ":1-10:20:50-60:99-200")
+		test.S(t).ExpectEquals(set.Subtract(subset).String(), gtidUUID2+":1-5,"+gtidUUID1+":11-19:21-49:61-98")
+	}
+	{
+		set, _ := ParseGtidIntervalSet(gtidUUID1 + ":1-100")
+		subset, _ := ParseGtidIntervalSet(gtidUUID1 + ":1-100," + gtidUUID2 + ":1-5")
+		test.S(t).ExpectTrue(set.Subtract(subset).IsEmpty())
+	}
+	{
+		set, _ := ParseGtidIntervalSet(gtidUUID1 + ":5-10:20-30")
+		subset, _ := ParseGtidIntervalSet(gtidUUID1 + ":1-4:11-19:31-40")
+		test.S(t).ExpectEquals(set.Subtract(subset).String(), gtidUUID1+":5-10:20-30")
+	}
+	{
+		subtract, err := subtractGtidSets(gtidUUID1+":1-10", gtidUUID1+":3-4")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(subtract, gtidUUID1+":1-2:5-10")
+	}
+}
+
+func TestGtidIntervalSetUnion(t *testing.T) {
+	set, _ := ParseGtidIntervalSet(gtidUUID1 + ":1-10:20-30")
+	other, _ := ParseGtidIntervalSet(gtidUUID1 + ":11-15:25-40," + gtidUUID2 + ":1")
+	test.S(t).ExpectEquals(set.Union(other).String(), gtidUUID2+":1,"+gtidUUID1+":1-15:20-40")
+	// originals are unchanged
+	test.S(t).ExpectEquals(set.String(), gtidUUID1+":1-10:20-30")
+}
+
+func TestGtidIntervalSetContains(t *testing.T) {
+	set, _ := ParseGtidIntervalSet(gtidUUID1 + ":1-10:20-30," + gtidUUID2 + ":1-5")
+	{
+		other, _ := ParseGtidIntervalSet(gtidUUID1 + ":2-3:25")
+		test.S(t).ExpectTrue(set.Contains(other))
+	}
+	{
+		other, _ := ParseGtidIntervalSet(gtidUUID1 + ":9-11")
+		test.S(t).ExpectFalse(set.Contains(other))
+	}
+	{
+		other, _ := ParseGtidIntervalSet("00020193-2222-2222-2222-222222222222:1")
+		test.S(t).ExpectFalse(set.Contains(other))
+	}
+	test.S(t).ExpectTrue(set.Contains(NewGtidIntervalSet()))
+	test.S(t).ExpectTrue(set.ContainsTransaction(gtidUUID1, 20))
+	test.S(t).ExpectFalse(set.ContainsTransaction(gtidUUID1, 15))
+	test.S(t).ExpectFalse(set.ContainsTransaction(gtidUUID2, 6))
+
+	same, _ := ParseGtidIntervalSet(gtidUUID2 + ":1-3:4-5," + gtidUUID1 + ":20-30:1-10")
+	test.S(t).ExpectTrue(set.Equals(same))
+}
+
+func largeGtidSet(uuid string, intervals int, offset int) string {
+	tokens := []string{uuid}
+	for i := 0; i < intervals; i++ {
+		tokens = append(tokens, fmt.Sprintf("%d-%d", i*100+offset+1, i*100+offset+50))
+	}
+	return strings.Join(tokens, ":")
+}
+
+func BenchmarkParseGtidIntervalSet(b *testing.B) {
+	gtidSet := largeGtidSet(gtidUUID1, 5000, 0)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseGtidIntervalSet(gtidSet)
+	}
+}
+
+func BenchmarkGtidIntervalSetSubtract(b *testing.B) {
+	set, _ := ParseGtidIntervalSet(largeGtidSet(gtidUUID1, 5000, 0))
+	subset, _ := ParseGtidIntervalSet(largeGtidSet(gtidUUID1, 5000, 25))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set.Subtract(subset)
+	}
+}
+
+func BenchmarkGtidIntervalSetContains(b *testing.B) {
+	set, _ := ParseGtidIntervalSet(largeGtidSet(gtidUUID1, 5000, 0))
+	subset, _ := ParseGtidIntervalSet(largeGtidSet(gtidUUID1, 5000, 10))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set.Contains(subset)
+	}
+}
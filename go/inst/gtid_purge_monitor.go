@@ -0,0 +1,131 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+)
+
+// GTIDPurgeSample is a server's progress, as sampled at some time, in the transactions of a master's server UUID:
+// on the master, how far it has purged and executed them; on its replicas, how far they have executed them
+type GTIDPurgeSample struct {
+	Key                 InstanceKey
+	SourceUUID          string
+	PurgedTransaction   int64
+	ExecutedTransaction int64
+	RecordedAt          time.Time
+}
+
+// ReplicaGTIDPurgeForecast forecasts when a replica is to be unable to reconnect to its master, as the master
+// purges binary logs holding transactions the replica is yet to execute
+type ReplicaGTIDPurgeForecast struct {
+	Key                      InstanceKey
+	ExecutedTransaction      int64
+	ExecuteRatePerSecond     float64
+	TransactionsAheadOfPurge int64
+	AlreadyPurged            bool
+	ForecastKnown            bool
+	SecondsUntilPurged       int64
+	AtRisk                   bool
+	Warning                  string
+}
+
+// GTIDPurgeForecast is a master's gtid_purged advancement, and the forecast for each of its replicas
+type GTIDPurgeForecast struct {
+	MasterKey           InstanceKey
+	ClusterName         string
+	SourceUUID          string
+	PurgedTransaction   int64
+	ExecutedTransaction int64
+	PurgeRateKnown      bool
+	PurgeRatePerSecond  float64
+	BinlogExpireSeconds uint
+	Replicas            [](*ReplicaGTIDPurgeForecast)
+}
+
+// gtidSetLastTransaction returns the highest transaction number of given UUID in given GTID set; 0 when the set
+// has no transactions of the UUID
+func gtidSetLastTransaction(gtidSet string, uuid string) (int64, error) {
+	set, err := ParseGtidIntervalSet(gtidSet)
+	if err != nil {
+		return 0, err
+	}
+	intervals := set.Intervals(uuid)
+	if len(intervals) == 0 {
+		return 0, nil
+	}
+	return intervals[len(intervals)-1].End, nil
+}
+
+// gtidSampleRate returns the per-second advancement of a value over given samples, ordered by time: from the first
+// sample to the last. The rate is unknown with fewer than two samples, or with no time passing between them.
+func gtidSampleRate(samples []GTIDPurgeSample, value func(sample *GTIDPurgeSample) int64) (ratePerSecond float64, known bool) {
+	if len(samples) < 2 {
+		return 0, false
+	}
+	first := &samples[0]
+	last := &samples[len(samples)-1]
+	seconds := last.RecordedAt.Sub(first.RecordedAt).Seconds()
+	if seconds <= 0 {
+		return 0, false
+	}
+	return float64(value(last)-value(first)) / seconds, true
+}
+
+// approximateDuration depicts a forecast duration coarsely, e.g. "~3h"
+func approximateDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("~%dh", int64(d.Round(time.Hour)/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("~%dm", int64(d.Round(time.Minute)/time.Minute))
+	}
+	return fmt.Sprintf("~%ds", int64(d.Round(time.Second)/time.Second))
+}
+
+// forecastReplicaGTIDPurge forecasts when the master's purging overtakes the replica: the gap between the
+// transactions the replica has executed and those the master has purged closes at the purge rate less the
+// replica's execution rate. A replica is at risk when the gap is forecast to close within warningPeriod.
+func forecastReplicaGTIDPurge(forecast *ReplicaGTIDPurgeForecast, masterKey InstanceKey, purgedTransaction int64, purgeRatePerSecond float64, purgeRateKnown bool, replicaSamples []GTIDPurgeSample, warningPeriod time.Duration) {
+	forecast.TransactionsAheadOfPurge = forecast.ExecutedTransaction - purgedTransaction
+	if forecast.TransactionsAheadOfPurge < 0 {
+		forecast.AlreadyPurged = true
+		forecast.AtRisk = true
+		forecast.Warning = fmt.Sprintf("replica %+v is unable to reconnect: %+v has purged %d transactions it has not executed", forecast.Key, masterKey, -forecast.TransactionsAheadOfPurge)
+		return
+	}
+	if !purgeRateKnown {
+		return
+	}
+	executeRatePerSecond, executeRateKnown := gtidSampleRate(replicaSamples, func(sample *GTIDPurgeSample) int64 { return sample.ExecutedTransaction })
+	if executeRateKnown {
+		forecast.ExecuteRatePerSecond = executeRatePerSecond
+	}
+	closingRatePerSecond := purgeRatePerSecond - forecast.ExecuteRatePerSecond
+	if closingRatePerSecond <= 0 {
+		// The replica keeps ahead of purging
+		return
+	}
+	forecast.ForecastKnown = true
+	forecast.SecondsUntilPurged = int64(float64(forecast.TransactionsAheadOfPurge) / closingRatePerSecond)
+	untilPurged := time.Duration(forecast.SecondsUntilPurged) * time.Second
+	if untilPurged < warningPeriod {
+		forecast.AtRisk = true
+		forecast.Warning = fmt.Sprintf("replica %+v will be unable to reconnect to %+v in %s at current purge rate", forecast.Key, masterKey, approximateDuration(untilPurged))
+	}
+}
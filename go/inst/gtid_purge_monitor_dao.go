@@ -0,0 +1,234 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+// gtidPurgeWarningsCache keeps a replica's purge warning from being audited more than once an hour
+var gtidPurgeWarningsCache = cache.New(time.Hour, time.Minute)
+
+// isGTIDPurgeMonitoredMaster returns true for a GTID master whose purging is monitored. Replicas, intermediate
+// masters and co-masters alike, do not write transactions of their own server UUID, and are not monitored.
+func isGTIDPurgeMonitoredMaster(instance *Instance) bool {
+	return instance.SupportsOracleGTID && instance.LogBinEnabled && instance.ServerUUID != "" && !instance.IsReplica()
+}
+
+// readGTIDInstances reads the GTID instances of given cluster, or of all clusters
+func readGTIDInstances(clusterName string) ([](*Instance), error) {
+	condition := `
+			supports_oracle_gtid = 1
+			and (? = '' or cluster_name = ?)
+		`
+	return readInstancesByCondition(condition, sqlutils.Args(clusterName, clusterName), "")
+}
+
+// gtidPurgeSamplesOf returns the current progress of GTID masters, and of their replicas, in the masters'
+// transactions
+func gtidPurgeSamplesOf(instances [](*Instance)) (samples []GTIDPurgeSample) {
+	masters := make(map[InstanceKey]*Instance)
+	for _, instance := range instances {
+		if isGTIDPurgeMonitoredMaster(instance) {
+			masters[instance.Key] = instance
+		}
+	}
+	for _, instance := range instances {
+		sample := GTIDPurgeSample{Key: instance.Key}
+		if master, found := masters[instance.Key]; found {
+			sample.SourceUUID = master.ServerUUID
+			purgedTransaction, err := gtidSetLastTransaction(master.GtidPurged, master.ServerUUID)
+			if err != nil {
+				log.Errore(err)
+				continue
+			}
+			sample.PurgedTransaction = purgedTransaction
+		} else if master, found := masters[instance.MasterKey]; found {
+			sample.SourceUUID = master.ServerUUID
+		} else {
+			continue
+		}
+		executedTransaction, err := gtidSetLastTransaction(instance.ExecutedGtidSet, sample.SourceUUID)
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		sample.ExecutedTransaction = executedTransaction
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// RecordGTIDPurgeSamples samples the progress of GTID masters and their replicas, and expires samples older than
+// GTIDPurgeRateWindowMinutes
+func RecordGTIDPurgeSamples() error {
+	instances, err := readGTIDInstances("")
+	if err != nil {
+		return log.Errore(err)
+	}
+	for _, sample := range gtidPurgeSamplesOf(instances) {
+		sample := sample
+		writeFunc := func() error {
+			_, err := db.ExecOrchestrator(`
+				replace into gtid_purge_history (
+					hostname, port, source_uuid, purged_transaction, executed_transaction, recorded_timestamp
+				) values (
+					?, ?, ?, ?, ?, NOW()
+				)
+				`, sample.Key.Hostname, sample.Key.Port, sample.SourceUUID, sample.PurgedTransaction, sample.ExecutedTransaction,
+			)
+			return log.Errore(err)
+		}
+		if err := ExecDBWriteFunc(writeFunc); err != nil {
+			return err
+		}
+	}
+	_, err = db.ExecOrchestrator(`
+			delete from gtid_purge_history
+			where
+				recorded_timestamp < NOW() - INTERVAL ? MINUTE
+		`, config.Config.GTIDPurgeRateWindowMinutes,
+	)
+	return log.Errore(err)
+}
+
+// readGTIDPurgeSamples reads the samples within GTIDPurgeRateWindowMinutes, per instance, oldest first
+func readGTIDPurgeSamples() (map[InstanceKey][]GTIDPurgeSample, error) {
+	samples := make(map[InstanceKey][]GTIDPurgeSample)
+	query := `
+		select
+			hostname, port, source_uuid, purged_transaction, executed_transaction,
+			unix_timestamp(recorded_timestamp) as recorded_unixtime
+		from
+			gtid_purge_history
+		where
+			recorded_timestamp >= NOW() - INTERVAL ? MINUTE
+		order by
+			hostname, port, recorded_timestamp
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(config.Config.GTIDPurgeRateWindowMinutes), func(m sqlutils.RowMap) error {
+		sample := GTIDPurgeSample{
+			Key:                 InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			SourceUUID:          m.GetString("source_uuid"),
+			PurgedTransaction:   m.GetInt64("purged_transaction"),
+			ExecutedTransaction: m.GetInt64("executed_transaction"),
+			RecordedAt:          time.Unix(m.GetInt64("recorded_unixtime"), 0),
+		}
+		samples[sample.Key] = append(samples[sample.Key], sample)
+		return nil
+	})
+	return samples, log.Errore(err)
+}
+
+// samplesOfSource filters given samples down to those of given source UUID
+func samplesOfSource(samples []GTIDPurgeSample, sourceUUID string) (filtered []GTIDPurgeSample) {
+	for _, sample := range samples {
+		if sample.SourceUUID == sourceUUID {
+			filtered = append(filtered, sample)
+		}
+	}
+	return filtered
+}
+
+// ReadGTIDPurgeForecasts forecasts, for the GTID masters of given cluster, or of all clusters, when each of their
+// replicas is to be unable to reconnect, at the masters' current purge rates
+func ReadGTIDPurgeForecasts(clusterName string) ([](*GTIDPurgeForecast), error) {
+	instances, err := readGTIDInstances(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := readGTIDPurgeSamples()
+	if err != nil {
+		return nil, err
+	}
+	currentSamples := make(map[InstanceKey]GTIDPurgeSample)
+	for _, sample := range gtidPurgeSamplesOf(instances) {
+		currentSamples[sample.Key] = sample
+	}
+	warningPeriod := time.Duration(config.Config.GTIDPurgeWarningHours) * time.Hour
+
+	forecasts := [](*GTIDPurgeForecast){}
+	for _, master := range instances {
+		if !isGTIDPurgeMonitoredMaster(master) {
+			continue
+		}
+		current, found := currentSamples[master.Key]
+		if !found {
+			continue
+		}
+		forecast := &GTIDPurgeForecast{
+			MasterKey:           master.Key,
+			ClusterName:         master.ClusterName,
+			SourceUUID:          master.ServerUUID,
+			PurgedTransaction:   current.PurgedTransaction,
+			ExecutedTransaction: current.ExecutedTransaction,
+			BinlogExpireSeconds: master.BinlogExpireSeconds,
+			Replicas:            [](*ReplicaGTIDPurgeForecast){},
+		}
+		forecast.PurgeRatePerSecond, forecast.PurgeRateKnown = gtidSampleRate(samplesOfSource(samples[master.Key], master.ServerUUID), func(sample *GTIDPurgeSample) int64 { return sample.PurgedTransaction })
+		for _, replica := range instances {
+			if !replica.MasterKey.Equals(&master.Key) {
+				continue
+			}
+			replicaCurrent, found := currentSamples[replica.Key]
+			if !found {
+				continue
+			}
+			replicaForecast := &ReplicaGTIDPurgeForecast{Key: replica.Key, ExecutedTransaction: replicaCurrent.ExecutedTransaction}
+			forecastReplicaGTIDPurge(replicaForecast, master.Key, forecast.PurgedTransaction, forecast.PurgeRatePerSecond, forecast.PurgeRateKnown, samplesOfSource(samples[replica.Key], master.ServerUUID), warningPeriod)
+			forecast.Replicas = append(forecast.Replicas, replicaForecast)
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	return forecasts, nil
+}
+
+// MonitorGTIDPurge samples the progress of GTID masters and their replicas, and audits a warning for each replica
+// forecast to be unable to reconnect within GTIDPurgeWarningHours. Does nothing unless GTIDPurgeWarningHours is set.
+func MonitorGTIDPurge() error {
+	if config.Config.GTIDPurgeWarningHours == 0 {
+		return nil
+	}
+	if err := RecordGTIDPurgeSamples(); err != nil {
+		return err
+	}
+	forecasts, err := ReadGTIDPurgeForecasts("")
+	if err != nil {
+		return err
+	}
+	for _, forecast := range forecasts {
+		for _, replica := range forecast.Replicas {
+			if !replica.AtRisk {
+				continue
+			}
+			if _, found := gtidPurgeWarningsCache.Get(replica.Key.StringCode()); found {
+				continue
+			}
+			gtidPurgeWarningsCache.Set(replica.Key.StringCode(), true, cache.DefaultExpiration)
+			log.Warningf("gtid-purge-warning: %s", replica.Warning)
+			AuditOperation("gtid-purge-warning", &replica.Key, replica.Warning)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+const gtidPurgeTestUUID = "230ea8ea-81e3-11e4-972a-e25ec4bd140a"
+
+func TestGTIDSetLastTransaction(t *testing.T) {
+	{
+		last, err := gtidSetLastTransaction("230ea8ea-81e3-11e4-972a-e25ec4bd140a:1-10539,316d193c-70e5-11e5-adb2-ecf4bb2262ff:1-8935:8984-6124596", gtidPurgeTestUUID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(last, int64(10539))
+	}
+	{
+		last, err := gtidSetLastTransaction("316d193c-70e5-11e5-adb2-ecf4bb2262ff:1-8935:8984-6124596", "316d193c-70e5-11e5-adb2-ecf4bb2262ff")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(last, int64(6124596))
+	}
+	{
+		last, err := gtidSetLastTransaction("", gtidPurgeTestUUID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(last, int64(0))
+	}
+}
+
+func TestApproximateDuration(t *testing.T) {
+	test.S(t).ExpectEquals(approximateDuration(3*time.Hour+10*time.Minute), "~3h")
+	test.S(t).ExpectEquals(approximateDuration(42*time.Minute), "~42m")
+	test.S(t).ExpectEquals(approximateDuration(9*time.Second), "~9s")
+}
+
+func TestForecastReplicaGTIDPurge(t *testing.T) {
+	masterKey := InstanceKey{Hostname: "master", Port: 3306}
+	start := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	stoppedReplicaSamples := []GTIDPurgeSample{
+		{SourceUUID: gtidPurgeTestUUID, ExecutedTransaction: 50000, RecordedAt: start},
+		{SourceUUID: gtidPurgeTestUUID, ExecutedTransaction: 50000, RecordedAt: start.Add(time.Hour)},
+	}
+	purgeSamples := []GTIDPurgeSample{
+		{SourceUUID: gtidPurgeTestUUID, PurgedTransaction: 28400, RecordedAt: start},
+		{SourceUUID: gtidPurgeTestUUID, PurgedTransaction: 32000, RecordedAt: start.Add(time.Hour)},
+	}
+	purgeRate, known := gtidSampleRate(purgeSamples, func(sample *GTIDPurgeSample) int64 { return sample.PurgedTransaction })
+	test.S(t).ExpectTrue(known)
+	test.S(t).ExpectEquals(purgeRate, float64(1))
+	{
+		// Replication stopped: the master purges 1 transaction per second, 18000 transactions short of the replica
+		forecast := &ReplicaGTIDPurgeForecast{Key: InstanceKey{Hostname: "replica", Port: 3306}, ExecutedTransaction: 50000}
+		forecastReplicaGTIDPurge(forecast, masterKey, 32000, purgeRate, known, stoppedReplicaSamples, 6*time.Hour)
+		test.S(t).ExpectTrue(forecast.ForecastKnown)
+		test.S(t).ExpectEquals(forecast.TransactionsAheadOfPurge, int64(18000))
+		test.S(t).ExpectEquals(forecast.SecondsUntilPurged, int64(18000))
+		test.S(t).ExpectTrue(forecast.AtRisk)
+		test.S(t).ExpectEquals(forecast.Warning, "replica replica:3306 will be unable to reconnect to master:3306 in ~5h at current purge rate")
+	}
+	{
+		// Beyond the warning period
+		forecast := &ReplicaGTIDPurgeForecast{ExecutedTransaction: 50000}
+		forecastReplicaGTIDPurge(forecast, masterKey, 32000, purgeRate, known, stoppedReplicaSamples, time.Hour)
+		test.S(t).ExpectTrue(forecast.ForecastKnown)
+		test.S(t).ExpectFalse(forecast.AtRisk)
+	}
+	{
+		// Replicating faster than purging
+		replicatingSamples := []GTIDPurgeSample{
+			{SourceUUID: gtidPurgeTestUUID, ExecutedTransaction: 40000, RecordedAt: start},
+			{SourceUUID: gtidPurgeTestUUID, ExecutedTransaction: 50000, RecordedAt: start.Add(time.Hour)},
+		}
+		forecast := &ReplicaGTIDPurgeForecast{ExecutedTransaction: 50000}
+		forecastReplicaGTIDPurge(forecast, masterKey, 32000, purgeRate, known, replicatingSamples, 6*time.Hour)
+		test.S(t).ExpectFalse(forecast.ForecastKnown)
+		test.S(t).ExpectFalse(forecast.AtRisk)
+	}
+	{
+		// Already purged
+		forecast := &ReplicaGTIDPurgeForecast{ExecutedTransaction: 30000}
+		forecastReplicaGTIDPurge(forecast, masterKey, 32000, purgeRate, known, stoppedReplicaSamples, 6*time.Hour)
+		test.S(t).ExpectTrue(forecast.AlreadyPurged)
+		test.S(t).ExpectTrue(forecast.AtRisk)
+	}
+	{
+		// Purge rate not yet known
+		forecast := &ReplicaGTIDPurgeForecast{ExecutedTransaction: 50000}
+		forecastReplicaGTIDPurge(forecast, masterKey, 32000, 0, false, stoppedReplicaSamples, 6*time.Hour)
+		test.S(t).ExpectFalse(forecast.ForecastKnown)
+		test.S(t).ExpectFalse(forecast.AtRisk)
+	}
+}
@@ -0,0 +1,276 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/openark/golib/log"
+)
+
+type GTIDRollbackMethod string
+
+const (
+	GTIDRollbackNone      GTIDRollbackMethod = "none"
+	GTIDRollbackFlashback GTIDRollbackMethod = "flashback"
+	GTIDRollbackRebuild   GTIDRollbackMethod = "rebuild"
+)
+
+const (
+	GTIDRollbackStepStopReplication   = "stop-replication"
+	GTIDRollbackStepFlashback         = "flashback"
+	GTIDRollbackStepRebuild           = "rebuild"
+	GTIDRollbackStepResetGTIDPurged   = "reset-gtid-purged"
+	GTIDRollbackStepResumeReplication = "resume-replication"
+)
+
+// GTIDRollbackStep is a single step in rolling back a replica's divergent transactions. Manual steps are performed
+// by the operator, outside orchestrator. Destructive steps are only executed given the step's Token, which the
+// operator obtains by reviewing the current plan.
+type GTIDRollbackStep struct {
+	Name        string
+	Description string
+	Destructive bool
+	Manual      bool
+	Token       string
+}
+
+// GTIDRollbackPlan describes how to rid a replica of transactions its cluster's master does not have, e.g. such
+// which were replicated from a hijacked master during a split brain.
+type GTIDRollbackPlan struct {
+	Key              InstanceKey
+	MasterKey        InstanceKey
+	DivergentGtidSet string
+	BinaryLogs       []string
+	BinlogsAvailable bool
+	Method           GTIDRollbackMethod
+	Reasons          []string
+	Steps            []GTIDRollbackStep
+}
+
+// String returns the plan in human readable form, a line per step
+func (this *GTIDRollbackPlan) String() string {
+	lines := []string{
+		fmt.Sprintf("%s: divergent from %s: %s", this.Key.DisplayString(), this.MasterKey.DisplayString(), this.DivergentGtidSet),
+		fmt.Sprintf("method: %s (%s)", this.Method, strings.Join(this.Reasons, "; ")),
+	}
+	if len(this.BinaryLogs) > 0 {
+		lines = append(lines, fmt.Sprintf("binary logs: %s", strings.Join(this.BinaryLogs, ", ")))
+	}
+	for i, step := range this.Steps {
+		var attributes []string
+		if step.Manual {
+			attributes = append(attributes, "manual")
+		}
+		if step.Destructive {
+			attributes = append(attributes, "destructive")
+		}
+		if step.Destructive && !step.Manual {
+			attributes = append(attributes, fmt.Sprintf("confirm=%s", step.Token))
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s [%s]: %s", i+1, step.Name, strings.Join(attributes, ","), step.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// gtidRollbackStepToken identifies a step in the plan for given state. It changes whenever the divergent set
+// or the master does, such that a confirmation only applies to the plan which the operator reviewed.
+func gtidRollbackStepToken(instanceKey InstanceKey, masterKey InstanceKey, divergentGtidSet string, stepName string) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(strings.Join([]string{instanceKey.StringCode(), masterKey.StringCode(), divergentGtidSet, stepName}, "|")))
+	return hex.EncodeToString(hasher.Sum(nil))[0:12]
+}
+
+// chooseGTIDRollbackMethod picks flashback when the divergent transactions are in the replica's binary logs, in full row
+// image, and the replica has no replicas of its own; and otherwise rebuild. It returns the reasons for its choice.
+func chooseGTIDRollbackMethod(instance *Instance, binlogsAvailable bool) (method GTIDRollbackMethod, reasons []string) {
+	if !binlogsAvailable {
+		reasons = append(reasons, "divergent transactions are not (all) in the binary logs")
+	}
+	if instance.Binlog_format != "ROW" {
+		reasons = append(reasons, fmt.Sprintf("binlog_format is %s, flashback requires ROW", instance.Binlog_format))
+	}
+	if instance.BinlogRowImage != "" && instance.BinlogRowImage != "FULL" {
+		reasons = append(reasons, fmt.Sprintf("binlog_row_image is %s, flashback requires FULL", instance.BinlogRowImage))
+	}
+	if len(instance.SlaveHosts) > 0 {
+		reasons = append(reasons, fmt.Sprintf("has %d replicas, which applied the divergent transactions as well", len(instance.SlaveHosts)))
+	}
+	if len(reasons) > 0 {
+		return GTIDRollbackRebuild, reasons
+	}
+	return GTIDRollbackFlashback, []string{"divergent transactions are in the binary logs, in full row image"}
+}
+
+// gtidRollbackSteps lists the steps of given plan
+func gtidRollbackSteps(plan *GTIDRollbackPlan) (steps []GTIDRollbackStep) {
+	step := func(name string, description string, destructive bool, manual bool) GTIDRollbackStep {
+		return GTIDRollbackStep{
+			Name:        name,
+			Description: description,
+			Destructive: destructive,
+			Manual:      manual,
+			Token:       gtidRollbackStepToken(plan.Key, plan.MasterKey, plan.DivergentGtidSet, name),
+		}
+	}
+	replica := plan.Key.DisplayString()
+	master := plan.MasterKey.DisplayString()
+	if plan.Method != GTIDRollbackNone {
+		steps = append(steps, step(GTIDRollbackStepStopReplication, fmt.Sprintf("Stop replication on %s and set it read_only, so that it applies no further transactions", replica), false, false))
+	}
+	switch plan.Method {
+	case GTIDRollbackFlashback:
+		steps = append(steps, step(GTIDRollbackStepFlashback, fmt.Sprintf("Revert transactions %s on %s by applying their reversed row events, found in binary logs %s, using a flashback tool (e.g. mysqlbinlog --flashback, binlog2sql)", plan.DivergentGtidSet, replica, strings.Join(plan.BinaryLogs, ", ")), true, true))
+		steps = append(steps, step(GTIDRollbackStepResetGTIDPurged, fmt.Sprintf("RESET MASTER on %s and set its gtid_purged to its executed set, less the transactions %s lacks, including those applied by the flashback", replica, master), true, false))
+	case GTIDRollbackRebuild:
+		steps = append(steps, step(GTIDRollbackStepRebuild, fmt.Sprintf("Re-provision %s from a backup or clone of %s, or of one of its healthy replicas, discarding the data of %s", replica, master, replica), true, true))
+	}
+	steps = append(steps, step(GTIDRollbackStepResumeReplication, fmt.Sprintf("Verify %s has no transactions which %s lacks, replicate it from %s via GTID and start replication", replica, master, master), false, false))
+	return steps
+}
+
+// readGTIDRollbackMaster returns the writable master of the instance's cluster, which the instance is to be consistent with
+func readGTIDRollbackMaster(instance *Instance) (*Instance, error) {
+	masters, err := ReadClusterWriteableMaster(instance.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(masters) == 0 {
+		return nil, fmt.Errorf("gtid-rollback: found no writable master for cluster %s", instance.ClusterName)
+	}
+	if masters[0].Key.Equals(&instance.Key) {
+		return nil, fmt.Errorf("gtid-rollback: %+v is the master of cluster %s", instance.Key, instance.ClusterName)
+	}
+	return ReadTopologyInstance(&masters[0].Key)
+}
+
+// GetGTIDRollbackPlan computes the transactions which given replica executed and its cluster's master did not, verifies
+// whether they are still in the replica's binary logs, and plans how to roll them back.
+func GetGTIDRollbackPlan(instanceKey *InstanceKey) (*GTIDRollbackPlan, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	if !instance.SupportsOracleGTID {
+		return nil, fmt.Errorf("gtid-rollback: %+v does not use oracle-gtid", *instanceKey)
+	}
+	master, err := readGTIDRollbackMaster(instance)
+	if err != nil {
+		return nil, err
+	}
+	divergentGtidSet, err := subtractGtidSets(instance.ExecutedGtidSet, master.ExecutedGtidSet)
+	if err != nil {
+		return nil, err
+	}
+	plan := &GTIDRollbackPlan{
+		Key:              instance.Key,
+		MasterKey:        master.Key,
+		DivergentGtidSet: divergentGtidSet,
+		BinaryLogs:       []string{},
+		Method:           GTIDRollbackNone,
+		Reasons:          []string{},
+	}
+	if divergentGtidSet == "" {
+		plan.Reasons = append(plan.Reasons, fmt.Sprintf("%+v has no transactions which %+v lacks", instance.Key, master.Key))
+	} else {
+		binaryLogs, err := locateGTIDSetBinaryLogs(instance, divergentGtidSet, "gtid-rollback")
+		if err == nil {
+			plan.BinaryLogs = binaryLogs
+			plan.BinlogsAvailable = true
+		} else {
+			log.Errore(err)
+		}
+		plan.Method, plan.Reasons = chooseGTIDRollbackMethod(instance, plan.BinlogsAvailable)
+	}
+	plan.Steps = gtidRollbackSteps(plan)
+	return plan, nil
+}
+
+// ExecuteGTIDRollbackStep executes a single step of the current GTID rollback plan of given replica. A destructive step
+// requires given token to match the step's token in the current plan.
+func ExecuteGTIDRollbackStep(instanceKey *InstanceKey, stepName string, token string) (*GTIDRollbackPlan, error) {
+	return ExecuteGTIDRollbackStepContext(context.Background(), instanceKey, stepName, token)
+}
+
+// ExecuteGTIDRollbackStepContext is ExecuteGTIDRollbackStep, with maintenance attributed as per given context
+func ExecuteGTIDRollbackStepContext(ctx context.Context, instanceKey *InstanceKey, stepName string, token string) (*GTIDRollbackPlan, error) {
+	plan, err := GetGTIDRollbackPlan(instanceKey)
+	if err != nil {
+		return plan, err
+	}
+	var step *GTIDRollbackStep
+	stepNames := []string{}
+	for i := range plan.Steps {
+		stepNames = append(stepNames, plan.Steps[i].Name)
+		if plan.Steps[i].Name == stepName {
+			step = &plan.Steps[i]
+		}
+	}
+	if step == nil {
+		return plan, fmt.Errorf("gtid-rollback: %s is not a step in the plan for %+v, whose steps are: %s", stepName, *instanceKey, strings.Join(stepNames, ", "))
+	}
+	if step.Manual {
+		return plan, fmt.Errorf("gtid-rollback: %s is a manual step, performed by the operator: %s", step.Name, step.Description)
+	}
+	if step.Destructive && token != step.Token {
+		return plan, fmt.Errorf("gtid-rollback: %s is destructive: %s. Review the current plan and confirm with its token for this step", step.Name, step.Description)
+	}
+
+	switch step.Name {
+	case GTIDRollbackStepStopReplication:
+		if _, err := StopSlaveContext(ctx, instanceKey); err != nil {
+			return plan, err
+		}
+		if _, err := SetReadOnly(instanceKey, true); err != nil {
+			return plan, err
+		}
+	case GTIDRollbackStepResetGTIDPurged:
+		instance, err := ReadTopologyInstance(instanceKey)
+		if err != nil {
+			return plan, err
+		}
+		if len(instance.SlaveHosts) > 0 {
+			return plan, fmt.Errorf("gtid-rollback: will not reset master on %+v because it has %d replicas", *instanceKey, len(instance.SlaveHosts))
+		}
+		if _, err := resetMasterExcludingGTIDs(ctx, instance, plan.DivergentGtidSet, false, "gtid-rollback"); err != nil {
+			return plan, err
+		}
+	case GTIDRollbackStepResumeReplication:
+		if plan.DivergentGtidSet != "" {
+			return plan, fmt.Errorf("gtid-rollback: %+v still has transactions which %+v lacks: %s", *instanceKey, plan.MasterKey, plan.DivergentGtidSet)
+		}
+		instance, err := ReadTopologyInstance(instanceKey)
+		if err != nil {
+			return plan, err
+		}
+		if instance.MasterKey.Equals(&plan.MasterKey) {
+			_, err = StartSlaveContext(ctx, instanceKey)
+		} else {
+			_, err = MoveBelowGTID(instanceKey, &plan.MasterKey)
+		}
+		if err != nil {
+			return plan, err
+		}
+	}
+	AuditOperation("gtid-rollback", instanceKey, fmt.Sprintf("executed step %s: %s", step.Name, step.Description))
+
+	return GetGTIDRollbackPlan(instanceKey)
+}
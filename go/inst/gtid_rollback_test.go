@@ -0,0 +1,68 @@
+package inst
+
+import (
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestChooseGTIDRollbackMethod(t *testing.T) {
+	{
+		instance := &Instance{Key: i710Key, Binlog_format: "ROW", BinlogRowImage: "FULL"}
+		method, reasons := chooseGTIDRollbackMethod(instance, true)
+		test.S(t).ExpectEquals(method, GTIDRollbackFlashback)
+		test.S(t).ExpectEquals(len(reasons), 1)
+	}
+	{
+		instance := &Instance{Key: i710Key, Binlog_format: "ROW", BinlogRowImage: "FULL"}
+		method, reasons := chooseGTIDRollbackMethod(instance, false)
+		test.S(t).ExpectEquals(method, GTIDRollbackRebuild)
+		test.S(t).ExpectEquals(len(reasons), 1)
+	}
+	{
+		instance := &Instance{Key: i710Key, Binlog_format: "STATEMENT", BinlogRowImage: "MINIMAL", SlaveHosts: InstanceKeyMap{i810Key: true}}
+		method, reasons := chooseGTIDRollbackMethod(instance, true)
+		test.S(t).ExpectEquals(method, GTIDRollbackRebuild)
+		test.S(t).ExpectEquals(len(reasons), 3)
+	}
+}
+
+func TestGTIDRollbackSteps(t *testing.T) {
+	stepNames := func(steps []GTIDRollbackStep) string {
+		names := []string{}
+		for _, step := range steps {
+			names = append(names, step.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	plan := &GTIDRollbackPlan{Key: i710Key, MasterKey: i720Key, DivergentGtidSet: "00020192-1111-1111-1111-111111111111:1-3", Method: GTIDRollbackFlashback}
+	{
+		steps := gtidRollbackSteps(plan)
+		test.S(t).ExpectEquals(stepNames(steps), strings.Join([]string{GTIDRollbackStepStopReplication, GTIDRollbackStepFlashback, GTIDRollbackStepResetGTIDPurged, GTIDRollbackStepResumeReplication}, ","))
+		test.S(t).ExpectTrue(steps[1].Manual)
+		test.S(t).ExpectTrue(steps[2].Destructive)
+		test.S(t).ExpectFalse(steps[2].Manual)
+		test.S(t).ExpectNotEquals(steps[1].Token, steps[2].Token)
+	}
+	{
+		plan.Method = GTIDRollbackRebuild
+		steps := gtidRollbackSteps(plan)
+		test.S(t).ExpectEquals(stepNames(steps), strings.Join([]string{GTIDRollbackStepStopReplication, GTIDRollbackStepRebuild, GTIDRollbackStepResumeReplication}, ","))
+	}
+	{
+		plan.Method = GTIDRollbackNone
+		plan.DivergentGtidSet = ""
+		steps := gtidRollbackSteps(plan)
+		test.S(t).ExpectEquals(stepNames(steps), GTIDRollbackStepResumeReplication)
+	}
+}
+
+func TestGTIDRollbackStepToken(t *testing.T) {
+	gtidSet := "00020192-1111-1111-1111-111111111111:1-3"
+	token := gtidRollbackStepToken(i710Key, i720Key, gtidSet, GTIDRollbackStepResetGTIDPurged)
+	test.S(t).ExpectEquals(len(token), 12)
+	test.S(t).ExpectEquals(token, gtidRollbackStepToken(i710Key, i720Key, gtidSet, GTIDRollbackStepResetGTIDPurged))
+	test.S(t).ExpectNotEquals(token, gtidRollbackStepToken(i710Key, i730Key, gtidSet, GTIDRollbackStepResetGTIDPurged))
+	test.S(t).ExpectNotEquals(token, gtidRollbackStepToken(i710Key, i720Key, "00020192-1111-1111-1111-111111111111:1-4", GTIDRollbackStepResetGTIDPurged))
+}
@@ -0,0 +1,166 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/util"
+)
+
+// InFlightOperation is a multi-step operation this orchestrator node is running right now: a recovery, a
+// graceful takeover, a mutating topology API request or an operation intent. It is identified by its
+// correlation ID, which is also that of the master changes it makes, and is listed until it ends.
+type InFlightOperation struct {
+	CorrelationID  string
+	Type           string
+	ClusterName    string
+	InstanceKeys   []InstanceKey
+	Step           string
+	Owner          string
+	Automated      bool
+	StartedAt      time.Time
+	StepStartedAt  time.Time
+	ElapsedSeconds int64
+}
+
+func (this *InFlightOperation) String() string {
+	return fmt.Sprintf("%s %s on %s: %s; running for %ds; owner: %s", this.Type, this.CorrelationID, this.ClusterName, this.Step, this.ElapsedSeconds, this.Owner)
+}
+
+// hasInstance returns true when given instance already participates in the operation
+func (this *InFlightOperation) hasInstance(instanceKey *InstanceKey) bool {
+	for _, key := range this.InstanceKeys {
+		if key.Equals(instanceKey) {
+			return true
+		}
+	}
+	return false
+}
+
+var inFlightOperationsMutex sync.Mutex
+var inFlightOperations = make(map[string]*InFlightOperation)
+
+// BeginInFlightOperation lists an operation as in flight, and returns its correlation ID: the one given or, when
+// empty, a generated one. The operation must be ended via EndInFlightOperation.
+func BeginInFlightOperation(correlationID string, operationType string, clusterName string, owner string, automated bool, instanceKeys ...InstanceKey) string {
+	if correlationID == "" {
+		correlationID = util.PrettyUniqueToken()
+	}
+	now := time.Now()
+	operation := &InFlightOperation{
+		CorrelationID: correlationID,
+		Type:          operationType,
+		ClusterName:   clusterName,
+		InstanceKeys:  []InstanceKey{},
+		Step:          "starting",
+		Owner:         owner,
+		Automated:     automated,
+		StartedAt:     now,
+		StepStartedAt: now,
+	}
+	for _, instanceKey := range instanceKeys {
+		instanceKey := instanceKey
+		if instanceKey.IsValid() && !operation.hasInstance(&instanceKey) {
+			operation.InstanceKeys = append(operation.InstanceKeys, instanceKey)
+		}
+	}
+	inFlightOperationsMutex.Lock()
+	defer inFlightOperationsMutex.Unlock()
+
+	inFlightOperations[correlationID] = operation
+	return correlationID
+}
+
+// EndInFlightOperation removes the operation of given correlation ID from those in flight. Ending an operation
+// which is not in flight is a no-op.
+func EndInFlightOperation(correlationID string) {
+	inFlightOperationsMutex.Lock()
+	defer inFlightOperationsMutex.Unlock()
+
+	delete(inFlightOperations, correlationID)
+}
+
+// SetInFlightOperationStep records the step the operation of given correlation ID is now at, and any instances
+// the step involves. It is a no-op when no such operation is in flight.
+func SetInFlightOperationStep(correlationID string, step string, instanceKeys ...InstanceKey) {
+	inFlightOperationsMutex.Lock()
+	defer inFlightOperationsMutex.Unlock()
+
+	operation, found := inFlightOperations[correlationID]
+	if !found {
+		return
+	}
+	operation.Step = step
+	operation.StepStartedAt = time.Now()
+	for _, instanceKey := range instanceKeys {
+		instanceKey := instanceKey
+		if instanceKey.IsValid() && !operation.hasInstance(&instanceKey) {
+			operation.InstanceKeys = append(operation.InstanceKeys, instanceKey)
+		}
+	}
+}
+
+// recordInFlightOperationMasterChange notes a master change as the current step of the operation which made it
+func recordInFlightOperationMasterChange(change *MasterChange) {
+	step := fmt.Sprintf("changed master of %s to %s", change.Key.DisplayString(), change.MasterKey.DisplayString())
+	SetInFlightOperationStep(change.CorrelationID, step, change.Key, change.MasterKey)
+}
+
+type inFlightOperationContextKey struct{}
+
+// WithInFlightOperation returns a context carrying the correlation ID of an in-flight operation
+func WithInFlightOperation(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, inFlightOperationContextKey{}, correlationID)
+}
+
+// InFlightOperationFromContext returns the correlation ID of the in-flight operation carried by given context, or
+// an empty string
+func InFlightOperationFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(inFlightOperationContextKey{}).(string)
+	return correlationID
+}
+
+// ReadInFlightOperations returns the operations in flight on given cluster, or on all clusters when clusterName is
+// empty, longest running first
+func ReadInFlightOperations(clusterName string) (operations []InFlightOperation) {
+	inFlightOperationsMutex.Lock()
+	defer inFlightOperationsMutex.Unlock()
+
+	now := time.Now()
+	operations = []InFlightOperation{}
+	for _, operation := range inFlightOperations {
+		if clusterName != "" && operation.ClusterName != clusterName {
+			continue
+		}
+		listed := *operation
+		listed.InstanceKeys = append([]InstanceKey{}, operation.InstanceKeys...)
+		listed.ElapsedSeconds = int64(now.Sub(operation.StartedAt).Seconds())
+		operations = append(operations, listed)
+	}
+	sort.SliceStable(operations, func(i, j int) bool {
+		if !operations[i].StartedAt.Equal(operations[j].StartedAt) {
+			return operations[i].StartedAt.Before(operations[j].StartedAt)
+		}
+		return operations[i].CorrelationID < operations[j].CorrelationID
+	})
+	return operations
+}
@@ -0,0 +1,47 @@
+package inst
+
+import (
+	"context"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestInFlightOperationLifecycle(t *testing.T) {
+	masterKey := InstanceKey{Hostname: "flight-master", Port: 3306}
+	replicaKey := InstanceKey{Hostname: "flight-replica", Port: 3306}
+
+	correlationID := BeginInFlightOperation("", "relocate", "flight:3306", "ops", false, replicaKey, replicaKey)
+	test.S(t).ExpectNotEquals(correlationID, "")
+
+	operations := ReadInFlightOperations("flight:3306")
+	test.S(t).ExpectEquals(len(operations), 1)
+	test.S(t).ExpectEquals(operations[0].Type, "relocate")
+	test.S(t).ExpectEquals(operations[0].Step, "starting")
+	test.S(t).ExpectEquals(len(operations[0].InstanceKeys), 1)
+
+	recordInFlightOperationMasterChange(NewMasterChange(&replicaKey, &masterKey, &InstanceKey{}, nil, "relocate", NewMasterChangeOrigin(MasterChangeCausePlanned, "ops", correlationID)))
+	operations = ReadInFlightOperations("flight:3306")
+	test.S(t).ExpectEquals(operations[0].Step, "changed master of flight-replica:3306 to flight-master:3306")
+	test.S(t).ExpectEquals(len(operations[0].InstanceKeys), 2)
+
+	// listed operations are copies
+	operations[0].InstanceKeys[0] = masterKey
+	test.S(t).ExpectTrue(ReadInFlightOperations("flight:3306")[0].InstanceKeys[0].Equals(&replicaKey))
+
+	test.S(t).ExpectEquals(len(ReadInFlightOperations("other:3306")), 0)
+
+	EndInFlightOperation(correlationID)
+	test.S(t).ExpectEquals(len(ReadInFlightOperations("flight:3306")), 0)
+
+	// steps of operations not in flight are ignored
+	SetInFlightOperationStep(correlationID, "too late")
+	EndInFlightOperation(correlationID)
+	test.S(t).ExpectEquals(len(ReadInFlightOperations("flight:3306")), 0)
+}
+
+func TestInFlightOperationContext(t *testing.T) {
+	test.S(t).ExpectEquals(InFlightOperationFromContext(context.Background()), "")
+	ctx := WithInFlightOperation(context.Background(), "flight-uid")
+	test.S(t).ExpectEquals(InFlightOperationFromContext(ctx), "flight-uid")
+}
@@ -17,6 +17,7 @@
 package inst
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strconv"
@@ -45,7 +46,11 @@ type Instance struct {
 	BinlogRowImage            string
 	LogBinEnabled             bool
 	LogSlaveUpdatesEnabled    bool
+	BinlogEncryption          bool
+	RelayLogEncryption        bool
 	SelfBinlogCoordinates     BinlogCoordinates
+	BinaryLogsSize            int64
+	BinlogExpireSeconds       uint
 	MasterKey                 InstanceKey
 	MasterUUID                string
 	AncestryUUID              string
@@ -55,39 +60,67 @@ type Instance struct {
 	ReplicationSQLThreadState ReplicationThreadState
 	ReplicationIOThreadState  ReplicationThreadState
 	HasReplicationFilters     bool
+	HasBinlogFilters          bool
 	GTIDMode                  string
 	SupportsOracleGTID        bool
 	UsingOracleGTID           bool
 	UsingMariaDBGTID          bool
 	UsingPseudoGTID           bool
+	PseudoGTIDUnsafe          bool
 	ReadBinlogCoordinates     BinlogCoordinates
 	ExecBinlogCoordinates     BinlogCoordinates
 	IsDetached                bool
 	RelaylogCoordinates       BinlogCoordinates
 	LastSQLError              string
 	LastIOError               string
+	IOThreadConnectionState   string        // per performance_schema.replication_connection_status: ON, OFF or CONNECTING; empty when unknown
+	LastHeartbeatTimestamp    string        // as reported by the replica; empty when no heartbeat was received
+	ReceivedHeartbeats        int64         // since replication last started
+	IOThreadConnectLatency    time.Duration // time spent by the IO thread in its ongoing connection attempt; 0 when connected
+	IOThreadReconnects        uint          // IO thread reconnects observed within IOThreadReconnectsWindowSeconds
 	SecondsBehindMaster       sql.NullInt64
 	SQLDelay                  uint
 	ExecutedGtidSet           string
 	GtidPurged                string
 	GtidErrant                string
+	SuperReadOnly             bool
 
 	masterExecutedGtidSet string // Not exported
 
-	SlaveLagSeconds                 sql.NullInt64
-	SlaveHosts                      InstanceKeyMap
-	ClusterName                     string
-	SuggestedClusterAlias           string
-	DataCenter                      string
-	Region                          string
-	PhysicalEnvironment             string
-	ReplicationDepth                uint
-	IsCoMaster                      bool
-	HasReplicationCredentials       bool
-	ReplicationCredentialsAvailable bool
-	SemiSyncEnforced                bool
-	SemiSyncMasterEnabled           bool
-	SemiSyncReplicaEnabled          bool
+	ioThreadConnectErrorNumber    int    // Not exported; most recent IO thread connection error, if any
+	ioThreadConnectErrorTimestamp string // Not exported
+
+	SlaveLagSeconds                   sql.NullInt64
+	ReplicationLagPercentileSeconds   sql.NullInt64
+	ReplicationLagPattern             ReplicationLagPattern
+	ClockSkewSeconds                  int64 // The instance's clock minus orchestrator's, as of last poll
+	TimeZoneOffsetSeconds             int64 // The instance's time zone offset from UTC
+	IsClockSkewed                     bool
+	SlaveHosts                        InstanceKeyMap
+	ClusterName                       string
+	SuggestedClusterAlias             string
+	DataCenter                        string
+	Region                            string
+	PhysicalEnvironment               string
+	Namespace                         string
+	ReplicationDepth                  uint
+	IsCoMaster                        bool
+	HasReplicationCredentials         bool
+	ReplicationCredentialsAvailable   bool
+	SemiSyncEnforced                  bool
+	SemiSyncMasterEnabled             bool
+	SemiSyncReplicaEnabled            bool
+	SemiSyncMasterWaitForReplicaCount uint
+	SemiSyncMasterClients             uint
+
+	ReplicationGroupName               string
+	ReplicationGroupIsSinglePrimary    bool
+	ReplicationGroupMemberState        string
+	ReplicationGroupMemberRole         string
+	ReplicationGroupMembers            InstanceKeyMap
+	ReplicationGroupPrimaryInstanceKey InstanceKey
+
+	Capabilities InstanceCapabilities
 
 	LastSeenTimestamp    string
 	IsLastCheckValid     bool
@@ -102,6 +135,7 @@ type Instance struct {
 	// reading an instance from the db.
 	IsCandidate          bool
 	PromotionRule        CandidatePromotionRule
+	PromotionPriority    int
 	IsDowntimed          bool
 	DowntimeReason       string
 	DowntimeOwner        string
@@ -118,8 +152,9 @@ type Instance struct {
 // NewInstance creates a new, empty instance
 func NewInstance() *Instance {
 	return &Instance{
-		SlaveHosts: make(map[InstanceKey]bool),
-		Problems:   []string{},
+		SlaveHosts:              make(map[InstanceKey]bool),
+		ReplicationGroupMembers: make(map[InstanceKey]bool),
+		Problems:                []string{},
 	}
 }
 
@@ -196,12 +231,16 @@ func (this *Instance) IsNDB() bool {
 	return strings.Contains(this.Version, "-ndb-")
 }
 
-// IsBinlogServer checks whether this is any type of a binlog server (currently only maxscale)
+// IsBinlogServer checks whether this is any type of a binlog server: MaxScale, or any other implementation
+// registered via RegisterBinlogServer or configured in BinlogServerDetectionQueries
 func (this *Instance) IsBinlogServer() bool {
-	if this.isMaxScale() {
-		return true
-	}
-	return false
+	return this.BinlogServerName() != ""
+}
+
+// BinlogServerName returns the implementation name of this binlog server, or an empty string if this is
+// not a binlog server
+func (this *Instance) BinlogServerName() string {
+	return binlogServerNameByVersion(this.Version)
 }
 
 // IsOracleMySQL checks whether this is an Oracle MySQL distribution
@@ -234,6 +273,8 @@ func (this *Instance) applyFlavorName() {
 		this.FlavorName = "Percona"
 	} else if this.isMaxScale() {
 		this.FlavorName = "MaxScale"
+	} else if this.IsBinlogServer() {
+		this.FlavorName = this.BinlogServerName()
 	} else {
 		this.FlavorName = "unknown"
 	}
@@ -350,44 +391,28 @@ func (this *Instance) IsDescendantOf(other *Instance) bool {
 // CanReplicateFrom uses heursitics to decide whether this instacne can practically replicate from other instance.
 // Checks are made to binlog format, version number, binary logs etc.
 func (this *Instance) CanReplicateFrom(other *Instance) (bool, error) {
-	if this.Key.Equals(&other.Key) {
-		return false, fmt.Errorf("instance cannot replicate from itself: %+v", this.Key)
-	}
-	if !other.LogBinEnabled {
-		return false, fmt.Errorf("instance does not have binary logs enabled: %+v", other.Key)
-	}
-	if other.IsReplica() {
-		if !other.LogSlaveUpdatesEnabled {
-			return false, fmt.Errorf("instance does not have log_slave_updates enabled: %+v", other.Key)
-		}
-		// OK for a master to not have log_slave_updates
-		// Not OK for a replica, for it has to relay the logs.
-	}
-	if this.IsSmallerMajorVersion(other) && !this.IsBinlogServer() {
-		return false, fmt.Errorf("instance %+v has version %s, which is lower than %s on %+v ", this.Key, this.Version, other.Version, other.Key)
-	}
-	if this.LogBinEnabled && this.LogSlaveUpdatesEnabled {
-		if this.IsSmallerBinlogFormat(other) {
-			return false, fmt.Errorf("Cannot replicate from %+v binlog format on %+v to %+v on %+v", other.Binlog_format, other.Key, this.Binlog_format, this.Key)
-		}
-	}
-	if config.Config.VerifyReplicationFilters {
-		if other.HasReplicationFilters && !this.HasReplicationFilters {
-			return false, fmt.Errorf("%+v has replication filters", other.Key)
+	return this.CanReplicateFromContext(context.Background(), other)
+}
+
+// CanReplicateFromContext is CanReplicateFrom, where given context may override version compatibility
+func (this *Instance) CanReplicateFromContext(ctx context.Context, other *Instance) (bool, error) {
+	for _, check := range replicationChecks {
+		if err := check.check(this, other); err != nil {
+			if check.name == versionReplicationCheckName && isVersionCompatibilityOverridden(ctx) {
+				AuditOperation("override-version-compatibility", &this.Key, fmt.Sprintf("version compatibility overridden: %+v", err))
+				continue
+			}
+			return false, err
 		}
 	}
-	if this.ServerID == other.ServerID && !this.IsBinlogServer() {
-		return false, fmt.Errorf("Identical server id: %+v, %+v both have %d", other.Key, this.Key, this.ServerID)
-	}
-	if this.ServerUUID == other.ServerUUID && this.ServerUUID != "" && !this.IsBinlogServer() {
-		return false, fmt.Errorf("Identical server UUID: %+v, %+v both have %s", other.Key, this.Key, this.ServerUUID)
-	}
-	if this.SQLDelay < other.SQLDelay && int64(other.SQLDelay) > int64(config.Config.ReasonableMaintenanceReplicationLagSeconds) {
-		return false, fmt.Errorf("%+v has higher SQL_Delay (%+v seconds) than %+v does (%+v seconds)", other.Key, other.SQLDelay, this.Key, this.SQLDelay)
-	}
 	return true, nil
 }
 
+// IsDelayedReplica returns true when this replica is intentionally delayed via MASTER_DELAY
+func (this *Instance) IsDelayedReplica() bool {
+	return this.SQLDelay > 0
+}
+
 // HasReasonableMaintenanceReplicationLag returns true when the replica lag is reasonable, and maintenance operations should have a green light to go.
 func (this *Instance) HasReasonableMaintenanceReplicationLag() bool {
 	// replicas with SQLDelay are a special case
@@ -508,6 +533,27 @@ func (this *Instance) descriptionTokens() (tokens []string) {
 		if this.UsingPseudoGTID {
 			extraTokens = append(extraTokens, "P-GTID")
 		}
+		if this.HasBinlogFilters {
+			extraTokens = append(extraTokens, "binlog-filters")
+		}
+		if this.IsReplicationGroupMember() {
+			extraTokens = append(extraTokens, fmt.Sprintf("GR:%s", strings.ToLower(this.ReplicationGroupMemberRole)))
+		}
+		if this.BinlogEncryption {
+			extraTokens = append(extraTokens, "enc")
+		}
+		if this.GtidErrant != "" {
+			extraTokens = append(extraTokens, "errant-GTID")
+		}
+		if this.SemiSyncMasterEnabled {
+			extraTokens = append(extraTokens, "semi-sync:master")
+		}
+		if this.SemiSyncReplicaEnabled {
+			extraTokens = append(extraTokens, "semi-sync:replica")
+		}
+		if this.SuperReadOnly {
+			extraTokens = append(extraTokens, "super-ro")
+		}
 		if this.IsDowntimed {
 			extraTokens = append(extraTokens, "downtimed")
 		}
@@ -0,0 +1,158 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+// instanceCapabilitiesCache caches probed capabilities per instance, such that they are only probed once an hour,
+// or when the instance's version changes
+var instanceCapabilitiesCache = cache.New(time.Hour, time.Minute)
+
+// InstanceCapabilities is the matrix of features an instance supports, as probed on the instance itself. Operations
+// consult it rather than infer support from the instance's version and flavor.
+type InstanceCapabilities struct {
+	Version                      string // the version of the instance when probed; empty when not yet probed
+	ShowReplicaStatus            bool
+	ClonePlugin                  bool
+	BinlogTransactionCompression bool
+	WaitForExecutedGTIDSet       bool
+	GTIDMode                     bool
+	SemiSyncWaitForReplicaCount  bool
+	PerformanceSchema            bool
+	ReplicationGroupMembersTable bool
+	ReplicationGroupMemberRole   bool
+	GroupReplicationSetAsPrimary bool
+	StatusByThreadTable          bool
+	ReplicationConnectionStatus  bool
+}
+
+// IsProbed returns true when the capabilities were probed on the instance
+func (this *InstanceCapabilities) IsProbed() bool {
+	return this.Version != ""
+}
+
+// ToJSONString marshals the capabilities as JSON. Unprobed capabilities marshal as an empty string.
+func (this *InstanceCapabilities) ToJSONString() string {
+	if !this.IsProbed() {
+		return ""
+	}
+	bytes, _ := json.Marshal(this)
+	return string(bytes)
+}
+
+// ReadJson unmarshals the capabilities from JSON. An empty string reads as unprobed capabilities.
+func (this *InstanceCapabilities) ReadJson(jsonString string) error {
+	if jsonString == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(jsonString), this)
+}
+
+// globalVariableExists returns true when given global variable is known to the server
+func globalVariableExists(db *sql.DB, variableName string) (exists bool) {
+	sqlutils.QueryRowsMap(db, fmt.Sprintf("show global variables like '%s'", variableName), func(m sqlutils.RowMap) error {
+		exists = true
+		return nil
+	})
+	return exists
+}
+
+// probeInstanceCapabilities probes the capabilities of the instance behind given connection. Each capability
+// is probed by exercising the feature or by looking it up; failure to do so means the capability is not supported.
+func probeInstanceCapabilities(db *sql.DB, version string) InstanceCapabilities {
+	capabilities := InstanceCapabilities{Version: version}
+
+	capabilities.ShowReplicaStatus = (sqlutils.QueryRowsMap(db, "show replica status", func(m sqlutils.RowMap) error { return nil }) == nil)
+	capabilities.WaitForExecutedGTIDSet = (sqlutils.QueryRowsMap(db, "select wait_for_executed_gtid_set('', 1)", func(m sqlutils.RowMap) error { return nil }) == nil)
+	capabilities.BinlogTransactionCompression = globalVariableExists(db, "binlog_transaction_compression")
+	capabilities.GTIDMode = globalVariableExists(db, "gtid_mode")
+	capabilities.SemiSyncWaitForReplicaCount = globalVariableExists(db, "rpl_semi_sync_master_wait_for_slave_count")
+
+	db.QueryRow("select count(*) > 0 from information_schema.plugins where plugin_name = 'clone' and plugin_status = 'ACTIVE'").Scan(&capabilities.ClonePlugin)
+	db.QueryRow("select @@global.performance_schema").Scan(&capabilities.PerformanceSchema)
+
+	query := `
+		select
+			table_name
+		from
+			information_schema.tables
+		where
+			table_schema = 'performance_schema'
+			and table_name in ('replication_group_members', 'status_by_thread', 'replication_connection_status')
+	`
+	sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		switch m.GetString("table_name") {
+		case "replication_group_members":
+			capabilities.ReplicationGroupMembersTable = true
+		case "status_by_thread":
+			capabilities.StatusByThreadTable = true
+		case "replication_connection_status":
+			capabilities.ReplicationConnectionStatus = true
+		}
+		return nil
+	})
+	if capabilities.ReplicationGroupMembersTable {
+		db.QueryRow(`
+			select count(*) > 0 from information_schema.columns
+			where table_schema = 'performance_schema' and table_name = 'replication_group_members' and column_name = 'member_role'
+		`).Scan(&capabilities.ReplicationGroupMemberRole)
+		db.QueryRow(`
+			select count(*) > 0 from information_schema.tables
+			where table_schema = 'performance_schema' and table_name = 'user_defined_functions'
+		`).Scan(&capabilities.GroupReplicationSetAsPrimary)
+		if capabilities.GroupReplicationSetAsPrimary {
+			db.QueryRow("select count(*) > 0 from performance_schema.user_defined_functions where udf_name = 'group_replication_set_as_primary'").Scan(&capabilities.GroupReplicationSetAsPrimary)
+		}
+	}
+	return capabilities
+}
+
+// readInstanceCapabilities returns the capabilities of given instance, whose version is already read, probing
+// them via given connection unless cached
+func readInstanceCapabilities(db *sql.DB, instance *Instance) InstanceCapabilities {
+	if cached, found := instanceCapabilitiesCache.Get(instance.Key.StringCode()); found {
+		if capabilities := cached.(InstanceCapabilities); capabilities.Version == instance.Version {
+			return capabilities
+		}
+	}
+	capabilities := probeInstanceCapabilities(db, instance.Version)
+	instanceCapabilitiesCache.Set(instance.Key.StringCode(), capabilities, cache.DefaultExpiration)
+	return capabilities
+}
+
+// ReadInstanceCapabilities returns the capabilities of given instance, as last probed
+func ReadInstanceCapabilities(instanceKey *InstanceKey) (*InstanceCapabilities, error) {
+	instance, found, err := ReadInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("instance not found: %+v", *instanceKey)
+	}
+	if !instance.Capabilities.IsProbed() {
+		return nil, fmt.Errorf("capabilities of %+v are not yet probed", *instanceKey)
+	}
+	return &instance.Capabilities, nil
+}
@@ -0,0 +1,36 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestInstanceCapabilitiesJSON(t *testing.T) {
+	{
+		capabilities := InstanceCapabilities{}
+		test.S(t).ExpectFalse(capabilities.IsProbed())
+		test.S(t).ExpectEquals(capabilities.ToJSONString(), "")
+
+		err := capabilities.ReadJson("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(capabilities.IsProbed())
+	}
+	{
+		capabilities := InstanceCapabilities{Version: "8.0.23", ShowReplicaStatus: true, ReplicationGroupMemberRole: true}
+		test.S(t).ExpectTrue(capabilities.IsProbed())
+
+		read := InstanceCapabilities{}
+		err := read.ReadJson(capabilities.ToJSONString())
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(read, capabilities)
+	}
+}
+
+func TestSupportsSemiSyncWaitForReplicaCount(t *testing.T) {
+	instance := NewInstance()
+	instance.Version = "5.7.30"
+	test.S(t).ExpectFalse(instance.supportsSemiSyncWaitForReplicaCount())
+	instance.Capabilities = InstanceCapabilities{Version: instance.Version, SemiSyncWaitForReplicaCount: true}
+	test.S(t).ExpectTrue(instance.supportsSemiSyncWaitForReplicaCount())
+}
@@ -201,40 +201,26 @@ func unrecoverableError(err error) bool {
 	return false
 }
 
-// Check if the instance is a MaxScale binlog server (a proxy not a real
+// Check if the instance is a binlog server (a proxy not a real
 // MySQL server) and also update the resolved hostname
-func (instance *Instance) checkMaxScale(db *sql.DB, latency *stopwatch.NamedStopwatch) (isMaxScale bool, resolvedHostname string, err error) {
-	if config.Config.SkipMaxScaleCheck {
-		return isMaxScale, resolvedHostname, err
-	}
-
+func (instance *Instance) checkBinlogServer(db *sql.DB, latency *stopwatch.NamedStopwatch) (isBinlogServer bool, resolvedHostname string, err error) {
 	latency.Start("instance")
-	err = sqlutils.QueryRowsMap(db, "show variables like 'maxscale%'", func(m sqlutils.RowMap) error {
-		if m.GetString("Variable_name") == "MAXSCALE_VERSION" {
-			originalVersion := m.GetString("Value")
-			if originalVersion == "" {
-				originalVersion = m.GetString("value")
-			}
-			if originalVersion == "" {
-				originalVersion = "0.0.0"
-			}
-			instance.Version = originalVersion + "-maxscale"
-			instance.ServerID = 0
-			instance.ServerUUID = ""
-			instance.Uptime = 0
-			instance.Binlog_format = "INHERIT"
-			instance.ReadOnly = true
-			instance.LogBinEnabled = true
-			instance.LogSlaveUpdatesEnabled = true
-			resolvedHostname = instance.Key.Hostname
-			latency.Start("backend")
-			UpdateResolvedHostname(resolvedHostname, resolvedHostname)
-			latency.Stop("backend")
-			isMaxScale = true
-		}
-		return nil
-	})
+	version, isBinlogServer, err := detectBinlogServer(db)
 	latency.Stop("instance")
+	if isBinlogServer {
+		instance.Version = version
+		instance.ServerID = 0
+		instance.ServerUUID = ""
+		instance.Uptime = 0
+		instance.Binlog_format = "INHERIT"
+		instance.ReadOnly = true
+		instance.LogBinEnabled = true
+		instance.LogSlaveUpdatesEnabled = true
+		resolvedHostname = instance.Key.Hostname
+		latency.Start("backend")
+		UpdateResolvedHostname(resolvedHostname, resolvedHostname)
+		latency.Stop("backend")
+	}
 
 	// Detect failed connection attempts and don't report the command
 	// we are executing as that might be confusing.
@@ -245,11 +231,11 @@ func (instance *Instance) checkMaxScale(db *sql.DB, latency *stopwatch.NamedStop
 		if unrecoverableError(err) {
 			logReadTopologyInstanceError(&instance.Key, "", err)
 		} else {
-			logReadTopologyInstanceError(&instance.Key, "show variables like 'maxscale%'", err)
+			logReadTopologyInstanceError(&instance.Key, "binlog server detection", err)
 		}
 	}
 
-	return isMaxScale, resolvedHostname, err
+	return isBinlogServer, resolvedHostname, err
 }
 
 // expectReplicationThreadsState expects both replication threads to be running, or both to be not running.
@@ -260,6 +246,10 @@ func expectReplicationThreadsState(instanceKey *InstanceKey, expectedState Repli
 		return false, err
 	}
 	err = sqlutils.QueryRowsMap(db, "show slave status", func(m sqlutils.RowMap) error {
+		if strings.HasPrefix(m.GetStringD("Channel_Name", ""), "group_replication_") {
+			// Group Replication channels are not master-replica replication; see ReplicationGroupName
+			return nil
+		}
 		ioThreadState := ReplicationThreadStateFromStatus(m.GetString("Slave_IO_Running"))
 		sqlThreadState := ReplicationThreadStateFromStatus(m.GetString("Slave_SQL_Running"))
 
@@ -291,9 +281,11 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	foundByShowSlaveHosts := false
 	resolvedHostname := ""
 	maxScaleMasterHostname := ""
-	isMaxScale := false
+	isBinlogServer := false
 	isMaxScale110 := false
 	slaveStatusFound := false
+	probeSet := ProbeSetFull
+	var lastKnownInstance *Instance
 	var resolveErr error
 
 	if !instanceKey.IsValid() {
@@ -305,6 +297,19 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		return instance, fmt.Errorf("ReadTopologyInstance will not act on invalid instance key: %+v", *instanceKey)
 	}
 
+	if probeSet = instanceProbeSet(instanceKey); probeSet != ProbeSetFull {
+		// The light probe carries rarely changing attributes over from the last known state of the instance.
+		// The minimal probe does the same, and skips them altogether for instances not yet known.
+		latency.Start("backend")
+		lastKnownInstance, _, _ = ReadInstance(instanceKey)
+		latency.Stop("backend")
+		if lastKnownInstance != nil {
+			probeSet = ProbeSetLight
+		} else if probeSet == ProbeSetLight {
+			probeSet = ProbeSetFull
+		}
+	}
+
 	lastAttemptedCheckTimer := time.AfterFunc(time.Second, func() {
 		go UpdateInstanceLastAttemptedCheck(instanceKey)
 	})
@@ -318,12 +323,12 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 
 	instance.Key = *instanceKey
 
-	if isMaxScale, resolvedHostname, err = instance.checkMaxScale(db, latency); err != nil {
+	if isBinlogServer, resolvedHostname, err = instance.checkBinlogServer(db, latency); err != nil {
 		// We do not "goto Cleanup" here, although it should be the correct flow.
 		// Reason is 5.7's new security feature that requires GRANTs on performance_schema.session_variables.
 		// There is a wrong decision making in this design and the migration path to 5.7 will be difficult.
 		// I don't want orchestrator to put even more burden on this.
-		// If the statement errors, then we are unable to determine that this is maxscale, hence assume it is not.
+		// If the statement errors, then we are unable to determine that this is a binlog server, hence assume it is not.
 		// In which case there would be other queries sent to the server that are not affected by 5.7 behavior, and that will fail.
 
 		// Certain errors are not recoverable (for this discovery process) so it's fine to go to Cleanup
@@ -333,8 +338,8 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	}
 
 	latency.Start("instance")
-	if isMaxScale {
-		if strings.Contains(instance.Version, "1.1.0") {
+	if isBinlogServer {
+		if instance.isMaxScale() && strings.Contains(instance.Version, "1.1.0") {
 			isMaxScale110 = true
 
 			// Buggy buggy maxscale 1.1.0. Reported Master_Host can be corrupted.
@@ -352,7 +357,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			db.QueryRow("select @@global.server_uuid").Scan(&instance.ServerUUID)
 		}
 	} else {
-		// NOT MaxScale
+		// NOT a binlog server
 
 		// We begin with a few operations we can run concurrently, and which do not depend on anything
 		{
@@ -398,6 +403,8 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			resolvedHostname = instance.Key.Hostname
 		}
 
+		instance.Capabilities = readInstanceCapabilities(db, instance)
+
 		if instance.LogBinEnabled {
 			waitGroup.Add(1)
 			go func() {
@@ -406,26 +413,103 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 					var err error
 					instance.SelfBinlogCoordinates.LogFile = m.GetString("File")
 					instance.SelfBinlogCoordinates.LogPos = m.GetInt64("Position")
+					// binlog_do_db/binlog_ignore_db filter what this server writes to its binary logs
+					instance.HasBinlogFilters = (m.GetStringD("Binlog_Do_DB", "") != "") || (m.GetStringD("Binlog_Ignore_DB", "") != "")
 					return err
 				})
 			}()
 		}
 
+		if instance.LogBinEnabled && probeSet == ProbeSetFull && config.Config.BinlogVolumeCapacityMB > 0 {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				binaryLogs, err := showBinaryLogsSizes(db)
+				logReadTopologyInstanceError(instanceKey, "show binary logs", err)
+				for _, binaryLog := range binaryLogs {
+					instance.BinaryLogsSize += binaryLog.Size
+				}
+			}()
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				// binlog_expire_logs_seconds (MySQL 8.0) takes precedence over expire_logs_days
+				var expireLogsDays uint
+				err := sqlutils.QueryRowsMap(db, "show global variables where Variable_name in ('binlog_expire_logs_seconds', 'expire_logs_days')", func(m sqlutils.RowMap) error {
+					switch m.GetString("Variable_name") {
+					case "binlog_expire_logs_seconds":
+						instance.BinlogExpireSeconds = m.GetUint("Value")
+					case "expire_logs_days":
+						expireLogsDays = m.GetUint("Value")
+					}
+					return nil
+				})
+				logReadTopologyInstanceError(instanceKey, "show global variables where Variable_name in ('binlog_expire_logs_seconds', 'expire_logs_days')", err)
+				if instance.BinlogExpireSeconds == 0 {
+					instance.BinlogExpireSeconds = expireLogsDays * 24 * 3600
+				}
+			}()
+		}
+
+		if probeSet == ProbeSetFull {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				// MySQL 8.0 binlog_encryption encrypts both binary logs and relay logs; so do Percona's & MariaDB's encrypt_binlog
+				err := sqlutils.QueryRowsMap(db, "show global variables where Variable_name in ('binlog_encryption', 'encrypt_binlog')", func(m sqlutils.RowMap) error {
+					if m.GetString("Value") == "ON" {
+						instance.BinlogEncryption = true
+						instance.RelayLogEncryption = true
+					}
+					return nil
+				})
+				logReadTopologyInstanceError(instanceKey, "show global variables where Variable_name in ('binlog_encryption', 'encrypt_binlog')", err)
+			}()
+		}
+
+		{
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				// super_read_only is available in MySQL 5.7 and in Percona Server 5.6; elsewhere it reads as OFF
+				err := sqlutils.QueryRowsMap(db, "show global variables like 'super_read_only'", func(m sqlutils.RowMap) error {
+					instance.SuperReadOnly = (m.GetString("Value") == "ON")
+					return nil
+				})
+				logReadTopologyInstanceError(instanceKey, "show global variables like 'super_read_only'", err)
+			}()
+		}
+		{
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				err := readClockSkew(db, instance)
+				logReadTopologyInstanceError(instanceKey, "readClockSkew", err)
+			}()
+		}
 		{
 			waitGroup.Add(1)
 			go func() {
 				defer waitGroup.Done()
-				err = sqlutils.QueryRowsMap(db, "show global status like 'rpl_semi_sync_%_status'", func(m sqlutils.RowMap) error {
-					if m.GetString("Variable_name") == "Rpl_semi_sync_master_status" {
+				err = sqlutils.QueryRowsMap(db, "show global status like 'rpl_semi_sync_%'", func(m sqlutils.RowMap) error {
+					switch m.GetString("Variable_name") {
+					case "Rpl_semi_sync_master_status":
 						instance.SemiSyncMasterEnabled = (m.GetString("Value") == "ON")
-					} else if m.GetString("Variable_name") == "Rpl_semi_sync_slave_status" {
+					case "Rpl_semi_sync_slave_status":
 						instance.SemiSyncReplicaEnabled = (m.GetString("Value") == "ON")
+					case "Rpl_semi_sync_master_clients":
+						instance.SemiSyncMasterClients = m.GetUint("Value")
 					}
 					return nil
 				})
+				// Only available with 5.7 and above
+				_ = sqlutils.QueryRowsMap(db, "show global variables like 'rpl_semi_sync_master_wait_for_slave_count'", func(m sqlutils.RowMap) error {
+					instance.SemiSyncMasterWaitForReplicaCount = m.GetUint("Value")
+					return nil
+				})
 			}()
 		}
-		if (instance.IsOracleMySQL() || instance.IsPercona()) && !instance.IsSmallerMajorVersionByString("5.6") {
+		if instance.Capabilities.GTIDMode {
 			waitGroup.Add(1)
 			go func() {
 				defer waitGroup.Done()
@@ -484,6 +568,15 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}
 		// This can be overriden by later invocation of DetectPhysicalEnvironmentQuery
 	}
+	if config.Config.NamespacePattern != "" {
+		if pattern, err := regexp.Compile(config.Config.NamespacePattern); err == nil {
+			match := pattern.FindStringSubmatch(instance.Key.Hostname)
+			if len(match) != 0 {
+				instance.Namespace = match[1]
+			}
+		}
+		// This can be overriden by later invocation of DetectNamespaceQuery
+	}
 
 	instance.ReplicationIOThreadState = ReplicationThreadStateNoThread
 	instance.ReplicationSQLThreadState = ReplicationThreadStateNoThread
@@ -545,12 +638,21 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	if err != nil {
 		goto Cleanup
 	}
-	if isMaxScale && !slaveStatusFound {
-		err = fmt.Errorf("No 'SHOW SLAVE STATUS' output found for a MaxScale instance: %+v", instanceKey)
+	if isBinlogServer && !slaveStatusFound {
+		err = fmt.Errorf("No 'SHOW SLAVE STATUS' output found for a binlog server: %+v", instanceKey)
 		goto Cleanup
 	}
 
-	if config.Config.ReplicationLagQuery != "" && !isMaxScale {
+	if slaveStatusFound && !isBinlogServer && instance.Capabilities.ReplicationConnectionStatus {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := readReplicationConnectionStatus(db, instance)
+			logReadTopologyInstanceError(instanceKey, "readReplicationConnectionStatus", err)
+		}()
+	}
+
+	if config.Config.ReplicationLagQuery != "" && !isBinlogServer {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -574,8 +676,8 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	// -------------------------------------------------------------------------
 
 	// Get replicas, either by SHOW SLAVE HOSTS or via PROCESSLIST
-	// MaxScale does not support PROCESSLIST, so SHOW SLAVE HOSTS is the only option
-	if config.Config.DiscoverByShowSlaveHosts || isMaxScale {
+	// Binlog servers do not support PROCESSLIST, so SHOW SLAVE HOSTS is the only option
+	if config.Config.DiscoverByShowSlaveHosts || isBinlogServer {
 		err := sqlutils.QueryRowsMap(db, `show slave hosts`,
 			func(m sqlutils.RowMap) error {
 				// MaxScale 1.1 may trigger an error with this command, but
@@ -585,8 +687,8 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 				host := m.GetString("Host")
 				port := m.GetIntD("Port", 0)
 				if host == "" || port == 0 {
-					if isMaxScale && host == "" && port == 0 {
-						// MaxScale reports a bad response sometimes so ignore it.
+					if isBinlogServer && host == "" && port == 0 {
+						// MaxScale (and possibly other binlog servers) reports a bad response sometimes so ignore it.
 						// - seen in 1.1.0 and 1.4.3.4
 						return nil
 					}
@@ -604,7 +706,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 
 		logReadTopologyInstanceError(instanceKey, "show slave hosts", err)
 	}
-	if !foundByShowSlaveHosts && !isMaxScale {
+	if !foundByShowSlaveHosts && !isBinlogServer {
 		// Either not configured to read SHOW SLAVE HOSTS or nothing was there.
 		// Discover by information_schema.processlist
 		waitGroup.Add(1)
@@ -659,7 +761,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectDataCenterQuery != "" && !isMaxScale {
+	if config.Config.DetectDataCenterQuery != "" && !isBinlogServer && probeSet == ProbeSetFull {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -668,7 +770,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectRegionQuery != "" && !isMaxScale {
+	if config.Config.DetectRegionQuery != "" && !isBinlogServer && probeSet == ProbeSetFull {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -677,7 +779,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectPhysicalEnvironmentQuery != "" && !isMaxScale {
+	if config.Config.DetectPhysicalEnvironmentQuery != "" && !isBinlogServer && probeSet == ProbeSetFull {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -686,7 +788,16 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectInstanceAliasQuery != "" && !isMaxScale {
+	if config.Config.DetectNamespaceQuery != "" && !isBinlogServer && probeSet == ProbeSetFull {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			err := db.QueryRow(config.Config.DetectNamespaceQuery).Scan(&instance.Namespace)
+			logReadTopologyInstanceError(instanceKey, "DetectNamespaceQuery", err)
+		}()
+	}
+
+	if config.Config.DetectInstanceAliasQuery != "" && !isBinlogServer && probeSet == ProbeSetFull {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -695,7 +806,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectSemiSyncEnforcedQuery != "" && !isMaxScale {
+	if config.Config.DetectSemiSyncEnforcedQuery != "" && !isBinlogServer && probeSet == ProbeSetFull {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -704,6 +815,16 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
+	if probeSet == ProbeSetLight {
+		instance.inheritLightProbeAttributes(lastKnownInstance)
+	}
+
+	if !isBinlogServer && instance.Capabilities.ReplicationGroupMembersTable {
+		// Group membership is read ahead of, and affects, the cluster attributes
+		err := readReplicationGroupMembership(db, instance)
+		logReadTopologyInstanceError(instanceKey, "readReplicationGroupMembership", err)
+	}
+
 	{
 		latency.Start("backend")
 		err = ReadInstanceClusterAttributes(instance)
@@ -719,7 +840,9 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			var err error
 			instance.UsingPseudoGTID, err = isInjectedPseudoGTID(instance.ClusterName)
 			log.Errore(err)
-		} else if config.Config.DetectPseudoGTIDQuery != "" {
+		} else if config.Config.DetectPseudoGTIDQuery != "" && probeSet == ProbeSetLight {
+			instance.UsingPseudoGTID = lastKnownInstance.UsingPseudoGTID
+		} else if config.Config.DetectPseudoGTIDQuery != "" && probeSet == ProbeSetFull {
 			waitGroup.Add(1)
 			go func() {
 				defer waitGroup.Done()
@@ -748,31 +871,34 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	// Then check if the instance wants to set a different PromotionRule.
 	// We'll set it here on their behalf so there's no race between the first
 	// time an instance is discovered, and setting a rule like "must_not".
-	if config.Config.DetectPromotionRuleQuery != "" && !isMaxScale {
+	if config.Config.DetectPromotionRuleQuery != "" && !isBinlogServer && probeSet == ProbeSetFull {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
 			var value string
 			err := db.QueryRow(config.Config.DetectPromotionRuleQuery).Scan(&value)
 			logReadTopologyInstanceError(instanceKey, "DetectPromotionRuleQuery", err)
-			promotionRule, err := ParseCandidatePromotionRule(value)
+			promotionRule, promotionPriority, err := ParseCandidatePromotionRuleAndPriority(value)
 			logReadTopologyInstanceError(instanceKey, "ParseCandidatePromotionRule", err)
 			if err == nil {
 				// We need to update candidate_database_instance.
 				// We register the rule even if it hasn't changed,
 				// to bump the last_suggested time.
 				instance.PromotionRule = promotionRule
-				err = RegisterCandidateInstance(NewCandidateDatabaseInstance(instanceKey, promotionRule).WithCurrentTime())
+				instance.PromotionPriority = promotionPriority
+				err = RegisterCandidateInstance(NewCandidateDatabaseInstance(instanceKey, promotionRule).WithPromotionPriority(promotionPriority).WithCurrentTime())
 				logReadTopologyInstanceError(instanceKey, "RegisterCandidateInstance", err)
 			}
 		}()
 	}
 
 	ReadClusterAliasOverride(instance)
-	if !isMaxScale {
+	if !isBinlogServer {
 		if instance.SuggestedClusterAlias == "" {
 			// Only need to do on masters
-			if config.Config.DetectClusterAliasQuery != "" {
+			if config.Config.DetectClusterAliasQuery != "" && probeSet == ProbeSetLight {
+				instance.SuggestedClusterAlias = lastKnownInstance.SuggestedClusterAlias
+			} else if config.Config.DetectClusterAliasQuery != "" && probeSet == ProbeSetFull {
 				clusterAlias := ""
 				if err := db.QueryRow(config.Config.DetectClusterAliasQuery).Scan(&clusterAlias); err != nil {
 					logReadTopologyInstanceError(instanceKey, "DetectClusterAliasQuery", err)
@@ -789,7 +915,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			}
 		}
 	}
-	if instance.ReplicationDepth == 0 && config.Config.DetectClusterDomainQuery != "" && !isMaxScale {
+	if instance.ReplicationDepth == 0 && config.Config.DetectClusterDomainQuery != "" && !isBinlogServer && probeSet == ProbeSetFull {
 		// Only need to do on masters
 		domainName := ""
 		if err := db.QueryRow(config.Config.DetectClusterDomainQuery).Scan(&domainName); err != nil {
@@ -836,7 +962,11 @@ Cleanup:
 				redactedMasterExecutedGtidSet, _ := NewOracleGtidSet(instance.masterExecutedGtidSet)
 				redactedMasterExecutedGtidSet.RemoveUUID(instance.MasterUUID)
 
-				db.QueryRow("select gtid_subtract(?, ?)", redactedExecutedGtidSet.String(), redactedMasterExecutedGtidSet.String()).Scan(&instance.GtidErrant)
+				if gtidErrant, err := subtractGtidSets(redactedExecutedGtidSet.String(), redactedMasterExecutedGtidSet.String()); err == nil {
+					instance.GtidErrant = gtidErrant
+				} else {
+					db.QueryRow("select gtid_subtract(?, ?)", redactedExecutedGtidSet.String(), redactedMasterExecutedGtidSet.String()).Scan(&instance.GtidErrant)
+				}
 			}
 		}
 	}
@@ -844,6 +974,15 @@ Cleanup:
 	latency.Stop("instance")
 	readTopologyInstanceCounter.Inc(1)
 
+	if instanceFound {
+		instance.evaluateReplicationLag()
+		instance.evaluateClockSkew()
+		instance.inspectReplicationBreakage()
+		latency.Start("backend")
+		instance.evaluateReplicationConnection()
+		latency.Stop("backend")
+	}
+
 	if instanceFound {
 		instance.LastDiscoveryLatency = time.Since(readingStartTime)
 		instance.IsLastCheckValid = true
@@ -902,9 +1041,17 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 	var masterReplicationDepth uint
 	var ancestryUUID string
 	var masterExecutedGtidSet string
+	var masterHasBinlogFilters bool
+	var masterPseudoGTIDUnsafe bool
 	masterDataFound := false
 
 	// Read the cluster_name of the _master_ of our instance, derive it from there.
+	// A member of a replication group, other than the group's primary, derives it from the primary.
+	upstreamKey := &instance.MasterKey
+	groupUpstreamKey := instance.replicationGroupUpstreamKey()
+	if groupUpstreamKey != nil && !instance.MasterKey.IsValid() {
+		upstreamKey = groupUpstreamKey
+	}
 	query := `
 			select
 					cluster_name,
@@ -913,11 +1060,13 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 					master_host,
 					master_port,
 					ancestry_uuid,
-					executed_gtid_set
+					executed_gtid_set,
+					has_binlog_filters,
+					pseudo_gtid_unsafe
 				from database_instance
 				where hostname=? and port=?
 	`
-	args := sqlutils.Args(instance.MasterKey.Hostname, instance.MasterKey.Port)
+	args := sqlutils.Args(upstreamKey.Hostname, upstreamKey.Port)
 
 	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		masterClusterName = m.GetString("cluster_name")
@@ -927,6 +1076,8 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 		masterMasterKey.Port = m.GetInt("master_port")
 		ancestryUUID = m.GetString("ancestry_uuid")
 		masterExecutedGtidSet = m.GetString("executed_gtid_set")
+		masterHasBinlogFilters = m.GetBool("has_binlog_filters")
+		masterPseudoGTIDUnsafe = m.GetBool("pseudo_gtid_unsafe")
 		masterDataFound = true
 		return nil
 	})
@@ -936,9 +1087,13 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 
 	var replicationDepth uint = 0
 	var clusterName string
+	// A replica is unsafe for Pseudo-GTID matching when some server up its replication chain filters its
+	// binary logs: its relay logs then do not compare with the binary logs of servers in other branches.
+	pseudoGTIDUnsafe := false
 	if masterDataFound {
 		replicationDepth = masterReplicationDepth + 1
 		clusterName = masterClusterName
+		pseudoGTIDUnsafe = masterHasBinlogFilters || masterPseudoGTIDUnsafe
 	}
 	clusterNameByInstanceKey := instance.Key.StringCode()
 	if clusterName == "" {
@@ -961,13 +1116,20 @@ func ReadInstanceClusterAttributes(instance *Instance) (err error) {
 			replicationDepth = 0
 			ancestryUUID = ""
 		} // While the other stays "1"
+		// Avoid propagating the flag around the circle indefinitely
+		pseudoGTIDUnsafe = masterHasBinlogFilters
 	}
 	instance.ClusterName = clusterName
 	instance.SuggestedClusterAlias = masterSuggestedClusterAlias
 	instance.ReplicationDepth = replicationDepth
 	instance.IsCoMaster = isCoMaster
 	instance.AncestryUUID = ancestryUUID
+	if upstreamKey == groupUpstreamKey {
+		// Group members share the group's GTIDs; this is not a master-replica relationship
+		masterExecutedGtidSet = ""
+	}
 	instance.masterExecutedGtidSet = masterExecutedGtidSet
+	instance.PseudoGTIDUnsafe = pseudoGTIDUnsafe
 	return nil
 }
 
@@ -1013,9 +1175,11 @@ func BulkReadInstance() ([](*InstanceKey), error) {
 
 func ReadInstancePromotionRule(instance *Instance) (err error) {
 	var promotionRule CandidatePromotionRule = NeutralPromoteRule
+	var promotionPriority int
 	query := `
 			select
-				ifnull(nullif(promotion_rule, ''), 'neutral') as promotion_rule
+				ifnull(nullif(promotion_rule, ''), 'neutral') as promotion_rule,
+				promotion_priority
 				from candidate_database_instance
 				where hostname=? and port=?
 	`
@@ -1023,9 +1187,11 @@ func ReadInstancePromotionRule(instance *Instance) (err error) {
 
 	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		promotionRule = CandidatePromotionRule(m.GetString("promotion_rule"))
+		promotionPriority = m.GetInt("promotion_priority")
 		return nil
 	})
 	instance.PromotionRule = promotionRule
+	instance.PromotionPriority = promotionPriority
 	return log.Errore(err)
 }
 
@@ -1041,10 +1207,15 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.Version = m.GetString("version")
 	instance.VersionComment = m.GetString("version_comment")
 	instance.ReadOnly = m.GetBool("read_only")
+	instance.SuperReadOnly = m.GetBool("super_read_only")
 	instance.Binlog_format = m.GetString("binlog_format")
 	instance.BinlogRowImage = m.GetString("binlog_row_image")
 	instance.LogBinEnabled = m.GetBool("log_bin")
 	instance.LogSlaveUpdatesEnabled = m.GetBool("log_slave_updates")
+	instance.BinaryLogsSize = m.GetInt64("binary_logs_size")
+	instance.BinlogExpireSeconds = m.GetUint("binlog_expire_seconds")
+	instance.BinlogEncryption = m.GetBool("binlog_encryption")
+	instance.RelayLogEncryption = m.GetBool("relay_log_encryption")
 	instance.MasterKey.Hostname = m.GetString("master_host")
 	instance.MasterKey.Port = m.GetInt("master_port")
 	instance.IsDetachedMaster = instance.MasterKey.IsDetached()
@@ -1052,7 +1223,13 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.Slave_IO_Running = m.GetBool("slave_io_running")
 	instance.ReplicationSQLThreadState = ReplicationThreadState(m.GetInt("replication_sql_thread_state"))
 	instance.ReplicationIOThreadState = ReplicationThreadState(m.GetInt("replication_io_thread_state"))
+	instance.IOThreadConnectionState = m.GetString("io_thread_connection_state")
+	instance.LastHeartbeatTimestamp = m.GetString("last_heartbeat_timestamp")
+	instance.ReceivedHeartbeats = m.GetInt64("received_heartbeats")
+	instance.IOThreadConnectLatency = time.Duration(m.GetInt64("io_thread_connect_latency"))
+	instance.IOThreadReconnects = m.GetUint("io_thread_reconnects")
 	instance.HasReplicationFilters = m.GetBool("has_replication_filters")
+	instance.HasBinlogFilters = m.GetBool("has_binlog_filters")
 	instance.SupportsOracleGTID = m.GetBool("supports_oracle_gtid")
 	instance.UsingOracleGTID = m.GetBool("oracle_gtid")
 	instance.MasterUUID = m.GetString("master_uuid")
@@ -1063,6 +1240,7 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.GtidErrant = m.GetString("gtid_errant")
 	instance.UsingMariaDBGTID = m.GetBool("mariadb_gtid")
 	instance.UsingPseudoGTID = m.GetBool("pseudo_gtid")
+	instance.PseudoGTIDUnsafe = m.GetBool("pseudo_gtid_unsafe")
 	instance.SelfBinlogCoordinates.LogFile = m.GetString("binary_log_file")
 	instance.SelfBinlogCoordinates.LogPos = m.GetInt64("binary_log_pos")
 	instance.ReadBinlogCoordinates.LogFile = m.GetString("master_log_file")
@@ -1077,6 +1255,11 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.LastIOError = m.GetString("last_io_error")
 	instance.SecondsBehindMaster = m.GetNullInt64("seconds_behind_master")
 	instance.SlaveLagSeconds = m.GetNullInt64("slave_lag_seconds")
+	instance.ReplicationLagPercentileSeconds = m.GetNullInt64("slave_lag_percentile_seconds")
+	instance.ReplicationLagPattern = ReplicationLagPattern(m.GetString("replication_lag_pattern"))
+	instance.ClockSkewSeconds = m.GetInt64("clock_skew_seconds")
+	instance.TimeZoneOffsetSeconds = m.GetInt64("time_zone_offset_seconds")
+	instance.IsClockSkewed = isClockSkewed(instance.ClockSkewSeconds)
 	instance.SQLDelay = m.GetUint("sql_delay")
 	slaveHostsJSON := m.GetString("slave_hosts")
 	instance.ClusterName = m.GetString("cluster_name")
@@ -1084,9 +1267,20 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.DataCenter = m.GetString("data_center")
 	instance.Region = m.GetString("region")
 	instance.PhysicalEnvironment = m.GetString("physical_environment")
+	instance.Namespace = m.GetString("namespace")
 	instance.SemiSyncEnforced = m.GetBool("semi_sync_enforced")
 	instance.SemiSyncMasterEnabled = m.GetBool("semi_sync_master_enabled")
 	instance.SemiSyncReplicaEnabled = m.GetBool("semi_sync_replica_enabled")
+	instance.SemiSyncMasterWaitForReplicaCount = m.GetUint("semi_sync_master_wait_for_replica_count")
+	instance.SemiSyncMasterClients = m.GetUint("semi_sync_master_clients")
+	instance.ReplicationGroupName = m.GetString("replication_group_name")
+	instance.ReplicationGroupIsSinglePrimary = m.GetBool("replication_group_is_single_primary_mode")
+	instance.ReplicationGroupMemberState = m.GetString("replication_group_member_state")
+	instance.ReplicationGroupMemberRole = m.GetString("replication_group_member_role")
+	instance.ReplicationGroupMembers.ReadJson(m.GetString("replication_group_members"))
+	instance.ReplicationGroupPrimaryInstanceKey.Hostname = m.GetString("replication_group_primary_host")
+	instance.ReplicationGroupPrimaryInstanceKey.Port = m.GetInt("replication_group_primary_port")
+	instance.Capabilities.ReadJson(m.GetString("capabilities"))
 	instance.ReplicationDepth = m.GetUint("replication_depth")
 	instance.IsCoMaster = m.GetBool("is_co_master")
 	instance.ReplicationCredentialsAvailable = m.GetBool("replication_credentials_available")
@@ -1098,6 +1292,7 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.SecondsSinceLastSeen = m.GetNullInt64("seconds_since_last_seen")
 	instance.IsCandidate = m.GetBool("is_candidate")
 	instance.PromotionRule = CandidatePromotionRule(m.GetString("promotion_rule"))
+	instance.PromotionPriority = m.GetInt("promotion_priority")
 	instance.IsDowntimed = m.GetBool("is_downtimed")
 	instance.DowntimeReason = m.GetString("downtime_reason")
 	instance.DowntimeOwner = m.GetString("downtime_owner")
@@ -1120,10 +1315,15 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 		instance.Problems = append(instance.Problems, "not_replicating")
 	} else if instance.SlaveLagSeconds.Valid && math.AbsInt64(instance.SlaveLagSeconds.Int64-int64(instance.SQLDelay)) > int64(config.Config.ReasonableReplicationLagSeconds) {
 		instance.Problems = append(instance.Problems, "replication_lag")
+	} else if instance.ReplicationLagPattern == ReplicationLagSustained {
+		instance.Problems = append(instance.Problems, "replication_lag")
 	}
 	if instance.GtidErrant != "" {
 		instance.Problems = append(instance.Problems, "errant_gtid")
 	}
+	if instance.IsClockSkewed {
+		instance.Problems = append(instance.Problems, "clock_skew")
+	}
 
 	return instance
 }
@@ -1145,6 +1345,7 @@ func readInstancesByCondition(condition string, args []interface{}, sort string)
 			candidate_database_instance.last_suggested is not null
 				 and candidate_database_instance.promotion_rule in ('must', 'prefer') as is_candidate,
 			ifnull(nullif(candidate_database_instance.promotion_rule, ''), 'neutral') as promotion_rule,
+			ifnull(candidate_database_instance.promotion_priority, 0) as promotion_priority,
 			ifnull(unresolved_hostname, '') as unresolved_hostname,
 			(database_instance_downtime.downtime_active is not null and ifnull(database_instance_downtime.end_timestamp, now()) > now()) as is_downtimed,
     	ifnull(database_instance_downtime.reason, '') as downtime_reason,
@@ -1315,6 +1516,7 @@ func ReadProblemInstances(clusterName string) ([](*Instance), error) {
 				or (replication_io_thread_state not in (-1 ,1))
 				or (abs(cast(seconds_behind_master as signed) - cast(sql_delay as signed)) > ?)
 				or (abs(cast(slave_lag_seconds as signed) - cast(sql_delay as signed)) > ?)
+				or (replication_lag_pattern = 'sustained')
 				or (gtid_errant != '')
 			)
 		`
@@ -2046,8 +2248,10 @@ func ReadClustersInfo(clusterName string) ([]ClusterInfo, error) {
 		select
 			cluster_name,
 			count(*) as count_instances,
+			ifnull(sum(binlog_encryption), 0) as count_binlog_encrypted_instances,
 			ifnull(min(alias), cluster_name) as alias,
-			ifnull(min(domain_name), '') as domain_name
+			ifnull(min(domain_name), '') as domain_name,
+			max(namespace) as namespace
 		from
 			database_instance
 			left join cluster_alias using (cluster_name)
@@ -2058,10 +2262,12 @@ func ReadClustersInfo(clusterName string) ([]ClusterInfo, error) {
 
 	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		clusterInfo := ClusterInfo{
-			ClusterName:    m.GetString("cluster_name"),
-			CountInstances: m.GetUint("count_instances"),
-			ClusterAlias:   m.GetString("alias"),
-			ClusterDomain:  m.GetString("domain_name"),
+			ClusterName:                   m.GetString("cluster_name"),
+			CountInstances:                m.GetUint("count_instances"),
+			CountBinlogEncryptedInstances: m.GetUint("count_binlog_encrypted_instances"),
+			ClusterAlias:                  m.GetString("alias"),
+			ClusterDomain:                 m.GetString("domain_name"),
+			Namespace:                     m.GetString("namespace"),
 		}
 		clusterInfo.ApplyClusterAlias()
 		clusterInfo.ReadRecoveryInfo()
@@ -2199,9 +2405,15 @@ func ReadAllMinimalInstances() ([]MinimalInstance, error) {
 // the instance.
 func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
 	res := []InstanceKey{}
+	overrides := readEffectivePollOverrides()
+	if db.IsBackendOverloaded() {
+		overrides = withoutAcceleratedPolling(overrides)
+	}
 	query := `
 		select
-			hostname, port
+			hostname, port,
+			unix_timestamp() - unix_timestamp(last_checked) as seconds_since_last_checked,
+			last_attempted_check <= last_checked as last_check_completed
 		from
 			database_instance
 		where
@@ -2210,10 +2422,18 @@ func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
 				then last_checked < now() - interval ? second
 				else last_checked < now() - interval ? second
 			end
-			`
-	args := sqlutils.Args(config.Config.InstancePollSeconds, 2*config.Config.InstancePollSeconds)
+			` + instanceReverificationExclusionCondition()
+	// With poll overrides, we read instances by the shortest interval, and filter per instance below
+	pollSeconds := minPollSeconds(overrides)
+	args := sqlutils.Args(pollSeconds, 2*pollSeconds)
 
 	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		if len(overrides) > 0 {
+			rawInstanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+			if !isPollDue(effectivePollSeconds(overrides[rawInstanceKey]), m.GetInt64("seconds_since_last_checked"), m.GetBool("last_check_completed")) {
+				return nil
+			}
+		}
 		instanceKey, merr := NewResolveInstanceKey(m.GetString("hostname"), m.GetInt("port"))
 		if merr != nil {
 			log.Errore(merr)
@@ -2232,6 +2452,24 @@ func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
 
 }
 
+// ReadInstancesPollStaleness returns, for each polled instance, the number of seconds since it was last checked.
+// Instances handed over to the background re-verifier are not included.
+func ReadInstancesPollStaleness() (staleness []int64, err error) {
+	query := `
+		select
+			unix_timestamp() - unix_timestamp(last_checked) as seconds_since_last_checked
+		from
+			database_instance
+		where
+			1=1
+	` + instanceReverificationExclusionCondition()
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		staleness = append(staleness, m.GetInt64("seconds_since_last_checked"))
+		return nil
+	})
+	return staleness, log.Errore(err)
+}
+
 func mkInsertOdku(table string, columns []string, values []string, nrRows int, insertIgnore bool) (string, error) {
 	if len(columns) == 0 {
 		return "", errors.New("Column list cannot be empty")
@@ -2299,19 +2537,30 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"version_comment",
 		"binlog_server",
 		"read_only",
+		"super_read_only",
 		"binlog_format",
 		"binlog_row_image",
 		"log_bin",
 		"log_slave_updates",
+		"binlog_encryption",
+		"relay_log_encryption",
 		"binary_log_file",
 		"binary_log_pos",
+		"binary_logs_size",
+		"binlog_expire_seconds",
 		"master_host",
 		"master_port",
 		"slave_sql_running",
 		"slave_io_running",
 		"replication_sql_thread_state",
 		"replication_io_thread_state",
+		"io_thread_connection_state",
+		"last_heartbeat_timestamp",
+		"received_heartbeats",
+		"io_thread_connect_latency",
+		"io_thread_reconnects",
 		"has_replication_filters",
+		"has_binlog_filters",
 		"supports_oracle_gtid",
 		"oracle_gtid",
 		"master_uuid",
@@ -2322,6 +2571,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"gtid_errant",
 		"mariadb_gtid",
 		"pseudo_gtid",
+		"pseudo_gtid_unsafe",
 		"master_log_file",
 		"read_master_log_pos",
 		"relay_master_log_file",
@@ -2332,6 +2582,10 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"last_io_error",
 		"seconds_behind_master",
 		"slave_lag_seconds",
+		"slave_lag_percentile_seconds",
+		"replication_lag_pattern",
+		"clock_skew_seconds",
+		"time_zone_offset_seconds",
 		"sql_delay",
 		"num_slave_hosts",
 		"slave_hosts",
@@ -2340,6 +2594,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"data_center",
 		"region",
 		"physical_environment",
+		"namespace",
 		"replication_depth",
 		"is_co_master",
 		"replication_credentials_available",
@@ -2348,7 +2603,17 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"semi_sync_enforced",
 		"semi_sync_master_enabled",
 		"semi_sync_replica_enabled",
+		"semi_sync_master_wait_for_replica_count",
+		"semi_sync_master_clients",
+		"replication_group_name",
+		"replication_group_is_single_primary_mode",
+		"replication_group_member_state",
+		"replication_group_member_role",
+		"replication_group_members",
+		"replication_group_primary_host",
+		"replication_group_primary_port",
 		"instance_alias",
+		"capabilities",
 		"last_discovery_latency",
 	}
 
@@ -2379,19 +2644,30 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.VersionComment)
 		args = append(args, instance.IsBinlogServer())
 		args = append(args, instance.ReadOnly)
+		args = append(args, instance.SuperReadOnly)
 		args = append(args, instance.Binlog_format)
 		args = append(args, instance.BinlogRowImage)
 		args = append(args, instance.LogBinEnabled)
 		args = append(args, instance.LogSlaveUpdatesEnabled)
+		args = append(args, instance.BinlogEncryption)
+		args = append(args, instance.RelayLogEncryption)
 		args = append(args, instance.SelfBinlogCoordinates.LogFile)
 		args = append(args, instance.SelfBinlogCoordinates.LogPos)
+		args = append(args, instance.BinaryLogsSize)
+		args = append(args, instance.BinlogExpireSeconds)
 		args = append(args, instance.MasterKey.Hostname)
 		args = append(args, instance.MasterKey.Port)
 		args = append(args, instance.Slave_SQL_Running)
 		args = append(args, instance.Slave_IO_Running)
 		args = append(args, instance.ReplicationSQLThreadState)
 		args = append(args, instance.ReplicationIOThreadState)
+		args = append(args, instance.IOThreadConnectionState)
+		args = append(args, instance.LastHeartbeatTimestamp)
+		args = append(args, instance.ReceivedHeartbeats)
+		args = append(args, instance.IOThreadConnectLatency.Nanoseconds())
+		args = append(args, instance.IOThreadReconnects)
 		args = append(args, instance.HasReplicationFilters)
+		args = append(args, instance.HasBinlogFilters)
 		args = append(args, instance.SupportsOracleGTID)
 		args = append(args, instance.UsingOracleGTID)
 		args = append(args, instance.MasterUUID)
@@ -2402,6 +2678,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.GtidErrant)
 		args = append(args, instance.UsingMariaDBGTID)
 		args = append(args, instance.UsingPseudoGTID)
+		args = append(args, instance.PseudoGTIDUnsafe)
 		args = append(args, instance.ReadBinlogCoordinates.LogFile)
 		args = append(args, instance.ReadBinlogCoordinates.LogPos)
 		args = append(args, instance.ExecBinlogCoordinates.LogFile)
@@ -2412,6 +2689,10 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.LastIOError)
 		args = append(args, instance.SecondsBehindMaster)
 		args = append(args, instance.SlaveLagSeconds)
+		args = append(args, instance.ReplicationLagPercentileSeconds)
+		args = append(args, string(instance.ReplicationLagPattern))
+		args = append(args, instance.ClockSkewSeconds)
+		args = append(args, instance.TimeZoneOffsetSeconds)
 		args = append(args, instance.SQLDelay)
 		args = append(args, len(instance.SlaveHosts))
 		args = append(args, instance.SlaveHosts.ToJSONString())
@@ -2420,6 +2701,7 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.DataCenter)
 		args = append(args, instance.Region)
 		args = append(args, instance.PhysicalEnvironment)
+		args = append(args, instance.Namespace)
 		args = append(args, instance.ReplicationDepth)
 		args = append(args, instance.IsCoMaster)
 		args = append(args, instance.ReplicationCredentialsAvailable)
@@ -2428,7 +2710,17 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.SemiSyncEnforced)
 		args = append(args, instance.SemiSyncMasterEnabled)
 		args = append(args, instance.SemiSyncReplicaEnabled)
+		args = append(args, instance.SemiSyncMasterWaitForReplicaCount)
+		args = append(args, instance.SemiSyncMasterClients)
+		args = append(args, instance.ReplicationGroupName)
+		args = append(args, instance.ReplicationGroupIsSinglePrimary)
+		args = append(args, instance.ReplicationGroupMemberState)
+		args = append(args, instance.ReplicationGroupMemberRole)
+		args = append(args, instance.ReplicationGroupMembers.ToJSONString())
+		args = append(args, instance.ReplicationGroupPrimaryInstanceKey.Hostname)
+		args = append(args, instance.ReplicationGroupPrimaryInstanceKey.Port)
 		args = append(args, instance.InstanceAlias)
+		args = append(args, instance.Capabilities.ToJSONString())
 		args = append(args, instance.LastDiscoveryLatency.Nanoseconds())
 	}
 
@@ -2646,6 +2938,12 @@ func ForgetCluster(clusterName string) error {
 
 // ForgetLongUnseenInstances will remove entries of all instacnes that have long since been last seen.
 func ForgetLongUnseenInstances() error {
+	if config.Config.ReverifyUnreachableInstancesSeconds > 0 {
+		// Keep re-probing these instances in the background, and reinstate them should they come back
+		if err := trackForgottenUnseenInstances(); err != nil {
+			return err
+		}
+	}
 	sqlResult, err := db.ExecOrchestrator(`
 			delete
 				from database_instance
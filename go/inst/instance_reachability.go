@@ -0,0 +1,45 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/github/orchestrator/go/db"
+)
+
+// InstanceReachability is an orchestrator node's own view of whether an instance is reachable, regardless of the
+// instance's state as last polled
+type InstanceReachability struct {
+	Key       InstanceKey
+	Reachable bool
+	Error     string
+}
+
+// CheckInstanceReachability connects to given instance and runs a trivial query
+func CheckInstanceReachability(instanceKey *InstanceKey) *InstanceReachability {
+	reachability := &InstanceReachability{Key: *instanceKey}
+	sqlDB, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err == nil {
+		var one int
+		err = sqlDB.QueryRow("select 1").Scan(&one)
+	}
+	if err != nil {
+		reachability.Error = err.Error()
+		return reachability
+	}
+	reachability.Reachable = true
+	return reachability
+}
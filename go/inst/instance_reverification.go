@@ -0,0 +1,107 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/openark/golib/log"
+)
+
+type InstanceReverificationState string
+
+const (
+	// InstanceReverificationUnreachable is an instance which has not been seen for ReverifyUnreachableInstancesSeconds
+	InstanceReverificationUnreachable InstanceReverificationState = "unreachable"
+	// InstanceReverificationForgotten is an instance which has been forgotten for being unseen for UnseenInstanceForgetHours
+	InstanceReverificationForgotten = "forgotten"
+	// InstanceReverificationReachable is the state of an instance once reinstated; it is not persisted
+	InstanceReverificationReachable = "reachable"
+)
+
+// InstanceReverification is an instance which is no longer polled each InstancePollSeconds, but is rather
+// re-probed by the background re-verifier at low frequency, until found reachable again.
+type InstanceReverification struct {
+	Key           InstanceKey
+	State         InstanceReverificationState
+	StateChanged  string
+	LastAttempted string
+	Attempts      int
+}
+
+var reverifyingInstances int64
+
+// auditInstanceReverificationTransition audits a state transition of given instance
+func auditInstanceReverificationTransition(instanceKey *InstanceKey, fromState InstanceReverificationState, toState InstanceReverificationState) {
+	AuditOperation("reverify-instance", instanceKey, fmt.Sprintf("%s -> %s", fromState, toState))
+}
+
+// reverifyInstance re-probes given instance, and reinstates it if found reachable
+func reverifyInstance(reverification *InstanceReverification) error {
+	if err := recordInstanceReverificationAttempt(&reverification.Key); err != nil {
+		return err
+	}
+	instance, err := ReadTopologyInstance(&reverification.Key)
+	if err != nil {
+		log.Debugf("reverifyInstance: %+v still %s after %d attempts: %+v", reverification.Key, reverification.State, reverification.Attempts+1, err)
+		return nil
+	}
+	if instance == nil || !instance.IsLastCheckValid {
+		return nil
+	}
+	if err := deleteInstanceReverification(&reverification.Key); err != nil {
+		return err
+	}
+	auditInstanceReverificationTransition(&reverification.Key, reverification.State, InstanceReverificationReachable)
+	return nil
+}
+
+// ReverifyInstances is the background re-verifier. It hands over long unseen instances from normal polling,
+// and re-probes, at most ReverifyInstancesBatchSize at a time, instances which are due for re-verification.
+// Instances found reachable are reinstated and polled normally again. This function is called periodically
+// by the active node, and is a no-op while a previous run is in progress.
+func ReverifyInstances() {
+	if config.Config.ReverifyUnreachableInstancesSeconds == 0 {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&reverifyingInstances, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt64(&reverifyingInstances, 0)
+
+	if err := trackUnreachableInstances(); err != nil {
+		return
+	}
+	if err := clearSeenInstanceReverifications(); err != nil {
+		return
+	}
+	if err := expireInstanceReverifications(); err != nil {
+		return
+	}
+	reverifications, err := readDueInstanceReverifications(config.Config.ReverifyInstancesBatchSize)
+	if err != nil {
+		return
+	}
+	for _, reverification := range reverifications {
+		if err := reverifyInstance(reverification); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,262 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// instanceReverificationExclusionCondition returns a condition on database_instance which excludes instances
+// handled by the background re-verifier, or an empty string when re-verification is disabled
+func instanceReverificationExclusionCondition() string {
+	if config.Config.ReverifyUnreachableInstancesSeconds == 0 {
+		return ""
+	}
+	return `
+		and not exists (
+			select 1 from database_instance_reverification
+			where
+				database_instance_reverification.hostname = database_instance.hostname
+				and database_instance_reverification.port = database_instance.port
+		)
+	`
+}
+
+func writeInstanceReverification(instanceKey *InstanceKey, state InstanceReverificationState) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into database_instance_reverification (
+				hostname, port, state, state_changed, last_attempted, attempts
+			) values (
+				?, ?, ?, NOW(), NOW(), 0
+			) on duplicate key update
+				state=values(state),
+				state_changed=values(state_changed)
+			`, instanceKey.Hostname, instanceKey.Port, string(state),
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+func deleteInstanceReverification(instanceKey *InstanceKey) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from database_instance_reverification where hostname = ? and port = ?
+			`, instanceKey.Hostname, instanceKey.Port,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+func readInstanceReverifications(whereCondition string, args []interface{}, limit string) ([]*InstanceReverification, error) {
+	res := []*InstanceReverification{}
+	query := fmt.Sprintf(`
+		select
+			hostname,
+			port,
+			state,
+			state_changed,
+			last_attempted,
+			attempts
+		from
+			database_instance_reverification
+		%s
+		order by
+			last_attempted, hostname, port
+		%s
+		`, whereCondition, limit)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		reverification := &InstanceReverification{}
+		reverification.Key.Hostname = m.GetString("hostname")
+		reverification.Key.Port = m.GetInt("port")
+		reverification.State = InstanceReverificationState(m.GetString("state"))
+		reverification.StateChanged = m.GetString("state_changed")
+		reverification.LastAttempted = m.GetString("last_attempted")
+		reverification.Attempts = m.GetInt("attempts")
+
+		res = append(res, reverification)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadInstanceReverifications reads all instances handled by the background re-verifier
+func ReadInstanceReverifications() ([]*InstanceReverification, error) {
+	return readInstanceReverifications(``, sqlutils.Args(), ``)
+}
+
+// readDueInstanceReverifications reads up to given number of instances due to be re-probed
+func readDueInstanceReverifications(limit uint) ([]*InstanceReverification, error) {
+	return readInstanceReverifications(
+		`where last_attempted < NOW() - interval ? second`,
+		sqlutils.Args(config.Config.ReverifyUnreachableInstancesSeconds, limit),
+		`limit ?`,
+	)
+}
+
+// recordInstanceReverificationAttempt marks given instance as just having been re-probed
+func recordInstanceReverificationAttempt(instanceKey *InstanceKey) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			update database_instance_reverification
+				set last_attempted = NOW(), attempts = attempts + 1
+			where hostname = ? and port = ?
+			`, instanceKey.Hostname, instanceKey.Port,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// readInstanceKeys reads instance keys by given query
+func readInstanceKeys(query string, args []interface{}) (instanceKeys []InstanceKey, err error) {
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		instanceKeys = append(instanceKeys, InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")})
+		return nil
+	})
+	return instanceKeys, log.Errore(err)
+}
+
+// trackUnreachableInstances hands over to the re-verifier instances which have not been seen for
+// ReverifyUnreachableInstancesSeconds. These are then no longer polled every InstancePollSeconds.
+func trackUnreachableInstances() error {
+	query := `
+		select
+			hostname, port
+		from
+			database_instance
+		where
+			last_seen < NOW() - interval ? second
+	` + instanceReverificationExclusionCondition()
+	instanceKeys, err := readInstanceKeys(query, sqlutils.Args(config.Config.ReverifyUnreachableInstancesSeconds))
+	if err != nil {
+		return err
+	}
+	for _, instanceKey := range instanceKeys {
+		instanceKey := instanceKey
+		if err := writeInstanceReverification(&instanceKey, InstanceReverificationUnreachable); err != nil {
+			return err
+		}
+		auditInstanceReverificationTransition(&instanceKey, InstanceReverificationReachable, InstanceReverificationUnreachable)
+	}
+	return nil
+}
+
+// trackForgottenUnseenInstances hands over to the re-verifier instances which are about to be forgotten
+// for being unseen for UnseenInstanceForgetHours
+func trackForgottenUnseenInstances() error {
+	query := `
+		select
+			hostname, port
+		from
+			database_instance
+		where
+			last_seen < NOW() - interval ? hour
+	`
+	instanceKeys, err := readInstanceKeys(query, sqlutils.Args(config.Config.UnseenInstanceForgetHours))
+	if err != nil {
+		return err
+	}
+	for _, instanceKey := range instanceKeys {
+		instanceKey := instanceKey
+		if err := writeInstanceReverification(&instanceKey, InstanceReverificationForgotten); err != nil {
+			return err
+		}
+		auditInstanceReverificationTransition(&instanceKey, InstanceReverificationUnreachable, InstanceReverificationForgotten)
+	}
+	return nil
+}
+
+// clearSeenInstanceReverifications releases instances which have been seen by other means, e.g.
+// a manual discovery or a discovery via their master
+func clearSeenInstanceReverifications() error {
+	query := `
+		select
+			database_instance_reverification.hostname,
+			database_instance_reverification.port,
+			database_instance_reverification.state
+		from
+			database_instance_reverification
+			join database_instance on (
+				database_instance_reverification.hostname = database_instance.hostname
+				and database_instance_reverification.port = database_instance.port
+			)
+		where
+			database_instance.last_seen >= NOW() - interval ? second
+	`
+	reverifications := []*InstanceReverification{}
+	err := db.QueryOrchestrator(query, sqlutils.Args(config.Config.ReverifyUnreachableInstancesSeconds), func(m sqlutils.RowMap) error {
+		reverification := &InstanceReverification{}
+		reverification.Key.Hostname = m.GetString("hostname")
+		reverification.Key.Port = m.GetInt("port")
+		reverification.State = InstanceReverificationState(m.GetString("state"))
+		reverifications = append(reverifications, reverification)
+		return nil
+	})
+	if err != nil {
+		return log.Errore(err)
+	}
+	for _, reverification := range reverifications {
+		if err := deleteInstanceReverification(&reverification.Key); err != nil {
+			return err
+		}
+		auditInstanceReverificationTransition(&reverification.Key, reverification.State, InstanceReverificationReachable)
+	}
+	return nil
+}
+
+// expireInstanceReverifications stops re-probing forgotten instances after ReverifyForgottenInstancesHours,
+// as well as unreachable instances which have since been forgotten manually
+func expireInstanceReverifications() error {
+	writeFunc := func() error {
+		sqlResult, err := db.ExecOrchestrator(`
+			delete from database_instance_reverification
+			where
+				state = ?
+				and state_changed < NOW() - interval ? hour
+			`, string(InstanceReverificationForgotten), config.Config.ReverifyForgottenInstancesHours,
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+		if rows, err := sqlResult.RowsAffected(); err == nil && rows > 0 {
+			AuditOperation("reverify-expire", nil, fmt.Sprintf("No longer re-probing forgotten instances: %d", rows))
+		}
+		_, err = db.ExecOrchestrator(`
+			delete from database_instance_reverification
+			where
+				state = ?
+				and not exists (
+					select 1 from database_instance
+					where
+						database_instance.hostname = database_instance_reverification.hostname
+						and database_instance.port = database_instance_reverification.port
+				)
+			`, string(InstanceReverificationUnreachable),
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
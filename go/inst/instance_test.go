@@ -189,6 +189,13 @@ func TestRemoveInstance(t *testing.T) {
 		instances = RemoveInstance(instances, &key2)
 		test.S(t).ExpectEquals(len(instances), 0)
 	}
+	{
+		instances := [](*Instance){&instance1, &instance2}
+		remaining := RemoveInstances(instances, [](*Instance){&instance1})
+		test.S(t).ExpectEquals(len(remaining), 1)
+		test.S(t).ExpectEquals(remaining[0].Key, key2)
+		test.S(t).ExpectEquals(len(instances), 2)
+	}
 }
 
 func TestHumanReadableDescription(t *testing.T) {
@@ -205,6 +212,14 @@ func TestHumanReadableDescription(t *testing.T) {
 		desc := i57.HumanReadableDescription()
 		test.S(t).ExpectEquals(desc, "[unknown,invalid,5.7.8-log,rw,ROW,>>,P-GTID]")
 	}
+	{
+		i57.ReadOnly = true
+		i57.SuperReadOnly = true
+		i57.SemiSyncReplicaEnabled = true
+		i57.GtidErrant = "00020192-1111-1111-1111-111111111111:1-3"
+		desc := i57.HumanReadableDescription()
+		test.S(t).ExpectEquals(desc, "[unknown,invalid,5.7.8-log,ro,ROW,>>,P-GTID,errant-GTID,semi-sync:replica,super-ro]")
+	}
 }
 
 func TestTabulatedDescription(t *testing.T) {
@@ -221,6 +236,12 @@ func TestTabulatedDescription(t *testing.T) {
 		desc := i57.TabulatedDescription("|")
 		test.S(t).ExpectEquals(desc, "unknown|invalid|5.7.8-log|rw|ROW|>>,P-GTID")
 	}
+	{
+		i57.SemiSyncMasterEnabled = true
+		i57.GtidErrant = "00020192-1111-1111-1111-111111111111:1-3"
+		desc := i57.TabulatedDescription("|")
+		test.S(t).ExpectEquals(desc, "unknown|invalid|5.7.8-log|rw|ROW|>>,P-GTID,errant-GTID,semi-sync:master")
+	}
 }
 
 func TestReplicationThreads(t *testing.T) {
@@ -17,16 +17,17 @@
 package inst
 
 import (
+	"context"
 	"fmt"
 	goos "os"
 	"regexp"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/github/orchestrator/go/config"
 	"github.com/github/orchestrator/go/os"
+	"github.com/github/orchestrator/go/tracing"
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/math"
 	"github.com/openark/golib/util"
@@ -46,16 +47,26 @@ var asciiFillerCharacter = " "
 var tabulatorScharacter = "|"
 
 var countRetries = 5
-var MaxConcurrentReplicaOperations = 5
+
+// startOperationSpan starts a span of a topology operation on given instance, as a child of the span carried by
+// given context, if any. Statements the operation issues by way of the returned context are traced as its children.
+func startOperationSpan(ctx context.Context, operation string, instanceKey *InstanceKey) (context.Context, *tracing.Span) {
+	ctx, span := tracing.StartSpanFromContext(ctx, operation)
+	if instanceKey != nil {
+		span.SetAttribute("instance", instanceKey.StringCode())
+	}
+	return ctx, span
+}
 
 // getASCIITopologyEntry will get an ascii topology tree rooted at given instance. Ir recursively
-// draws the tree
-func getASCIITopologyEntry(depth int, instance *Instance, replicationMap map[*Instance]([]*Instance), extendedOutput bool, fillerCharacter string, tabulated bool) []string {
+// draws the tree. Replication edges crossing a WAN link (between given master and instance) are annotated.
+// Along with the entries, it returns the instance depicted by each entry.
+func getASCIITopologyEntry(depth int, master *Instance, instance *Instance, replicationMap map[*Instance]([]*Instance), extendedOutput bool, fillerCharacter string, tabulated bool) (entries []string, entryInstances [](*Instance)) {
 	if instance == nil {
-		return []string{}
+		return entries, entryInstances
 	}
 	if instance.IsCoMaster && depth > 1 {
-		return []string{}
+		return entries, entryInstances
 	}
 	prefix := ""
 	if depth > 0 {
@@ -67,6 +78,9 @@ func getASCIITopologyEntry(depth int, instance *Instance, replicationMap map[*In
 		}
 	}
 	entry := fmt.Sprintf("%s%s", prefix, instance.Key.DisplayString())
+	if cost, isWAN := instance.replicationEdgeWANCost(master); isWAN {
+		entry = fmt.Sprintf("%s%s~WAN(%d)~", entry, fillerCharacter, cost)
+	}
 	if extendedOutput {
 		if tabulated {
 			entry = fmt.Sprintf("%s%s%s", entry, tabulatorScharacter, instance.TabulatedDescription(tabulatorScharacter))
@@ -74,35 +88,60 @@ func getASCIITopologyEntry(depth int, instance *Instance, replicationMap map[*In
 			entry = fmt.Sprintf("%s%s%s", entry, fillerCharacter, instance.HumanReadableDescription())
 		}
 	}
-	result := []string{entry}
+	entries = []string{entry}
+	entryInstances = [](*Instance){instance}
 	for _, replica := range replicationMap[instance] {
-		replicasResult := getASCIITopologyEntry(depth+1, replica, replicationMap, extendedOutput, fillerCharacter, tabulated)
-		result = append(result, replicasResult...)
+		replicaEntries, replicaEntryInstances := getASCIITopologyEntry(depth+1, instance, replica, replicationMap, extendedOutput, fillerCharacter, tabulated)
+		entries = append(entries, replicaEntries...)
+		entryInstances = append(entryInstances, replicaEntryInstances...)
 	}
-	return result
+	return entries, entryInstances
 }
 
-// ASCIITopology returns a string representation of the topology of given cluster.
-func ASCIITopology(clusterName string, historyTimestampPattern string, tabulated bool) (result string, err error) {
-	fillerCharacter := asciiFillerCharacter
-	var instances [](*Instance)
-	if historyTimestampPattern == "" {
-		instances, err = ReadClusterInstances(clusterName)
-	} else {
-		instances, err = ReadHistoryClusterInstances(clusterName, historyTimestampPattern)
+const (
+	asciiColorReset  = "\033[0m"
+	asciiColorRed    = "\033[31m"
+	asciiColorYellow = "\033[33m"
+	asciiColorDim    = "\033[2m"
+)
+
+// asciiTopologyEntryColor returns the terminal color in which to render given instance's topology entry:
+// dim for a downtimed instance, red for an instance which is unreachable or not replicating, yellow for
+// a lagging replica. It returns an empty string for a healthy instance.
+func asciiTopologyEntryColor(instance *Instance) string {
+	if instance.IsDowntimed {
+		return asciiColorDim
 	}
-	if err != nil {
-		return "", err
+	switch instance.StatusString() {
+	case "invalid", "unchecked", "nonreplicating":
+		return asciiColorRed
+	case "lag":
+		return asciiColorYellow
 	}
+	return ""
+}
+
+// colorizeASCIITopologyEntries wraps each of given (aligned) entries with the terminal color of its instance
+func colorizeASCIITopologyEntries(entries []string, entryInstances [](*Instance)) []string {
+	for i, entry := range entries {
+		if color := asciiTopologyEntryColor(entryInstances[i]); color != "" {
+			entries[i] = fmt.Sprintf("%s%s%s", color, entry, asciiColorReset)
+		}
+	}
+	return entries
+}
 
+// getTopologyReplicationMap maps each of given instances to its replicas among given instances. It also
+// returns the master of the topology, if there is a single one: an instance whose master is not
+// among given instances.
+func getTopologyReplicationMap(instances [](*Instance)) (replicationMap map[*Instance]([]*Instance), masterInstance *Instance) {
 	instancesMap := make(map[InstanceKey](*Instance))
 	for _, instance := range instances {
 		log.Debugf("instanceKey: %+v", instance.Key)
 		instancesMap[instance.Key] = instance
 	}
 
-	replicationMap := make(map[*Instance]([]*Instance))
-	var masterInstance *Instance
+	replicationMap = make(map[*Instance]([]*Instance))
 	// Investigate replicas:
 	for _, instance := range instances {
 		master, ok := instancesMap[instance.MasterKey]
@@ -115,16 +154,115 @@ func ASCIITopology(clusterName string, historyTimestampPattern string, tabulated
 			masterInstance = instance
 		}
 	}
+	return replicationMap, masterInstance
+}
+
+// TopologyFilter limits a rendered topology to the instances whose hostname matches HostnamePattern, a
+// regular expression, and which have all of Tags, e.g. 'role=replica,dc1'. Matching instances are rendered
+// along with their replicas, and along with their masters up to the top of the topology.
+type TopologyFilter struct {
+	HostnamePattern string
+	Tags            string
+}
+
+func (this *TopologyFilter) IsEmpty() bool {
+	return this == nil || (this.HostnamePattern == "" && this.Tags == "")
+}
+
+// filterTopologyInstances returns given instances which match given hostname regexp and tagged keys (either
+// may be nil), along with their descendants and ancestors
+func filterTopologyInstances(instances [](*Instance), hostnameRegexp *regexp.Regexp, tagged *InstanceKeyMap) (filtered [](*Instance)) {
+	instancesMap := make(map[InstanceKey](*Instance))
+	for _, instance := range instances {
+		instancesMap[instance.Key] = instance
+	}
+	replicationMap, _ := getTopologyReplicationMap(instances)
+
+	included := make(map[InstanceKey]bool)
+	var includeReplicas func(instance *Instance)
+	includeReplicas = func(instance *Instance) {
+		for _, replica := range replicationMap[instance] {
+			if !included[replica.Key] {
+				included[replica.Key] = true
+				includeReplicas(replica)
+			}
+		}
+	}
+	for _, instance := range instances {
+		if hostnameRegexp != nil && !hostnameRegexp.MatchString(instance.Key.Hostname) {
+			continue
+		}
+		if tagged != nil && !tagged.HasKey(instance.Key) {
+			continue
+		}
+		included[instance.Key] = true
+		includeReplicas(instance)
+		// Masters up the topology; co-masters make for a cycle
+		for master, found := instancesMap[instance.MasterKey]; found && !included[master.Key]; master, found = instancesMap[master.MasterKey] {
+			included[master.Key] = true
+		}
+	}
+	for _, instance := range instances {
+		if included[instance.Key] {
+			filtered = append(filtered, instance)
+		}
+	}
+	return filtered
+}
+
+// ASCIITopology returns a string representation of the topology of given cluster. The topology may be
+// limited by given filter, which may be nil. With colorize, entries are wrapped with terminal colors
+// depicting the health of their instances.
+func ASCIITopology(clusterName string, historyTimestampPattern string, tabulated bool, colorize bool, filter *TopologyFilter) (result string, err error) {
+	var instances [](*Instance)
+	if historyTimestampPattern == "" {
+		instances, err = ReadClusterInstances(clusterName)
+	} else {
+		instances, err = ReadHistoryClusterInstances(clusterName, historyTimestampPattern)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !filter.IsEmpty() {
+		var hostnameRegexp *regexp.Regexp
+		var tagged *InstanceKeyMap
+		if filter.HostnamePattern != "" {
+			if hostnameRegexp, err = regexp.Compile(filter.HostnamePattern); err != nil {
+				return "", err
+			}
+		}
+		if filter.Tags != "" {
+			if tagged, err = GetInstanceKeysByTags(filter.Tags); err != nil {
+				return "", err
+			}
+		}
+		instances = filterTopologyInstances(instances, hostnameRegexp, tagged)
+		if len(instances) == 0 {
+			return "", fmt.Errorf("No instances of %s match the filter", clusterName)
+		}
+	}
+
+	return renderASCIITopology(instances, historyTimestampPattern == "", tabulated, colorize), nil
+}
+
+// renderASCIITopology renders given instances as a topology tree, an entry per instance. With extendedOutput,
+// entries describe their instances.
+func renderASCIITopology(instances [](*Instance), extendedOutput bool, tabulated bool, colorize bool) string {
+	fillerCharacter := asciiFillerCharacter
+	replicationMap, masterInstance := getTopologyReplicationMap(instances)
 	// Get entries:
 	var entries []string
+	var entryInstances [](*Instance)
 	if masterInstance != nil {
 		// Single master
-		entries = getASCIITopologyEntry(0, masterInstance, replicationMap, historyTimestampPattern == "", fillerCharacter, tabulated)
+		entries, entryInstances = getASCIITopologyEntry(0, nil, masterInstance, replicationMap, extendedOutput, fillerCharacter, tabulated)
 	} else {
 		// Co-masters? For visualization we put each in its own branch while ignoring its other co-masters.
 		for _, instance := range instances {
 			if instance.IsCoMaster {
-				entries = append(entries, getASCIITopologyEntry(1, instance, replicationMap, historyTimestampPattern == "", fillerCharacter, tabulated)...)
+				coMasterEntries, coMasterEntryInstances := getASCIITopologyEntry(1, nil, instance, replicationMap, extendedOutput, fillerCharacter, tabulated)
+				entries = append(entries, coMasterEntries...)
+				entryInstances = append(entryInstances, coMasterEntryInstances...)
 			}
 		}
 	}
@@ -146,9 +284,11 @@ func ASCIITopology(clusterName string, historyTimestampPattern string, tabulated
 			}
 		}
 	}
-	// Turn into string
-	result = strings.Join(entries, "\n")
-	return result, nil
+	// Colors go last, so as not to affect alignment
+	if colorize {
+		entries = colorizeASCIITopologyEntries(entries, entryInstances)
+	}
+	return strings.Join(entries, "\n")
 }
 
 func shouldPostponeRelocatingReplica(replica *Instance, postponedFunctionsContainer *PostponedFunctionsContainer) bool {
@@ -156,7 +296,8 @@ func shouldPostponeRelocatingReplica(replica *Instance, postponedFunctionsContai
 		return false
 	}
 	if config.Config.PostponeReplicaRecoveryOnLagMinutes > 0 &&
-		replica.SQLDelay > config.Config.PostponeReplicaRecoveryOnLagMinutes*60 {
+		replica.SQLDelay > config.Config.PostponeReplicaRecoveryOnLagMinutes*60 &&
+		!postponedFunctionsContainer.IsExemptFromLagPostponing(&replica.Key) {
 		// This replica is lagging very much, AND
 		// we're configured to postpone operation on this replica so as not to delay everyone else.
 		return true
@@ -255,6 +396,15 @@ Cleanup:
 // It will perform all safety and sanity checks and will tamper with this instance's replication
 // as well as its master.
 func MoveUp(instanceKey *InstanceKey) (*Instance, error) {
+	return MoveUpContext(context.Background(), instanceKey)
+}
+
+// MoveUpContext is MoveUp, bounded by given context. Should the context be done mid-operation, the operation
+// is aborted and replication is restarted on the involved instances.
+func MoveUpContext(ctx context.Context, instanceKey *InstanceKey) (movedInstance *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "move-up", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -275,58 +425,68 @@ func MoveUp(instanceKey *InstanceKey) (*Instance, error) {
 		return instance, fmt.Errorf("master is not a replica itself: %+v", master.Key)
 	}
 
-	if canReplicate, err := instance.CanReplicateFrom(master); canReplicate == false {
+	if canReplicate, err := instance.CanReplicateFromContext(ctx, master); canReplicate == false {
 		return instance, err
 	}
 	if master.IsBinlogServer() {
 		// Quick solution via binlog servers
-		return Repoint(instanceKey, &master.MasterKey, GTIDHintDeny)
+		return RepointContext(ctx, instanceKey, &master.MasterKey, GTIDHintDeny)
+	}
+	if grandparent := moveUpViaGTIDGrandparent(instance, master); grandparent != nil {
+		// Quick solution via Oracle GTID: no need to stop the master
+		return moveUpViaGTID(ctx, instance, master, grandparent)
 	}
 
 	log.Infof("Will move %+v up the topology", *instanceKey)
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "move up"); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, "move up"); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
-	if maintenanceToken, merr := BeginMaintenance(&master.Key, GetMaintenanceOwner(), fmt.Sprintf("child %+v moves up", *instanceKey)); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, &master.Key, fmt.Sprintf("child %+v moves up", *instanceKey)); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", master.Key)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
 	if !instance.UsingMariaDBGTID {
-		master, err = StopSlave(&master.Key)
+		master, err = StopSlaveContext(ctx, &master.Key)
 		if err != nil {
 			goto Cleanup
 		}
 	}
 
-	instance, err = StopSlave(instanceKey)
+	instance, err = StopSlaveContext(ctx, instanceKey)
 	if err != nil {
 		goto Cleanup
 	}
 
 	if !instance.UsingMariaDBGTID {
-		instance, err = StartSlaveUntilMasterCoordinates(instanceKey, &master.SelfBinlogCoordinates)
+		instance, err = StartSlaveUntilMasterCoordinatesContext(ctx, instanceKey, &master.SelfBinlogCoordinates)
 		if err != nil {
 			goto Cleanup
 		}
 	}
 
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
 	// We can skip hostname unresolve; we just copy+paste whatever our master thinks of its master.
-	instance, err = ChangeMasterTo(instanceKey, &master.MasterKey, &master.ExecBinlogCoordinates, true, GTIDHintDeny)
+	instance, err = ChangeMasterToContext(ctx, instanceKey, &master.MasterKey, &master.ExecBinlogCoordinates, true, GTIDHintDeny)
 	if err != nil {
 		goto Cleanup
 	}
 
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
 	if !instance.UsingMariaDBGTID {
-		master, _ = StartSlave(&master.Key)
+		master, _ = StartSlaveContext(ctx, &master.Key)
 	}
 	if err != nil {
 		return instance, log.Errore(err)
@@ -337,14 +497,82 @@ Cleanup:
 	return instance, err
 }
 
+// moveUpViaGTIDGrandparent returns the master of given instance's master, if the instance can move up below it
+// via Oracle GTID; or else nil, in which case moving up requires stopping the master.
+func moveUpViaGTIDGrandparent(instance, master *Instance) *Instance {
+	if !instance.UsingOracleGTID {
+		return nil
+	}
+	grandparent, err := ReadTopologyInstance(&master.MasterKey)
+	if err != nil || grandparent == nil {
+		return nil
+	}
+	if canReplicate, _ := instance.CanReplicateFrom(grandparent); !canReplicate {
+		return nil
+	}
+	if err := CheckMoveViaGTID(instance, grandparent); err != nil {
+		log.Debugf("moveUpViaGTIDGrandparent: %+v", err)
+		return nil
+	}
+	return grandparent
+}
+
+// moveUpViaGTID moves given instance up below its grandparent by way of GTID auto positioning. Unlike the
+// file:pos approach, replication on the master is not stopped.
+func moveUpViaGTID(ctx context.Context, instance, master, grandparent *Instance) (*Instance, error) {
+	instanceKey := &instance.Key
+	log.Infof("Will move %+v up the topology via GTID", *instanceKey)
+
+	var err error
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, "move up via GTID"); merr != nil {
+		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
+		goto Cleanup
+	} else {
+		defer EndMaintenance(maintenanceToken)
+	}
+
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
+	instance, err = StopSlaveContext(ctx, instanceKey)
+	if err != nil {
+		goto Cleanup
+	}
+
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
+	// We can skip hostname unresolve; we just copy+paste whatever our master thinks of its master.
+	instance, err = ChangeMasterToContext(ctx, instanceKey, &master.MasterKey, &grandparent.SelfBinlogCoordinates, true, GTIDHintForce)
+	if err != nil {
+		goto Cleanup
+	}
+
+Cleanup:
+	instance, _ = StartSlaveContext(ctx, instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	// and we're done (pending deferred functions)
+	AuditOperation("move-up", instanceKey, fmt.Sprintf("moved up %+v via GTID. Previous master: %+v", *instanceKey, master.Key))
+
+	return instance, err
+}
+
 // MoveUpReplicas will attempt moving up all replicas of a given instance, at the same time.
 // Clock-time, this is fater than moving one at a time. However this means all replicas of the given instance, and the instance itself,
 // will all stop replicating together.
 func MoveUpReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), *Instance, error, []error) {
+	return MoveUpReplicasContext(context.Background(), instanceKey, pattern)
+}
+
+// MoveUpReplicasContext is MoveUpReplicas, with maintenance attributed as per given context
+func MoveUpReplicasContext(ctx context.Context, instanceKey *InstanceKey, pattern string) (movedReplicas [](*Instance), newMaster *Instance, err error, errs []error) {
+	ctx, span := startOperationSpan(ctx, "move-up-replicas", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	res := [](*Instance){}
-	errs := []error{}
-	replicaMutex := make(chan bool, 1)
-	var barrier chan *InstanceKey
+	errs = []error{}
 
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
@@ -374,14 +602,18 @@ func MoveUpReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), *I
 	}
 	log.Infof("Will move replicas of %+v up the topology", *instanceKey)
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "move up replicas"); merr != nil {
+	ctx, cancel := NewOperationContext(context.Background(), 0)
+	defer cancel()
+	pool := newReplicaOperationsPool(ctx, instance.ClusterName)
+
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, "move up replicas"); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 	for _, replica := range replicas {
-		if maintenanceToken, merr := BeginMaintenance(&replica.Key, GetMaintenanceOwner(), fmt.Sprintf("%+v moves up", replica.Key)); merr != nil {
+		if maintenanceToken, merr := beginOperationMaintenance(ctx, &replica.Key, fmt.Sprintf("%+v moves up", replica.Key)); merr != nil {
 			err = fmt.Errorf("Cannot begin maintenance on %+v", replica.Key)
 			goto Cleanup
 		} else {
@@ -389,19 +621,16 @@ func MoveUpReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), *I
 		}
 	}
 
-	instance, err = StopSlave(instanceKey)
+	instance, err = StopSlaveContext(ctx, instanceKey)
 	if err != nil {
 		goto Cleanup
 	}
 
-	barrier = make(chan *InstanceKey)
 	for _, replica := range replicas {
 		replica := replica
-		go func() {
-			defer func() {
-				defer func() { barrier <- &replica.Key }()
-				StartSlave(&replica.Key)
-			}()
+		replicaKey := replica.Key
+		pool.Go(replicaKey.StringCode(), func() error {
+			defer StartSlaveContext(ctx, &replicaKey)
 
 			var replicaErr error
 			ExecuteOnTopology(func() {
@@ -409,51 +638,33 @@ func MoveUpReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), *I
 					replicaErr = err
 					return
 				}
-				if instance.IsBinlogServer() {
-					// Special case. Just repoint
-					replica, err = Repoint(&replica.Key, instanceKey, GTIDHintDeny)
-					if err != nil {
-						replicaErr = err
-						return
+				replicaErr = retryReplicaMove(ctx, &replicaKey, func() (err error) {
+					if instance.IsBinlogServer() {
+						// Special case. Just repoint
+						replica, err = Repoint(&replicaKey, instanceKey, GTIDHintDeny)
+						return err
 					}
-				} else {
 					// Normal case. Do the math.
-					replica, err = StopSlave(&replica.Key)
-					if err != nil {
-						replicaErr = err
-						return
+					if replica, err = StopSlaveContext(ctx, &replicaKey); err != nil {
+						return err
 					}
-					replica, err = StartSlaveUntilMasterCoordinates(&replica.Key, &instance.SelfBinlogCoordinates)
-					if err != nil {
-						replicaErr = err
-						return
-					}
-
-					replica, err = ChangeMasterTo(&replica.Key, &instance.MasterKey, &instance.ExecBinlogCoordinates, false, GTIDHintDeny)
-					if err != nil {
-						replicaErr = err
-						return
+					if replica, err = StartSlaveUntilMasterCoordinates(&replicaKey, &instance.SelfBinlogCoordinates); err != nil {
+						return err
 					}
-				}
+					replica, err = ChangeMasterTo(&replicaKey, &instance.MasterKey, &instance.ExecBinlogCoordinates, false, GTIDHintDeny)
+					return err
+				})
 			})
-
-			func() {
-				replicaMutex <- true
-				defer func() { <-replicaMutex }()
-				if replicaErr == nil {
-					res = append(res, replica)
-				} else {
-					errs = append(errs, replicaErr)
-				}
-			}()
-		}()
-	}
-	for range replicas {
-		<-barrier
+			if replicaErr == nil {
+				pool.Synchronized(func() { res = append(res, replica) })
+			}
+			return replicaErr
+		})
 	}
+	errs = pool.Wait()
 
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
 	if err != nil {
 		return res, instance, log.Errore(err), errs
 	}
@@ -470,6 +681,14 @@ Cleanup:
 // It will perform all safety and sanity checks and will tamper with this instance's replication
 // as well as its sibling.
 func MoveBelow(instanceKey, siblingKey *InstanceKey) (*Instance, error) {
+	return MoveBelowContext(context.Background(), instanceKey, siblingKey)
+}
+
+// MoveBelowContext is MoveBelow, bounded by given context
+func MoveBelowContext(ctx context.Context, instanceKey, siblingKey *InstanceKey) (movedInstance *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "move-below", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -482,7 +701,7 @@ func MoveBelow(instanceKey, siblingKey *InstanceKey) (*Instance, error) {
 	if sibling.IsBinlogServer() {
 		// Binlog server has same coordinates as master
 		// Easy solution!
-		return Repoint(instanceKey, &sibling.Key, GTIDHintDeny)
+		return RepointContext(ctx, instanceKey, &sibling.Key, GTIDHintDeny)
 	}
 
 	rinstance, _, _ := ReadInstance(&instance.Key)
@@ -498,54 +717,60 @@ func MoveBelow(instanceKey, siblingKey *InstanceKey) (*Instance, error) {
 		return instance, fmt.Errorf("instances are not siblings: %+v, %+v", *instanceKey, *siblingKey)
 	}
 
-	if canReplicate, err := instance.CanReplicateFrom(sibling); !canReplicate {
+	if canReplicate, err := instance.CanReplicateFromContext(ctx, sibling); !canReplicate {
 		return instance, err
 	}
 	log.Infof("Will move %+v below %+v", instanceKey, siblingKey)
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("move below %+v", *siblingKey)); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, fmt.Sprintf("move below %+v", *siblingKey)); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
-	if maintenanceToken, merr := BeginMaintenance(siblingKey, GetMaintenanceOwner(), fmt.Sprintf("%+v moves below this", *instanceKey)); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, siblingKey, fmt.Sprintf("%+v moves below this", *instanceKey)); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *siblingKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 
-	instance, err = StopSlave(instanceKey)
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
+	instance, err = StopSlaveContext(ctx, instanceKey)
 	if err != nil {
 		goto Cleanup
 	}
 
-	sibling, err = StopSlave(siblingKey)
+	sibling, err = StopSlaveContext(ctx, siblingKey)
 	if err != nil {
 		goto Cleanup
 	}
 	if instance.ExecBinlogCoordinates.SmallerThan(&sibling.ExecBinlogCoordinates) {
-		instance, err = StartSlaveUntilMasterCoordinates(instanceKey, &sibling.ExecBinlogCoordinates)
+		instance, err = StartSlaveUntilMasterCoordinatesContext(ctx, instanceKey, &sibling.ExecBinlogCoordinates)
 		if err != nil {
 			goto Cleanup
 		}
 	} else if sibling.ExecBinlogCoordinates.SmallerThan(&instance.ExecBinlogCoordinates) {
-		sibling, err = StartSlaveUntilMasterCoordinates(siblingKey, &instance.ExecBinlogCoordinates)
+		sibling, err = StartSlaveUntilMasterCoordinatesContext(ctx, siblingKey, &instance.ExecBinlogCoordinates)
 		if err != nil {
 			goto Cleanup
 		}
 	}
 	// At this point both siblings have executed exact same statements and are identical
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
 
-	instance, err = ChangeMasterTo(instanceKey, &sibling.Key, &sibling.SelfBinlogCoordinates, false, GTIDHintDeny)
+	instance, err = ChangeMasterToContext(ctx, instanceKey, &sibling.Key, &sibling.SelfBinlogCoordinates, false, GTIDHintDeny)
 	if err != nil {
 		goto Cleanup
 	}
 
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
-	sibling, _ = StartSlave(siblingKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
+	sibling, _ = StartSlaveContext(ctx, siblingKey)
 
 	if err != nil {
 		return instance, log.Errore(err)
@@ -557,6 +782,11 @@ Cleanup:
 }
 
 func canReplicateAssumingOracleGTID(instance, masterInstance *Instance) (canReplicate bool, err error) {
+	if masterGtidPurged, err := ParseGtidIntervalSet(masterInstance.GtidPurged); err == nil {
+		if executedGtidSet, err := ParseGtidIntervalSet(instance.ExecutedGtidSet); err == nil {
+			return executedGtidSet.Contains(masterGtidPurged), nil
+		}
+	}
 	subtract, err := GTIDSubtract(&instance.Key, masterInstance.GtidPurged, instance.ExecutedGtidSet)
 	if err != nil {
 		return false, err
@@ -576,31 +806,23 @@ func instancesAreGTIDAndCompatible(instance, otherInstance *Instance) (isOracleG
 }
 
 func CheckMoveViaGTID(instance, otherInstance *Instance) (err error) {
-	isOracleGTID, _, moveCompatible := instancesAreGTIDAndCompatible(instance, otherInstance)
-	if !moveCompatible {
-		return fmt.Errorf("Instances %+v, %+v not GTID compatible or not using GTID", instance.Key, otherInstance.Key)
-	}
-	if isOracleGTID {
-		canReplicate, err := canReplicateAssumingOracleGTID(instance, otherInstance)
-		if err != nil {
+	for _, check := range gtidReplicationChecks {
+		if err := check.check(instance, otherInstance); err != nil {
 			return err
 		}
-		if !canReplicate {
-			return fmt.Errorf("Instance %+v has purged GTID entries not found on %+v", otherInstance.Key, instance.Key)
-		}
 	}
-
 	return nil
 }
 
 // moveInstanceBelowViaGTID will attempt moving given instance below another instance using either Oracle GTID or MariaDB GTID.
-func moveInstanceBelowViaGTID(instance, otherInstance *Instance) (*Instance, error) {
+func moveInstanceBelowViaGTID(ctx context.Context, instance, otherInstance *Instance) (*Instance, error) {
+	relocatedInstance := *instance
 	rinstance, _, _ := ReadInstance(&instance.Key)
 	if canMove, merr := rinstance.CanMoveViaMatch(); !canMove {
 		return instance, merr
 	}
 
-	if canReplicate, err := instance.CanReplicateFrom(otherInstance); !canReplicate {
+	if canReplicate, err := instance.CanReplicateFromContext(ctx, otherInstance); !canReplicate {
 		return instance, err
 	}
 	if err := CheckMoveViaGTID(instance, otherInstance); err != nil {
@@ -612,35 +834,50 @@ func moveInstanceBelowViaGTID(instance, otherInstance *Instance) (*Instance, err
 	otherInstanceKey := &otherInstance.Key
 
 	var err error
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("move below %+v", *otherInstanceKey)); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, fmt.Sprintf("move below %+v", *otherInstanceKey)); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 
-	instance, err = StopSlave(instanceKey)
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
+	instance, err = StopSlaveContext(ctx, instanceKey)
 	if err != nil {
 		goto Cleanup
 	}
 
-	instance, err = ChangeMasterTo(instanceKey, &otherInstance.Key, &otherInstance.SelfBinlogCoordinates, false, GTIDHintForce)
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
+	instance, err = ChangeMasterToContext(ctx, instanceKey, &otherInstance.Key, &otherInstance.SelfBinlogCoordinates, false, GTIDHintForce)
 	if err != nil {
 		goto Cleanup
 	}
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
 	// and we're done (pending deferred functions)
 	AuditOperation("move-below-gtid", instanceKey, fmt.Sprintf("moved %+v below %+v", *instanceKey, *otherInstanceKey))
+	recordRelocation(ctx, RelocationOperationMoveBelowGTID, &relocatedInstance, otherInstanceKey)
 
 	return instance, err
 }
 
 // MoveBelowGTID will attempt moving instance indicated by instanceKey below another instance using either Oracle GTID or MariaDB GTID.
 func MoveBelowGTID(instanceKey, otherKey *InstanceKey) (*Instance, error) {
+	return MoveBelowGTIDContext(context.Background(), instanceKey, otherKey)
+}
+
+// MoveBelowGTIDContext is MoveBelowGTID, bounded by given context
+func MoveBelowGTIDContext(ctx context.Context, instanceKey, otherKey *InstanceKey) (movedInstance *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "move-below-gtid", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -649,12 +886,12 @@ func MoveBelowGTID(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
-	return moveInstanceBelowViaGTID(instance, other)
+	return moveInstanceBelowViaGTID(ctx, instance, other)
 }
 
 // moveReplicasViaGTID moves a list of replicas under another instance via GTID, returning those replicas
 // that could not be moved (do not use GTID or had GTID errors)
-func moveReplicasViaGTID(replicas [](*Instance), other *Instance, postponedFunctionsContainer *PostponedFunctionsContainer) (movedReplicas [](*Instance), unmovedReplicas [](*Instance), err error, errs []error) {
+func moveReplicasViaGTID(ctx context.Context, replicas [](*Instance), other *Instance, postponedFunctionsContainer *PostponedFunctionsContainer) (movedReplicas [](*Instance), unmovedReplicas [](*Instance), err error, errs []error) {
 	replicas = RemoveNilInstances(replicas)
 	replicas = RemoveInstance(replicas, &other.Key)
 	if len(replicas) == 0 {
@@ -664,49 +901,53 @@ func moveReplicasViaGTID(replicas [](*Instance), other *Instance, postponedFunct
 
 	log.Infof("moveReplicasViaGTID: Will move %+v replicas below %+v via GTID", len(replicas), other.Key)
 
-	var waitGroup sync.WaitGroup
-	var replicaMutex sync.Mutex
-
-	var concurrencyChan = make(chan bool, MaxConcurrentReplicaOperations)
+	pool := newReplicaOperationsPool(ctx, other.ClusterName)
+	postponedResults := &postponedReplicaResults{}
 
 	for _, replica := range replicas {
 		replica := replica
 
-		waitGroup.Add(1)
 		// Parallelize repoints
-		go func() {
-			defer waitGroup.Done()
-			moveFunc := func() error {
-
-				concurrencyChan <- true
-				defer func() { recover(); <-concurrencyChan }()
-
-				movedReplica, replicaErr := moveInstanceBelowViaGTID(replica, other)
-				if replicaErr != nil && movedReplica != nil {
-					replica = movedReplica
-				}
-
-				// After having moved replicas, update local shared variables:
-				replicaMutex.Lock()
-				defer replicaMutex.Unlock()
-
-				if replicaErr == nil {
-					movedReplicas = append(movedReplicas, replica)
-				} else {
-					unmovedReplicas = append(unmovedReplicas, replica)
-					errs = append(errs, replicaErr)
+		pool.Go(replica.Key.StringCode(), func() (replicaErr error) {
+			moveFunc := func(ctx context.Context) (*Instance, error) {
+				var movedReplica *Instance
+				err := retryReplicaMove(ctx, &replica.Key, func() (err error) {
+					movedReplica, err = moveInstanceBelowViaGTID(ctx, replica, other)
+					return err
+				})
+				if err != nil && movedReplica != nil {
+					return movedReplica, err
 				}
-				return replicaErr
+				return replica, err
 			}
 			if shouldPostponeRelocatingReplica(replica, postponedFunctionsContainer) {
-				postponedFunctionsContainer.AddPostponedFunction(moveFunc, fmt.Sprintf("move-replicas-gtid %+v", replica.Key))
+				// Postponed functions outlive the invoker's context, and the pool: they report to postponedResults
+				postponedFunc := func(ctx context.Context) error {
+					movedReplica, err := moveFunc(ctx)
+					moved, unmoved := postponedResults.add(movedReplica, err)
+					AuditOperation("move-replicas-gtid", &other.Key, fmt.Sprintf("postponed move of %+v below %+v via GTID: %+v; postponed moves so far: %d moved, %d unmoved", replica.Key, other.Key, err, moved, unmoved))
+					return err
+				}
+				postponedFunctionsContainer.AddPrioritizedPostponedFunction(detachedOperationContext(ctx), postponedFunc, fmt.Sprintf("move-replicas-gtid %+v", replica.Key), PostponedRelocationPriority(replica), 0)
 				// We bail out and trust our invoker to later call upon this postponed function
-			} else {
-				ExecuteOnTopology(func() { moveFunc() })
+				return nil
 			}
-		}()
+			ExecuteOnTopology(func() {
+				var movedReplica *Instance
+				movedReplica, replicaErr = moveFunc(ctx)
+				// After having moved replicas, update local shared variables:
+				pool.Synchronized(func() {
+					if replicaErr == nil {
+						movedReplicas = append(movedReplicas, movedReplica)
+					} else {
+						unmovedReplicas = append(unmovedReplicas, movedReplica)
+					}
+				})
+			})
+			return replicaErr
+		})
 	}
-	waitGroup.Wait()
+	errs = pool.Wait()
 
 	if len(errs) == len(replicas) {
 		// All returned with error
@@ -731,7 +972,7 @@ func MoveReplicasGTID(masterKey *InstanceKey, belowKey *InstanceKey, pattern str
 		return movedReplicas, unmovedReplicas, err, errs
 	}
 	replicas = filterInstancesByPattern(replicas, pattern)
-	movedReplicas, unmovedReplicas, err, errs = moveReplicasViaGTID(replicas, belowInstance, nil)
+	movedReplicas, unmovedReplicas, err, errs = moveReplicasViaGTID(context.Background(), replicas, belowInstance, nil)
 	if err != nil {
 		log.Errore(err)
 	}
@@ -749,6 +990,14 @@ func MoveReplicasGTID(masterKey *InstanceKey, belowKey *InstanceKey, pattern str
 // - masterKey is nil: use case is corrupted relay logs on replica
 // - masterKey is not nil: using Binlog servers (coordinates remain the same)
 func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint OperationGTIDHint) (*Instance, error) {
+	return RepointContext(context.Background(), instanceKey, masterKey, gtidHint)
+}
+
+// RepointContext is Repoint, bounded by given context
+func RepointContext(ctx context.Context, instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint OperationGTIDHint) (repointedInstance *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "repoint", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -760,6 +1009,7 @@ func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint Operatio
 	if masterKey == nil {
 		masterKey = &instance.MasterKey
 	}
+	relocatedInstance := *instance
 	// With repoint we *prefer* the master to be alive, but we don't strictly require it.
 	// The use case for the master being alive is with hostname-resolve or hostname-unresolve: asking the replica
 	// to reconnect to its same master while changing the MASTER_HOST in CHANGE MASTER TO due to DNS changes etc.
@@ -771,7 +1021,7 @@ func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint Operatio
 			return instance, err
 		}
 	}
-	if canReplicate, err := instance.CanReplicateFrom(master); !canReplicate {
+	if canReplicate, err := instance.CanReplicateFromContext(ctx, master); !canReplicate {
 		return instance, err
 	}
 
@@ -785,14 +1035,17 @@ func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint Operatio
 
 	log.Infof("Will repoint %+v to master %+v", *instanceKey, *masterKey)
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "repoint"); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, "repoint"); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 
-	instance, err = StopSlave(instanceKey)
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
+	instance, err = StopSlaveContext(ctx, instanceKey)
 	if err != nil {
 		goto Cleanup
 	}
@@ -803,18 +1056,19 @@ func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint Operatio
 	if instance.ExecBinlogCoordinates.IsEmpty() {
 		instance.ExecBinlogCoordinates.LogFile = "orchestrator-unknown-log-file"
 	}
-	instance, err = ChangeMasterTo(instanceKey, masterKey, &instance.ExecBinlogCoordinates, !masterIsAccessible, gtidHint)
+	instance, err = ChangeMasterToContext(ctx, instanceKey, masterKey, &instance.ExecBinlogCoordinates, !masterIsAccessible, gtidHint)
 	if err != nil {
 		goto Cleanup
 	}
 
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
 	// and we're done (pending deferred functions)
 	AuditOperation("repoint", instanceKey, fmt.Sprintf("replica %+v repointed to master: %+v", *instanceKey, *masterKey))
+	recordRelocation(ctx, RelocationOperationRepoint, &relocatedInstance, masterKey)
 
 	return instance, err
 
@@ -823,8 +1077,16 @@ Cleanup:
 // RepointTo repoints list of replicas onto another master.
 // Binlog Server is the major use case
 func RepointTo(replicas [](*Instance), belowKey *InstanceKey) ([](*Instance), error, []error) {
+	return RepointToContext(context.Background(), replicas, belowKey)
+}
+
+// RepointToContext is RepointTo, where master changes are attributed to the master change origin of given context
+func RepointToContext(ctx context.Context, replicas [](*Instance), belowKey *InstanceKey) (repointedReplicas [](*Instance), err error, errs []error) {
+	ctx, span := startOperationSpan(ctx, "repoint-to", belowKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	res := [](*Instance){}
-	errs := []error{}
+	errs = []error{}
 
 	replicas = RemoveInstance(replicas, belowKey)
 	if len(replicas) == 0 {
@@ -836,33 +1098,24 @@ func RepointTo(replicas [](*Instance), belowKey *InstanceKey) ([](*Instance), er
 	}
 
 	log.Infof("Will repoint %+v replicas below %+v", len(replicas), *belowKey)
-	barrier := make(chan *InstanceKey)
-	replicaMutex := make(chan bool, 1)
+	ctx, cancel := NewOperationContext(ctx, 0)
+	defer cancel()
+	pool := newReplicaOperationsPool(ctx, replicas[0].ClusterName)
 	for _, replica := range replicas {
 		replica := replica
 
 		// Parallelize repoints
-		go func() {
-			defer func() { barrier <- &replica.Key }()
+		pool.Go(replica.Key.StringCode(), func() (replicaErr error) {
 			ExecuteOnTopology(func() {
-				replica, replicaErr := Repoint(&replica.Key, belowKey, GTIDHintNeutral)
-
-				func() {
-					// Instantaneous mutex.
-					replicaMutex <- true
-					defer func() { <-replicaMutex }()
-					if replicaErr == nil {
-						res = append(res, replica)
-					} else {
-						errs = append(errs, replicaErr)
-					}
-				}()
+				var repointedReplica *Instance
+				if repointedReplica, replicaErr = RepointContext(ctx, &replica.Key, belowKey, GTIDHintNeutral); replicaErr == nil {
+					pool.Synchronized(func() { res = append(res, repointedReplica) })
+				}
 			})
-		}()
-	}
-	for range replicas {
-		<-barrier
+			return replicaErr
+		})
 	}
+	errs = pool.Wait()
 
 	if len(errs) == len(replicas) {
 		// All returned with error
@@ -905,6 +1158,14 @@ func RepointReplicas(instanceKey *InstanceKey, pattern string) ([](*Instance), e
 // MakeCoMaster will attempt to make an instance co-master with its master, by making its master a replica of its own.
 // This only works out if the master is not replicating; the master does not have a known master (it may have an unknown master).
 func MakeCoMaster(instanceKey *InstanceKey) (*Instance, error) {
+	return MakeCoMasterContext(context.Background(), instanceKey)
+}
+
+// MakeCoMasterContext is MakeCoMaster, with maintenance attributed as per given context
+func MakeCoMasterContext(ctx context.Context, instanceKey *InstanceKey) (coMaster *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "make-co-master", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -951,13 +1212,13 @@ func MakeCoMaster(instanceKey *InstanceKey) (*Instance, error) {
 	log.Infof("Will make %+v co-master of %+v", instanceKey, master.Key)
 
 	var gitHint OperationGTIDHint = GTIDHintNeutral
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("make co-master of %+v", master.Key)); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, fmt.Sprintf("make co-master of %+v", master.Key)); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
-	if maintenanceToken, merr := BeginMaintenance(&master.Key, GetMaintenanceOwner(), fmt.Sprintf("%+v turns into co-master of this", *instanceKey)); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, &master.Key, fmt.Sprintf("%+v turns into co-master of this", *instanceKey)); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", master.Key)
 		goto Cleanup
 	} else {
@@ -969,7 +1230,7 @@ func MakeCoMaster(instanceKey *InstanceKey) (*Instance, error) {
 	if master.IsReplica() {
 		// this is the case of a co-master. For masters, the StopSlave operation throws an error, and
 		// there's really no point in doing it.
-		master, err = StopSlave(&master.Key)
+		master, err = StopSlaveContext(ctx, &master.Key)
 		if err != nil {
 			goto Cleanup
 		}
@@ -1002,7 +1263,7 @@ func MakeCoMaster(instanceKey *InstanceKey) (*Instance, error) {
 	}
 
 Cleanup:
-	master, _ = StartSlave(&master.Key)
+	master, _ = StartSlaveContext(ctx, &master.Key)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
@@ -1014,14 +1275,22 @@ Cleanup:
 
 // ResetSlaveOperation will reset a replica
 func ResetSlaveOperation(instanceKey *InstanceKey) (*Instance, error) {
+	return ResetSlaveOperationContext(context.Background(), instanceKey)
+}
+
+// ResetSlaveOperationContext is ResetSlaveOperation, with maintenance attributed as per given context
+func ResetSlaveOperationContext(ctx context.Context, instanceKey *InstanceKey) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
 	}
+	if err := checkOperationPolicy("reset-replica", instanceKey, nil, nil); err != nil {
+		return instance, err
+	}
 
 	log.Infof("Will reset replica on %+v", instanceKey)
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "reset replica"); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, "reset replica"); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
@@ -1029,7 +1298,7 @@ func ResetSlaveOperation(instanceKey *InstanceKey) (*Instance, error) {
 	}
 
 	if instance.IsReplica() {
-		instance, err = StopSlave(instanceKey)
+		instance, err = StopSlaveContext(ctx, instanceKey)
 		if err != nil {
 			goto Cleanup
 		}
@@ -1041,7 +1310,7 @@ func ResetSlaveOperation(instanceKey *InstanceKey) (*Instance, error) {
 	}
 
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
 
 	if err != nil {
 		return instance, log.Errore(err)
@@ -1055,6 +1324,11 @@ Cleanup:
 
 // DetachReplicaMasterHost detaches a replica from its master by corrupting the Master_Host (in such way that is reversible)
 func DetachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
+	return DetachReplicaMasterHostContext(context.Background(), instanceKey)
+}
+
+// DetachReplicaMasterHostContext is DetachReplicaMasterHost, with maintenance attributed as per given context
+func DetachReplicaMasterHostContext(ctx context.Context, instanceKey *InstanceKey) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -1065,18 +1339,21 @@ func DetachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
 	if instance.MasterKey.IsDetached() {
 		return instance, fmt.Errorf("instance already detached: %+v", *instanceKey)
 	}
+	if err := checkOperationPolicy("detach-replica-master-host", instanceKey, &instance.MasterKey, nil); err != nil {
+		return instance, err
+	}
 	detachedMasterKey := instance.MasterKey.DetachedKey()
 
 	log.Infof("Will detach master host on %+v. Detached key is %+v", *instanceKey, *detachedMasterKey)
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "detach-replica-master-host"); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, "detach-replica-master-host"); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 
-	instance, err = StopSlave(instanceKey)
+	instance, err = StopSlaveContext(ctx, instanceKey)
 	if err != nil {
 		goto Cleanup
 	}
@@ -1087,7 +1364,7 @@ func DetachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
 	}
 
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
@@ -1099,6 +1376,11 @@ Cleanup:
 
 // ReattachReplicaMasterHost reattaches a replica back onto its master by undoing a DetachReplicaMasterHost operation
 func ReattachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
+	return ReattachReplicaMasterHostContext(context.Background(), instanceKey)
+}
+
+// ReattachReplicaMasterHostContext is ReattachReplicaMasterHost, with maintenance attributed as per given context
+func ReattachReplicaMasterHostContext(ctx context.Context, instanceKey *InstanceKey) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -1114,19 +1396,20 @@ func ReattachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
 
 	log.Infof("Will reattach master host on %+v. Reattached key is %+v", *instanceKey, *reattachedMasterKey)
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "reattach-replica-master-host"); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, "reattach-replica-master-host"); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
 		defer EndMaintenance(maintenanceToken)
 	}
 
-	instance, err = StopSlave(instanceKey)
+	instance, err = StopSlaveContext(ctx, instanceKey)
 	if err != nil {
 		goto Cleanup
 	}
 
-	instance, err = ChangeMasterTo(instanceKey, reattachedMasterKey, &instance.ExecBinlogCoordinates, true, GTIDHintNeutral)
+	// Reattaching restores the replication edge the instance had before being detached
+	instance, err = ChangeMasterToContext(withWANRelocationChecked(context.Background()), instanceKey, reattachedMasterKey, &instance.ExecBinlogCoordinates, true, GTIDHintNeutral)
 	if err != nil {
 		goto Cleanup
 	}
@@ -1134,7 +1417,7 @@ func ReattachReplicaMasterHost(instanceKey *InstanceKey) (*Instance, error) {
 	ReplaceAliasClusterName(instanceKey.StringCode(), reattachedMasterKey.StringCode())
 
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
@@ -1199,50 +1482,59 @@ func LocateErrantGTID(instanceKey *InstanceKey) (errantBinlogs []string, err err
 	if err != nil {
 		return errantBinlogs, err
 	}
-	errantSearch := instance.GtidErrant
-	if errantSearch == "" {
+	if instance.GtidErrant == "" {
 		return errantBinlogs, log.Errorf("locate-errant-gtid: no errant-gtid on %+v", *instanceKey)
 	}
-	subtract, err := GTIDSubtract(instanceKey, errantSearch, instance.GtidPurged)
+	return locateGTIDSetBinaryLogs(instance, instance.GtidErrant, "locate-errant-gtid")
+}
+
+// locateGTIDSetBinaryLogs lists the binary logs of given instance which contain transactions of given GTID set.
+// It fails when any such transaction is already purged.
+func locateGTIDSetBinaryLogs(instance *Instance, gtidSet string, operation string) (gtidSetBinlogs []string, err error) {
+	instanceKey := &instance.Key
+	gtidSearch, err := ParseGtidIntervalSet(gtidSet)
+	if err != nil {
+		return gtidSetBinlogs, err
+	}
+	gtidPurged, err := ParseGtidIntervalSet(instance.GtidPurged)
 	if err != nil {
-		return errantBinlogs, err
+		return gtidSetBinlogs, err
 	}
-	if subtract != errantSearch {
-		return errantBinlogs, fmt.Errorf("locate-errant-gtid: %+v is already purged on %+v", subtract, *instanceKey)
+	if subtract := gtidSearch.Subtract(gtidPurged); !subtract.Equals(gtidSearch) {
+		return gtidSetBinlogs, fmt.Errorf("%s: %+v is already purged on %+v", operation, gtidSearch.Subtract(subtract).String(), *instanceKey)
 	}
 	binlogs, err := ShowBinaryLogs(instanceKey)
 	if err != nil {
-		return errantBinlogs, err
+		return gtidSetBinlogs, err
 	}
 	previousGTIDs := make(map[string]*OracleGtidSet)
 	for _, binlog := range binlogs {
 		oracleGTIDSet, err := GetPreviousGTIDs(instanceKey, binlog)
 		if err != nil {
-			return errantBinlogs, err
+			return gtidSetBinlogs, err
 		}
 		previousGTIDs[binlog] = oracleGTIDSet
 	}
 	for i, binlog := range binlogs {
-		if errantSearch == "" {
+		if gtidSearch.IsEmpty() {
 			break
 		}
-		previousGTID := previousGTIDs[binlog]
-		subtract, err := GTIDSubtract(instanceKey, errantSearch, previousGTID.String())
+		previousGTID, err := ParseGtidIntervalSet(previousGTIDs[binlog].String())
 		if err != nil {
-			return errantBinlogs, err
+			return gtidSetBinlogs, err
 		}
-		if subtract != errantSearch {
+		if subtract := gtidSearch.Subtract(previousGTID); !subtract.Equals(gtidSearch) {
 			// binlogs[i-1] is safe to use when i==0. because that implies GTIDs have been purged,
 			// which covered by an earlier assertion
-			errantBinlogs = append(errantBinlogs, binlogs[i-1])
-			errantSearch = subtract
+			gtidSetBinlogs = append(gtidSetBinlogs, binlogs[i-1])
+			gtidSearch = subtract
 		}
 	}
-	if errantSearch != "" {
+	if !gtidSearch.IsEmpty() {
 		// then it's in the last binary log
-		errantBinlogs = append(errantBinlogs, binlogs[len(binlogs)-1])
+		gtidSetBinlogs = append(gtidSetBinlogs, binlogs[len(binlogs)-1])
 	}
-	return errantBinlogs, err
+	return gtidSetBinlogs, err
 }
 
 // ErrantGTIDResetMaster will issue a safe RESET MASTER on a replica that replicates via GTID:
@@ -1250,6 +1542,11 @@ func LocateErrantGTID(instanceKey *InstanceKey) (errantBinlogs []string, err err
 // this will enable new replicas to be attached to given instance without complaints about missing/purged entries.
 // This function requires that the instance does not have replicas.
 func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err error) {
+	return ErrantGTIDResetMasterContext(context.Background(), instanceKey)
+}
+
+// ErrantGTIDResetMasterContext is ErrantGTIDResetMaster, with maintenance attributed as per given context
+func ErrantGTIDResetMasterContext(ctx context.Context, instanceKey *InstanceKey) (instance *Instance, err error) {
 	instance, err = ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -1263,14 +1560,24 @@ func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err er
 	if len(instance.SlaveHosts) > 0 {
 		return instance, log.Errorf("gtid-errant-reset-master will not operate on %+v because it has %+v replicas. Expecting no replicas", *instanceKey, len(instance.SlaveHosts))
 	}
+	if err := checkOperationPolicy("gtid-errant-reset-master", instanceKey, nil, map[string]interface{}{"GtidErrant": instance.GtidErrant}); err != nil {
+		return instance, err
+	}
+	return resetMasterExcludingGTIDs(ctx, instance, instance.GtidErrant, true, "gtid-errant-reset-master")
+}
 
+// resetMasterExcludingGTIDs issues a RESET MASTER on given instance, and sets its gtid_purged to its executed set as read just
+// before the reset, less given excluded GTID set. Replication is stopped throughout, and only restarted if so requested.
+func resetMasterExcludingGTIDs(ctx context.Context, instance *Instance, excludedGtidSet string, restartReplication bool, operation string) (*Instance, error) {
+	instanceKey := &instance.Key
+	var err error
 	gtidSubtract := ""
 	executedGtidSet := ""
 	masterStatusFound := false
 	replicationStopped := false
 	waitInterval := time.Second * 5
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), "reset-master-gtid"); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, operation); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
@@ -1278,7 +1585,7 @@ func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err er
 	}
 
 	if instance.IsReplica() {
-		instance, err = StopSlave(instanceKey)
+		instance, err = StopSlaveContext(ctx, instanceKey)
 		if err != nil {
 			goto Cleanup
 		}
@@ -1287,12 +1594,12 @@ func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err er
 			goto Cleanup
 		}
 		if !replicationStopped {
-			err = fmt.Errorf("gtid-errant-reset-master: timeout while waiting for replication to stop on %+v", instance.Key)
+			err = fmt.Errorf("%s: timeout while waiting for replication to stop on %+v", operation, instance.Key)
 			goto Cleanup
 		}
 	}
 
-	gtidSubtract, err = GTIDSubtract(instanceKey, instance.ExecutedGtidSet, instance.GtidErrant)
+	gtidSubtract, err = GTIDSubtract(instanceKey, instance.ExecutedGtidSet, excludedGtidSet)
 	if err != nil {
 		goto Cleanup
 	}
@@ -1308,21 +1615,21 @@ func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err er
 		time.Sleep(waitInterval)
 	}
 	if err != nil {
-		err = fmt.Errorf("gtid-errant-reset-master: error while resetting master on %+v, after which intended to set gtid_purged to: %s. Error was: %+v", instance.Key, gtidSubtract, err)
+		err = fmt.Errorf("%s: error while resetting master on %+v, after which intended to set gtid_purged to: %s. Error was: %+v", operation, instance.Key, gtidSubtract, err)
 		goto Cleanup
 	}
 
 	masterStatusFound, executedGtidSet, err = ShowMasterStatus(instanceKey)
 	if err != nil {
-		err = fmt.Errorf("gtid-errant-reset-master: error getting master status on %+v, after which intended to set gtid_purged to: %s. Error was: %+v", instance.Key, gtidSubtract, err)
+		err = fmt.Errorf("%s: error getting master status on %+v, after which intended to set gtid_purged to: %s. Error was: %+v", operation, instance.Key, gtidSubtract, err)
 		goto Cleanup
 	}
 	if !masterStatusFound {
-		err = fmt.Errorf("gtid-errant-reset-master: cannot get master status on %+v, after which intended to set gtid_purged to: %s.", instance.Key, gtidSubtract)
+		err = fmt.Errorf("%s: cannot get master status on %+v, after which intended to set gtid_purged to: %s.", operation, instance.Key, gtidSubtract)
 		goto Cleanup
 	}
 	if executedGtidSet != "" {
-		err = fmt.Errorf("gtid-errant-reset-master: Unexpected non-empty Executed_Gtid_Set found on %+v following RESET MASTER, after which intended to set gtid_purged to: %s. Executed_Gtid_Set found to be: %+v", instance.Key, gtidSubtract, executedGtidSet)
+		err = fmt.Errorf("%s: Unexpected non-empty Executed_Gtid_Set found on %+v following RESET MASTER, after which intended to set gtid_purged to: %s. Executed_Gtid_Set found to be: %+v", operation, instance.Key, gtidSubtract, executedGtidSet)
 		goto Cleanup
 	}
 
@@ -1335,21 +1642,23 @@ func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err er
 		time.Sleep(waitInterval)
 	}
 	if err != nil {
-		err = fmt.Errorf("gtid-errant-reset-master: error setting gtid_purged on %+v to: %s. Error was: %+v", instance.Key, gtidSubtract, err)
+		err = fmt.Errorf("%s: error setting gtid_purged on %+v to: %s. Error was: %+v", operation, instance.Key, gtidSubtract, err)
 		goto Cleanup
 	}
 
 Cleanup:
-	var startSlaveErr error
-	instance, startSlaveErr = StartSlave(instanceKey)
-	log.Errore(startSlaveErr)
+	if restartReplication {
+		var startSlaveErr error
+		instance, startSlaveErr = StartSlaveContext(ctx, instanceKey)
+		log.Errore(startSlaveErr)
+	}
 
 	if err != nil {
 		return instance, log.Errore(err)
 	}
 
 	// and we're done (pending deferred functions)
-	AuditOperation("gtid-errant-reset-master", instanceKey, fmt.Sprintf("%+v master reset", *instanceKey))
+	AuditOperation(operation, instanceKey, fmt.Sprintf("%+v master reset", *instanceKey))
 
 	return instance, err
 }
@@ -1381,23 +1690,78 @@ func ErrantGTIDInjectEmpty(instanceKey *InstanceKey) (instance *Instance, cluste
 		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty requested for %+v but the cluster's master %+v does not support oracle-gtid", *instanceKey, clusterMaster.Key)
 	}
 
+	countInjectedTransactions, err = injectEmptyErrantGTIDTransactions(instance, &clusterMaster.Key)
+	return instance, clusterMaster, countInjectedTransactions, err
+}
+
+// injectEmptyErrantGTIDTransactions injects an empty transaction on given master for each errant GTID entry of
+// given instance
+func injectEmptyErrantGTIDTransactions(instance *Instance, masterKey *InstanceKey) (countInjectedTransactions int64, err error) {
 	gtidSet, err := NewOracleGtidSet(instance.GtidErrant)
 	if err != nil {
-		return instance, clusterMaster, countInjectedTransactions, err
+		return countInjectedTransactions, err
 	}
 	explodedEntries := gtidSet.Explode()
-	log.Infof("gtid-errant-inject-empty: about to inject %+v empty transactions %+v on cluster master %+v", len(explodedEntries), gtidSet.String(), clusterMaster.Key)
+	log.Infof("gtid-errant-inject-empty: about to inject %+v empty transactions %+v on cluster master %+v", len(explodedEntries), gtidSet.String(), *masterKey)
 	for _, entry := range explodedEntries {
-		if err := injectEmptyGTIDTransaction(&clusterMaster.Key, entry); err != nil {
-			return instance, clusterMaster, countInjectedTransactions, err
+		if err := injectEmptyGTIDTransaction(masterKey, entry); err != nil {
+			return countInjectedTransactions, err
 		}
 		countInjectedTransactions++
 	}
 
 	// and we're done (pending deferred functions)
-	AuditOperation("gtid-errant-inject-empty", instanceKey, fmt.Sprintf("injected %+v empty transactions on %+v", countInjectedTransactions, clusterMaster.Key))
+	AuditOperation("gtid-errant-inject-empty", &instance.Key, fmt.Sprintf("injected %+v empty transactions on %+v", countInjectedTransactions, *masterKey))
 
-	return instance, clusterMaster, countInjectedTransactions, err
+	return countInjectedTransactions, err
+}
+
+// RemediateErrantGTIDBelow removes errant GTID from the replicas of given master, as found throughout its
+// topology, by injecting empty transactions on the master. It is the equivalent of gtid-errant-inject-empty on
+// each such replica, run against a master which may not yet be recognized as its cluster's writable master, e.g.
+// just after its promotion. Returns the replicas which were remediated.
+func RemediateErrantGTIDBelow(masterKey *InstanceKey) (remediated [](*Instance), countInjectedTransactions int64, err error) {
+	master, err := ReadTopologyInstance(masterKey)
+	if err != nil {
+		return remediated, countInjectedTransactions, err
+	}
+	if !master.SupportsOracleGTID {
+		return remediated, countInjectedTransactions, log.Errorf("RemediateErrantGTIDBelow: %+v does not support oracle-gtid", *masterKey)
+	}
+	visited := map[InstanceKey]bool{*masterKey: true}
+	pendingKeys := []InstanceKey{*masterKey}
+	for len(pendingKeys) > 0 {
+		parentKey := pendingKeys[0]
+		pendingKeys = pendingKeys[1:]
+		replicas, err := ReadReplicaInstances(&parentKey)
+		if err != nil {
+			return remediated, countInjectedTransactions, err
+		}
+		for _, replica := range replicas {
+			if visited[replica.Key] {
+				continue
+			}
+			visited[replica.Key] = true
+			pendingKeys = append(pendingKeys, replica.Key)
+
+			// Errant GTID is computed against the replica's master as of the replica's last read; re-read it.
+			instance, err := ReadTopologyInstance(&replica.Key)
+			if err != nil {
+				log.Errore(err)
+				continue
+			}
+			if instance.GtidErrant == "" || !instance.SupportsOracleGTID {
+				continue
+			}
+			count, err := injectEmptyErrantGTIDTransactions(instance, masterKey)
+			countInjectedTransactions += count
+			if err != nil {
+				return remediated, countInjectedTransactions, err
+			}
+			remediated = append(remediated, instance)
+		}
+	}
+	return remediated, countInjectedTransactions, nil
 }
 
 // FindLastPseudoGTIDEntry will search an instance's binary logs or relay logs for the last pseudo-GTID entry,
@@ -1502,12 +1866,82 @@ func CorrelateRelaylogCoordinates(instance *Instance, relaylogCoordinates *Binlo
 	return instanceCoordinates, correlatedCoordinates, nextCoordinates, found, err
 }
 
+// binlogFilteringServers returns the servers with binary log filters (binlog_do_db, binlog_ignore_db) up
+// the replication chain of given instance, optionally including the instance itself
+func binlogFilteringServers(instance *Instance, includeSelf bool) (*InstanceKeyMap, error) {
+	filteringServers := NewInstanceKeyMap()
+	if includeSelf && instance.HasBinlogFilters {
+		filteringServers.AddKey(instance.Key)
+	}
+	visited := NewInstanceKeyMap()
+	visited.AddKey(instance.Key)
+	masterKey := instance.MasterKey
+	for masterKey.IsValid() && !visited.HasKey(masterKey) {
+		visited.AddKey(masterKey)
+		master, found, err := ReadInstance(&masterKey)
+		if err != nil {
+			return filteringServers, err
+		}
+		if !found {
+			break
+		}
+		if master.HasBinlogFilters {
+			filteringServers.AddKey(master.Key)
+		}
+		masterKey = master.MasterKey
+	}
+	return filteringServers, nil
+}
+
+// CheckPseudoGTIDBinlogFilters returns an error when binary log filters make the logs of instance incomparable
+// with the binary logs of other, such that Pseudo-GTID matching cannot be trusted: it would either fail after
+// scanning the logs, or match the wrong position. The logs of instance are shaped by filters up its replication
+// chain (and its own, when it logs replicated events), those of other by filters on itself and up its chain.
+// Matching is safe when both are shaped by the very same filtering servers.
+func CheckPseudoGTIDBinlogFilters(instance, other *Instance) error {
+	if !instance.PseudoGTIDUnsafe && !instance.HasBinlogFilters && !other.PseudoGTIDUnsafe && !other.HasBinlogFilters {
+		return nil
+	}
+	instanceFilteringServers, err := binlogFilteringServers(instance, instance.LogBinEnabled && instance.LogSlaveUpdatesEnabled)
+	if err != nil {
+		return err
+	}
+	otherFilteringServers, err := binlogFilteringServers(other, true)
+	if err != nil {
+		return err
+	}
+	differingServers := NewInstanceKeyMap()
+	for _, key := range instanceFilteringServers.GetInstanceKeys() {
+		if !otherFilteringServers.HasKey(key) {
+			differingServers.AddKey(key)
+		}
+	}
+	for _, key := range otherFilteringServers.GetInstanceKeys() {
+		if !instanceFilteringServers.HasKey(key) {
+			differingServers.AddKey(key)
+		}
+	}
+	if len(*differingServers) > 0 {
+		return fmt.Errorf("Cannot match %+v below %+v via Pseudo-GTID: binary log filters (binlog_do_db/binlog_ignore_db) on %s make their logs incomparable", instance.Key, other.Key, differingServers.ToCommaDelimitedList())
+	}
+	return nil
+}
+
 // MatchBelow will attempt moving instance indicated by instanceKey below its the one indicated by otherKey.
 // The refactoring is based on matching binlog entries, not on "classic" positions comparisons.
 // The "other instance" could be the sibling of the moving instance any of its ancestors. It may actually be
 // a cousin of some sort (though unlikely). The only important thing is that the "other instance" is more
 // advanced in replication than given instance.
 func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance bool) (*Instance, *BinlogCoordinates, error) {
+	return MatchBelowContext(context.Background(), instanceKey, otherKey, requireInstanceMaintenance)
+}
+
+// MatchBelowContext is MatchBelow, bounded by given context. The potentially lengthy binlog correlation is not
+// interrupted, but the replica is not repointed once the context is done.
+func MatchBelowContext(ctx context.Context, instanceKey, otherKey *InstanceKey, requireInstanceMaintenance bool) (matchedInstance *Instance, matchedCoordinates *BinlogCoordinates, err error) {
+	ctx, span := startOperationSpan(ctx, "match-below", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, nil, err
@@ -1528,7 +1962,10 @@ func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance b
 		return instance, nil, merr
 	}
 
-	if canReplicate, err := instance.CanReplicateFrom(otherInstance); !canReplicate {
+	if canReplicate, err := instance.CanReplicateFromContext(ctx, otherInstance); !canReplicate {
+		return instance, nil, err
+	}
+	if err := CheckPseudoGTIDBinlogFilters(instance, otherInstance); err != nil {
 		return instance, nil, err
 	}
 	var nextBinlogCoordinatesToMatch *BinlogCoordinates
@@ -1543,7 +1980,7 @@ func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance b
 	log.Infof("Will match %+v below %+v", *instanceKey, *otherKey)
 
 	if requireInstanceMaintenance {
-		if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("match below %+v", *otherKey)); merr != nil {
+		if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, fmt.Sprintf("match below %+v", *otherKey)); merr != nil {
 			err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 			goto Cleanup
 		} else {
@@ -1561,8 +1998,11 @@ func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance b
 		}
 	}
 
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
 	log.Debugf("Stopping replica on %+v", *instanceKey)
-	instance, err = StopSlave(instanceKey)
+	instance, err = StopSlaveContext(ctx, instanceKey)
 	if err != nil {
 		goto Cleanup
 	}
@@ -1575,14 +2015,17 @@ func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance b
 	}
 	log.Debugf("%+v will match below %+v at %+v; validated events: %d", *instanceKey, *otherKey, *nextBinlogCoordinatesToMatch, countMatchedEvents)
 
+	if err = checkOperationContext(ctx, instanceKey); err != nil {
+		goto Cleanup
+	}
 	// Drum roll...
-	instance, err = ChangeMasterTo(instanceKey, otherKey, nextBinlogCoordinatesToMatch, false, GTIDHintDeny)
+	instance, err = ChangeMasterToContext(ctx, instanceKey, otherKey, nextBinlogCoordinatesToMatch, false, GTIDHintDeny)
 	if err != nil {
 		goto Cleanup
 	}
 
 Cleanup:
-	instance, _ = StartSlave(instanceKey)
+	instance, _ = StartSlaveContext(ctx, instanceKey)
 	if err != nil {
 		return instance, nextBinlogCoordinatesToMatch, log.Errore(err)
 	}
@@ -1608,6 +2051,14 @@ func RematchReplica(instanceKey *InstanceKey, requireInstanceMaintenance bool) (
 // MakeMaster will take an instance, make all its siblings its replicas (via pseudo-GTID) and make it master
 // (stop its replicaiton, make writeable).
 func MakeMaster(instanceKey *InstanceKey) (*Instance, error) {
+	return MakeMasterContext(context.Background(), instanceKey)
+}
+
+// MakeMasterContext is MakeMaster, with maintenance attributed as per given context
+func MakeMasterContext(ctx context.Context, instanceKey *InstanceKey) (master *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "make-master", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -1637,7 +2088,7 @@ func MakeMaster(instanceKey *InstanceKey) (*Instance, error) {
 		}
 	}
 
-	if maintenanceToken, merr := BeginMaintenance(instanceKey, GetMaintenanceOwner(), fmt.Sprintf("siblings match below this: %+v", *instanceKey)); merr != nil {
+	if maintenanceToken, merr := beginOperationMaintenance(ctx, instanceKey, fmt.Sprintf("siblings match below this: %+v", *instanceKey)); merr != nil {
 		err = fmt.Errorf("Cannot begin maintenance on %+v", *instanceKey)
 		goto Cleanup
 	} else {
@@ -1711,6 +2162,14 @@ func TakeMasterHook(successor *Instance, demoted *Instance) {
 // Note that the master must itself be a replica; however the grandparent does not necessarily have to be reachable
 // and can in fact be dead.
 func TakeMaster(instanceKey *InstanceKey, allowTakingCoMaster bool) (*Instance, error) {
+	return TakeMasterContext(context.Background(), instanceKey, allowTakingCoMaster)
+}
+
+// TakeMasterContext is TakeMaster, where master changes are attributed to the master change origin of given context
+func TakeMasterContext(ctx context.Context, instanceKey *InstanceKey, allowTakingCoMaster bool) (tookMaster *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "take-master", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, err
@@ -1728,11 +2187,11 @@ func TakeMaster(instanceKey *InstanceKey, allowTakingCoMaster bool) (*Instance,
 		return instance, err
 	}
 	// We begin
-	masterInstance, err = StopSlave(&masterInstance.Key)
+	masterInstance, err = StopSlaveContext(ctx, &masterInstance.Key)
 	if err != nil {
 		goto Cleanup
 	}
-	instance, err = StopSlave(&instance.Key)
+	instance, err = StopSlaveContext(ctx, &instance.Key)
 	if err != nil {
 		goto Cleanup
 	}
@@ -1746,20 +2205,20 @@ func TakeMaster(instanceKey *InstanceKey, allowTakingCoMaster bool) (*Instance,
 	// We skip name unresolve. It is OK if the master's master is dead, unreachable, does not resolve properly.
 	// We just copy+paste info from the master.
 	// In particular, this is commonly calledin DeadMaster recovery
-	instance, err = ChangeMasterTo(&instance.Key, &masterInstance.MasterKey, &masterInstance.ExecBinlogCoordinates, true, GTIDHintNeutral)
+	instance, err = ChangeMasterToContext(ctx, &instance.Key, &masterInstance.MasterKey, &masterInstance.ExecBinlogCoordinates, true, GTIDHintNeutral)
 	if err != nil {
 		goto Cleanup
 	}
 	// instance is now sibling of master
-	masterInstance, err = ChangeMasterTo(&masterInstance.Key, &instance.Key, &instance.SelfBinlogCoordinates, false, GTIDHintNeutral)
+	masterInstance, err = ChangeMasterToContext(ctx, &masterInstance.Key, &instance.Key, &instance.SelfBinlogCoordinates, false, GTIDHintNeutral)
 	if err != nil {
 		goto Cleanup
 	}
 	// swap is done!
 
 Cleanup:
-	instance, _ = StartSlave(&instance.Key)
-	masterInstance, _ = StartSlave(&masterInstance.Key)
+	instance, _ = StartSlaveContext(ctx, &instance.Key)
+	masterInstance, _ = StartSlaveContext(ctx, &masterInstance.Key)
 	if err != nil {
 		return instance, err
 	}
@@ -1803,7 +2262,7 @@ func MakeLocalMaster(instanceKey *InstanceKey) (*Instance, error) {
 		}
 	}
 
-	instance, err = StopSlaveNicely(instanceKey, 0)
+	instance, err = stopSlaveByPolicy(instanceKey, StopReplicationOperationMakeMaster)
 	if err != nil {
 		goto Cleanup
 	}
@@ -1830,7 +2289,22 @@ Cleanup:
 
 // sortInstances shuffles given list of instances according to some logic
 func sortInstancesDataCenterHint(instances [](*Instance), dataCenterHint string) {
-	sort.Sort(sort.Reverse(NewInstancesSorterByExec(instances, dataCenterHint)))
+	sortInstancesDataCenterPreference(instances, &PromotionDataCenterPreference{FailedMasterDataCenter: dataCenterHint})
+}
+
+// sortInstancesDataCenterPreference sorts given instances as promotion candidates, most up-to-date first, then
+// by given data center preference among equally up-to-date instances
+func sortInstancesDataCenterPreference(instances [](*Instance), dataCenterPreference *PromotionDataCenterPreference) {
+	sortInstancesPromotionPreference(instances, dataCenterPreference, nil)
+}
+
+// sortInstancesPromotionPreference sorts given instances as promotion candidates, most up-to-date first. Among
+// equally up-to-date instances, those which acknowledged semi-sync transactions (if given) are preferred, then
+// by given data center preference.
+func sortInstancesPromotionPreference(instances [](*Instance), dataCenterPreference *PromotionDataCenterPreference, semiSyncAcknowledgers *InstanceKeyMap) {
+	sorter := NewInstancesSorterByExecDataCenterPreference(instances, dataCenterPreference)
+	sorter.semiSyncAcknowledgers = semiSyncAcknowledgers
+	sort.Sort(sort.Reverse(sorter))
 }
 
 // sortInstances shuffles given list of instances according to some logic
@@ -1849,21 +2323,21 @@ func getReplicasForSorting(masterKey *InstanceKey, includeBinlogServerSubReplica
 }
 
 func sortedReplicas(replicas [](*Instance), stopReplicationMethod StopReplicationMethod) [](*Instance) {
-	return sortedReplicasDataCenterHint(replicas, stopReplicationMethod, "")
+	return sortedReplicasPromotionPreference(replicas, stopReplicationMethod, time.Duration(config.Config.InstanceBulkOperationsWaitTimeoutSeconds)*time.Second, &PromotionDataCenterPreference{}, nil)
 }
 
 // sortedReplicas returns the list of replicas of some master, sorted by exec coordinates
 // (most up-to-date replica first).
 // This function assumes given `replicas` argument is indeed a list of instances all replicating
 // from the same master (the result of `getReplicasForSorting()` is appropriate)
-func sortedReplicasDataCenterHint(replicas [](*Instance), stopReplicationMethod StopReplicationMethod, dataCenterHint string) [](*Instance) {
+func sortedReplicasPromotionPreference(replicas [](*Instance), stopReplicationMethod StopReplicationMethod, stopReplicationTimeout time.Duration, dataCenterPreference *PromotionDataCenterPreference, semiSyncAcknowledgers *InstanceKeyMap) [](*Instance) {
 	if len(replicas) == 0 {
 		return replicas
 	}
-	replicas = StopSlaves(replicas, stopReplicationMethod, time.Duration(config.Config.InstanceBulkOperationsWaitTimeoutSeconds)*time.Second)
+	replicas = StopSlaves(replicas, stopReplicationMethod, stopReplicationTimeout)
 	replicas = RemoveNilInstances(replicas)
 
-	sortInstancesDataCenterHint(replicas, dataCenterHint)
+	sortInstancesPromotionPreference(replicas, dataCenterPreference, semiSyncAcknowledgers)
 	for _, replica := range replicas {
 		log.Debugf("- sorted replica: %+v %+v", replica.Key, replica.ExecBinlogCoordinates)
 	}
@@ -1887,6 +2361,14 @@ func GetSortedReplicas(masterKey *InstanceKey, stopReplicationMethod StopReplica
 // MultiMatchBelow will efficiently match multiple replicas below a given instance.
 // It is assumed that all given replicas are siblings
 func MultiMatchBelow(replicas [](*Instance), belowKey *InstanceKey, postponedFunctionsContainer *PostponedFunctionsContainer) (matchedReplicas [](*Instance), belowInstance *Instance, err error, errs []error) {
+	return MultiMatchBelowContext(context.Background(), replicas, belowKey, postponedFunctionsContainer)
+}
+
+// MultiMatchBelowContext is MultiMatchBelow, bounded by given context. Postponed functions are not bound to the context.
+func MultiMatchBelowContext(ctx context.Context, replicas [](*Instance), belowKey *InstanceKey, postponedFunctionsContainer *PostponedFunctionsContainer) (matchedReplicas [](*Instance), belowInstance *Instance, err error, errs []error) {
+	ctx, span := startOperationSpan(ctx, "multi-match-below", belowKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	belowInstance, found, err := ReadInstance(belowKey)
 	if err != nil || !found {
 		return matchedReplicas, belowInstance, err, errs
@@ -1900,39 +2382,40 @@ func MultiMatchBelow(replicas [](*Instance), belowKey *InstanceKey, postponedFun
 
 	log.Infof("Will match %+v replicas below %+v via Pseudo-GTID, independently", len(replicas), belowKey)
 
-	barrier := make(chan *InstanceKey)
-	replicaMutex := &sync.Mutex{}
+	pool := newReplicaOperationsPool(ctx, belowInstance.ClusterName)
+	postponedResults := &postponedReplicaResults{}
 
 	for _, replica := range replicas {
 		replica := replica
 
 		// Parallelize repoints
-		go func() {
-			defer func() { barrier <- &replica.Key }()
-			matchFunc := func() error {
-				replica, _, replicaErr := MatchBelow(&replica.Key, belowKey, true)
-
-				replicaMutex.Lock()
-				defer replicaMutex.Unlock()
-
-				if replicaErr == nil {
-					matchedReplicas = append(matchedReplicas, replica)
-				} else {
-					errs = append(errs, replicaErr)
-				}
-				return replicaErr
-			}
+		pool.Go(replica.Key.StringCode(), func() (replicaErr error) {
 			if shouldPostponeRelocatingReplica(replica, postponedFunctionsContainer) {
-				postponedFunctionsContainer.AddPostponedFunction(matchFunc, fmt.Sprintf("multi-match-below-independent %+v", replica.Key))
+				// Postponed functions outlive the invoker's context, and the pool: they report to postponedResults
+				postponedFunc := func(ctx context.Context) error {
+					matchedReplica, _, err := MatchBelowContext(ctx, &replica.Key, belowKey, true)
+					if matchedReplica == nil {
+						matchedReplica = replica
+					}
+					matched, unmatched := postponedResults.add(matchedReplica, err)
+					AuditOperation("multi-match-below-independent", belowKey, fmt.Sprintf("postponed match of %+v below %+v via Pseudo-GTID: %+v; postponed matches so far: %d matched, %d unmatched", replica.Key, *belowKey, err, matched, unmatched))
+					return err
+				}
+				postponedFunctionsContainer.AddPrioritizedPostponedFunction(detachedOperationContext(ctx), postponedFunc, fmt.Sprintf("multi-match-below-independent %+v", replica.Key), PostponedRelocationPriority(replica), 0)
 				// We bail out and trust our invoker to later call upon this postponed function
-			} else {
-				ExecuteOnTopology(func() { matchFunc() })
+				return nil
 			}
-		}()
-	}
-	for range replicas {
-		<-barrier
+			ExecuteOnTopology(func() {
+				var matchedReplica *Instance
+				matchedReplica, _, replicaErr = MatchBelowContext(ctx, &replica.Key, belowKey, true)
+				if replicaErr == nil {
+					pool.Synchronized(func() { matchedReplicas = append(matchedReplicas, matchedReplica) })
+				}
+			})
+			return replicaErr
+		})
 	}
+	errs = pool.Wait()
 	if len(errs) == len(replicas) {
 		// All returned with error
 		return matchedReplicas, belowInstance, fmt.Errorf("MultiMatchBelowIndependently: Error on all %+v operations", len(errs)), errs
@@ -2083,35 +2566,92 @@ func isValidAsCandidateMasterInBinlogServerTopology(replica *Instance) bool {
 }
 
 func IsBannedFromBeingCandidateReplica(replica *Instance) bool {
-	if replica.PromotionRule == MustNotPromoteRule {
-		log.Debugf("instance %+v is banned because of promotion rule", replica.Key)
+	if reason := candidateReplicaBanReason(replica); reason != "" {
+		log.Debugf("instance %+v is banned because %s", replica.Key, reason)
 		return true
 	}
+	return false
+}
+
+// candidateReplicaBanReason explains why given replica is banned from being a candidate replica; empty when it is not
+func candidateReplicaBanReason(replica *Instance) string {
+	if replica.PromotionRule == MustNotPromoteRule {
+		return "of promotion rule"
+	}
 	for _, filter := range config.Config.PromotionIgnoreHostnameFilters {
 		if matched, _ := regexp.MatchString(filter, replica.Key.Hostname); matched {
-			return true
+			return fmt.Sprintf("it matches PromotionIgnoreHostnameFilters entry %s", filter)
 		}
 	}
-	return false
+	if IsActiveDRStandbyMaster(&replica.Key) {
+		return "it is a DR standby master"
+	}
+	if replica.GtidErrant != "" && isErrantGTIDPromotionPrevented(replica.ClusterName) {
+		return fmt.Sprintf("it has errant GTID: %s", replica.GtidErrant)
+	}
+	if violation := promotionTagConstraintViolation(replica); violation != "" {
+		return violation
+	}
+	return ""
 }
 
-// getPriorityMajorVersionForCandidate returns the primary (most common) major version found
-// among given instances. This will be used for choosing best candidate for promotion.
+// isErrantGTIDPromotionPrevented returns true when replicas with errant GTID may not be promoted in given cluster,
+// per PreventErrantGTIDPromotion, unless exempt by ErrantGTIDPromotionClusterFilters
+func isErrantGTIDPromotionPrevented(clusterName string) bool {
+	if !config.Config.PreventErrantGTIDPromotion {
+		return false
+	}
+	if clusterName == "" || len(config.Config.ErrantGTIDPromotionClusterFilters) == 0 {
+		return true
+	}
+	clusterInfo := &ClusterInfo{ClusterName: clusterName}
+	if clusterInfo.filtersMatchCluster(config.Config.ErrantGTIDPromotionClusterFilters) {
+		return false
+	}
+	// Filters may also match by alias
+	if clusterInfo.ClusterAlias, _ = ReadAliasByClusterName(clusterName); clusterInfo.ClusterAlias == "" {
+		return true
+	}
+	return !clusterInfo.filtersMatchCluster(config.Config.ErrantGTIDPromotionClusterFilters)
+}
+
+// getPriorityMajorVersionForCandidate returns the primary (most common) release series found
+// among given instances; among equally common ones, the one from which most instances can replicate,
+// as per versionCompatibilityMatrix. This will be used for choosing best candidate for promotion.
 func getPriorityMajorVersionForCandidate(replicas [](*Instance)) (priorityMajorVersion string, err error) {
 	if len(replicas) == 0 {
 		return "", log.Errorf("empty replicas list in getPriorityMajorVersionForCandidate")
 	}
 	majorVersionsCount := make(map[string]int)
 	for _, replica := range replicas {
-		majorVersionsCount[replica.MajorVersionString()] = majorVersionsCount[replica.MajorVersionString()] + 1
+		series := ParseReleaseSeries(replica.Version).String()
+		majorVersionsCount[series] = majorVersionsCount[series] + 1
 	}
 	if len(majorVersionsCount) == 1 {
 		// all same version, simple case
-		return replicas[0].MajorVersionString(), nil
+		return ParseReleaseSeries(replicas[0].Version).String(), nil
 	}
 	sorted := NewMajorVersionsSortedByCount(majorVersionsCount)
 	sort.Sort(sort.Reverse(sorted))
-	return sorted.First(), nil
+
+	priorityMajorVersion = sorted.First()
+	mostCompatibleReplicas := -1
+	for _, series := range sorted.versions {
+		if majorVersionsCount[series] < majorVersionsCount[sorted.First()] {
+			break
+		}
+		compatibleReplicas := 0
+		for _, replica := range replicas {
+			if IsVersionCompatible(replica.Version, series) {
+				compatibleReplicas++
+			}
+		}
+		if compatibleReplicas > mostCompatibleReplicas {
+			priorityMajorVersion = series
+			mostCompatibleReplicas = compatibleReplicas
+		}
+	}
+	return priorityMajorVersion, nil
 }
 
 // getPriorityBinlogFormatForCandidate returns the primary (most common) binlog format found
@@ -2135,20 +2675,64 @@ func getPriorityBinlogFormatForCandidate(replicas [](*Instance)) (priorityBinlog
 
 // chooseCandidateReplica
 func chooseCandidateReplica(replicas [](*Instance)) (candidateReplica *Instance, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas [](*Instance), err error) {
+	return chooseCandidateReplicaPromotionPreference(replicas, &PromotionDataCenterPreference{}, nil)
+}
+
+// chooseCandidateReplicaPromotionPreference is chooseCandidateReplica, where replicas were sorted by given data center
+// preference and semi-sync acknowledgers (see sortInstancesPromotionPreference)
+func chooseCandidateReplicaPromotionPreference(replicas [](*Instance), dataCenterPreference *PromotionDataCenterPreference, semiSyncAcknowledgers *InstanceKeyMap) (candidateReplica *Instance, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas [](*Instance), err error) {
 	if len(replicas) == 0 {
 		return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, fmt.Errorf("No replicas found given in chooseCandidateReplica")
 	}
 	priorityMajorVersion, _ := getPriorityMajorVersionForCandidate(replicas)
 	priorityBinlogFormat, _ := getPriorityBinlogFormatForCandidate(replicas)
 
+	lagRejections := make(map[InstanceKey]string)
 	for _, replica := range replicas {
-		replica := replica
-		if isGenerallyValidAsCandidateReplica(replica) &&
-			!IsBannedFromBeingCandidateReplica(replica) &&
-			!IsSmallerMajorVersion(priorityMajorVersion, replica.MajorVersionString()) &&
-			!IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
-			// this is the one
-			candidateReplica = replica
+		if reason := promotionLagRejection(replica); reason != "" {
+			lagRejections[replica.Key] = reason
+		}
+	}
+	// Replicas vetoed by the candidate health check are not considered at all
+	healthVetoes := make(map[InstanceKey]string)
+	for key, health := range readCandidateHealths(replicas) {
+		if health.Vetoed {
+			healthVetoes[key] = health.Reason
+		}
+	}
+	// Replicas in promotion cool-down, then intentionally delayed replicas, and then replicas lagging beyond
+	// PromotionMaxLagSeconds, are only considered when no other replica is valid as candidate
+	candidatePasses := []struct{ allowCooldownReplicas, allowDelayedReplicas, allowLaggingReplicas bool }{
+		{false, false, false}, {true, false, false}, {true, true, false}, {true, true, true},
+	}
+	for _, pass := range candidatePasses {
+		validCandidates := [](*Instance){}
+		for _, replica := range replicas {
+			replica := replica
+			if replica.IsDelayedReplica() && !pass.allowDelayedReplicas {
+				continue
+			}
+			if _, lagging := lagRejections[replica.Key]; lagging && !pass.allowLaggingReplicas {
+				continue
+			}
+			if !pass.allowCooldownReplicas && IsInPromotionCooldown(replica) {
+				continue
+			}
+			if _, vetoed := healthVetoes[replica.Key]; vetoed {
+				continue
+			}
+			if isGenerallyValidAsCandidateReplica(replica) &&
+				!IsBannedFromBeingCandidateReplica(replica) &&
+				IsVersionCompatible(priorityMajorVersion, replica.Version) &&
+				!IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
+				validCandidates = append(validCandidates, replica)
+			}
+		}
+		if len(validCandidates) > 0 {
+			// this is the one, or else one which CandidateScoring prefers and is just as up to date
+			sorter := NewInstancesSorterByExecDataCenterPreference(validCandidates, dataCenterPreference)
+			sorter.semiSyncAcknowledgers = semiSyncAcknowledgers
+			candidateReplica = chooseScoredCandidateReplica(validCandidates, sorter)
 			break
 		}
 	}
@@ -2168,6 +2752,14 @@ func chooseCandidateReplica(replicas [](*Instance)) (candidateReplica *Instance,
 		}
 		return candidateReplica, replicas, equalReplicas, laterReplicas, cannotReplicateReplicas, fmt.Errorf("chooseCandidateReplica: no candidate replica found")
 	}
+	for key, reason := range lagRejections {
+		if !key.Equals(&candidateReplica.Key) {
+			log.Infof("chooseCandidateReplica: %+v not considered as candidate: %s", key, reason)
+		}
+	}
+	for key, reason := range healthVetoes {
+		log.Infof("chooseCandidateReplica: %+v not considered as candidate: %s", key, reason)
+	}
 	replicas = RemoveInstance(replicas, &candidateReplica.Key)
 	for _, replica := range replicas {
 		replica := replica
@@ -2194,34 +2786,101 @@ func GetCandidateReplica(masterKey *InstanceKey, forRematchPurposes bool) (*Inst
 	laterReplicas := [](*Instance){}
 	cannotReplicateReplicas := [](*Instance){}
 
-	dataCenterHint := ""
-	if master, _, _ := ReadInstance(masterKey); master != nil {
-		dataCenterHint = master.DataCenter
+	dataCenterPreference := &PromotionDataCenterPreference{}
+	master, _, _ := ReadInstance(masterKey)
+	if master != nil {
+		dataCenterPreference = NewPromotionDataCenterPreference(master.DataCenter, master.ClusterName, master.SuggestedClusterAlias)
 	}
 	replicas, err := getReplicasForSorting(masterKey, false)
 	if err != nil {
 		return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
 	}
+	// The acknowledging set is taken from the replicas' state as last polled, before any stopping of replication
+	semiSyncAcknowledgers := semiSyncAcknowledgingReplicas(master, replicas)
 	stopReplicationMethod := NoStopReplication
+	var stopReplicationTimeout time.Duration
 	if forRematchPurposes {
-		stopReplicationMethod = StopReplicationNicely
+		stopReplicationMethod, stopReplicationTimeout = stopReplicationPolicy(StopReplicationOperationRegroup)
 	}
-	replicas = sortedReplicasDataCenterHint(replicas, stopReplicationMethod, dataCenterHint)
+	replicas = sortedReplicasPromotionPreference(replicas, stopReplicationMethod, stopReplicationTimeout, dataCenterPreference, semiSyncAcknowledgers)
 	if err != nil {
 		return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
 	}
 	if len(replicas) == 0 {
 		return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, fmt.Errorf("No replicas found for %+v", *masterKey)
 	}
-	candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err = chooseCandidateReplica(replicas)
+	candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err = chooseCandidateReplicaPromotionPreference(replicas, dataCenterPreference, semiSyncAcknowledgers)
 	if err != nil {
 		return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
 	}
+	if forRematchPurposes && candidateReplica.IsDelayedReplica() {
+		// A delayed replica must be promoted. It must not remain delayed: remove its delay, let it apply its relay logs,
+		// and choose again based on its updated coordinates.
+		log.Infof("GetCandidateReplica: candidate %+v is delayed by %d seconds; removing delay", candidateReplica.Key, candidateReplica.SQLDelay)
+		if _, err := removeMasterDelayForPromotion(&candidateReplica.Key, time.Duration(config.Config.InstanceBulkOperationsWaitTimeoutSeconds)*time.Second); err != nil {
+			return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
+		}
+		if replicas, err = getReplicasForSorting(masterKey, false); err != nil {
+			return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
+		}
+		replicas = sortedReplicasPromotionPreference(replicas, NoStopReplication, 0, dataCenterPreference, semiSyncAcknowledgers)
+		candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err = chooseCandidateReplicaPromotionPreference(replicas, dataCenterPreference, semiSyncAcknowledgers)
+		if err != nil {
+			return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
+		}
+	}
+	if forRematchPurposes && isBehindMostUpToDateReplica(candidateReplica, replicas) && master != nil {
+		// The more up-to-date replica may well become valid shortly, e.g. once polled successfully again. Promoting
+		// it is preferable to losing its transactions.
+		if gracePeriod := candidateGracePeriod(master.ClusterName, master.SuggestedClusterAlias); gracePeriod > 0 {
+			behindKey := candidateReplica.Key
+			log.Infof("GetCandidateReplica: candidate %+v is behind most-up-to-date replica %+v; retrying for up to %+v", candidateReplica.Key, replicas[0].Key, gracePeriod)
+			for deadline := time.Now().Add(gracePeriod); time.Now().Before(deadline); {
+				time.Sleep(candidateGracePeriodRetryInterval)
+				if replicas, err = getReplicasForSorting(masterKey, false); err != nil {
+					return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
+				}
+				replicas = sortedReplicasPromotionPreference(replicas, NoStopReplication, 0, dataCenterPreference, semiSyncAcknowledgers)
+				candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err = chooseCandidateReplicaPromotionPreference(replicas, dataCenterPreference, semiSyncAcknowledgers)
+				if err != nil {
+					return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
+				}
+				if !isBehindMostUpToDateReplica(candidateReplica, replicas) {
+					break
+				}
+			}
+			if isBehindMostUpToDateReplica(candidateReplica, replicas) {
+				AuditOperation("candidate-grace-period", masterKey, fmt.Sprintf("%+v remains behind most-up-to-date replica %+v after %+v; proceeding", candidateReplica.Key, replicas[0].Key, gracePeriod))
+			} else {
+				AuditOperation("candidate-grace-period", masterKey, fmt.Sprintf("chose %+v, which is most up-to-date, over %+v within grace period", candidateReplica.Key, behindKey))
+			}
+		}
+	}
 	if candidateReplica != nil {
 		mostUpToDateReplica := replicas[0]
 		if candidateReplica.ExecBinlogCoordinates.SmallerThan(&mostUpToDateReplica.ExecBinlogCoordinates) {
 			log.Warningf("GetCandidateReplica: chosen replica: %+v is behind most-up-to-date replica: %+v", candidateReplica.Key, mostUpToDateReplica.Key)
 		}
+		if dataCenterPreference.HasPolicy() {
+			AuditOperation("promotion-data-center-policy", masterKey, fmt.Sprintf("chose %+v as candidate replica; %s", candidateReplica.Key, dataCenterPreference.Reason(candidateReplica.DataCenter)))
+		}
+		if semiSyncAcknowledgers != nil {
+			if semiSyncAcknowledgers.HasKey(candidateReplica.Key) {
+				AuditOperation("promotion-semi-sync", masterKey, fmt.Sprintf("chose %+v as candidate replica; it acknowledged semi-sync transactions", candidateReplica.Key))
+			} else {
+				AuditOperation("promotion-semi-sync", masterKey, fmt.Sprintf("chose %+v as candidate replica, although it did not acknowledge semi-sync transactions; acknowledging replicas: %s", candidateReplica.Key, semiSyncAcknowledgers.ToCommaDelimitedList()))
+			}
+		}
+		for _, replica := range replicas {
+			if reason := promotionLagRejection(replica); reason != "" {
+				if replica.Key.Equals(&candidateReplica.Key) {
+					reason = fmt.Sprintf("chosen as candidate replica for lack of another valid candidate, although its %s", reason)
+				} else {
+					reason = fmt.Sprintf("rejected as candidate replica: %s", reason)
+				}
+				AuditOperation("promotion-lag-gate", &replica.Key, reason)
+			}
+		}
 	}
 	log.Debugf("GetCandidateReplica: candidate: %+v, ahead: %d, equal: %d, late: %d, break: %d", candidateReplica.Key, len(aheadReplicas), len(equalReplicas), len(laterReplicas), len(cannotReplicateReplicas))
 	return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, nil
@@ -2270,6 +2929,28 @@ func RegroupReplicasPseudoGTID(
 	candidateReplica *Instance,
 	err error,
 ) {
+	return RegroupReplicasPseudoGTIDContext(context.Background(), masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, postponeAllMatchOperations)
+}
+
+// RegroupReplicasPseudoGTIDContext is RegroupReplicasPseudoGTID, bounded by given context. Postponed functions are not bound to the context.
+func RegroupReplicasPseudoGTIDContext(
+	ctx context.Context,
+	masterKey *InstanceKey,
+	returnReplicaEvenOnFailureToRegroup bool,
+	onCandidateReplicaChosen func(*Instance),
+	postponedFunctionsContainer *PostponedFunctionsContainer,
+	postponeAllMatchOperations func(*Instance) bool,
+) (
+	aheadReplicas [](*Instance),
+	equalReplicas [](*Instance),
+	laterReplicas [](*Instance),
+	cannotReplicateReplicas [](*Instance),
+	candidateReplica *Instance,
+	err error,
+) {
+	ctx, span := startOperationSpan(ctx, "regroup-replicas-pgtid", masterKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err = GetCandidateReplica(masterKey, true)
 	if err != nil {
 		if !returnReplicaEvenOnFailureToRegroup {
@@ -2286,58 +2967,58 @@ func RegroupReplicasPseudoGTID(
 		onCandidateReplicaChosen(candidateReplica)
 	}
 
-	allMatchingFunc := func() error {
+	allMatchingFunc := func(ctx context.Context) error {
 		log.Debugf("RegroupReplicas: working on %d equals replicas", len(equalReplicas))
-		barrier := make(chan *InstanceKey)
+		clusterName := candidateReplica.ClusterName
+		pool := newReplicaOperationsPool(ctx, clusterName)
 		for _, replica := range equalReplicas {
 			replica := replica
 			// This replica has the exact same executing coordinates as the candidate replica. This replica
 			// is *extremely* easy to attach below the candidate replica!
-			go func() {
-				defer func() { barrier <- &candidateReplica.Key }()
+			pool.Go(replica.Key.StringCode(), func() (err error) {
 				ExecuteOnTopology(func() {
-					ChangeMasterTo(&replica.Key, &candidateReplica.Key, &candidateReplica.SelfBinlogCoordinates, false, GTIDHintDeny)
+					_, err = ChangeMasterToContext(ctx, &replica.Key, &candidateReplica.Key, &candidateReplica.SelfBinlogCoordinates, false, GTIDHintDeny)
 				})
-			}()
-		}
-		for range equalReplicas {
-			<-barrier
+				return err
+			})
 		}
+		pool.Wait()
 
 		log.Debugf("RegroupReplicas: multi matching %d later replicas", len(laterReplicas))
 		// As for the laterReplicas, we'll have to apply pseudo GTID
-		laterReplicas, candidateReplica, err, _ = MultiMatchBelow(laterReplicas, &candidateReplica.Key, postponedFunctionsContainer)
+		laterReplicas, candidateReplica, err, _ = MultiMatchBelowContext(ctx, laterReplicas, &candidateReplica.Key, postponedFunctionsContainer)
 
 		operatedReplicas := append(equalReplicas, candidateReplica)
 		operatedReplicas = append(operatedReplicas, laterReplicas...)
 		log.Debugf("RegroupReplicas: starting %d replicas", len(operatedReplicas))
-		barrier = make(chan *InstanceKey)
+		// Replication is started regardless of the operation's context
+		pool = newReplicaOperationsPool(context.Background(), clusterName)
 		for _, replica := range operatedReplicas {
 			replica := replica
-			go func() {
-				defer func() { barrier <- &candidateReplica.Key }()
+			pool.Go(replica.Key.StringCode(), func() (err error) {
 				ExecuteOnTopology(func() {
-					StartSlave(&replica.Key)
+					_, err = StartSlave(&replica.Key)
 				})
-			}()
-		}
-		for range operatedReplicas {
-			<-barrier
+				return err
+			})
 		}
+		pool.Wait()
 		AuditOperation("regroup-replicas", masterKey, fmt.Sprintf("regrouped %+v replicas below %+v", len(operatedReplicas), *masterKey))
 		return err
 	}
 	if postponedFunctionsContainer != nil && postponeAllMatchOperations != nil && postponeAllMatchOperations(candidateReplica) {
-		postponedFunctionsContainer.AddPostponedFunction(allMatchingFunc, fmt.Sprintf("regroup-replicas-pseudo-gtid %+v", candidateReplica.Key))
+		postponedFunctionsContainer.AddPrioritizedPostponedFunction(detachedOperationContext(ctx), allMatchingFunc, fmt.Sprintf("regroup-replicas-pseudo-gtid %+v", candidateReplica.Key), PostponedFunctionPriorityNormal, 0)
 	} else {
-		err = allMatchingFunc()
+		err = allMatchingFunc(ctx)
 	}
 	log.Debugf("RegroupReplicas: done")
 	// aheadReplicas are lost (they were ahead in replication as compared to promoted replica)
 	return aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, candidateReplica, err
 }
 
-func getMostUpToDateActiveBinlogServer(masterKey *InstanceKey) (mostAdvancedBinlogServer *Instance, binlogServerReplicas [](*Instance), err error) {
+// GetMostUpToDateActiveBinlogServer returns the most advanced, valid binlog server replicating from given master,
+// along with all binlog servers replicating from given master
+func GetMostUpToDateActiveBinlogServer(masterKey *InstanceKey) (mostAdvancedBinlogServer *Instance, binlogServerReplicas [](*Instance), err error) {
 	if binlogServerReplicas, err = ReadBinlogServerReplicaInstances(masterKey); err == nil && len(binlogServerReplicas) > 0 {
 		// Pick the most advanced binlog sever that is good to go
 		for _, binlogServer := range binlogServerReplicas {
@@ -2371,11 +3052,33 @@ func RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(
 	candidateReplica *Instance,
 	err error,
 ) {
+	return RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServersContext(context.Background(), masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, postponeAllMatchOperations)
+}
+
+// RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServersContext is RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers, bounded by given context. Postponed functions are not bound to the context.
+func RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServersContext(
+	ctx context.Context,
+	masterKey *InstanceKey,
+	returnReplicaEvenOnFailureToRegroup bool,
+	onCandidateReplicaChosen func(*Instance),
+	postponedFunctionsContainer *PostponedFunctionsContainer,
+	postponeAllMatchOperations func(*Instance) bool,
+) (
+	aheadReplicas [](*Instance),
+	equalReplicas [](*Instance),
+	laterReplicas [](*Instance),
+	cannotReplicateReplicas [](*Instance),
+	candidateReplica *Instance,
+	err error,
+) {
+	ctx, span := startOperationSpan(ctx, "regroup-replicas-pgtid-including-binlog-servers", masterKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	// First, handle binlog server issues:
 	func() error {
 		log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: starting on replicas of %+v", *masterKey)
 		// Find the most up to date binlog server:
-		mostUpToDateBinlogServer, binlogServerReplicas, err := getMostUpToDateActiveBinlogServer(masterKey)
+		mostUpToDateBinlogServer, binlogServerReplicas, err := GetMostUpToDateActiveBinlogServer(masterKey)
 		if err != nil {
 			return log.Errore(err)
 		}
@@ -2401,18 +3104,18 @@ func RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(
 		if candidateReplica.ExecBinlogCoordinates.SmallerThan(&mostUpToDateBinlogServer.ExecBinlogCoordinates) {
 			log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: candidate replica %+v coordinates smaller than binlog server %+v", candidateReplica.Key, mostUpToDateBinlogServer.Key)
 			// Need to align under binlog server...
-			candidateReplica, err = Repoint(&candidateReplica.Key, &mostUpToDateBinlogServer.Key, GTIDHintDeny)
+			candidateReplica, err = RepointContext(ctx, &candidateReplica.Key, &mostUpToDateBinlogServer.Key, GTIDHintDeny)
 			if err != nil {
 				return log.Errore(err)
 			}
 			log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: repointed candidate replica %+v under binlog server %+v", candidateReplica.Key, mostUpToDateBinlogServer.Key)
-			candidateReplica, err = StartSlaveUntilMasterCoordinates(&candidateReplica.Key, &mostUpToDateBinlogServer.ExecBinlogCoordinates)
+			candidateReplica, err = StartSlaveUntilMasterCoordinatesContext(ctx, &candidateReplica.Key, &mostUpToDateBinlogServer.ExecBinlogCoordinates)
 			if err != nil {
 				return log.Errore(err)
 			}
 			log.Debugf("RegroupReplicasIncludingSubReplicasOfBinlogServers: aligned candidate replica %+v under binlog server %+v", candidateReplica.Key, mostUpToDateBinlogServer.Key)
 			// and move back
-			candidateReplica, err = Repoint(&candidateReplica.Key, masterKey, GTIDHintDeny)
+			candidateReplica, err = RepointContext(ctx, &candidateReplica.Key, masterKey, GTIDHintDeny)
 			if err != nil {
 				return log.Errore(err)
 			}
@@ -2433,7 +3136,7 @@ func RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(
 		return nil
 	}()
 	// Proceed to normal regroup:
-	return RegroupReplicasPseudoGTID(masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, postponeAllMatchOperations)
+	return RegroupReplicasPseudoGTIDContext(ctx, masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, postponeAllMatchOperations)
 }
 
 // RegroupReplicasGTID will choose a candidate replica of a given instance, and take its siblings using GTID
@@ -2450,6 +3153,27 @@ func RegroupReplicasGTID(
 	candidateReplica *Instance,
 	err error,
 ) {
+	return RegroupReplicasGTIDContext(context.Background(), masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, postponeAllMatchOperations)
+}
+
+// RegroupReplicasGTIDContext is RegroupReplicasGTID, bounded by given context. Postponed functions are not bound to the context.
+func RegroupReplicasGTIDContext(
+	ctx context.Context,
+	masterKey *InstanceKey,
+	returnReplicaEvenOnFailureToRegroup bool,
+	onCandidateReplicaChosen func(*Instance),
+	postponedFunctionsContainer *PostponedFunctionsContainer,
+	postponeAllMatchOperations func(*Instance) bool,
+) (
+	lostReplicas [](*Instance),
+	movedReplicas [](*Instance),
+	cannotReplicateReplicas [](*Instance),
+	candidateReplica *Instance,
+	err error,
+) {
+	ctx, span := startOperationSpan(ctx, "regroup-replicas-gtid", masterKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	var emptyReplicas [](*Instance)
 	var unmovedReplicas [](*Instance)
 	candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := GetCandidateReplica(masterKey, true)
@@ -2463,18 +3187,18 @@ func RegroupReplicasGTID(
 	if onCandidateReplicaChosen != nil {
 		onCandidateReplicaChosen(candidateReplica)
 	}
-	moveGTIDFunc := func() error {
+	moveGTIDFunc := func(ctx context.Context) error {
 		replicasToMove := append(equalReplicas, laterReplicas...)
 		log.Debugf("RegroupReplicasGTID: working on %d replicas", len(replicasToMove))
 
-		movedReplicas, unmovedReplicas, err, _ = moveReplicasViaGTID(replicasToMove, candidateReplica, postponedFunctionsContainer)
+		movedReplicas, unmovedReplicas, err, _ = moveReplicasViaGTID(ctx, replicasToMove, candidateReplica, postponedFunctionsContainer)
 		unmovedReplicas = append(unmovedReplicas, aheadReplicas...)
 		return log.Errore(err)
 	}
 	if postponedFunctionsContainer != nil && postponeAllMatchOperations != nil && postponeAllMatchOperations(candidateReplica) {
-		postponedFunctionsContainer.AddPostponedFunction(moveGTIDFunc, fmt.Sprintf("regroup-replicas-gtid %+v", candidateReplica.Key))
+		postponedFunctionsContainer.AddPrioritizedPostponedFunction(detachedOperationContext(ctx), moveGTIDFunc, fmt.Sprintf("regroup-replicas-gtid %+v", candidateReplica.Key), PostponedFunctionPriorityNormal, 0)
 	} else {
-		err = moveGTIDFunc()
+		err = moveGTIDFunc(ctx)
 	}
 
 	StartSlave(&candidateReplica.Key)
@@ -2487,8 +3211,17 @@ func RegroupReplicasGTID(
 // RegroupReplicasBinlogServers works on a binlog-servers topology. It picks the most up-to-date BLS and repoints all other
 // BLS below it
 func RegroupReplicasBinlogServers(masterKey *InstanceKey, returnReplicaEvenOnFailureToRegroup bool) (repointedBinlogServers [](*Instance), promotedBinlogServer *Instance, err error) {
+	return RegroupReplicasBinlogServersContext(context.Background(), masterKey, returnReplicaEvenOnFailureToRegroup)
+}
+
+// RegroupReplicasBinlogServersContext is RegroupReplicasBinlogServers, where master changes are attributed to the
+// master change origin of given context
+func RegroupReplicasBinlogServersContext(ctx context.Context, masterKey *InstanceKey, returnReplicaEvenOnFailureToRegroup bool) (repointedBinlogServers [](*Instance), promotedBinlogServer *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "regroup-replicas-bls", masterKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	var binlogServerReplicas [](*Instance)
-	promotedBinlogServer, binlogServerReplicas, err = getMostUpToDateActiveBinlogServer(masterKey)
+	promotedBinlogServer, binlogServerReplicas, err = GetMostUpToDateActiveBinlogServer(masterKey)
 
 	resultOnError := func(err error) ([](*Instance), *Instance, error) {
 		if !returnReplicaEvenOnFailureToRegroup {
@@ -2501,7 +3234,7 @@ func RegroupReplicasBinlogServers(masterKey *InstanceKey, returnReplicaEvenOnFai
 		return resultOnError(err)
 	}
 
-	repointedBinlogServers, err, _ = RepointTo(binlogServerReplicas, &promotedBinlogServer.Key)
+	repointedBinlogServers, err, _ = RepointToContext(ctx, binlogServerReplicas, &promotedBinlogServer.Key)
 
 	if err != nil {
 		return resultOnError(err)
@@ -2523,6 +3256,24 @@ func RegroupReplicas(masterKey *InstanceKey, returnReplicaEvenOnFailureToRegroup
 	instance *Instance,
 	err error,
 ) {
+	return RegroupReplicasContext(context.Background(), masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer)
+}
+
+// RegroupReplicasContext is RegroupReplicas, bounded by given context. Postponed functions are not bound to the context.
+func RegroupReplicasContext(ctx context.Context, masterKey *InstanceKey, returnReplicaEvenOnFailureToRegroup bool,
+	onCandidateReplicaChosen func(*Instance),
+	postponedFunctionsContainer *PostponedFunctionsContainer) (
+
+	aheadReplicas [](*Instance),
+	equalReplicas [](*Instance),
+	laterReplicas [](*Instance),
+	cannotReplicateReplicas [](*Instance),
+	instance *Instance,
+	err error,
+) {
+	ctx, span := startOperationSpan(ctx, "regroup-replicas", masterKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	//
 	var emptyReplicas [](*Instance)
 
@@ -2552,7 +3303,7 @@ func RegroupReplicas(masterKey *InstanceKey, returnReplicaEvenOnFailureToRegroup
 	}
 	if allGTID {
 		log.Debugf("RegroupReplicas: using GTID to regroup replicas of %+v", *masterKey)
-		unmovedReplicas, movedReplicas, cannotReplicateReplicas, candidateReplica, err := RegroupReplicasGTID(masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, nil, nil)
+		unmovedReplicas, movedReplicas, cannotReplicateReplicas, candidateReplica, err := RegroupReplicasGTIDContext(ctx, masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, nil, nil)
 		return unmovedReplicas, emptyReplicas, movedReplicas, cannotReplicateReplicas, candidateReplica, err
 	}
 	if allBinlogServers {
@@ -2562,24 +3313,24 @@ func RegroupReplicas(masterKey *InstanceKey, returnReplicaEvenOnFailureToRegroup
 	}
 	if allPseudoGTID {
 		log.Debugf("RegroupReplicas: using Pseudo-GTID to regroup replicas of %+v", *masterKey)
-		return RegroupReplicasPseudoGTID(masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, nil)
+		return RegroupReplicasPseudoGTIDContext(ctx, masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, nil)
 	}
 	// And, as last resort, we do PseudoGTID & binlog servers
 	log.Warningf("RegroupReplicas: unsure what method to invoke for %+v; trying Pseudo-GTID+Binlog Servers", *masterKey)
-	return RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, nil)
+	return RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServersContext(ctx, masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, nil)
 }
 
 // relocateBelowInternal is a protentially recursive function which chooses how to relocate an instance below another.
 // It may choose to use Pseudo-GTID, or normal binlog positions, or take advantage of binlog servers,
 // or it may combine any of the above in a multi-step operation.
-func relocateBelowInternal(instance, other *Instance) (*Instance, error) {
-	if canReplicate, err := instance.CanReplicateFrom(other); !canReplicate {
+func relocateBelowInternal(ctx context.Context, instance, other *Instance) (*Instance, error) {
+	if canReplicate, err := instance.CanReplicateFromContext(ctx, other); !canReplicate {
 		return instance, log.Errorf("%+v cannot replicate from %+v. Reason: %+v", instance.Key, other.Key, err)
 	}
 	// simplest:
 	if InstanceIsMasterOf(other, instance) {
 		// already the desired setup.
-		return Repoint(&instance.Key, &other.Key, GTIDHintNeutral)
+		return RepointContext(ctx, &instance.Key, &other.Key, GTIDHintNeutral)
 	}
 	// Do we have record of equivalent coordinates?
 	if !instance.IsBinlogServer() {
@@ -2589,7 +3340,7 @@ func relocateBelowInternal(instance, other *Instance) (*Instance, error) {
 	}
 	// Try and take advantage of binlog servers:
 	if InstancesAreSiblings(instance, other) && other.IsBinlogServer() {
-		return MoveBelow(&instance.Key, &other.Key)
+		return MoveBelowContext(ctx, &instance.Key, &other.Key)
 	}
 	instanceMaster, _, err := ReadInstance(&instance.MasterKey)
 	if err != nil {
@@ -2597,12 +3348,12 @@ func relocateBelowInternal(instance, other *Instance) (*Instance, error) {
 	}
 	if instanceMaster != nil && instanceMaster.MasterKey.Equals(&other.Key) && instanceMaster.IsBinlogServer() {
 		// Moving to grandparent via binlog server
-		return Repoint(&instance.Key, &instanceMaster.MasterKey, GTIDHintDeny)
+		return RepointContext(ctx, &instance.Key, &instanceMaster.MasterKey, GTIDHintDeny)
 	}
 	if other.IsBinlogServer() {
 		if instanceMaster != nil && instanceMaster.IsBinlogServer() && InstancesAreSiblings(instanceMaster, other) {
 			// Special case: this is a binlog server family; we move under the uncle, in one single step
-			return Repoint(&instance.Key, &other.Key, GTIDHintDeny)
+			return RepointContext(ctx, &instance.Key, &other.Key, GTIDHintDeny)
 		}
 
 		// Relocate to its master, then repoint to the binlog server
@@ -2618,10 +3369,10 @@ func relocateBelowInternal(instance, other *Instance) (*Instance, error) {
 		}
 
 		log.Debugf("Relocating to a binlog server; will first attempt to relocate to the binlog server's master: %+v, and then repoint down", otherMaster.Key)
-		if _, err := relocateBelowInternal(instance, otherMaster); err != nil {
+		if _, err := relocateBelowInternal(ctx, instance, otherMaster); err != nil {
 			return instance, err
 		}
-		return Repoint(&instance.Key, &other.Key, GTIDHintDeny)
+		return RepointContext(ctx, &instance.Key, &other.Key, GTIDHintDeny)
 	}
 	if instance.IsBinlogServer() {
 		// Can only move within the binlog-server family tree
@@ -2631,43 +3382,77 @@ func relocateBelowInternal(instance, other *Instance) (*Instance, error) {
 	}
 	// Next, try GTID
 	if _, _, gtidCompatible := instancesAreGTIDAndCompatible(instance, other); gtidCompatible {
-		return moveInstanceBelowViaGTID(instance, other)
+		return moveInstanceBelowViaGTID(ctx, instance, other)
 	}
 
-	// Next, try Pseudo-GTID
-	if instance.UsingPseudoGTID && other.UsingPseudoGTID {
+	// Next, try Pseudo-GTID, unless binary log filters make it unsafe
+	if instance.UsingPseudoGTID && other.UsingPseudoGTID && CheckPseudoGTIDBinlogFilters(instance, other) == nil {
 		// We prefer PseudoGTID to anything else because, while it takes longer to run, it does not issue
 		// a STOP SLAVE on any server other than "instance" itself.
-		instance, _, err := MatchBelow(&instance.Key, &other.Key, true)
+		instance, _, err := MatchBelowContext(ctx, &instance.Key, &other.Key, true)
 		return instance, err
 	}
 	// No Pseudo-GTID; cehck simple binlog file/pos operations:
 	if InstancesAreSiblings(instance, other) {
 		// If comastering, only move below if it's read-only
 		if !other.IsCoMaster || other.ReadOnly {
-			return MoveBelow(&instance.Key, &other.Key)
+			return MoveBelowContext(ctx, &instance.Key, &other.Key)
 		}
 	}
 	// See if we need to MoveUp
 	if instanceMaster != nil && instanceMaster.MasterKey.Equals(&other.Key) {
 		// Moving to grandparent--handles co-mastering writable case
-		return MoveUp(&instance.Key)
+		return MoveUpContext(ctx, &instance.Key)
 	}
 	if instanceMaster != nil && instanceMaster.IsBinlogServer() {
 		// Break operation into two: move (repoint) up, then continue
-		if _, err := MoveUp(&instance.Key); err != nil {
+		if _, err := MoveUpContext(ctx, &instance.Key); err != nil {
 			return instance, err
 		}
-		return relocateBelowInternal(instance, other)
+		return relocateBelowInternal(ctx, instance, other)
 	}
 	// Too complex
 	return nil, log.Errorf("Relocating %+v below %+v turns to be too complex; please do it manually", instance.Key, other.Key)
 }
 
+// binlogEncryptionRelocationWarning returns a non empty warning when relocating instance below other mixes
+// binary log encryption along the replication chain: an encrypted instance below an unencrypted master, which
+// in turn serves unencrypted replicas. Binary log based operations which read across the chain (Pseudo-GTID
+// search, or tooling which reads log files directly) then face encrypted logs in the middle of a plaintext chain.
+func binlogEncryptionRelocationWarning(instance, other *Instance, replicas [](*Instance)) string {
+	if instance.BinlogEncryption == other.BinlogEncryption {
+		return ""
+	}
+	if !instance.BinlogEncryption {
+		return fmt.Sprintf("relocating %+v below %+v: %+v has binlog encryption, %+v has not", instance.Key, other.Key, other.Key, instance.Key)
+	}
+	unencryptedReplicas := 0
+	for _, replica := range replicas {
+		if !replica.BinlogEncryption {
+			unencryptedReplicas++
+		}
+	}
+	if unencryptedReplicas == 0 {
+		return fmt.Sprintf("relocating %+v below %+v: %+v has binlog encryption, %+v has not", instance.Key, other.Key, instance.Key, other.Key)
+	}
+	return fmt.Sprintf("relocating %+v below %+v: %+v has binlog encryption, while both its new master and %d of its replicas have not. Pseudo-GTID operations across this chain may be incompatible", instance.Key, other.Key, instance.Key, unencryptedReplicas)
+}
+
 // RelocateBelow will attempt moving instance indicated by instanceKey below another instance.
 // Orchestrator will try and figure out the best way to relocate the server. This could span normal
 // binlog-position, pseudo-gtid, repointing, binlog servers...
-func RelocateBelow(instanceKey, otherKey *InstanceKey) (*Instance, error) {
+// Unless allowWAN is given, the relocation may be refused if it creates a new WAN-crossing replication edge.
+// See PlanRelocateBelow for a dry run.
+func RelocateBelow(instanceKey, otherKey *InstanceKey, allowWAN bool) (*Instance, error) {
+	return RelocateBelowContext(context.Background(), instanceKey, otherKey, allowWAN)
+}
+
+// RelocateBelowContext is RelocateBelow, bounded by given context. A multi-step relocation does not proceed
+// to its next step once the context is done.
+func RelocateBelowContext(ctx context.Context, instanceKey, otherKey *InstanceKey, allowWAN bool) (relocatedInstance *Instance, err error) {
+	ctx, span := startOperationSpan(ctx, "relocate-below", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
+
 	instance, found, err := ReadInstance(instanceKey)
 	if err != nil || !found {
 		return instance, log.Errorf("Error reading %+v", *instanceKey)
@@ -2679,7 +3464,20 @@ func RelocateBelow(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	if other.IsDescendantOf(instance) {
 		return instance, log.Errorf("relocate: %+v is a descendant of %+v", *otherKey, instance.Key)
 	}
-	instance, err = relocateBelowInternal(instance, other)
+	if err := CheckWANRelocation(instance, other, allowWAN); err != nil {
+		return instance, log.Errore(err)
+	}
+	ctx = withWANRelocationChecked(ctx)
+	if err := checkOperationPolicy("relocate", instanceKey, otherKey, map[string]interface{}{"AllowWAN": allowWAN}); err != nil {
+		return instance, err
+	}
+	if replicas, err := ReadReplicaInstances(instanceKey); err == nil {
+		if warning := binlogEncryptionRelocationWarning(instance, other, replicas); warning != "" {
+			log.Warningf("%s", warning)
+			AuditOperation("binlog-encryption-relocation", instanceKey, warning)
+		}
+	}
+	instance, err = relocateBelowInternal(ctx, instance, other)
 	if err == nil {
 		AuditOperation("relocate-below", instanceKey, fmt.Sprintf("relocated %+v below %+v", *instanceKey, *otherKey))
 	}
@@ -2690,26 +3488,26 @@ func RelocateBelow(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 // replicas of an instance below another.
 // It may choose to use Pseudo-GTID, or normal binlog positions, or take advantage of binlog servers,
 // or it may combine any of the above in a multi-step operation.
-func relocateReplicasInternal(replicas [](*Instance), instance, other *Instance) ([](*Instance), error, []error) {
+func relocateReplicasInternal(ctx context.Context, replicas [](*Instance), instance, other *Instance) ([](*Instance), error, []error) {
 	errs := []error{}
 	var err error
 	// simplest:
 	if instance.Key.Equals(&other.Key) {
 		// already the desired setup.
-		return RepointTo(replicas, &other.Key)
+		return RepointToContext(ctx, replicas, &other.Key)
 	}
 	// Try and take advantage of binlog servers:
 	if InstanceIsMasterOf(other, instance) && instance.IsBinlogServer() {
 		// Up from a binlog server
-		return RepointTo(replicas, &other.Key)
+		return RepointToContext(ctx, replicas, &other.Key)
 	}
 	if InstanceIsMasterOf(instance, other) && other.IsBinlogServer() {
 		// Down under a binlog server
-		return RepointTo(replicas, &other.Key)
+		return RepointToContext(ctx, replicas, &other.Key)
 	}
 	if InstancesAreSiblings(instance, other) && instance.IsBinlogServer() && other.IsBinlogServer() {
 		// Between siblings
-		return RepointTo(replicas, &other.Key)
+		return RepointToContext(ctx, replicas, &other.Key)
 	}
 	if other.IsBinlogServer() {
 		// Relocate to binlog server's parent (recursive call), then repoint down
@@ -2717,23 +3515,23 @@ func relocateReplicasInternal(replicas [](*Instance), instance, other *Instance)
 		if err != nil || !found {
 			return nil, err, errs
 		}
-		replicas, err, errs = relocateReplicasInternal(replicas, instance, otherMaster)
+		replicas, err, errs = relocateReplicasInternal(ctx, replicas, instance, otherMaster)
 		if err != nil {
 			return replicas, err, errs
 		}
 
-		return RepointTo(replicas, &other.Key)
+		return RepointToContext(ctx, replicas, &other.Key)
 	}
 	// GTID
 	{
-		movedReplicas, unmovedReplicas, err, errs := moveReplicasViaGTID(replicas, other, nil)
+		movedReplicas, unmovedReplicas, err, errs := moveReplicasViaGTID(ctx, replicas, other, nil)
 
 		if len(movedReplicas) == len(replicas) {
 			// Moved (or tried moving) everything via GTID
 			return movedReplicas, err, errs
 		} else if len(movedReplicas) > 0 {
 			// something was moved via GTID; let's try further on
-			return relocateReplicasInternal(unmovedReplicas, instance, other)
+			return relocateReplicasInternal(ctx, unmovedReplicas, instance, other)
 		}
 		// Otherwise nothing was moved via GTID. Maybe we don't have any GTIDs, we continue.
 	}
@@ -2748,7 +3546,7 @@ func relocateReplicasInternal(replicas [](*Instance), instance, other *Instance)
 				pseudoGTIDReplicas = append(pseudoGTIDReplicas, replica)
 			}
 		}
-		pseudoGTIDReplicas, _, err, errs = MultiMatchBelow(pseudoGTIDReplicas, &other.Key, nil)
+		pseudoGTIDReplicas, _, err, errs = MultiMatchBelowContext(ctx, pseudoGTIDReplicas, &other.Key, nil)
 		return pseudoGTIDReplicas, err, errs
 	}
 
@@ -2765,6 +3563,13 @@ func relocateReplicasInternal(replicas [](*Instance), instance, other *Instance)
 // Orchestrator will try and figure out the best way to relocate the servers. This could span normal
 // binlog-position, pseudo-gtid, repointing, binlog servers...
 func RelocateReplicas(instanceKey, otherKey *InstanceKey, pattern string) (replicas [](*Instance), other *Instance, err error, errs []error) {
+	return RelocateReplicasContext(context.Background(), instanceKey, otherKey, pattern)
+}
+
+// RelocateReplicasContext is RelocateReplicas, bounded by given context
+func RelocateReplicasContext(ctx context.Context, instanceKey, otherKey *InstanceKey, pattern string) (replicas [](*Instance), other *Instance, err error, errs []error) {
+	ctx, span := startOperationSpan(ctx, "relocate-replicas", instanceKey)
+	defer func() { span.SetError(err); span.End() }()
 
 	instance, found, err := ReadInstance(instanceKey)
 	if err != nil || !found {
@@ -2790,7 +3595,14 @@ func RelocateReplicas(instanceKey, otherKey *InstanceKey, pattern string) (repli
 			return replicas, other, log.Errorf("relocate-replicas: %+v is a descendant of %+v", *otherKey, replica.Key), errs
 		}
 	}
-	replicas, err, errs = relocateReplicasInternal(replicas, instance, other)
+	replicaKeys := []string{}
+	for _, replica := range replicas {
+		replicaKeys = append(replicaKeys, replica.Key.StringCode())
+	}
+	if err := checkOperationPolicy("relocate-replicas", instanceKey, otherKey, map[string]interface{}{"Pattern": pattern, "Replicas": replicaKeys}); err != nil {
+		return replicas, other, err, errs
+	}
+	replicas, err, errs = relocateReplicasInternal(ctx, replicas, instance, other)
 
 	if err == nil {
 		AuditOperation("relocate-replicas", instanceKey, fmt.Sprintf("relocated %+v replicas of %+v below %+v", len(replicas), *instanceKey, *otherKey))
@@ -2812,6 +3624,9 @@ func PurgeBinaryLogsTo(instanceKey *InstanceKey, logFile string, force bool) (*I
 			}
 		}
 	}
+	if err := checkOperationPolicy("purge-binary-logs", instanceKey, nil, map[string]interface{}{"LogFile": logFile, "Force": force}); err != nil {
+		return nil, err
+	}
 	return purgeBinaryLogsTo(instanceKey, logFile)
 }
 
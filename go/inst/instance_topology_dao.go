@@ -25,6 +25,7 @@ import (
 
 	"github.com/github/orchestrator/go/config"
 	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/tracing"
 	"github.com/github/orchestrator/go/util"
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/sqlutils"
@@ -51,11 +52,41 @@ const (
 
 // ExecInstance executes a given query on the given MySQL topology instance
 func ExecInstance(instanceKey *InstanceKey, query string, args ...interface{}) (sql.Result, error) {
+	return ExecInstanceContext(context.Background(), instanceKey, query, args...)
+}
+
+// ExecInstanceContext is ExecInstance, where the query is traced as a child of the span carried by given context
+func ExecInstanceContext(ctx context.Context, instanceKey *InstanceKey, query string, args ...interface{}) (sql.Result, error) {
 	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
 	if err != nil {
 		return nil, err
 	}
-	return sqlutils.ExecNoPrepare(db, query, args...)
+	operation := tracing.StatementOperation(query)
+	span := tracing.StartClientSpanFromContext(ctx, fmt.Sprintf("mysql %s", operation))
+	span.SetAttribute("db.system", "mysql")
+	span.SetAttribute("db.operation", operation)
+	span.SetAttribute("instance", instanceKey.StringCode())
+	result, err := sqlutils.ExecNoPrepare(db, query, args...)
+	span.SetError(err)
+	span.End()
+	return result, err
+}
+
+// ExecInstanceSuper executes a given query on the given MySQL topology instance using the elevated
+// (SUPER) credentials, if configured. These credentials are opened just in time and released
+// immediately after. When no such credentials are configured, this falls back to ExecInstance.
+func ExecInstanceSuper(instanceKey *InstanceKey, query string, args ...interface{}) (sql.Result, error) {
+	if !db.HasTopologySuperCredentials() {
+		return ExecInstance(instanceKey, query, args...)
+	}
+	superDB, err := db.OpenTopologySuper(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return nil, err
+	}
+	defer superDB.Close()
+
+	AuditOperation("super-credentials", instanceKey, fmt.Sprintf("using elevated credentials of %s: %s", config.Config.MySQLTopologySuperUser, query))
+	return sqlutils.ExecNoPrepare(superDB, query, args...)
 }
 
 // ExecuteOnTopology will execute given function while maintaining concurrency limit
@@ -345,7 +376,8 @@ func WaitForSQLThreadUpToDate(instanceKey *InstanceKey, overallTimeout time.Dura
 }
 
 // StopSlaves will stop replication concurrently on given set of replicas.
-// It will potentially do nothing, or attempt to stop _nicely_ or just stop normally, all according to stopReplicationMethod
+// It will potentially do nothing, or attempt to stop _nicely_ (escalating to a normal stop upon timeout) or just stop normally,
+// all according to stopReplicationMethod
 func StopSlaves(replicas [](*Instance), stopReplicationMethod StopReplicationMethod, timeout time.Duration) [](*Instance) {
 	if stopReplicationMethod == NoStopReplication {
 		return replicas
@@ -364,9 +396,10 @@ func StopSlaves(replicas [](*Instance), stopReplicationMethod StopReplicationMet
 			// Wait your turn to read a replica
 			ExecuteOnTopology(func() {
 				if stopReplicationMethod == StopReplicationNicely {
-					StopSlaveNicely(&replica.Key, timeout)
+					replica, _ = StopSlaveEscalating(&replica.Key, timeout)
+				} else {
+					replica, _ = StopSlave(&replica.Key)
 				}
-				replica, _ = StopSlave(&replica.Key)
 				updatedReplica = &replica
 			})
 		}()
@@ -384,6 +417,11 @@ func StopSlavesNicely(replicas [](*Instance), timeout time.Duration) [](*Instanc
 
 // StopSlave stops replication on a given instance
 func StopSlave(instanceKey *InstanceKey) (*Instance, error) {
+	return StopSlaveContext(context.Background(), instanceKey)
+}
+
+// StopSlaveContext is StopSlave, where the statement is traced as a child of the span carried by given context
+func StopSlaveContext(ctx context.Context, instanceKey *InstanceKey) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
@@ -392,7 +430,7 @@ func StopSlave(instanceKey *InstanceKey) (*Instance, error) {
 	if !instance.IsReplica() {
 		return instance, fmt.Errorf("instance is not a replica: %+v", instanceKey)
 	}
-	_, err = ExecInstance(instanceKey, `stop slave`)
+	_, err = ExecInstanceContext(ctx, instanceKey, `stop slave`)
 	if err != nil {
 		// Patch; current MaxScale behavior for STOP SLAVE is to throw an error if replica already stopped.
 		if instance.isMaxScale() && err.Error() == "Error 1199: Slave connection is not running" {
@@ -434,6 +472,11 @@ func waitForReplicationState(instanceKey *InstanceKey, expectedState Replication
 
 // StartSlave starts replication on a given instance.
 func StartSlave(instanceKey *InstanceKey) (*Instance, error) {
+	return StartSlaveContext(context.Background(), instanceKey)
+}
+
+// StartSlaveContext is StartSlave, where the statement is traced as a child of the span carried by given context
+func StartSlaveContext(ctx context.Context, instanceKey *InstanceKey) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
@@ -457,7 +500,7 @@ func StartSlave(instanceKey *InstanceKey) (*Instance, error) {
 		}
 	}
 
-	_, err = ExecInstance(instanceKey, `start slave`)
+	_, err = ExecInstanceContext(ctx, instanceKey, `start slave`)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
@@ -506,6 +549,13 @@ func StartSlaves(replicas [](*Instance)) {
 
 // StartSlaveUntilMasterCoordinates issuesa START SLAVE UNTIL... statement on given instance
 func StartSlaveUntilMasterCoordinates(instanceKey *InstanceKey, masterCoordinates *BinlogCoordinates) (*Instance, error) {
+	return StartSlaveUntilMasterCoordinatesContext(context.Background(), instanceKey, masterCoordinates)
+}
+
+// StartSlaveUntilMasterCoordinatesContext issues a START SLAVE UNTIL... statement on given instance and waits
+// for it to reach given coordinates. Should the context be done while waiting, replication is stopped and
+// an error is returned.
+func StartSlaveUntilMasterCoordinatesContext(ctx context.Context, instanceKey *InstanceKey, masterCoordinates *BinlogCoordinates) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
@@ -532,7 +582,7 @@ func StartSlaveUntilMasterCoordinates(instanceKey *InstanceKey, masterCoordinate
 	// MariaDB has a bug: a CHANGE MASTER TO statement does not work properly with prepared statement... :P
 	// See https://mariadb.atlassian.net/browse/MDEV-7640
 	// This is the reason for ExecInstance
-	_, err = ExecInstance(instanceKey, "start slave until master_log_file=?, master_log_pos=?",
+	_, err = ExecInstanceContext(ctx, instanceKey, "start slave until master_log_file=?, master_log_pos=?",
 		masterCoordinates.LogFile, masterCoordinates.LogPos)
 	if err != nil {
 		return instance, log.Errore(err)
@@ -546,7 +596,12 @@ func StartSlaveUntilMasterCoordinates(instanceKey *InstanceKey, masterCoordinate
 
 		switch {
 		case instance.ExecBinlogCoordinates.SmallerThan(masterCoordinates):
-			time.Sleep(retryInterval)
+			select {
+			case <-ctx.Done():
+				StopSlave(instanceKey)
+				return instance, log.Errore(checkOperationContext(ctx, instanceKey))
+			case <-time.After(retryInterval):
+			}
 		case instance.ExecBinlogCoordinates.Equals(masterCoordinates):
 			upToDate = true
 		case masterCoordinates.SmallerThan(&instance.ExecBinlogCoordinates):
@@ -648,14 +703,37 @@ func workaroundBug83713(instanceKey *InstanceKey) {
 
 // ChangeMasterTo changes the given instance's master according to given input.
 func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinlogCoordinates *BinlogCoordinates, skipUnresolve bool, gtidHint OperationGTIDHint) (*Instance, error) {
+	return ChangeMasterToContext(context.Background(), instanceKey, masterKey, masterBinlogCoordinates, skipUnresolve, gtidHint)
+}
+
+// ChangeMasterToContext is ChangeMasterTo, where the change is recorded in the instance's master history
+// as originating from the master change origin of given context.
+func ChangeMasterToContext(ctx context.Context, instanceKey *InstanceKey, masterKey *InstanceKey, masterBinlogCoordinates *BinlogCoordinates, skipUnresolve bool, gtidHint OperationGTIDHint) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
+	// Neither the replica, nor the master it is pointed at, may have changed IPs since the operation began
+	if err := validateOperationIPPins(ctx, instanceKey, masterKey); err != nil {
+		return instance, log.Errore(err)
+	}
 
 	if instance.ReplicationThreadsExist() && !instance.ReplicationThreadsStopped() {
 		return instance, fmt.Errorf("ChangeMasterTo: Cannot change master on: %+v because replication threads are not stopped", *instanceKey)
 	}
+	if master, _, _ := ReadInstance(masterKey); master != nil {
+		if err := checkReplicationGroupChangeMaster(instance, master); err != nil {
+			return instance, log.Errore(err)
+		}
+		if err := checkWANMasterChange(ctx, instance, master); err != nil {
+			return instance, log.Errore(err)
+		}
+		if config.Config.VerifyReplicationSettings && !instance.IsBinlogServer() && !master.IsBinlogServer() {
+			if err := verifyReplicationSettings(instanceKey, masterKey); err != nil {
+				return instance, log.Errore(fmt.Errorf("ChangeMasterTo: %+v", err))
+			}
+		}
+	}
 	log.Debugf("ChangeMasterTo: will attempt changing master on %+v to %+v, %+v", *instanceKey, *masterKey, *masterBinlogCoordinates)
 	changeToMasterKey := masterKey
 	if !skipUnresolve {
@@ -679,56 +757,61 @@ func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinl
 
 	var changeMasterFunc func() error
 	changedViaGTID := false
+	method := "binlog-file-pos"
 	if instance.UsingMariaDBGTID && gtidHint != GTIDHintDeny {
 		// Keep on using GTID
 		changeMasterFunc = func() error {
-			_, err := ExecInstance(instanceKey, "change master to master_host=?, master_port=?",
+			_, err := ExecInstanceContext(ctx, instanceKey, "change master to master_host=?, master_port=?",
 				changeToMasterKey.Hostname, changeToMasterKey.Port)
 			return err
 		}
 		changedViaGTID = true
+		method = "mariadb-gtid"
 	} else if instance.UsingMariaDBGTID && gtidHint == GTIDHintDeny {
 		// Make sure to not use GTID
 		changeMasterFunc = func() error {
-			_, err = ExecInstance(instanceKey, "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?, master_use_gtid=no",
+			_, err = ExecInstanceContext(ctx, instanceKey, "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?, master_use_gtid=no",
 				changeToMasterKey.Hostname, changeToMasterKey.Port, masterBinlogCoordinates.LogFile, masterBinlogCoordinates.LogPos)
 			return err
 		}
 	} else if instance.IsMariaDB() && gtidHint == GTIDHintForce {
 		// Is MariaDB; not using GTID, turn into GTID
 		changeMasterFunc = func() error {
-			_, err = ExecInstance(instanceKey, "change master to master_host=?, master_port=?, master_use_gtid=slave_pos",
+			_, err = ExecInstanceContext(ctx, instanceKey, "change master to master_host=?, master_port=?, master_use_gtid=slave_pos",
 				changeToMasterKey.Hostname, changeToMasterKey.Port)
 			return err
 		}
 		changedViaGTID = true
+		method = "mariadb-gtid"
 	} else if instance.UsingOracleGTID && gtidHint != GTIDHintDeny {
 		// Is Oracle; already uses GTID; keep using it.
 		changeMasterFunc = func() error {
-			_, err = ExecInstance(instanceKey, "change master to master_host=?, master_port=?",
+			_, err = ExecInstanceContext(ctx, instanceKey, "change master to master_host=?, master_port=?",
 				changeToMasterKey.Hostname, changeToMasterKey.Port)
 			return err
 		}
 		changedViaGTID = true
+		method = "oracle-gtid"
 	} else if instance.UsingOracleGTID && gtidHint == GTIDHintDeny {
 		// Is Oracle; already uses GTID
 		changeMasterFunc = func() error {
-			_, err = ExecInstance(instanceKey, "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?, master_auto_position=0",
+			_, err = ExecInstanceContext(ctx, instanceKey, "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?, master_auto_position=0",
 				changeToMasterKey.Hostname, changeToMasterKey.Port, masterBinlogCoordinates.LogFile, masterBinlogCoordinates.LogPos)
 			return err
 		}
 	} else if instance.SupportsOracleGTID && gtidHint == GTIDHintForce {
 		// Is Oracle; not using GTID right now; turn into GTID
 		changeMasterFunc = func() error {
-			_, err = ExecInstance(instanceKey, "change master to master_host=?, master_port=?, master_auto_position=1",
+			_, err = ExecInstanceContext(ctx, instanceKey, "change master to master_host=?, master_port=?, master_auto_position=1",
 				changeToMasterKey.Hostname, changeToMasterKey.Port)
 			return err
 		}
 		changedViaGTID = true
+		method = "oracle-gtid"
 	} else {
 		// Normal binlog file:pos
 		changeMasterFunc = func() error {
-			_, err = ExecInstance(instanceKey, "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?",
+			_, err = ExecInstanceContext(ctx, instanceKey, "change master to master_host=?, master_port=?, master_log_file=?, master_log_pos=?",
 				changeToMasterKey.Hostname, changeToMasterKey.Port, masterBinlogCoordinates.LogFile, masterBinlogCoordinates.LogPos)
 			return err
 		}
@@ -744,6 +827,11 @@ func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinl
 	}
 	WriteMasterPositionEquivalence(&originalMasterKey, &originalExecBinlogCoordinates, changeToMasterKey, masterBinlogCoordinates)
 	ResetInstanceRelaylogCoordinatesHistory(instanceKey)
+	masterChange := NewMasterChange(instanceKey, masterKey, &originalMasterKey, masterBinlogCoordinates, method, masterChangeOriginFromContext(ctx))
+	if err := WriteMasterChange(masterChange); err != nil {
+		log.Errore(err)
+	}
+	recordInFlightOperationMasterChange(masterChange)
 
 	log.Infof("ChangeMasterTo: Changed master on %+v to: %+v, %+v. GTID: %+v", *instanceKey, masterKey, masterBinlogCoordinates, changedViaGTID)
 
@@ -828,7 +916,7 @@ func ResetMaster(instanceKey *InstanceKey) (*Instance, error) {
 		return instance, fmt.Errorf("noop: aborting reset-master operation on %+v; signalling error but nothing went wrong.", *instanceKey)
 	}
 
-	_, err = ExecInstance(instanceKey, `reset master`)
+	_, err = ExecInstanceSuper(instanceKey, `reset master`)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
@@ -844,7 +932,7 @@ func setGTIDPurged(instance *Instance, gtidPurged string) error {
 		return fmt.Errorf("noop: aborting set-gtid-purged operation on %+v; signalling error but nothing went wrong.", instance.Key)
 	}
 
-	_, err := ExecInstance(&instance.Key, `set global gtid_purged := ?`, gtidPurged)
+	_, err := ExecInstanceSuper(&instance.Key, `set global gtid_purged := ?`, gtidPurged)
 	return err
 }
 
@@ -920,6 +1008,9 @@ func SkipQuery(instanceKey *InstanceKey) (*Instance, error) {
 	if instance.LastSQLError == "" {
 		return instance, fmt.Errorf("No SQL error on %+v", instanceKey)
 	}
+	if err := checkOperationPolicy("skip-query", instanceKey, nil, map[string]interface{}{"LastSQLError": instance.LastSQLError}); err != nil {
+		return instance, err
+	}
 
 	if *config.RuntimeCLIFlags.Noop {
 		return instance, fmt.Errorf("noop: aborting skip-query operation on %+v; signalling error but nothing went wrong.", *instanceKey)
@@ -1038,12 +1129,37 @@ func SetReadOnly(instanceKey *InstanceKey, readOnly bool) (*Instance, error) {
 	return instance, err
 }
 
+// SetSuperReadOnly sets or clears the instance's global super_read_only variable, regardless of UseSuperReadOnly.
+// Setting super_read_only implicitly sets read_only. It is only available on MySQL 5.7.8 and Percona Server 5.6.21-70.
+func SetSuperReadOnly(instanceKey *InstanceKey, superReadOnly bool) (*Instance, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+
+	if *config.RuntimeCLIFlags.Noop {
+		return instance, fmt.Errorf("noop: aborting set-super-read-only operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	if _, err := ExecInstance(instanceKey, "set global super_read_only = ?", superReadOnly); err != nil {
+		return instance, log.Errore(err)
+	}
+	instance, err = ReadTopologyInstance(instanceKey)
+
+	log.Infof("instance %+v super_read_only: %t", instanceKey, superReadOnly)
+	AuditOperation("super-read-only", instanceKey, fmt.Sprintf("set as %t", superReadOnly))
+
+	return instance, err
+}
+
 // KillQuery stops replication on a given instance
 func KillQuery(instanceKey *InstanceKey, process int64) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
+	if err := checkOperationPolicy("kill-query", instanceKey, nil, map[string]interface{}{"Process": process}); err != nil {
+		return instance, err
+	}
 
 	if *config.RuntimeCLIFlags.Noop {
 		return instance, fmt.Errorf("noop: aborting kill-query operation on %+v; signalling error but nothing went wrong.", *instanceKey)
@@ -1161,7 +1277,13 @@ func CheckAndInjectPseudoGTIDOnWriter(instance *Instance) (injected bool, err er
 	return injected, nil
 }
 
+// GTIDSubtract computes GTID_SUBTRACT(gtidSet, gtidSubset). This is done natively; the given instance
+// is only queried when the sets cannot be parsed natively.
 func GTIDSubtract(instanceKey *InstanceKey, gtidSet string, gtidSubset string) (gtidSubtract string, err error) {
+	if gtidSubtract, err = subtractGtidSets(gtidSet, gtidSubset); err == nil {
+		return gtidSubtract, nil
+	}
+	log.Debugf("GTIDSubtract: cannot subtract natively, querying %+v: %+v", *instanceKey, err)
 	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
 	if err != nil {
 		return gtidSubtract, err
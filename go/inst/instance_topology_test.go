@@ -2,6 +2,8 @@ package inst
 
 import (
 	"math/rand"
+	"regexp"
+	"strings"
 
 	"github.com/github/orchestrator/go/config"
 	"github.com/openark/golib/log"
@@ -102,6 +104,47 @@ func TestSortInstancesDataCenterHint(t *testing.T) {
 	test.S(t).ExpectEquals(instances[0].Key, i810Key)
 }
 
+func TestSortInstancesDataCenterPreference(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instances[0].ExecBinlogCoordinates
+		instance.DataCenter = "somedc"
+	}
+	instancesMap[i720Key.StringCode()].DataCenter = "dc3"
+	instancesMap[i810Key.StringCode()].DataCenter = "dc2"
+	preference := &PromotionDataCenterPreference{FailedMasterDataCenter: "dc1", Policy: &config.DCPolicy{DataCenters: []string{"dc2", "dc3"}}}
+	sortInstancesDataCenterPreference(instances, preference)
+	test.S(t).ExpectEquals(instances[0].Key, i810Key)
+	test.S(t).ExpectEquals(instances[1].Key, i720Key)
+
+	instancesMap[i830Key.StringCode()].DataCenter = "dc1"
+	sortInstancesDataCenterPreference(instances, preference)
+	test.S(t).ExpectEquals(instances[0].Key, i830Key)
+
+	preference.Policy.IgnoreFailedMasterDataCenter = true
+	sortInstancesDataCenterPreference(instances, preference)
+	test.S(t).ExpectEquals(instances[0].Key, i810Key)
+}
+
+func TestSortInstancesSemiSyncAcknowledgers(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instances[0].ExecBinlogCoordinates
+	}
+	instancesMap[i830Key.StringCode()].DataCenter = "dc1"
+	acknowledgers := NewInstanceKeyMap()
+	acknowledgers.AddKey(i720Key)
+	sortInstancesPromotionPreference(instances, &PromotionDataCenterPreference{FailedMasterDataCenter: "dc1"}, acknowledgers)
+	test.S(t).ExpectEquals(instances[0].Key, i720Key)
+	test.S(t).ExpectEquals(instances[1].Key, i830Key)
+
+	// A more up to date replica comes first regardless
+	instancesMap[i810Key.StringCode()].ExecBinlogCoordinates.LogPos = 99
+	sortInstancesPromotionPreference(instances, &PromotionDataCenterPreference{FailedMasterDataCenter: "dc1"}, acknowledgers)
+	test.S(t).ExpectEquals(instances[0].Key, i810Key)
+	test.S(t).ExpectEquals(instances[1].Key, i720Key)
+}
+
 func TestSortInstancesGtidErrant(t *testing.T) {
 	instances, instancesMap := generateTestInstances()
 	for _, instance := range instances {
@@ -113,6 +156,82 @@ func TestSortInstancesGtidErrant(t *testing.T) {
 	test.S(t).ExpectEquals(instances[0].Key, i810Key)
 }
 
+func TestSortInstancesPromotionPriority(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instances[0].ExecBinlogCoordinates
+		instance.PromotionRule = PreferPromoteRule
+	}
+	instancesMap[i720Key.StringCode()].PromotionPriority = 5
+	instancesMap[i810Key.StringCode()].PromotionPriority = 5
+	sortInstances(instances)
+	test.S(t).ExpectEquals(instances[0].Key, i720Key)
+	test.S(t).ExpectEquals(instances[1].Key, i810Key)
+	test.S(t).ExpectEquals(instances[2].Key, i710Key)
+	test.S(t).ExpectEquals(instances[3].Key, i730Key)
+}
+
+func TestSortInstancesDeterministicTies(t *testing.T) {
+	instances, _ := generateTestInstances()
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instances[0].ExecBinlogCoordinates
+	}
+	for _, reversed := range [][](*Instance){instances, {instances[5], instances[4], instances[3], instances[2], instances[1], instances[0]}} {
+		sortInstances(reversed)
+		test.S(t).ExpectEquals(reversed[0].Key, i710Key)
+		test.S(t).ExpectEquals(reversed[5].Key, i830Key)
+	}
+}
+
+func TestSortInstancesConflictingCriteria(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instances[0].ExecBinlogCoordinates
+	}
+	// i710 is preferred by the earlier criteria, i720 by the later ones
+	i710 := instancesMap[i710Key.StringCode()]
+	i720 := instancesMap[i720Key.StringCode()]
+	i720.LogSlaveUpdatesEnabled = false
+	i720.PromotionRule = MustPromoteRule
+	i710.GtidErrant = "00020192-1111-1111-1111-111111111111:1"
+	i710.DataCenter = "dc2"
+
+	tests := []struct {
+		name      string
+		configure func()
+	}{
+		{"log_slave_updates over errant GTID and promotion rule", func() {}},
+		{"log_slave_updates over data center", func() { i720.DataCenter = "dc1" }},
+		{"version over data center", func() {
+			i720.LogSlaveUpdatesEnabled = true
+			i720.Version = "5.7.8"
+		}},
+		{"data center over errant GTID", func() {
+			i720.Version = i710.Version
+			i710.DataCenter = "dc1"
+			i720.DataCenter = "dc2"
+			i720.GtidErrant = ""
+		}},
+	}
+	for _, tt := range tests {
+		tt.configure()
+		for _, pair := range [][](*Instance){{i710, i720}, {i720, i710}} {
+			sorter := NewInstancesSorterByExec(pair, "dc1")
+			i710Index, i720Index := 0, 1
+			if pair[0] == i720 {
+				i710Index, i720Index = 1, 0
+			}
+			test.S(t).ExpectFalse(sorter.Less(i710Index, i720Index))
+			test.S(t).ExpectTrue(sorter.Less(i720Index, i710Index))
+			test.S(t).ExpectFalse(sorter.isEquallyPreferred(i710, i720))
+
+			sortInstancesDataCenterHint(pair, "dc1")
+			test.S(t).ExpectEquals(pair[0].Key, i710Key)
+		}
+	}
+}
+
 func TestGetPriorityMajorVersionForCandidate(t *testing.T) {
 	{
 		instances, instancesMap := generateTestInstances()
@@ -267,6 +386,27 @@ func TestIsBannedFromBeingCandidateReplica(t *testing.T) {
 		}
 		config.Config.PromotionIgnoreHostnameFilters = []string{}
 	}
+	{
+		defer func(prevent bool) { config.Config.PreventErrantGTIDPromotion = prevent }(config.Config.PreventErrantGTIDPromotion)
+		defer func(filters []string) {
+			config.Config.ErrantGTIDPromotionClusterFilters = filters
+		}(config.Config.ErrantGTIDPromotionClusterFilters)
+
+		instances, instancesMap := generateTestInstances()
+		for _, instance := range instances {
+			instance.ClusterName = "c1:3306"
+		}
+		errant := instancesMap[i810Key.StringCode()]
+		errant.GtidErrant = "00020192-1111-1111-1111-111111111111:1"
+		test.S(t).ExpectFalse(IsBannedFromBeingCandidateReplica(errant))
+
+		config.Config.PreventErrantGTIDPromotion = true
+		test.S(t).ExpectTrue(IsBannedFromBeingCandidateReplica(errant))
+		test.S(t).ExpectFalse(IsBannedFromBeingCandidateReplica(instancesMap[i820Key.StringCode()]))
+
+		config.Config.ErrantGTIDPromotionClusterFilters = []string{"c1:3306"}
+		test.S(t).ExpectFalse(IsBannedFromBeingCandidateReplica(errant))
+	}
 }
 
 func TestChooseCandidateReplicaNoCandidateReplica(t *testing.T) {
@@ -293,6 +433,34 @@ func TestChooseCandidateReplica(t *testing.T) {
 	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
 }
 
+func TestChooseCandidateReplicaSkipsDelayedReplica(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	instancesMap[i830Key.StringCode()].SQLDelay = 3600
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i820Key)
+	test.S(t).ExpectEquals(len(aheadReplicas), 1)
+	test.S(t).ExpectEquals(len(equalReplicas), 0)
+	test.S(t).ExpectEquals(len(laterReplicas), 4)
+	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
+}
+
+func TestChooseCandidateReplicaAllDelayed(t *testing.T) {
+	instances, _ := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.SQLDelay = 3600
+	}
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, _, _, laterReplicas, _, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i830Key)
+	test.S(t).ExpectTrue(candidate.IsDelayedReplica())
+	test.S(t).ExpectEquals(len(laterReplicas), 5)
+}
+
 func TestChooseCandidateReplica2(t *testing.T) {
 	instances, instancesMap := generateTestInstances()
 	applyGeneralGoodToGoReplicationParams(instances)
@@ -568,3 +736,152 @@ func TestChooseCandidateReplicaPromoteRuleOrdering3(t *testing.T) {
 	test.S(t).ExpectEquals(len(laterReplicas), 0)
 	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
 }
+
+func TestChooseCandidateReplicaPromotionPriority(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instancesMap[i710Key.StringCode()].ExecBinlogCoordinates
+		instance.PromotionRule = PreferPromoteRule
+		instance.PromotionPriority = 10
+	}
+	instancesMap[i820Key.StringCode()].PromotionPriority = 20
+	instancesMap[i830Key.StringCode()].PromotionRule = NeutralPromoteRule
+	instancesMap[i830Key.StringCode()].PromotionPriority = 100
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i820Key)
+	test.S(t).ExpectEquals(len(aheadReplicas), 0)
+	test.S(t).ExpectEquals(len(equalReplicas), 5)
+	test.S(t).ExpectEquals(len(laterReplicas), 0)
+	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
+}
+
+func TestBinlogEncryptionRelocationWarning(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	instance := instancesMap[i710Key.StringCode()]
+	other := instancesMap[i720Key.StringCode()]
+	replicas := instances[3:]
+	{
+		test.S(t).ExpectEquals(binlogEncryptionRelocationWarning(instance, other, replicas), "")
+	}
+	{
+		other.BinlogEncryption = true
+		test.S(t).ExpectTrue(binlogEncryptionRelocationWarning(instance, other, replicas) != "")
+		other.BinlogEncryption = false
+	}
+	{
+		instance.BinlogEncryption = true
+		warning := binlogEncryptionRelocationWarning(instance, other, replicas)
+		test.S(t).ExpectTrue(strings.Contains(warning, "3 of its replicas"))
+	}
+	{
+		for _, replica := range replicas {
+			replica.BinlogEncryption = true
+		}
+		warning := binlogEncryptionRelocationWarning(instance, other, replicas)
+		test.S(t).ExpectTrue(warning != "")
+		test.S(t).ExpectFalse(strings.Contains(warning, "of its replicas"))
+	}
+}
+
+func TestCheckPseudoGTIDBinlogFilters(t *testing.T) {
+	instances, _ := generateTestInstances()
+	instance, other := instances[0], instances[1]
+	test.S(t).ExpectNil(CheckPseudoGTIDBinlogFilters(instance, other))
+
+	instance.HasBinlogFilters = true
+	test.S(t).ExpectNil(CheckPseudoGTIDBinlogFilters(instance, other))
+	instance.LogBinEnabled = true
+	instance.LogSlaveUpdatesEnabled = true
+	test.S(t).ExpectNotNil(CheckPseudoGTIDBinlogFilters(instance, other))
+
+	instance.HasBinlogFilters = false
+	other.HasBinlogFilters = true
+	err := CheckPseudoGTIDBinlogFilters(instance, other)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectTrue(strings.Contains(err.Error(), other.Key.StringCode()))
+}
+
+func TestFilterTopologyInstances(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	instancesMap[i720Key.StringCode()].MasterKey = i710Key
+	instancesMap[i730Key.StringCode()].MasterKey = i710Key
+	instancesMap[i810Key.StringCode()].MasterKey = i720Key
+	instancesMap[i820Key.StringCode()].MasterKey = i720Key
+	instancesMap[i830Key.StringCode()].MasterKey = i820Key
+
+	filteredHostnames := func(filtered [](*Instance)) string {
+		hostnames := []string{}
+		for _, instance := range filtered {
+			hostnames = append(hostnames, instance.Key.Hostname)
+		}
+		return strings.Join(hostnames, ",")
+	}
+	{
+		// subtree, along with its master
+		filtered := filterTopologyInstances(instances, regexp.MustCompile("^i820$"), nil)
+		test.S(t).ExpectEquals(filteredHostnames(filtered), "i710,i720,i820,i830")
+	}
+	{
+		filtered := filterTopologyInstances(instances, regexp.MustCompile("^i73"), nil)
+		test.S(t).ExpectEquals(filteredHostnames(filtered), "i710,i730")
+	}
+	{
+		tagged := NewInstanceKeyMap()
+		tagged.AddKeys([]InstanceKey{i730Key, i810Key})
+		filtered := filterTopologyInstances(instances, nil, tagged)
+		test.S(t).ExpectEquals(filteredHostnames(filtered), "i710,i720,i730,i810")
+
+		filtered = filterTopologyInstances(instances, regexp.MustCompile("^i8"), tagged)
+		test.S(t).ExpectEquals(filteredHostnames(filtered), "i710,i720,i810")
+	}
+	{
+		filtered := filterTopologyInstances(instances, regexp.MustCompile("^i9"), nil)
+		test.S(t).ExpectEquals(len(filtered), 0)
+	}
+	{
+		// co-masters
+		instancesMap[i710Key.StringCode()].MasterKey = i720Key
+		filtered := filterTopologyInstances(instances, regexp.MustCompile("^i830$"), nil)
+		test.S(t).ExpectEquals(filteredHostnames(filtered), "i710,i720,i820,i830")
+	}
+}
+
+func TestColorizeASCIITopologyEntries(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances[1:] {
+		instance.MasterKey = i710Key
+		instance.ReadBinlogCoordinates = instance.ExecBinlogCoordinates
+		instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+		instance.ReplicationIOThreadState = ReplicationThreadStateRunning
+		instance.IsRecentlyChecked = true
+	}
+	instances[0].IsRecentlyChecked = true
+	instancesMap[i720Key.StringCode()].ReplicationIOThreadState = ReplicationThreadStateStopped
+	instancesMap[i730Key.StringCode()].SecondsBehindMaster.Int64 = int64(config.Config.ReasonableMaintenanceReplicationLagSeconds + 1)
+	instancesMap[i810Key.StringCode()].IsLastCheckValid = false
+	instancesMap[i810Key.StringCode()].IsDowntimed = true
+
+	replicationMap, masterInstance := getTopologyReplicationMap(instances)
+	entries, entryInstances := getASCIITopologyEntry(0, nil, masterInstance, replicationMap, false, asciiFillerCharacter, false)
+	test.S(t).ExpectEquals(len(entries), len(instances))
+	test.S(t).ExpectEquals(len(entryInstances), len(instances))
+
+	entries = colorizeASCIITopologyEntries(entries, entryInstances)
+	for i, instance := range entryInstances {
+		test.S(t).ExpectTrue(strings.Contains(entries[i], instance.Key.DisplayString()))
+		switch instance.Key {
+		case i720Key:
+			test.S(t).ExpectTrue(strings.HasPrefix(entries[i], asciiColorRed))
+		case i730Key:
+			test.S(t).ExpectTrue(strings.HasPrefix(entries[i], asciiColorYellow))
+		case i810Key:
+			test.S(t).ExpectTrue(strings.HasPrefix(entries[i], asciiColorDim))
+		default:
+			test.S(t).ExpectFalse(strings.Contains(entries[i], "\033["))
+		}
+	}
+}
@@ -94,14 +94,19 @@ func (this *binlogFormatSortedByCount) First() string {
 
 // InstancesSorterByExec sorts instances by executed binlog coordinates
 type InstancesSorterByExec struct {
-	instances  [](*Instance)
-	dataCenter string
+	instances             [](*Instance)
+	dataCenterPreference  *PromotionDataCenterPreference
+	semiSyncAcknowledgers *InstanceKeyMap // optional; replicas which acknowledged the master's semi-sync transactions
 }
 
 func NewInstancesSorterByExec(instances [](*Instance), dataCenter string) *InstancesSorterByExec {
+	return NewInstancesSorterByExecDataCenterPreference(instances, &PromotionDataCenterPreference{FailedMasterDataCenter: dataCenter})
+}
+
+func NewInstancesSorterByExecDataCenterPreference(instances [](*Instance), dataCenterPreference *PromotionDataCenterPreference) *InstancesSorterByExec {
 	return &InstancesSorterByExec{
-		instances:  instances,
-		dataCenter: dataCenter,
+		instances:            instances,
+		dataCenterPreference: dataCenterPreference,
 	}
 }
 
@@ -121,38 +126,76 @@ func (this *InstancesSorterByExec) Less(i, j int) bool {
 		return true
 	}
 	if this.instances[i].ExecBinlogCoordinates.Equals(&this.instances[j].ExecBinlogCoordinates) {
-		// Secondary sorting: "smaller" if not logging replica updates
-		if this.instances[j].LogSlaveUpdatesEnabled && !this.instances[i].LogSlaveUpdatesEnabled {
-			return true
-		}
-		// Next sorting: "smaller" if of higher version (this will be reversed eventually)
-		// Idea is that given 5.6 a& 5.7 both of the exact position, we will want to promote
-		// the 5.6 on top of 5.7, as the other way around is invalid
-		if this.instances[j].IsSmallerMajorVersion(this.instances[i]) {
-			return true
-		}
-		// Next sorting: "smaller" if of larger binlog-format (this will be reversed eventually)
-		// Idea is that given ROW & STATEMENT both of the exact position, we will want to promote
-		// the STATEMENT on top of ROW, as the other way around is invalid
-		if this.instances[j].IsSmallerBinlogFormat(this.instances[i]) {
-			return true
-		}
-		// Prefer local datacenter:
-		if this.instances[j].DataCenter == this.dataCenter && this.instances[i].DataCenter != this.dataCenter {
-			return true
-		}
-		// Prefer if not having errant GTID
-		if this.instances[j].GtidErrant == "" && this.instances[i].GtidErrant != "" {
+		if this.isSmallerAmongEquallyUpToDate(this.instances[i], this.instances[j]) {
 			return true
 		}
-		// Prefer candidates:
-		if this.instances[j].PromotionRule.SmallerThan(this.instances[i].PromotionRule) {
-			return true
+		if this.isSmallerAmongEquallyUpToDate(this.instances[j], this.instances[i]) {
+			return false
 		}
+		// Lastly, break ties by key, such that the order of equally preferred instances is deterministic.
+		// The smaller key is preferred.
+		return this.instances[j].Key.SmallerThan(&this.instances[i].Key)
 	}
 	return this.instances[i].ExecBinlogCoordinates.SmallerThan(&this.instances[j].ExecBinlogCoordinates)
 }
 
+// isSmallerAmongEquallyUpToDate returns true when other is a better candidate for promotion than given instance,
+// both being equally up to date. Criteria apply in order: a criterion only decides when the two instances differ by it.
+func (this *InstancesSorterByExec) isSmallerAmongEquallyUpToDate(instance *Instance, other *Instance) bool {
+	// Secondary sorting: "smaller" if not logging replica updates
+	if instance.LogSlaveUpdatesEnabled != other.LogSlaveUpdatesEnabled {
+		return other.LogSlaveUpdatesEnabled
+	}
+	// Next sorting: "smaller" if of higher version (this will be reversed eventually)
+	// Idea is that given 5.6 a& 5.7 both of the exact position, we will want to promote
+	// the 5.6 on top of 5.7, as the other way around is invalid
+	if other.IsSmallerMajorVersion(instance) {
+		return true
+	}
+	if instance.IsSmallerMajorVersion(other) {
+		return false
+	}
+	// Next sorting: "smaller" if of larger binlog-format (this will be reversed eventually)
+	// Idea is that given ROW & STATEMENT both of the exact position, we will want to promote
+	// the STATEMENT on top of ROW, as the other way around is invalid
+	if other.IsSmallerBinlogFormat(instance) {
+		return true
+	}
+	if instance.IsSmallerBinlogFormat(other) {
+		return false
+	}
+	// Prefer replicas which acknowledged semi-sync transactions: they are guaranteed to have the last
+	// committed transactions
+	if this.semiSyncAcknowledgers != nil {
+		instanceAcknowledged := this.semiSyncAcknowledgers.HasKey(instance.Key)
+		otherAcknowledged := this.semiSyncAcknowledgers.HasKey(other.Key)
+		if instanceAcknowledged != otherAcknowledged {
+			return otherAcknowledged
+		}
+	}
+	// Prefer local datacenter, or the datacenter ranked higher by the cluster's promotion data center policy:
+	instanceRank := this.dataCenterPreference.Rank(instance.DataCenter)
+	otherRank := this.dataCenterPreference.Rank(other.DataCenter)
+	if instanceRank != otherRank {
+		return otherRank < instanceRank
+	}
+	// Prefer if not having errant GTID
+	if (instance.GtidErrant == "") != (other.GtidErrant == "") {
+		return other.GtidErrant == ""
+	}
+	// Prefer candidates, and among candidates of the same rule, higher promotion priority:
+	return other.IsPreferredForPromotionOver(instance)
+}
+
+// isEquallyPreferred returns true when neither of given instances is a better candidate for promotion than the
+// other, by all criteria of this sorter but the tie breaking by key
+func (this *InstancesSorterByExec) isEquallyPreferred(instance *Instance, other *Instance) bool {
+	if !instance.ExecBinlogCoordinates.Equals(&other.ExecBinlogCoordinates) {
+		return false
+	}
+	return !this.isSmallerAmongEquallyUpToDate(instance, other) && !this.isSmallerAmongEquallyUpToDate(other, instance)
+}
+
 // filterInstancesByPattern will filter given array of instances according to regular expression pattern
 func filterInstancesByPattern(instances [](*Instance), pattern string) [](*Instance) {
 	if pattern == "" {
@@ -180,6 +223,21 @@ func RemoveInstance(instances [](*Instance), instanceKey *InstanceKey) [](*Insta
 	return instances
 }
 
+// RemoveInstances returns the instances in given list which do not appear in the list of instances to remove
+func RemoveInstances(instances [](*Instance), instancesToRemove [](*Instance)) [](*Instance) {
+	removeKeys := NewInstanceKeyMap()
+	for _, instance := range instancesToRemove {
+		removeKeys.AddKey(instance.Key)
+	}
+	filtered := [](*Instance){}
+	for _, instance := range instances {
+		if !removeKeys.HasKey(instance.Key) {
+			filtered = append(filtered, instance)
+		}
+	}
+	return filtered
+}
+
 // removeBinlogServerInstances will remove all binlog servers from given lsit
 func RemoveBinlogServerInstances(instances [](*Instance)) [](*Instance) {
 	for i := len(instances) - 1; i >= 0; i-- {
@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+type IntermediateMasterRecoveryStrategyType string
+
+const (
+	// AutoIntermediateMasterRecovery tries, in turn: weighted siblings, a candidate sibling in same DC, regrouping,
+	// a candidate sibling in another DC, and matching up below the grandparent
+	AutoIntermediateMasterRecovery IntermediateMasterRecoveryStrategyType = "auto"
+	// MatchUpIntermediateMasterRecovery relocates all replicas below the grandparent
+	MatchUpIntermediateMasterRecovery IntermediateMasterRecoveryStrategyType = "match-up"
+	// RegroupIntermediateMasterRecovery regroups the replicas below the most up to date of them, which then
+	// relocates, with any replicas not regrouped, below the grandparent
+	RegroupIntermediateMasterRecovery IntermediateMasterRecoveryStrategyType = "regroup"
+	// DesignatedIntermediateMasterRecovery relocates all replicas below a designated server
+	DesignatedIntermediateMasterRecovery IntermediateMasterRecoveryStrategyType = "designated"
+)
+
+// IntermediateMasterRecoveryStrategy is how replicas of a dead intermediate master are recovered
+type IntermediateMasterRecoveryStrategy struct {
+	Type          IntermediateMasterRecoveryStrategyType
+	DesignatedKey *InstanceKey
+}
+
+// ParseIntermediateMasterRecoveryStrategy parses a strategy: "auto" (or empty), "match-up", "regroup", or
+// "designated:<host>:<port>"
+func ParseIntermediateMasterRecoveryStrategy(strategy string) (*IntermediateMasterRecoveryStrategy, error) {
+	strategy = strings.TrimSpace(strategy)
+	switch strategyType := IntermediateMasterRecoveryStrategyType(strategy); strategyType {
+	case "", AutoIntermediateMasterRecovery:
+		return &IntermediateMasterRecoveryStrategy{Type: AutoIntermediateMasterRecovery}, nil
+	case MatchUpIntermediateMasterRecovery, RegroupIntermediateMasterRecovery:
+		return &IntermediateMasterRecoveryStrategy{Type: strategyType}, nil
+	}
+	if hostPort := strings.TrimPrefix(strategy, string(DesignatedIntermediateMasterRecovery)+":"); hostPort != strategy {
+		designatedKey, err := ParseRawInstanceKey(hostPort)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid intermediate master recovery strategy %s: %+v", strategy, err)
+		}
+		return &IntermediateMasterRecoveryStrategy{Type: DesignatedIntermediateMasterRecovery, DesignatedKey: designatedKey}, nil
+	}
+	return nil, fmt.Errorf("Unknown intermediate master recovery strategy: %s. Expected auto, match-up, regroup or designated:<host>:<port>", strategy)
+}
+
+// resolveIntermediateMasterRecoveryStrategy returns the configured strategy for given cluster.
+// ClusterIntermediateMasterStrategies, by cluster name then by alias, takes precedence over IntermediateMasterRecoveryStrategy.
+func resolveIntermediateMasterRecoveryStrategy(clusterName string, clusterAlias string) string {
+	if strategy, found := config.Config.ClusterIntermediateMasterStrategies[clusterName]; found {
+		return strategy
+	}
+	if clusterAlias != "" {
+		if strategy, found := config.Config.ClusterIntermediateMasterStrategies[clusterAlias]; found {
+			return strategy
+		}
+	}
+	return config.Config.IntermediateMasterRecoveryStrategy
+}
+
+// GetIntermediateMasterRecoveryStrategy returns the strategy by which dead intermediate masters of given cluster
+// are recovered
+func GetIntermediateMasterRecoveryStrategy(clusterName string, clusterAlias string) (*IntermediateMasterRecoveryStrategy, error) {
+	return ParseIntermediateMasterRecoveryStrategy(resolveIntermediateMasterRecoveryStrategy(clusterName, clusterAlias))
+}
@@ -0,0 +1,53 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseIntermediateMasterRecoveryStrategy(t *testing.T) {
+	{
+		strategy, err := ParseIntermediateMasterRecoveryStrategy("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(strategy.Type, AutoIntermediateMasterRecovery)
+	}
+	{
+		strategy, err := ParseIntermediateMasterRecoveryStrategy("regroup")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(strategy.Type, RegroupIntermediateMasterRecovery)
+		test.S(t).ExpectTrue(strategy.DesignatedKey == nil)
+	}
+	{
+		strategy, err := ParseIntermediateMasterRecoveryStrategy("designated:relay.example.com:3307")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(strategy.Type, DesignatedIntermediateMasterRecovery)
+		test.S(t).ExpectEquals(strategy.DesignatedKey.Hostname, "relay.example.com")
+		test.S(t).ExpectEquals(strategy.DesignatedKey.Port, 3307)
+	}
+	{
+		_, err := ParseIntermediateMasterRecoveryStrategy("designated:")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseIntermediateMasterRecoveryStrategy("promote-sibling")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestResolveIntermediateMasterRecoveryStrategy(t *testing.T) {
+	defer func(strategy string) { config.Config.IntermediateMasterRecoveryStrategy = strategy }(config.Config.IntermediateMasterRecoveryStrategy)
+	defer func(strategies map[string]string) {
+		config.Config.ClusterIntermediateMasterStrategies = strategies
+	}(config.Config.ClusterIntermediateMasterStrategies)
+
+	config.Config.IntermediateMasterRecoveryStrategy = "match-up"
+	config.Config.ClusterIntermediateMasterStrategies = map[string]string{
+		"relayed":        "designated:relay:3306",
+		"regrouped:3306": "regroup",
+	}
+	test.S(t).ExpectEquals(resolveIntermediateMasterRecoveryStrategy("other:3306", ""), "match-up")
+	test.S(t).ExpectEquals(resolveIntermediateMasterRecoveryStrategy("other:3306", "relayed"), "designated:relay:3306")
+	test.S(t).ExpectEquals(resolveIntermediateMasterRecoveryStrategy("regrouped:3306", "relayed"), "regroup")
+}
@@ -0,0 +1,161 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// inventoryTimeout is the timeout of reading the external inventory
+const inventoryTimeout = 30 * time.Second
+
+// InventoryEntry is a MySQL server as listed by the external inventory
+type InventoryEntry struct {
+	Hostname       string
+	Port           int
+	Cluster        string
+	Decommissioned bool
+}
+
+// inventoryKnownInstance is an instance known to orchestrator, as reconciled against the inventory
+type inventoryKnownInstance struct {
+	Key          InstanceKey
+	ClusterName  string
+	ClusterAlias string
+}
+
+// InventoryClusterReconciliation lists the discrepancies between the inventory and orchestrator on a cluster
+type InventoryClusterReconciliation struct {
+	Cluster string
+	// Undiscovered are listed by the inventory, but were never discovered: the discovery chain is broken
+	Undiscovered []InstanceKey
+	// Decommissioned are known to orchestrator, though the inventory says they are decommissioned
+	Decommissioned []InstanceKey
+}
+
+// InventoryReconciliation is the outcome of reconciling known instances against the inventory
+type InventoryReconciliation struct {
+	ReconciledAt time.Time
+	Clusters     []*InventoryClusterReconciliation
+}
+
+// CountDiscrepancies returns the number of undiscovered and decommissioned instances over all clusters
+func (this *InventoryReconciliation) CountDiscrepancies() (undiscovered int, decommissioned int) {
+	for _, cluster := range this.Clusters {
+		undiscovered += len(cluster.Undiscovered)
+		decommissioned += len(cluster.Decommissioned)
+	}
+	return undiscovered, decommissioned
+}
+
+// FilterCluster returns the reconciliation of given cluster only, as named by the reconciliation
+func (this *InventoryReconciliation) FilterCluster(cluster string) *InventoryReconciliation {
+	filtered := &InventoryReconciliation{ReconciledAt: this.ReconciledAt, Clusters: []*InventoryClusterReconciliation{}}
+	for _, clusterReconciliation := range this.Clusters {
+		if clusterReconciliation.Cluster == cluster {
+			filtered.Clusters = append(filtered.Clusters, clusterReconciliation)
+		}
+	}
+	return filtered
+}
+
+// inventoryEntryKey returns the instance key of an inventory entry, defaulting to DefaultInstancePort
+func inventoryEntryKey(entry InventoryEntry) InstanceKey {
+	key := InstanceKey{Hostname: strings.ToLower(strings.TrimSpace(entry.Hostname)), Port: entry.Port}
+	if key.Port == 0 {
+		key.Port = config.Config.DefaultInstancePort
+	}
+	return key
+}
+
+// parseInventory parses the JSON list of inventory entries
+func parseInventory(body []byte) (entries []InventoryEntry, err error) {
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return entries, fmt.Errorf("cannot parse inventory: %+v", err)
+	}
+	return entries, nil
+}
+
+// ReadInventory reads the external inventory from InventoryURL
+func ReadInventory() ([]InventoryEntry, error) {
+	if config.Config.InventoryURL == "" {
+		return nil, fmt.Errorf("InventoryURL is not configured")
+	}
+	client := &http.Client{Timeout: inventoryTimeout}
+	response, err := client.Get(config.Config.InventoryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("inventory responded with status %d", response.StatusCode)
+	}
+	return parseInventory(body)
+}
+
+// reconcileInventory compares the inventory with the instances known to orchestrator. An inventory entry not
+// decommissioned and not known is undiscovered; a known instance decommissioned by the inventory is decommissioned.
+// Instances the inventory does not list at all are of no concern, as the inventory may well cover part of the
+// topologies. Undiscovered instances are reported by their inventory cluster, decommissioned ones by their cluster
+// alias.
+func reconcileInventory(entries []InventoryEntry, knownInstances []inventoryKnownInstance) []*InventoryClusterReconciliation {
+	known := make(map[InstanceKey]inventoryKnownInstance)
+	for _, knownInstance := range knownInstances {
+		known[InstanceKey{Hostname: strings.ToLower(knownInstance.Key.Hostname), Port: knownInstance.Key.Port}] = knownInstance
+	}
+	clusters := make(map[string]*InventoryClusterReconciliation)
+	getCluster := func(cluster string) *InventoryClusterReconciliation {
+		if _, found := clusters[cluster]; !found {
+			clusters[cluster] = &InventoryClusterReconciliation{Cluster: cluster, Undiscovered: []InstanceKey{}, Decommissioned: []InstanceKey{}}
+		}
+		return clusters[cluster]
+	}
+	for _, entry := range entries {
+		key := inventoryEntryKey(entry)
+		knownInstance, isKnown := known[key]
+		switch {
+		case !entry.Decommissioned && !isKnown:
+			cluster := getCluster(entry.Cluster)
+			cluster.Undiscovered = append(cluster.Undiscovered, key)
+		case entry.Decommissioned && isKnown:
+			clusterAlias := knownInstance.ClusterAlias
+			if clusterAlias == "" {
+				clusterAlias = knownInstance.ClusterName
+			}
+			cluster := getCluster(clusterAlias)
+			cluster.Decommissioned = append(cluster.Decommissioned, knownInstance.Key)
+		}
+	}
+	reconciliations := []*InventoryClusterReconciliation{}
+	for _, cluster := range clusters {
+		reconciliations = append(reconciliations, cluster)
+	}
+	sort.Slice(reconciliations, func(i, j int) bool { return reconciliations[i].Cluster < reconciliations[j].Cluster })
+	return reconciliations
+}
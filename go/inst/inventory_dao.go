@@ -0,0 +1,102 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+var inventoryUndiscoveredGauge = metrics.NewGauge()
+var inventoryDecommissionedGauge = metrics.NewGauge()
+
+var lastInventoryReconciliation *InventoryReconciliation
+var lastInventoryReconciliationMutex sync.Mutex
+
+func init() {
+	metrics.Register("inventory.undiscovered", inventoryUndiscoveredGauge)
+	metrics.Register("inventory.decommissioned", inventoryDecommissionedGauge)
+}
+
+// readInventoryKnownInstances reads all instances known to orchestrator, along with their clusters
+func readInventoryKnownInstances() ([]inventoryKnownInstance, error) {
+	res := []inventoryKnownInstance{}
+	query := `
+		select
+			database_instance.hostname,
+			database_instance.port,
+			database_instance.cluster_name,
+			ifnull(cluster_alias.alias, '') as cluster_alias
+		from
+			database_instance
+			left join cluster_alias on (cluster_alias.cluster_name = database_instance.cluster_name)
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		knownInstance := inventoryKnownInstance{
+			Key:          InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			ClusterName:  m.GetString("cluster_name"),
+			ClusterAlias: m.GetString("cluster_alias"),
+		}
+		res = append(res, knownInstance)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReconcileInventory reconciles the instances known to orchestrator against the external inventory. Discrepancies
+// are audited per cluster and counted by the inventory.undiscovered and inventory.decommissioned metrics.
+func ReconcileInventory() (*InventoryReconciliation, error) {
+	entries, err := ReadInventory()
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	knownInstances, err := readInventoryKnownInstances()
+	if err != nil {
+		return nil, err
+	}
+	reconciliation := &InventoryReconciliation{
+		ReconciledAt: time.Now(),
+		Clusters:     reconcileInventory(entries, knownInstances),
+	}
+	for _, cluster := range reconciliation.Clusters {
+		message := fmt.Sprintf("cluster %s: undiscovered: %d %+v; decommissioned: %d %+v", cluster.Cluster, len(cluster.Undiscovered), cluster.Undiscovered, len(cluster.Decommissioned), cluster.Decommissioned)
+		AuditOperation("inventory-reconciliation", nil, message)
+	}
+	undiscovered, decommissioned := reconciliation.CountDiscrepancies()
+	inventoryUndiscoveredGauge.Update(int64(undiscovered))
+	inventoryDecommissionedGauge.Update(int64(decommissioned))
+
+	lastInventoryReconciliationMutex.Lock()
+	defer lastInventoryReconciliationMutex.Unlock()
+	lastInventoryReconciliation = reconciliation
+	return reconciliation, nil
+}
+
+// ReadLastInventoryReconciliation returns the outcome of the latest reconciliation run by this node, if any
+func ReadLastInventoryReconciliation() *InventoryReconciliation {
+	lastInventoryReconciliationMutex.Lock()
+	defer lastInventoryReconciliationMutex.Unlock()
+	return lastInventoryReconciliation
+}
@@ -0,0 +1,57 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseInventory(t *testing.T) {
+	entries, err := parseInventory([]byte(`[{"hostname": "db-1", "port": 3306, "cluster": "main"}, {"Hostname": "db-2", "Decommissioned": true}]`))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(entries), 2)
+	test.S(t).ExpectEquals(entries[0].Cluster, "main")
+	test.S(t).ExpectTrue(entries[1].Decommissioned)
+	test.S(t).ExpectEquals(inventoryEntryKey(entries[1]), InstanceKey{Hostname: "db-2", Port: 3306})
+
+	_, err = parseInventory([]byte(`{"hostname": "db-1"}`))
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestReconcileInventory(t *testing.T) {
+	entries := []InventoryEntry{
+		{Hostname: "db-1", Port: 3306, Cluster: "main"},
+		{Hostname: "DB-2", Port: 3306, Cluster: "main"},
+		{Hostname: "db-3", Port: 3306, Cluster: "main"},
+		{Hostname: "db-4", Port: 3306, Cluster: "main", Decommissioned: true},
+		{Hostname: "db-5", Port: 3306, Cluster: "main", Decommissioned: true},
+		{Hostname: "db-6", Port: 3306, Cluster: "analytics"},
+	}
+	knownInstances := []inventoryKnownInstance{
+		{Key: InstanceKey{Hostname: "db-1", Port: 3306}, ClusterName: "db-1:3306", ClusterAlias: "main"},
+		{Key: InstanceKey{Hostname: "db-2", Port: 3306}, ClusterName: "db-1:3306", ClusterAlias: "main"},
+		{Key: InstanceKey{Hostname: "db-4", Port: 3306}, ClusterName: "db-1:3306"},
+		{Key: InstanceKey{Hostname: "db-7", Port: 3306}, ClusterName: "db-7:3306"},
+	}
+	reconciliations := reconcileInventory(entries, knownInstances)
+	test.S(t).ExpectEquals(len(reconciliations), 3)
+
+	test.S(t).ExpectEquals(reconciliations[0].Cluster, "analytics")
+	test.S(t).ExpectEquals(len(reconciliations[0].Undiscovered), 1)
+	test.S(t).ExpectEquals(reconciliations[0].Undiscovered[0], InstanceKey{Hostname: "db-6", Port: 3306})
+
+	test.S(t).ExpectEquals(reconciliations[1].Cluster, "db-1:3306")
+	test.S(t).ExpectEquals(len(reconciliations[1].Undiscovered), 0)
+	test.S(t).ExpectEquals(len(reconciliations[1].Decommissioned), 1)
+	test.S(t).ExpectEquals(reconciliations[1].Decommissioned[0], InstanceKey{Hostname: "db-4", Port: 3306})
+
+	test.S(t).ExpectEquals(reconciliations[2].Cluster, "main")
+	test.S(t).ExpectEquals(len(reconciliations[2].Undiscovered), 1)
+	test.S(t).ExpectEquals(reconciliations[2].Undiscovered[0], InstanceKey{Hostname: "db-3", Port: 3306})
+
+	reconciliation := &InventoryReconciliation{Clusters: reconciliations}
+	undiscovered, decommissioned := reconciliation.CountDiscrepancies()
+	test.S(t).ExpectEquals(undiscovered, 2)
+	test.S(t).ExpectEquals(decommissioned, 1)
+	test.S(t).ExpectEquals(len(reconciliation.FilterCluster("main").Clusters), 1)
+}
@@ -17,6 +17,10 @@
 package inst
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/github/orchestrator/go/config"
 )
 
@@ -43,3 +47,49 @@ func GetMaintenanceOwner() string {
 func SetMaintenanceOwner(owner string) {
 	maintenanceOwner = owner
 }
+
+// OperationAttribution is the acting user and the reason an operation is run by and for, as given by the API
+// request or command line invocation requesting it
+type OperationAttribution struct {
+	Owner  string
+	Reason string
+}
+
+type operationAttributionContextKey struct{}
+
+// WithOperationAttribution returns a context by which operations are attributed to given acting user and reason
+func WithOperationAttribution(ctx context.Context, owner string, reason string) context.Context {
+	return context.WithValue(ctx, operationAttributionContextKey{}, &OperationAttribution{Owner: owner, Reason: reason})
+}
+
+// operationAttributionFromContext returns the attribution of given context; or else, the maintenance owner,
+// with no reason
+func operationAttributionFromContext(ctx context.Context) *OperationAttribution {
+	if attribution, ok := ctx.Value(operationAttributionContextKey{}).(*OperationAttribution); ok && attribution != nil {
+		return attribution
+	}
+	return &OperationAttribution{Owner: GetMaintenanceOwner()}
+}
+
+// maintenanceReason returns given maintenance reason, along with the reason for the operation, if any
+func (this *OperationAttribution) maintenanceReason(reason string) string {
+	if this.Reason == "" || this.Reason == reason {
+		return reason
+	}
+	return fmt.Sprintf("%s (%s)", reason, this.Reason)
+}
+
+// ValidateOperationAttribution makes sure a mutating operation is attributable, i.e. has an acting user and a reason,
+// if so configured by RequireOperationReason
+func ValidateOperationAttribution(owner string, reason string) error {
+	if !config.Config.RequireOperationReason {
+		return nil
+	}
+	if strings.TrimSpace(owner) == "" {
+		return fmt.Errorf("RequireOperationReason is set, yet no acting user is given for this operation")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("RequireOperationReason is set, yet no reason is given for this operation")
+	}
+	return nil
+}
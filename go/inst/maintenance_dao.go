@@ -17,6 +17,7 @@
 package inst
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/github/orchestrator/go/config"
@@ -113,6 +114,13 @@ func BeginMaintenance(instanceKey *InstanceKey, owner string, reason string) (in
 	return BeginBoundedMaintenance(instanceKey, owner, reason, 0, false)
 }
 
+// beginOperationMaintenance begins maintenance for given instance, for the duration of an operation, attributed as
+// per given context
+func beginOperationMaintenance(ctx context.Context, instanceKey *InstanceKey, reason string) (int64, error) {
+	attribution := operationAttributionFromContext(ctx)
+	return BeginMaintenance(instanceKey, attribution.Owner, attribution.maintenanceReason(reason))
+}
+
 // EndMaintenanceByInstanceKey will terminate an active maintenance using given instanceKey as hint
 func EndMaintenanceByInstanceKey(instanceKey *InstanceKey) (wasMaintenance bool, err error) {
 	res, err := db.ExecOrchestrator(`
@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/openark/golib/log"
+)
+
+// SetMasterDelay sets MASTER_DELAY on given replica, making it an intentionally delayed replica. A zero
+// delay clears it. The SQL thread is stopped as required by CHANGE MASTER TO, and restarted if it was running.
+func SetMasterDelay(instanceKey *InstanceKey, delaySeconds uint) (*Instance, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	if !instance.IsReplica() {
+		return instance, fmt.Errorf("instance is not a replica: %+v", *instanceKey)
+	}
+	if *config.RuntimeCLIFlags.Noop {
+		return instance, fmt.Errorf("noop: aborting CHANGE MASTER TO MASTER_DELAY=%d operation on %+v; signaling error but nothing went wrong.", delaySeconds, *instanceKey)
+	}
+	sqlThreadWasRunning := instance.ReplicationSQLThreadState.IsRunning()
+	if sqlThreadWasRunning {
+		if _, err := ExecInstance(instanceKey, `stop slave sql_thread`); err != nil {
+			return instance, log.Errore(err)
+		}
+	}
+	_, err = ExecInstance(instanceKey, `change master to master_delay=?`, delaySeconds)
+	if sqlThreadWasRunning {
+		if _, startErr := ExecInstance(instanceKey, `start slave sql_thread`); startErr != nil && err == nil {
+			err = startErr
+		}
+	}
+	if err != nil {
+		return instance, log.Errore(err)
+	}
+	log.Infof("SetMasterDelay: %+v now delayed by %d seconds (was: %d)", *instanceKey, delaySeconds, instance.SQLDelay)
+	AuditOperation("set-master-delay", instanceKey, fmt.Sprintf("MASTER_DELAY=%d (was: %d)", delaySeconds, instance.SQLDelay))
+
+	return ReadTopologyInstance(instanceKey)
+}
+
+// ClearMasterDelay removes MASTER_DELAY from given replica
+func ClearMasterDelay(instanceKey *InstanceKey) (*Instance, error) {
+	return SetMasterDelay(instanceKey, 0)
+}
+
+// removeMasterDelayForPromotion clears the delay of an intentionally delayed replica which is about to be
+// promoted, and has it apply its relay logs, up to given timeout. Replication is stopped on return.
+func removeMasterDelayForPromotion(instanceKey *InstanceKey, timeout time.Duration) (*Instance, error) {
+	instance, err := ClearMasterDelay(instanceKey)
+	if err != nil {
+		return instance, err
+	}
+	if _, err := ExecInstance(instanceKey, `start slave sql_thread`); err != nil {
+		return instance, log.Errore(err)
+	}
+	message := "delay removed, relay logs applied"
+	if _, err := WaitForSQLThreadUpToDate(instanceKey, timeout, 0); err != nil {
+		log.Warningf("removeMasterDelayForPromotion: %+v has not applied all of its relay logs: %+v", *instanceKey, err)
+		message = fmt.Sprintf("delay removed, relay logs not fully applied: %+v", err)
+	}
+	AuditOperation("remove-master-delay-for-promotion", instanceKey, message)
+	return StopSlave(instanceKey)
+}
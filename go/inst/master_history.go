@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/github/orchestrator/go/tracing"
+	"github.com/github/orchestrator/go/util"
+)
+
+type MasterChangeCause string
+
+const (
+	MasterChangeCausePlanned  MasterChangeCause = "planned"
+	MasterChangeCauseRecovery                   = "recovery"
+)
+
+// MasterChangeOrigin tells who changed an instance's master, and as part of what
+type MasterChangeOrigin struct {
+	Cause         MasterChangeCause
+	Owner         string
+	CorrelationID string
+}
+
+// NewMasterChangeOrigin returns an origin for master changes. With an empty correlation ID, a unique one
+// is generated, such that all master changes by the same operation may be correlated.
+func NewMasterChangeOrigin(cause MasterChangeCause, owner string, correlationID string) *MasterChangeOrigin {
+	if correlationID == "" {
+		correlationID = util.PrettyUniqueToken()
+	}
+	return &MasterChangeOrigin{Cause: cause, Owner: owner, CorrelationID: correlationID}
+}
+
+type masterChangeOriginContextKey struct{}
+
+// WithMasterChangeOrigin returns a context by which master changes are attributed to given origin
+func WithMasterChangeOrigin(ctx context.Context, origin *MasterChangeOrigin) context.Context {
+	return context.WithValue(ctx, masterChangeOriginContextKey{}, origin)
+}
+
+// masterChangeOriginFromContext returns the origin attributed by given context; or else, a planned change
+// by the maintenance owner
+func masterChangeOriginFromContext(ctx context.Context) *MasterChangeOrigin {
+	if origin, ok := ctx.Value(masterChangeOriginContextKey{}).(*MasterChangeOrigin); ok && origin != nil {
+		return origin
+	}
+	return &MasterChangeOrigin{Cause: MasterChangeCausePlanned, Owner: GetMaintenanceOwner()}
+}
+
+// detachedOperationContext returns a context which is not bound to given context's cancellation or deadline,
+// but which carries its master change origin, attribution, IP pins, WAN relocation permission and tracing span. It is used for
+// postponed functions, which outlive their invoker.
+func detachedOperationContext(ctx context.Context) context.Context {
+	detached := WithMasterChangeOrigin(context.Background(), masterChangeOriginFromContext(ctx))
+	detached = context.WithValue(detached, operationAttributionContextKey{}, operationAttributionFromContext(ctx))
+	if pins := operationIPPinsFromContext(ctx); pins != nil {
+		detached = context.WithValue(detached, operationIPPinsContextKey{}, pins)
+	}
+	if permission := wanRelocationPermissionFromContext(ctx); permission != 0 {
+		detached = context.WithValue(detached, wanRelocationContextKey{}, permission)
+	}
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		detached = tracing.ContextWithSpan(detached, span)
+	}
+	return detached
+}
+
+// MasterChange is a single, recorded change of an instance's master
+type MasterChange struct {
+	Key               InstanceKey
+	MasterKey         InstanceKey
+	PreviousMasterKey InstanceKey
+	Coordinates       BinlogCoordinates
+	Method            string
+	Cause             MasterChangeCause
+	Owner             string
+	CorrelationID     string
+	ChangedTimestamp  string
+}
+
+func NewMasterChange(instanceKey, masterKey, previousMasterKey *InstanceKey, coordinates *BinlogCoordinates, method string, origin *MasterChangeOrigin) *MasterChange {
+	change := &MasterChange{
+		Key:               *instanceKey,
+		MasterKey:         *masterKey,
+		PreviousMasterKey: *previousMasterKey,
+		Method:            method,
+		Cause:             origin.Cause,
+		Owner:             origin.Owner,
+		CorrelationID:     origin.CorrelationID,
+	}
+	if coordinates != nil {
+		change.Coordinates = *coordinates
+	}
+	return change
+}
+
+func (this *MasterChange) String() string {
+	return fmt.Sprintf("%s %s: %s -> %s via %s (%s by %s %s)",
+		this.ChangedTimestamp, this.Key.DisplayString(), this.PreviousMasterKey.DisplayString(), this.MasterKey.DisplayString(),
+		this.Method, this.Cause, this.Owner, this.CorrelationID)
+}
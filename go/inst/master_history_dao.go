@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteMasterChange records a change of an instance's master
+func WriteMasterChange(change *MasterChange) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into database_instance_master_history (
+				hostname, port, master_host, master_port, previous_master_host, previous_master_port,
+				master_log_file, master_log_pos, method, cause, owner, correlation_id, changed_timestamp
+			) values (
+				?, ?, ?, ?, ?, ?,
+				?, ?, ?, ?, ?, ?, NOW()
+			)
+			`, change.Key.Hostname, change.Key.Port, change.MasterKey.Hostname, change.MasterKey.Port,
+			change.PreviousMasterKey.Hostname, change.PreviousMasterKey.Port,
+			change.Coordinates.LogFile, change.Coordinates.LogPos,
+			change.Method, string(change.Cause), change.Owner, change.CorrelationID,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadMasterHistory reads the recorded master changes of given instance, oldest first
+func ReadMasterHistory(instanceKey *InstanceKey) ([]*MasterChange, error) {
+	res := []*MasterChange{}
+	query := `
+		select
+			hostname,
+			port,
+			master_host,
+			master_port,
+			previous_master_host,
+			previous_master_port,
+			master_log_file,
+			master_log_pos,
+			method,
+			cause,
+			owner,
+			correlation_id,
+			changed_timestamp
+		from
+			database_instance_master_history
+		where
+			hostname = ?
+			and port = ?
+		order by
+			history_id
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(instanceKey.Hostname, instanceKey.Port), func(m sqlutils.RowMap) error {
+		change := &MasterChange{}
+		change.Key.Hostname = m.GetString("hostname")
+		change.Key.Port = m.GetInt("port")
+		change.MasterKey.Hostname = m.GetString("master_host")
+		change.MasterKey.Port = m.GetInt("master_port")
+		change.PreviousMasterKey.Hostname = m.GetString("previous_master_host")
+		change.PreviousMasterKey.Port = m.GetInt("previous_master_port")
+		change.Coordinates.LogFile = m.GetString("master_log_file")
+		change.Coordinates.LogPos = m.GetInt64("master_log_pos")
+		change.Method = m.GetString("method")
+		change.Cause = MasterChangeCause(m.GetString("cause"))
+		change.Owner = m.GetString("owner")
+		change.CorrelationID = m.GetString("correlation_id")
+		change.ChangedTimestamp = m.GetString("changed_timestamp")
+
+		res = append(res, change)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ExpireMasterHistory removes old master changes
+func ExpireMasterHistory() error {
+	return ExpireTableData("database_instance_master_history", "changed_timestamp")
+}
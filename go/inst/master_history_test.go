@@ -0,0 +1,50 @@
+package inst
+
+import (
+	"context"
+	"testing"
+
+	"github.com/github/orchestrator/go/tracing"
+	test "github.com/openark/golib/tests"
+)
+
+func TestMasterChangeOriginFromContext(t *testing.T) {
+	{
+		origin := masterChangeOriginFromContext(context.Background())
+		test.S(t).ExpectEquals(origin.Cause, MasterChangeCausePlanned)
+		test.S(t).ExpectEquals(origin.Owner, GetMaintenanceOwner())
+		test.S(t).ExpectEquals(origin.CorrelationID, "")
+	}
+	{
+		ctx := WithMasterChangeOrigin(context.Background(), NewMasterChangeOrigin(MasterChangeCauseRecovery, "orchestrator", "recovery-uid"))
+		origin := masterChangeOriginFromContext(ctx)
+		test.S(t).ExpectEquals(origin.Cause, MasterChangeCause(MasterChangeCauseRecovery))
+		test.S(t).ExpectEquals(origin.CorrelationID, "recovery-uid")
+	}
+	{
+		origin := NewMasterChangeOrigin(MasterChangeCausePlanned, "someone", "")
+		test.S(t).ExpectTrue(origin.CorrelationID != "")
+	}
+}
+
+func TestDetachedOperationContext(t *testing.T) {
+	span := &tracing.Span{Name: "relocate-replicas"}
+	ctx, cancel := context.WithCancel(WithMasterChangeOrigin(context.Background(), NewMasterChangeOrigin(MasterChangeCauseRecovery, "orchestrator", "recovery-uid")))
+	ctx = tracing.ContextWithSpan(ctx, span)
+	cancel()
+	detached := detachedOperationContext(ctx)
+	test.S(t).ExpectNil(detached.Err())
+	test.S(t).ExpectEquals(masterChangeOriginFromContext(detached).CorrelationID, "recovery-uid")
+	test.S(t).ExpectTrue(tracing.SpanFromContext(detached) == span)
+}
+
+func TestNewMasterChange(t *testing.T) {
+	origin := NewMasterChangeOrigin(MasterChangeCausePlanned, "someone", "intent-uid")
+	change := NewMasterChange(&i720Key, &i710Key, &i730Key, &BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 4}, "binlog-file-pos", origin)
+	test.S(t).ExpectTrue(change.Key.Equals(&i720Key))
+	test.S(t).ExpectTrue(change.MasterKey.Equals(&i710Key))
+	test.S(t).ExpectTrue(change.PreviousMasterKey.Equals(&i730Key))
+	test.S(t).ExpectEquals(change.Coordinates.LogPos, int64(4))
+	test.S(t).ExpectEquals(change.Owner, "someone")
+	test.S(t).ExpectEquals(change.CorrelationID, "intent-uid")
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// NamespaceSummary lists the clusters and instances of an environment namespace
+type NamespaceSummary struct {
+	Namespace      string
+	CountClusters  uint
+	CountInstances uint
+	HasPolicy      bool
+}
+
+// namespacePolicy returns the configured policy of given environment namespace, if any
+func namespacePolicy(namespace string) (policy config.NamespacePolicy, found bool) {
+	if namespace == "" {
+		return policy, false
+	}
+	policy, found = config.Config.NamespacePolicies[namespace]
+	return policy, found
+}
+
+// IsNamespaceOperator returns true when given user may operate on instances of given environment namespace, as per
+// the namespace's PowerAuthUsers
+func IsNamespaceOperator(namespace string, user string) bool {
+	policy, found := namespacePolicy(namespace)
+	if !found || len(policy.PowerAuthUsers) == 0 {
+		return true
+	}
+	for _, powerAuthUser := range policy.PowerAuthUsers {
+		if powerAuthUser == "*" || powerAuthUser == user {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadClusterNamespace reads the environment namespace of given cluster, which is that of its instances
+func ReadClusterNamespace(clusterName string) (namespace string, err error) {
+	query := `
+		select
+			ifnull(max(namespace), '') as namespace
+		from
+			database_instance
+		where
+			cluster_name = ?
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		namespace = m.GetString("namespace")
+		return nil
+	})
+	return namespace, log.Errore(err)
+}
+
+// ReadNamespaces reads the environment namespaces of all known instances, along with their cluster and instance counts
+func ReadNamespaces() ([]NamespaceSummary, error) {
+	res := []NamespaceSummary{}
+	query := `
+		select
+			namespace,
+			count(distinct cluster_name) as count_clusters,
+			count(*) as count_instances
+		from
+			database_instance
+		group by
+			namespace
+		order by
+			namespace
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		summary := NamespaceSummary{
+			Namespace:      m.GetString("namespace"),
+			CountClusters:  m.GetUint("count_clusters"),
+			CountInstances: m.GetUint("count_instances"),
+		}
+		_, summary.HasPolicy = namespacePolicy(summary.Namespace)
+		res = append(res, summary)
+		return nil
+	})
+	return res, log.Errore(err)
+}
@@ -0,0 +1,47 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestIsNamespaceOperator(t *testing.T) {
+	defer func(policies config.NamespacePolicies) { config.Config.NamespacePolicies = policies }(config.Config.NamespacePolicies)
+	config.Config.NamespacePolicies = config.NamespacePolicies{
+		"prod":    {PowerAuthUsers: []string{"dba"}},
+		"staging": {PowerAuthUsers: []string{"*"}},
+		"dev":     {},
+	}
+	test.S(t).ExpectTrue(IsNamespaceOperator("prod", "dba"))
+	test.S(t).ExpectFalse(IsNamespaceOperator("prod", "developer"))
+	test.S(t).ExpectTrue(IsNamespaceOperator("staging", "developer"))
+	test.S(t).ExpectTrue(IsNamespaceOperator("dev", "developer"))
+	test.S(t).ExpectTrue(IsNamespaceOperator("unknown", "developer"))
+	test.S(t).ExpectTrue(IsNamespaceOperator("", "developer"))
+}
+
+func TestReadRecoveryInfoNamespacePolicy(t *testing.T) {
+	defer func(policies config.NamespacePolicies, filters []string) {
+		config.Config.NamespacePolicies = policies
+		config.Config.RecoverMasterClusterFilters = filters
+	}(config.Config.NamespacePolicies, config.Config.RecoverMasterClusterFilters)
+	config.Config.RecoverMasterClusterFilters = []string{}
+	config.Config.NamespacePolicies = config.NamespacePolicies{
+		"staging": {RecoverMasterClusterFilters: []string{"*"}},
+		"prod":    {PowerAuthUsers: []string{"dba"}},
+	}
+
+	clusterInfo := &ClusterInfo{ClusterName: "db-1:3306", Namespace: "staging"}
+	clusterInfo.ReadRecoveryInfo()
+	test.S(t).ExpectTrue(clusterInfo.HasAutomatedMasterRecovery)
+
+	clusterInfo = &ClusterInfo{ClusterName: "db-2:3306", Namespace: "prod"}
+	clusterInfo.ReadRecoveryInfo()
+	test.S(t).ExpectFalse(clusterInfo.HasAutomatedMasterRecovery)
+
+	clusterInfo = &ClusterInfo{ClusterName: "db-3:3306"}
+	clusterInfo.ReadRecoveryInfo()
+	test.S(t).ExpectFalse(clusterInfo.HasAutomatedMasterRecovery)
+}
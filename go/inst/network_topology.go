@@ -0,0 +1,153 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+)
+
+// maxWANChainDepth limits how far up the topology we walk when counting WAN hops
+const maxWANChainDepth = 32
+
+// WANLinkCost returns the declared cost of the WAN link between two data centers, and whether
+// the two are separated by a WAN link in the first place. Links are symmetric.
+func WANLinkCost(dataCenter string, otherDataCenter string) (cost int, isWAN bool) {
+	if dataCenter == "" || otherDataCenter == "" || dataCenter == otherDataCenter {
+		return 0, false
+	}
+	if links, ok := config.Config.WANLinks[dataCenter]; ok {
+		if cost, ok := links[otherDataCenter]; ok {
+			return cost, true
+		}
+	}
+	if links, ok := config.Config.WANLinks[otherDataCenter]; ok {
+		if cost, ok := links[dataCenter]; ok {
+			return cost, true
+		}
+	}
+	return 0, false
+}
+
+// replicationEdgeWANCost returns the WAN link cost of replicating from given master, and whether
+// such replication crosses a WAN link
+func (this *Instance) replicationEdgeWANCost(master *Instance) (cost int, isWAN bool) {
+	if master == nil {
+		return 0, false
+	}
+	return WANLinkCost(this.DataCenter, master.DataCenter)
+}
+
+// countUpstreamWANHops counts the WAN-crossing replication edges on the path from given
+// instance up to its topology's master
+func countUpstreamWANHops(instance *Instance) (hops int, err error) {
+	visited := make(map[InstanceKey]bool)
+	for depth := 0; depth < maxWANChainDepth && instance.IsReplica(); depth++ {
+		if visited[instance.Key] {
+			// co-masters or otherwise circular
+			break
+		}
+		visited[instance.Key] = true
+		master, found, err := ReadInstance(&instance.MasterKey)
+		if err != nil {
+			return hops, err
+		}
+		if !found {
+			break
+		}
+		if _, isWAN := instance.replicationEdgeWANCost(master); isWAN {
+			hops++
+		}
+		instance = master
+	}
+	return hops, nil
+}
+
+// CheckWANRelocation tests whether relocating given instance below other would create a new WAN-crossing
+// replication edge, or extend a chain of such edges. A warning is logged and audited in such case.
+// When RequireWANRelocationConfirmation is set, such relocation is refused unless allowWAN is given.
+func CheckWANRelocation(instance, other *Instance, allowWAN bool) error {
+	cost, isWAN := instance.replicationEdgeWANCost(other)
+	if !isWAN {
+		return nil
+	}
+	if instance.IsReplica() {
+		if currentMaster, found, _ := ReadInstance(&instance.MasterKey); found && currentMaster.DataCenter == other.DataCenter {
+			// instance already replicates across this very link
+			return nil
+		}
+	}
+	upstreamHops, err := countUpstreamWANHops(other)
+	if err != nil {
+		return log.Errore(err)
+	}
+	message := fmt.Sprintf("relocating %+v (%s) below %+v (%s) creates a new WAN-crossing replication edge (cost: %d)", instance.Key, instance.DataCenter, other.Key, other.DataCenter, cost)
+	if upstreamHops > 0 {
+		message = fmt.Sprintf("%s, making for a chain of %d WAN hops", message, upstreamHops+1)
+	}
+	if config.Config.RequireWANRelocationConfirmation && !allowWAN {
+		return fmt.Errorf("%s; explicit confirmation required", message)
+	}
+	log.Warningf("%s", message)
+	AuditOperation("wan-relocation", &instance.Key, message)
+	return nil
+}
+
+type wanRelocationContextKey struct{}
+
+type wanRelocationPermission int
+
+const (
+	// wanRelocationAllowed: WAN-crossing master changes are warned of, but not refused
+	wanRelocationAllowed wanRelocationPermission = iota + 1
+	// wanRelocationChecked: the caller has checked the relocation as a whole; master changes are not checked again
+	wanRelocationChecked
+)
+
+// WithWANRelocationAllowed returns a context by which master changes creating WAN-crossing replication edges are
+// not refused, even with RequireWANRelocationConfirmation. They are still warned of and audited.
+func WithWANRelocationAllowed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, wanRelocationContextKey{}, wanRelocationAllowed)
+}
+
+// withWANRelocationChecked returns a context by which master changes are not checked for WAN crossing, since the
+// relocation they take part of has been
+func withWANRelocationChecked(ctx context.Context) context.Context {
+	return context.WithValue(ctx, wanRelocationContextKey{}, wanRelocationChecked)
+}
+
+func wanRelocationPermissionFromContext(ctx context.Context) wanRelocationPermission {
+	permission, _ := ctx.Value(wanRelocationContextKey{}).(wanRelocationPermission)
+	return permission
+}
+
+// checkWANMasterChange applies CheckWANRelocation to any change of master, be it by relocation, move, repoint,
+// match or take-master. Recoveries are never refused: they are warned of and audited.
+func checkWANMasterChange(ctx context.Context, instance, master *Instance) error {
+	if instance.MasterKey.Equals(&master.Key) {
+		return nil
+	}
+	permission := wanRelocationPermissionFromContext(ctx)
+	if permission == wanRelocationChecked {
+		return nil
+	}
+	allowWAN := permission == wanRelocationAllowed || masterChangeOriginFromContext(ctx).Cause == MasterChangeCauseRecovery
+	return CheckWANRelocation(instance, master, allowWAN)
+}
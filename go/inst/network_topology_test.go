@@ -0,0 +1,88 @@
+package inst
+
+import (
+	"context"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestWANLinkCost(t *testing.T) {
+	defer func(links config.WANLinkCosts) { config.Config.WANLinks = links }(config.Config.WANLinks)
+	config.Config.WANLinks = config.WANLinkCosts{
+		"dc1": {"dc2": 10},
+		"dc3": {"dc1": 30},
+	}
+	{
+		cost, isWAN := WANLinkCost("dc1", "dc2")
+		test.S(t).ExpectTrue(isWAN)
+		test.S(t).ExpectEquals(cost, 10)
+	}
+	{
+		cost, isWAN := WANLinkCost("dc2", "dc1")
+		test.S(t).ExpectTrue(isWAN)
+		test.S(t).ExpectEquals(cost, 10)
+	}
+	{
+		cost, isWAN := WANLinkCost("dc1", "dc3")
+		test.S(t).ExpectTrue(isWAN)
+		test.S(t).ExpectEquals(cost, 30)
+	}
+	{
+		_, isWAN := WANLinkCost("dc2", "dc3")
+		test.S(t).ExpectFalse(isWAN)
+	}
+	{
+		_, isWAN := WANLinkCost("dc1", "dc1")
+		test.S(t).ExpectFalse(isWAN)
+	}
+	{
+		_, isWAN := WANLinkCost("", "dc2")
+		test.S(t).ExpectFalse(isWAN)
+	}
+}
+
+func TestReplicationEdgeWANCost(t *testing.T) {
+	defer func(links config.WANLinkCosts) { config.Config.WANLinks = links }(config.Config.WANLinks)
+	config.Config.WANLinks = config.WANLinkCosts{"dc1": {"dc2": 10}}
+
+	master := &Instance{Key: key1, DataCenter: "dc1"}
+	replica := &Instance{Key: key2, DataCenter: "dc2", MasterKey: key1}
+	local := &Instance{Key: key3, DataCenter: "dc1", MasterKey: key1}
+
+	_, isWAN := replica.replicationEdgeWANCost(master)
+	test.S(t).ExpectTrue(isWAN)
+	_, isWAN = local.replicationEdgeWANCost(master)
+	test.S(t).ExpectFalse(isWAN)
+	_, isWAN = replica.replicationEdgeWANCost(nil)
+	test.S(t).ExpectFalse(isWAN)
+}
+
+func TestCheckWANMasterChange(t *testing.T) {
+	defer func(links config.WANLinkCosts, require bool) {
+		config.Config.WANLinks = links
+		config.Config.RequireWANRelocationConfirmation = require
+	}(config.Config.WANLinks, config.Config.RequireWANRelocationConfirmation)
+	config.Config.WANLinks = config.WANLinkCosts{"dc1": {"dc2": 10}}
+	config.Config.RequireWANRelocationConfirmation = true
+
+	// e.g. a repoint or take-master: neither instance replicates, so no backend lookups take place
+	master := &Instance{Key: key1, DataCenter: "dc1"}
+	remote := &Instance{Key: key2, DataCenter: "dc2"}
+	local := &Instance{Key: key3, DataCenter: "dc1"}
+
+	test.S(t).ExpectNotNil(checkWANMasterChange(context.Background(), remote, master))
+	test.S(t).ExpectNil(checkWANMasterChange(context.Background(), local, master))
+	test.S(t).ExpectNil(checkWANMasterChange(WithWANRelocationAllowed(context.Background()), remote, master))
+	test.S(t).ExpectNil(checkWANMasterChange(withWANRelocationChecked(context.Background()), remote, master))
+
+	recoveryContext := WithMasterChangeOrigin(context.Background(), NewMasterChangeOrigin(MasterChangeCauseRecovery, "test", ""))
+	test.S(t).ExpectNil(checkWANMasterChange(recoveryContext, remote, master))
+	// the permission survives into postponed functions
+	test.S(t).ExpectNil(checkWANMasterChange(detachedOperationContext(WithWANRelocationAllowed(context.Background())), remote, master))
+
+	// no new edge: repointing onto the current master
+	remote.MasterKey = master.Key
+	test.S(t).ExpectNil(checkWANMasterChange(context.Background(), remote, master))
+}
@@ -0,0 +1,50 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// NewOperationContext returns a context for a topology operation, derived from given parent. The context
+// times out after given timeout; a zero timeout falls back to TopologyOperationTimeoutSeconds, and when
+// that, too, is zero, the context has no deadline of its own. The context pins the IPs of the instances the
+// operation operates on; see OperationIPChangePolicy.
+func NewOperationContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	parent = withOperationIPPins(parent)
+	if timeout <= 0 {
+		timeout = time.Duration(config.Config.TopologyOperationTimeoutSeconds) * time.Second
+	}
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// checkOperationContext returns an error when given context is cancelled or past its deadline, or when given
+// instance's hostname no longer resolves to its pinned IPs, in which case an operation on the instance should
+// not proceed
+func checkOperationContext(ctx context.Context, instanceKey *InstanceKey) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("operation on %+v aborted: %+v", *instanceKey, err)
+	}
+	return validateOperationIPPins(ctx, instanceKey)
+}
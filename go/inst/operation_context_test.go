@@ -0,0 +1,45 @@
+package inst
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewOperationContext(t *testing.T) {
+	defer func(timeoutSeconds uint) { config.Config.TopologyOperationTimeoutSeconds = timeoutSeconds }(config.Config.TopologyOperationTimeoutSeconds)
+
+	config.Config.TopologyOperationTimeoutSeconds = 0
+	{
+		ctx, cancel := NewOperationContext(context.Background(), 0)
+		_, hasDeadline := ctx.Deadline()
+		test.S(t).ExpectFalse(hasDeadline)
+		test.S(t).ExpectNil(checkOperationContext(ctx, &i710Key))
+		cancel()
+		test.S(t).ExpectNotNil(checkOperationContext(ctx, &i710Key))
+	}
+	{
+		ctx, cancel := NewOperationContext(context.Background(), time.Minute)
+		defer cancel()
+		deadline, hasDeadline := ctx.Deadline()
+		test.S(t).ExpectTrue(hasDeadline)
+		test.S(t).ExpectTrue(time.Until(deadline) <= time.Minute)
+	}
+	config.Config.TopologyOperationTimeoutSeconds = 600
+	{
+		ctx, cancel := NewOperationContext(context.Background(), 0)
+		defer cancel()
+		deadline, hasDeadline := ctx.Deadline()
+		test.S(t).ExpectTrue(hasDeadline)
+		test.S(t).ExpectTrue(time.Until(deadline) > time.Minute)
+	}
+	{
+		ctx, cancel := NewOperationContext(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+		test.S(t).ExpectNotNil(checkOperationContext(ctx, &i710Key))
+	}
+}
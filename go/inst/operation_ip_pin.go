@@ -0,0 +1,169 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+const (
+	OperationIPChangeAbort      = "abort"
+	OperationIPChangeRevalidate = "revalidate"
+)
+
+// operationIPPin is what an instance's hostname resolved to, and which server was known to run there, as an
+// operation first operated on the instance
+type operationIPPin struct {
+	IPs        []string
+	ServerUUID string
+	ServerID   uint
+}
+
+// operationIPPins are the pins of an operation's instances. They are shared by all contexts derived from the
+// operation's context, such that all steps of the operation validate against the same pins.
+type operationIPPins struct {
+	mutex sync.Mutex
+	pins  map[InstanceKey]*operationIPPin
+}
+
+type operationIPPinsContextKey struct{}
+
+// lookupOperationIPs resolves a hostname to its sorted IPs. It bypasses the hostname IPs cache, which would hide
+// a change made mid-operation.
+var lookupOperationIPs = func(hostname string) ([]string, error) {
+	ips, err := net.LookupIP(hostname)
+	if err != nil {
+		return nil, err
+	}
+	ipStrings := []string{}
+	for _, ip := range ips {
+		ipStrings = append(ipStrings, ip.String())
+	}
+	sort.Strings(ipStrings)
+	return ipStrings, nil
+}
+
+// withOperationIPPins returns a context carrying the IP pins of an operation: those of given context, if any, or
+// else fresh ones
+func withOperationIPPins(ctx context.Context) context.Context {
+	if operationIPPinsFromContext(ctx) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, operationIPPinsContextKey{}, &operationIPPins{pins: make(map[InstanceKey]*operationIPPin)})
+}
+
+func operationIPPinsFromContext(ctx context.Context) *operationIPPins {
+	pins, _ := ctx.Value(operationIPPinsContextKey{}).(*operationIPPins)
+	return pins
+}
+
+// ipsOverlap returns true when given IP lists share an IP. A hostname with several IPs, e.g. by DNS round robin,
+// is not considered changed as long as any of its pinned IPs remains.
+func ipsOverlap(ips []string, otherIPs []string) bool {
+	for _, ip := range ips {
+		for _, otherIP := range otherIPs {
+			if ip == otherIP {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// revalidateOperationIPPin checks that the server now answering at given instance is the one pinned
+func revalidateOperationIPPin(instanceKey *InstanceKey, pin *operationIPPin) error {
+	if pin.ServerUUID == "" && pin.ServerID == 0 {
+		return fmt.Errorf("server identity unknown")
+	}
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return err
+	}
+	if pin.ServerUUID != "" && instance.ServerUUID != "" {
+		if instance.ServerUUID != pin.ServerUUID {
+			return fmt.Errorf("server_uuid changed from %s to %s", pin.ServerUUID, instance.ServerUUID)
+		}
+		return nil
+	}
+	if instance.ServerID != pin.ServerID {
+		return fmt.Errorf("server_id changed from %d to %d", pin.ServerID, instance.ServerID)
+	}
+	return nil
+}
+
+// validate pins the IPs of given instance upon first validation, and on further validations checks that its
+// hostname still resolves to them, as per OperationIPChangePolicy. An unresolvable hostname is not validated: the
+// operation's next connection to it fails on its own.
+func (this *operationIPPins) validate(instanceKey *InstanceKey) error {
+	ips, err := lookupOperationIPs(instanceKey.Hostname)
+	if err != nil || len(ips) == 0 {
+		return nil
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	pin, found := this.pins[*instanceKey]
+	if !found {
+		pin = &operationIPPin{IPs: ips}
+		if instance, _, _ := ReadInstance(instanceKey); instance != nil {
+			pin.ServerUUID = instance.ServerUUID
+			pin.ServerID = instance.ServerID
+		}
+		this.pins[*instanceKey] = pin
+		return nil
+	}
+	if ipsOverlap(pin.IPs, ips) {
+		return nil
+	}
+	change := fmt.Sprintf("%s resolved to %s as the operation began, and now resolves to %s", instanceKey.Hostname, strings.Join(pin.IPs, ","), strings.Join(ips, ","))
+	if config.Config.OperationIPChangePolicy == OperationIPChangeRevalidate {
+		revalidateErr := revalidateOperationIPPin(instanceKey, pin)
+		if revalidateErr == nil {
+			pin.IPs = ips
+			AuditOperation("operation-ip-change-revalidated", instanceKey, fmt.Sprintf("%s; same server, continuing", change))
+			return nil
+		}
+		change = fmt.Sprintf("%s; revalidation failed: %+v", change, revalidateErr)
+	}
+	AuditOperation("operation-ip-change-aborted", instanceKey, change)
+	return fmt.Errorf("operation on %+v aborted: %s", *instanceKey, change)
+}
+
+// validateOperationIPPins validates the IP pins of given instances, as operated on by the operation of given
+// context. It is a no-op unless OperationIPChangePolicy is set, and for contexts not derived from NewOperationContext.
+func validateOperationIPPins(ctx context.Context, instanceKeys ...*InstanceKey) error {
+	if config.Config.OperationIPChangePolicy == "" {
+		return nil
+	}
+	pins := operationIPPinsFromContext(ctx)
+	if pins == nil {
+		return nil
+	}
+	for _, instanceKey := range instanceKeys {
+		if err := pins.validate(instanceKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
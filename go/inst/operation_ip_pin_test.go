@@ -0,0 +1,76 @@
+package inst
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestIPsOverlap(t *testing.T) {
+	test.S(t).ExpectTrue(ipsOverlap([]string{"10.0.0.1"}, []string{"10.0.0.1"}))
+	test.S(t).ExpectTrue(ipsOverlap([]string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.2", "10.0.0.3"}))
+	test.S(t).ExpectFalse(ipsOverlap([]string{"10.0.0.1"}, []string{"10.0.0.2"}))
+	test.S(t).ExpectFalse(ipsOverlap([]string{}, []string{"10.0.0.2"}))
+}
+
+func TestValidateOperationIPPins(t *testing.T) {
+	defer func(policy string) { config.Config.OperationIPChangePolicy = policy }(config.Config.OperationIPChangePolicy)
+	defer func(lookup func(string) ([]string, error)) { lookupOperationIPs = lookup }(lookupOperationIPs)
+
+	resolved := map[string][]string{}
+	lookupOperationIPs = func(hostname string) ([]string, error) {
+		if ips, found := resolved[hostname]; found {
+			return ips, nil
+		}
+		return nil, fmt.Errorf("unknown host %s", hostname)
+	}
+	resolved[i710Key.Hostname] = []string{"10.0.0.1"}
+
+	config.Config.OperationIPChangePolicy = ""
+	{
+		ctx, cancel := NewOperationContext(context.Background(), 0)
+		defer cancel()
+		test.S(t).ExpectNil(checkOperationContext(ctx, &i710Key))
+		resolved[i710Key.Hostname] = []string{"10.0.0.9"}
+		// not pinned
+		test.S(t).ExpectNil(checkOperationContext(ctx, &i710Key))
+		resolved[i710Key.Hostname] = []string{"10.0.0.1"}
+	}
+	config.Config.OperationIPChangePolicy = OperationIPChangeAbort
+	{
+		ctx, cancel := NewOperationContext(context.Background(), 0)
+		defer cancel()
+		test.S(t).ExpectNil(checkOperationContext(ctx, &i710Key))
+		// a context with no pins is not validated
+		test.S(t).ExpectNil(validateOperationIPPins(context.Background(), &i710Key))
+
+		// round robin keeps a pinned IP
+		resolved[i710Key.Hostname] = []string{"10.0.0.1", "10.0.0.2"}
+		test.S(t).ExpectNil(checkOperationContext(ctx, &i710Key))
+		// an unresolvable hostname is not validated
+		delete(resolved, i710Key.Hostname)
+		test.S(t).ExpectNil(checkOperationContext(ctx, &i710Key))
+
+		resolved[i710Key.Hostname] = []string{"10.0.0.9"}
+		test.S(t).ExpectNotNil(checkOperationContext(ctx, &i710Key))
+		// postponed functions validate against the same pins
+		test.S(t).ExpectNotNil(validateOperationIPPins(detachedOperationContext(ctx), &i710Key))
+		resolved[i710Key.Hostname] = []string{"10.0.0.1"}
+	}
+	{
+		// a fresh operation pins anew
+		ctx, cancel := NewOperationContext(context.Background(), 0)
+		defer cancel()
+		resolved[i710Key.Hostname] = []string{"10.0.0.9"}
+		test.S(t).ExpectNil(checkOperationContext(ctx, &i710Key))
+		test.S(t).ExpectNil(checkOperationContext(ctx, &i710Key))
+	}
+}
+
+func TestRevalidateOperationIPPinUnknownIdentity(t *testing.T) {
+	err := revalidateOperationIPPin(&i710Key, &operationIPPin{IPs: []string{"10.0.0.1"}})
+	test.S(t).ExpectNotNil(err)
+}
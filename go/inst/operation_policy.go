@@ -0,0 +1,148 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/process"
+	"github.com/openark/golib/log"
+)
+
+// OperationPolicyInput is the input of an OperationPolicyURL query: the operation about to execute, along with the
+// instances it operates on, as known to orchestrator
+type OperationPolicyInput struct {
+	Operation        string
+	InstanceKey      InstanceKey
+	Instance         *Instance
+	OtherKey         *InstanceKey           `json:",omitempty"`
+	Other            *Instance              `json:",omitempty"`
+	Details          map[string]interface{} `json:",omitempty"`
+	Analysis         *ReplicationAnalysis   `json:",omitempty"`
+	ClusterAlias     string
+	MaintenanceOwner string
+	ProcessHostname  string
+}
+
+// OperationPolicyDecision is the decision of an OperationPolicyURL query
+type OperationPolicyDecision struct {
+	Allow  bool
+	Reason string
+}
+
+// NewOperationPolicyInput returns the input of an operation on given instance, and on given other instance, if any,
+// such as the one a relocation moves the instance below
+func NewOperationPolicyInput(operation string, instanceKey *InstanceKey, otherKey *InstanceKey) *OperationPolicyInput {
+	return &OperationPolicyInput{
+		Operation:   operation,
+		InstanceKey: *instanceKey,
+		OtherKey:    otherKey,
+	}
+}
+
+// parseOperationPolicyResponse parses the response of an OpenPolicyAgent data API query, whose result is either a
+// boolean or a decision object. An undefined result, as of a policy with no such rule, is an error.
+func parseOperationPolicyResponse(body []byte) (decision OperationPolicyDecision, err error) {
+	response := struct {
+		Result *json.RawMessage
+	}{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return decision, fmt.Errorf("cannot parse policy response: %+v", err)
+	}
+	if response.Result == nil {
+		return decision, fmt.Errorf("policy decision is undefined")
+	}
+	if err := json.Unmarshal(*response.Result, &decision.Allow); err == nil {
+		return decision, nil
+	}
+	if err := json.Unmarshal(*response.Result, &decision); err != nil {
+		return decision, fmt.Errorf("cannot parse policy decision %s: %+v", string(*response.Result), err)
+	}
+	return decision, nil
+}
+
+// queryOperationPolicy queries OperationPolicyURL with given input, within OperationPolicyTimeoutSeconds
+func queryOperationPolicy(input *OperationPolicyInput) (decision OperationPolicyDecision, err error) {
+	requestBody, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return decision, err
+	}
+	client := &http.Client{Timeout: time.Duration(config.Config.OperationPolicyTimeoutSeconds) * time.Second}
+	response, err := client.Post(config.Config.OperationPolicyURL, "application/json", bytes.NewReader(requestBody))
+	if err != nil {
+		return decision, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return decision, err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return decision, fmt.Errorf("policy responded with status %d", response.StatusCode)
+	}
+	return parseOperationPolicyResponse(body)
+}
+
+// CheckOperationPolicy evaluates the operation of given input against OperationPolicyURL, and returns an error, with
+// the policy's reason, when denied. A failing query results as per OperationPolicyFailurePolicy. Denials are audited.
+// This is a no-op when no OperationPolicyURL is configured.
+func CheckOperationPolicy(input *OperationPolicyInput) error {
+	if config.Config.OperationPolicyURL == "" {
+		return nil
+	}
+	if input.Instance == nil {
+		input.Instance, _, _ = ReadInstance(&input.InstanceKey)
+	}
+	if input.Other == nil && input.OtherKey != nil {
+		input.Other, _, _ = ReadInstance(input.OtherKey)
+	}
+	if input.Instance != nil {
+		input.ClusterAlias, _ = ReadAliasByClusterName(input.Instance.ClusterName)
+	}
+	input.MaintenanceOwner = GetMaintenanceOwner()
+	input.ProcessHostname = process.ThisHostname
+
+	decision, err := queryOperationPolicy(input)
+	if err != nil {
+		log.Errorf("CheckOperationPolicy: %s on %+v: %+v", input.Operation, input.InstanceKey, err)
+		if config.Config.OperationPolicyFailurePolicy == "allow" {
+			return nil
+		}
+		decision = OperationPolicyDecision{Reason: fmt.Sprintf("policy unavailable: %+v", err)}
+	}
+	if decision.Allow {
+		return nil
+	}
+	if decision.Reason == "" {
+		decision.Reason = "no reason given"
+	}
+	AuditOperation("operation-policy-denied", &input.InstanceKey, fmt.Sprintf("%s denied: %s", input.Operation, decision.Reason))
+	return fmt.Errorf("%s on %+v denied by operation policy: %s", input.Operation, input.InstanceKey, decision.Reason)
+}
+
+// checkOperationPolicy evaluates an operation on given instance, and on given other instance, if any
+func checkOperationPolicy(operation string, instanceKey *InstanceKey, otherKey *InstanceKey, details map[string]interface{}) error {
+	input := NewOperationPolicyInput(operation, instanceKey, otherKey)
+	input.Details = details
+	return CheckOperationPolicy(input)
+}
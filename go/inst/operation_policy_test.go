@@ -0,0 +1,65 @@
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseOperationPolicyResponse(t *testing.T) {
+	decision, err := parseOperationPolicyResponse([]byte(`{"result": true}`))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(decision.Allow)
+
+	decision, err = parseOperationPolicyResponse([]byte(`{"result": {"allow": false, "reason": "change freeze"}}`))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(decision.Allow)
+	test.S(t).ExpectEquals(decision.Reason, "change freeze")
+
+	_, err = parseOperationPolicyResponse([]byte(`{}`))
+	test.S(t).ExpectNotNil(err)
+
+	_, err = parseOperationPolicyResponse([]byte(`{"result": "yes"}`))
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestQueryOperationPolicy(t *testing.T) {
+	defer func(url string) { config.Config.OperationPolicyURL = url }(config.Config.OperationPolicyURL)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Input OperationPolicyInput
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch request.Input.Operation {
+		case "relocate":
+			fmt.Fprintf(w, `{"result": {"allow": true}}`)
+		case "reset-replica":
+			fmt.Fprintf(w, `{"result": {"allow": false, "reason": "%s is protected"}}`, request.Input.InstanceKey.Hostname)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	config.Config.OperationPolicyURL = server.URL + "/v1/data/orchestrator/decision"
+
+	decision, err := queryOperationPolicy(NewOperationPolicyInput("relocate", &i710Key, &i720Key))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(decision.Allow)
+
+	decision, err = queryOperationPolicy(NewOperationPolicyInput("reset-replica", &i710Key, nil))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(decision.Allow)
+	test.S(t).ExpectEquals(decision.Reason, "i710 is protected")
+
+	_, err = queryOperationPolicy(NewOperationPolicyInput("kill-query", &i710Key, nil))
+	test.S(t).ExpectNotNil(err)
+}
@@ -0,0 +1,256 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/patrickmn/go-cache"
+)
+
+type ProbeSet string
+
+const (
+	// ProbeSetFull runs all discovery queries. This is the default.
+	ProbeSetFull ProbeSet = "full"
+	// ProbeSetLight skips the queries reading rarely changing attributes, such as the Detect*Query queries and
+	// binlog encryption, and carries their last known values over instead
+	ProbeSetLight = "light"
+	// ProbeSetMinimal is the light probe set, which for instances not yet known skips the rarely changing attributes
+	// altogether. It is used by rapid rediscovery, and is not available to poll overrides.
+	ProbeSetMinimal = "minimal"
+)
+
+// rapidRediscoveryActive is non-zero while rapid rediscovery crawls the fleet
+var rapidRediscoveryActive int64
+
+// BeginRapidRediscovery marks rapid rediscovery as active, and returns false if it was already active
+func BeginRapidRediscovery() bool {
+	return atomic.CompareAndSwapInt64(&rapidRediscoveryActive, 0, 1)
+}
+
+// EndRapidRediscovery marks rapid rediscovery as no longer active
+func EndRapidRediscovery() {
+	atomic.StoreInt64(&rapidRediscoveryActive, 0)
+}
+
+// IsRapidRediscoveryActive returns true while rapid rediscovery crawls the fleet
+func IsRapidRediscoveryActive() bool {
+	return atomic.LoadInt64(&rapidRediscoveryActive) != 0
+}
+
+// ParseProbeSet returns the probe set by given name; empty name stands for the default, full probe set
+func ParseProbeSet(name string) (ProbeSet, error) {
+	switch ProbeSet(name) {
+	case "", ProbeSetFull:
+		return ProbeSetFull, nil
+	case ProbeSetLight:
+		return ProbeSetLight, nil
+	}
+	return ProbeSetFull, fmt.Errorf("Unknown probe set: %s. Expected %s or %s", name, ProbeSetFull, ProbeSetLight)
+}
+
+// PollOverride overrides the polling interval and probe set of a single instance, or of all instances having a tag.
+// A zero PollSeconds keeps the InstancePollSeconds interval.
+type PollOverride struct {
+	Key         InstanceKey
+	TagName     string
+	TagValue    string
+	PollSeconds uint
+	ProbeSet    ProbeSet
+	LastUpdated string
+}
+
+// NewInstancePollOverride creates an override for given instance
+func NewInstancePollOverride(instanceKey *InstanceKey, pollSeconds uint, probeSet ProbeSet) *PollOverride {
+	return &PollOverride{Key: *instanceKey, PollSeconds: pollSeconds, ProbeSet: probeSet}
+}
+
+// NewTagPollOverride creates an override for instances having given tag. A tag with no value
+// applies to all instances having a tag by that name.
+func NewTagPollOverride(tag *Tag, pollSeconds uint, probeSet ProbeSet) *PollOverride {
+	return &PollOverride{TagName: tag.TagName, TagValue: tag.TagValue, PollSeconds: pollSeconds, ProbeSet: probeSet}
+}
+
+// IsTagOverride returns true when this override applies by tag rather than to a single instance
+func (this *PollOverride) IsTagOverride() bool {
+	return this.TagName != ""
+}
+
+// Tag returns the tag by which this override applies
+func (this *PollOverride) Tag() *Tag {
+	return &Tag{TagName: this.TagName, TagValue: this.TagValue, HasValue: this.TagValue != ""}
+}
+
+func (this *PollOverride) String() string {
+	target := this.Key.DisplayString()
+	if this.IsTagOverride() {
+		target = fmt.Sprintf("tag %s", this.TagName)
+		if this.TagValue != "" {
+			target = fmt.Sprintf("tag %s=%s", this.TagName, this.TagValue)
+		}
+	}
+	return fmt.Sprintf("%s: poll seconds: %d, probe set: %s", target, this.PollSeconds, this.ProbeSet)
+}
+
+// Validate checks this override is applicable
+func (this *PollOverride) Validate() error {
+	if !this.IsTagOverride() && !this.Key.IsValid() {
+		return fmt.Errorf("Poll override requires either a valid instance or a tag")
+	}
+	if this.IsTagOverride() && this.Key.IsValid() {
+		return fmt.Errorf("Poll override applies to either an instance or a tag, not both")
+	}
+	if _, err := ParseProbeSet(string(this.ProbeSet)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// resolvePollOverrides computes the effective override per instance. An instance override takes precedence
+// over tag overrides. Of multiple tag overrides applying to an instance, the one with the shortest interval
+// applies.
+func resolvePollOverrides(overrides []*PollOverride, getTaggedKeys func(tag *Tag) (*InstanceKeyMap, error)) (map[InstanceKey]*PollOverride, error) {
+	effective := make(map[InstanceKey]*PollOverride)
+	for _, override := range overrides {
+		if !override.IsTagOverride() {
+			continue
+		}
+		taggedKeys, err := getTaggedKeys(override.Tag())
+		if err != nil {
+			return effective, err
+		}
+		for _, key := range taggedKeys.GetInstanceKeys() {
+			if current, found := effective[key]; found && effectivePollSeconds(current) <= effectivePollSeconds(override) {
+				continue
+			}
+			effective[key] = override
+		}
+	}
+	for _, override := range overrides {
+		if !override.IsTagOverride() {
+			effective[override.Key] = override
+		}
+	}
+	return effective, nil
+}
+
+// effectivePollSeconds returns the polling interval implied by given override
+func effectivePollSeconds(override *PollOverride) uint {
+	if override == nil || override.PollSeconds == 0 {
+		return config.Config.InstancePollSeconds
+	}
+	return override.PollSeconds
+}
+
+// withoutAcceleratedPolling returns given overrides, such that none polls more frequently than InstancePollSeconds.
+// While the backend is overloaded, accelerated polling is shed as low priority work; probe sets still apply.
+func withoutAcceleratedPolling(overrides map[InstanceKey]*PollOverride) map[InstanceKey]*PollOverride {
+	result := make(map[InstanceKey]*PollOverride)
+	for key, override := range overrides {
+		if override != nil && override.PollSeconds > 0 && override.PollSeconds < config.Config.InstancePollSeconds {
+			decelerated := *override
+			decelerated.PollSeconds = config.Config.InstancePollSeconds
+			override = &decelerated
+		}
+		result[key] = override
+	}
+	return result
+}
+
+// minPollSeconds returns the shortest polling interval, given the effective overrides
+func minPollSeconds(overrides map[InstanceKey]*PollOverride) uint {
+	pollSeconds := config.Config.InstancePollSeconds
+	for _, override := range overrides {
+		if seconds := effectivePollSeconds(override); seconds < pollSeconds {
+			pollSeconds = seconds
+		}
+	}
+	return pollSeconds
+}
+
+// isPollDue returns true when an instance last checked given number of seconds ago is due for polling. An
+// instance whose last check did not complete is given twice the interval.
+func isPollDue(pollSeconds uint, secondsSinceLastChecked int64, lastCheckCompleted bool) bool {
+	threshold := int64(pollSeconds)
+	if !lastCheckCompleted {
+		threshold = 2 * threshold
+	}
+	return secondsSinceLastChecked >= threshold
+}
+
+var pollOverridesCache = cache.New(time.Duration(config.Config.InstancePollSeconds)*time.Second, time.Second)
+
+// readEffectivePollOverrides returns the effective override per instance. These are cached briefly, as they
+// are consulted on every discovery.
+func readEffectivePollOverrides() map[InstanceKey]*PollOverride {
+	return readThroughCache(pollOverridesCache, "effective", func() interface{} {
+		overrides, _ := ReadPollOverrides()
+		effective, _ := resolvePollOverrides(overrides, GetInstanceKeysByTag)
+		if HasFocusedClusters() {
+			focusedKeys, _ := readFocusedInstanceKeys()
+			effective = applyClusterFocus(effective, focusedKeys)
+		}
+		return effective
+	}).(map[InstanceKey]*PollOverride)
+}
+
+// ReadEffectivePollOverride returns the override applying to given instance, or nil when the instance is
+// polled by default interval and probe set
+func ReadEffectivePollOverride(instanceKey *InstanceKey) *PollOverride {
+	return readEffectivePollOverrides()[*instanceKey]
+}
+
+// instanceProbeSet returns the probe set by which given instance is to be discovered
+func instanceProbeSet(instanceKey *InstanceKey) ProbeSet {
+	if IsRapidRediscoveryActive() {
+		return ProbeSetMinimal
+	}
+	if override := ReadEffectivePollOverride(instanceKey); override != nil && override.ProbeSet != "" {
+		return override.ProbeSet
+	}
+	return ProbeSetFull
+}
+
+// inheritLightProbeAttributes carries over, from given last known state of this instance, the attributes
+// which the light probe set does not read
+func (this *Instance) inheritLightProbeAttributes(lastKnown *Instance) {
+	this.BinlogEncryption = lastKnown.BinlogEncryption
+	this.RelayLogEncryption = lastKnown.RelayLogEncryption
+	if config.Config.DetectDataCenterQuery != "" {
+		this.DataCenter = lastKnown.DataCenter
+	}
+	if config.Config.DetectRegionQuery != "" {
+		this.Region = lastKnown.Region
+	}
+	if config.Config.DetectPhysicalEnvironmentQuery != "" {
+		this.PhysicalEnvironment = lastKnown.PhysicalEnvironment
+	}
+	if config.Config.DetectNamespaceQuery != "" {
+		this.Namespace = lastKnown.Namespace
+	}
+	if config.Config.DetectInstanceAliasQuery != "" {
+		this.InstanceAlias = lastKnown.InstanceAlias
+	}
+	if config.Config.DetectSemiSyncEnforcedQuery != "" {
+		this.SemiSyncEnforced = lastKnown.SemiSyncEnforced
+	}
+}
@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WritePollOverride creates or updates a poll override
+func WritePollOverride(override *PollOverride) error {
+	if err := override.Validate(); err != nil {
+		return err
+	}
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into poll_override (
+				hostname, port, tag_name, tag_value, poll_seconds, probe_set, last_updated
+			) values (
+				?, ?, ?, ?, ?, ?, NOW()
+			) on duplicate key update
+				poll_seconds=values(poll_seconds),
+				probe_set=values(probe_set),
+				last_updated=values(last_updated)
+			`, override.Key.Hostname, override.Key.Port, override.TagName, override.TagValue, override.PollSeconds, string(override.ProbeSet),
+		)
+		pollOverridesCache.Flush()
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// DeletePollOverride removes the poll override of given instance or tag
+func DeletePollOverride(override *PollOverride) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from poll_override
+			where
+				hostname = ?
+				and port = ?
+				and tag_name = ?
+				and tag_value = ?
+			`, override.Key.Hostname, override.Key.Port, override.TagName, override.TagValue,
+		)
+		pollOverridesCache.Flush()
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadPollOverrides reads all poll overrides, instance overrides first
+func ReadPollOverrides() ([]*PollOverride, error) {
+	res := []*PollOverride{}
+	query := `
+		select
+			hostname,
+			port,
+			tag_name,
+			tag_value,
+			poll_seconds,
+			probe_set,
+			last_updated
+		from
+			poll_override
+		order by
+			tag_name, tag_value, hostname, port
+		`
+	err := db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		override := &PollOverride{}
+		override.Key.Hostname = m.GetString("hostname")
+		override.Key.Port = m.GetInt("port")
+		override.TagName = m.GetString("tag_name")
+		override.TagValue = m.GetString("tag_value")
+		override.PollSeconds = m.GetUint("poll_seconds")
+		override.ProbeSet = ProbeSet(m.GetString("probe_set"))
+		override.LastUpdated = m.GetString("last_updated")
+
+		res = append(res, override)
+		return nil
+	})
+	return res, log.Errore(err)
+}
@@ -0,0 +1,105 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseProbeSet(t *testing.T) {
+	{
+		probeSet, err := ParseProbeSet("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(probeSet, ProbeSetFull)
+	}
+	{
+		probeSet, err := ParseProbeSet("light")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(probeSet, ProbeSet(ProbeSetLight))
+	}
+	{
+		_, err := ParseProbeSet("heavy")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseProbeSet("minimal")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestRapidRediscoveryProbeSet(t *testing.T) {
+	defer EndRapidRediscovery()
+
+	test.S(t).ExpectFalse(IsRapidRediscoveryActive())
+	test.S(t).ExpectTrue(BeginRapidRediscovery())
+	test.S(t).ExpectFalse(BeginRapidRediscovery())
+	test.S(t).ExpectTrue(IsRapidRediscoveryActive())
+	test.S(t).ExpectEquals(instanceProbeSet(&i710Key), ProbeSet(ProbeSetMinimal))
+	EndRapidRediscovery()
+	test.S(t).ExpectFalse(IsRapidRediscoveryActive())
+}
+
+func TestPollOverrideValidate(t *testing.T) {
+	test.S(t).ExpectNil(NewInstancePollOverride(&i710Key, 2, ProbeSetFull).Validate())
+	test.S(t).ExpectNil(NewTagPollOverride(&Tag{TagName: "role", TagValue: "dr"}, 60, ProbeSetLight).Validate())
+	test.S(t).ExpectNotNil(NewInstancePollOverride(&InstanceKey{}, 2, ProbeSetFull).Validate())
+	test.S(t).ExpectNotNil(NewInstancePollOverride(&i710Key, 2, ProbeSet("heavy")).Validate())
+}
+
+func TestResolvePollOverrides(t *testing.T) {
+	drOverride := NewTagPollOverride(&Tag{TagName: "role", TagValue: "dr"}, 60, ProbeSetLight)
+	backupOverride := NewTagPollOverride(&Tag{TagName: "backup"}, 30, ProbeSetLight)
+	instanceOverride := NewInstancePollOverride(&i720Key, 2, ProbeSetFull)
+	getTaggedKeys := func(tag *Tag) (*InstanceKeyMap, error) {
+		keys := NewInstanceKeyMap()
+		switch tag.TagName {
+		case "role":
+			keys.AddKeys([]InstanceKey{i720Key, i730Key, i810Key})
+		case "backup":
+			keys.AddKeys([]InstanceKey{i810Key})
+		}
+		return keys, nil
+	}
+	effective, err := resolvePollOverrides([]*PollOverride{instanceOverride, backupOverride, drOverride}, getTaggedKeys)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(effective), 3)
+	// instance override takes precedence over tag override
+	test.S(t).ExpectTrue(effective[i720Key] == instanceOverride)
+	test.S(t).ExpectTrue(effective[i730Key] == drOverride)
+	// shortest interval of tag overrides
+	test.S(t).ExpectTrue(effective[i810Key] == backupOverride)
+
+	test.S(t).ExpectEquals(minPollSeconds(effective), uint(2))
+	test.S(t).ExpectEquals(minPollSeconds(map[InstanceKey]*PollOverride{}), config.Config.InstancePollSeconds)
+}
+
+func TestIsPollDue(t *testing.T) {
+	test.S(t).ExpectTrue(isPollDue(60, 60, true))
+	test.S(t).ExpectFalse(isPollDue(60, 59, true))
+	test.S(t).ExpectFalse(isPollDue(60, 60, false))
+	test.S(t).ExpectTrue(isPollDue(60, 120, false))
+}
+
+func TestWithoutAcceleratedPolling(t *testing.T) {
+	defer func(pollSeconds uint) { config.Config.InstancePollSeconds = pollSeconds }(config.Config.InstancePollSeconds)
+	config.Config.InstancePollSeconds = 5
+
+	acceleratedKey := InstanceKey{Hostname: "accelerated", Port: 3306}
+	deceleratedKey := InstanceKey{Hostname: "decelerated", Port: 3306}
+	probeSetKey := InstanceKey{Hostname: "probe-set", Port: 3306}
+	overrides := map[InstanceKey]*PollOverride{
+		acceleratedKey: NewInstancePollOverride(&acceleratedKey, 1, ProbeSetFull),
+		deceleratedKey: NewInstancePollOverride(&deceleratedKey, 60, ProbeSetFull),
+		probeSetKey:    NewInstancePollOverride(&probeSetKey, 0, ProbeSetMinimal),
+	}
+	result := withoutAcceleratedPolling(overrides)
+	test.S(t).ExpectEquals(len(result), 3)
+	test.S(t).ExpectEquals(result[acceleratedKey].PollSeconds, uint(5))
+	test.S(t).ExpectEquals(result[deceleratedKey].PollSeconds, uint(60))
+	test.S(t).ExpectEquals(result[probeSetKey].PollSeconds, uint(0))
+	test.S(t).ExpectEquals(result[probeSetKey].ProbeSet, ProbeSet(ProbeSetMinimal))
+	// Given overrides are not modified
+	test.S(t).ExpectEquals(overrides[acceleratedKey].PollSeconds, uint(1))
+	test.S(t).ExpectEquals(minPollSeconds(result), uint(5))
+}
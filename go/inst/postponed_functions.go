@@ -17,15 +17,60 @@
 package inst
 
 import (
+	"context"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/github/orchestrator/go/config"
 	"github.com/openark/golib/log"
+	"github.com/rcrowley/go-metrics"
 )
 
+// PostponedFunctionPriority orders the execution of postponed functions; higher priorities run first
+type PostponedFunctionPriority int
+
+const (
+	PostponedFunctionPriorityLow    PostponedFunctionPriority = -100
+	PostponedFunctionPriorityNormal PostponedFunctionPriority = 0
+	PostponedFunctionPriorityHigh   PostponedFunctionPriority = 100
+)
+
+// PostponedPriorityTagName tags an instance with the priority of postponed operations on it, e.g. postponed-priority=200
+const PostponedPriorityTagName = "postponed-priority"
+
+var postponedFunctionsExecutedCounter = metrics.NewCounter()
+var postponedFunctionsFailedCounter = metrics.NewCounter()
+var postponedFunctionsDeadlineExceededCounter = metrics.NewCounter()
+var postponedFunctionsWaitHistogram = metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015))
+var postponedFunctionsRunHistogram = metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015))
+
+func init() {
+	metrics.Register("postponed_functions.executed", postponedFunctionsExecutedCounter)
+	metrics.Register("postponed_functions.failed", postponedFunctionsFailedCounter)
+	metrics.Register("postponed_functions.deadline_exceeded", postponedFunctionsDeadlineExceededCounter)
+	metrics.Register("postponed_functions.wait_seconds", postponedFunctionsWaitHistogram)
+	metrics.Register("postponed_functions.run_seconds", postponedFunctionsRunHistogram)
+}
+
+// postponedFunction is a function pending execution, along with its priority and deadline
+type postponedFunction struct {
+	run         func(ctx context.Context) error
+	ctx         context.Context
+	description string
+	priority    PostponedFunctionPriority
+	addedAt     time.Time
+	deadline    time.Time // zero for none
+}
+
 type PostponedFunctionsContainer struct {
 	waitGroup    sync.WaitGroup
 	mutex        sync.Mutex
 	descriptions []string
+	executed     []string
+	pending      []*postponedFunction
+	slots        chan bool
+	exemptKeys   map[InstanceKey]bool
 }
 
 func NewPostponedFunctionsContainer() *PostponedFunctionsContainer {
@@ -35,19 +80,125 @@ func NewPostponedFunctionsContainer() *PostponedFunctionsContainer {
 	return postponedFunctionsContainer
 }
 
+// AddPostponedFunction postpones given function, at normal priority and with the default deadline
 func (this *PostponedFunctionsContainer) AddPostponedFunction(postponedFunction func() error, description string) {
+	this.AddPrioritizedPostponedFunction(context.Background(), func(ctx context.Context) error { return postponedFunction() }, description, PostponedFunctionPriorityNormal, 0)
+}
+
+// AddPrioritizedPostponedFunction postpones given function. Postponed functions run concurrently, at most
+// PostponedFunctionsMaxConcurrency at a time when non-zero, and pending functions of higher priority run first.
+// The function's context, derived from given context, expires given deadline after being added (or
+// PostponedFunctionDeadlineSeconds when 0); a function whose deadline passes before it gets to run does not run.
+func (this *PostponedFunctionsContainer) AddPrioritizedPostponedFunction(ctx context.Context, postponedFunc func(ctx context.Context) error, description string, priority PostponedFunctionPriority, deadline time.Duration) {
 	this.mutex.Lock()
 	defer this.mutex.Unlock()
 
+	if deadline == 0 {
+		deadline = time.Duration(config.Config.PostponedFunctionDeadlineSeconds) * time.Second
+	}
+	pending := &postponedFunction{run: postponedFunc, ctx: ctx, description: description, priority: priority, addedAt: time.Now()}
+	if deadline > 0 {
+		pending.deadline = pending.addedAt.Add(deadline)
+	}
+	if this.slots == nil && config.Config.PostponedFunctionsMaxConcurrency > 0 {
+		this.slots = make(chan bool, config.Config.PostponedFunctionsMaxConcurrency)
+	}
 	this.descriptions = append(this.descriptions, description)
+	this.pending = append(this.pending, pending)
 
+	// Each function added gets to run the highest priority function pending at the time a slot frees up,
+	// which is not necessarily itself
 	this.waitGroup.Add(1)
 	go func() {
 		defer this.waitGroup.Done()
-		postponedFunction()
+		this.runNext()
 	}()
 }
 
+// nextPending removes and returns the pending function of highest priority; of equal priorities, the first added
+func (this *PostponedFunctionsContainer) nextPending() *postponedFunction {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if len(this.pending) == 0 {
+		return nil
+	}
+	next := 0
+	for i, pending := range this.pending {
+		if pending.priority > this.pending[next].priority {
+			next = i
+		}
+	}
+	pending := this.pending[next]
+	this.pending = append(this.pending[:next], this.pending[next+1:]...)
+	return pending
+}
+
+// runNext waits for a free slot, then runs the highest priority pending function
+func (this *PostponedFunctionsContainer) runNext() {
+	this.mutex.Lock()
+	slots := this.slots
+	this.mutex.Unlock()
+	if slots != nil {
+		slots <- true
+		defer func() { <-slots }()
+	}
+	pending := this.nextPending()
+	if pending == nil {
+		return
+	}
+	postponedFunctionsWaitHistogram.Update(int64(time.Since(pending.addedAt).Seconds()))
+
+	ctx := pending.ctx
+	if !pending.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, pending.deadline)
+		defer cancel()
+		if ctx.Err() != nil {
+			postponedFunctionsDeadlineExceededCounter.Inc(1)
+			log.Warningf("PostponedFunctionsContainer: deadline exceeded before running %s; skipping", pending.description)
+			return
+		}
+	}
+	this.mutex.Lock()
+	this.executed = append(this.executed, pending.description)
+	this.mutex.Unlock()
+
+	startTime := time.Now()
+	err := pending.run(ctx)
+	postponedFunctionsRunHistogram.Update(int64(time.Since(startTime).Seconds()))
+	postponedFunctionsExecutedCounter.Inc(1)
+	if err != nil {
+		postponedFunctionsFailedCounter.Inc(1)
+		log.Errorf("PostponedFunctionsContainer: %s: %+v", pending.description, err)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		postponedFunctionsDeadlineExceededCounter.Inc(1)
+	}
+}
+
+// ExemptFromLagPostponing designates given replicas to be relocated right away, even if they would otherwise
+// be postponed due to PostponeReplicaRecoveryOnLagMinutes
+func (this *PostponedFunctionsContainer) ExemptFromLagPostponing(instanceKeys ...InstanceKey) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.exemptKeys == nil {
+		this.exemptKeys = make(map[InstanceKey]bool)
+	}
+	for _, instanceKey := range instanceKeys {
+		this.exemptKeys[instanceKey] = true
+	}
+}
+
+// IsExemptFromLagPostponing returns true when given replica was designated via ExemptFromLagPostponing
+func (this *PostponedFunctionsContainer) IsExemptFromLagPostponing(instanceKey *InstanceKey) bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.exemptKeys[*instanceKey]
+}
+
 func (this *PostponedFunctionsContainer) Wait() {
 	log.Debugf("PostponedFunctionsContainer: waiting on %+v postponed functions", this.Len())
 	this.waitGroup.Wait()
@@ -67,3 +218,33 @@ func (this *PostponedFunctionsContainer) Descriptions() []string {
 
 	return this.descriptions
 }
+
+// ExecutedDescriptions returns the descriptions of the functions which got to run, in order of execution. Functions
+// skipped for their deadline are not listed
+func (this *PostponedFunctionsContainer) ExecutedDescriptions() []string {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.executed
+}
+
+// PostponedRelocationPriority returns the priority of postponed relocation of given replica: that of its
+// postponed-priority tag when tagged, high for an active DR standby master, and normal otherwise
+func PostponedRelocationPriority(replica *Instance) PostponedFunctionPriority {
+	tags, err := ReadInstanceTags(&replica.Key)
+	if err == nil {
+		for _, tag := range tags {
+			if tag.TagName != PostponedPriorityTagName {
+				continue
+			}
+			if priority, err := strconv.Atoi(tag.TagValue); err == nil {
+				return PostponedFunctionPriority(priority)
+			}
+			log.Warningf("PostponedRelocationPriority: %+v has invalid %s tag value: %s", replica.Key, tag.TagName, tag.TagValue)
+		}
+	}
+	if IsActiveDRStandbyMaster(&replica.Key) {
+		return PostponedFunctionPriorityHigh
+	}
+	return PostponedFunctionPriorityNormal
+}
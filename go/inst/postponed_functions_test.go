@@ -0,0 +1,34 @@
+package inst
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestPostponedFunctionsPriority(t *testing.T) {
+	defer func(concurrency uint) { config.Config.PostponedFunctionsMaxConcurrency = concurrency }(config.Config.PostponedFunctionsMaxConcurrency)
+	config.Config.PostponedFunctionsMaxConcurrency = 1
+
+	container := NewPostponedFunctionsContainer()
+	release := make(chan bool)
+	noop := func(ctx context.Context) error { return nil }
+	// The blocker takes the single slot while the rest are added
+	container.AddPrioritizedPostponedFunction(context.Background(), func(ctx context.Context) error { <-release; return nil }, "blocker", 1000, 0)
+	container.AddPrioritizedPostponedFunction(context.Background(), noop, "low", PostponedFunctionPriorityLow, 0)
+	container.AddPostponedFunction(func() error { return nil }, "normal")
+	container.AddPrioritizedPostponedFunction(context.Background(), noop, "high", PostponedFunctionPriorityHigh, 0)
+	container.AddPrioritizedPostponedFunction(context.Background(), noop, "expired", PostponedFunctionPriorityHigh, time.Millisecond)
+	container.AddPrioritizedPostponedFunction(context.Background(), noop, "normal-2", PostponedFunctionPriorityNormal, 0)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	container.Wait()
+
+	test.S(t).ExpectEquals(container.Len(), 6)
+	test.S(t).ExpectEquals(len(container.Descriptions()), 6)
+	test.S(t).ExpectEquals(strings.Join(container.ExecutedDescriptions(), ","), "blocker,high,normal,normal-2,low")
+}
@@ -0,0 +1,68 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultPostponementRelaxationDuration is the duration of a postponement relaxation which does not specify one
+const DefaultPostponementRelaxationDuration = time.Hour
+
+// PostponementRelaxation designates a critical replica, e.g. the only replica in the surviving data center, which
+// recoveries of its cluster relocate right away, rather than postpone per PostponeReplicaRecoveryOnLagMinutes.
+// It applies to recoveries which begin before it expires.
+type PostponementRelaxation struct {
+	Key          InstanceKey
+	ClusterName  string
+	Owner        string
+	Reason       string
+	Duration     time.Duration
+	EndTimestamp string
+}
+
+func NewPostponementRelaxation(instanceKey *InstanceKey, clusterName string, owner string, reason string, duration time.Duration) *PostponementRelaxation {
+	if duration == 0 {
+		duration = DefaultPostponementRelaxationDuration
+	}
+	return &PostponementRelaxation{
+		Key:         *instanceKey,
+		ClusterName: clusterName,
+		Owner:       owner,
+		Reason:      reason,
+		Duration:    duration,
+	}
+}
+
+func (this *PostponementRelaxation) String() string {
+	return fmt.Sprintf("%+v of %s: attached immediately in recoveries, until %s; owner: %s, reason: %s", this.Key, this.ClusterName, this.EndTimestamp, this.Owner, this.Reason)
+}
+
+// Validate checks this relaxation is applicable
+func (this *PostponementRelaxation) Validate() error {
+	if !this.Key.IsValid() {
+		return fmt.Errorf("Postponement relaxation requires a valid instance key; got %+v", this.Key)
+	}
+	if this.ClusterName == "" {
+		return fmt.Errorf("Postponement relaxation of %+v requires a cluster name", this.Key)
+	}
+	if this.Duration < 0 {
+		return fmt.Errorf("Postponement relaxation of %+v: duration must be non-negative", this.Key)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WritePostponementRelaxation creates or renews the postponement relaxation of a replica
+func WritePostponementRelaxation(relaxation *PostponementRelaxation) error {
+	if err := relaxation.Validate(); err != nil {
+		return err
+	}
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into postponement_relaxation (
+				hostname, port, cluster_name, owner, reason, begin_timestamp, end_timestamp
+			) values (
+				?, ?, ?, ?, ?, NOW(), NOW() + INTERVAL ? SECOND
+			) on duplicate key update
+				cluster_name=values(cluster_name),
+				owner=values(owner),
+				reason=values(reason),
+				begin_timestamp=values(begin_timestamp),
+				end_timestamp=values(end_timestamp)
+			`, relaxation.Key.Hostname, relaxation.Key.Port, relaxation.ClusterName, relaxation.Owner, relaxation.Reason, int(relaxation.Duration.Seconds()),
+		)
+		return log.Errore(err)
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	AuditOperation("relax-lag-postponement", &relaxation.Key, fmt.Sprintf("attached immediately in recoveries of %s for %+v; owner: %s, reason: %s", relaxation.ClusterName, relaxation.Duration, relaxation.Owner, relaxation.Reason))
+	return nil
+}
+
+// DeletePostponementRelaxation removes the postponement relaxation of a replica
+func DeletePostponementRelaxation(instanceKey *InstanceKey) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from postponement_relaxation where hostname = ? and port = ?
+			`, instanceKey.Hostname, instanceKey.Port,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadPostponementRelaxations reads the unexpired postponement relaxations of given cluster, or of all clusters
+// when clusterName is empty
+func ReadPostponementRelaxations(clusterName string) ([]*PostponementRelaxation, error) {
+	res := []*PostponementRelaxation{}
+	query := `
+		select
+			hostname,
+			port,
+			cluster_name,
+			owner,
+			reason,
+			end_timestamp
+		from
+			postponement_relaxation
+		where
+			end_timestamp > NOW()
+			and (cluster_name = ? or ? = '')
+		order by
+			cluster_name, hostname, port
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName, clusterName), func(m sqlutils.RowMap) error {
+		relaxation := &PostponementRelaxation{}
+		relaxation.Key.Hostname = m.GetString("hostname")
+		relaxation.Key.Port = m.GetInt("port")
+		relaxation.ClusterName = m.GetString("cluster_name")
+		relaxation.Owner = m.GetString("owner")
+		relaxation.Reason = m.GetString("reason")
+		relaxation.EndTimestamp = m.GetString("end_timestamp")
+
+		res = append(res, relaxation)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ExpirePostponementRelaxations removes expired postponement relaxations
+func ExpirePostponementRelaxations() error {
+	_, err := db.ExecOrchestrator(`
+			delete from postponement_relaxation where end_timestamp < NOW()
+		`,
+	)
+	return log.Errore(err)
+}
@@ -0,0 +1,43 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewPostponementRelaxation(t *testing.T) {
+	key := InstanceKey{Hostname: "replica-dc2", Port: 3306}
+	relaxation := NewPostponementRelaxation(&key, "cluster:3306", "ops", "only replica in dc2", 0)
+	test.S(t).ExpectEquals(relaxation.Duration, DefaultPostponementRelaxationDuration)
+	test.S(t).ExpectNil(relaxation.Validate())
+
+	relaxation = NewPostponementRelaxation(&key, "", "ops", "only replica in dc2", time.Minute)
+	test.S(t).ExpectEquals(relaxation.Duration, time.Minute)
+	test.S(t).ExpectNotNil(relaxation.Validate())
+
+	relaxation = NewPostponementRelaxation(&InstanceKey{}, "cluster:3306", "ops", "only replica in dc2", time.Minute)
+	test.S(t).ExpectNotNil(relaxation.Validate())
+}
+
+func TestShouldPostponeRelocatingReplicaExemption(t *testing.T) {
+	defer func(minutes uint) { config.Config.PostponeReplicaRecoveryOnLagMinutes = minutes }(config.Config.PostponeReplicaRecoveryOnLagMinutes)
+	config.Config.PostponeReplicaRecoveryOnLagMinutes = 10
+
+	delayed := &Instance{Key: InstanceKey{Hostname: "delayed", Port: 3306}, SQLDelay: 3600}
+	critical := &Instance{Key: InstanceKey{Hostname: "critical", Port: 3306}, SQLDelay: 3600}
+	container := NewPostponedFunctionsContainer()
+
+	test.S(t).ExpectFalse(shouldPostponeRelocatingReplica(critical, nil))
+	test.S(t).ExpectTrue(shouldPostponeRelocatingReplica(delayed, container))
+	test.S(t).ExpectTrue(shouldPostponeRelocatingReplica(critical, container))
+
+	container.ExemptFromLagPostponing(critical.Key)
+	test.S(t).ExpectTrue(shouldPostponeRelocatingReplica(delayed, container))
+	test.S(t).ExpectFalse(shouldPostponeRelocatingReplica(critical, container))
+
+	critical.LastDiscoveryLatency = ReasonableDiscoveryLatency + time.Second
+	test.S(t).ExpectTrue(shouldPostponeRelocatingReplica(critical, container))
+}
@@ -0,0 +1,216 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+)
+
+// PromotionCandidateQuorum is the evaluation of a cluster's promotion candidates: those replicas of its master
+// which candidate selection would currently consider, were the master to fail now, as against the configured minimum
+type PromotionCandidateQuorum struct {
+	ClusterName        string
+	ClusterAlias       string
+	MasterKey          InstanceKey
+	MinCandidates      uint
+	EligibleCandidates []InstanceKey
+	IneligibleReplicas []CandidateReplicaReasons
+	IsBelowMinimum     bool
+	EvaluatedAt        time.Time
+}
+
+// promotionCandidateQuorums holds the latest evaluation per cluster, of clusters having a minimum
+var promotionCandidateQuorums = struct {
+	sync.RWMutex
+	byCluster map[string]*PromotionCandidateQuorum
+}{byCluster: make(map[string]*PromotionCandidateQuorum)}
+
+// resolveMinPromotionCandidates returns the configured minimum of promotion candidates for given cluster.
+// ClusterMinPromotionCandidates, by cluster name then by alias, takes precedence over MinPromotionCandidates.
+func resolveMinPromotionCandidates(clusterName string, clusterAlias string) uint {
+	if min, found := config.Config.ClusterMinPromotionCandidates[clusterName]; found {
+		return min
+	}
+	if clusterAlias != "" {
+		if min, found := config.Config.ClusterMinPromotionCandidates[clusterAlias]; found {
+			return min
+		}
+	}
+	return config.Config.MinPromotionCandidates
+}
+
+// promotionCandidateIneligibility returns why candidate selection would not consider given replica, other than as a
+// fallback when no replica is valid; empty when it would
+func promotionCandidateIneligibility(replica *Instance, priorityMajorVersion string, priorityBinlogFormat string) string {
+	if banReason := candidateReplicaBanReason(replica); banReason != "" {
+		return fmt.Sprintf("banned from promotion because %s", banReason)
+	}
+	if !replica.IsLastCheckValid {
+		return "last check is invalid"
+	}
+	if !replica.LogBinEnabled || !replica.LogSlaveUpdatesEnabled {
+		return "binary logs or log_slave_updates disabled"
+	}
+	if replica.IsBinlogServer() {
+		return "binlog server"
+	}
+	if !IsVersionCompatible(priorityMajorVersion, replica.Version) {
+		return fmt.Sprintf("version %s is incompatible with the prevailing %s", replica.Version, priorityMajorVersion)
+	}
+	if IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
+		return fmt.Sprintf("binlog format %s is incompatible with the prevailing %s", replica.Binlog_format, priorityBinlogFormat)
+	}
+	if health := ReadCandidateHealth(&replica.Key); health.Vetoed {
+		return health.Reason
+	}
+	if IsInPromotionCooldown(replica) {
+		return "in promotion cool-down"
+	}
+	if replica.IsDelayedReplica() {
+		return fmt.Sprintf("delayed by %d seconds", replica.SQLDelay)
+	}
+	if lagReason := promotionLagRejection(replica); lagReason != "" {
+		return lagReason
+	}
+	return ""
+}
+
+// evaluatePromotionCandidates splits given replicas of a master into those eligible for promotion, and the reasons
+// of those which are not
+func evaluatePromotionCandidates(replicas [](*Instance)) (eligible []InstanceKey, ineligible []CandidateReplicaReasons) {
+	eligible = []InstanceKey{}
+	ineligible = []CandidateReplicaReasons{}
+	if len(replicas) == 0 {
+		return eligible, ineligible
+	}
+	priorityMajorVersion, _ := getPriorityMajorVersionForCandidate(replicas)
+	priorityBinlogFormat, _ := getPriorityBinlogFormatForCandidate(replicas)
+	for _, replica := range replicas {
+		if reason := promotionCandidateIneligibility(replica, priorityMajorVersion, priorityBinlogFormat); reason != "" {
+			ineligible = append(ineligible, CandidateReplicaReasons{Key: replica.Key, Reasons: []string{reason}})
+		} else {
+			eligible = append(eligible, replica.Key)
+		}
+	}
+	return eligible, ineligible
+}
+
+// EvaluatePromotionCandidateQuorum evaluates the promotion candidates of given cluster's master
+func EvaluatePromotionCandidateQuorum(clusterName string) (*PromotionCandidateQuorum, error) {
+	clusterMasters, err := ReadClusterMaster(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusterMasters) != 1 {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
+	}
+	clusterAlias, _ := ReadAliasByClusterName(clusterName)
+	return evaluatePromotionCandidateQuorum(clusterMasters[0], clusterAlias)
+}
+
+func evaluatePromotionCandidateQuorum(master *Instance, clusterAlias string) (*PromotionCandidateQuorum, error) {
+	replicas, err := getReplicasForSorting(&master.Key, false)
+	if err != nil {
+		return nil, err
+	}
+	quorum := &PromotionCandidateQuorum{
+		ClusterName:   master.ClusterName,
+		ClusterAlias:  clusterAlias,
+		MasterKey:     master.Key,
+		MinCandidates: resolveMinPromotionCandidates(master.ClusterName, clusterAlias),
+		EvaluatedAt:   time.Now(),
+	}
+	quorum.EligibleCandidates, quorum.IneligibleReplicas = evaluatePromotionCandidates(replicas)
+	quorum.IsBelowMinimum = uint(len(quorum.EligibleCandidates)) < quorum.MinCandidates
+	return quorum, nil
+}
+
+// MonitorPromotionCandidateQuorums evaluates the promotion candidates of each cluster having a minimum, per
+// MinPromotionCandidates or ClusterMinPromotionCandidates, and keeps the evaluations as a standing warning on clusters
+// below their minimum. A cluster dropping below its minimum, or recovering from it, is audited.
+func MonitorPromotionCandidateQuorums() error {
+	if config.Config.MinPromotionCandidates == 0 && len(config.Config.ClusterMinPromotionCandidates) == 0 {
+		return nil
+	}
+	masters, err := ReadWriteableClustersMasters()
+	if err != nil {
+		return log.Errore(err)
+	}
+	quorums := make(map[string]*PromotionCandidateQuorum)
+	for _, master := range masters {
+		clusterAlias, _ := ReadAliasByClusterName(master.ClusterName)
+		if resolveMinPromotionCandidates(master.ClusterName, clusterAlias) == 0 {
+			continue
+		}
+		quorum, err := evaluatePromotionCandidateQuorum(master, clusterAlias)
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		quorums[quorum.ClusterName] = quorum
+	}
+
+	promotionCandidateQuorums.Lock()
+	previousQuorums := promotionCandidateQuorums.byCluster
+	promotionCandidateQuorums.byCluster = quorums
+	promotionCandidateQuorums.Unlock()
+
+	for clusterName, quorum := range quorums {
+		wasBelowMinimum := false
+		if previous, found := previousQuorums[clusterName]; found {
+			wasBelowMinimum = previous.IsBelowMinimum
+		}
+		if quorum.IsBelowMinimum && !wasBelowMinimum {
+			message := fmt.Sprintf("%s has %d promotion candidates, below its minimum of %d", clusterName, len(quorum.EligibleCandidates), quorum.MinCandidates)
+			log.Warningf("promotion-candidates-below-minimum: %s", message)
+			AuditOperation("promotion-candidates-below-minimum", &quorum.MasterKey, message)
+		}
+		if !quorum.IsBelowMinimum && wasBelowMinimum {
+			AuditOperation("promotion-candidates-restored", &quorum.MasterKey, fmt.Sprintf("%s has %d promotion candidates, meeting its minimum of %d", clusterName, len(quorum.EligibleCandidates), quorum.MinCandidates))
+		}
+	}
+	return nil
+}
+
+// ReadPromotionCandidateQuorums returns the latest evaluations, ordered by cluster name
+func ReadPromotionCandidateQuorums() [](*PromotionCandidateQuorum) {
+	promotionCandidateQuorums.RLock()
+	defer promotionCandidateQuorums.RUnlock()
+
+	quorums := [](*PromotionCandidateQuorum){}
+	for _, quorum := range promotionCandidateQuorums.byCluster {
+		quorums = append(quorums, quorum)
+	}
+	sort.Slice(quorums, func(i, j int) bool { return quorums[i].ClusterName < quorums[j].ClusterName })
+	return quorums
+}
+
+// IsBelowMinimumPromotionCandidates returns true when the latest evaluation of given cluster found its master, given,
+// to be below its minimum of promotion candidates
+func IsBelowMinimumPromotionCandidates(clusterName string, masterKey *InstanceKey) bool {
+	promotionCandidateQuorums.RLock()
+	defer promotionCandidateQuorums.RUnlock()
+
+	quorum, found := promotionCandidateQuorums.byCluster[clusterName]
+	return found && quorum.IsBelowMinimum && quorum.MasterKey.Equals(masterKey)
+}
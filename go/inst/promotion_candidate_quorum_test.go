@@ -0,0 +1,61 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestResolveMinPromotionCandidates(t *testing.T) {
+	defer func(min uint, mins map[string]uint) {
+		config.Config.MinPromotionCandidates = min
+		config.Config.ClusterMinPromotionCandidates = mins
+	}(config.Config.MinPromotionCandidates, config.Config.ClusterMinPromotionCandidates)
+
+	config.Config.MinPromotionCandidates = 2
+	config.Config.ClusterMinPromotionCandidates = map[string]uint{"c1:3306": 3, "tiny": 0}
+	test.S(t).ExpectEquals(resolveMinPromotionCandidates("c1:3306", "c1"), uint(3))
+	test.S(t).ExpectEquals(resolveMinPromotionCandidates("c2:3306", "tiny"), uint(0))
+	test.S(t).ExpectEquals(resolveMinPromotionCandidates("c3:3306", ""), uint(2))
+}
+
+func TestEvaluatePromotionCandidates(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	instancesMap[i710Key.StringCode()].PromotionRule = MustNotPromoteRule
+	instancesMap[i720Key.StringCode()].IsLastCheckValid = false
+	instancesMap[i730Key.StringCode()].LogSlaveUpdatesEnabled = false
+	instancesMap[i810Key.StringCode()].SQLDelay = 3600
+
+	eligible, ineligible := evaluatePromotionCandidates(instances)
+	test.S(t).ExpectEquals(len(eligible), 2)
+	test.S(t).ExpectEquals(eligible[0], i820Key)
+	test.S(t).ExpectEquals(eligible[1], i830Key)
+	test.S(t).ExpectEquals(len(ineligible), 4)
+	test.S(t).ExpectEquals(ineligible[0].Key, i710Key)
+	test.S(t).ExpectEquals(ineligible[0].Reasons[0], "banned from promotion because of promotion rule")
+	test.S(t).ExpectEquals(ineligible[3].Reasons[0], "delayed by 3600 seconds")
+
+	eligible, ineligible = evaluatePromotionCandidates([](*Instance){})
+	test.S(t).ExpectEquals(len(eligible), 0)
+	test.S(t).ExpectEquals(len(ineligible), 0)
+}
+
+func TestIsBelowMinimumPromotionCandidates(t *testing.T) {
+	defer func(byCluster map[string]*PromotionCandidateQuorum) {
+		promotionCandidateQuorums.byCluster = byCluster
+	}(promotionCandidateQuorums.byCluster)
+
+	promotionCandidateQuorums.byCluster = map[string]*PromotionCandidateQuorum{
+		"c1:3306": {ClusterName: "c1:3306", MasterKey: i710Key, MinCandidates: 2, IsBelowMinimum: true},
+		"c2:3306": {ClusterName: "c2:3306", MasterKey: i810Key, MinCandidates: 1},
+	}
+	test.S(t).ExpectTrue(IsBelowMinimumPromotionCandidates("c1:3306", &i710Key))
+	// a master changed since the evaluation
+	test.S(t).ExpectFalse(IsBelowMinimumPromotionCandidates("c1:3306", &i720Key))
+	test.S(t).ExpectFalse(IsBelowMinimumPromotionCandidates("c2:3306", &i810Key))
+	test.S(t).ExpectFalse(IsBelowMinimumPromotionCandidates("c3:3306", &i830Key))
+	test.S(t).ExpectEquals(len(ReadPromotionCandidateQuorums()), 2)
+	test.S(t).ExpectEquals(ReadPromotionCandidateQuorums()[0].ClusterName, "c1:3306")
+}
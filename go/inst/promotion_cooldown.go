@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+var recentPromotionParticipantsCache = cache.New(time.Duration(config.Config.InstancePollSeconds)*time.Second, time.Second)
+
+// maxPromotionCooldownSeconds returns the longest cool-down configured, globally or for any cluster
+func maxPromotionCooldownSeconds() uint {
+	maxCooldownSeconds := config.Config.PromotionCooldownSeconds
+	for _, cooldownSeconds := range config.Config.ClusterPromotionCooldownSeconds {
+		if cooldownSeconds > maxCooldownSeconds {
+			maxCooldownSeconds = cooldownSeconds
+		}
+	}
+	return maxCooldownSeconds
+}
+
+// readRecentPromotionParticipants reads the instances which, within given number of seconds, either failed
+// and were recovered, or were promoted by a recovery. The result maps each to its most recent such recovery.
+func readRecentPromotionParticipants(withinSeconds uint) (map[InstanceKey]time.Time, error) {
+	participants := make(map[InstanceKey]time.Time)
+	query := `
+		select
+			hostname,
+			port,
+			ifnull(successor_hostname, '') as successor_hostname,
+			ifnull(successor_port, 0) as successor_port,
+			unix_timestamp(start_active_period) as start_active_period_unixtime
+		from
+			topology_recovery
+		where
+			is_successful = 1
+			and start_active_period >= now() - interval ? second
+	`
+	err := db.QueryOrchestrator(query, sqlutils.Args(withinSeconds), func(m sqlutils.RowMap) error {
+		recoveredAt := time.Unix(m.GetInt64("start_active_period_unixtime"), 0)
+		keys := []InstanceKey{{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}}
+		if successorHostname := m.GetString("successor_hostname"); successorHostname != "" {
+			keys = append(keys, InstanceKey{Hostname: successorHostname, Port: m.GetInt("successor_port")})
+		}
+		for _, key := range keys {
+			if recoveredAt.After(participants[key]) {
+				participants[key] = recoveredAt
+			}
+		}
+		return nil
+	})
+	return participants, log.Errore(err)
+}
+
+// recentPromotionParticipants returns the recent participants of recoveries. These are cached briefly, as
+// this is checked repeatedly when evaluating promotion candidates.
+func recentPromotionParticipants() map[InstanceKey]time.Time {
+	return readThroughCache(recentPromotionParticipantsCache, "participants", func() interface{} {
+		participants, _ := readRecentPromotionParticipants(maxPromotionCooldownSeconds())
+		return participants
+	}).(map[InstanceKey]time.Time)
+}
+
+// resolvePromotionCooldownSeconds returns the effective cool-down for given cluster. ClusterPromotionCooldownSeconds,
+// by cluster name then by alias, takes precedence over PromotionCooldownSeconds.
+func resolvePromotionCooldownSeconds(clusterName string, clusterAlias string) uint {
+	if cooldownSeconds, found := config.Config.ClusterPromotionCooldownSeconds[clusterName]; found {
+		return cooldownSeconds
+	}
+	if clusterAlias != "" {
+		if cooldownSeconds, found := config.Config.ClusterPromotionCooldownSeconds[clusterAlias]; found {
+			return cooldownSeconds
+		}
+	}
+	return config.Config.PromotionCooldownSeconds
+}
+
+// PromotionCooldownSeconds returns the promotion cool-down, in seconds, which applies to given cluster
+func PromotionCooldownSeconds(clusterName string) uint {
+	clusterAlias := ""
+	if len(config.Config.ClusterPromotionCooldownSeconds) > 0 {
+		clusterAlias, _ = ReadAliasByClusterName(clusterName)
+	}
+	return resolvePromotionCooldownSeconds(clusterName, clusterAlias)
+}
+
+// IsInPromotionCooldown returns true when given instance failed, or was promoted, in a recovery more
+// recent than its cluster's promotion cool-down. Such an instance should not be promoted again unless
+// there is no other choice, so as to avoid failing over back and forth between two flaky hosts.
+func IsInPromotionCooldown(instance *Instance) bool {
+	if maxPromotionCooldownSeconds() == 0 {
+		return false
+	}
+	recoveredAt, found := recentPromotionParticipants()[instance.Key]
+	if !found {
+		return false
+	}
+	cooldownSeconds := PromotionCooldownSeconds(instance.ClusterName)
+	if time.Since(recoveredAt) >= time.Duration(cooldownSeconds)*time.Second {
+		return false
+	}
+	log.Debugf("instance %+v is in promotion cool-down, having participated in a recovery at %+v", instance.Key, recoveredAt)
+	return true
+}
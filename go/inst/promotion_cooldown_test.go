@@ -0,0 +1,70 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+	"github.com/patrickmn/go-cache"
+)
+
+func TestResolvePromotionCooldownSeconds(t *testing.T) {
+	defer func(cooldownSeconds uint) { config.Config.PromotionCooldownSeconds = cooldownSeconds }(config.Config.PromotionCooldownSeconds)
+	defer func(cooldowns map[string]uint) {
+		config.Config.ClusterPromotionCooldownSeconds = cooldowns
+	}(config.Config.ClusterPromotionCooldownSeconds)
+
+	config.Config.PromotionCooldownSeconds = 600
+	config.Config.ClusterPromotionCooldownSeconds = map[string]uint{
+		"flaky":        3600,
+		"stable:3306":  0,
+		"another:3306": 60,
+	}
+	test.S(t).ExpectEquals(resolvePromotionCooldownSeconds("other:3306", ""), uint(600))
+	test.S(t).ExpectEquals(resolvePromotionCooldownSeconds("other:3306", "flaky"), uint(3600))
+	test.S(t).ExpectEquals(resolvePromotionCooldownSeconds("stable:3306", "flaky"), uint(0))
+	test.S(t).ExpectEquals(resolvePromotionCooldownSeconds("another:3306", ""), uint(60))
+	test.S(t).ExpectEquals(maxPromotionCooldownSeconds(), uint(3600))
+}
+
+func TestChooseCandidateReplicaSkipsCooldownReplica(t *testing.T) {
+	defer func(cooldownSeconds uint) { config.Config.PromotionCooldownSeconds = cooldownSeconds }(config.Config.PromotionCooldownSeconds)
+	defer recentPromotionParticipantsCache.Flush()
+
+	config.Config.PromotionCooldownSeconds = 600
+	participants := map[InstanceKey]time.Time{
+		i830Key: time.Now().Add(-time.Minute),
+		i820Key: time.Now().Add(-time.Hour),
+	}
+	recentPromotionParticipantsCache.Set("participants", participants, cache.DefaultExpiration)
+
+	instances, _ := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, aheadReplicas, _, laterReplicas, _, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i820Key)
+	test.S(t).ExpectEquals(len(aheadReplicas), 1)
+	test.S(t).ExpectEquals(len(laterReplicas), 4)
+}
+
+func TestChooseCandidateReplicaAllInCooldown(t *testing.T) {
+	defer func(cooldownSeconds uint) { config.Config.PromotionCooldownSeconds = cooldownSeconds }(config.Config.PromotionCooldownSeconds)
+	defer recentPromotionParticipantsCache.Flush()
+
+	config.Config.PromotionCooldownSeconds = 600
+	instances, _ := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	participants := map[InstanceKey]time.Time{}
+	for _, instance := range instances {
+		participants[instance.Key] = time.Now()
+	}
+	recentPromotionParticipantsCache.Set("participants", participants, cache.DefaultExpiration)
+
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, _, _, _, _, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i830Key)
+	test.S(t).ExpectTrue(IsInPromotionCooldown(candidate))
+}
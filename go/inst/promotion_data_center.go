@@ -0,0 +1,93 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// PromotionDataCenterPreference ranks data centers as the location of a promoted master, given the failed master's
+// data center and the cluster's ClusterPromotionDataCenterPolicies entry. Lower ranks are preferred; rank 0 is ideal.
+// Without a policy, the failed master's data center ranks 0 and any other data center ranks 1.
+type PromotionDataCenterPreference struct {
+	FailedMasterDataCenter string
+	Policy                 *config.DCPolicy // nil when the cluster has no promotion data center policy
+}
+
+// NewPromotionDataCenterPreference returns the preference applying to given cluster, whose master in given data
+// center failed. The cluster's policy is looked up by cluster name, then by alias.
+func NewPromotionDataCenterPreference(failedMasterDataCenter string, clusterName string, clusterAlias string) *PromotionDataCenterPreference {
+	preference := &PromotionDataCenterPreference{FailedMasterDataCenter: failedMasterDataCenter}
+	if len(config.Config.ClusterPromotionDataCenterPolicies) == 0 {
+		return preference
+	}
+	if policy, found := config.Config.ClusterPromotionDataCenterPolicies[clusterName]; found {
+		preference.Policy = &policy
+		return preference
+	}
+	if clusterAlias == "" {
+		clusterAlias, _ = ReadAliasByClusterName(clusterName)
+	}
+	if policy, found := config.Config.ClusterPromotionDataCenterPolicies[clusterAlias]; found && clusterAlias != "" {
+		preference.Policy = &policy
+	}
+	return preference
+}
+
+// HasPolicy returns true when a ClusterPromotionDataCenterPolicies entry applies
+func (this *PromotionDataCenterPreference) HasPolicy() bool {
+	return this.Policy != nil
+}
+
+// Rank returns the rank of given data center; lower is preferred
+func (this *PromotionDataCenterPreference) Rank(dataCenter string) int {
+	rank := 0
+	if this.Policy == nil || !this.Policy.IgnoreFailedMasterDataCenter {
+		if dataCenter == this.FailedMasterDataCenter {
+			return rank
+		}
+		rank++
+	}
+	if this.Policy == nil {
+		return rank
+	}
+	for i, policyDataCenter := range this.Policy.DataCenters {
+		if policyDataCenter == dataCenter {
+			return rank + i
+		}
+	}
+	return rank + len(this.Policy.DataCenters)
+}
+
+// Reason explains the rank of given data center, for the audit
+func (this *PromotionDataCenterPreference) Reason(dataCenter string) string {
+	rank := this.Rank(dataCenter)
+	if dataCenter == this.FailedMasterDataCenter && (this.Policy == nil || !this.Policy.IgnoreFailedMasterDataCenter) {
+		return fmt.Sprintf("data center %s is the failed master's data center (rank %d)", dataCenter, rank)
+	}
+	if this.Policy == nil {
+		return fmt.Sprintf("data center %s is not the failed master's data center %s (rank %d)", dataCenter, this.FailedMasterDataCenter, rank)
+	}
+	for _, policyDataCenter := range this.Policy.DataCenters {
+		if policyDataCenter == dataCenter {
+			return fmt.Sprintf("data center %s is listed by the promotion data center policy (rank %d)", dataCenter, rank)
+		}
+	}
+	return fmt.Sprintf("data center %s is not listed by the promotion data center policy (rank %d)", dataCenter, rank)
+}
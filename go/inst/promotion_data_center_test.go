@@ -0,0 +1,52 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestPromotionDataCenterPreferenceWithoutPolicy(t *testing.T) {
+	preference := &PromotionDataCenterPreference{FailedMasterDataCenter: "dc1"}
+	test.S(t).ExpectFalse(preference.HasPolicy())
+	test.S(t).ExpectEquals(preference.Rank("dc1"), 0)
+	test.S(t).ExpectEquals(preference.Rank("dc2"), 1)
+	test.S(t).ExpectEquals(preference.Rank(""), 1)
+}
+
+func TestPromotionDataCenterPreferenceRank(t *testing.T) {
+	preference := &PromotionDataCenterPreference{FailedMasterDataCenter: "dc1", Policy: &config.DCPolicy{DataCenters: []string{"dc2", "dc3"}}}
+	test.S(t).ExpectEquals(preference.Rank("dc1"), 0)
+	test.S(t).ExpectEquals(preference.Rank("dc2"), 1)
+	test.S(t).ExpectEquals(preference.Rank("dc3"), 2)
+	test.S(t).ExpectEquals(preference.Rank("dc4"), 3)
+
+	preference.Policy.IgnoreFailedMasterDataCenter = true
+	test.S(t).ExpectEquals(preference.Rank("dc2"), 0)
+	test.S(t).ExpectEquals(preference.Rank("dc3"), 1)
+	test.S(t).ExpectEquals(preference.Rank("dc1"), 2)
+	test.S(t).ExpectEquals(preference.Rank("dc4"), 2)
+}
+
+func TestNewPromotionDataCenterPreference(t *testing.T) {
+	defer func(policies config.DCPolicies) { config.Config.ClusterPromotionDataCenterPolicies = policies }(config.Config.ClusterPromotionDataCenterPolicies)
+	config.Config.ClusterPromotionDataCenterPolicies = config.DCPolicies{
+		"primary.db:3306": {DataCenters: []string{"dc2"}},
+		"orders":          {IgnoreFailedMasterDataCenter: true, DataCenters: []string{"dc3"}},
+	}
+
+	preference := NewPromotionDataCenterPreference("dc1", "primary.db:3306", "")
+	test.S(t).ExpectTrue(preference.HasPolicy())
+	test.S(t).ExpectEquals(preference.Rank("dc2"), 1)
+
+	preference = NewPromotionDataCenterPreference("dc1", "orders.db:3306", "orders")
+	test.S(t).ExpectTrue(preference.HasPolicy())
+	test.S(t).ExpectEquals(preference.Rank("dc3"), 0)
+	test.S(t).ExpectEquals(preference.Reason("dc3"), "data center dc3 is listed by the promotion data center policy (rank 0)")
+	test.S(t).ExpectEquals(preference.Reason("dc1"), "data center dc1 is not listed by the promotion data center policy (rank 1)")
+
+	preference = NewPromotionDataCenterPreference("dc1", "other.db:3306", "other")
+	test.S(t).ExpectFalse(preference.HasPolicy())
+	test.S(t).ExpectEquals(preference.Reason("dc1"), "data center dc1 is the failed master's data center (rank 0)")
+}
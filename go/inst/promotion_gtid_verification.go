@@ -0,0 +1,95 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MissingTransactions reports a replica whose transactions a designated replica may not have: those it executed
+// which the designated replica has not, or why they cannot be told
+type MissingTransactions struct {
+	Key            InstanceKey
+	MissingGtidSet string
+	Reason         string
+}
+
+func (this *MissingTransactions) String() string {
+	if this.MissingGtidSet != "" {
+		return fmt.Sprintf("%+v executed %s, missing on designated replica", this.Key, this.MissingGtidSet)
+	}
+	return fmt.Sprintf("%+v cannot be verified: %s", this.Key, this.Reason)
+}
+
+// NewUnreachableMissingTransactions reports a replica which cannot be read, hence whose transactions cannot be verified
+func NewUnreachableMissingTransactions(key InstanceKey, err error) *MissingTransactions {
+	return &MissingTransactions{Key: key, Reason: fmt.Sprintf("unreachable: %+v", err)}
+}
+
+// findMissingTransactions returns the transactions given replica executed which designated has not. Errant
+// transactions of the replica, not originating from the master, are not expected on designated.
+func findMissingTransactions(designated *Instance, replica *Instance) *MissingTransactions {
+	report := &MissingTransactions{Key: replica.Key}
+	if !replica.SupportsOracleGTID || replica.ExecutedGtidSet == "" {
+		report.Reason = "no Oracle GTID executed set"
+		return report
+	}
+	missing, err := subtractGtidSets(replica.ExecutedGtidSet, designated.ExecutedGtidSet)
+	if err == nil && missing != "" && replica.GtidErrant != "" {
+		missing, err = subtractGtidSets(missing, replica.GtidErrant)
+	}
+	if err != nil {
+		report.Reason = err.Error()
+		return report
+	}
+	if missing == "" {
+		return nil
+	}
+	report.MissingGtidSet = missing
+	return report
+}
+
+// VerifyNoMissingTransactions verifies, by GTID sets, that designated is not missing transactions which any of
+// given replicas executed. Given unverifiable replicas, such as unreachable ones, fail verification as they are.
+// It returns a report per replica failing verification, along with an error summarizing them; designated itself
+// must have an Oracle GTID executed set.
+func VerifyNoMissingTransactions(designated *Instance, replicas [](*Instance), unverifiable [](*MissingTransactions)) (reports [](*MissingTransactions), err error) {
+	if !designated.SupportsOracleGTID || designated.ExecutedGtidSet == "" {
+		return reports, fmt.Errorf("%+v has no Oracle GTID executed set; cannot verify transactions", designated.Key)
+	}
+	reports = append(reports, unverifiable...)
+	for _, replica := range replicas {
+		if replica.Key.Equals(&designated.Key) {
+			continue
+		}
+		if report := findMissingTransactions(designated, replica); report != nil {
+			reports = append(reports, report)
+		}
+	}
+	if len(reports) == 0 {
+		return reports, nil
+	}
+	descriptions := []string{}
+	for _, report := range reports {
+		descriptions = append(descriptions, report.String())
+	}
+	if !designated.SQLThreadUpToDate() {
+		descriptions = append(descriptions, fmt.Sprintf("note that %+v has relay logs yet to apply", designated.Key))
+	}
+	return reports, fmt.Errorf("%+v may be missing transactions: %s", designated.Key, strings.Join(descriptions, "; "))
+}
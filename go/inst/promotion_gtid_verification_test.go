@@ -0,0 +1,66 @@
+package inst
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestVerifyNoMissingTransactions(t *testing.T) {
+	newGTIDInstance := func(hostname string, executedGtidSet string) *Instance {
+		return &Instance{
+			Key:                       InstanceKey{Hostname: hostname, Port: 3306},
+			SupportsOracleGTID:        true,
+			ExecutedGtidSet:           executedGtidSet,
+			ReadBinlogCoordinates:     BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 100},
+			ExecBinlogCoordinates:     BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 100},
+			ReplicationSQLThreadState: ReplicationThreadStateRunning,
+		}
+	}
+	designated := newGTIDInstance("designated", "00020192-1111-1111-1111-111111111111:1-100")
+	{
+		reports, err := VerifyNoMissingTransactions(designated, [](*Instance){
+			designated,
+			newGTIDInstance("behind", "00020192-1111-1111-1111-111111111111:1-90"),
+			newGTIDInstance("equal", "00020192-1111-1111-1111-111111111111:1-100"),
+		}, nil)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(reports), 0)
+	}
+	{
+		ahead := newGTIDInstance("ahead", "00020192-1111-1111-1111-111111111111:1-105")
+		noGTID := newGTIDInstance("nogtid", "")
+		noGTID.SupportsOracleGTID = false
+		reports, err := VerifyNoMissingTransactions(designated, [](*Instance){ahead, noGTID}, nil)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(len(reports), 2)
+		test.S(t).ExpectEquals(reports[0].Key.Hostname, "ahead")
+		test.S(t).ExpectEquals(reports[0].MissingGtidSet, "00020192-1111-1111-1111-111111111111:101-105")
+		test.S(t).ExpectEquals(reports[1].Key.Hostname, "nogtid")
+		test.S(t).ExpectTrue(reports[1].Reason != "")
+	}
+	{
+		// errant transactions of a sibling are not expected on designated
+		errant := newGTIDInstance("errant", "00020192-1111-1111-1111-111111111111:1-100,00020192-2222-2222-2222-222222222222:1-3")
+		errant.GtidErrant = "00020192-2222-2222-2222-222222222222:1-3"
+		reports, err := VerifyNoMissingTransactions(designated, [](*Instance){errant}, nil)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(reports), 0)
+	}
+	{
+		designated := newGTIDInstance("designated", "")
+		_, err := VerifyNoMissingTransactions(designated, [](*Instance){}, nil)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		unreachableKey := InstanceKey{Hostname: "unreachable", Port: 3306}
+		unverifiable := [](*MissingTransactions){NewUnreachableMissingTransactions(unreachableKey, fmt.Errorf("connection refused"))}
+		reports, err := VerifyNoMissingTransactions(designated, [](*Instance){newGTIDInstance("behind", "00020192-1111-1111-1111-111111111111:1-90")}, unverifiable)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(len(reports), 1)
+		test.S(t).ExpectEquals(reports[0].Key, unreachableKey)
+		test.S(t).ExpectTrue(strings.Contains(err.Error(), "unreachable:3306 cannot be verified: unreachable: connection refused"))
+	}
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// resolvePromotionMaxLagSeconds returns the effective maximum candidate lag for given cluster.
+// ClusterPromotionMaxLagSeconds, by cluster name then by alias, takes precedence over PromotionMaxLagSeconds.
+func resolvePromotionMaxLagSeconds(clusterName string, clusterAlias string) uint {
+	if maxLagSeconds, found := config.Config.ClusterPromotionMaxLagSeconds[clusterName]; found {
+		return maxLagSeconds
+	}
+	if clusterAlias != "" {
+		if maxLagSeconds, found := config.Config.ClusterPromotionMaxLagSeconds[clusterAlias]; found {
+			return maxLagSeconds
+		}
+	}
+	return config.Config.PromotionMaxLagSeconds
+}
+
+// PromotionMaxLagSeconds returns the maximum replication lag, in seconds, acceptable of a promotion candidate
+// in given cluster. 0 means no limit.
+func PromotionMaxLagSeconds(clusterName string) uint {
+	clusterAlias := ""
+	if len(config.Config.ClusterPromotionMaxLagSeconds) > 0 {
+		clusterAlias, _ = ReadAliasByClusterName(clusterName)
+	}
+	return resolvePromotionMaxLagSeconds(clusterName, clusterAlias)
+}
+
+// promotionLagRejection returns the reason for which given replica lags too much to be a promotion candidate,
+// or an empty string if it does not. The lag of an intentionally delayed replica is counted beyond its delay.
+// A replica whose lag is unknown, e.g. with its IO thread disconnected from a dead master, is not rejected: the
+// lag gate is not to stand in the way of a recovery.
+func promotionLagRejection(replica *Instance) string {
+	if !replica.SlaveLagSeconds.Valid {
+		return ""
+	}
+	maxLagSeconds := PromotionMaxLagSeconds(replica.ClusterName)
+	if maxLagSeconds == 0 {
+		return ""
+	}
+	lagSeconds := replica.SlaveLagSeconds.Int64 - int64(replica.SQLDelay)
+	if lagSeconds <= int64(maxLagSeconds) {
+		return ""
+	}
+	return fmt.Sprintf("replication lag of %ds exceeds the maximum of %ds acceptable of a promotion candidate", lagSeconds, maxLagSeconds)
+}
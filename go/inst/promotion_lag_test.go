@@ -0,0 +1,78 @@
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestResolvePromotionMaxLagSeconds(t *testing.T) {
+	defer func(maxLagSeconds uint) { config.Config.PromotionMaxLagSeconds = maxLagSeconds }(config.Config.PromotionMaxLagSeconds)
+	defer func(maxLags map[string]uint) {
+		config.Config.ClusterPromotionMaxLagSeconds = maxLags
+	}(config.Config.ClusterPromotionMaxLagSeconds)
+
+	config.Config.PromotionMaxLagSeconds = 60
+	config.Config.ClusterPromotionMaxLagSeconds = map[string]uint{
+		"strict":        5,
+		"lenient:3306":  0,
+		"customer:3306": 300,
+	}
+	test.S(t).ExpectEquals(resolvePromotionMaxLagSeconds("other:3306", ""), uint(60))
+	test.S(t).ExpectEquals(resolvePromotionMaxLagSeconds("other:3306", "strict"), uint(5))
+	test.S(t).ExpectEquals(resolvePromotionMaxLagSeconds("lenient:3306", "strict"), uint(0))
+	test.S(t).ExpectEquals(resolvePromotionMaxLagSeconds("customer:3306", ""), uint(300))
+}
+
+func TestPromotionLagRejection(t *testing.T) {
+	defer func(maxLagSeconds uint) { config.Config.PromotionMaxLagSeconds = maxLagSeconds }(config.Config.PromotionMaxLagSeconds)
+
+	replica := &Instance{SlaveLagSeconds: sql.NullInt64{Int64: 120, Valid: true}}
+	config.Config.PromotionMaxLagSeconds = 0
+	test.S(t).ExpectEquals(promotionLagRejection(replica), "")
+
+	config.Config.PromotionMaxLagSeconds = 60
+	test.S(t).ExpectNotEquals(promotionLagRejection(replica), "")
+
+	replica.SQLDelay = 90
+	test.S(t).ExpectEquals(promotionLagRejection(replica), "")
+
+	replica.SQLDelay = 0
+	replica.SlaveLagSeconds.Valid = false
+	test.S(t).ExpectEquals(promotionLagRejection(replica), "")
+}
+
+func TestChooseCandidateReplicaSkipsLaggingReplica(t *testing.T) {
+	defer func(maxLagSeconds uint) { config.Config.PromotionMaxLagSeconds = maxLagSeconds }(config.Config.PromotionMaxLagSeconds)
+
+	config.Config.PromotionMaxLagSeconds = 60
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.SlaveLagSeconds = sql.NullInt64{Int64: 1, Valid: true}
+	}
+	instancesMap[i830Key.StringCode()].SlaveLagSeconds.Int64 = 600
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, _, _, _, _, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i820Key)
+}
+
+func TestChooseCandidateReplicaAllLagging(t *testing.T) {
+	defer func(maxLagSeconds uint) { config.Config.PromotionMaxLagSeconds = maxLagSeconds }(config.Config.PromotionMaxLagSeconds)
+
+	config.Config.PromotionMaxLagSeconds = 60
+	instances, _ := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.SlaveLagSeconds = sql.NullInt64{Int64: 600, Valid: true}
+	}
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, aheadReplicas, _, laterReplicas, _, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i830Key)
+	test.S(t).ExpectEquals(len(aheadReplicas), 0)
+	test.S(t).ExpectEquals(len(laterReplicas), 5)
+}
@@ -0,0 +1,192 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CandidateReplicaReasons lists the considerations which applied to a replica in candidate selection
+type CandidateReplicaReasons struct {
+	Key     InstanceKey
+	Reasons []string
+}
+
+// CandidateReplicaPreview is the outcome candidate selection would have, were a master to fail now: the replica to
+// be promoted, and what would become of its siblings. It is computed from the replicas' state as last polled.
+type CandidateReplicaPreview struct {
+	MasterKey               InstanceKey
+	CandidateKey            InstanceKey
+	AheadReplicas           []InstanceKey // ahead of the candidate; would be lost
+	EqualReplicas           []InstanceKey // at the candidate's coordinates; would move below it
+	LaterReplicas           []InstanceKey // behind the candidate; would move below it
+	CannotReplicateReplicas []InstanceKey // unable to replicate from the candidate; would be lost
+	Replicas                []CandidateReplicaReasons
+	Error                   string // set when no valid candidate is found
+}
+
+func instanceKeysOf(instances [](*Instance)) []InstanceKey {
+	keys := []InstanceKey{}
+	for _, instance := range instances {
+		keys = append(keys, instance.Key)
+	}
+	return keys
+}
+
+// String returns a human readable description of the preview
+func (this *CandidateReplicaPreview) String() string {
+	lines := []string{fmt.Sprintf("candidate: %s", this.CandidateKey.DisplayString())}
+	if this.Error != "" {
+		lines = append(lines, fmt.Sprintf("error: %s", this.Error))
+	}
+	lines = append(lines,
+		fmt.Sprintf("ahead (lost): %s", displayInstanceKeys(this.AheadReplicas)),
+		fmt.Sprintf("equal: %s", displayInstanceKeys(this.EqualReplicas)),
+		fmt.Sprintf("later: %s", displayInstanceKeys(this.LaterReplicas)),
+		fmt.Sprintf("cannot replicate (lost): %s", displayInstanceKeys(this.CannotReplicateReplicas)),
+	)
+	for _, replica := range this.Replicas {
+		for _, reason := range replica.Reasons {
+			lines = append(lines, fmt.Sprintf("%s: %s", replica.Key.DisplayString(), reason))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// candidateReplicaReasons explains how given replica fared in candidate selection
+func candidateReplicaReasons(replica *Instance, candidateReplica *Instance, priorityMajorVersion string, priorityBinlogFormat string,
+	dataCenterPreference *PromotionDataCenterPreference, semiSyncAcknowledgers *InstanceKeyMap) (reasons []string) {
+	reasons = []string{}
+	isCandidate := candidateReplica != nil && replica.Key.Equals(&candidateReplica.Key)
+	if isCandidate {
+		reasons = append(reasons, "chosen as candidate replica")
+		if replica.IsDelayedReplica() {
+			reasons = append(reasons, fmt.Sprintf("delayed by %d seconds; the delay would be removed, and candidate selection run again", replica.SQLDelay))
+		}
+	}
+	if banReason := candidateReplicaBanReason(replica); banReason != "" {
+		reasons = append(reasons, fmt.Sprintf("banned from promotion because %s", banReason))
+	}
+	if !replica.IsLastCheckValid {
+		reasons = append(reasons, "last check is invalid")
+	}
+	if !replica.LogBinEnabled || !replica.LogSlaveUpdatesEnabled {
+		reasons = append(reasons, "binary logs or log_slave_updates disabled; cannot act as master of its siblings")
+	}
+	if replica.IsBinlogServer() {
+		reasons = append(reasons, "binlog server")
+	}
+	if err := checkVersionCompatibility(priorityMajorVersion, replica.Version); err != nil {
+		reasons = append(reasons, fmt.Sprintf("version %s is incompatible with the prevailing %s: %+v", replica.Version, priorityMajorVersion, err))
+	}
+	if IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
+		reasons = append(reasons, fmt.Sprintf("binlog format %s is incompatible with the prevailing %s", replica.Binlog_format, priorityBinlogFormat))
+	}
+	if health := ReadCandidateHealth(&replica.Key); health.Vetoed {
+		reasons = append(reasons, fmt.Sprintf("%s; not considered as candidate", health.Reason))
+	} else if IsCandidateHealthCheckConfigured() {
+		reasons = append(reasons, fmt.Sprintf("health score %.2f", health.Score))
+	}
+	if IsInPromotionCooldown(replica) {
+		reasons = append(reasons, "in promotion cool-down; only considered when no other replica is valid")
+	}
+	if replica.IsDelayedReplica() && !isCandidate {
+		reasons = append(reasons, fmt.Sprintf("delayed by %d seconds; only considered when no other replica is valid", replica.SQLDelay))
+	}
+	if lagReason := promotionLagRejection(replica); lagReason != "" {
+		reasons = append(reasons, fmt.Sprintf("%s; only considered when no other replica is valid", lagReason))
+	}
+	if dataCenterPreference.HasPolicy() {
+		reasons = append(reasons, dataCenterPreference.Reason(replica.DataCenter))
+	}
+	if semiSyncAcknowledgers != nil {
+		if semiSyncAcknowledgers.HasKey(replica.Key) {
+			reasons = append(reasons, "acknowledged semi-sync transactions")
+		} else {
+			reasons = append(reasons, "did not acknowledge semi-sync transactions")
+		}
+	}
+	if candidateReplica == nil || isCandidate {
+		return reasons
+	}
+	if canReplicate, err := replica.CanReplicateFrom(candidateReplica); !canReplicate {
+		reasons = append(reasons, fmt.Sprintf("cannot replicate from candidate: %+v", err))
+	} else if replica.ExecBinlogCoordinates.SmallerThan(&candidateReplica.ExecBinlogCoordinates) {
+		reasons = append(reasons, "behind candidate; would move below it")
+	} else if replica.ExecBinlogCoordinates.Equals(&candidateReplica.ExecBinlogCoordinates) {
+		reasons = append(reasons, "at candidate's coordinates; would move below it")
+	} else {
+		reasons = append(reasons, "ahead of candidate; would be lost")
+	}
+	return reasons
+}
+
+// PreviewCandidateReplica reports the replica which GetCandidateReplica would choose to promote, were given master to
+// fail now, along with the reasons. Replication is not stopped, nothing is changed and nothing is audited.
+func PreviewCandidateReplica(masterKey *InstanceKey) (*CandidateReplicaPreview, error) {
+	preview := &CandidateReplicaPreview{MasterKey: *masterKey}
+
+	dataCenterPreference := &PromotionDataCenterPreference{}
+	master, _, _ := ReadInstance(masterKey)
+	if master != nil {
+		dataCenterPreference = NewPromotionDataCenterPreference(master.DataCenter, master.ClusterName, master.SuggestedClusterAlias)
+	}
+	replicas, err := getReplicasForSorting(masterKey, false)
+	if err != nil {
+		return preview, err
+	}
+	if len(replicas) == 0 {
+		return preview, fmt.Errorf("No replicas found for %+v", *masterKey)
+	}
+	semiSyncAcknowledgers := semiSyncAcknowledgingReplicas(master, replicas)
+	replicas = sortedReplicasPromotionPreference(replicas, NoStopReplication, 0, dataCenterPreference, semiSyncAcknowledgers)
+
+	candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := chooseCandidateReplicaPromotionPreference(replicas, dataCenterPreference, semiSyncAcknowledgers)
+	if err != nil {
+		preview.Error = err.Error()
+	}
+	if candidateReplica != nil {
+		preview.CandidateKey = candidateReplica.Key
+	}
+	preview.AheadReplicas = instanceKeysOf(aheadReplicas)
+	preview.EqualReplicas = instanceKeysOf(equalReplicas)
+	preview.LaterReplicas = instanceKeysOf(laterReplicas)
+	preview.CannotReplicateReplicas = instanceKeysOf(cannotReplicateReplicas)
+
+	priorityMajorVersion, _ := getPriorityMajorVersionForCandidate(replicas)
+	priorityBinlogFormat, _ := getPriorityBinlogFormatForCandidate(replicas)
+	for _, replica := range replicas {
+		preview.Replicas = append(preview.Replicas, CandidateReplicaReasons{
+			Key:     replica.Key,
+			Reasons: candidateReplicaReasons(replica, candidateReplica, priorityMajorVersion, priorityBinlogFormat, dataCenterPreference, semiSyncAcknowledgers),
+		})
+	}
+	return preview, nil
+}
+
+// PreviewClusterCandidateReplica is PreviewCandidateReplica, for the master of given cluster
+func PreviewClusterCandidateReplica(clusterName string) (*CandidateReplicaPreview, error) {
+	clusterMasters, err := ReadClusterMaster(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusterMasters) != 1 {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
+	}
+	return PreviewCandidateReplica(&clusterMasters[0].Key)
+}
@@ -0,0 +1,44 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestCandidateReplicaReasons(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	candidate := instancesMap[i830Key.StringCode()]
+	noPreference := &PromotionDataCenterPreference{}
+	{
+		reasons := candidateReplicaReasons(candidate, candidate, "5.6", "STATEMENT", noPreference, nil)
+		test.S(t).ExpectEquals(len(reasons), 1)
+		test.S(t).ExpectEquals(reasons[0], "chosen as candidate replica")
+	}
+	{
+		reasons := candidateReplicaReasons(instancesMap[i720Key.StringCode()], candidate, "5.6", "STATEMENT", noPreference, nil)
+		test.S(t).ExpectEquals(len(reasons), 1)
+		test.S(t).ExpectEquals(reasons[0], "behind candidate; would move below it")
+	}
+	{
+		replica := instancesMap[i810Key.StringCode()]
+		replica.PromotionRule = MustNotPromoteRule
+		replica.LogSlaveUpdatesEnabled = false
+		reasons := candidateReplicaReasons(replica, candidate, "5.6", "STATEMENT", noPreference, nil)
+		test.S(t).ExpectEquals(len(reasons), 3)
+		test.S(t).ExpectEquals(reasons[0], "banned from promotion because of promotion rule")
+		test.S(t).ExpectEquals(reasons[2], "behind candidate; would move below it")
+	}
+	{
+		acknowledgers := NewInstanceKeyMap()
+		acknowledgers.AddKey(candidate.Key)
+		ahead := &Instance{Key: InstanceKey{Hostname: "i840", Port: 3306}, ServerID: 840, Version: "5.6.7", Binlog_format: "STATEMENT",
+			ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql.000008", LogPos: 40}}
+		applyGeneralGoodToGoReplicationParams([](*Instance){ahead})
+		reasons := candidateReplicaReasons(ahead, candidate, "5.6", "STATEMENT", noPreference, acknowledgers)
+		test.S(t).ExpectEquals(len(reasons), 2)
+		test.S(t).ExpectEquals(reasons[0], "did not acknowledge semi-sync transactions")
+		test.S(t).ExpectEquals(reasons[1], "ahead of candidate; would be lost")
+	}
+}
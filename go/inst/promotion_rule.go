@@ -18,6 +18,8 @@ package inst
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // CandidatePromotionRule describe the promotion preference/rule for an instance.
@@ -56,3 +58,27 @@ func ParseCandidatePromotionRule(ruleName string) (CandidatePromotionRule, error
 		return CandidatePromotionRule(""), fmt.Errorf("Invalid CandidatePromotionRule: %v", ruleName)
 	}
 }
+
+// ParseCandidatePromotionRuleAndPriority parses a promotion rule, optionally followed by a numeric promotion
+// priority, e.g. "prefer" or "prefer:10". The priority defaults to 0.
+func ParseCandidatePromotionRuleAndPriority(value string) (promotionRule CandidatePromotionRule, promotionPriority int, err error) {
+	tokens := strings.SplitN(value, ":", 2)
+	if promotionRule, err = ParseCandidatePromotionRule(tokens[0]); err != nil {
+		return promotionRule, promotionPriority, err
+	}
+	if len(tokens) == 2 {
+		if promotionPriority, err = strconv.Atoi(tokens[1]); err != nil {
+			return CandidatePromotionRule(""), 0, fmt.Errorf("Invalid promotion priority: %v", tokens[1])
+		}
+	}
+	return promotionRule, promotionPriority, nil
+}
+
+// IsPreferredForPromotionOver returns true when this instance's promotion rule is preferred over the other's,
+// or, given the same rule, when its promotion priority is higher
+func (this *Instance) IsPreferredForPromotionOver(other *Instance) bool {
+	if this.PromotionRule != other.PromotionRule {
+		return this.PromotionRule.SmallerThan(other.PromotionRule)
+	}
+	return this.PromotionPriority > other.PromotionPriority
+}
@@ -0,0 +1,48 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseCandidatePromotionRuleAndPriority(t *testing.T) {
+	{
+		promotionRule, promotionPriority, err := ParseCandidatePromotionRuleAndPriority("prefer")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(promotionRule, CandidatePromotionRule(PreferPromoteRule))
+		test.S(t).ExpectEquals(promotionPriority, 0)
+	}
+	{
+		promotionRule, promotionPriority, err := ParseCandidatePromotionRuleAndPriority("prefer:10")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(promotionRule, CandidatePromotionRule(PreferPromoteRule))
+		test.S(t).ExpectEquals(promotionPriority, 10)
+	}
+	{
+		promotionRule, promotionPriority, err := ParseCandidatePromotionRuleAndPriority("prefer_not:-5")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(promotionRule, CandidatePromotionRule(PreferNotPromoteRule))
+		test.S(t).ExpectEquals(promotionPriority, -5)
+	}
+	{
+		_, _, err := ParseCandidatePromotionRuleAndPriority("prefer:high")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, _, err := ParseCandidatePromotionRuleAndPriority("preferred:10")
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestIsPreferredForPromotionOver(t *testing.T) {
+	nvme := &Instance{PromotionRule: PreferPromoteRule, PromotionPriority: 20}
+	spinning := &Instance{PromotionRule: PreferPromoteRule, PromotionPriority: 10}
+	neutral := &Instance{PromotionRule: NeutralPromoteRule, PromotionPriority: 100}
+
+	test.S(t).ExpectTrue(nvme.IsPreferredForPromotionOver(spinning))
+	test.S(t).ExpectFalse(spinning.IsPreferredForPromotionOver(nvme))
+	test.S(t).ExpectTrue(spinning.IsPreferredForPromotionOver(neutral))
+	test.S(t).ExpectFalse(neutral.IsPreferredForPromotionOver(spinning))
+	test.S(t).ExpectFalse(nvme.IsPreferredForPromotionOver(nvme))
+}
@@ -0,0 +1,144 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+var instanceTagsCache = cache.New(time.Duration(config.Config.InstancePollSeconds)*time.Second, time.Second)
+
+// resolvePromotionTagConstraint returns the effective promotion tag constraint for given cluster.
+// ClusterPromotionTagConstraints, by cluster name then by alias, takes precedence over PromotionTagConstraint.
+func resolvePromotionTagConstraint(clusterName string, clusterAlias string) string {
+	if constraint, found := config.Config.ClusterPromotionTagConstraints[clusterName]; found {
+		return constraint
+	}
+	if clusterAlias != "" {
+		if constraint, found := config.Config.ClusterPromotionTagConstraints[clusterAlias]; found {
+			return constraint
+		}
+	}
+	return config.Config.PromotionTagConstraint
+}
+
+// PromotionTagConstraint returns the tags, in the syntax of the tagged command, which promotion candidates of given
+// cluster must all have. Empty when there is no constraint.
+func PromotionTagConstraint(clusterName string) string {
+	clusterAlias := ""
+	if len(config.Config.ClusterPromotionTagConstraints) > 0 {
+		clusterAlias, _ = ReadAliasByClusterName(clusterName)
+	}
+	return resolvePromotionTagConstraint(clusterName, clusterAlias)
+}
+
+// tagsSatisfy returns true when given tags satisfy all of given constraint tags. A constraint tag may require
+// a tag (role), a tag value (role=failover-target), a different tag value (~role=backup), or a tag's absence (~backup).
+func tagsSatisfy(tags [](*Tag), constraint [](*Tag)) bool {
+	for _, required := range constraint {
+		found := false
+		valueMatches := false
+		for _, tag := range tags {
+			if tag.TagName == required.TagName {
+				found = true
+				valueMatches = (tag.TagValue == required.TagValue)
+				break
+			}
+		}
+		switch {
+		case required.HasValue && !required.Negate:
+			if !found || !valueMatches {
+				return false
+			}
+		case !required.HasValue && !required.Negate:
+			if !found {
+				return false
+			}
+		case required.HasValue && required.Negate:
+			if !found || valueMatches {
+				return false
+			}
+		default:
+			if found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// readAllInstanceTags reads the tags of all tagged instances
+func readAllInstanceTags() (map[InstanceKey][](*Tag), error) {
+	instanceTags := make(map[InstanceKey][](*Tag))
+	query := `
+		select
+			hostname, port, tag_name, tag_value
+		from
+			database_instance_tags
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		key := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		instanceTags[key] = append(instanceTags[key], &Tag{TagName: m.GetString("tag_name"), TagValue: m.GetString("tag_value")})
+		return nil
+	})
+	return instanceTags, log.Errore(err)
+}
+
+// cachedInstanceTags returns the tags of all tagged instances. These are cached briefly, as this is checked
+// repeatedly when evaluating promotion candidates.
+func cachedInstanceTags() (map[InstanceKey][](*Tag), error) {
+	if cached, found := instanceTagsCache.Get("tags"); found {
+		return cached.(map[InstanceKey][](*Tag)), nil
+	}
+	instanceTags, err := readAllInstanceTags()
+	if err != nil {
+		return instanceTags, err
+	}
+	instanceTagsCache.Set("tags", instanceTags, cache.DefaultExpiration)
+	return instanceTags, nil
+}
+
+// promotionTagConstraintViolation returns the reason for which given replica does not satisfy the promotion tag
+// constraint of its cluster, or an empty string if it does. An invalid constraint, or failure to read tags, is
+// logged and not enforced: the constraint is not to stand in the way of a recovery for lack of information.
+func promotionTagConstraintViolation(replica *Instance) string {
+	constraintString := PromotionTagConstraint(replica.ClusterName)
+	if constraintString == "" {
+		return ""
+	}
+	constraint, err := ParseIntersectTags(constraintString)
+	if err != nil {
+		log.Errorf("promotionTagConstraintViolation: invalid promotion tag constraint %s: %+v", constraintString, err)
+		return ""
+	}
+	instanceTags, err := cachedInstanceTags()
+	if err != nil {
+		return ""
+	}
+	if tagsSatisfy(instanceTags[replica.Key], constraint) {
+		return ""
+	}
+	return fmt.Sprintf("it does not satisfy promotion tag constraint %s", constraintString)
+}
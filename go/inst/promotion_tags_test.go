@@ -0,0 +1,52 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestResolvePromotionTagConstraint(t *testing.T) {
+	defer func(constraint string) { config.Config.PromotionTagConstraint = constraint }(config.Config.PromotionTagConstraint)
+	defer func(constraints map[string]string) {
+		config.Config.ClusterPromotionTagConstraints = constraints
+	}(config.Config.ClusterPromotionTagConstraints)
+
+	config.Config.PromotionTagConstraint = "~no-promote"
+	config.Config.ClusterPromotionTagConstraints = map[string]string{
+		"strict":        "role=failover-target",
+		"lenient:3306":  "",
+		"customer:3306": "dc=east",
+	}
+	test.S(t).ExpectEquals(resolvePromotionTagConstraint("other:3306", ""), "~no-promote")
+	test.S(t).ExpectEquals(resolvePromotionTagConstraint("other:3306", "strict"), "role=failover-target")
+	test.S(t).ExpectEquals(resolvePromotionTagConstraint("lenient:3306", "strict"), "")
+	test.S(t).ExpectEquals(resolvePromotionTagConstraint("customer:3306", ""), "dc=east")
+}
+
+func TestTagsSatisfy(t *testing.T) {
+	tags := [](*Tag){
+		{TagName: "role", TagValue: "failover-target"},
+		{TagName: "dc", TagValue: "east"},
+	}
+	satisfy := func(constraintString string) bool {
+		constraint, err := ParseIntersectTags(constraintString)
+		test.S(t).ExpectNil(err)
+		return tagsSatisfy(tags, constraint)
+	}
+	test.S(t).ExpectTrue(satisfy("role=failover-target"))
+	test.S(t).ExpectTrue(satisfy("role"))
+	test.S(t).ExpectTrue(satisfy("role,dc=east"))
+	test.S(t).ExpectTrue(satisfy("~role=backup"))
+	test.S(t).ExpectTrue(satisfy("~no-promote"))
+	test.S(t).ExpectFalse(satisfy("role=backup"))
+	test.S(t).ExpectFalse(satisfy("role,dc=west"))
+	test.S(t).ExpectFalse(satisfy("~role=failover-target"))
+	test.S(t).ExpectFalse(satisfy("~dc"))
+	test.S(t).ExpectFalse(satisfy("owner"))
+	test.S(t).ExpectFalse(satisfy("~owner=dba"))
+
+	test.S(t).ExpectFalse(tagsSatisfy(nil, [](*Tag){{TagName: "role", TagValue: "failover-target", HasValue: true}}))
+	test.S(t).ExpectTrue(tagsSatisfy(nil, [](*Tag){{TagName: "no-promote", Negate: true}}))
+}
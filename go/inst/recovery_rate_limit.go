@@ -0,0 +1,109 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// recoveryRateLimitWindow is the period over which automated recoveries are counted against a cluster's limit
+const recoveryRateLimitWindow = time.Hour
+
+// RecoveryRateLimit counts the automated recoveries on a cluster within the current hour long window. Once the
+// cluster's limit is reached, automated recoveries back off: they are refused until BackoffUntil, for a period
+// which doubles with each consecutive time the limit is hit.
+type RecoveryRateLimit struct {
+	ClusterName     string
+	WindowStart     time.Time
+	RecoveriesCount uint
+	BackoffCount    uint
+	BackoffUntil    time.Time
+	LimitPerHour    uint
+	IsBackingOff    bool
+}
+
+func NewRecoveryRateLimit(clusterName string) *RecoveryRateLimit {
+	return &RecoveryRateLimit{ClusterName: clusterName}
+}
+
+func (this *RecoveryRateLimit) String() string {
+	if this.IsBackingOff {
+		return fmt.Sprintf("%s: %d/%d recoveries this hour; backing off until %s (backoff %d)", this.ClusterName, this.RecoveriesCount, this.LimitPerHour, this.BackoffUntil.Format(time.RFC3339), this.BackoffCount)
+	}
+	return fmt.Sprintf("%s: %d/%d recoveries this hour", this.ClusterName, this.RecoveriesCount, this.LimitPerHour)
+}
+
+// recoveryRateLimitBackoff returns the backoff following given number of consecutive times the limit was hit: the
+// initial backoff, doubling with each further time, up to the maximum backoff
+func recoveryRateLimitBackoff(backoffCount uint, initialBackoff time.Duration, maxBackoff time.Duration) time.Duration {
+	backoff := initialBackoff
+	for i := uint(1); i < backoffCount && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// Admit decides whether an automated recovery may run at given time, under given hourly limit, and updates the
+// counters accordingly: an admitted recovery is counted, and a recovery refused by the limit begins a backoff.
+// A backoff doubles the previous one, unless that ended over an hour ago. Recoveries refused while backing off
+// change nothing. Returns whether the counters changed, and, for a refused recovery, why it was refused.
+func (this *RecoveryRateLimit) Admit(now time.Time, limitPerHour uint, initialBackoff time.Duration, maxBackoff time.Duration) (admitted bool, changed bool, reason string) {
+	if now.Before(this.BackoffUntil) {
+		return false, false, fmt.Sprintf("%s is rate limited: backing off automated recoveries until %s", this.ClusterName, this.BackoffUntil.Format(time.RFC3339))
+	}
+	if now.Sub(this.WindowStart) >= recoveryRateLimitWindow {
+		this.WindowStart = now
+		this.RecoveriesCount = 0
+	}
+	if this.RecoveriesCount < limitPerHour {
+		this.RecoveriesCount++
+		return true, true, ""
+	}
+	if now.Sub(this.BackoffUntil) >= recoveryRateLimitWindow {
+		this.BackoffCount = 0
+	}
+	this.BackoffCount++
+	backoff := recoveryRateLimitBackoff(this.BackoffCount, initialBackoff, maxBackoff)
+	this.BackoffUntil = now.Add(backoff)
+	return false, true, fmt.Sprintf("%s is rate limited: %d automated recoveries within the hour reached its limit of %d; backing off for %+v", this.ClusterName, this.RecoveriesCount, limitPerHour, backoff)
+}
+
+// resolveRecoveryRateLimitPerHour returns the configured hourly limit of automated recoveries for given cluster.
+// ClusterRecoveryRateLimitsPerHour, by cluster name then by alias, takes precedence over RecoveryRateLimitPerHour.
+func resolveRecoveryRateLimitPerHour(clusterName string, clusterAlias string) uint {
+	if limit, found := config.Config.ClusterRecoveryRateLimitsPerHour[clusterName]; found {
+		return limit
+	}
+	if clusterAlias != "" {
+		if limit, found := config.Config.ClusterRecoveryRateLimitsPerHour[clusterAlias]; found {
+			return limit
+		}
+	}
+	return config.Config.RecoveryRateLimitPerHour
+}
+
+// GetRecoveryRateLimitPerHour returns the hourly limit of automated recoveries on given cluster; 0 for no limit
+func GetRecoveryRateLimitPerHour(clusterName string) uint {
+	clusterAlias, _ := ReadAliasByClusterName(clusterName)
+	return resolveRecoveryRateLimitPerHour(clusterName, clusterAlias)
+}
@@ -0,0 +1,138 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// rateLimitUnixtime returns the unix time of given time; 0 for the zero time
+func rateLimitUnixtime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// WriteRecoveryRateLimit records the automated recovery counters of a cluster
+func WriteRecoveryRateLimit(rateLimit *RecoveryRateLimit) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			replace into cluster_recovery_rate_limit (
+				cluster_name, window_start_unixtime, recoveries_count, backoff_count, backoff_until_unixtime
+			) values (
+				?, ?, ?, ?, ?
+			)
+			`, rateLimit.ClusterName, rateLimitUnixtime(rateLimit.WindowStart), rateLimit.RecoveriesCount, rateLimit.BackoffCount, rateLimitUnixtime(rateLimit.BackoffUntil),
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// DeleteRecoveryRateLimit resets the automated recovery counters, and any backoff, of given cluster, or of all
+// clusters when clusterName is empty
+func DeleteRecoveryRateLimit(clusterName string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from cluster_recovery_rate_limit where (cluster_name = ? or ? = '')
+			`, clusterName, clusterName,
+		)
+		return log.Errore(err)
+	}
+	if err := ExecDBWriteFunc(writeFunc); err != nil {
+		return err
+	}
+	if clusterName == "" {
+		clusterName = "all clusters"
+	}
+	AuditOperation("reset-recovery-rate-limit", nil, fmt.Sprintf("%s: automated recovery counters reset", clusterName))
+	return nil
+}
+
+// ReadRecoveryRateLimits reads the automated recovery counters of given cluster, or of all clusters when
+// clusterName is empty, along with each cluster's limit and whether it is backing off
+func ReadRecoveryRateLimits(clusterName string) ([]*RecoveryRateLimit, error) {
+	res := []*RecoveryRateLimit{}
+	query := `
+		select
+			cluster_name,
+			window_start_unixtime,
+			recoveries_count,
+			backoff_count,
+			backoff_until_unixtime
+		from
+			cluster_recovery_rate_limit
+		where
+			(cluster_name = ? or ? = '')
+		order by
+			cluster_name
+		`
+	now := time.Now()
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName, clusterName), func(m sqlutils.RowMap) error {
+		rateLimit := NewRecoveryRateLimit(m.GetString("cluster_name"))
+		rateLimit.WindowStart = time.Unix(m.GetInt64("window_start_unixtime"), 0)
+		rateLimit.RecoveriesCount = m.GetUint("recoveries_count")
+		rateLimit.BackoffCount = m.GetUint("backoff_count")
+		rateLimit.BackoffUntil = time.Unix(m.GetInt64("backoff_until_unixtime"), 0)
+		rateLimit.IsBackingOff = now.Before(rateLimit.BackoffUntil)
+
+		res = append(res, rateLimit)
+		return nil
+	})
+	if err != nil {
+		return res, log.Errore(err)
+	}
+	for _, rateLimit := range res {
+		rateLimit.LimitPerHour = GetRecoveryRateLimitPerHour(rateLimit.ClusterName)
+	}
+	return res, nil
+}
+
+// ReadRecoveryRateLimit reads the automated recovery counters of given cluster; fresh counters when none are recorded
+func ReadRecoveryRateLimit(clusterName string) (*RecoveryRateLimit, error) {
+	rateLimits, err := ReadRecoveryRateLimits(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(rateLimits) > 0 {
+		return rateLimits[0], nil
+	}
+	rateLimit := NewRecoveryRateLimit(clusterName)
+	rateLimit.LimitPerHour = GetRecoveryRateLimitPerHour(clusterName)
+	return rateLimit, nil
+}
+
+// ExpireRecoveryRateLimits removes the counters of clusters with no automated recovery, nor any backoff, within the
+// past hour
+func ExpireRecoveryRateLimits() error {
+	expiry := time.Now().Add(-recoveryRateLimitWindow).Unix()
+	_, err := db.ExecOrchestrator(`
+			delete from cluster_recovery_rate_limit
+			where
+				window_start_unixtime < ?
+				and backoff_until_unixtime < ?
+		`, expiry, expiry,
+	)
+	return log.Errore(err)
+}
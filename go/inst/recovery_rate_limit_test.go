@@ -0,0 +1,75 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestRecoveryRateLimitBackoff(t *testing.T) {
+	test.S(t).ExpectEquals(recoveryRateLimitBackoff(1, 10*time.Minute, time.Hour), 10*time.Minute)
+	test.S(t).ExpectEquals(recoveryRateLimitBackoff(2, 10*time.Minute, time.Hour), 20*time.Minute)
+	test.S(t).ExpectEquals(recoveryRateLimitBackoff(3, 10*time.Minute, time.Hour), 40*time.Minute)
+	test.S(t).ExpectEquals(recoveryRateLimitBackoff(4, 10*time.Minute, time.Hour), time.Hour)
+	test.S(t).ExpectEquals(recoveryRateLimitBackoff(40, 10*time.Minute, time.Hour), time.Hour)
+}
+
+func TestRecoveryRateLimitAdmit(t *testing.T) {
+	rateLimit := NewRecoveryRateLimit("c1:3306")
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	admit := func(at time.Time) (bool, bool) {
+		admitted, changed, _ := rateLimit.Admit(at, 2, 10*time.Minute, time.Hour)
+		return admitted, changed
+	}
+
+	admitted, changed := admit(now)
+	test.S(t).ExpectTrue(admitted && changed)
+	admitted, _ = admit(now.Add(time.Minute))
+	test.S(t).ExpectTrue(admitted)
+	test.S(t).ExpectEquals(rateLimit.RecoveriesCount, uint(2))
+
+	// limit reached: backs off
+	admitted, changed = admit(now.Add(2 * time.Minute))
+	test.S(t).ExpectFalse(admitted)
+	test.S(t).ExpectTrue(changed)
+	test.S(t).ExpectEquals(rateLimit.BackoffCount, uint(1))
+	test.S(t).ExpectTrue(rateLimit.BackoffUntil.Equal(now.Add(12 * time.Minute)))
+
+	// refused while backing off, with no change
+	admitted, changed = admit(now.Add(5 * time.Minute))
+	test.S(t).ExpectFalse(admitted || changed)
+
+	// backoff over, limit still reached within the window: backoff doubles
+	admitted, changed = admit(now.Add(13 * time.Minute))
+	test.S(t).ExpectFalse(admitted)
+	test.S(t).ExpectTrue(changed)
+	test.S(t).ExpectEquals(rateLimit.BackoffCount, uint(2))
+	test.S(t).ExpectTrue(rateLimit.BackoffUntil.Equal(now.Add(33 * time.Minute)))
+
+	// a new window admits again
+	admitted, _ = admit(now.Add(time.Hour))
+	test.S(t).ExpectTrue(admitted)
+	test.S(t).ExpectEquals(rateLimit.RecoveriesCount, uint(1))
+
+	// backoff restarts once the previous one ended over an hour ago
+	admit(now.Add(2 * time.Hour))
+	admit(now.Add(2*time.Hour + time.Minute))
+	admitted, _ = admit(now.Add(2*time.Hour + 2*time.Minute))
+	test.S(t).ExpectFalse(admitted)
+	test.S(t).ExpectEquals(rateLimit.BackoffCount, uint(1))
+}
+
+func TestResolveRecoveryRateLimitPerHour(t *testing.T) {
+	defer func(limit uint, limits map[string]uint) {
+		config.Config.RecoveryRateLimitPerHour = limit
+		config.Config.ClusterRecoveryRateLimitsPerHour = limits
+	}(config.Config.RecoveryRateLimitPerHour, config.Config.ClusterRecoveryRateLimitsPerHour)
+
+	config.Config.RecoveryRateLimitPerHour = 3
+	config.Config.ClusterRecoveryRateLimitsPerHour = map[string]uint{"c1:3306": 1, "flappy": 0}
+	test.S(t).ExpectEquals(resolveRecoveryRateLimitPerHour("c1:3306", "c1"), uint(1))
+	test.S(t).ExpectEquals(resolveRecoveryRateLimitPerHour("c2:3306", "flappy"), uint(0))
+	test.S(t).ExpectEquals(resolveRecoveryRateLimitPerHour("c3:3306", ""), uint(3))
+}
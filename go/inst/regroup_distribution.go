@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	// RegroupWeightTagName tags an instance with its share of orphaned replicas to take over in a regroup
+	RegroupWeightTagName = "regroup-weight"
+	// RegroupCapacityTagName tags an instance with the maximum number of replicas it may have after a regroup
+	RegroupCapacityTagName = "regroup-capacity"
+)
+
+// RegroupTarget is a surviving instance which may take over orphaned replicas, along with its weight and capacity
+type RegroupTarget struct {
+	Instance *Instance
+	Weight   uint // share of orphaned replicas, relative to other targets. 0 when not a target
+	Capacity uint // maximum number of replicas, including existing ones. 0 for unlimited
+}
+
+// NewRegroupTarget returns the regroup target of given instance, given its regroup-weight and regroup-capacity tags
+func NewRegroupTarget(instance *Instance, tags [](*Tag)) (*RegroupTarget, error) {
+	target := &RegroupTarget{Instance: instance}
+	for _, tag := range tags {
+		var value *uint
+		switch tag.TagName {
+		case RegroupWeightTagName:
+			value = &target.Weight
+		case RegroupCapacityTagName:
+			value = &target.Capacity
+		default:
+			continue
+		}
+		parsed, err := strconv.ParseUint(tag.TagValue, 10, 32)
+		if err != nil {
+			return target, fmt.Errorf("NewRegroupTarget: %+v has invalid %s tag value: %s", instance.Key, tag.TagName, tag.TagValue)
+		}
+		*value = uint(parsed)
+	}
+	return target, nil
+}
+
+// ReadRegroupTarget reads the regroup target of given instance off its tags
+func ReadRegroupTarget(instance *Instance) (*RegroupTarget, error) {
+	tags, err := ReadInstanceTags(&instance.Key)
+	if err != nil {
+		return nil, err
+	}
+	return NewRegroupTarget(instance, tags)
+}
+
+// DistributeReplicas assigns given replicas among given targets, in proportion to the targets' weights and within
+// their capacity. Replicas the targets already have count towards both, such that the resulting tree is balanced.
+// Replicas which fit no target are returned as unassigned.
+func DistributeReplicas(replicas [](*Instance), targets [](*RegroupTarget)) (distribution map[InstanceKey][](*Instance), unassigned [](*Instance)) {
+	distribution = make(map[InstanceKey][](*Instance))
+	for _, replica := range replicas {
+		var chosen *RegroupTarget
+		var chosenLoad float64
+		for _, target := range targets {
+			if target.Weight == 0 || target.Instance.Key.Equals(&replica.Key) {
+				continue
+			}
+			replicasCount := len(target.Instance.SlaveHosts) + len(distribution[target.Instance.Key])
+			if target.Capacity > 0 && uint(replicasCount) >= target.Capacity {
+				continue
+			}
+			load := float64(replicasCount+1) / float64(target.Weight)
+			if chosen == nil || load < chosenLoad {
+				chosen = target
+				chosenLoad = load
+			}
+		}
+		if chosen == nil {
+			unassigned = append(unassigned, replica)
+			continue
+		}
+		distribution[chosen.Instance.Key] = append(distribution[chosen.Instance.Key], replica)
+	}
+	return distribution, unassigned
+}
@@ -0,0 +1,65 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewRegroupTarget(t *testing.T) {
+	instance := &Instance{Key: i710Key}
+	{
+		target, err := NewRegroupTarget(instance, [](*Tag){{TagName: "role", TagValue: "im"}})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(target.Weight, uint(0))
+		test.S(t).ExpectEquals(target.Capacity, uint(0))
+	}
+	{
+		target, err := NewRegroupTarget(instance, [](*Tag){{TagName: RegroupCapacityTagName, TagValue: "10"}, {TagName: RegroupWeightTagName, TagValue: "2"}})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(target.Weight, uint(2))
+		test.S(t).ExpectEquals(target.Capacity, uint(10))
+	}
+	{
+		_, err := NewRegroupTarget(instance, [](*Tag){{TagName: RegroupWeightTagName, TagValue: "heavy"}})
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestDistributeReplicas(t *testing.T) {
+	replicas := [](*Instance){}
+	for _, hostname := range []string{"r1", "r2", "r3", "r4", "r5", "r6"} {
+		replicas = append(replicas, &Instance{Key: InstanceKey{Hostname: hostname, Port: 3306}})
+	}
+	{
+		heavy := &RegroupTarget{Instance: &Instance{Key: i710Key}, Weight: 2}
+		light := &RegroupTarget{Instance: &Instance{Key: i720Key}, Weight: 1}
+		unweighted := &RegroupTarget{Instance: &Instance{Key: i730Key}}
+		distribution, unassigned := DistributeReplicas(replicas, [](*RegroupTarget){heavy, light, unweighted})
+		test.S(t).ExpectEquals(len(distribution[i710Key]), 4)
+		test.S(t).ExpectEquals(len(distribution[i720Key]), 2)
+		test.S(t).ExpectEquals(len(distribution[i730Key]), 0)
+		test.S(t).ExpectEquals(len(unassigned), 0)
+	}
+	{
+		// Existing replicas count towards the share
+		loaded := &RegroupTarget{Instance: &Instance{Key: i710Key, SlaveHosts: *NewInstanceKeyMap()}, Weight: 1}
+		loaded.Instance.SlaveHosts.AddKey(InstanceKey{Hostname: "e1", Port: 3306})
+		loaded.Instance.SlaveHosts.AddKey(InstanceKey{Hostname: "e2", Port: 3306})
+		empty := &RegroupTarget{Instance: &Instance{Key: i720Key}, Weight: 1}
+		distribution, unassigned := DistributeReplicas(replicas, [](*RegroupTarget){loaded, empty})
+		test.S(t).ExpectEquals(len(distribution[i710Key]), 2)
+		test.S(t).ExpectEquals(len(distribution[i720Key]), 4)
+		test.S(t).ExpectEquals(len(unassigned), 0)
+	}
+	{
+		// Capacity is never exceeded
+		capped := &RegroupTarget{Instance: &Instance{Key: i710Key}, Weight: 5, Capacity: 2}
+		other := &RegroupTarget{Instance: &Instance{Key: i720Key}, Weight: 1, Capacity: 3}
+		distribution, unassigned := DistributeReplicas(replicas, [](*RegroupTarget){capped, other})
+		test.S(t).ExpectEquals(len(distribution[i710Key]), 2)
+		test.S(t).ExpectEquals(len(distribution[i720Key]), 3)
+		test.S(t).ExpectEquals(len(unassigned), 1)
+		test.S(t).ExpectEquals(unassigned[0].Key.Hostname, "r6")
+	}
+}
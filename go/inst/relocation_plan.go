@@ -0,0 +1,483 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+type RelocationStrategy string
+
+const (
+	RelocationStrategyRepoint      RelocationStrategy = "repoint"
+	RelocationStrategyEquivalence                     = "equivalence"
+	RelocationStrategyBinlogServer                    = "binlog-server"
+	RelocationStrategyGTID                            = "gtid"
+	RelocationStrategyPseudoGTID                      = "pseudo-gtid"
+	RelocationStrategyFilePos                         = "file-pos"
+	RelocationStrategyMultiStep                       = "multi-step"
+)
+
+// RelocationPlan describes what a relocation operation would do, as computed by a dry run: which operation and
+// strategy would be used, at which coordinates the instance would be pointed at its new master, and which instances
+// would be placed in maintenance or have their replication stopped. A multi-step relocation lists its steps.
+type RelocationPlan struct {
+	Operation   string
+	Key         InstanceKey
+	MasterKey   InstanceKey
+	Strategy    RelocationStrategy
+	GTIDHint    OperationGTIDHint
+	Coordinates *BinlogCoordinates
+	// CoordinatesAreCurrent is true when Coordinates are read off a running server; the operation would
+	// re-read them once replication is stopped
+	CoordinatesAreCurrent bool
+	MaintenanceKeys       []InstanceKey
+	StopReplicationKeys   []InstanceKey
+	Steps                 []*RelocationPlan
+	Warnings              []string
+	// TopologyDiff is the topology before and after the relocation; only computed for the plan as a whole
+	TopologyDiff *TopologyDiff
+}
+
+func newRelocationPlan(operation string, instance *Instance, masterKey *InstanceKey, strategy RelocationStrategy) *RelocationPlan {
+	return &RelocationPlan{
+		Operation:           operation,
+		Key:                 instance.Key,
+		MasterKey:           *masterKey,
+		Strategy:            strategy,
+		GTIDHint:            GTIDHintDeny,
+		MaintenanceKeys:     []InstanceKey{},
+		StopReplicationKeys: []InstanceKey{},
+		Steps:               []*RelocationPlan{},
+		Warnings:            []string{},
+	}
+}
+
+func newMultiStepRelocationPlan(operation string, instance *Instance, masterKey *InstanceKey, steps ...*RelocationPlan) *RelocationPlan {
+	plan := newRelocationPlan(operation, instance, masterKey, RelocationStrategyMultiStep)
+	plan.GTIDHint = GTIDHintNeutral
+	plan.Steps = steps
+	return plan
+}
+
+// String returns a human readable description of the plan, one line per step
+func (this *RelocationPlan) String() string {
+	return strings.Join(this.describe(""), "\n")
+}
+
+func (this *RelocationPlan) describe(indent string) (lines []string) {
+	line := fmt.Sprintf("%s%s: %s below %s via %s", indent, this.Operation, this.Key.DisplayString(), this.MasterKey.DisplayString(), this.Strategy)
+	if this.Coordinates != nil {
+		line = fmt.Sprintf("%s at %s", line, this.Coordinates.DisplayString())
+		if this.CoordinatesAreCurrent {
+			line = fmt.Sprintf("%s (current)", line)
+		}
+	}
+	lines = append(lines, line)
+	if len(this.MaintenanceKeys) > 0 {
+		lines = append(lines, fmt.Sprintf("%s  maintenance: %s", indent, displayInstanceKeys(this.MaintenanceKeys)))
+	}
+	if len(this.StopReplicationKeys) > 0 {
+		lines = append(lines, fmt.Sprintf("%s  stop replication: %s", indent, displayInstanceKeys(this.StopReplicationKeys)))
+	}
+	for _, warning := range this.Warnings {
+		lines = append(lines, fmt.Sprintf("%s  warning: %s", indent, warning))
+	}
+	for i, step := range this.Steps {
+		lines = append(lines, fmt.Sprintf("%s  step %d:", indent, i+1))
+		lines = append(lines, step.describe(indent+"    ")...)
+	}
+	return lines
+}
+
+func displayInstanceKeys(keys []InstanceKey) string {
+	tokens := []string{}
+	for _, key := range keys {
+		tokens = append(tokens, key.DisplayString())
+	}
+	return strings.Join(tokens, ", ")
+}
+
+// The Plan* functions below mirror the checks and decisions of their respective operations in instance_topology.go,
+// reading instance and backend state only. They must be kept in sync with those operations.
+
+// planRepoint is the plan of Repoint(), given validated instance and master
+func planRepoint(instance *Instance, masterKey *InstanceKey, masterIsBinlogServer bool, gtidHint OperationGTIDHint) *RelocationPlan {
+	strategy := RelocationStrategyRepoint
+	if masterIsBinlogServer {
+		strategy = RelocationStrategyBinlogServer
+	}
+	plan := newRelocationPlan("repoint", instance, masterKey, RelocationStrategy(strategy))
+	plan.GTIDHint = gtidHint
+	plan.Coordinates = &instance.ExecBinlogCoordinates
+	plan.CoordinatesAreCurrent = !instance.ReplicationThreadsStopped()
+	plan.MaintenanceKeys = append(plan.MaintenanceKeys, instance.Key)
+	plan.StopReplicationKeys = append(plan.StopReplicationKeys, instance.Key)
+	return plan
+}
+
+// PlanRepoint is the dry run of Repoint()
+func PlanRepoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint OperationGTIDHint) (*RelocationPlan, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	if !instance.IsReplica() {
+		return nil, fmt.Errorf("instance is not a replica: %+v", *instanceKey)
+	}
+	if masterKey == nil {
+		masterKey = &instance.MasterKey
+	}
+	master, err := ReadTopologyInstance(masterKey)
+	if err != nil {
+		if master, _, err = ReadInstance(masterKey); err != nil {
+			return nil, err
+		}
+	}
+	if canReplicate, err := instance.CanReplicateFrom(master); !canReplicate {
+		return nil, err
+	}
+	if master.IsBinlogServer() {
+		if !instance.ExecBinlogCoordinates.SmallerThanOrEquals(&master.SelfBinlogCoordinates) {
+			return nil, fmt.Errorf("repoint: binlog server %+v is not sufficiently up to date to repoint %+v below it", *masterKey, *instanceKey)
+		}
+	}
+	return planRepoint(instance, masterKey, master.IsBinlogServer(), gtidHint), nil
+}
+
+// PlanMoveEquivalent is the dry run of MoveEquivalent()
+func PlanMoveEquivalent(instanceKey, otherKey *InstanceKey) (*RelocationPlan, error) {
+	instance, found, err := ReadInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Instance not found: %+v", *instanceKey)
+	}
+	if instance.Key.Equals(otherKey) {
+		return nil, fmt.Errorf("MoveEquivalent: attempt to move an instance below itself %+v", instance.Key)
+	}
+	instanceCoordinates := &InstanceBinlogCoordinates{Key: instance.MasterKey, Coordinates: instance.ExecBinlogCoordinates}
+	binlogCoordinates, err := GetEquivalentBinlogCoordinatesFor(instanceCoordinates, otherKey)
+	if err != nil {
+		return nil, err
+	}
+	if binlogCoordinates == nil {
+		return nil, fmt.Errorf("No equivalent coordinates found for %+v replicating from %+v at %+v", instance.Key, instance.MasterKey, instance.ExecBinlogCoordinates)
+	}
+	plan := newRelocationPlan("move-equivalent", instance, otherKey, RelocationStrategyEquivalence)
+	plan.GTIDHint = GTIDHintNeutral
+	plan.Coordinates = binlogCoordinates
+	plan.StopReplicationKeys = append(plan.StopReplicationKeys, instance.Key)
+	if !instance.ReplicationThreadsStopped() {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%+v is replicating; the operation is aborted if its coordinates change once replication is stopped", instance.Key))
+	}
+	return plan, nil
+}
+
+// PlanMoveUp is the dry run of MoveUp()
+func PlanMoveUp(instanceKey *InstanceKey) (*RelocationPlan, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	if !instance.IsReplica() {
+		return nil, fmt.Errorf("instance is not a replica: %+v", instanceKey)
+	}
+	rinstance, _, _ := ReadInstance(&instance.Key)
+	if canMove, merr := rinstance.CanMove(); !canMove {
+		return nil, merr
+	}
+	master, err := GetInstanceMaster(instance)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot GetInstanceMaster() for %+v. error=%+v", instance.Key, err)
+	}
+	if !master.IsReplica() {
+		return nil, fmt.Errorf("master is not a replica itself: %+v", master.Key)
+	}
+	if canReplicate, err := instance.CanReplicateFrom(master); !canReplicate {
+		return nil, err
+	}
+	if master.IsBinlogServer() {
+		plan := planRepoint(instance, &master.MasterKey, false, GTIDHintDeny)
+		plan.Operation = "move-up"
+		plan.Strategy = RelocationStrategyBinlogServer
+		return plan, nil
+	}
+	if grandparent := moveUpViaGTIDGrandparent(instance, master); grandparent != nil {
+		// With GTID auto positioning, no coordinates are used, and the master keeps replicating
+		plan := newRelocationPlan("move-up", instance, &master.MasterKey, RelocationStrategyGTID)
+		plan.GTIDHint = GTIDHintForce
+		plan.MaintenanceKeys = append(plan.MaintenanceKeys, instance.Key)
+		plan.StopReplicationKeys = append(plan.StopReplicationKeys, instance.Key)
+		return plan, nil
+	}
+	plan := newRelocationPlan("move-up", instance, &master.MasterKey, RelocationStrategyFilePos)
+	plan.Coordinates = &master.ExecBinlogCoordinates
+	plan.CoordinatesAreCurrent = !master.ReplicationThreadsStopped()
+	plan.MaintenanceKeys = append(plan.MaintenanceKeys, instance.Key, master.Key)
+	if !instance.UsingMariaDBGTID {
+		plan.StopReplicationKeys = append(plan.StopReplicationKeys, master.Key)
+	}
+	plan.StopReplicationKeys = append(plan.StopReplicationKeys, instance.Key)
+	return plan, nil
+}
+
+// PlanMoveBelow is the dry run of MoveBelow()
+func PlanMoveBelow(instanceKey, siblingKey *InstanceKey) (*RelocationPlan, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	sibling, err := ReadTopologyInstance(siblingKey)
+	if err != nil {
+		return nil, err
+	}
+	if sibling.IsBinlogServer() {
+		plan, err := PlanRepoint(instanceKey, &sibling.Key, GTIDHintDeny)
+		if err != nil {
+			return nil, err
+		}
+		plan.Operation = "move-below"
+		return plan, nil
+	}
+	rinstance, _, _ := ReadInstance(&instance.Key)
+	if canMove, merr := rinstance.CanMove(); !canMove {
+		return nil, merr
+	}
+	rinstance, _, _ = ReadInstance(&sibling.Key)
+	if canMove, merr := rinstance.CanMove(); !canMove {
+		return nil, merr
+	}
+	if !InstancesAreSiblings(instance, sibling) {
+		return nil, fmt.Errorf("instances are not siblings: %+v, %+v", *instanceKey, *siblingKey)
+	}
+	if canReplicate, err := instance.CanReplicateFrom(sibling); !canReplicate {
+		return nil, err
+	}
+	plan := newRelocationPlan("move-below", instance, &sibling.Key, RelocationStrategyFilePos)
+	// Both siblings are first synced to the more advanced of the two; the sibling's own coordinates are then used
+	plan.Coordinates = &sibling.SelfBinlogCoordinates
+	plan.CoordinatesAreCurrent = true
+	plan.MaintenanceKeys = append(plan.MaintenanceKeys, instance.Key, sibling.Key)
+	plan.StopReplicationKeys = append(plan.StopReplicationKeys, instance.Key, sibling.Key)
+	return plan, nil
+}
+
+// planMoveBelowViaGTID is the dry run of moveInstanceBelowViaGTID()
+func planMoveBelowViaGTID(instance, otherInstance *Instance) (*RelocationPlan, error) {
+	rinstance, _, _ := ReadInstance(&instance.Key)
+	if canMove, merr := rinstance.CanMoveViaMatch(); !canMove {
+		return nil, merr
+	}
+	if canReplicate, err := instance.CanReplicateFrom(otherInstance); !canReplicate {
+		return nil, err
+	}
+	if err := CheckMoveViaGTID(instance, otherInstance); err != nil {
+		return nil, err
+	}
+	// With GTID auto positioning, no coordinates are used
+	plan := newRelocationPlan("move-below-gtid", instance, &otherInstance.Key, RelocationStrategyGTID)
+	plan.GTIDHint = GTIDHintForce
+	plan.MaintenanceKeys = append(plan.MaintenanceKeys, instance.Key)
+	plan.StopReplicationKeys = append(plan.StopReplicationKeys, instance.Key)
+	return plan, nil
+}
+
+// PlanMoveBelowGTID is the dry run of MoveBelowGTID()
+func PlanMoveBelowGTID(instanceKey, otherKey *InstanceKey) (*RelocationPlan, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	other, err := ReadTopologyInstance(otherKey)
+	if err != nil {
+		return nil, err
+	}
+	return planMoveBelowViaGTID(instance, other)
+}
+
+// PlanMatchBelow is the dry run of MatchBelow(). The matching coordinates are computed by correlating
+// binary logs, as the operation would; this reads, but does not change, both servers.
+func PlanMatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance bool) (*RelocationPlan, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	if config.Config.PseudoGTIDPattern == "" {
+		return nil, fmt.Errorf("PseudoGTIDPattern not configured; cannot use Pseudo-GTID")
+	}
+	if instanceKey.Equals(otherKey) {
+		return nil, fmt.Errorf("MatchBelow: attempt to match an instance below itself %+v", *instanceKey)
+	}
+	otherInstance, err := ReadTopologyInstance(otherKey)
+	if err != nil {
+		return nil, err
+	}
+	rinstance, _, _ := ReadInstance(&instance.Key)
+	if canMove, merr := rinstance.CanMoveViaMatch(); !canMove {
+		return nil, merr
+	}
+	if canReplicate, err := instance.CanReplicateFrom(otherInstance); !canReplicate {
+		return nil, err
+	}
+	if err := CheckPseudoGTIDBinlogFilters(instance, otherInstance); err != nil {
+		return nil, err
+	}
+	if otherInstance.IsBinlogServer() {
+		return nil, fmt.Errorf("Cannot use PseudoGTID with Binlog Server %+v", otherInstance.Key)
+	}
+	plan := newRelocationPlan("match-below", instance, &otherInstance.Key, RelocationStrategyPseudoGTID)
+	if requireInstanceMaintenance {
+		if inMaintenance, err := InMaintenance(&otherInstance.Key); err != nil {
+			return nil, err
+		} else if inMaintenance {
+			return nil, fmt.Errorf("Cannot match below %+v; it is in maintenance", otherInstance.Key)
+		}
+		plan.MaintenanceKeys = append(plan.MaintenanceKeys, instance.Key)
+	}
+	plan.StopReplicationKeys = append(plan.StopReplicationKeys, instance.Key)
+
+	nextBinlogCoordinatesToMatch, countMatchedEvents, err := CorrelateBinlogCoordinates(instance, nil, otherInstance)
+	if err != nil {
+		return nil, err
+	}
+	if countMatchedEvents == 0 {
+		return nil, fmt.Errorf("Unexpected: 0 events processed while iterating logs. nextBinlogCoordinatesToMatch: %+v", nextBinlogCoordinatesToMatch)
+	}
+	plan.Coordinates = nextBinlogCoordinatesToMatch
+	plan.CoordinatesAreCurrent = !instance.ReplicationThreadsStopped()
+	return plan, nil
+}
+
+// planRelocateBelowInternal is the dry run of relocateBelowInternal(). It follows the same order of preference.
+func planRelocateBelowInternal(instance, other *Instance) (*RelocationPlan, error) {
+	if canReplicate, err := instance.CanReplicateFrom(other); !canReplicate {
+		return nil, fmt.Errorf("%+v cannot replicate from %+v. Reason: %+v", instance.Key, other.Key, err)
+	}
+	if InstanceIsMasterOf(other, instance) {
+		return PlanRepoint(&instance.Key, &other.Key, GTIDHintNeutral)
+	}
+	if !instance.IsBinlogServer() {
+		if plan, err := PlanMoveEquivalent(&instance.Key, &other.Key); err == nil {
+			return plan, nil
+		}
+	}
+	if InstancesAreSiblings(instance, other) && other.IsBinlogServer() {
+		return PlanMoveBelow(&instance.Key, &other.Key)
+	}
+	instanceMaster, _, err := ReadInstance(&instance.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+	if instanceMaster != nil && instanceMaster.MasterKey.Equals(&other.Key) && instanceMaster.IsBinlogServer() {
+		return PlanRepoint(&instance.Key, &instanceMaster.MasterKey, GTIDHintDeny)
+	}
+	if other.IsBinlogServer() {
+		if instanceMaster != nil && instanceMaster.IsBinlogServer() && InstancesAreSiblings(instanceMaster, other) {
+			return PlanRepoint(&instance.Key, &other.Key, GTIDHintDeny)
+		}
+		otherMaster, found, err := ReadInstance(&other.MasterKey)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("Cannot find master %+v", other.MasterKey)
+		}
+		if !other.IsLastCheckValid {
+			return nil, fmt.Errorf("Binlog server %+v is not reachable. It would take two steps to relocate %+v below it, and I won't even do the first step.", other.Key, instance.Key)
+		}
+		firstStep, err := planRelocateBelowInternal(instance, otherMaster)
+		if err != nil {
+			return nil, err
+		}
+		// Coordinates of the second step are only known once the first step completes
+		secondStep := planRepoint(instance, &other.Key, true, GTIDHintDeny)
+		secondStep.Coordinates = nil
+		secondStep.CoordinatesAreCurrent = false
+		return newMultiStepRelocationPlan("relocate-below", instance, &other.Key, firstStep, secondStep), nil
+	}
+	if instance.IsBinlogServer() {
+		return nil, fmt.Errorf("Relocating binlog server %+v below %+v turns to be too complex; please do it manually", instance.Key, other.Key)
+	}
+	if _, _, gtidCompatible := instancesAreGTIDAndCompatible(instance, other); gtidCompatible {
+		return planMoveBelowViaGTID(instance, other)
+	}
+	if instance.UsingPseudoGTID && other.UsingPseudoGTID && CheckPseudoGTIDBinlogFilters(instance, other) == nil {
+		return PlanMatchBelow(&instance.Key, &other.Key, true)
+	}
+	if InstancesAreSiblings(instance, other) {
+		if !other.IsCoMaster || other.ReadOnly {
+			return PlanMoveBelow(&instance.Key, &other.Key)
+		}
+	}
+	if instanceMaster != nil && instanceMaster.MasterKey.Equals(&other.Key) {
+		return PlanMoveUp(&instance.Key)
+	}
+	if instanceMaster != nil && instanceMaster.IsBinlogServer() {
+		firstStep, err := PlanMoveUp(&instance.Key)
+		if err != nil {
+			return nil, err
+		}
+		// Plan the remainder as if the first step had completed
+		movedInstance := *instance
+		movedInstance.MasterKey = instanceMaster.MasterKey
+		secondStep, err := planRelocateBelowInternal(&movedInstance, other)
+		if err != nil {
+			return nil, err
+		}
+		return newMultiStepRelocationPlan("relocate-below", instance, &other.Key, firstStep, secondStep), nil
+	}
+	return nil, fmt.Errorf("Relocating %+v below %+v turns to be too complex; please do it manually", instance.Key, other.Key)
+}
+
+// PlanRelocateBelow is the dry run of RelocateBelow(): it returns the plan RelocateBelow would follow,
+// without changing replication
+func PlanRelocateBelow(instanceKey, otherKey *InstanceKey, allowWAN bool) (*RelocationPlan, error) {
+	instance, found, err := ReadInstance(instanceKey)
+	if err != nil || !found {
+		return nil, fmt.Errorf("Error reading %+v", *instanceKey)
+	}
+	other, found, err := ReadInstance(otherKey)
+	if err != nil || !found {
+		return nil, fmt.Errorf("Error reading %+v", *otherKey)
+	}
+	if other.IsDescendantOf(instance) {
+		return nil, fmt.Errorf("relocate: %+v is a descendant of %+v", *otherKey, instance.Key)
+	}
+	if err := CheckWANRelocation(instance, other, allowWAN); err != nil {
+		return nil, err
+	}
+	plan, err := planRelocateBelowInternal(instance, other)
+	if err != nil {
+		return nil, err
+	}
+	if replicas, err := ReadReplicaInstances(instanceKey); err == nil {
+		if warning := binlogEncryptionRelocationWarning(instance, other, replicas); warning != "" {
+			plan.Warnings = append(plan.Warnings, warning)
+		}
+	}
+	currentMasters, err := readClustersMasters(instance.Key, other.Key)
+	if err != nil {
+		return nil, err
+	}
+	plan.TopologyDiff = computeTopologyDiff(currentMasters, []TopologyEdgeChange{{Key: instance.Key, FromMasterKey: instance.MasterKey, ToMasterKey: plan.MasterKey}})
+	return plan, nil
+}
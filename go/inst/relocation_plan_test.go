@@ -0,0 +1,41 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestPlanRepoint(t *testing.T) {
+	instances, _ := generateTestInstances()
+	instance := instances[0]
+	instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+	plan := planRepoint(instance, &i720Key, true, GTIDHintDeny)
+	test.S(t).ExpectEquals(plan.Strategy, RelocationStrategy(RelocationStrategyBinlogServer))
+	test.S(t).ExpectEquals(plan.MasterKey, i720Key)
+	test.S(t).ExpectTrue(plan.Coordinates.Equals(&instance.ExecBinlogCoordinates))
+	test.S(t).ExpectTrue(plan.CoordinatesAreCurrent)
+	test.S(t).ExpectEquals(len(plan.MaintenanceKeys), 1)
+	test.S(t).ExpectEquals(len(plan.StopReplicationKeys), 1)
+}
+
+func TestRelocationPlanString(t *testing.T) {
+	instances, _ := generateTestInstances()
+	instance := instances[0]
+
+	firstStep := newRelocationPlan("move-up", instance, &i730Key, RelocationStrategyFilePos)
+	firstStep.Coordinates = &BinlogCoordinates{LogFile: "mysql.000007", LogPos: 30}
+	firstStep.MaintenanceKeys = append(firstStep.MaintenanceKeys, i710Key, i720Key)
+	secondStep := newRelocationPlan("move-below-gtid", instance, &i810Key, RelocationStrategyGTID)
+	plan := newMultiStepRelocationPlan("relocate-below", instance, &i810Key, firstStep, secondStep)
+	plan.Warnings = append(plan.Warnings, "mixed encryption")
+
+	expected := `relocate-below: i710:3306 below i810:3306 via multi-step
+  warning: mixed encryption
+  step 1:
+    move-up: i710:3306 below i730:3306 via file-pos at mysql.000007:30
+      maintenance: i710:3306, i720:3306
+  step 2:
+    move-below-gtid: i710:3306 below i810:3306 via gtid`
+	test.S(t).ExpectEquals(plan.String(), expected)
+}
@@ -0,0 +1,133 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+)
+
+const (
+	RelocationOperationRepoint       = "repoint"
+	RelocationOperationMoveBelowGTID = "move-below-gtid"
+)
+
+// Relocation is the most recent planned relocation of an instance by a simple operation (repoint, or GTID move),
+// along with the state required to undo it within RelocationUndoWindowSeconds
+type Relocation struct {
+	Key                     InstanceKey
+	Operation               string
+	MasterKey               InstanceKey
+	PreviousMasterKey       InstanceKey
+	PreviousExecCoordinates BinlogCoordinates
+	Owner                   string
+	RelocatedTimestamp      string
+}
+
+func (this *Relocation) String() string {
+	return fmt.Sprintf("%s %s: %s -> %s via %s, at %s by %s",
+		this.RelocatedTimestamp, this.Key.DisplayString(), this.PreviousMasterKey.DisplayString(), this.MasterKey.DisplayString(),
+		this.Operation, this.PreviousExecCoordinates.DisplayString(), this.Owner)
+}
+
+// newRelocation returns the relocation of given instance, as read before relocation, below given master; or nil
+// when it is not to be recorded. Only planned relocations which change the instance's master are recorded.
+func newRelocation(ctx context.Context, operation string, instance *Instance, masterKey *InstanceKey) *Relocation {
+	if config.Config.RelocationUndoWindowSeconds == 0 {
+		return nil
+	}
+	origin := masterChangeOriginFromContext(ctx)
+	if origin.Cause != MasterChangeCausePlanned {
+		return nil
+	}
+	if instance.MasterKey.Equals(masterKey) {
+		return nil
+	}
+	return &Relocation{
+		Key:                     instance.Key,
+		Operation:               operation,
+		MasterKey:               *masterKey,
+		PreviousMasterKey:       instance.MasterKey,
+		PreviousExecCoordinates: instance.ExecBinlogCoordinates,
+		Owner:                   origin.Owner,
+	}
+}
+
+// recordRelocation records the relocation of given instance, as read before relocation, below given master, such
+// that it may be undone. Failure to record is logged and does not fail the relocation.
+func recordRelocation(ctx context.Context, operation string, instance *Instance, masterKey *InstanceKey) {
+	relocation := newRelocation(ctx, operation, instance, masterKey)
+	if relocation == nil {
+		return
+	}
+	if err := WriteLastRelocation(relocation); err != nil {
+		log.Errorf("recordRelocation: %+v: %+v", instance.Key, err)
+	}
+}
+
+// UndoLastRelocation moves given instance back below the master it replicated from before its most recent
+// relocation, provided that relocation took place within RelocationUndoWindowSeconds, and the instance has not
+// moved since.
+func UndoLastRelocation(instanceKey *InstanceKey) (*Instance, *Relocation, error) {
+	return UndoLastRelocationContext(context.Background(), instanceKey)
+}
+
+// UndoLastRelocationContext is UndoLastRelocation, bounded by given context
+func UndoLastRelocationContext(ctx context.Context, instanceKey *InstanceKey) (*Instance, *Relocation, error) {
+	relocation, found, err := ReadLastRelocation(instanceKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("undo-last-relocation: no relocation of %+v within the last %d seconds to undo", *instanceKey, config.Config.RelocationUndoWindowSeconds)
+	}
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, relocation, err
+	}
+	if !instance.MasterKey.Equals(&relocation.MasterKey) {
+		return instance, relocation, fmt.Errorf("undo-last-relocation: %+v was relocated below %+v, but now replicates from %+v. Will not undo", *instanceKey, relocation.MasterKey, instance.MasterKey)
+	}
+	switch relocation.Operation {
+	case RelocationOperationMoveBelowGTID:
+		instance, err = MoveBelowGTIDContext(ctx, instanceKey, &relocation.PreviousMasterKey)
+	case RelocationOperationRepoint:
+		// Repointing back is only valid while the previous master has the instance's coordinates
+		previousMaster, rerr := ReadTopologyInstance(&relocation.PreviousMasterKey)
+		if rerr != nil {
+			return instance, relocation, fmt.Errorf("undo-last-relocation: cannot read previous master %+v: %+v", relocation.PreviousMasterKey, rerr)
+		}
+		if !instance.ExecBinlogCoordinates.SmallerThanOrEquals(&previousMaster.SelfBinlogCoordinates) {
+			return instance, relocation, fmt.Errorf("undo-last-relocation: previous master %+v at %+v does not have coordinates %+v of %+v. Will not repoint", relocation.PreviousMasterKey, previousMaster.SelfBinlogCoordinates, instance.ExecBinlogCoordinates, *instanceKey)
+		}
+		instance, err = RepointContext(ctx, instanceKey, &relocation.PreviousMasterKey, GTIDHintNeutral)
+	default:
+		return instance, relocation, fmt.Errorf("undo-last-relocation: unsupported operation %s", relocation.Operation)
+	}
+	if err != nil {
+		return instance, relocation, err
+	}
+	// The undo is itself a relocation; it is not to be undone in turn
+	if err := DeleteLastRelocation(instanceKey); err != nil {
+		log.Errore(err)
+	}
+	AuditOperation("undo-last-relocation", instanceKey, fmt.Sprintf("undid %s by %s: moved %+v back below %+v", relocation.Operation, relocation.Owner, *instanceKey, relocation.PreviousMasterKey))
+	return instance, relocation, nil
+}
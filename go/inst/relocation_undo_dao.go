@@ -0,0 +1,118 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteLastRelocation records the most recent relocation of an instance, replacing any previous one
+func WriteLastRelocation(relocation *Relocation) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into database_instance_last_relocation (
+				hostname, port, operation, master_host, master_port, previous_master_host, previous_master_port,
+				previous_exec_master_log_file, previous_exec_master_log_pos, owner, relocated_timestamp
+			) values (
+				?, ?, ?, ?, ?, ?, ?,
+				?, ?, ?, NOW()
+			) on duplicate key update
+				operation=values(operation),
+				master_host=values(master_host),
+				master_port=values(master_port),
+				previous_master_host=values(previous_master_host),
+				previous_master_port=values(previous_master_port),
+				previous_exec_master_log_file=values(previous_exec_master_log_file),
+				previous_exec_master_log_pos=values(previous_exec_master_log_pos),
+				owner=values(owner),
+				relocated_timestamp=values(relocated_timestamp)
+			`, relocation.Key.Hostname, relocation.Key.Port, relocation.Operation,
+			relocation.MasterKey.Hostname, relocation.MasterKey.Port,
+			relocation.PreviousMasterKey.Hostname, relocation.PreviousMasterKey.Port,
+			relocation.PreviousExecCoordinates.LogFile, relocation.PreviousExecCoordinates.LogPos,
+			relocation.Owner,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// DeleteLastRelocation forgets the most recent relocation of an instance
+func DeleteLastRelocation(instanceKey *InstanceKey) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from database_instance_last_relocation where hostname = ? and port = ?
+			`, instanceKey.Hostname, instanceKey.Port,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadLastRelocation reads the most recent relocation of given instance, provided it took place within
+// RelocationUndoWindowSeconds
+func ReadLastRelocation(instanceKey *InstanceKey) (relocation *Relocation, found bool, err error) {
+	query := `
+		select
+			hostname,
+			port,
+			operation,
+			master_host,
+			master_port,
+			previous_master_host,
+			previous_master_port,
+			previous_exec_master_log_file,
+			previous_exec_master_log_pos,
+			owner,
+			relocated_timestamp
+		from
+			database_instance_last_relocation
+		where
+			hostname = ?
+			and port = ?
+			and relocated_timestamp >= NOW() - INTERVAL ? SECOND
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(instanceKey.Hostname, instanceKey.Port, config.Config.RelocationUndoWindowSeconds), func(m sqlutils.RowMap) error {
+		relocation = &Relocation{}
+		relocation.Key.Hostname = m.GetString("hostname")
+		relocation.Key.Port = m.GetInt("port")
+		relocation.Operation = m.GetString("operation")
+		relocation.MasterKey.Hostname = m.GetString("master_host")
+		relocation.MasterKey.Port = m.GetInt("master_port")
+		relocation.PreviousMasterKey.Hostname = m.GetString("previous_master_host")
+		relocation.PreviousMasterKey.Port = m.GetInt("previous_master_port")
+		relocation.PreviousExecCoordinates.LogFile = m.GetString("previous_exec_master_log_file")
+		relocation.PreviousExecCoordinates.LogPos = m.GetInt64("previous_exec_master_log_pos")
+		relocation.Owner = m.GetString("owner")
+		relocation.RelocatedTimestamp = m.GetString("relocated_timestamp")
+		return nil
+	})
+	return relocation, (relocation != nil), log.Errore(err)
+}
+
+// ExpireLastRelocations removes relocations which may no longer be undone
+func ExpireLastRelocations() error {
+	_, err := db.ExecOrchestrator(`
+			delete from database_instance_last_relocation where relocated_timestamp < NOW() - INTERVAL ? SECOND
+		`, config.Config.RelocationUndoWindowSeconds,
+	)
+	return log.Errore(err)
+}
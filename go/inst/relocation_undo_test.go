@@ -0,0 +1,42 @@
+package inst
+
+import (
+	"context"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewRelocation(t *testing.T) {
+	defer func(seconds uint) { config.Config.RelocationUndoWindowSeconds = seconds }(config.Config.RelocationUndoWindowSeconds)
+	config.Config.RelocationUndoWindowSeconds = 600
+
+	instance := &Instance{Key: i720Key, MasterKey: i710Key, ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 4}}
+	ctx := WithMasterChangeOrigin(context.Background(), NewMasterChangeOrigin(MasterChangeCausePlanned, "someone", ""))
+	{
+		relocation := newRelocation(ctx, RelocationOperationMoveBelowGTID, instance, &i730Key)
+		test.S(t).ExpectNotNil(relocation)
+		test.S(t).ExpectTrue(relocation.Key.Equals(&i720Key))
+		test.S(t).ExpectTrue(relocation.MasterKey.Equals(&i730Key))
+		test.S(t).ExpectTrue(relocation.PreviousMasterKey.Equals(&i710Key))
+		test.S(t).ExpectEquals(relocation.PreviousExecCoordinates.LogPos, int64(4))
+		test.S(t).ExpectEquals(relocation.Owner, "someone")
+	}
+	{
+		// Not a change of master
+		relocation := newRelocation(ctx, RelocationOperationRepoint, instance, &i710Key)
+		test.S(t).ExpectTrue(relocation == nil)
+	}
+	{
+		// Recoveries are not to be undone
+		recoveryCtx := WithMasterChangeOrigin(context.Background(), NewMasterChangeOrigin(MasterChangeCauseRecovery, "orchestrator", "recovery-uid"))
+		relocation := newRelocation(recoveryCtx, RelocationOperationMoveBelowGTID, instance, &i730Key)
+		test.S(t).ExpectTrue(relocation == nil)
+	}
+	{
+		config.Config.RelocationUndoWindowSeconds = 0
+		relocation := newRelocation(ctx, RelocationOperationMoveBelowGTID, instance, &i730Key)
+		test.S(t).ExpectTrue(relocation == nil)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// ReplicaConcurrencyOverride sets, at runtime, the number of replicas of a cluster concurrently moved
+// by mass replica operations
+type ReplicaConcurrencyOverride struct {
+	ClusterName                    string
+	MaxConcurrentReplicaOperations uint
+	LastUpdated                    string
+}
+
+func NewReplicaConcurrencyOverride(clusterName string, maxConcurrentReplicaOperations uint) *ReplicaConcurrencyOverride {
+	return &ReplicaConcurrencyOverride{ClusterName: clusterName, MaxConcurrentReplicaOperations: maxConcurrentReplicaOperations}
+}
+
+func (this *ReplicaConcurrencyOverride) String() string {
+	return fmt.Sprintf("%s: max concurrent replica operations: %d", this.ClusterName, this.MaxConcurrentReplicaOperations)
+}
+
+// Validate checks this override is applicable
+func (this *ReplicaConcurrencyOverride) Validate() error {
+	if this.ClusterName == "" {
+		return fmt.Errorf("Replica concurrency override requires a cluster name")
+	}
+	if this.MaxConcurrentReplicaOperations == 0 {
+		return fmt.Errorf("Replica concurrency override for %s must be positive", this.ClusterName)
+	}
+	return nil
+}
+
+// resolveMaxConcurrentReplicaOperations returns the effective concurrency for given cluster. A runtime override
+// takes precedence over ClusterMaxConcurrentReplicaOperations, by cluster name then by alias, which takes
+// precedence over MaxConcurrentReplicaOperations.
+func resolveMaxConcurrentReplicaOperations(clusterName string, clusterAlias string, overrides map[string]uint) uint {
+	if concurrency, found := overrides[clusterName]; found && concurrency > 0 {
+		return concurrency
+	}
+	if concurrency, found := config.Config.ClusterMaxConcurrentReplicaOperations[clusterName]; found && concurrency > 0 {
+		return concurrency
+	}
+	if clusterAlias != "" {
+		if concurrency, found := config.Config.ClusterMaxConcurrentReplicaOperations[clusterAlias]; found && concurrency > 0 {
+			return concurrency
+		}
+	}
+	if config.Config.MaxConcurrentReplicaOperations == 0 {
+		return 1
+	}
+	return config.Config.MaxConcurrentReplicaOperations
+}
+
+var replicaConcurrencyOverridesCache = cache.New(time.Duration(config.Config.InstancePollSeconds)*time.Second, time.Second)
+
+// readReplicaConcurrencyOverrides returns runtime overrides mapped by cluster name. These are cached briefly.
+func readReplicaConcurrencyOverrides() map[string]uint {
+	return readThroughCache(replicaConcurrencyOverridesCache, "overrides", func() interface{} {
+		overridesMap := make(map[string]uint)
+		overrides, _ := ReadReplicaConcurrencyOverrides()
+		for _, override := range overrides {
+			overridesMap[override.ClusterName] = override.MaxConcurrentReplicaOperations
+		}
+		return overridesMap
+	}).(map[string]uint)
+}
+
+// MaxConcurrentReplicaOperations returns the number of replicas of given cluster which may be moved concurrently
+func MaxConcurrentReplicaOperations(clusterName string) uint {
+	clusterAlias := ""
+	if len(config.Config.ClusterMaxConcurrentReplicaOperations) > 0 {
+		clusterAlias, _ = ReadAliasByClusterName(clusterName)
+	}
+	return resolveMaxConcurrentReplicaOperations(clusterName, clusterAlias, readReplicaConcurrencyOverrides())
+}
+
+// newReplicaOperationsPool returns a pool running operations on replicas of given cluster, bounded by the cluster's
+// MaxConcurrentReplicaOperations. Operations not complete by the time given context is done are abandoned.
+func newReplicaOperationsPool(ctx context.Context, clusterName string) *util.WorkerPool {
+	return util.NewWorkerPool(ctx, MaxConcurrentReplicaOperations(clusterName))
+}
+
+// postponedReplicaResults collects the outcome of replica operations which were postponed, and hence run after
+// their replica operations pool was waited upon, at which time the pool no longer accepts results.
+type postponedReplicaResults struct {
+	mutex     sync.Mutex
+	succeeded [](*Instance)
+	failed    [](*Instance)
+}
+
+// add records the outcome of an operation on given replica, and returns the number of succeeded and failed
+// operations recorded so far
+func (this *postponedReplicaResults) add(replica *Instance, err error) (succeeded int, failed int) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if err == nil {
+		this.succeeded = append(this.succeeded, replica)
+	} else {
+		this.failed = append(this.failed, replica)
+	}
+	return len(this.succeeded), len(this.failed)
+}
@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteReplicaConcurrencyOverride creates or updates the replica concurrency override of a cluster
+func WriteReplicaConcurrencyOverride(override *ReplicaConcurrencyOverride) error {
+	if err := override.Validate(); err != nil {
+		return err
+	}
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into replica_concurrency_override (
+				cluster_name, max_concurrent_operations, last_updated
+			) values (
+				?, ?, NOW()
+			) on duplicate key update
+				max_concurrent_operations=values(max_concurrent_operations),
+				last_updated=values(last_updated)
+			`, override.ClusterName, override.MaxConcurrentReplicaOperations,
+		)
+		replicaConcurrencyOverridesCache.Flush()
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// DeleteReplicaConcurrencyOverride removes the replica concurrency override of a cluster
+func DeleteReplicaConcurrencyOverride(clusterName string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from replica_concurrency_override where cluster_name = ?
+			`, clusterName,
+		)
+		replicaConcurrencyOverridesCache.Flush()
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadReplicaConcurrencyOverrides reads all replica concurrency overrides
+func ReadReplicaConcurrencyOverrides() ([]*ReplicaConcurrencyOverride, error) {
+	res := []*ReplicaConcurrencyOverride{}
+	query := `
+		select
+			cluster_name,
+			max_concurrent_operations,
+			last_updated
+		from
+			replica_concurrency_override
+		order by
+			cluster_name
+		`
+	err := db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		override := &ReplicaConcurrencyOverride{}
+		override.ClusterName = m.GetString("cluster_name")
+		override.MaxConcurrentReplicaOperations = m.GetUint("max_concurrent_operations")
+		override.LastUpdated = m.GetString("last_updated")
+
+		res = append(res, override)
+		return nil
+	})
+	return res, log.Errore(err)
+}
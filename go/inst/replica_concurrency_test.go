@@ -0,0 +1,61 @@
+package inst
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestResolveMaxConcurrentReplicaOperations(t *testing.T) {
+	defer func(concurrency uint) { config.Config.MaxConcurrentReplicaOperations = concurrency }(config.Config.MaxConcurrentReplicaOperations)
+	defer func(concurrencies map[string]uint) {
+		config.Config.ClusterMaxConcurrentReplicaOperations = concurrencies
+	}(config.Config.ClusterMaxConcurrentReplicaOperations)
+
+	config.Config.MaxConcurrentReplicaOperations = 5
+	config.Config.ClusterMaxConcurrentReplicaOperations = map[string]uint{
+		"large":        50,
+		"fragile:3306": 1,
+	}
+	overrides := map[string]uint{"runtime:3306": 100, "fragile:3306": 3}
+
+	test.S(t).ExpectEquals(resolveMaxConcurrentReplicaOperations("other:3306", "", overrides), uint(5))
+	test.S(t).ExpectEquals(resolveMaxConcurrentReplicaOperations("other:3306", "large", overrides), uint(50))
+	test.S(t).ExpectEquals(resolveMaxConcurrentReplicaOperations("runtime:3306", "large", overrides), uint(100))
+	test.S(t).ExpectEquals(resolveMaxConcurrentReplicaOperations("fragile:3306", "", overrides), uint(3))
+	test.S(t).ExpectEquals(resolveMaxConcurrentReplicaOperations("fragile:3306", "", nil), uint(1))
+
+	config.Config.MaxConcurrentReplicaOperations = 0
+	test.S(t).ExpectEquals(resolveMaxConcurrentReplicaOperations("other:3306", "", nil), uint(1))
+}
+
+func TestReplicaConcurrencyOverrideValidate(t *testing.T) {
+	test.S(t).ExpectNil(NewReplicaConcurrencyOverride("cluster:3306", 10).Validate())
+	test.S(t).ExpectNotNil(NewReplicaConcurrencyOverride("cluster:3306", 0).Validate())
+	test.S(t).ExpectNotNil(NewReplicaConcurrencyOverride("", 10).Validate())
+}
+
+func TestPostponedReplicaResults(t *testing.T) {
+	results := &postponedReplicaResults{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			replica := &Instance{Key: InstanceKey{Hostname: fmt.Sprintf("replica%d", i), Port: 3306}}
+			var err error
+			if i%3 == 0 {
+				err = fmt.Errorf("failed")
+			}
+			results.add(replica, err)
+		}()
+	}
+	wg.Wait()
+	succeeded, failed := results.add(&Instance{Key: InstanceKey{Hostname: "last", Port: 3306}}, nil)
+	test.S(t).ExpectEquals(succeeded, 7)
+	test.S(t).ExpectEquals(failed, 4)
+}
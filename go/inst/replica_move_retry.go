@@ -0,0 +1,69 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/openark/golib/log"
+)
+
+// isRetryableReplicaMoveError returns true when given error matches any of ReplicaMoveRetryableErrors
+func isRetryableReplicaMoveError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, pattern := range config.Config.ReplicaMoveRetryableErrors {
+		if matched, _ := regexp.MatchString(pattern, err.Error()); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// replicaMoveRetryBackoff returns the wait time before given retry (1 based)
+func replicaMoveRetryBackoff(retry uint) time.Duration {
+	backoff := time.Duration(config.Config.ReplicaMoveRetryBackoffMilliseconds) * time.Millisecond
+	for i := uint(1); i < retry; i++ {
+		backoff *= 2
+	}
+	return backoff
+}
+
+// retryReplicaMove runs given move of a single replica. A move failing with a retryable error is retried up to
+// ReplicaMoveRetries times, with exponential backoff, unless the context is done.
+func retryReplicaMove(ctx context.Context, replicaKey *InstanceKey, move func() error) (err error) {
+	for retry := uint(0); ; retry++ {
+		if err = move(); err == nil {
+			return nil
+		}
+		if retry >= config.Config.ReplicaMoveRetries || !isRetryableReplicaMoveError(err) {
+			return err
+		}
+		backoff := replicaMoveRetryBackoff(retry + 1)
+		log.Warningf("retryReplicaMove: %+v: retry %d/%d in %+v after error: %+v", *replicaKey, retry+1, config.Config.ReplicaMoveRetries, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package inst
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestIsRetryableReplicaMoveError(t *testing.T) {
+	test.S(t).ExpectFalse(isRetryableReplicaMoveError(nil))
+	test.S(t).ExpectTrue(isRetryableReplicaMoveError(fmt.Errorf("dial tcp 10.0.0.1:3306: connect: connection refused")))
+	test.S(t).ExpectTrue(isRetryableReplicaMoveError(fmt.Errorf("Error 2013: Lost connection to MySQL server during query")))
+	test.S(t).ExpectFalse(isRetryableReplicaMoveError(fmt.Errorf("Instances are not GTID compatible")))
+}
+
+func TestReplicaMoveRetryBackoff(t *testing.T) {
+	defer func(backoff uint) { config.Config.ReplicaMoveRetryBackoffMilliseconds = backoff }(config.Config.ReplicaMoveRetryBackoffMilliseconds)
+	config.Config.ReplicaMoveRetryBackoffMilliseconds = 100
+
+	test.S(t).ExpectEquals(replicaMoveRetryBackoff(1), 100*time.Millisecond)
+	test.S(t).ExpectEquals(replicaMoveRetryBackoff(2), 200*time.Millisecond)
+	test.S(t).ExpectEquals(replicaMoveRetryBackoff(3), 400*time.Millisecond)
+}
+
+func TestRetryReplicaMove(t *testing.T) {
+	defer func(retries uint) { config.Config.ReplicaMoveRetries = retries }(config.Config.ReplicaMoveRetries)
+	defer func(backoff uint) { config.Config.ReplicaMoveRetryBackoffMilliseconds = backoff }(config.Config.ReplicaMoveRetryBackoffMilliseconds)
+	config.Config.ReplicaMoveRetryBackoffMilliseconds = 0
+
+	failingMove := func(attempts *int, failures int, err error) func() error {
+		return func() error {
+			*attempts++
+			if *attempts <= failures {
+				return err
+			}
+			return nil
+		}
+	}
+	transientErr := fmt.Errorf("invalid connection")
+	{
+		config.Config.ReplicaMoveRetries = 0
+		attempts := 0
+		err := retryReplicaMove(context.Background(), &key1, failingMove(&attempts, 1, transientErr))
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(attempts, 1)
+	}
+	{
+		config.Config.ReplicaMoveRetries = 3
+		attempts := 0
+		err := retryReplicaMove(context.Background(), &key1, failingMove(&attempts, 2, transientErr))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(attempts, 3)
+	}
+	{
+		config.Config.ReplicaMoveRetries = 3
+		attempts := 0
+		err := retryReplicaMove(context.Background(), &key1, failingMove(&attempts, 5, transientErr))
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(attempts, 4)
+	}
+	{
+		config.Config.ReplicaMoveRetries = 3
+		attempts := 0
+		err := retryReplicaMove(context.Background(), &key1, failingMove(&attempts, 5, fmt.Errorf("not GTID compatible")))
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(attempts, 1)
+	}
+	{
+		config.Config.ReplicaMoveRetries = 3
+		config.Config.ReplicaMoveRetryBackoffMilliseconds = 60000
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		attempts := 0
+		err := retryReplicaMove(ctx, &key1, failingMove(&attempts, 5, transientErr))
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(attempts, 1)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	DuplicateKeyErrorCode = 1062
+	RowNotFoundErrorCode  = 1032
+)
+
+type ReplicationBreakageType string
+
+const (
+	DuplicateKeyBreakage ReplicationBreakageType = "duplicate-key"
+	RowNotFoundBreakage  ReplicationBreakageType = "row-not-found"
+)
+
+var (
+	sqlErrorCodeRegexp            = regexp.MustCompile(`Error_code: ([0-9]+)`)
+	sqlErrorRowsEventRegexp       = regexp.MustCompile(`event on table ([^ .;]+)\.([^ ;]+);`)
+	sqlErrorQueryRegexp           = regexp.MustCompile(`Default database: '([^']*)'. Query: '(.*)'`)
+	sqlErrorMasterLogRegexp       = regexp.MustCompile(`master log ([^ ,]+), end_log_pos ([0-9]+)`)
+	relayLogTableMapRegexp        = regexp.MustCompile(`\(([^ .]+)\.([^ )]+)\)`)
+	relayLogRowsQueryPrefixRegexp = regexp.MustCompile(`^# `)
+)
+
+// ReplicationBreakageHint describes the likely root cause of a replica's SQL thread having stopped
+// on a data inconsistency (duplicate key, row not found), along with suggested remediations.
+type ReplicationBreakageHint struct {
+	ErrorCode             int
+	BreakageType          ReplicationBreakageType
+	Schema                string
+	Table                 string
+	Statement             string
+	MasterLogCoordinates  BinlogCoordinates
+	RelaylogCoordinates   BinlogCoordinates
+	RelayLogInspected     bool
+	SuggestedRemediations []string
+}
+
+// ParseReplicationBreakageHint analyzes a replica's Last_SQL_Error, and returns a hint if the error
+// is a data inconsistency error. nil is returned for any other error.
+func ParseReplicationBreakageHint(lastSQLError string) *ReplicationBreakageHint {
+	if lastSQLError == "" {
+		return nil
+	}
+	hint := &ReplicationBreakageHint{}
+	if submatch := sqlErrorCodeRegexp.FindStringSubmatch(lastSQLError); len(submatch) > 1 {
+		hint.ErrorCode, _ = strconv.Atoi(submatch[1])
+	} else if strings.Contains(lastSQLError, "Duplicate entry") {
+		// Statement based replication does not report the error code
+		hint.ErrorCode = DuplicateKeyErrorCode
+	}
+	switch hint.ErrorCode {
+	case DuplicateKeyErrorCode:
+		hint.BreakageType = DuplicateKeyBreakage
+	case RowNotFoundErrorCode:
+		hint.BreakageType = RowNotFoundBreakage
+	default:
+		return nil
+	}
+	if submatch := sqlErrorRowsEventRegexp.FindStringSubmatch(lastSQLError); len(submatch) > 2 {
+		hint.Schema, hint.Table = submatch[1], submatch[2]
+	}
+	if submatch := sqlErrorQueryRegexp.FindStringSubmatch(lastSQLError); len(submatch) > 2 {
+		hint.Schema, hint.Statement = submatch[1], submatch[2]
+	}
+	if submatch := sqlErrorMasterLogRegexp.FindStringSubmatch(lastSQLError); len(submatch) > 2 {
+		hint.MasterLogCoordinates.LogFile = submatch[1]
+		hint.MasterLogCoordinates.LogPos, _ = strconv.ParseInt(submatch[2], 10, 64)
+	}
+	hint.suggestRemediations()
+	return hint
+}
+
+// applyRelayLogEvents enriches the hint with information found in the relay log events of the failing transaction
+func (this *ReplicationBreakageHint) applyRelayLogEvents(events []BinlogEvent) {
+	this.RelayLogInspected = true
+	for _, event := range events {
+		switch event.EventType {
+		case "Table_map":
+			if this.Table == "" {
+				if submatch := relayLogTableMapRegexp.FindStringSubmatch(event.Info); len(submatch) > 2 {
+					this.Schema, this.Table = submatch[1], submatch[2]
+				}
+			}
+		case "Rows_query":
+			if this.Statement == "" {
+				this.Statement = relayLogRowsQueryPrefixRegexp.ReplaceAllString(event.Info, "")
+			}
+		case "Query":
+			if this.Statement == "" && event.Info != "BEGIN" {
+				this.Statement = event.Info
+			}
+		}
+	}
+	this.suggestRemediations()
+}
+
+func (this *ReplicationBreakageHint) suggestRemediations() {
+	table := "the table"
+	if this.Table != "" {
+		table = fmt.Sprintf("%s.%s", this.Schema, this.Table)
+	}
+	this.SuggestedRemediations = []string{}
+	switch this.BreakageType {
+	case DuplicateKeyBreakage:
+		this.SuggestedRemediations = append(this.SuggestedRemediations,
+			"if the conflicting row on the replica is identical to the master's, skip the event (orchestrator -c skip-query)",
+			"otherwise delete the conflicting row on the replica and restart replication",
+		)
+	case RowNotFoundBreakage:
+		this.SuggestedRemediations = append(this.SuggestedRemediations,
+			"restore the missing row on the replica from the master and restart replication",
+			"if the row is deleted by the event anyhow, skip the event (orchestrator -c skip-query)",
+		)
+	}
+	this.SuggestedRemediations = append(this.SuggestedRemediations,
+		fmt.Sprintf("verify and resync %s against the master (e.g. pt-table-checksum, pt-table-sync)", table),
+		"rebuild the replica from a backup or a healthy sibling",
+	)
+}
+
+// String returns a human readable summary of the hint
+func (this *ReplicationBreakageHint) String() string {
+	tokens := []string{string(this.BreakageType)}
+	if this.Table != "" {
+		tokens = append(tokens, fmt.Sprintf("table: %s.%s", this.Schema, this.Table))
+	}
+	if this.Statement != "" {
+		tokens = append(tokens, fmt.Sprintf("statement: %s", this.Statement))
+	}
+	return strings.Join(tokens, "; ")
+}
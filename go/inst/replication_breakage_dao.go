@@ -0,0 +1,118 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+// maxBreakageTransactionEvents limits the number of relay log events read when inspecting a failing transaction
+const maxBreakageTransactionEvents = 20
+
+// failedBreakageInspectionExpiry is the time after which a failed relay log inspection may be retried
+const failedBreakageInspectionExpiry = 10 * time.Minute
+
+// inspectedBreakageHints caches relay log inspections, such that a given breakage is only inspected once.
+// Inspections in progress and failed inspections are cached as the uninspected hint.
+var inspectedBreakageHints = cache.New(time.Hour, time.Minute)
+
+// readRelayLogTransactionEvents reads the events of the transaction starting at given relay log coordinates,
+// up to and including its terminating Xid/COMMIT event.
+func readRelayLogTransactionEvents(instanceKey *InstanceKey, relaylogCoordinates *BinlogCoordinates) (events []BinlogEvent, err error) {
+	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return events, err
+	}
+	query := fmt.Sprintf("show relaylog events in '%s' FROM %d LIMIT %d", relaylogCoordinates.LogFile, relaylogCoordinates.LogPos, maxBreakageTransactionEvents)
+	transactionComplete := false
+	err = sqlutils.QueryRowsMapBuffered(db, query, func(m sqlutils.RowMap) error {
+		if transactionComplete {
+			return nil
+		}
+		binlogEvent := BinlogEvent{Coordinates: *relaylogCoordinates}
+		readBinlogEvent(&binlogEvent, m)
+		events = append(events, binlogEvent)
+		if binlogEvent.EventType == "Xid" || (binlogEvent.EventType == "Query" && binlogEvent.Info == "COMMIT") {
+			transactionComplete = true
+		}
+		return nil
+	})
+	return events, err
+}
+
+func inspectedBreakageCacheKey(instanceKey *InstanceKey, relaylogCoordinates *BinlogCoordinates) string {
+	return fmt.Sprintf("%s:%s", instanceKey.StringCode(), relaylogCoordinates.DisplayString())
+}
+
+// InspectReplicationBreakage enriches given hint by inspecting the failing transaction in the replica's relay log,
+// which is where the replica's SQL thread has stopped. Inspections are cached per instance & relay log coordinates,
+// failures included, such that a given relay log position is inspected at most once per failedBreakageInspectionExpiry.
+// This reads from the topology; analysis uses ReadInspectedReplicationBreakage.
+func InspectReplicationBreakage(instanceKey *InstanceKey, relaylogCoordinates *BinlogCoordinates, hint *ReplicationBreakageHint) (*ReplicationBreakageHint, error) {
+	if hint == nil {
+		return hint, nil
+	}
+	if relaylogCoordinates.LogFile == "" {
+		return hint, nil
+	}
+	hint.RelaylogCoordinates = *relaylogCoordinates
+	cacheKey := inspectedBreakageCacheKey(instanceKey, relaylogCoordinates)
+	uninspectedHint := *hint
+	if err := inspectedBreakageHints.Add(cacheKey, &uninspectedHint, cache.DefaultExpiration); err != nil {
+		// Already inspected, or being inspected
+		if cachedHint, found := inspectedBreakageHints.Get(cacheKey); found {
+			return cachedHint.(*ReplicationBreakageHint), nil
+		}
+		return hint, nil
+	}
+	events, err := readRelayLogTransactionEvents(instanceKey, relaylogCoordinates)
+	if err != nil {
+		inspectedBreakageHints.Set(cacheKey, &uninspectedHint, failedBreakageInspectionExpiry)
+		return hint, log.Errore(err)
+	}
+	hint.applyRelayLogEvents(events)
+	inspectedBreakageHints.Set(cacheKey, hint, cache.DefaultExpiration)
+	return hint, nil
+}
+
+// ReadInspectedReplicationBreakage returns the hint as enriched by a previous inspection of the instance's relay log,
+// or given hint as is if no such inspection took place. It does not access the topology.
+func ReadInspectedReplicationBreakage(instanceKey *InstanceKey, relaylogCoordinates *BinlogCoordinates, hint *ReplicationBreakageHint) *ReplicationBreakageHint {
+	if hint == nil {
+		return hint
+	}
+	if cachedHint, found := inspectedBreakageHints.Get(inspectedBreakageCacheKey(instanceKey, relaylogCoordinates)); found {
+		return cachedHint.(*ReplicationBreakageHint)
+	}
+	return hint
+}
+
+// inspectReplicationBreakage inspects the relay log of a replica whose SQL thread stopped on a data inconsistency,
+// such that the analysis may later read the result without accessing the topology. The inspection runs
+// asynchronously, outside the discovery path.
+func (this *Instance) inspectReplicationBreakage() {
+	if !this.IsReplica() || !this.ReplicationSQLThreadState.IsStopped() || this.LastSQLError == "" {
+		return
+	}
+	go InspectReplicationBreakage(&this.Key, &this.RelaylogCoordinates, ParseReplicationBreakageHint(this.LastSQLError))
+}
@@ -0,0 +1,98 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseReplicationBreakageHintRowBased(t *testing.T) {
+	lastSQLError := "Could not execute Write_rows event on table test.t1; Duplicate entry '1' for key 'PRIMARY', Error_code: 1062; handler error HA_ERR_FOUND_DUPP_KEY; the event's master log mysql-bin.000003, end_log_pos 1234"
+	hint := ParseReplicationBreakageHint(lastSQLError)
+	test.S(t).ExpectNotNil(hint)
+	test.S(t).ExpectEquals(hint.ErrorCode, DuplicateKeyErrorCode)
+	test.S(t).ExpectEquals(hint.BreakageType, DuplicateKeyBreakage)
+	test.S(t).ExpectEquals(hint.Schema, "test")
+	test.S(t).ExpectEquals(hint.Table, "t1")
+	test.S(t).ExpectEquals(hint.MasterLogCoordinates.LogFile, "mysql-bin.000003")
+	test.S(t).ExpectEquals(hint.MasterLogCoordinates.LogPos, int64(1234))
+	test.S(t).ExpectTrue(len(hint.SuggestedRemediations) > 0)
+}
+
+func TestParseReplicationBreakageHintRowNotFound(t *testing.T) {
+	lastSQLError := "Could not execute Update_rows event on table db1.tbl; Can't find record in 'tbl', Error_code: 1032; handler error HA_ERR_KEY_NOT_FOUND; the event's master log mysql-bin.000007, end_log_pos 55"
+	hint := ParseReplicationBreakageHint(lastSQLError)
+	test.S(t).ExpectNotNil(hint)
+	test.S(t).ExpectEquals(hint.BreakageType, ReplicationBreakageType(RowNotFoundBreakage))
+	test.S(t).ExpectEquals(hint.Schema, "db1")
+	test.S(t).ExpectEquals(hint.Table, "tbl")
+}
+
+func TestParseReplicationBreakageHintStatementBased(t *testing.T) {
+	lastSQLError := "Error 'Duplicate entry '1' for key 'PRIMARY'' on query. Default database: 'test'. Query: 'insert into t1 values (1)'"
+	hint := ParseReplicationBreakageHint(lastSQLError)
+	test.S(t).ExpectNotNil(hint)
+	test.S(t).ExpectEquals(hint.ErrorCode, DuplicateKeyErrorCode)
+	test.S(t).ExpectEquals(hint.Schema, "test")
+	test.S(t).ExpectEquals(hint.Statement, "insert into t1 values (1)")
+}
+
+func TestParseReplicationBreakageHintIrrelevant(t *testing.T) {
+	test.S(t).ExpectTrue(ParseReplicationBreakageHint("") == nil)
+	test.S(t).ExpectTrue(ParseReplicationBreakageHint("Error 'Table 't1' doesn't exist' on query. Error_code: 1146") == nil)
+}
+
+func TestReplicationBreakageHintRelayLogEvents(t *testing.T) {
+	hint := ParseReplicationBreakageHint("Could not execute Delete_rows event; Error_code: 1032")
+	test.S(t).ExpectNotNil(hint)
+	test.S(t).ExpectEquals(hint.Table, "")
+	hint.applyRelayLogEvents([]BinlogEvent{
+		{EventType: "Query", Info: "BEGIN"},
+		{EventType: "Rows_query", Info: "# delete from t2 where id=7"},
+		{EventType: "Table_map", Info: "table_id: 108 (test.t2)"},
+		{EventType: "Delete_rows", Info: "table_id: 108 flags: STMT_END_F"},
+		{EventType: "Xid", Info: "COMMIT /* xid=17 */"},
+	})
+	test.S(t).ExpectTrue(hint.RelayLogInspected)
+	test.S(t).ExpectEquals(hint.Schema, "test")
+	test.S(t).ExpectEquals(hint.Table, "t2")
+	test.S(t).ExpectEquals(hint.Statement, "delete from t2 where id=7")
+}
+
+func TestReadInspectedReplicationBreakage(t *testing.T) {
+	instanceKey := &InstanceKey{Hostname: "replica-breakage", Port: 3306}
+	relaylogCoordinates := &BinlogCoordinates{LogFile: "relay-bin.000007", LogPos: 1004, Type: RelayLog}
+	hint := ParseReplicationBreakageHint("Could not execute Delete_rows event; Error_code: 1032")
+
+	// Not inspected: the hint is returned as is, without accessing the topology
+	test.S(t).ExpectTrue(ReadInspectedReplicationBreakage(instanceKey, relaylogCoordinates, hint) == hint)
+	test.S(t).ExpectTrue(ReadInspectedReplicationBreakage(instanceKey, relaylogCoordinates, nil) == nil)
+
+	inspectedHint := ParseReplicationBreakageHint("Could not execute Delete_rows event; Error_code: 1032")
+	inspectedHint.applyRelayLogEvents([]BinlogEvent{
+		{EventType: "Table_map", Info: "table_id: 108 (test.t2)"},
+		{EventType: "Delete_rows", Info: "table_id: 108 flags: STMT_END_F"},
+	})
+	inspectedBreakageHints.Set(inspectedBreakageCacheKey(instanceKey, relaylogCoordinates), inspectedHint, 0)
+	defer inspectedBreakageHints.Delete(inspectedBreakageCacheKey(instanceKey, relaylogCoordinates))
+
+	readHint := ReadInspectedReplicationBreakage(instanceKey, relaylogCoordinates, hint)
+	test.S(t).ExpectTrue(readHint.RelayLogInspected)
+	test.S(t).ExpectEquals(readHint.Table, "t2")
+}
+
+func TestInspectReplicationBreakageCachesFailure(t *testing.T) {
+	instanceKey := &InstanceKey{Hostname: "127.0.0.1", Port: 1}
+	relaylogCoordinates := &BinlogCoordinates{LogFile: "relay-bin.000007", LogPos: 1004, Type: RelayLog}
+	defer inspectedBreakageHints.Delete(inspectedBreakageCacheKey(instanceKey, relaylogCoordinates))
+
+	hint, err := InspectReplicationBreakage(instanceKey, relaylogCoordinates, ParseReplicationBreakageHint("Could not execute Delete_rows event; Error_code: 1032"))
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectFalse(hint.RelayLogInspected)
+
+	// The failed inspection is not retried for the same relay log position
+	hint, err = InspectReplicationBreakage(instanceKey, relaylogCoordinates, ParseReplicationBreakageHint("Could not execute Delete_rows event; Error_code: 1032"))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectFalse(hint.RelayLogInspected)
+	test.S(t).ExpectEquals(hint.BreakageType, RowNotFoundBreakage)
+}
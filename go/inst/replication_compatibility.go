@@ -0,0 +1,177 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// ReplicationCheck is the outcome of a single check of whether an instance can replicate from another
+type ReplicationCheck struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// ReplicationCompatibility lists all checks of whether an instance can replicate from another. Checks are those
+// applied by CanReplicateFrom, followed by GTID checks, which only apply to GTID based operations.
+type ReplicationCompatibility struct {
+	Key                 InstanceKey
+	MasterKey           InstanceKey
+	CanReplicate        bool
+	CanReplicateViaGTID bool
+	Checks              []ReplicationCheck
+	GTIDChecks          []ReplicationCheck
+}
+
+type replicationCheckFunc func(instance *Instance, other *Instance) error
+
+type replicationCheckDefinition struct {
+	name  string
+	check replicationCheckFunc
+}
+
+// versionReplicationCheckName names the check which an operation may override, see WithVersionCompatibilityOverride
+const versionReplicationCheckName = "version"
+
+// replicationChecks are evaluated, in order, by CanReplicateFrom
+var replicationChecks = []replicationCheckDefinition{
+	{"self", func(this *Instance, other *Instance) error {
+		if this.Key.Equals(&other.Key) {
+			return fmt.Errorf("instance cannot replicate from itself: %+v", this.Key)
+		}
+		return nil
+	}},
+	{"replication-group", checkReplicationGroupChangeMaster},
+	{"log-bin", func(this *Instance, other *Instance) error {
+		if !other.LogBinEnabled {
+			return fmt.Errorf("instance does not have binary logs enabled: %+v", other.Key)
+		}
+		return nil
+	}},
+	{"log-slave-updates", func(this *Instance, other *Instance) error {
+		// OK for a master to not have log_slave_updates
+		// Not OK for a replica, for it has to relay the logs.
+		if other.IsReplica() && !other.LogSlaveUpdatesEnabled {
+			return fmt.Errorf("instance does not have log_slave_updates enabled: %+v", other.Key)
+		}
+		return nil
+	}},
+	{versionReplicationCheckName, func(this *Instance, other *Instance) error {
+		if this.IsBinlogServer() || other.IsBinlogServer() {
+			return nil
+		}
+		if err := checkVersionCompatibility(this.Version, other.Version); err != nil {
+			return fmt.Errorf("instance %+v has version %s, which cannot replicate from %s on %+v: %+v", this.Key, this.Version, other.Version, other.Key, err)
+		}
+		return nil
+	}},
+	{"binlog-format", func(this *Instance, other *Instance) error {
+		if this.LogBinEnabled && this.LogSlaveUpdatesEnabled && this.IsSmallerBinlogFormat(other) {
+			return fmt.Errorf("Cannot replicate from %+v binlog format on %+v to %+v on %+v", other.Binlog_format, other.Key, this.Binlog_format, this.Key)
+		}
+		return nil
+	}},
+	{"replication-filters", func(this *Instance, other *Instance) error {
+		if config.Config.VerifyReplicationFilters && other.HasReplicationFilters && !this.HasReplicationFilters {
+			return fmt.Errorf("%+v has replication filters", other.Key)
+		}
+		return nil
+	}},
+	{"server-id", func(this *Instance, other *Instance) error {
+		if this.ServerID == other.ServerID && !this.IsBinlogServer() {
+			return fmt.Errorf("Identical server id: %+v, %+v both have %d", other.Key, this.Key, this.ServerID)
+		}
+		return nil
+	}},
+	{"server-uuid", func(this *Instance, other *Instance) error {
+		if this.ServerUUID == other.ServerUUID && this.ServerUUID != "" && !this.IsBinlogServer() {
+			return fmt.Errorf("Identical server UUID: %+v, %+v both have %s", other.Key, this.Key, this.ServerUUID)
+		}
+		return nil
+	}},
+	{"sql-delay", func(this *Instance, other *Instance) error {
+		if this.SQLDelay < other.SQLDelay && int64(other.SQLDelay) > int64(config.Config.ReasonableMaintenanceReplicationLagSeconds) {
+			return fmt.Errorf("%+v has higher SQL_Delay (%+v seconds) than %+v does (%+v seconds)", other.Key, other.SQLDelay, this.Key, this.SQLDelay)
+		}
+		return nil
+	}},
+}
+
+// gtidReplicationChecks are evaluated, in order, by CheckMoveViaGTID
+var gtidReplicationChecks = []replicationCheckDefinition{
+	{"gtid", func(this *Instance, other *Instance) error {
+		if _, _, compatible := instancesAreGTIDAndCompatible(this, other); !compatible {
+			return fmt.Errorf("Instances %+v, %+v not GTID compatible or not using GTID", this.Key, other.Key)
+		}
+		return nil
+	}},
+	{"gtid-purged", func(this *Instance, other *Instance) error {
+		isOracleGTID, _, _ := instancesAreGTIDAndCompatible(this, other)
+		if !isOracleGTID {
+			return nil
+		}
+		canReplicate, err := canReplicateAssumingOracleGTID(this, other)
+		if err != nil {
+			return err
+		}
+		if !canReplicate {
+			return fmt.Errorf("Instance %+v has purged GTID entries not found on %+v", other.Key, this.Key)
+		}
+		return nil
+	}},
+}
+
+// evaluateReplicationChecks evaluates all given checks, and returns true when all have passed
+func evaluateReplicationChecks(instance *Instance, other *Instance, checks []replicationCheckDefinition) (results []ReplicationCheck, passed bool) {
+	passed = true
+	for _, check := range checks {
+		result := ReplicationCheck{Name: check.name, Passed: true}
+		if err := check.check(instance, other); err != nil {
+			result.Passed = false
+			result.Message = err.Error()
+			passed = false
+		}
+		results = append(results, result)
+	}
+	return results, passed
+}
+
+// GetReplicationCompatibility evaluates all checks of whether given instance can replicate from another
+func GetReplicationCompatibility(instance *Instance, other *Instance) *ReplicationCompatibility {
+	compatibility := &ReplicationCompatibility{Key: instance.Key, MasterKey: other.Key}
+	compatibility.Checks, compatibility.CanReplicate = evaluateReplicationChecks(instance, other, replicationChecks)
+	var gtidPassed bool
+	compatibility.GTIDChecks, gtidPassed = evaluateReplicationChecks(instance, other, gtidReplicationChecks)
+	compatibility.CanReplicateViaGTID = compatibility.CanReplicate && gtidPassed
+	return compatibility
+}
+
+// CheckReplicationCompatibility freshly reads both instances, and evaluates whether the first can replicate from the second
+func CheckReplicationCompatibility(instanceKey *InstanceKey, otherKey *InstanceKey) (*ReplicationCompatibility, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	other, err := ReadTopologyInstance(otherKey)
+	if err != nil {
+		return nil, err
+	}
+	return GetReplicationCompatibility(instance, other), nil
+}
@@ -0,0 +1,49 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestGetReplicationCompatibility(t *testing.T) {
+	i55 := Instance{Key: key1, Version: "5.5", LogBinEnabled: true, ServerID: 1}
+	i56 := Instance{Key: key2, Version: "5.6", LogBinEnabled: true, ServerID: 2}
+	{
+		compatibility := GetReplicationCompatibility(&i56, &i55)
+		test.S(t).ExpectTrue(compatibility.CanReplicate)
+		test.S(t).ExpectFalse(compatibility.CanReplicateViaGTID)
+		test.S(t).ExpectEquals(len(compatibility.Checks), len(replicationChecks))
+		for _, check := range compatibility.Checks {
+			test.S(t).ExpectTrue(check.Passed)
+		}
+		test.S(t).ExpectFalse(compatibility.GTIDChecks[0].Passed)
+	}
+	{
+		compatibility := GetReplicationCompatibility(&i55, &i56)
+		test.S(t).ExpectFalse(compatibility.CanReplicate)
+		failed := []string{}
+		for _, check := range compatibility.Checks {
+			if !check.Passed {
+				failed = append(failed, check.Name)
+			}
+		}
+		test.S(t).ExpectEquals(len(failed), 1)
+		test.S(t).ExpectEquals(failed[0], "version")
+	}
+	{
+		i56.ServerID = 1
+		i56.LogBinEnabled = false
+		compatibility := GetReplicationCompatibility(&i55, &i56)
+		failed := []string{}
+		for _, check := range compatibility.Checks {
+			if !check.Passed {
+				failed = append(failed, check.Name)
+			}
+		}
+		test.S(t).ExpectEquals(len(failed), 3)
+		canReplicate, err := i55.CanReplicateFrom(&i56)
+		test.S(t).ExpectFalse(canReplicate)
+		test.S(t).ExpectNotNil(err)
+	}
+}
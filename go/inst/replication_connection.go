@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/rcrowley/go-metrics"
+)
+
+// Client errors by which a replication IO thread fails to connect, or loses its connection, to its master.
+// Either way, the IO thread reconnects.
+var ioThreadConnectErrorNumbers = map[int]bool{
+	2003: true, // CR_CONN_HOST_ERROR
+	2005: true, // CR_UNKNOWN_HOST
+	2006: true, // CR_SERVER_GONE_ERROR
+	2013: true, // CR_SERVER_LOST
+}
+
+var ioThreadReconnectsCounter = metrics.NewCounter()
+var ioThreadConnectLatencyHistogram = metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015))
+var heartbeatAgeHistogram = metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015))
+
+func init() {
+	metrics.Register("replication_connection.reconnects", ioThreadReconnectsCounter)
+	metrics.Register("replication_connection.connect_latency_seconds", ioThreadConnectLatencyHistogram)
+	metrics.Register("replication_connection.heartbeat_age_seconds", heartbeatAgeHistogram)
+}
+
+// isZeroTimestamp returns true for an unset performance_schema timestamp
+func isZeroTimestamp(timestamp string) bool {
+	return timestamp == "" || strings.HasPrefix(timestamp, "0000-00-00")
+}
+
+// readReplicationConnectionStatus reads the health of the replica's IO thread connection to its master from
+// performance_schema.replication_connection_status: its state, received heartbeats, the time spent connecting
+// while the IO thread is connecting, and its most recent connection error.
+func readReplicationConnectionStatus(db *sql.DB, instance *Instance) error {
+	query := `
+		select
+			replication_connection_status.service_state,
+			replication_connection_status.count_received_heartbeats,
+			ifnull(replication_connection_status.last_heartbeat_timestamp, '') as last_heartbeat_timestamp,
+			ifnull(timestampdiff(second, replication_connection_status.last_heartbeat_timestamp, now()), 0) as heartbeat_age_seconds,
+			replication_connection_status.last_error_number,
+			ifnull(replication_connection_status.last_error_timestamp, '') as last_error_timestamp,
+			ifnull(timestampdiff(second, replication_connection_status.last_error_timestamp, now()), 0) as last_error_age_seconds,
+			ifnull(threads.processlist_time, 0) as processlist_time
+		from
+			performance_schema.replication_connection_status
+			left join performance_schema.threads on (threads.thread_id = replication_connection_status.thread_id)
+		order by
+			replication_connection_status.channel_name
+		limit 1
+	`
+	return sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		instance.IOThreadConnectionState = m.GetString("service_state")
+		instance.ReceivedHeartbeats = m.GetInt64("count_received_heartbeats")
+		if lastHeartbeatTimestamp := m.GetString("last_heartbeat_timestamp"); !isZeroTimestamp(lastHeartbeatTimestamp) {
+			instance.LastHeartbeatTimestamp = lastHeartbeatTimestamp
+			heartbeatAgeHistogram.Update(m.GetInt64("heartbeat_age_seconds"))
+		}
+		if instance.IOThreadConnectionState == "CONNECTING" {
+			instance.IOThreadConnectLatency = time.Duration(m.GetInt64("processlist_time")) * time.Second
+			ioThreadConnectLatencyHistogram.Update(m.GetInt64("processlist_time"))
+		}
+		// A connection error remains reported until replication restarts; only a recent one may be a new reconnect
+		lastErrorTimestamp := m.GetString("last_error_timestamp")
+		if ioThreadConnectErrorNumbers[m.GetInt("last_error_number")] && !isZeroTimestamp(lastErrorTimestamp) &&
+			m.GetInt64("last_error_age_seconds") < int64(config.Config.IOThreadReconnectsWindowSeconds) {
+			instance.ioThreadConnectErrorNumber = m.GetInt("last_error_number")
+			instance.ioThreadConnectErrorTimestamp = lastErrorTimestamp
+		}
+		return nil
+	})
+}
+
+// evaluateReplicationConnection records the instance's most recent IO thread connection error, if any, as a
+// reconnect, and sets the instance's count of recent reconnects accordingly
+func (this *Instance) evaluateReplicationConnection() {
+	if this.ioThreadConnectErrorTimestamp != "" {
+		recorded, err := WriteIOThreadReconnect(&this.Key, this.ioThreadConnectErrorTimestamp, this.ioThreadConnectErrorNumber)
+		if err != nil {
+			log.Errorf("evaluateReplicationConnection: %+v: %+v", this.Key, err)
+		}
+		if recorded {
+			ioThreadReconnectsCounter.Inc(1)
+			log.Debugf("evaluateReplicationConnection: %+v: IO thread reconnect upon error %d at %s", this.Key, this.ioThreadConnectErrorNumber, this.ioThreadConnectErrorTimestamp)
+		}
+	}
+	this.IOThreadReconnects = recentIOThreadReconnects()[this.Key]
+}
@@ -0,0 +1,92 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+var recentIOThreadReconnectsCache = cache.New(time.Duration(config.Config.InstancePollSeconds)*time.Second, time.Second)
+
+// WriteIOThreadReconnect records a reconnect of given instance's IO thread, identified by the timestamp of the
+// connection error reported by the instance. It returns true when the reconnect was not already recorded.
+func WriteIOThreadReconnect(instanceKey *InstanceKey, errorTimestamp string, errorNumber int) (recorded bool, err error) {
+	sqlResult, err := db.ExecOrchestrator(`
+			insert ignore into database_instance_replication_reconnect (
+				hostname, port, error_timestamp, error_number, observed_timestamp
+			) values (
+				?, ?, ?, ?, NOW()
+			)
+		`, instanceKey.Hostname, instanceKey.Port, errorTimestamp, errorNumber,
+	)
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	rows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	return rows > 0, nil
+}
+
+// readRecentIOThreadReconnects counts, per instance, the IO thread reconnects observed within given number of seconds
+func readRecentIOThreadReconnects(withinSeconds uint) (map[InstanceKey]uint, error) {
+	reconnects := make(map[InstanceKey]uint)
+	query := `
+		select
+			hostname,
+			port,
+			count(*) as reconnects
+		from
+			database_instance_replication_reconnect
+		where
+			observed_timestamp >= now() - interval ? second
+		group by
+			hostname, port
+	`
+	err := db.QueryOrchestrator(query, sqlutils.Args(withinSeconds), func(m sqlutils.RowMap) error {
+		key := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		reconnects[key] = m.GetUint("reconnects")
+		return nil
+	})
+	return reconnects, log.Errore(err)
+}
+
+// recentIOThreadReconnects returns the IO thread reconnects observed within IOThreadReconnectsWindowSeconds,
+// per instance. These are cached briefly, as this is checked upon each instance poll.
+func recentIOThreadReconnects() map[InstanceKey]uint {
+	return readThroughCache(recentIOThreadReconnectsCache, "reconnects", func() interface{} {
+		reconnects, _ := readRecentIOThreadReconnects(config.Config.IOThreadReconnectsWindowSeconds)
+		return reconnects
+	}).(map[InstanceKey]uint)
+}
+
+// ExpireIOThreadReconnects removes reconnects observed outside IOThreadReconnectsWindowSeconds
+func ExpireIOThreadReconnects() error {
+	_, err := db.ExecOrchestrator(`
+			delete from database_instance_replication_reconnect where observed_timestamp < NOW() - INTERVAL ? SECOND
+		`, config.Config.IOThreadReconnectsWindowSeconds,
+	)
+	return log.Errore(err)
+}
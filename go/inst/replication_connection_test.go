@@ -0,0 +1,14 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestIsZeroTimestamp(t *testing.T) {
+	test.S(t).ExpectTrue(isZeroTimestamp(""))
+	test.S(t).ExpectTrue(isZeroTimestamp("0000-00-00 00:00:00"))
+	test.S(t).ExpectTrue(isZeroTimestamp("0000-00-00 00:00:00.000000"))
+	test.S(t).ExpectFalse(isZeroTimestamp("2020-06-01 10:11:12.123456"))
+}
@@ -0,0 +1,189 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/patrickmn/go-cache"
+)
+
+type ReplicationLagPattern string
+
+const (
+	NoReplicationLag        ReplicationLagPattern = ""
+	ReplicationLagSpike                           = "spike"
+	ReplicationLagSustained                       = "sustained"
+)
+
+// replicationLagWindows keeps the recent lag samples per replica, keyed by instance key
+var replicationLagWindows = cache.New(10*time.Minute, time.Minute)
+
+// ReplicationLagWindow is a sliding window of most recent replication lag samples, oldest first
+type ReplicationLagWindow struct {
+	Samples []int64
+}
+
+// Add appends a sample, retaining at most maxSize most recent samples
+func (this *ReplicationLagWindow) Add(sample int64, maxSize int) {
+	this.Samples = append(this.Samples, sample)
+	if maxSize < 1 {
+		maxSize = 1
+	}
+	if len(this.Samples) > maxSize {
+		this.Samples = this.Samples[len(this.Samples)-maxSize:]
+	}
+}
+
+// Latest returns the most recent sample
+func (this *ReplicationLagWindow) Latest() int64 {
+	if len(this.Samples) == 0 {
+		return 0
+	}
+	return this.Samples[len(this.Samples)-1]
+}
+
+// Percentile returns the given percentile (nearest rank) of the samples in the window
+func (this *ReplicationLagWindow) Percentile(percentile float64) int64 {
+	if len(this.Samples) == 0 {
+		return 0
+	}
+	sorted := make([]int64, len(this.Samples))
+	copy(sorted, this.Samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Pattern classifies the window against given threshold: lag is sustained when the given percentile
+// exceeds the threshold, and is a spike when only the latest sample does.
+// sqlDelay is the configured delay of the replica, and is not considered as lag.
+func (this *ReplicationLagWindow) Pattern(percentile float64, thresholdSeconds int64, sqlDelay int64) ReplicationLagPattern {
+	if len(this.Samples) == 0 {
+		return NoReplicationLag
+	}
+	exceeds := func(lag int64) bool {
+		delta := lag - sqlDelay
+		if delta < 0 {
+			delta = -delta
+		}
+		return delta > thresholdSeconds
+	}
+	if exceeds(this.Percentile(percentile)) {
+		return ReplicationLagSustained
+	}
+	if exceeds(this.Latest()) {
+		return ReplicationLagSpike
+	}
+	return NoReplicationLag
+}
+
+// replicationLagPolicy returns the effective lag evaluation policy for given cluster, taking
+// ClusterReplicationLagPolicies overrides into account
+func replicationLagPolicy(clusterName string, clusterAlias string) config.LagPolicy {
+	policy := config.LagPolicy{
+		WindowSize:                      config.Config.ReplicationLagWindowSize,
+		Percentile:                      config.Config.ReplicationLagPercentile,
+		ReasonableReplicationLagSeconds: config.Config.ReasonableReplicationLagSeconds,
+	}
+	override, found := config.Config.ClusterReplicationLagPolicies[clusterName]
+	if !found && clusterAlias != "" {
+		override, found = config.Config.ClusterReplicationLagPolicies[clusterAlias]
+	}
+	if !found {
+		return policy
+	}
+	if override.WindowSize > 0 {
+		policy.WindowSize = override.WindowSize
+	}
+	if override.Percentile > 0 {
+		policy.Percentile = override.Percentile
+	}
+	if override.ReasonableReplicationLagSeconds > 0 {
+		policy.ReasonableReplicationLagSeconds = override.ReasonableReplicationLagSeconds
+	}
+	return policy
+}
+
+// evaluateReplicationLag records the instance's current lag in its sliding window, and sets the
+// instance's lag percentile and lag pattern accordingly
+func (this *Instance) evaluateReplicationLag() {
+	this.ReplicationLagPercentileSeconds.Valid = false
+	this.ReplicationLagPattern = NoReplicationLag
+	if !this.SlaveLagSeconds.Valid {
+		replicationLagWindows.Delete(this.Key.StringCode())
+		return
+	}
+	clusterAlias := this.SuggestedClusterAlias
+	if clusterAlias == "" && len(config.Config.ClusterReplicationLagPolicies) > 0 {
+		clusterAlias, _ = ReadAliasByClusterName(this.ClusterName)
+	}
+	policy := replicationLagPolicy(this.ClusterName, clusterAlias)
+
+	window := &ReplicationLagWindow{}
+	if cached, found := replicationLagWindows.Get(this.Key.StringCode()); found {
+		window.Samples = append(window.Samples, cached.(*ReplicationLagWindow).Samples...)
+	}
+	window.Add(this.SlaveLagSeconds.Int64, int(policy.WindowSize))
+	replicationLagWindows.Set(this.Key.StringCode(), window, cache.DefaultExpiration)
+
+	this.ReplicationLagPercentileSeconds.Int64 = window.Percentile(policy.Percentile)
+	this.ReplicationLagPercentileSeconds.Valid = true
+	this.ReplicationLagPattern = window.Pattern(policy.Percentile, int64(policy.ReasonableReplicationLagSeconds), int64(this.SQLDelay))
+}
+
+type ReplicationLagBucket string
+
+const (
+	NoReplicationLagBucket         ReplicationLagBucket = "none"
+	UnknownReplicationLagBucket                         = "unknown"
+	ReasonableReplicationLagBucket                      = "reasonable"
+	HighReplicationLagBucket                            = "high"
+	CriticalReplicationLagBucket                        = "critical"
+)
+
+// replicationLagBucket classifies the instance's current lag, net of any intentional delay, for presentation.
+// Lag is "high" above the cluster's ReasonableReplicationLagSeconds, and "critical" when also above
+// ReasonableMaintenanceReplicationLagSeconds, where relocations are blocked.
+func (this *Instance) replicationLagBucket() ReplicationLagBucket {
+	if !this.IsReplica() {
+		return NoReplicationLagBucket
+	}
+	if !this.SlaveLagSeconds.Valid || !this.ReplicaRunning() {
+		return UnknownReplicationLagBucket
+	}
+	lag := this.SlaveLagSeconds.Int64 - int64(this.SQLDelay)
+	reasonableLagSeconds := int64(replicationLagPolicy(this.ClusterName, this.SuggestedClusterAlias).ReasonableReplicationLagSeconds)
+	if lag <= reasonableLagSeconds {
+		return ReasonableReplicationLagBucket
+	}
+	if lag <= int64(config.Config.ReasonableMaintenanceReplicationLagSeconds) {
+		return HighReplicationLagBucket
+	}
+	return CriticalReplicationLagBucket
+}
@@ -0,0 +1,114 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestReplicationLagWindowAdd(t *testing.T) {
+	window := &ReplicationLagWindow{}
+	for _, sample := range []int64{1, 2, 3, 4, 5, 6} {
+		window.Add(sample, 4)
+	}
+	test.S(t).ExpectEquals(len(window.Samples), 4)
+	test.S(t).ExpectEquals(window.Samples[0], int64(3))
+	test.S(t).ExpectEquals(window.Latest(), int64(6))
+}
+
+func TestReplicationLagWindowPercentile(t *testing.T) {
+	window := &ReplicationLagWindow{Samples: []int64{30, 0, 1, 2, 0}}
+	test.S(t).ExpectEquals(window.Percentile(50), int64(1))
+	test.S(t).ExpectEquals(window.Percentile(100), int64(30))
+	test.S(t).ExpectEquals(window.Percentile(80), int64(2))
+	test.S(t).ExpectEquals(window.Percentile(1), int64(0))
+
+	empty := &ReplicationLagWindow{}
+	test.S(t).ExpectEquals(empty.Percentile(50), int64(0))
+}
+
+func TestReplicationLagWindowPattern(t *testing.T) {
+	{
+		window := &ReplicationLagWindow{Samples: []int64{0, 1, 0, 0, 60}}
+		test.S(t).ExpectEquals(window.Pattern(50, 10, 0), ReplicationLagPattern(ReplicationLagSpike))
+	}
+	{
+		window := &ReplicationLagWindow{Samples: []int64{20, 25, 30, 0, 60}}
+		test.S(t).ExpectEquals(window.Pattern(50, 10, 0), ReplicationLagPattern(ReplicationLagSustained))
+	}
+	{
+		window := &ReplicationLagWindow{Samples: []int64{0, 1, 0, 0, 2}}
+		test.S(t).ExpectEquals(window.Pattern(50, 10, 0), NoReplicationLag)
+	}
+	{
+		// delayed replica
+		window := &ReplicationLagWindow{Samples: []int64{3600, 3601, 3599}}
+		test.S(t).ExpectEquals(window.Pattern(50, 10, 3600), NoReplicationLag)
+	}
+	{
+		// single sample window behaves as point value
+		window := &ReplicationLagWindow{Samples: []int64{11}}
+		test.S(t).ExpectEquals(window.Pattern(50, 10, 0), ReplicationLagPattern(ReplicationLagSustained))
+	}
+}
+
+func TestReplicationLagPolicy(t *testing.T) {
+	defer func(policies config.LagPolicies) { config.Config.ClusterReplicationLagPolicies = policies }(config.Config.ClusterReplicationLagPolicies)
+	config.Config.ClusterReplicationLagPolicies = config.LagPolicies{
+		"analytics":      {WindowSize: 12, ReasonableReplicationLagSeconds: 300},
+		"db-main-0:3306": {Percentile: 90},
+	}
+	{
+		policy := replicationLagPolicy("db-analytics-0:3306", "analytics")
+		test.S(t).ExpectEquals(policy.WindowSize, uint(12))
+		test.S(t).ExpectEquals(policy.ReasonableReplicationLagSeconds, 300)
+		test.S(t).ExpectEquals(policy.Percentile, config.Config.ReplicationLagPercentile)
+	}
+	{
+		policy := replicationLagPolicy("db-main-0:3306", "main")
+		test.S(t).ExpectEquals(policy.Percentile, float64(90))
+		test.S(t).ExpectEquals(policy.WindowSize, config.Config.ReplicationLagWindowSize)
+		test.S(t).ExpectEquals(policy.ReasonableReplicationLagSeconds, config.Config.ReasonableReplicationLagSeconds)
+	}
+	{
+		policy := replicationLagPolicy("db-other-0:3306", "")
+		test.S(t).ExpectEquals(policy.ReasonableReplicationLagSeconds, config.Config.ReasonableReplicationLagSeconds)
+	}
+}
+
+func TestReplicationLagBucket(t *testing.T) {
+	defer func(seconds int) { config.Config.ReasonableReplicationLagSeconds = seconds }(config.Config.ReasonableReplicationLagSeconds)
+	defer func(seconds int) {
+		config.Config.ReasonableMaintenanceReplicationLagSeconds = seconds
+	}(config.Config.ReasonableMaintenanceReplicationLagSeconds)
+	config.Config.ReasonableReplicationLagSeconds = 10
+	config.Config.ReasonableMaintenanceReplicationLagSeconds = 20
+
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	instance := instancesMap[i720Key.StringCode()]
+	instance.MasterKey = i710Key
+	instance.ReadBinlogCoordinates = instance.ExecBinlogCoordinates
+	instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+	instance.ReplicationIOThreadState = ReplicationThreadStateRunning
+
+	lagBucket := func(lagSeconds int64) ReplicationLagBucket {
+		instance.SlaveLagSeconds.Valid = true
+		instance.SlaveLagSeconds.Int64 = lagSeconds
+		return instance.replicationLagBucket()
+	}
+	test.S(t).ExpectEquals(lagBucket(0), ReplicationLagBucket(ReasonableReplicationLagBucket))
+	test.S(t).ExpectEquals(lagBucket(10), ReplicationLagBucket(ReasonableReplicationLagBucket))
+	test.S(t).ExpectEquals(lagBucket(15), ReplicationLagBucket(HighReplicationLagBucket))
+	test.S(t).ExpectEquals(lagBucket(60), ReplicationLagBucket(CriticalReplicationLagBucket))
+
+	instance.SQLDelay = 3600
+	test.S(t).ExpectEquals(lagBucket(3605), ReplicationLagBucket(ReasonableReplicationLagBucket))
+
+	instance.SlaveLagSeconds.Valid = false
+	test.S(t).ExpectEquals(instance.replicationLagBucket(), ReplicationLagBucket(UnknownReplicationLagBucket))
+
+	instance.MasterKey = InstanceKey{}
+	test.S(t).ExpectEquals(instance.replicationLagBucket(), ReplicationLagBucket(NoReplicationLagBucket))
+}
@@ -0,0 +1,163 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// replicationCertificateExpiryWarning is how soon before its expiry a master's certificate is reported as expiring
+const replicationCertificateExpiryWarning = 30 * 24 * time.Hour
+
+// sslCertificateNotAfterLayout is the layout of the Ssl_server_not_after status variable, e.g. "Jan  1 00:00:00 2030 GMT"
+const sslCertificateNotAfterLayout = "Jan _2 15:04:05 2006 MST"
+
+// ReplicationChannelSecurity inventories the credentials and TLS status of a replica's replication channel
+type ReplicationChannelSecurity struct {
+	Key                 InstanceKey
+	MasterKey           InstanceKey
+	ReplicationUser     string
+	SSLAllowed          bool
+	SSLVerifyServerCert bool
+	// UserRequiresSSL is as per the replication user's REQUIRE clause on the master, when UserSSLTypeKnown
+	UserSSLTypeKnown bool
+	UserRequiresSSL  bool
+	// MasterCertificateExpiry is the expiry of the master's server certificate, when MasterCertificateExpiryKnown
+	MasterCertificateExpiryKnown bool
+	MasterCertificateExpiry      time.Time
+	Findings                     []string
+	Error                        string
+}
+
+// ClusterReplicationSecurity aggregates the replication channel security of a cluster's replicas
+type ClusterReplicationSecurity struct {
+	ClusterName         string
+	ClusterAlias        string
+	CountReplicas       int
+	CountUnencrypted    int
+	CountUnverified     int
+	CountSSLNotRequired int
+	CountExpiringCerts  int
+	CountUnreadable     int
+	ReplicationUsers    []string
+	ReplicationChannels [](*ReplicationChannelSecurity)
+}
+
+// ReplicationSecurityReport is the replication channel security of all clusters, or of a single cluster
+type ReplicationSecurityReport struct {
+	ReportedAt time.Time
+	Clusters   [](*ClusterReplicationSecurity)
+}
+
+// parseSSLCertificateNotAfter parses the value of the Ssl_server_not_after status variable, which is empty when the
+// server does not use SSL
+func parseSSLCertificateNotAfter(value string) (notAfter time.Time, known bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return notAfter, false, nil
+	}
+	notAfter, err = time.Parse(sslCertificateNotAfterLayout, value)
+	if err != nil {
+		return notAfter, false, fmt.Errorf("cannot parse certificate expiry: %s", value)
+	}
+	return notAfter, true, nil
+}
+
+// isSSLRequiredBySSLTypes returns true when all accounts of a user, as per their mysql.user ssl_type, require SSL.
+// A user with no accounts does not.
+func isSSLRequiredBySSLTypes(sslTypes []string) bool {
+	if len(sslTypes) == 0 {
+		return false
+	}
+	for _, sslType := range sslTypes {
+		if strings.TrimSpace(sslType) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateFindings lists the security concerns of the channel, as of given time
+func (this *ReplicationChannelSecurity) evaluateFindings(now time.Time) {
+	this.Findings = []string{}
+	if this.Error != "" {
+		return
+	}
+	if !this.SSLAllowed {
+		this.Findings = append(this.Findings, "channel is not encrypted")
+	} else if !this.SSLVerifyServerCert {
+		this.Findings = append(this.Findings, "master certificate is not verified")
+	}
+	if this.UserSSLTypeKnown && !this.UserRequiresSSL {
+		this.Findings = append(this.Findings, fmt.Sprintf("user %s does not require SSL", this.ReplicationUser))
+	}
+	if this.MasterCertificateExpiryKnown {
+		if !this.MasterCertificateExpiry.After(now) {
+			this.Findings = append(this.Findings, fmt.Sprintf("master certificate expired on %s", this.MasterCertificateExpiry.Format(time.RFC3339)))
+		} else if this.MasterCertificateExpiry.Sub(now) < replicationCertificateExpiryWarning {
+			this.Findings = append(this.Findings, fmt.Sprintf("master certificate expires on %s", this.MasterCertificateExpiry.Format(time.RFC3339)))
+		}
+	}
+}
+
+// isCertificateExpiring returns true when the master's certificate expires, or has expired, within
+// replicationCertificateExpiryWarning of given time
+func (this *ReplicationChannelSecurity) isCertificateExpiring(now time.Time) bool {
+	return this.MasterCertificateExpiryKnown && this.MasterCertificateExpiry.Sub(now) < replicationCertificateExpiryWarning
+}
+
+// aggregateClusterReplicationSecurity counts the findings over a cluster's replication channels
+func aggregateClusterReplicationSecurity(clusterName string, clusterAlias string, channels [](*ReplicationChannelSecurity), now time.Time) *ClusterReplicationSecurity {
+	cluster := &ClusterReplicationSecurity{
+		ClusterName:         clusterName,
+		ClusterAlias:        clusterAlias,
+		ReplicationUsers:    []string{},
+		ReplicationChannels: channels,
+	}
+	users := make(map[string]bool)
+	for _, channel := range channels {
+		channel.evaluateFindings(now)
+		cluster.CountReplicas++
+		if channel.Error != "" {
+			cluster.CountUnreadable++
+			continue
+		}
+		if channel.ReplicationUser != "" {
+			users[channel.ReplicationUser] = true
+		}
+		if !channel.SSLAllowed {
+			cluster.CountUnencrypted++
+		} else if !channel.SSLVerifyServerCert {
+			cluster.CountUnverified++
+		}
+		if channel.UserSSLTypeKnown && !channel.UserRequiresSSL {
+			cluster.CountSSLNotRequired++
+		}
+		if channel.isCertificateExpiring(now) {
+			cluster.CountExpiringCerts++
+		}
+	}
+	for user := range users {
+		cluster.ReplicationUsers = append(cluster.ReplicationUsers, user)
+	}
+	sort.Strings(cluster.ReplicationUsers)
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Key.SmallerThan(&channels[j].Key) })
+	return cluster
+}
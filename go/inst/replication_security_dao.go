@@ -0,0 +1,199 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// replicationSecurityReportConcurrency is the number of servers concurrently queried by a replication security report
+const replicationSecurityReportConcurrency = 16
+
+// masterReplicationSecurity is what a master tells about the security of its replicas' channels: its server
+// certificate's expiry, and whether each replication user requires SSL
+type masterReplicationSecurity struct {
+	mutex                  sync.Mutex
+	certificateExpiryRead  bool
+	certificateExpiryKnown bool
+	certificateExpiry      time.Time
+	usersRequireSSL        map[string]bool // by user; users whose requirement cannot be read are absent
+	usersRead              map[string]bool
+}
+
+// readMasterCertificateExpiry reads the expiry of given master's server certificate, as per Ssl_server_not_after
+func readMasterCertificateExpiry(masterKey *InstanceKey) (notAfter time.Time, known bool, err error) {
+	sqlDB, err := db.OpenTopology(masterKey.Hostname, masterKey.Port)
+	if err != nil {
+		return notAfter, false, err
+	}
+	value := ""
+	err = sqlutils.QueryRowsMap(sqlDB, "show global status like 'Ssl_server_not_after'", func(m sqlutils.RowMap) error {
+		value = m.GetString("Value")
+		return nil
+	})
+	if err != nil {
+		return notAfter, false, err
+	}
+	return parseSSLCertificateNotAfter(value)
+}
+
+// readUserRequiresSSL reads whether given replication user requires SSL on given master, as per mysql.user. This
+// requires orchestrator's topology user to be privileged to read mysql.user.
+func readUserRequiresSSL(masterKey *InstanceKey, user string) (bool, error) {
+	sqlDB, err := db.OpenTopology(masterKey.Hostname, masterKey.Port)
+	if err != nil {
+		return false, err
+	}
+	sslTypes := []string{}
+	err = sqlutils.QueryRowsMap(sqlDB, "select ssl_type from mysql.user where user = ?", func(m sqlutils.RowMap) error {
+		sslTypes = append(sslTypes, m.GetString("ssl_type"))
+		return nil
+	}, user)
+	if err != nil {
+		return false, err
+	}
+	if len(sslTypes) == 0 {
+		return false, fmt.Errorf("user %s not found on %+v", user, *masterKey)
+	}
+	return isSSLRequiredBySSLTypes(sslTypes), nil
+}
+
+// readReplicationChannel reads the replication user and SSL settings of given replica's channel
+func readReplicationChannel(replicaKey *InstanceKey) (channel *ReplicationChannelSecurity, err error) {
+	channel = &ReplicationChannelSecurity{Key: *replicaKey}
+	sqlDB, err := db.OpenTopology(replicaKey.Hostname, replicaKey.Port)
+	if err != nil {
+		return channel, err
+	}
+	foundChannel := false
+	err = sqlutils.QueryRowsMap(sqlDB, "show slave status", func(m sqlutils.RowMap) error {
+		if foundChannel {
+			return nil
+		}
+		foundChannel = true
+		channel.MasterKey = InstanceKey{Hostname: m.GetString("Master_Host"), Port: m.GetIntD("Master_Port", 0)}
+		channel.ReplicationUser = m.GetString("Master_User")
+		channel.SSLAllowed = (m.GetString("Master_SSL_Allowed") == "Yes")
+		channel.SSLVerifyServerCert = (m.GetString("Master_SSL_Verify_Server_Cert") == "Yes")
+		return nil
+	})
+	if err != nil {
+		return channel, err
+	}
+	if !foundChannel {
+		return channel, fmt.Errorf("%+v has no replication channel", *replicaKey)
+	}
+	return channel, nil
+}
+
+// fillMasterReplicationSecurity fills in what given master tells about given channel, reading the master once
+// per report
+func fillMasterReplicationSecurity(channel *ReplicationChannelSecurity, masterKey *InstanceKey, master *masterReplicationSecurity) {
+	master.mutex.Lock()
+	defer master.mutex.Unlock()
+
+	if !master.certificateExpiryRead {
+		master.certificateExpiryRead = true
+		notAfter, known, err := readMasterCertificateExpiry(masterKey)
+		if err != nil {
+			log.Errorf("ReportReplicationSecurity: cannot read certificate expiry of %+v: %+v", *masterKey, err)
+		}
+		master.certificateExpiry, master.certificateExpiryKnown = notAfter, known
+	}
+	channel.MasterCertificateExpiry, channel.MasterCertificateExpiryKnown = master.certificateExpiry, master.certificateExpiryKnown
+
+	if channel.ReplicationUser == "" {
+		return
+	}
+	if !master.usersRead[channel.ReplicationUser] {
+		master.usersRead[channel.ReplicationUser] = true
+		requiresSSL, err := readUserRequiresSSL(masterKey, channel.ReplicationUser)
+		if err != nil {
+			log.Errorf("ReportReplicationSecurity: cannot read SSL requirement of %s on %+v: %+v", channel.ReplicationUser, *masterKey, err)
+		} else {
+			master.usersRequireSSL[channel.ReplicationUser] = requiresSSL
+		}
+	}
+	channel.UserRequiresSSL, channel.UserSSLTypeKnown = master.usersRequireSSL[channel.ReplicationUser]
+}
+
+// ReportReplicationSecurity inventories, per replica, the replication user in use, whether the channel is
+// encrypted and verifies its master's certificate, whether the user requires SSL, and when the master's certificate
+// expires; aggregated per cluster. Reports on all clusters when given cluster name is empty.
+func ReportReplicationSecurity(clusterName string) (*ReplicationSecurityReport, error) {
+	clustersInfo, err := ReadClustersInfo(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	report := &ReplicationSecurityReport{ReportedAt: time.Now(), Clusters: [](*ClusterReplicationSecurity){}}
+
+	masters := make(map[InstanceKey]*masterReplicationSecurity)
+	var mastersMutex sync.Mutex
+	getMaster := func(masterKey InstanceKey) *masterReplicationSecurity {
+		mastersMutex.Lock()
+		defer mastersMutex.Unlock()
+		if _, found := masters[masterKey]; !found {
+			masters[masterKey] = &masterReplicationSecurity{usersRequireSSL: make(map[string]bool), usersRead: make(map[string]bool)}
+		}
+		return masters[masterKey]
+	}
+	semaphore := make(chan bool, replicationSecurityReportConcurrency)
+	for _, clusterInfo := range clustersInfo {
+		instances, err := ReadClusterInstances(clusterInfo.ClusterName)
+		if err != nil {
+			return nil, err
+		}
+		channels := [](*ReplicationChannelSecurity){}
+		var channelsMutex sync.Mutex
+		var wg sync.WaitGroup
+		for _, instance := range instances {
+			if !instance.IsReplica() || instance.IsBinlogServer() {
+				continue
+			}
+			wg.Add(1)
+			go func(instance *Instance) {
+				defer wg.Done()
+				semaphore <- true
+				defer func() { <-semaphore }()
+
+				channel, err := readReplicationChannel(&instance.Key)
+				if err != nil {
+					channel.MasterKey = instance.MasterKey
+					channel.Error = err.Error()
+				} else {
+					fillMasterReplicationSecurity(channel, &instance.MasterKey, getMaster(instance.MasterKey))
+				}
+				channelsMutex.Lock()
+				defer channelsMutex.Unlock()
+				channels = append(channels, channel)
+			}(instance)
+		}
+		wg.Wait()
+		if len(channels) == 0 {
+			continue
+		}
+		report.Clusters = append(report.Clusters, aggregateClusterReplicationSecurity(clusterInfo.ClusterName, clusterInfo.ClusterAlias, channels, report.ReportedAt))
+	}
+	return report, nil
+}
@@ -0,0 +1,93 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseSSLCertificateNotAfter(t *testing.T) {
+	{
+		_, known, err := parseSSLCertificateNotAfter("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(known)
+	}
+	{
+		notAfter, known, err := parseSSLCertificateNotAfter("Jan  1 00:00:00 2030 GMT")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(known)
+		test.S(t).ExpectEquals(notAfter.Year(), 2030)
+		test.S(t).ExpectEquals(notAfter.Month(), time.January)
+		test.S(t).ExpectEquals(notAfter.Day(), 1)
+	}
+	{
+		notAfter, known, err := parseSSLCertificateNotAfter("Oct 17 12:30:00 2026 GMT")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(known)
+		test.S(t).ExpectEquals(notAfter.Day(), 17)
+		test.S(t).ExpectEquals(notAfter.Hour(), 12)
+	}
+	{
+		_, known, err := parseSSLCertificateNotAfter("not a date")
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectFalse(known)
+	}
+}
+
+func TestIsSSLRequiredBySSLTypes(t *testing.T) {
+	test.S(t).ExpectFalse(isSSLRequiredBySSLTypes([]string{}))
+	test.S(t).ExpectTrue(isSSLRequiredBySSLTypes([]string{"ANY"}))
+	test.S(t).ExpectTrue(isSSLRequiredBySSLTypes([]string{"X509", "SPECIFIED"}))
+	test.S(t).ExpectFalse(isSSLRequiredBySSLTypes([]string{"ANY", ""}))
+}
+
+func TestAggregateClusterReplicationSecurity(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	secure := &ReplicationChannelSecurity{
+		Key:                          InstanceKey{Hostname: "host1", Port: 3306},
+		ReplicationUser:              "repl",
+		SSLAllowed:                   true,
+		SSLVerifyServerCert:          true,
+		UserSSLTypeKnown:             true,
+		UserRequiresSSL:              true,
+		MasterCertificateExpiryKnown: true,
+		MasterCertificateExpiry:      now.Add(365 * 24 * time.Hour),
+	}
+	unencrypted := &ReplicationChannelSecurity{
+		Key:              InstanceKey{Hostname: "host0", Port: 3306},
+		ReplicationUser:  "legacy_repl",
+		UserSSLTypeKnown: true,
+	}
+	unverified := &ReplicationChannelSecurity{
+		Key:                          InstanceKey{Hostname: "host2", Port: 3306},
+		ReplicationUser:              "repl",
+		SSLAllowed:                   true,
+		MasterCertificateExpiryKnown: true,
+		MasterCertificateExpiry:      now.Add(7 * 24 * time.Hour),
+	}
+	unreadable := &ReplicationChannelSecurity{
+		Key:   InstanceKey{Hostname: "host3", Port: 3306},
+		Error: "connection refused",
+	}
+	cluster := aggregateClusterReplicationSecurity("host9:3306", "mycluster", [](*ReplicationChannelSecurity){secure, unencrypted, unverified, unreadable}, now)
+
+	test.S(t).ExpectEquals(cluster.ClusterAlias, "mycluster")
+	test.S(t).ExpectEquals(cluster.CountReplicas, 4)
+	test.S(t).ExpectEquals(cluster.CountUnencrypted, 1)
+	test.S(t).ExpectEquals(cluster.CountUnverified, 1)
+	test.S(t).ExpectEquals(cluster.CountSSLNotRequired, 1)
+	test.S(t).ExpectEquals(cluster.CountExpiringCerts, 1)
+	test.S(t).ExpectEquals(cluster.CountUnreadable, 1)
+	test.S(t).ExpectEquals(len(cluster.ReplicationUsers), 2)
+	test.S(t).ExpectEquals(cluster.ReplicationUsers[0], "legacy_repl")
+	test.S(t).ExpectEquals(cluster.ReplicationUsers[1], "repl")
+
+	test.S(t).ExpectEquals(cluster.ReplicationChannels[0].Key.Hostname, "host0")
+	test.S(t).ExpectEquals(len(secure.Findings), 0)
+	test.S(t).ExpectEquals(len(unencrypted.Findings), 2)
+	test.S(t).ExpectEquals(unencrypted.Findings[0], "channel is not encrypted")
+	test.S(t).ExpectEquals(len(unverified.Findings), 2)
+	test.S(t).ExpectEquals(unverified.Findings[0], "master certificate is not verified")
+	test.S(t).ExpectEquals(len(unreadable.Findings), 0)
+}
@@ -0,0 +1,156 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// replicationDoFilterColumns are the SHOW SLAVE STATUS columns listing what a replica applies, exclusively
+var replicationDoFilterColumns = []string{"Replicate_Do_DB", "Replicate_Do_Table", "Replicate_Wild_Do_Table"}
+
+// replicationIgnoreFilterColumns are the SHOW SLAVE STATUS columns listing what a replica does not apply
+var replicationIgnoreFilterColumns = []string{"Replicate_Ignore_DB", "Replicate_Ignore_Table", "Replicate_Wild_Ignore_Table"}
+
+// ReplicationSettings are those settings of a server which must agree between a replica and its master
+type ReplicationSettings struct {
+	BinlogFormat        string
+	BinlogRowImage      string
+	LowerCaseTableNames string
+	CharacterSetServer  string
+	ReplicationFilters  map[string]string // by SHOW SLAVE STATUS column
+}
+
+// ReadReplicationSettings reads the replication settings of given server. Settings a server does not support,
+// such as binlog_row_image prior to 5.6, are empty.
+func ReadReplicationSettings(instanceKey *InstanceKey) (*ReplicationSettings, error) {
+	settings := &ReplicationSettings{ReplicationFilters: make(map[string]string)}
+	sqlDB, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return settings, err
+	}
+	query := "show global variables where Variable_name in ('binlog_format', 'binlog_row_image', 'lower_case_table_names', 'character_set_server')"
+	err = sqlutils.QueryRowsMap(sqlDB, query, func(m sqlutils.RowMap) error {
+		value := m.GetString("Value")
+		switch m.GetString("Variable_name") {
+		case "binlog_format":
+			settings.BinlogFormat = value
+		case "binlog_row_image":
+			settings.BinlogRowImage = value
+		case "lower_case_table_names":
+			settings.LowerCaseTableNames = value
+		case "character_set_server":
+			settings.CharacterSetServer = value
+		}
+		return nil
+	})
+	if err != nil {
+		return settings, err
+	}
+	err = sqlutils.QueryRowsMap(sqlDB, "show slave status", func(m sqlutils.RowMap) error {
+		for _, column := range append(replicationDoFilterColumns, replicationIgnoreFilterColumns...) {
+			settings.ReplicationFilters[column] = m.GetStringD(column, "")
+		}
+		return nil
+	})
+	return settings, err
+}
+
+// replicationFilterSet returns the entries of a comma delimited replication filter
+func replicationFilterSet(filter string) map[string]bool {
+	set := make(map[string]bool)
+	for _, entry := range strings.Split(filter, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			set[entry] = true
+		}
+	}
+	return set
+}
+
+// missingReplicationFilterEntries returns the entries of given filter which are not in given other filter
+func missingReplicationFilterEntries(filter map[string]bool, other map[string]bool) (missing []string) {
+	for entry := range filter {
+		if !other[entry] {
+			missing = append(missing, entry)
+		}
+	}
+	return missing
+}
+
+// checkReplicationSettings returns an error when a replica of given settings cannot replicate from a master of given
+// settings without breaking, or without its data silently diverging:
+// - lower_case_table_names and character_set_server must be identical
+// - when the master logs row events, binlog_row_image must be identical
+// - whatever the master does not apply by its replication filters, the replica must not apply either
+func checkReplicationSettings(replica *ReplicationSettings, master *ReplicationSettings) error {
+	if replica.LowerCaseTableNames != "" && master.LowerCaseTableNames != "" && replica.LowerCaseTableNames != master.LowerCaseTableNames {
+		return fmt.Errorf("lower_case_table_names is %s, while %s on master", replica.LowerCaseTableNames, master.LowerCaseTableNames)
+	}
+	if replica.CharacterSetServer != "" && master.CharacterSetServer != "" && replica.CharacterSetServer != master.CharacterSetServer {
+		return fmt.Errorf("character_set_server is %s, while %s on master", replica.CharacterSetServer, master.CharacterSetServer)
+	}
+	if master.BinlogFormat != "STATEMENT" && replica.BinlogRowImage != "" && master.BinlogRowImage != "" && replica.BinlogRowImage != master.BinlogRowImage {
+		return fmt.Errorf("binlog_row_image is %s, while %s on master", replica.BinlogRowImage, master.BinlogRowImage)
+	}
+	for _, column := range replicationDoFilterColumns {
+		masterFilter := replicationFilterSet(master.ReplicationFilters[column])
+		if len(masterFilter) == 0 {
+			continue
+		}
+		replicaFilter := replicationFilterSet(replica.ReplicationFilters[column])
+		if len(replicaFilter) == 0 {
+			return fmt.Errorf("%s is empty, while %s on master", column, master.ReplicationFilters[column])
+		}
+		if missing := missingReplicationFilterEntries(replicaFilter, masterFilter); len(missing) > 0 {
+			return fmt.Errorf("%s includes %s, which master does not apply", column, strings.Join(missing, ","))
+		}
+	}
+	for _, column := range replicationIgnoreFilterColumns {
+		masterFilter := replicationFilterSet(master.ReplicationFilters[column])
+		replicaFilter := replicationFilterSet(replica.ReplicationFilters[column])
+		if missing := missingReplicationFilterEntries(masterFilter, replicaFilter); len(missing) > 0 {
+			return fmt.Errorf("%s does not include %s, which master does not apply", column, strings.Join(missing, ","))
+		}
+	}
+	return nil
+}
+
+// verifyReplicationSettings returns an error when given replica's settings do not agree with given master's, as per
+// checkReplicationSettings. Failure to read settings is logged and not enforced: the check only prevents moves
+// which are known to break.
+func verifyReplicationSettings(instanceKey *InstanceKey, masterKey *InstanceKey) error {
+	replicaSettings, err := ReadReplicationSettings(instanceKey)
+	if err != nil {
+		log.Errorf("verifyReplicationSettings: cannot read replication settings of %+v: %+v", *instanceKey, err)
+		return nil
+	}
+	masterSettings, err := ReadReplicationSettings(masterKey)
+	if err != nil {
+		log.Errorf("verifyReplicationSettings: cannot read replication settings of %+v: %+v", *masterKey, err)
+		return nil
+	}
+	if err := checkReplicationSettings(replicaSettings, masterSettings); err != nil {
+		return fmt.Errorf("%+v cannot replicate from %+v: %+v", *instanceKey, *masterKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func newTestReplicationSettings() *ReplicationSettings {
+	return &ReplicationSettings{
+		BinlogFormat:        "ROW",
+		BinlogRowImage:      "FULL",
+		LowerCaseTableNames: "0",
+		CharacterSetServer:  "utf8mb4",
+		ReplicationFilters:  make(map[string]string),
+	}
+}
+
+func TestReplicationFilterSet(t *testing.T) {
+	test.S(t).ExpectEquals(len(replicationFilterSet("")), 0)
+	set := replicationFilterSet("db1, db2,db1")
+	test.S(t).ExpectEquals(len(set), 2)
+	test.S(t).ExpectTrue(set["db1"])
+	test.S(t).ExpectTrue(set["db2"])
+}
+
+func TestCheckReplicationSettings(t *testing.T) {
+	{
+		test.S(t).ExpectNil(checkReplicationSettings(newTestReplicationSettings(), newTestReplicationSettings()))
+	}
+	{
+		replica, master := newTestReplicationSettings(), newTestReplicationSettings()
+		replica.LowerCaseTableNames = "1"
+		test.S(t).ExpectNotNil(checkReplicationSettings(replica, master))
+	}
+	{
+		replica, master := newTestReplicationSettings(), newTestReplicationSettings()
+		master.CharacterSetServer = "latin1"
+		test.S(t).ExpectNotNil(checkReplicationSettings(replica, master))
+	}
+	{
+		replica, master := newTestReplicationSettings(), newTestReplicationSettings()
+		replica.BinlogRowImage = "MINIMAL"
+		test.S(t).ExpectNotNil(checkReplicationSettings(replica, master))
+		master.BinlogFormat = "STATEMENT"
+		test.S(t).ExpectNil(checkReplicationSettings(replica, master))
+		// not supported by the server
+		master.BinlogFormat = "ROW"
+		master.BinlogRowImage = ""
+		test.S(t).ExpectNil(checkReplicationSettings(replica, master))
+	}
+	{
+		replica, master := newTestReplicationSettings(), newTestReplicationSettings()
+		master.ReplicationFilters["Replicate_Ignore_DB"] = "db1,db2"
+		test.S(t).ExpectNotNil(checkReplicationSettings(replica, master))
+		replica.ReplicationFilters["Replicate_Ignore_DB"] = "db1"
+		test.S(t).ExpectNotNil(checkReplicationSettings(replica, master))
+		replica.ReplicationFilters["Replicate_Ignore_DB"] = "db2,db1,db3"
+		test.S(t).ExpectNil(checkReplicationSettings(replica, master))
+		// replica's own filters are of no concern
+		test.S(t).ExpectNil(checkReplicationSettings(replica, newTestReplicationSettings()))
+	}
+	{
+		replica, master := newTestReplicationSettings(), newTestReplicationSettings()
+		master.ReplicationFilters["Replicate_Do_DB"] = "db1,db2"
+		test.S(t).ExpectNotNil(checkReplicationSettings(replica, master))
+		replica.ReplicationFilters["Replicate_Do_DB"] = "db1,db3"
+		test.S(t).ExpectNotNil(checkReplicationSettings(replica, master))
+		replica.ReplicationFilters["Replicate_Do_DB"] = "db1"
+		test.S(t).ExpectNil(checkReplicationSettings(replica, master))
+		test.S(t).ExpectNil(checkReplicationSettings(master, newTestReplicationSettings()))
+	}
+}
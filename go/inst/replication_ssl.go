@@ -0,0 +1,232 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// ReplicationSSLStatus describes the SSL state of a single replica's replication connection
+type ReplicationSSLStatus struct {
+	Key        InstanceKey
+	MasterKey  InstanceKey
+	SSLAllowed bool
+	Changed    bool
+	Verified   bool
+	Encrypted  bool
+	Error      string
+}
+
+// IsPlaintext returns true when the replica's connection could not be verified as encrypted
+func (this *ReplicationSSLStatus) IsPlaintext() bool {
+	return !this.Verified || !this.Encrypted
+}
+
+// readEncryptedReplicaConnections reads, on a master, the replication (binlog dump) connections and whether
+// each is encrypted, by means of performance_schema. The result is keyed by resolved replica hostname.
+func readEncryptedReplicaConnections(masterKey *InstanceKey) (map[string]bool, error) {
+	connections := make(map[string]bool)
+	if master, found, _ := ReadInstance(masterKey); found && master.Capabilities.IsProbed() && !master.Capabilities.StatusByThreadTable {
+		return connections, fmt.Errorf("%+v does not support performance_schema.status_by_thread", *masterKey)
+	}
+	db, err := db.OpenTopology(masterKey.Hostname, masterKey.Port)
+	if err != nil {
+		return connections, err
+	}
+	query := `
+		select
+			substring_index(threads.processlist_host, ':', 1) as replica_hostname,
+			ifnull(status_by_thread.variable_value, '') as ssl_cipher
+		from
+			performance_schema.threads
+			left join performance_schema.status_by_thread on (
+				threads.thread_id = status_by_thread.thread_id
+				and status_by_thread.variable_name = 'Ssl_cipher'
+			)
+		where
+			threads.processlist_command in ('Binlog Dump', 'Binlog Dump GTID')
+	`
+	err = sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		hostname, resolveErr := ResolveHostname(m.GetString("replica_hostname"))
+		if resolveErr != nil {
+			hostname = m.GetString("replica_hostname")
+		}
+		connections[hostname] = connections[hostname] || (m.GetString("ssl_cipher") != "")
+		return nil
+	})
+	return connections, err
+}
+
+// enableReplicaSSL sets MASTER_SSL=1 on given replica, stopping and restarting replication as required
+func enableReplicaSSL(replica *Instance) (status *ReplicationSSLStatus) {
+	status = &ReplicationSSLStatus{Key: replica.Key, MasterKey: replica.MasterKey, SSLAllowed: replica.AllowTLS}
+	if replica.AllowTLS {
+		return status
+	}
+	wasRunning := replica.ReplicaRunning()
+	var err error
+	if replica.ReplicationThreadsExist() && !replica.ReplicationThreadsStopped() {
+		if replica, err = StopSlave(&replica.Key); err != nil {
+			status.Error = err.Error()
+			return status
+		}
+	}
+	instance, err := EnableMasterSSL(&replica.Key)
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.SSLAllowed = instance.AllowTLS
+		status.Changed = true
+		AuditOperation("enable-replication-ssl", &replica.Key, fmt.Sprintf("MASTER_SSL=1, replicating from %+v", replica.MasterKey))
+	}
+	if wasRunning {
+		if _, err := StartSlave(&replica.Key); err != nil && status.Error == "" {
+			status.Error = err.Error()
+		}
+	}
+	return status
+}
+
+// replicationSSLRolloutStages splits given replicas into consecutive stages of at most given size
+func replicationSSLRolloutStages(replicas [](*Instance), stageSize uint) [][](*Instance) {
+	if stageSize < 1 {
+		stageSize = 1
+	}
+	stages := [][](*Instance){}
+	for len(replicas) > 0 {
+		size := int(stageSize)
+		if size > len(replicas) {
+			size = len(replicas)
+		}
+		stages = append(stages, replicas[:size])
+		replicas = replicas[size:]
+	}
+	return stages
+}
+
+// clusterSSLReplicas returns the replicas of given cluster which are subject to SSL replication.
+// Binlog servers and MaxScale do not support MASTER_SSL, and are not included.
+func clusterSSLReplicas(clusterName string) ([](*Instance), error) {
+	instances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	replicas := [](*Instance){}
+	for _, instance := range instances {
+		if !instance.IsReplica() || instance.IsBinlogServer() {
+			continue
+		}
+		replicas = append(replicas, instance)
+	}
+	return replicas, nil
+}
+
+// verifyReplicationSSL updates given statuses with the actual encryption state of each replica's
+// connection, as seen on its master
+func verifyReplicationSSL(statuses [](*ReplicationSSLStatus)) {
+	connectionsByMaster := make(map[InstanceKey]map[string]bool)
+	errorsByMaster := make(map[InstanceKey]error)
+	for _, status := range statuses {
+		if _, found := connectionsByMaster[status.MasterKey]; !found {
+			connectionsByMaster[status.MasterKey], errorsByMaster[status.MasterKey] = readEncryptedReplicaConnections(&status.MasterKey)
+		}
+		if err := errorsByMaster[status.MasterKey]; err != nil {
+			status.Error = fmt.Sprintf("cannot verify on %+v: %+v", status.MasterKey, err)
+			continue
+		}
+		encrypted, found := connectionsByMaster[status.MasterKey][status.Key.Hostname]
+		if !found {
+			if status.Error == "" {
+				status.Error = fmt.Sprintf("no replication connection found on %+v", status.MasterKey)
+			}
+			continue
+		}
+		status.Verified = true
+		status.Encrypted = encrypted
+	}
+}
+
+// VerifyClusterReplicationSSL reports, for each replica in given cluster, whether its replication
+// connection is encrypted
+func VerifyClusterReplicationSSL(clusterName string) (statuses [](*ReplicationSSLStatus), err error) {
+	replicas, err := clusterSSLReplicas(clusterName)
+	if err != nil {
+		return statuses, err
+	}
+	for _, replica := range replicas {
+		statuses = append(statuses, &ReplicationSSLStatus{Key: replica.Key, MasterKey: replica.MasterKey, SSLAllowed: replica.AllowTLS})
+	}
+	verifyReplicationSSL(statuses)
+	return statuses, nil
+}
+
+// EnableClusterReplicationSSL enables MASTER_SSL on all replicas of given cluster. Replicas are handled in
+// stages of ReplicationSSLRolloutConcurrency replicas at a time; a stage with failures halts the rollout.
+// All replicas are then verified for encrypted replication connections.
+func EnableClusterReplicationSSL(clusterName string) (statuses [](*ReplicationSSLStatus), err error) {
+	replicas, err := clusterSSLReplicas(clusterName)
+	if err != nil {
+		return statuses, err
+	}
+	stages := replicationSSLRolloutStages(replicas, config.Config.ReplicationSSLRolloutConcurrency)
+	halted := false
+	for i, stage := range stages {
+		if halted {
+			for _, replica := range stage {
+				statuses = append(statuses, &ReplicationSSLStatus{Key: replica.Key, MasterKey: replica.MasterKey, SSLAllowed: replica.AllowTLS})
+			}
+			continue
+		}
+		log.Infof("EnableClusterReplicationSSL: %s: stage %d/%d, %d replicas", clusterName, i+1, len(stages), len(stage))
+		barrier := make(chan *ReplicationSSLStatus)
+		for _, replica := range stage {
+			replica := replica
+			go func() {
+				var status *ReplicationSSLStatus
+				defer func() { barrier <- status }()
+				ExecuteOnTopology(func() {
+					status = enableReplicaSSL(replica)
+				})
+			}()
+		}
+		for range stage {
+			status := <-barrier
+			if status.Error != "" {
+				log.Errorf("EnableClusterReplicationSSL: %+v: %s", status.Key, status.Error)
+				halted = true
+			}
+			statuses = append(statuses, status)
+		}
+		if halted {
+			log.Errorf("EnableClusterReplicationSSL: %s: halting rollout after stage %d/%d due to errors", clusterName, i+1, len(stages))
+		}
+	}
+	verifyReplicationSSL(statuses)
+	for _, status := range statuses {
+		if status.IsPlaintext() {
+			log.Warningf("EnableClusterReplicationSSL: %+v still replicates in plaintext from %+v", status.Key, status.MasterKey)
+		}
+	}
+	AuditOperation("enable-cluster-replication-ssl", nil, fmt.Sprintf("cluster %s: %d replicas, halted: %t", clusterName, len(statuses), halted))
+	return statuses, nil
+}
@@ -0,0 +1,39 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestReplicationSSLRolloutStages(t *testing.T) {
+	replicas := [](*Instance){}
+	for i := 0; i < 5; i++ {
+		replicas = append(replicas, &Instance{Key: InstanceKey{Hostname: "replica", Port: 3306 + i}})
+	}
+	{
+		stages := replicationSSLRolloutStages(replicas, 2)
+		test.S(t).ExpectEquals(len(stages), 3)
+		test.S(t).ExpectEquals(len(stages[0]), 2)
+		test.S(t).ExpectEquals(len(stages[2]), 1)
+		test.S(t).ExpectEquals(stages[2][0].Key.Port, 3310)
+	}
+	{
+		stages := replicationSSLRolloutStages(replicas, 0)
+		test.S(t).ExpectEquals(len(stages), 5)
+	}
+	{
+		stages := replicationSSLRolloutStages(replicas, 10)
+		test.S(t).ExpectEquals(len(stages), 1)
+	}
+	{
+		stages := replicationSSLRolloutStages([](*Instance){}, 3)
+		test.S(t).ExpectEquals(len(stages), 0)
+	}
+}
+
+func TestReplicationSSLStatusIsPlaintext(t *testing.T) {
+	test.S(t).ExpectTrue((&ReplicationSSLStatus{}).IsPlaintext())
+	test.S(t).ExpectTrue((&ReplicationSSLStatus{Verified: true}).IsPlaintext())
+	test.S(t).ExpectFalse((&ReplicationSSLStatus{Verified: true, Encrypted: true}).IsPlaintext())
+}
@@ -0,0 +1,213 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/openark/golib/log"
+)
+
+// isSemiSyncReplicaCandidate returns true when given replica may acknowledge semi-sync transactions. As with
+// SemiSyncEnforced, only promotable replicas acknowledge.
+func isSemiSyncReplicaCandidate(replica *Instance) bool {
+	if !replica.IsLastCheckValid || !replica.Slave_IO_Running {
+		return false
+	}
+	if replica.IsBinlogServer() {
+		return false
+	}
+	return replica.PromotionRule != MustNotPromoteRule
+}
+
+// semiSyncReplicaCandidates returns the replicas which may acknowledge semi-sync transactions, in order of
+// preference: replicas already acknowledging first (so as to avoid flapping), then by promotion rule and priority.
+func semiSyncReplicaCandidates(replicas [](*Instance)) (candidates [](*Instance)) {
+	for _, replica := range replicas {
+		if isSemiSyncReplicaCandidate(replica) {
+			candidates = append(candidates, replica)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].SemiSyncReplicaEnabled != candidates[j].SemiSyncReplicaEnabled {
+			return candidates[i].SemiSyncReplicaEnabled
+		}
+		if candidates[i].IsPreferredForPromotionOver(candidates[j]) != candidates[j].IsPreferredForPromotionOver(candidates[i]) {
+			return candidates[i].IsPreferredForPromotionOver(candidates[j])
+		}
+		return candidates[i].Key.SmallerThan(&candidates[j].Key)
+	})
+	return candidates
+}
+
+// semiSyncAcknowledgingReplicas returns those of given replicas which, as last polled, acknowledged semi-sync
+// transactions of given master. Since the master only commits once acknowledged, these are guaranteed to have
+// its last committed transactions. Returns nil when the master was not known to be running semi-sync, or no
+// replica acknowledged.
+func semiSyncAcknowledgingReplicas(master *Instance, replicas [](*Instance)) *InstanceKeyMap {
+	if master == nil || !master.SemiSyncMasterEnabled {
+		return nil
+	}
+	acknowledgers := NewInstanceKeyMap()
+	for _, replica := range replicas {
+		if replica.SemiSyncReplicaEnabled && replica.MasterKey.Equals(&master.Key) {
+			acknowledgers.AddKey(replica.Key)
+		}
+	}
+	if len(*acknowledgers) == 0 {
+		return nil
+	}
+	return acknowledgers
+}
+
+// planMasterSemiSync computes which replicas of a master should have semi-sync enabled or disabled, such that
+// (up to) given number of replicas acknowledge. It returns the master's wait count to match.
+func planMasterSemiSync(replicas [](*Instance), count uint) (enable [](*Instance), disable [](*Instance), waitCount uint) {
+	candidates := semiSyncReplicaCandidates(replicas)
+	if uint(len(candidates)) > count {
+		candidates = candidates[:count]
+	}
+	chosen := NewInstanceKeyMap()
+	for _, candidate := range candidates {
+		chosen.AddKey(candidate.Key)
+		if !candidate.SemiSyncReplicaEnabled {
+			enable = append(enable, candidate)
+		}
+	}
+	for _, replica := range replicas {
+		if replica.SemiSyncReplicaEnabled && !chosen.HasKey(replica.Key) {
+			disable = append(disable, replica)
+		}
+	}
+	return enable, disable, uint(len(candidates))
+}
+
+// supportsSemiSyncWaitForReplicaCount returns true for servers supporting rpl_semi_sync_master_wait_for_slave_count
+func (this *Instance) supportsSemiSyncWaitForReplicaCount() bool {
+	return this.Capabilities.SemiSyncWaitForReplicaCount
+}
+
+// SetSemiSyncMasterWaitForReplicaCount sets rpl_semi_sync_master_wait_for_slave_count on given master
+func SetSemiSyncMasterWaitForReplicaCount(instanceKey *InstanceKey, count uint) (*Instance, error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, err
+	}
+	if !instance.supportsSemiSyncWaitForReplicaCount() {
+		return instance, fmt.Errorf("%+v does not support rpl_semi_sync_master_wait_for_slave_count", *instanceKey)
+	}
+	if count < 1 {
+		return instance, fmt.Errorf("rpl_semi_sync_master_wait_for_slave_count must be at least 1")
+	}
+	if _, err := ExecInstance(instanceKey, "set @@global.rpl_semi_sync_master_wait_for_slave_count=?", count); err != nil {
+		return instance, log.Errore(err)
+	}
+	AuditOperation("semi-sync-wait-count", instanceKey, fmt.Sprintf("rpl_semi_sync_master_wait_for_slave_count=%d", count))
+	return ReadTopologyInstance(instanceKey)
+}
+
+// EnforceMasterSemiSync makes (up to) given number of the master's replicas acknowledge semi-sync transactions,
+// disabling semi-sync on other replicas, and sets the master's semi-sync wait count to match. Replicas are enabled
+// before the master waits for them, and disabled only after.
+func EnforceMasterSemiSync(masterKey *InstanceKey, count uint) (*Instance, error) {
+	master, err := ReadTopologyInstance(masterKey)
+	if err != nil {
+		return master, err
+	}
+	replicas, err := ReadReplicaInstances(masterKey)
+	if err != nil {
+		return master, err
+	}
+	enable, disable, waitCount := planMasterSemiSync(replicas, count)
+	if waitCount == 0 {
+		return master, fmt.Errorf("EnforceMasterSemiSync: %+v has no replicas eligible for semi-sync", *masterKey)
+	}
+	if waitCount < count {
+		log.Warningf("EnforceMasterSemiSync: %+v has only %d replicas eligible for semi-sync; %d requested", *masterKey, waitCount, count)
+	}
+	for _, replica := range enable {
+		if _, err := SetSemiSyncReplica(&replica.Key, true); err != nil {
+			return master, err
+		}
+	}
+	if master.supportsSemiSyncWaitForReplicaCount() && master.SemiSyncMasterWaitForReplicaCount != waitCount {
+		if _, err := ExecInstance(masterKey, "set @@global.rpl_semi_sync_master_wait_for_slave_count=?", waitCount); err != nil {
+			return master, log.Errore(err)
+		}
+	}
+	if !master.SemiSyncMasterEnabled {
+		if _, err := ExecInstance(masterKey, "set @@global.rpl_semi_sync_master_enabled=1"); err != nil {
+			return master, log.Errore(err)
+		}
+	}
+	for _, replica := range disable {
+		if _, err := SetSemiSyncReplica(&replica.Key, false); err != nil {
+			return master, err
+		}
+	}
+	AuditOperation("enforce-semi-sync", masterKey, fmt.Sprintf("semi-sync replicas: %d; enabled on %d, disabled on %d", waitCount, len(enable), len(disable)))
+	return ReadTopologyInstance(masterKey)
+}
+
+// isMasterSemiSyncEnforced returns true when backend data shows given master's semi-sync setup matches given
+// number of semi-sync replicas
+func isMasterSemiSyncEnforced(master *Instance, replicas [](*Instance), count uint) bool {
+	enable, disable, waitCount := planMasterSemiSync(replicas, count)
+	if waitCount == 0 {
+		// Nothing we can enforce
+		return true
+	}
+	if len(enable) > 0 || len(disable) > 0 {
+		return false
+	}
+	if master.supportsSemiSyncWaitForReplicaCount() && master.SemiSyncMasterWaitForReplicaCount != waitCount {
+		return false
+	}
+	return true
+}
+
+// EnforceSemiSyncReplicasPerMaster periodically enforces SemiSyncReplicasPerMaster on writeable masters which
+// use semi-sync. Masters are only touched when their backend data does not match the expected setup.
+func EnforceSemiSyncReplicasPerMaster() {
+	count := config.Config.SemiSyncReplicasPerMaster
+	if count == 0 {
+		return
+	}
+	masters, err := ReadWriteableClustersMasters()
+	if err != nil {
+		return
+	}
+	for _, master := range masters {
+		if !master.SemiSyncMasterEnabled || !master.IsLastCheckValid {
+			continue
+		}
+		replicas, err := ReadReplicaInstances(&master.Key)
+		if err != nil {
+			continue
+		}
+		if isMasterSemiSyncEnforced(master, replicas, count) {
+			continue
+		}
+		ExecuteOnTopology(func() {
+			_, err := EnforceMasterSemiSync(&master.Key, count)
+			log.Errore(err)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func mkSemiSyncReplicas() []*Instance {
+	replicas := []*Instance{}
+	for _, hostname := range []string{"r1", "r2", "r3", "r4"} {
+		replica := NewInstance()
+		replica.Key = InstanceKey{Hostname: hostname, Port: 3306}
+		replica.IsLastCheckValid = true
+		replica.Slave_IO_Running = true
+		replica.PromotionRule = NeutralPromoteRule
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
+
+func TestSemiSyncReplicaCandidates(t *testing.T) {
+	replicas := mkSemiSyncReplicas()
+	replicas[0].PromotionRule = MustNotPromoteRule
+	replicas[1].Slave_IO_Running = false
+	replicas[2].PromotionRule = PreferNotPromoteRule
+	replicas[3].PromotionRule = PreferPromoteRule
+
+	candidates := semiSyncReplicaCandidates(replicas)
+	test.S(t).ExpectEquals(len(candidates), 2)
+	test.S(t).ExpectTrue(candidates[0].Key.Equals(&replicas[3].Key))
+	test.S(t).ExpectTrue(candidates[1].Key.Equals(&replicas[2].Key))
+
+	replicas[2].SemiSyncReplicaEnabled = true
+	candidates = semiSyncReplicaCandidates(replicas)
+	test.S(t).ExpectTrue(candidates[0].Key.Equals(&replicas[2].Key))
+}
+
+func TestPlanMasterSemiSync(t *testing.T) {
+	replicas := mkSemiSyncReplicas()
+	replicas[1].SemiSyncReplicaEnabled = true
+	replicas[3].SemiSyncReplicaEnabled = true
+	replicas[3].PromotionRule = MustNotPromoteRule
+
+	enable, disable, waitCount := planMasterSemiSync(replicas, 2)
+	test.S(t).ExpectEquals(waitCount, uint(2))
+	test.S(t).ExpectEquals(len(enable), 1)
+	test.S(t).ExpectTrue(enable[0].Key.Equals(&replicas[0].Key))
+	test.S(t).ExpectEquals(len(disable), 1)
+	test.S(t).ExpectTrue(disable[0].Key.Equals(&replicas[3].Key))
+
+	enable, disable, waitCount = planMasterSemiSync(replicas, 5)
+	test.S(t).ExpectEquals(waitCount, uint(3))
+	test.S(t).ExpectEquals(len(enable), 2)
+	test.S(t).ExpectEquals(len(disable), 1)
+}
+
+func TestIsMasterSemiSyncEnforced(t *testing.T) {
+	master := NewInstance()
+	master.Key = InstanceKey{Hostname: "m", Port: 3306}
+	master.Version = "5.7.30"
+	master.SemiSyncMasterWaitForReplicaCount = 1
+	replicas := mkSemiSyncReplicas()
+	replicas[2].SemiSyncReplicaEnabled = true
+
+	test.S(t).ExpectTrue(isMasterSemiSyncEnforced(master, replicas, 1))
+	test.S(t).ExpectFalse(isMasterSemiSyncEnforced(master, replicas, 2))
+
+	for _, replica := range replicas {
+		replica.Slave_IO_Running = false
+	}
+	test.S(t).ExpectTrue(isMasterSemiSyncEnforced(master, replicas, 2))
+}
+
+func TestSemiSyncAcknowledgingReplicas(t *testing.T) {
+	master := NewInstance()
+	master.Key = InstanceKey{Hostname: "m", Port: 3306}
+	replicas := mkSemiSyncReplicas()
+	for _, replica := range replicas {
+		replica.MasterKey = master.Key
+	}
+	replicas[1].SemiSyncReplicaEnabled = true
+	replicas[3].SemiSyncReplicaEnabled = true
+
+	test.S(t).ExpectTrue(semiSyncAcknowledgingReplicas(nil, replicas) == nil)
+	test.S(t).ExpectTrue(semiSyncAcknowledgingReplicas(master, replicas) == nil)
+
+	master.SemiSyncMasterEnabled = true
+	acknowledgers := semiSyncAcknowledgingReplicas(master, replicas)
+	test.S(t).ExpectEquals(len(*acknowledgers), 2)
+	test.S(t).ExpectTrue(acknowledgers.HasKey(replicas[1].Key))
+	test.S(t).ExpectTrue(acknowledgers.HasKey(replicas[3].Key))
+
+	replicas[1].SemiSyncReplicaEnabled = false
+	replicas[3].SemiSyncReplicaEnabled = false
+	test.S(t).ExpectTrue(semiSyncAcknowledgingReplicas(master, replicas) == nil)
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/openark/golib/log"
+)
+
+// Operation types by which StopReplicationPolicies are configured
+const (
+	StopReplicationOperationRegroup    = "regroup"
+	StopReplicationOperationMakeMaster = "make-master"
+)
+
+// stopReplicationPolicy returns the method and timeout by which given operation type stops replication,
+// taking StopReplicationPolicies overrides into account. A zero timeout waits indefinitely for a nice stop,
+// as long as the SQL thread makes progress.
+func stopReplicationPolicy(operation string) (method StopReplicationMethod, timeout time.Duration) {
+	method = StopReplicationNicely
+	if operation == StopReplicationOperationRegroup {
+		timeout = time.Duration(config.Config.InstanceBulkOperationsWaitTimeoutSeconds) * time.Second
+	}
+	if policy, found := config.Config.StopReplicationPolicies[operation]; found {
+		if policy.Method != "" {
+			method = StopReplicationMethod(policy.Method)
+		}
+		if policy.TimeoutSeconds > 0 {
+			timeout = time.Duration(policy.TimeoutSeconds) * time.Second
+		}
+	}
+	return method, timeout
+}
+
+// StopSlaveEscalating attempts to stop replication nicely, up to given timeout, and escalates to a normal
+// stop should that fail. The escalation is audited.
+func StopSlaveEscalating(instanceKey *InstanceKey, timeout time.Duration) (*Instance, error) {
+	instance, err := StopSlaveNicely(instanceKey, timeout)
+	if err == nil {
+		return instance, nil
+	}
+	log.Warningf("StopSlaveEscalating: %+v: nice stop failed; stopping normally", *instanceKey)
+	AuditOperation("stop-slave-escalated", instanceKey, fmt.Sprintf("nice stop failed: %+v; stopped normally", err))
+	return StopSlave(instanceKey)
+}
+
+// stopSlaveByPolicy stops replication on given instance by the policy of given operation type
+func stopSlaveByPolicy(instanceKey *InstanceKey, operation string) (*Instance, error) {
+	method, timeout := stopReplicationPolicy(operation)
+	switch method {
+	case NoStopReplication:
+		return ReadTopologyInstance(instanceKey)
+	case StopReplicationNormal:
+		return StopSlave(instanceKey)
+	}
+	return StopSlaveEscalating(instanceKey, timeout)
+}
@@ -0,0 +1,39 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestStopReplicationPolicy(t *testing.T) {
+	defer func(policies config.StopSlavePolicies) { config.Config.StopReplicationPolicies = policies }(config.Config.StopReplicationPolicies)
+	config.Config.StopReplicationPolicies = config.StopSlavePolicies{}
+	bulkTimeout := time.Duration(config.Config.InstanceBulkOperationsWaitTimeoutSeconds) * time.Second
+	{
+		method, timeout := stopReplicationPolicy(StopReplicationOperationRegroup)
+		test.S(t).ExpectEquals(method, StopReplicationMethod(StopReplicationNicely))
+		test.S(t).ExpectEquals(timeout, bulkTimeout)
+	}
+	{
+		method, timeout := stopReplicationPolicy(StopReplicationOperationMakeMaster)
+		test.S(t).ExpectEquals(method, StopReplicationMethod(StopReplicationNicely))
+		test.S(t).ExpectEquals(timeout, time.Duration(0))
+	}
+	config.Config.StopReplicationPolicies = config.StopSlavePolicies{
+		StopReplicationOperationRegroup:    {Method: "StopReplicationNormal"},
+		StopReplicationOperationMakeMaster: {TimeoutSeconds: 30},
+	}
+	{
+		method, timeout := stopReplicationPolicy(StopReplicationOperationRegroup)
+		test.S(t).ExpectEquals(method, StopReplicationMethod(StopReplicationNormal))
+		test.S(t).ExpectEquals(timeout, bulkTimeout)
+	}
+	{
+		method, timeout := stopReplicationPolicy(StopReplicationOperationMakeMaster)
+		test.S(t).ExpectEquals(method, StopReplicationMethod(StopReplicationNicely))
+		test.S(t).ExpectEquals(timeout, 30*time.Second)
+	}
+}
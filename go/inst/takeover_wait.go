@@ -0,0 +1,145 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// resolveTakeoverWait returns the replicas which graceful master takeovers of given cluster must wait for, as
+// declared in TakeoverMustWaitForReplicas by cluster name, then by alias
+func resolveTakeoverWait(clusterName string, clusterAlias string) (wait config.TakeoverWait, found bool) {
+	if wait, found = config.Config.TakeoverMustWaitForReplicas[clusterName]; found {
+		return wait, found
+	}
+	if clusterAlias != "" {
+		wait, found = config.Config.TakeoverMustWaitForReplicas[clusterAlias]
+	}
+	return wait, found
+}
+
+// GetTakeoverWait returns the replicas which graceful master takeovers of given cluster must wait for, if any
+func GetTakeoverWait(clusterName string) (wait config.TakeoverWait, found bool) {
+	if len(config.Config.TakeoverMustWaitForReplicas) == 0 {
+		return wait, false
+	}
+	clusterAlias, _ := ReadAliasByClusterName(clusterName)
+	return resolveTakeoverWait(clusterName, clusterAlias)
+}
+
+// ReadTakeoverWaitReplicas returns the instances of given cluster matching given wait's tags or keys, other than
+// excludedKey. A declared key which is not an instance of the cluster is an error: a takeover is not to proceed
+// without a replica it was declared to wait for.
+func ReadTakeoverWaitReplicas(clusterName string, wait config.TakeoverWait, excludedKey *InstanceKey) ([](*Instance), error) {
+	clusterInstances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	declaredKeys := NewInstanceKeyMap()
+	for _, hostPort := range wait.Keys {
+		key, err := ParseResolveInstanceKey(hostPort)
+		if err != nil {
+			return nil, fmt.Errorf("TakeoverMustWaitForReplicas: %+v", err)
+		}
+		declaredKeys.AddKey(*key)
+	}
+	var tagsConstraint [](*Tag)
+	var instanceTags map[InstanceKey][](*Tag)
+	if wait.Tags != "" {
+		if tagsConstraint, err = ParseIntersectTags(wait.Tags); err != nil {
+			return nil, fmt.Errorf("TakeoverMustWaitForReplicas: invalid tags %s: %+v", wait.Tags, err)
+		}
+		if instanceTags, err = readAllInstanceTags(); err != nil {
+			return nil, err
+		}
+	}
+
+	replicas := [](*Instance){}
+	foundKeys := NewInstanceKeyMap()
+	for _, instance := range clusterInstances {
+		declared := declaredKeys.HasKey(instance.Key)
+		if declared {
+			foundKeys.AddKey(instance.Key)
+		}
+		if !declared && !(tagsConstraint != nil && tagsSatisfy(instanceTags[instance.Key], tagsConstraint)) {
+			continue
+		}
+		if !instance.IsReplica() || (excludedKey != nil && instance.Key.Equals(excludedKey)) {
+			continue
+		}
+		replicas = append(replicas, instance)
+	}
+	for _, key := range declaredKeys.GetInstanceKeys() {
+		if !foundKeys.HasKey(key) {
+			return nil, fmt.Errorf("TakeoverMustWaitForReplicas: %+v is not an instance of %s", key, clusterName)
+		}
+	}
+	return replicas, nil
+}
+
+// takeoverWaitViolation returns why given replica is not confirmed caught up, or an empty string when it is
+func takeoverWaitViolation(replica *Instance, maxLagSeconds uint) string {
+	if !replica.ReplicaRunning() {
+		return "not replicating"
+	}
+	if !replica.SlaveLagSeconds.Valid {
+		return "replication lag unknown"
+	}
+	if replica.SlaveLagSeconds.Int64 > int64(maxLagSeconds) {
+		return fmt.Sprintf("lag of %ds exceeds %ds", replica.SlaveLagSeconds.Int64, maxLagSeconds)
+	}
+	return ""
+}
+
+// WaitForTakeoverReplicas waits for given replicas to replicate within the wait's MaxLagSeconds. Once its
+// TimeoutSeconds pass, returns an error stating why each replica still pending is so.
+func WaitForTakeoverReplicas(replicas [](*Instance), wait config.TakeoverWait) error {
+	deadline := time.Now().Add(time.Duration(wait.TimeoutSeconds) * time.Second)
+	pendingKeys := []InstanceKey{}
+	for _, replica := range replicas {
+		pendingKeys = append(pendingKeys, replica.Key)
+	}
+	for {
+		stillPendingKeys := []InstanceKey{}
+		reasons := []string{}
+		for _, key := range pendingKeys {
+			key := key
+			reason := ""
+			if replica, err := ReadTopologyInstance(&key); err != nil {
+				reason = fmt.Sprintf("cannot be read: %+v", err)
+			} else {
+				reason = takeoverWaitViolation(replica, wait.MaxLagSeconds)
+			}
+			if reason != "" {
+				stillPendingKeys = append(stillPendingKeys, key)
+				reasons = append(reasons, fmt.Sprintf("%+v: %s", key, reason))
+			}
+		}
+		pendingKeys = stillPendingKeys
+		if len(pendingKeys) == 0 {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("replicas not confirmed within %ds: %s", wait.TimeoutSeconds, strings.Join(reasons, "; "))
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,50 @@
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestTakeoverWaitViolation(t *testing.T) {
+	replica := &Instance{
+		Key:                       InstanceKey{Hostname: "replica", Port: 3306},
+		MasterKey:                 InstanceKey{Hostname: "master", Port: 3306},
+		ReadBinlogCoordinates:     BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 4},
+		ReplicationSQLThreadState: ReplicationThreadStateRunning,
+		ReplicationIOThreadState:  ReplicationThreadStateRunning,
+		SlaveLagSeconds:           sql.NullInt64{Int64: 3, Valid: true},
+	}
+	test.S(t).ExpectEquals(takeoverWaitViolation(replica, 5), "")
+	test.S(t).ExpectEquals(takeoverWaitViolation(replica, 3), "")
+	test.S(t).ExpectEquals(takeoverWaitViolation(replica, 2), "lag of 3s exceeds 2s")
+
+	replica.SlaveLagSeconds.Valid = false
+	test.S(t).ExpectEquals(takeoverWaitViolation(replica, 5), "replication lag unknown")
+
+	replica.ReplicationIOThreadState = ReplicationThreadStateStopped
+	test.S(t).ExpectEquals(takeoverWaitViolation(replica, 5), "not replicating")
+}
+
+func TestResolveTakeoverWait(t *testing.T) {
+	defer func(waits config.TakeoverWaits) {
+		config.Config.TakeoverMustWaitForReplicas = waits
+	}(config.Config.TakeoverMustWaitForReplicas)
+
+	config.Config.TakeoverMustWaitForReplicas = config.TakeoverWaits{
+		"c1:3306":  {Tags: "role=backup", MaxLagSeconds: 1},
+		"payments": {Keys: []string{"db-dr:3306"}, MaxLagSeconds: 2},
+	}
+	wait, found := resolveTakeoverWait("c1:3306", "c1")
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(wait.Tags, "role=backup")
+
+	wait, found = resolveTakeoverWait("c2:3306", "payments")
+	test.S(t).ExpectTrue(found)
+	test.S(t).ExpectEquals(wait.MaxLagSeconds, uint(2))
+
+	_, found = resolveTakeoverWait("c3:3306", "")
+	test.S(t).ExpectFalse(found)
+}
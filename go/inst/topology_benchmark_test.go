@@ -0,0 +1,176 @@
+package inst
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// benchmarkFleetSizes are the numbers of instances in synthetic benchmark fleets. script/benchmark compares how
+// benchmarks scale between them.
+var benchmarkFleetSizes = []int{1000, 10000}
+
+// benchmarkFleets runs given benchmark on each of benchmarkFleetSizes
+func benchmarkFleets(b *testing.B, benchmark func(b *testing.B, fleetSize int)) {
+	for _, fleetSize := range benchmarkFleetSizes {
+		fleetSize := fleetSize
+		b.Run(fmt.Sprintf("instances=%d", fleetSize), func(b *testing.B) {
+			b.ReportAllocs()
+			benchmark(b, fleetSize)
+		})
+	}
+}
+
+// syntheticFleetUUID returns a distinct server UUID per given index
+func syntheticFleetUUID(i int) string {
+	return fmt.Sprintf("%08x-0000-11e9-0000-%012x", i, i)
+}
+
+// syntheticReplicas returns given number of valid candidate replicas of a single master, in random though
+// reproducible order. Replicas span binary logs, data centers, versions and binlog formats.
+func syntheticReplicas(count int) [](*Instance) {
+	versions := []string{"5.7.28-log", "5.7.30-log", "8.0.20"}
+	binlogFormats := []string{"ROW", "ROW", "MIXED"}
+	replicas := [](*Instance){}
+	for i := 0; i < count; i++ {
+		replica := &Instance{
+			Key:                    InstanceKey{Hostname: fmt.Sprintf("replica-%05d", i), Port: 3306},
+			MasterKey:              InstanceKey{Hostname: "master", Port: 3306},
+			ServerID:               uint(i + 1),
+			ServerUUID:             syntheticFleetUUID(i),
+			ClusterName:            "master:3306",
+			DataCenter:             fmt.Sprintf("dc%d", i%3),
+			Version:                versions[i%len(versions)],
+			Binlog_format:          binlogFormats[i%len(binlogFormats)],
+			ExecBinlogCoordinates:  BinlogCoordinates{LogFile: fmt.Sprintf("mysql-bin.%06d", 100+i%7), LogPos: int64(i % 997)},
+			IsLastCheckValid:       true,
+			IsRecentlyChecked:      true,
+			LogBinEnabled:          true,
+			LogSlaveUpdatesEnabled: true,
+			Slave_SQL_Running:      true,
+			Slave_IO_Running:       true,
+			PromotionRule:          NeutralPromoteRule,
+		}
+		replicas = append(replicas, replica)
+	}
+	random := rand.New(rand.NewSource(1))
+	random.Shuffle(len(replicas), func(i, j int) { replicas[i], replicas[j] = replicas[j], replicas[i] })
+	return replicas
+}
+
+// syntheticTopology returns a topology of given number of instances: a master, intermediate masters replicating
+// from it, and leaf replicas spread among the intermediate masters
+func syntheticTopology(count int) [](*Instance) {
+	master := &Instance{Key: InstanceKey{Hostname: "master", Port: 3306}, Version: "5.7.30-log", IsLastCheckValid: true, IsRecentlyChecked: true}
+	instances := [](*Instance){master}
+	intermediateMasters := count / 100
+	for i, replica := range syntheticReplicas(count - 1) {
+		if i >= intermediateMasters {
+			replica.MasterKey = instances[1+i%intermediateMasters].Key
+		}
+		instances = append(instances, replica)
+	}
+	return instances
+}
+
+func BenchmarkChooseCandidateReplicaFleet(b *testing.B) {
+	benchmarkFleets(b, func(b *testing.B, fleetSize int) {
+		replicas := syntheticReplicas(fleetSize)
+		sortInstances(replicas)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			// chooseCandidateReplica removes the candidate from given list, in place
+			replicasCopy := append([](*Instance){}, replicas...)
+			chooseCandidateReplica(replicasCopy)
+		}
+	})
+}
+
+func BenchmarkSortInstancesPromotionPreferenceFleet(b *testing.B) {
+	benchmarkFleets(b, func(b *testing.B, fleetSize int) {
+		replicas := syntheticReplicas(fleetSize)
+		dataCenterPreference := &PromotionDataCenterPreference{FailedMasterDataCenter: "dc1"}
+		semiSyncAcknowledgers := NewInstanceKeyMap()
+		for _, replica := range replicas[:fleetSize/10] {
+			semiSyncAcknowledgers.AddKey(replica.Key)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			replicasCopy := append([](*Instance){}, replicas...)
+			sortInstancesPromotionPreference(replicasCopy, dataCenterPreference, semiSyncAcknowledgers)
+		}
+	})
+}
+
+func BenchmarkRenderASCIITopologyFleet(b *testing.B) {
+	benchmarkFleets(b, func(b *testing.B, fleetSize int) {
+		instances := syntheticTopology(fleetSize)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			renderASCIITopology(instances, true, false, false)
+		}
+	})
+}
+
+func BenchmarkRenderASCIITopologyTabulatedFleet(b *testing.B) {
+	benchmarkFleets(b, func(b *testing.B, fleetSize int) {
+		instances := syntheticTopology(fleetSize)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			renderASCIITopology(instances, true, true, true)
+		}
+	})
+}
+
+// syntheticFleetGtidSet returns a GTID set with an entry per server of a fleet of given size
+func syntheticFleetGtidSet(count int, offset int) string {
+	entries := []string{}
+	for i := 0; i < count; i++ {
+		entries = append(entries, fmt.Sprintf("%s:1-%d:%d-%d", syntheticFleetUUID(i), 1000+offset, 2000+offset, 5000+offset))
+	}
+	return strings.Join(entries, ",")
+}
+
+func BenchmarkParseGtidIntervalSetFleet(b *testing.B) {
+	benchmarkFleets(b, func(b *testing.B, fleetSize int) {
+		gtidSet := syntheticFleetGtidSet(fleetSize, 0)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ParseGtidIntervalSet(gtidSet)
+		}
+	})
+}
+
+func BenchmarkGtidIntervalSetSubtractFleet(b *testing.B) {
+	benchmarkFleets(b, func(b *testing.B, fleetSize int) {
+		set, _ := ParseGtidIntervalSet(syntheticFleetGtidSet(fleetSize, 10))
+		subset, _ := ParseGtidIntervalSet(syntheticFleetGtidSet(fleetSize/2, 0))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			set.Subtract(subset)
+		}
+	})
+}
+
+func BenchmarkGtidIntervalSetUnionFleet(b *testing.B) {
+	benchmarkFleets(b, func(b *testing.B, fleetSize int) {
+		set, _ := ParseGtidIntervalSet(syntheticFleetGtidSet(fleetSize, 10))
+		other, _ := ParseGtidIntervalSet(syntheticFleetGtidSet(fleetSize/2, 0))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			set.Union(other)
+		}
+	})
+}
+
+func BenchmarkOracleGtidSetSharedUUIDsFleet(b *testing.B) {
+	benchmarkFleets(b, func(b *testing.B, fleetSize int) {
+		set, _ := NewOracleGtidSet(syntheticFleetGtidSet(fleetSize, 0))
+		other, _ := NewOracleGtidSet(syntheticFleetGtidSet(fleetSize/2, 0))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			set.SharedUUIDs(other)
+		}
+	})
+}
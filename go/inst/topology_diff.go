@@ -0,0 +1,172 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TopologyEdgeChange is a change of master of a single instance
+type TopologyEdgeChange struct {
+	Key           InstanceKey
+	FromMasterKey InstanceKey
+	ToMasterKey   InstanceKey
+}
+
+// TopologyDiffNode is an instance in a before or after tree of a topology diff. Changed is true when the
+// edge to the instance's master is removed (before tree) or added (after tree).
+type TopologyDiffNode struct {
+	Key       InstanceKey
+	MasterKey InstanceKey
+	Changed   bool
+	Children  [](*TopologyDiffNode)
+}
+
+// TopologyDiff is the topology before and after a planned restructure, along with the edges which change
+type TopologyDiff struct {
+	ChangedEdges []TopologyEdgeChange
+	Before       [](*TopologyDiffNode)
+	After        [](*TopologyDiffNode)
+}
+
+// buildTopologyDiffTree builds the trees of given instance-to-master mapping. Instances whose master is
+// unknown are roots; so is the smallest instance of a replication cycle, such as co-masters.
+func buildTopologyDiffTree(masters map[InstanceKey]InstanceKey, changed map[InstanceKey]bool) (roots [](*TopologyDiffNode)) {
+	keys := []InstanceKey{}
+	replicas := make(map[InstanceKey][]InstanceKey)
+	for instanceKey, masterKey := range masters {
+		keys = append(keys, instanceKey)
+		if _, found := masters[masterKey]; found {
+			replicas[masterKey] = append(replicas[masterKey], instanceKey)
+		}
+	}
+	sortKeys := func(keys []InstanceKey) {
+		sort.Slice(keys, func(i, j int) bool { return keys[i].SmallerThan(&keys[j]) })
+	}
+	sortKeys(keys)
+	visited := make(map[InstanceKey]bool)
+	var buildNode func(instanceKey InstanceKey) *TopologyDiffNode
+	buildNode = func(instanceKey InstanceKey) *TopologyDiffNode {
+		visited[instanceKey] = true
+		node := &TopologyDiffNode{Key: instanceKey, MasterKey: masters[instanceKey], Changed: changed[instanceKey], Children: [](*TopologyDiffNode){}}
+		sortKeys(replicas[instanceKey])
+		for _, replicaKey := range replicas[instanceKey] {
+			if !visited[replicaKey] {
+				node.Children = append(node.Children, buildNode(replicaKey))
+			}
+		}
+		return node
+	}
+	for _, instanceKey := range keys {
+		if _, found := masters[masters[instanceKey]]; !found {
+			roots = append(roots, buildNode(instanceKey))
+		}
+	}
+	for _, instanceKey := range keys {
+		if !visited[instanceKey] {
+			roots = append(roots, buildNode(instanceKey))
+		}
+	}
+	return roots
+}
+
+// computeTopologyDiff computes the before and after trees of given current masters, once given changes apply
+func computeTopologyDiff(currentMasters map[InstanceKey]InstanceKey, changes []TopologyEdgeChange) *TopologyDiff {
+	changed := make(map[InstanceKey]bool)
+	masters := make(map[InstanceKey]InstanceKey)
+	for instanceKey, masterKey := range currentMasters {
+		masters[instanceKey] = masterKey
+	}
+	for _, change := range changes {
+		changed[change.Key] = true
+		masters[change.Key] = change.ToMasterKey
+	}
+	return &TopologyDiff{
+		ChangedEdges: changes,
+		Before:       buildTopologyDiffTree(currentMasters, changed),
+		After:        buildTopologyDiffTree(masters, changed),
+	}
+}
+
+// String returns a unified tree diff: the topology as it would be after the change, with each relocated
+// instance marked "+" at its new position and "-" at its former position
+func (this *TopologyDiff) String() string {
+	removedBelow := make(map[InstanceKey][]TopologyEdgeChange)
+	for _, change := range this.ChangedEdges {
+		removedBelow[change.FromMasterKey] = append(removedBelow[change.FromMasterKey], change)
+	}
+	lines := []string{}
+	var describe func(node *TopologyDiffNode, indent string)
+	describe = func(node *TopologyDiffNode, indent string) {
+		marker := " "
+		line := node.Key.DisplayString()
+		if node.Changed {
+			marker = "+"
+			fromMasterKey := this.fromMasterKey(node.Key)
+			line = fmt.Sprintf("%s (from %s)", line, fromMasterKey.DisplayString())
+		}
+		lines = append(lines, fmt.Sprintf("%s %s%s", marker, indent, line))
+		for _, change := range removedBelow[node.Key] {
+			lines = append(lines, fmt.Sprintf("- %s  %s (to %s)", indent, change.Key.DisplayString(), change.ToMasterKey.DisplayString()))
+		}
+		for _, child := range node.Children {
+			describe(child, indent+"  ")
+		}
+	}
+	for _, root := range this.After {
+		describe(root, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (this *TopologyDiff) fromMasterKey(instanceKey InstanceKey) InstanceKey {
+	for _, change := range this.ChangedEdges {
+		if change.Key.Equals(&instanceKey) {
+			return change.FromMasterKey
+		}
+	}
+	return InstanceKey{}
+}
+
+// readClustersMasters reads the current master of each instance in the clusters of given instances
+func readClustersMasters(instanceKeys ...InstanceKey) (map[InstanceKey]InstanceKey, error) {
+	currentMasters := make(map[InstanceKey]InstanceKey)
+	readClusters := make(map[string]bool)
+	for _, instanceKey := range instanceKeys {
+		instance, found, err := ReadInstance(&instanceKey)
+		if err != nil {
+			return nil, err
+		}
+		if !found || instance == nil {
+			return nil, fmt.Errorf("Unknown instance: %s", instanceKey.DisplayString())
+		}
+		if readClusters[instance.ClusterName] {
+			continue
+		}
+		readClusters[instance.ClusterName] = true
+		clusterInstances, err := ReadClusterInstances(instance.ClusterName)
+		if err != nil {
+			return nil, err
+		}
+		for _, clusterInstance := range clusterInstances {
+			currentMasters[clusterInstance.Key] = clusterInstance.MasterKey
+		}
+	}
+	return currentMasters, nil
+}
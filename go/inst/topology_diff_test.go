@@ -0,0 +1,50 @@
+package inst
+
+import (
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestComputeTopologyDiff(t *testing.T) {
+	currentMasters := map[InstanceKey]InstanceKey{
+		i710Key: {},
+		i720Key: i710Key,
+		i730Key: i720Key,
+		i810Key: i730Key,
+	}
+	diff := computeTopologyDiff(currentMasters, []TopologyEdgeChange{{Key: i730Key, FromMasterKey: i720Key, ToMasterKey: i710Key}})
+
+	test.S(t).ExpectEquals(len(diff.Before), 1)
+	test.S(t).ExpectEquals(len(diff.Before[0].Children), 1)
+	test.S(t).ExpectEquals(diff.Before[0].Children[0].Children[0].Key, i730Key)
+	test.S(t).ExpectTrue(diff.Before[0].Children[0].Children[0].Changed)
+
+	test.S(t).ExpectEquals(len(diff.After), 1)
+	test.S(t).ExpectEquals(len(diff.After[0].Children), 2)
+	test.S(t).ExpectEquals(diff.After[0].Children[1].Key, i730Key)
+	test.S(t).ExpectEquals(diff.After[0].Children[1].Children[0].Key, i810Key)
+
+	expected := []string{
+		"  i710:3306",
+		"    i720:3306",
+		"-     i730:3306 (to i710:3306)",
+		"+   i730:3306 (from i720:3306)",
+		"      i810:3306",
+	}
+	test.S(t).ExpectEquals(diff.String(), strings.Join(expected, "\n"))
+}
+
+func TestBuildTopologyDiffTreeCoMasters(t *testing.T) {
+	masters := map[InstanceKey]InstanceKey{
+		i710Key: i720Key,
+		i720Key: i710Key,
+		i730Key: i720Key,
+	}
+	roots := buildTopologyDiffTree(masters, nil)
+	test.S(t).ExpectEquals(len(roots), 1)
+	test.S(t).ExpectEquals(roots[0].Key, i710Key)
+	test.S(t).ExpectEquals(roots[0].Children[0].Key, i720Key)
+	test.S(t).ExpectEquals(roots[0].Children[0].Children[0].Key, i730Key)
+}
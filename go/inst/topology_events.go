@@ -0,0 +1,217 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/events"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// Types of topology events, published by PublishTopologyChanges
+const (
+	TopologyMasterChangedEvent    = "master-changed"
+	TopologyInstanceAddedEvent    = "instance-added"
+	TopologyInstanceLostEvent     = "instance-lost"
+	TopologyInstanceMovedEvent    = "instance-moved"
+	TopologyRecoveryStartedEvent  = "recovery-started"
+	TopologyRecoveryFinishedEvent = "recovery-finished"
+	// TopologyReplicationChangedEvent is only published for clusters in focus mode
+	TopologyReplicationChangedEvent = "replication-changed"
+)
+
+// topologyChangesStaleFactor is the number of InstancePollSeconds after which the previous state is stale
+const topologyChangesStaleFactor = 3
+
+// topologyChangesInstance is the state of an instance, as compared between consecutive topology change checks
+type topologyChangesInstance struct {
+	MasterKey        InstanceKey
+	ClusterName      string
+	Valid            bool
+	Focused          bool // the instance's cluster is in focus mode
+	IOThreadRunning  bool
+	SQLThreadRunning bool
+}
+
+var topologyChangesMutex sync.Mutex
+var topologyChangesRunning int64
+var topologyChangesInstances map[InstanceKey]topologyChangesInstance
+var topologyChangesCheckedAt time.Time
+
+// readTopologyChangesInstances reads the master, cluster, reachability and replication threads state of all known instances
+func readTopologyChangesInstances() (map[InstanceKey]topologyChangesInstance, error) {
+	instances := make(map[InstanceKey]topologyChangesInstance)
+	focusedClusters := focusedClusterNames()
+	query := `
+		select
+			hostname,
+			port,
+			master_host,
+			master_port,
+			cluster_name,
+			ifnull(last_checked <= last_seen, 0) as is_last_check_valid,
+			slave_io_running,
+			slave_sql_running
+		from
+			database_instance
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		instances[instanceKey] = topologyChangesInstance{
+			MasterKey:        InstanceKey{Hostname: m.GetString("master_host"), Port: m.GetInt("master_port")},
+			ClusterName:      m.GetString("cluster_name"),
+			Valid:            m.GetBool("is_last_check_valid"),
+			Focused:          focusedClusters[m.GetString("cluster_name")],
+			IOThreadRunning:  m.GetBool("slave_io_running"),
+			SQLThreadRunning: m.GetBool("slave_sql_running"),
+		}
+		return nil
+	})
+	return instances, log.Errore(err)
+}
+
+// topologyChangesRoots maps each instance to the root of its tree: its master's master and so forth. The root of
+// a replication cycle, such as co-masters, is the smallest instance of the cycle.
+func topologyChangesRoots(instances map[InstanceKey]topologyChangesInstance) map[InstanceKey]InstanceKey {
+	masters := make(map[InstanceKey]InstanceKey)
+	for instanceKey, instance := range instances {
+		masters[instanceKey] = instance.MasterKey
+	}
+	roots := make(map[InstanceKey]InstanceKey)
+	var assignRoot func(node *TopologyDiffNode, rootKey InstanceKey)
+	assignRoot = func(node *TopologyDiffNode, rootKey InstanceKey) {
+		roots[node.Key] = rootKey
+		for _, child := range node.Children {
+			assignRoot(child, rootKey)
+		}
+	}
+	for _, root := range buildTopologyDiffTree(masters, nil) {
+		assignRoot(root, root.Key)
+	}
+	return roots
+}
+
+// computeTopologyChangeEvents compares two states of the known instances. A cluster's master is deemed changed when a
+// former replica in its tree is now a root which replicas of the former tree replicate from, or which the former master
+// replicates from. Instances are added once known or reachable, and lost once forgotten or unreachable. In clusters in
+// focus mode, changes to the replication threads state are published as well.
+func computeTopologyChangeEvents(before, after map[InstanceKey]topologyChangesInstance) (topologyEvents []*events.Event) {
+	newEvent := func(eventType string, instanceKey InstanceKey, clusterName string, message string) *events.Event {
+		return &events.Event{Kind: events.TopologyEvent, Type: eventType, Hostname: instanceKey.Hostname, Port: instanceKey.Port, ClusterName: clusterName, Message: message}
+	}
+	beforeRoots := topologyChangesRoots(before)
+	afterRoots := topologyChangesRoots(after)
+
+	promoted := make(map[InstanceKey]bool)
+	promotedKeys := []InstanceKey{}
+	for instanceKey := range after {
+		beforeRootKey, found := beforeRoots[instanceKey]
+		if !found || afterRoots[instanceKey] != instanceKey || beforeRootKey == instanceKey {
+			continue
+		}
+		// instanceKey used to be a replica, and is now a root
+		heads := afterRoots[beforeRootKey] == instanceKey
+		for replicaKey := range after {
+			if heads {
+				break
+			}
+			heads = replicaKey != instanceKey && afterRoots[replicaKey] == instanceKey && beforeRoots[replicaKey] == beforeRootKey
+		}
+		if heads {
+			promoted[instanceKey] = true
+			promotedKeys = append(promotedKeys, instanceKey)
+		}
+	}
+	sort.Slice(promotedKeys, func(i, j int) bool { return promotedKeys[i].SmallerThan(&promotedKeys[j]) })
+	for _, instanceKey := range promotedKeys {
+		beforeRootKey := beforeRoots[instanceKey]
+		message := fmt.Sprintf("master changed: %s -> %s", beforeRootKey.DisplayString(), instanceKey.DisplayString())
+		// The cluster is named after its former master; subscribers know it by that name
+		topologyEvents = append(topologyEvents, newEvent(TopologyMasterChangedEvent, instanceKey, before[instanceKey].ClusterName, message))
+	}
+	keys := []InstanceKey{}
+	for instanceKey := range before {
+		keys = append(keys, instanceKey)
+	}
+	for instanceKey := range after {
+		if _, found := before[instanceKey]; !found {
+			keys = append(keys, instanceKey)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].SmallerThan(&keys[j]) })
+	for _, instanceKey := range keys {
+		beforeInstance, wasKnown := before[instanceKey]
+		afterInstance, isKnown := after[instanceKey]
+		switch {
+		case !isKnown:
+			topologyEvents = append(topologyEvents, newEvent(TopologyInstanceLostEvent, instanceKey, beforeInstance.ClusterName, fmt.Sprintf("forgotten: %s", instanceKey.DisplayString())))
+		case !wasKnown:
+			topologyEvents = append(topologyEvents, newEvent(TopologyInstanceAddedEvent, instanceKey, afterInstance.ClusterName, fmt.Sprintf("discovered: %s, replicating from %s", instanceKey.DisplayString(), afterInstance.MasterKey.DisplayString())))
+		default:
+			if beforeInstance.Valid && !afterInstance.Valid {
+				topologyEvents = append(topologyEvents, newEvent(TopologyInstanceLostEvent, instanceKey, afterInstance.ClusterName, fmt.Sprintf("unreachable: %s", instanceKey.DisplayString())))
+			}
+			if !beforeInstance.Valid && afterInstance.Valid {
+				topologyEvents = append(topologyEvents, newEvent(TopologyInstanceAddedEvent, instanceKey, afterInstance.ClusterName, fmt.Sprintf("reachable: %s", instanceKey.DisplayString())))
+			}
+			if !beforeInstance.MasterKey.Equals(&afterInstance.MasterKey) && !promoted[instanceKey] {
+				topologyEvents = append(topologyEvents, newEvent(TopologyInstanceMovedEvent, instanceKey, afterInstance.ClusterName, fmt.Sprintf("moved: %s from %s to %s", instanceKey.DisplayString(), beforeInstance.MasterKey.DisplayString(), afterInstance.MasterKey.DisplayString())))
+			}
+			if afterInstance.Focused && (beforeInstance.IOThreadRunning != afterInstance.IOThreadRunning || beforeInstance.SQLThreadRunning != afterInstance.SQLThreadRunning) {
+				message := fmt.Sprintf("replication changed: %s io thread running: %t, sql thread running: %t", instanceKey.DisplayString(), afterInstance.IOThreadRunning, afterInstance.SQLThreadRunning)
+				topologyEvents = append(topologyEvents, newEvent(TopologyReplicationChangedEvent, instanceKey, afterInstance.ClusterName, message))
+			}
+		}
+	}
+	return topologyEvents
+}
+
+// PublishTopologyChanges compares the known instances with their state as of the previous invocation, and publishes
+// the changes as topology events. It is invoked every InstancePollSeconds, or every FocusModePollSeconds while any
+// cluster is in focus mode. A stale previous state, e.g. as of the last
+// time this node was the leader, is only used as baseline for the next invocation.
+func PublishTopologyChanges() error {
+	if !atomic.CompareAndSwapInt64(&topologyChangesRunning, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreInt64(&topologyChangesRunning, 0)
+
+	instances, err := readTopologyChangesInstances()
+	if err != nil {
+		return err
+	}
+	topologyChangesMutex.Lock()
+	defer topologyChangesMutex.Unlock()
+
+	staleDuration := topologyChangesStaleFactor * time.Duration(config.Config.InstancePollSeconds) * time.Second
+	if topologyChangesInstances != nil && time.Since(topologyChangesCheckedAt) <= staleDuration {
+		for _, event := range computeTopologyChangeEvents(topologyChangesInstances, instances) {
+			events.Publish(event)
+		}
+	}
+	topologyChangesInstances = instances
+	topologyChangesCheckedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,91 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/events"
+	test "github.com/openark/golib/tests"
+)
+
+func TestComputeTopologyChangeEventsFailover(t *testing.T) {
+	before := map[InstanceKey]topologyChangesInstance{
+		i710Key: {ClusterName: "c1", Valid: true},
+		i720Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true},
+		i730Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true},
+		i810Key: {MasterKey: i720Key, ClusterName: "c1", Valid: true},
+	}
+	after := map[InstanceKey]topologyChangesInstance{
+		i710Key: {ClusterName: "c1", Valid: false},
+		i720Key: {ClusterName: "c2", Valid: true},
+		i730Key: {MasterKey: i720Key, ClusterName: "c2", Valid: true},
+		i810Key: {MasterKey: i720Key, ClusterName: "c2", Valid: true},
+	}
+	topologyEvents := computeTopologyChangeEvents(before, after)
+	test.S(t).ExpectEquals(len(topologyEvents), 3)
+
+	test.S(t).ExpectEquals(string(topologyEvents[0].Kind), events.TopologyEvent)
+	test.S(t).ExpectEquals(topologyEvents[0].Type, TopologyMasterChangedEvent)
+	test.S(t).ExpectEquals(topologyEvents[0].Hostname, i720Key.Hostname)
+	test.S(t).ExpectEquals(topologyEvents[0].ClusterName, "c1")
+
+	test.S(t).ExpectEquals(topologyEvents[1].Type, TopologyInstanceLostEvent)
+	test.S(t).ExpectEquals(topologyEvents[1].Hostname, i710Key.Hostname)
+
+	test.S(t).ExpectEquals(topologyEvents[2].Type, TopologyInstanceMovedEvent)
+	test.S(t).ExpectEquals(topologyEvents[2].Hostname, i730Key.Hostname)
+	test.S(t).ExpectEquals(topologyEvents[2].ClusterName, "c2")
+}
+
+func TestComputeTopologyChangeEventsAddedLostDetached(t *testing.T) {
+	before := map[InstanceKey]topologyChangesInstance{
+		i710Key: {ClusterName: "c1", Valid: true},
+		i720Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true},
+		i730Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true},
+		i810Key: {MasterKey: i710Key, ClusterName: "c1", Valid: false},
+	}
+	after := map[InstanceKey]topologyChangesInstance{
+		i710Key: {ClusterName: "c1", Valid: true},
+		i720Key: {ClusterName: "c720", Valid: true},
+		i810Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true},
+		i820Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true},
+	}
+	topologyEvents := computeTopologyChangeEvents(before, after)
+	test.S(t).ExpectEquals(len(topologyEvents), 4)
+	// a detached replica, with no replicas of its own, is not a new master
+	test.S(t).ExpectEquals(topologyEvents[0].Type, TopologyInstanceMovedEvent)
+	test.S(t).ExpectEquals(topologyEvents[0].Hostname, i720Key.Hostname)
+	test.S(t).ExpectEquals(topologyEvents[1].Type, TopologyInstanceLostEvent)
+	test.S(t).ExpectEquals(topologyEvents[1].Hostname, i730Key.Hostname)
+	test.S(t).ExpectEquals(topologyEvents[2].Type, TopologyInstanceAddedEvent)
+	test.S(t).ExpectEquals(topologyEvents[2].Hostname, i810Key.Hostname)
+	test.S(t).ExpectEquals(topologyEvents[3].Type, TopologyInstanceAddedEvent)
+	test.S(t).ExpectEquals(topologyEvents[3].Hostname, i820Key.Hostname)
+}
+
+func TestComputeTopologyChangeEventsNoChange(t *testing.T) {
+	instances := map[InstanceKey]topologyChangesInstance{
+		i710Key: {MasterKey: i720Key, ClusterName: "c1", Valid: true},
+		i720Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true},
+		i730Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true},
+	}
+	test.S(t).ExpectEquals(len(computeTopologyChangeEvents(instances, instances)), 0)
+}
+
+func TestComputeTopologyChangeEventsFocusedReplication(t *testing.T) {
+	before := map[InstanceKey]topologyChangesInstance{
+		i710Key: {ClusterName: "c1", Valid: true, Focused: true},
+		i720Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true, Focused: true, IOThreadRunning: true, SQLThreadRunning: true},
+		i810Key: {MasterKey: i810Key, ClusterName: "c2", Valid: true, IOThreadRunning: true, SQLThreadRunning: true},
+	}
+	after := map[InstanceKey]topologyChangesInstance{
+		i710Key: {ClusterName: "c1", Valid: true, Focused: true},
+		i720Key: {MasterKey: i710Key, ClusterName: "c1", Valid: true, Focused: true, IOThreadRunning: false, SQLThreadRunning: true},
+		i810Key: {MasterKey: i810Key, ClusterName: "c2", Valid: true, IOThreadRunning: false, SQLThreadRunning: true},
+	}
+	topologyEvents := computeTopologyChangeEvents(before, after)
+	// only the cluster in focus mode has its replication changes published
+	test.S(t).ExpectEquals(len(topologyEvents), 1)
+	test.S(t).ExpectEquals(topologyEvents[0].Type, TopologyReplicationChangedEvent)
+	test.S(t).ExpectEquals(topologyEvents[0].Hostname, i720Key.Hostname)
+	test.S(t).ExpectEquals(topologyEvents[0].ClusterName, "c1")
+}
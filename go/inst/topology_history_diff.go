@@ -0,0 +1,227 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+const topologyHistoryTimeLayout = "2006-01-02 15:04:05"
+
+// TopologyHistoryInstance is an instance, along with its master, as seen in one side of a topology history diff
+type TopologyHistoryInstance struct {
+	Key       InstanceKey
+	MasterKey InstanceKey
+}
+
+// TopologyHistoryDiff is the difference in a cluster's topology between two points in time. Since is the
+// topology snapshot taken at or before the requested "since" time; Until is likewise the snapshot for the
+// "until" time, or zero when comparing with the current topology.
+type TopologyHistoryDiff struct {
+	ClusterName    string
+	Since          time.Time
+	Until          time.Time
+	SinceMasters   []InstanceKey
+	UntilMasters   []InstanceKey
+	MasterChanged  bool
+	MovedInstances []TopologyEdgeChange
+	LostInstances  []TopologyHistoryInstance
+	NewInstances   []TopologyHistoryInstance
+}
+
+// IsEmpty returns true when the topology has not changed
+func (this *TopologyHistoryDiff) IsEmpty() bool {
+	return !this.MasterChanged && len(this.MovedInstances) == 0 && len(this.LostInstances) == 0 && len(this.NewInstances) == 0
+}
+
+// String returns a line per change
+func (this *TopologyHistoryDiff) String() string {
+	until := "now"
+	if !this.Until.IsZero() {
+		until = this.Until.Format(topologyHistoryTimeLayout)
+	}
+	lines := []string{fmt.Sprintf("%s: %s to %s", this.ClusterName, this.Since.Format(topologyHistoryTimeLayout), until)}
+	if this.MasterChanged {
+		lines = append(lines, fmt.Sprintf("master changed: %s -> %s", displayInstanceKeys(this.SinceMasters), displayInstanceKeys(this.UntilMasters)))
+	}
+	for _, change := range this.MovedInstances {
+		lines = append(lines, fmt.Sprintf("moved: %s from %s to %s", change.Key.DisplayString(), change.FromMasterKey.DisplayString(), change.ToMasterKey.DisplayString()))
+	}
+	for _, instance := range this.LostInstances {
+		lines = append(lines, fmt.Sprintf("lost: %s (replicated from %s)", instance.Key.DisplayString(), instance.MasterKey.DisplayString()))
+	}
+	for _, instance := range this.NewInstances {
+		lines = append(lines, fmt.Sprintf("new: %s (replicates from %s)", instance.Key.DisplayString(), instance.MasterKey.DisplayString()))
+	}
+	if this.IsEmpty() {
+		lines = append(lines, "no changes")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// topologyRootKeys returns the masters (or co-masters) of given instance-to-master mapping
+func topologyRootKeys(masters map[InstanceKey]InstanceKey) (rootKeys []InstanceKey) {
+	for _, root := range buildTopologyDiffTree(masters, nil) {
+		rootKeys = append(rootKeys, root.Key)
+		// co-masters: the root's master replicates from the root
+		if coMasterKey, found := masters[root.MasterKey]; found && coMasterKey.Equals(&root.Key) {
+			rootKeys = append(rootKeys, root.MasterKey)
+		}
+	}
+	sort.Slice(rootKeys, func(i, j int) bool { return rootKeys[i].SmallerThan(&rootKeys[j]) })
+	return rootKeys
+}
+
+// computeTopologyHistoryDiff compares two instance-to-master mappings of a cluster
+func computeTopologyHistoryDiff(sinceMasters, untilMasters map[InstanceKey]InstanceKey) *TopologyHistoryDiff {
+	diff := &TopologyHistoryDiff{
+		SinceMasters:   topologyRootKeys(sinceMasters),
+		UntilMasters:   topologyRootKeys(untilMasters),
+		MovedInstances: []TopologyEdgeChange{},
+		LostInstances:  []TopologyHistoryInstance{},
+		NewInstances:   []TopologyHistoryInstance{},
+	}
+	if len(diff.SinceMasters) != len(diff.UntilMasters) {
+		diff.MasterChanged = true
+	} else {
+		for i := range diff.SinceMasters {
+			if !diff.SinceMasters[i].Equals(&diff.UntilMasters[i]) {
+				diff.MasterChanged = true
+			}
+		}
+	}
+	for instanceKey, sinceMasterKey := range sinceMasters {
+		untilMasterKey, found := untilMasters[instanceKey]
+		if !found {
+			diff.LostInstances = append(diff.LostInstances, TopologyHistoryInstance{Key: instanceKey, MasterKey: sinceMasterKey})
+		} else if !untilMasterKey.Equals(&sinceMasterKey) {
+			diff.MovedInstances = append(diff.MovedInstances, TopologyEdgeChange{Key: instanceKey, FromMasterKey: sinceMasterKey, ToMasterKey: untilMasterKey})
+		}
+	}
+	for instanceKey, untilMasterKey := range untilMasters {
+		if _, found := sinceMasters[instanceKey]; !found {
+			diff.NewInstances = append(diff.NewInstances, TopologyHistoryInstance{Key: instanceKey, MasterKey: untilMasterKey})
+		}
+	}
+	sort.Slice(diff.MovedInstances, func(i, j int) bool {
+		return diff.MovedInstances[i].Key.SmallerThan(&diff.MovedInstances[j].Key)
+	})
+	sort.Slice(diff.LostInstances, func(i, j int) bool {
+		return diff.LostInstances[i].Key.SmallerThan(&diff.LostInstances[j].Key)
+	})
+	sort.Slice(diff.NewInstances, func(i, j int) bool {
+		return diff.NewInstances[i].Key.SmallerThan(&diff.NewInstances[j].Key)
+	})
+	return diff
+}
+
+// ParseTopologyHistoryTime parses a point in time given as a unix timestamp, or as "YYYY-MM-DD hh:mm:ss" or
+// "YYYY-MM-DD" in local time. Empty or "now" return a zero time, standing for the current topology.
+func ParseTopologyHistoryTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "now" {
+		return time.Time{}, nil
+	}
+	if unixTimestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unixTimestamp, 0), nil
+	}
+	for _, layout := range []string{topologyHistoryTimeLayout, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Cannot parse time: %s. Expected unix timestamp, 'YYYY-MM-DD hh:mm:ss', 'YYYY-MM-DD' or 'now'", value)
+}
+
+// readTopologySnapshotTimestamp returns the time of the latest topology snapshot of given cluster taken at or before given time
+func readTopologySnapshotTimestamp(clusterName string, at time.Time) (snapshotTime time.Time, err error) {
+	query := `
+		select
+			ifnull(max(snapshot_unix_timestamp), 0) as snapshot_unix_timestamp
+		from
+			database_instance_topology_history
+		where
+			snapshot_unix_timestamp <= ?
+			and cluster_name = ?`
+	var snapshotUnixTimestamp int64
+	err = db.QueryOrchestrator(query, sqlutils.Args(at.Unix(), clusterName), func(m sqlutils.RowMap) error {
+		snapshotUnixTimestamp = m.GetInt64("snapshot_unix_timestamp")
+		return nil
+	})
+	if err != nil {
+		return snapshotTime, log.Errore(err)
+	}
+	if snapshotUnixTimestamp == 0 {
+		return snapshotTime, fmt.Errorf("No topology snapshot of %s at or before %s", clusterName, at.Format(topologyHistoryTimeLayout))
+	}
+	return time.Unix(snapshotUnixTimestamp, 0), nil
+}
+
+// readClusterMastersAt returns the instance-to-master mapping of given cluster, as of the latest snapshot at or before
+// given time, or as of now for a zero time. It returns the time of the snapshot used.
+func readClusterMastersAt(clusterName string, at time.Time) (masters map[InstanceKey]InstanceKey, snapshotTime time.Time, err error) {
+	var instances [](*Instance)
+	if at.IsZero() {
+		instances, err = ReadClusterInstances(clusterName)
+	} else {
+		if snapshotTime, err = readTopologySnapshotTimestamp(clusterName, at); err != nil {
+			return masters, snapshotTime, err
+		}
+		instances, err = ReadHistoryClusterInstances(clusterName, fmt.Sprintf("^%d$", snapshotTime.Unix()))
+	}
+	if err != nil {
+		return masters, snapshotTime, err
+	}
+	masters = make(map[InstanceKey]InstanceKey)
+	for _, instance := range instances {
+		masters[instance.Key] = instance.MasterKey
+	}
+	return masters, snapshotTime, nil
+}
+
+// TopologyHistoryDiffOf compares the topology of given cluster at two points in time. A zero until compares
+// with the current topology. Snapshots are recorded every SnapshotTopologiesIntervalHours, and the latest
+// snapshot at or before each given time is used.
+func TopologyHistoryDiffOf(clusterName string, since time.Time, until time.Time) (*TopologyHistoryDiff, error) {
+	if since.IsZero() {
+		return nil, fmt.Errorf("TopologyHistoryDiffOf: since must be given")
+	}
+	if !until.IsZero() && until.Before(since) {
+		return nil, fmt.Errorf("TopologyHistoryDiffOf: until (%s) is before since (%s)", until.Format(topologyHistoryTimeLayout), since.Format(topologyHistoryTimeLayout))
+	}
+	sinceMasters, sinceSnapshotTime, err := readClusterMastersAt(clusterName, since)
+	if err != nil {
+		return nil, err
+	}
+	untilMasters, untilSnapshotTime, err := readClusterMastersAt(clusterName, until)
+	if err != nil {
+		return nil, err
+	}
+	diff := computeTopologyHistoryDiff(sinceMasters, untilMasters)
+	diff.ClusterName = clusterName
+	diff.Since = sinceSnapshotTime
+	diff.Until = untilSnapshotTime
+	return diff, nil
+}
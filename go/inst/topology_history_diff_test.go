@@ -0,0 +1,75 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestComputeTopologyHistoryDiff(t *testing.T) {
+	sinceMasters := map[InstanceKey]InstanceKey{
+		i710Key: {},
+		i720Key: i710Key,
+		i730Key: i710Key,
+		i810Key: i720Key,
+		i820Key: i720Key,
+	}
+	untilMasters := map[InstanceKey]InstanceKey{
+		i720Key: {},
+		i730Key: i720Key,
+		i810Key: i720Key,
+		i830Key: i730Key,
+	}
+	diff := computeTopologyHistoryDiff(sinceMasters, untilMasters)
+
+	test.S(t).ExpectTrue(diff.MasterChanged)
+	test.S(t).ExpectEquals(diff.SinceMasters[0], i710Key)
+	test.S(t).ExpectEquals(diff.UntilMasters[0], i720Key)
+
+	test.S(t).ExpectEquals(len(diff.MovedInstances), 2)
+	test.S(t).ExpectEquals(diff.MovedInstances[0].Key, i720Key)
+	test.S(t).ExpectEquals(diff.MovedInstances[1], TopologyEdgeChange{Key: i730Key, FromMasterKey: i710Key, ToMasterKey: i720Key})
+
+	test.S(t).ExpectEquals(len(diff.LostInstances), 2)
+	test.S(t).ExpectEquals(diff.LostInstances[0], TopologyHistoryInstance{Key: i710Key})
+	test.S(t).ExpectEquals(diff.LostInstances[1], TopologyHistoryInstance{Key: i820Key, MasterKey: i720Key})
+
+	test.S(t).ExpectEquals(len(diff.NewInstances), 1)
+	test.S(t).ExpectEquals(diff.NewInstances[0], TopologyHistoryInstance{Key: i830Key, MasterKey: i730Key})
+	test.S(t).ExpectFalse(diff.IsEmpty())
+}
+
+func TestComputeTopologyHistoryDiffCoMasters(t *testing.T) {
+	masters := map[InstanceKey]InstanceKey{
+		i710Key: i720Key,
+		i720Key: i710Key,
+		i730Key: i720Key,
+	}
+	diff := computeTopologyHistoryDiff(masters, masters)
+	test.S(t).ExpectEquals(len(diff.SinceMasters), 2)
+	test.S(t).ExpectFalse(diff.MasterChanged)
+	test.S(t).ExpectTrue(diff.IsEmpty())
+}
+
+func TestParseTopologyHistoryTime(t *testing.T) {
+	{
+		parsed, err := ParseTopologyHistoryTime("now")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(parsed.IsZero())
+	}
+	{
+		parsed, err := ParseTopologyHistoryTime("1600000000")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(parsed.Unix(), int64(1600000000))
+	}
+	{
+		parsed, err := ParseTopologyHistoryTime("2020-09-13 12:26:40")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(parsed, time.Date(2020, 9, 13, 12, 26, 40, 0, time.Local))
+	}
+	{
+		_, err := ParseTopologyHistoryTime("yesterday")
+		test.S(t).ExpectNotNil(err)
+	}
+}
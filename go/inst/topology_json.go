@@ -0,0 +1,124 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+// TopologyNode is an instance in a structured topology tree, along with its replicas
+type TopologyNode struct {
+	Key                   InstanceKey
+	MasterKey             InstanceKey
+	Version               string
+	ReadOnly              bool
+	IsCoMaster            bool
+	ReplicaRunning        bool
+	IsLastCheckValid      bool
+	IsRecentlyChecked     bool
+	IsDowntimed           bool
+	DowntimeReason        string
+	DowntimeEndTimestamp  string
+	SelfBinlogCoordinates BinlogCoordinates
+	ReadBinlogCoordinates BinlogCoordinates
+	ExecBinlogCoordinates BinlogCoordinates
+	ReplicationLagSeconds *int64
+	LagBucket             ReplicationLagBucket
+	SQLDelay              uint
+	Problems              []string
+	Children              [](*TopologyNode)
+}
+
+func newTopologyNode(instance *Instance) *TopologyNode {
+	node := &TopologyNode{
+		Key:                   instance.Key,
+		MasterKey:             instance.MasterKey,
+		Version:               instance.Version,
+		ReadOnly:              instance.ReadOnly,
+		IsCoMaster:            instance.IsCoMaster,
+		ReplicaRunning:        instance.ReplicaRunning(),
+		IsLastCheckValid:      instance.IsLastCheckValid,
+		IsRecentlyChecked:     instance.IsRecentlyChecked,
+		IsDowntimed:           instance.IsDowntimed,
+		DowntimeReason:        instance.DowntimeReason,
+		DowntimeEndTimestamp:  instance.DowntimeEndTimestamp,
+		SelfBinlogCoordinates: instance.SelfBinlogCoordinates,
+		ReadBinlogCoordinates: instance.ReadBinlogCoordinates,
+		ExecBinlogCoordinates: instance.ExecBinlogCoordinates,
+		LagBucket:             instance.replicationLagBucket(),
+		SQLDelay:              instance.SQLDelay,
+		Problems:              instance.Problems,
+		Children:              [](*TopologyNode){},
+	}
+	if instance.SlaveLagSeconds.Valid {
+		lag := instance.SlaveLagSeconds.Int64
+		node.ReplicationLagSeconds = &lag
+	}
+	if node.Problems == nil {
+		node.Problems = []string{}
+	}
+	return node
+}
+
+// getTopologyNode builds the topology tree rooted at given instance. As with the ascii topology, co-masters
+// are only presented as roots.
+func getTopologyNode(depth int, instance *Instance, replicationMap map[*Instance]([]*Instance)) *TopologyNode {
+	node := newTopologyNode(instance)
+	for _, replica := range replicationMap[instance] {
+		if replica.IsCoMaster && depth > 0 {
+			continue
+		}
+		node.Children = append(node.Children, getTopologyNode(depth+1, replica, replicationMap))
+	}
+	return node
+}
+
+// TopologyJSON returns the topology of given cluster as a tree, suitable for JSON serialization. There is
+// a single root for a single master, or one root per co-master.
+func TopologyJSON(clusterName string) (roots [](*TopologyNode), err error) {
+	instances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return roots, err
+	}
+	replicationMap, masterInstance := getTopologyReplicationMap(instances)
+	roots = [](*TopologyNode){}
+	if masterInstance != nil {
+		roots = append(roots, getTopologyNode(0, masterInstance, replicationMap))
+	} else {
+		for _, instance := range instances {
+			if instance.IsCoMaster {
+				roots = append(roots, getTopologyNode(0, instance, replicationMap))
+			}
+		}
+	}
+	return roots, nil
+}
+
+// ClusterTree is a cluster's topology as a single tree: its children are the master, or co-masters, of the
+// cluster. It is suitable for hierarchical rendering (e.g. d3.hierarchy(tree, d => d.Children)) of the
+// entire cluster, with lag, validity and downtime status of each node, in a single request.
+type ClusterTree struct {
+	ClusterName  string
+	ClusterAlias string
+	Children     [](*TopologyNode)
+}
+
+// ReadClusterTree returns the topology of given cluster as a single rooted tree
+func ReadClusterTree(clusterName string) (*ClusterTree, error) {
+	roots, err := TopologyJSON(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	clusterAlias, _ := ReadAliasByClusterName(clusterName)
+	return &ClusterTree{ClusterName: clusterName, ClusterAlias: clusterAlias, Children: roots}, nil
+}
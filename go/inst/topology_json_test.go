@@ -0,0 +1,37 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestGetTopologyNode(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.MasterKey = i710Key
+	}
+	instancesMap[i710Key.StringCode()].MasterKey = InstanceKey{}
+	instancesMap[i830Key.StringCode()].MasterKey = i820Key
+	instancesMap[i830Key.StringCode()].Problems = []string{"replication_lag"}
+
+	replicationMap, masterInstance := getTopologyReplicationMap(instances)
+	test.S(t).ExpectEquals(masterInstance.Key, i710Key)
+
+	root := getTopologyNode(0, masterInstance, replicationMap)
+	test.S(t).ExpectEquals(root.Key, i710Key)
+	test.S(t).ExpectEquals(len(root.Children), 4)
+	test.S(t).ExpectEquals(len(root.Problems), 0)
+	test.S(t).ExpectTrue(root.ReplicationLagSeconds == nil)
+
+	var i820Node *TopologyNode
+	for _, child := range root.Children {
+		if child.Key.Equals(&i820Key) {
+			i820Node = child
+		}
+	}
+	test.S(t).ExpectNotNil(i820Node)
+	test.S(t).ExpectEquals(len(i820Node.Children), 1)
+	test.S(t).ExpectEquals(i820Node.Children[0].Key, i830Key)
+	test.S(t).ExpectEquals(i820Node.Children[0].Problems[0], "replication_lag")
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mermaidNodeClasses are the class definitions applied to topology nodes, by replication status
+var mermaidNodeClasses = []string{
+	"classDef unreachable fill:#bbbbbb,stroke:#666666,color:#333333",
+	"classDef broken fill:#f8d7da,stroke:#d9534f",
+	"classDef problem fill:#fff3cd,stroke:#f0ad4e",
+}
+
+// mermaidEscape escapes characters which would otherwise break a quoted mermaid label
+func mermaidEscape(text string) string {
+	text = strings.Replace(text, `"`, "#quot;", -1)
+	text = strings.Replace(text, "<", "#lt;", -1)
+	text = strings.Replace(text, ">", "#gt;", -1)
+	return text
+}
+
+// mermaidNodeStatus returns the annotations of a node, as well as its status class (empty when healthy)
+func mermaidNodeStatus(node *TopologyNode, isRoot bool) (annotations []string, class string) {
+	if node.Version != "" {
+		annotations = append(annotations, node.Version)
+	}
+	if node.ReadOnly {
+		annotations = append(annotations, "read_only")
+	}
+	if node.IsCoMaster {
+		annotations = append(annotations, "co-master")
+	}
+	if !isRoot || node.IsCoMaster {
+		if !node.ReplicaRunning {
+			annotations = append(annotations, "replication stopped")
+			class = "broken"
+		} else if node.ReplicationLagSeconds != nil {
+			annotations = append(annotations, fmt.Sprintf("lag: %ds", *node.ReplicationLagSeconds))
+		}
+	}
+	if node.SQLDelay > 0 {
+		annotations = append(annotations, fmt.Sprintf("delay: %ds", node.SQLDelay))
+	}
+	if len(node.Problems) > 0 {
+		annotations = append(annotations, strings.Join(node.Problems, ", "))
+		if class == "" {
+			class = "problem"
+		}
+	}
+	if !node.IsLastCheckValid {
+		annotations = append(annotations, "last check invalid")
+		class = "unreachable"
+	}
+	return annotations, class
+}
+
+// mermaidFlowchart renders given topology trees in mermaid flowchart syntax. Each instance is a node,
+// annotated with its replication status; each replication edge points from master to replica.
+func mermaidFlowchart(roots [](*TopologyNode)) string {
+	lines := []string{"flowchart TD"}
+	classes := []string{}
+	nodeIds := make(map[InstanceKey]string)
+
+	var renderNode func(node *TopologyNode, isRoot bool)
+	renderNode = func(node *TopologyNode, isRoot bool) {
+		nodeId := fmt.Sprintf("n%d", len(nodeIds))
+		nodeIds[node.Key] = nodeId
+
+		annotations, class := mermaidNodeStatus(node, isRoot)
+		label := mermaidEscape(node.Key.DisplayString())
+		if len(annotations) > 0 {
+			label = fmt.Sprintf("%s<br/>%s", label, mermaidEscape(strings.Join(annotations, " | ")))
+		}
+		lines = append(lines, fmt.Sprintf(`    %s["%s"]`, nodeId, label))
+		if class != "" {
+			classes = append(classes, fmt.Sprintf("    class %s %s", nodeId, class))
+		}
+		for _, child := range node.Children {
+			renderNode(child, false)
+			lines = append(lines, fmt.Sprintf("    %s --> %s", nodeId, nodeIds[child.Key]))
+		}
+	}
+	for _, root := range roots {
+		renderNode(root, true)
+	}
+	// co-masters replicate from one another
+	for i, root := range roots {
+		for _, other := range roots[i+1:] {
+			if root.IsCoMaster && other.IsCoMaster && root.MasterKey.Equals(&other.Key) && other.MasterKey.Equals(&root.Key) {
+				lines = append(lines, fmt.Sprintf("    %s <--> %s", nodeIds[root.Key], nodeIds[other.Key]))
+			}
+		}
+	}
+	for _, classDef := range mermaidNodeClasses {
+		lines = append(lines, fmt.Sprintf("    %s", classDef))
+	}
+	lines = append(lines, classes...)
+	return strings.Join(lines, "\n")
+}
+
+// TopologyMermaid returns the topology of given cluster as a mermaid flowchart, suitable for embedding
+// in markdown documents
+func TopologyMermaid(clusterName string) (string, error) {
+	roots, err := TopologyJSON(clusterName)
+	if err != nil {
+		return "", err
+	}
+	return mermaidFlowchart(roots), nil
+}
@@ -0,0 +1,37 @@
+package inst
+
+import (
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestMermaidFlowchart(t *testing.T) {
+	lag := int64(7)
+	replica := &TopologyNode{Key: i720Key, MasterKey: i710Key, Version: "5.7.30", ReadOnly: true, ReplicaRunning: true, IsLastCheckValid: true, ReplicationLagSeconds: &lag}
+	brokenReplica := &TopologyNode{Key: i730Key, MasterKey: i710Key, IsLastCheckValid: true, Problems: []string{"not_replicating"}}
+	root := &TopologyNode{Key: i710Key, Version: "5.7.30", IsLastCheckValid: true, Children: [](*TopologyNode){replica, brokenReplica}}
+
+	lines := strings.Split(mermaidFlowchart([](*TopologyNode){root}), "\n")
+	test.S(t).ExpectEquals(lines[0], "flowchart TD")
+	test.S(t).ExpectEquals(lines[1], `    n0["i710:3306<br/>5.7.30"]`)
+	test.S(t).ExpectEquals(lines[2], `    n1["i720:3306<br/>5.7.30 | read_only | lag: 7s"]`)
+	test.S(t).ExpectEquals(lines[3], "    n0 --> n1")
+	test.S(t).ExpectEquals(lines[4], `    n2["i730:3306<br/>replication stopped | not_replicating"]`)
+	test.S(t).ExpectEquals(lines[5], "    n0 --> n2")
+	test.S(t).ExpectEquals(lines[len(lines)-1], "    class n2 broken")
+}
+
+func TestMermaidFlowchartCoMasters(t *testing.T) {
+	coMaster1 := &TopologyNode{Key: i710Key, MasterKey: i720Key, IsCoMaster: true, ReplicaRunning: true, IsLastCheckValid: true}
+	coMaster2 := &TopologyNode{Key: i720Key, MasterKey: i710Key, IsCoMaster: true, ReplicaRunning: true, IsLastCheckValid: false}
+
+	flowchart := mermaidFlowchart([](*TopologyNode){coMaster1, coMaster2})
+	test.S(t).ExpectTrue(strings.Contains(flowchart, "    n0 <--> n1"))
+	test.S(t).ExpectTrue(strings.Contains(flowchart, "    class n1 unreachable"))
+}
+
+func TestMermaidEscape(t *testing.T) {
+	test.S(t).ExpectEquals(mermaidEscape(`say "hi" <now>`), "say #quot;hi#quot; #lt;now#gt;")
+}
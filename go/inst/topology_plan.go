@@ -0,0 +1,287 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/github/orchestrator/go/events"
+
+	"github.com/openark/golib/log"
+)
+
+// DesiredTopology maps instances onto their intended masters
+type DesiredTopology map[InstanceKey]InstanceKey
+
+type TopologyPlanStepState string
+
+const (
+	TopologyPlanStepPending   TopologyPlanStepState = "pending"
+	TopologyPlanStepCompleted                       = "completed"
+	TopologyPlanStepFailed                          = "failed"
+	TopologyPlanStepSkipped                         = "skipped"
+)
+
+// TopologyPlanStep relocates a single instance below its intended master
+type TopologyPlanStep struct {
+	Key           InstanceKey
+	FromMasterKey InstanceKey
+	ToMasterKey   InstanceKey
+	State         TopologyPlanStepState
+	Error         string
+}
+
+// TopologyPlan is the sequence of relocations which converges the current topology onto a desired topology.
+// Instances already replicating from their intended masters are listed as unchanged, and are not touched.
+type TopologyPlan struct {
+	Steps        []*TopologyPlanStep
+	Unchanged    []InstanceKey
+	TopologyDiff *TopologyDiff
+}
+
+// String returns a human readable description of the plan, one line per step
+func (this *TopologyPlan) String() string {
+	lines := []string{}
+	for i, step := range this.Steps {
+		line := fmt.Sprintf("%d: relocate %s from %s below %s", i+1, step.Key.DisplayString(), step.FromMasterKey.DisplayString(), step.ToMasterKey.DisplayString())
+		if step.State != TopologyPlanStepPending {
+			line = fmt.Sprintf("%s: %s", line, step.State)
+		}
+		if step.Error != "" {
+			line = fmt.Sprintf("%s: %s", line, step.Error)
+		}
+		lines = append(lines, line)
+	}
+	if len(this.Unchanged) > 0 {
+		lines = append(lines, fmt.Sprintf("unchanged: %s", displayInstanceKeys(this.Unchanged)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IsSuccessful returns true when all steps of the plan have completed
+func (this *TopologyPlan) IsSuccessful() bool {
+	for _, step := range this.Steps {
+		if step.State != TopologyPlanStepCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDesiredTopologyEntries parses a desired topology, given as a JSON object of instance to master,
+// or as a flat YAML mapping, one `instance: master` entry per line
+func parseDesiredTopologyEntries(content []byte) (entries map[string]string, err error) {
+	entries = make(map[string]string)
+	content = bytes.TrimSpace(content)
+	if bytes.HasPrefix(content, []byte("{")) {
+		err = json.Unmarshal(content, &entries)
+		return entries, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line == "---" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// instance keys contain colons; YAML keys are separated from values by a colon and a space
+		tokens := strings.SplitN(line, ": ", 2)
+		if len(tokens) != 2 {
+			return entries, fmt.Errorf("Cannot parse desired topology line: %s", line)
+		}
+		instance := strings.Trim(strings.TrimSpace(tokens[0]), `"'`)
+		master := strings.Trim(strings.TrimSpace(tokens[1]), `"'`)
+		if _, found := entries[instance]; found {
+			return entries, fmt.Errorf("Instance listed more than once in desired topology: %s", instance)
+		}
+		entries[instance] = master
+	}
+	return entries, scanner.Err()
+}
+
+// ReadDesiredTopology reads and resolves a desired topology; see parseDesiredTopologyEntries for format
+func ReadDesiredTopology(r io.Reader) (DesiredTopology, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseDesiredTopologyEntries(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("Desired topology is empty")
+	}
+	desired := make(DesiredTopology)
+	for instance, master := range entries {
+		instanceKey, err := ParseResolveInstanceKey(instance)
+		if err != nil {
+			return nil, err
+		}
+		masterKey, err := ParseResolveInstanceKey(master)
+		if err != nil {
+			return nil, err
+		}
+		desired[*instanceKey] = *masterKey
+	}
+	return desired, nil
+}
+
+// desiredDepth returns the depth of given instance within the desired topology, where instances whose
+// intended master is not itself in the desired topology are at depth 0
+func (this DesiredTopology) desiredDepth(instanceKey InstanceKey) (depth int, err error) {
+	visited := map[InstanceKey]bool{instanceKey: true}
+	for {
+		masterKey, found := this[instanceKey]
+		if !found {
+			return depth - 1, nil
+		}
+		if visited[masterKey] {
+			return depth, fmt.Errorf("Desired topology has a replication cycle through %s", masterKey.DisplayString())
+		}
+		visited[masterKey] = true
+		instanceKey = masterKey
+		depth++
+	}
+}
+
+// replicatesFrom returns true when, given current masters, instanceKey replicates directly or indirectly from ancestorKey
+func replicatesFrom(masters map[InstanceKey]InstanceKey, instanceKey InstanceKey, ancestorKey InstanceKey) bool {
+	visited := map[InstanceKey]bool{}
+	for !visited[instanceKey] {
+		visited[instanceKey] = true
+		masterKey, found := masters[instanceKey]
+		if !found {
+			return false
+		}
+		if masterKey.Equals(&ancestorKey) {
+			return true
+		}
+		instanceKey = masterKey
+	}
+	return false
+}
+
+// computeTopologyPlan computes the minimal sequence of relocations which converge given current masters
+// onto the desired topology. Instances are relocated top-down, such that an instance is only relocated
+// once its intended master is in place. The plan is validated against a simulation of the topology as
+// each step completes, so that no step would make an instance replicate from its own replica.
+func computeTopologyPlan(desired DesiredTopology, currentMasters map[InstanceKey]InstanceKey) (*TopologyPlan, error) {
+	plan := &TopologyPlan{Steps: []*TopologyPlanStep{}, Unchanged: []InstanceKey{}}
+	depths := make(map[InstanceKey]int)
+	keys := []InstanceKey{}
+	for instanceKey, masterKey := range desired {
+		if instanceKey.Equals(&masterKey) {
+			return nil, fmt.Errorf("Instance cannot replicate from itself: %s", instanceKey.DisplayString())
+		}
+		if _, found := currentMasters[instanceKey]; !found {
+			return nil, fmt.Errorf("Unknown instance: %s", instanceKey.DisplayString())
+		}
+		depth, err := desired.desiredDepth(instanceKey)
+		if err != nil {
+			return nil, err
+		}
+		depths[instanceKey] = depth
+		keys = append(keys, instanceKey)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if depths[keys[i]] != depths[keys[j]] {
+			return depths[keys[i]] < depths[keys[j]]
+		}
+		return keys[i].SmallerThan(&keys[j])
+	})
+
+	masters := make(map[InstanceKey]InstanceKey)
+	for instanceKey, masterKey := range currentMasters {
+		masters[instanceKey] = masterKey
+	}
+	for _, instanceKey := range keys {
+		masterKey := desired[instanceKey]
+		currentMasterKey := masters[instanceKey]
+		if currentMasterKey.Equals(&masterKey) {
+			plan.Unchanged = append(plan.Unchanged, instanceKey)
+			continue
+		}
+		if replicatesFrom(masters, masterKey, instanceKey) {
+			return nil, fmt.Errorf("Cannot relocate %s below %s, which would then replicate from %s", instanceKey.DisplayString(), masterKey.DisplayString(), instanceKey.DisplayString())
+		}
+		plan.Steps = append(plan.Steps, &TopologyPlanStep{
+			Key:           instanceKey,
+			FromMasterKey: currentMasterKey,
+			ToMasterKey:   masterKey,
+			State:         TopologyPlanStepPending,
+		})
+		masters[instanceKey] = masterKey
+	}
+	return plan, nil
+}
+
+// PlanTopology computes the plan converging the current topology onto given desired topology. Current
+// state is read from the backend database, covering the clusters of all instances and masters involved.
+func PlanTopology(desired DesiredTopology) (*TopologyPlan, error) {
+	instanceKeys := []InstanceKey{}
+	for instanceKey, masterKey := range desired {
+		instanceKeys = append(instanceKeys, instanceKey, masterKey)
+	}
+	currentMasters, err := readClustersMasters(instanceKeys...)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := computeTopologyPlan(desired, currentMasters)
+	if err != nil {
+		return nil, err
+	}
+	changes := []TopologyEdgeChange{}
+	for _, step := range plan.Steps {
+		changes = append(changes, TopologyEdgeChange{Key: step.Key, FromMasterKey: step.FromMasterKey, ToMasterKey: step.ToMasterKey})
+	}
+	plan.TopologyDiff = computeTopologyDiff(currentMasters, changes)
+	return plan, nil
+}
+
+// ApplyTopologyPlan executes the steps of given plan, in order, via RelocateBelow. Each step builds on the
+// previous ones, hence a failed step halts the plan, and remaining steps are skipped.
+func ApplyTopologyPlan(plan *TopologyPlan, allowWAN bool) (*TopologyPlan, error) {
+	var failedStep *TopologyPlanStep
+	for i, step := range plan.Steps {
+		if failedStep != nil {
+			step.State = TopologyPlanStepSkipped
+			continue
+		}
+		events.PublishProgress("topology-apply", "", fmt.Sprintf("step %d/%d: relocating %s below %s", i+1, len(plan.Steps), step.Key.DisplayString(), step.ToMasterKey.DisplayString()))
+		if _, err := RelocateBelow(&step.Key, &step.ToMasterKey, allowWAN); err != nil {
+			step.State = TopologyPlanStepFailed
+			step.Error = err.Error()
+			failedStep = step
+			log.Errorf("ApplyTopologyPlan: step %d/%d failed: %+v", i+1, len(plan.Steps), err)
+			continue
+		}
+		step.State = TopologyPlanStepCompleted
+	}
+	if failedStep != nil {
+		AuditOperation("topology-apply", &failedStep.Key, fmt.Sprintf("failed relocating below %s; %d steps in plan", failedStep.ToMasterKey.DisplayString(), len(plan.Steps)))
+		return plan, fmt.Errorf("Topology plan halted: failed relocating %s below %s: %s", failedStep.Key.DisplayString(), failedStep.ToMasterKey.DisplayString(), failedStep.Error)
+	}
+	AuditOperation("topology-apply", nil, fmt.Sprintf("applied %d steps, %d instances unchanged", len(plan.Steps), len(plan.Unchanged)))
+	return plan, nil
+}
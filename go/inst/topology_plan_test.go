@@ -0,0 +1,75 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseDesiredTopologyEntries(t *testing.T) {
+	{
+		entries, err := parseDesiredTopologyEntries([]byte(`{"i720:3306": "i710:3306", "i730:3306": "i720:3306"}`))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(entries), 2)
+		test.S(t).ExpectEquals(entries["i730:3306"], "i720:3306")
+	}
+	{
+		entries, err := parseDesiredTopologyEntries([]byte(`
+---
+# intermediate master
+i720:3306: i710:3306
+"i730:3306": 'i720:3306'
+`))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(entries), 2)
+		test.S(t).ExpectEquals(entries["i720:3306"], "i710:3306")
+		test.S(t).ExpectEquals(entries["i730:3306"], "i720:3306")
+	}
+	{
+		_, err := parseDesiredTopologyEntries([]byte("i720:3306 i710:3306"))
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := parseDesiredTopologyEntries([]byte("i720:3306: i710:3306\ni720:3306: i730:3306"))
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestComputeTopologyPlan(t *testing.T) {
+	// i710 is the master of i720, i730; i730 is the master of i820
+	currentMasters := map[InstanceKey]InstanceKey{
+		i710Key: {},
+		i720Key: i710Key,
+		i730Key: i710Key,
+		i820Key: i730Key,
+	}
+	{
+		// swap i730 and i820: i820 is to be relocated first, and i730 then below it
+		desired := DesiredTopology{i730Key: i820Key, i820Key: i710Key, i720Key: i710Key}
+		plan, err := computeTopologyPlan(desired, currentMasters)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(plan.Steps), 2)
+		test.S(t).ExpectEquals(plan.Steps[0].Key, i820Key)
+		test.S(t).ExpectEquals(plan.Steps[0].FromMasterKey, i730Key)
+		test.S(t).ExpectEquals(plan.Steps[1].Key, i730Key)
+		test.S(t).ExpectEquals(plan.Steps[1].ToMasterKey, i820Key)
+		test.S(t).ExpectEquals(len(plan.Unchanged), 1)
+		test.S(t).ExpectEquals(plan.Unchanged[0], i720Key)
+	}
+	{
+		// i730 would replicate from its own replica, i820, which is not listed and so keeps its master
+		desired := DesiredTopology{i730Key: i820Key}
+		_, err := computeTopologyPlan(desired, currentMasters)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		desired := DesiredTopology{i720Key: i730Key, i730Key: i720Key}
+		_, err := computeTopologyPlan(desired, currentMasters)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		desired := DesiredTopology{i830Key: i710Key}
+		_, err := computeTopologyPlan(desired, currentMasters)
+		test.S(t).ExpectNotNil(err)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// ReleaseSeries is a MySQL or MariaDB release series, e.g. MySQL 5.7 or MariaDB 10.3
+type ReleaseSeries struct {
+	MariaDB bool
+	Major   int
+	Minor   int
+}
+
+// leadingNumber parses the leading digits of given token, e.g. 3 for "3-MariaDB"
+func leadingNumber(token string) int {
+	digits := strings.IndexFunc(token, func(r rune) bool { return r < '0' || r > '9' })
+	if digits >= 0 {
+		token = token[:digits]
+	}
+	number, _ := strconv.Atoi(token)
+	return number
+}
+
+// ParseReleaseSeries returns the release series of given version, e.g. given "10.3.22-MariaDB-log" it
+// returns MariaDB 10.3. Also parses the series' own string representation, e.g. "10.3-MariaDB".
+func ParseReleaseSeries(version string) ReleaseSeries {
+	majorVersion := MajorVersion(version)
+	return ReleaseSeries{
+		MariaDB: strings.Contains(version, "MariaDB"),
+		Major:   leadingNumber(majorVersion[0]),
+		Minor:   leadingNumber(majorVersion[1]),
+	}
+}
+
+func (this ReleaseSeries) String() string {
+	if this.MariaDB {
+		return fmt.Sprintf("%d.%d-MariaDB", this.Major, this.Minor)
+	}
+	return fmt.Sprintf("%d.%d", this.Major, this.Minor)
+}
+
+func (this ReleaseSeries) flavor() string {
+	if this.MariaDB {
+		return "MariaDB"
+	}
+	return "MySQL"
+}
+
+// IsOlderThan compares release series of the same flavor
+func (this ReleaseSeries) IsOlderThan(other ReleaseSeries) bool {
+	if this.Major != other.Major {
+		return this.Major < other.Major
+	}
+	return this.Minor < other.Minor
+}
+
+// unboundedSeries is newer than any release series, and stands for "and newer" in versionCompatibilityMatrix
+var unboundedSeries = ReleaseSeries{Major: math.MaxInt32}
+
+// versionCompatibilityRule states that replicas of given flavor and series range replicate from masters of given
+// flavor and series range. With masterUpToReplicaSeries, the master's series range ends at the replica's own series.
+type versionCompatibilityRule struct {
+	replicaMariaDB          bool
+	replicaOldestSeries     ReleaseSeries
+	replicaNewestSeries     ReleaseSeries
+	masterMariaDB           bool
+	masterOldestSeries      ReleaseSeries
+	masterNewestSeries      ReleaseSeries
+	masterUpToReplicaSeries bool
+}
+
+// coversReplica returns true when this rule applies to given replica series replicating from given master flavor
+func (this versionCompatibilityRule) coversReplica(replicaSeries ReleaseSeries, masterMariaDB bool) bool {
+	if this.replicaMariaDB != replicaSeries.MariaDB || this.masterMariaDB != masterMariaDB {
+		return false
+	}
+	return !replicaSeries.IsOlderThan(this.replicaOldestSeries) && !this.replicaNewestSeries.IsOlderThan(replicaSeries)
+}
+
+// allowsMaster returns true when given master series is within this rule's range for given replica series
+func (this versionCompatibilityRule) allowsMaster(replicaSeries ReleaseSeries, masterSeries ReleaseSeries) bool {
+	masterNewestSeries := this.masterNewestSeries
+	if this.masterUpToReplicaSeries {
+		masterNewestSeries = replicaSeries
+	}
+	return !masterSeries.IsOlderThan(this.masterOldestSeries) && !masterNewestSeries.IsOlderThan(masterSeries)
+}
+
+// versionCompatibilityMatrix lists, per replica series and master flavor, the master series a replica replicates
+// from. The first rule covering the replica's series and the master's flavor decides. A replica not covered by any
+// rule may replicate from any master of its own flavor and of its own or an older series, and from no master of
+// another flavor: MySQL does not replicate from MariaDB.
+var versionCompatibilityMatrix = []versionCompatibilityRule{
+	// MySQL 5.7 replicates from MySQL up to 5.7, but not from 8.0
+	{
+		replicaOldestSeries: ReleaseSeries{Major: 5, Minor: 7}, replicaNewestSeries: ReleaseSeries{Major: 5, Minor: 7},
+		masterOldestSeries: ReleaseSeries{Major: 5, Minor: 0}, masterNewestSeries: ReleaseSeries{Major: 5, Minor: 7},
+	},
+	// MySQL 8.0 replicates from MySQL 5.x and 8.0, but not from 8.1 and newer
+	{
+		replicaOldestSeries: ReleaseSeries{Major: 8, Minor: 0}, replicaNewestSeries: ReleaseSeries{Major: 8, Minor: 0},
+		masterOldestSeries: ReleaseSeries{Major: 5, Minor: 0}, masterNewestSeries: ReleaseSeries{Major: 8, Minor: 0},
+	},
+	// MariaDB 10.x and newer replicates from MySQL 5.x up to 5.7, but not from 8.0
+	{
+		replicaMariaDB: true, replicaOldestSeries: ReleaseSeries{Major: 10, Minor: 0}, replicaNewestSeries: unboundedSeries,
+		masterOldestSeries: ReleaseSeries{Major: 5, Minor: 0}, masterNewestSeries: ReleaseSeries{Major: 5, Minor: 7},
+	},
+	// MariaDB 10.x and newer replicates from MariaDB 5.5 up to its own series
+	{
+		replicaMariaDB: true, replicaOldestSeries: ReleaseSeries{Major: 10, Minor: 0}, replicaNewestSeries: unboundedSeries,
+		masterMariaDB: true, masterOldestSeries: ReleaseSeries{Major: 5, Minor: 5}, masterUpToReplicaSeries: true,
+	},
+}
+
+// checkVersionCompatibility returns an error when replicas of given version cannot replicate from masters of given
+// version, as per versionCompatibilityMatrix
+func checkVersionCompatibility(replicaVersion string, masterVersion string) error {
+	replicaSeries := ParseReleaseSeries(replicaVersion)
+	masterSeries := ParseReleaseSeries(masterVersion)
+	compatible := replicaSeries.MariaDB == masterSeries.MariaDB && !replicaSeries.IsOlderThan(masterSeries)
+	for _, rule := range versionCompatibilityMatrix {
+		if rule.coversReplica(replicaSeries, masterSeries.MariaDB) {
+			compatible = rule.allowsMaster(replicaSeries, masterSeries)
+			break
+		}
+	}
+	if compatible {
+		return nil
+	}
+	if replicaSeries.MariaDB == masterSeries.MariaDB && replicaSeries.IsOlderThan(masterSeries) {
+		return fmt.Errorf("%s %s is older than %s", replicaSeries.flavor(), replicaSeries, masterSeries)
+	}
+	return fmt.Errorf("%s %s cannot replicate from %s %s", replicaSeries.flavor(), replicaSeries, masterSeries.flavor(), masterSeries)
+}
+
+// IsVersionCompatible returns true when replicas of given version can replicate from masters of given version
+func IsVersionCompatible(replicaVersion string, masterVersion string) bool {
+	return checkVersionCompatibility(replicaVersion, masterVersion) == nil
+}
+
+type versionCompatibilityOverrideContextKey struct{}
+
+// WithVersionCompatibilityOverride returns a context by which the operation does not enforce version compatibility,
+// for emergency moves onto otherwise unsupported setups
+func WithVersionCompatibilityOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, versionCompatibilityOverrideContextKey{}, true)
+}
+
+// isVersionCompatibilityOverridden returns true when given context, or the --ignore-version-compatibility command
+// line flag, overrides version compatibility
+func isVersionCompatibilityOverridden(ctx context.Context) bool {
+	if overridden, ok := ctx.Value(versionCompatibilityOverrideContextKey{}).(bool); ok && overridden {
+		return true
+	}
+	return config.RuntimeCLIFlags.IgnoreVersionCompatibility != nil && *config.RuntimeCLIFlags.IgnoreVersionCompatibility
+}
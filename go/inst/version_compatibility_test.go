@@ -0,0 +1,133 @@
+package inst
+
+import (
+	"context"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseReleaseSeries(t *testing.T) {
+	test.S(t).ExpectEquals(ParseReleaseSeries("5.7.30-log"), ReleaseSeries{Major: 5, Minor: 7})
+	test.S(t).ExpectEquals(ParseReleaseSeries("8.0.23"), ReleaseSeries{Major: 8, Minor: 0})
+	test.S(t).ExpectEquals(ParseReleaseSeries("10.3.22-MariaDB-log"), ReleaseSeries{MariaDB: true, Major: 10, Minor: 3})
+	test.S(t).ExpectEquals(ParseReleaseSeries("10.3-MariaDB"), ReleaseSeries{MariaDB: true, Major: 10, Minor: 3})
+	test.S(t).ExpectEquals(ParseReleaseSeries("5.6"), ReleaseSeries{Major: 5, Minor: 6})
+	test.S(t).ExpectEquals(ParseReleaseSeries(""), ReleaseSeries{})
+
+	test.S(t).ExpectEquals(ParseReleaseSeries("10.3.22-MariaDB-log").String(), "10.3-MariaDB")
+	test.S(t).ExpectEquals(ParseReleaseSeries("5.7.30-log").String(), "5.7")
+}
+
+func TestIsVersionCompatible(t *testing.T) {
+	// replica version, master version
+	test.S(t).ExpectTrue(IsVersionCompatible("5.7.30", "5.7.8"))
+	test.S(t).ExpectTrue(IsVersionCompatible("5.7.8", "5.7.30"))
+	test.S(t).ExpectTrue(IsVersionCompatible("5.7.30", "5.6.9"))
+	test.S(t).ExpectTrue(IsVersionCompatible("8.0.23", "5.7.30"))
+	test.S(t).ExpectFalse(IsVersionCompatible("5.7.30", "8.0.23"))
+	test.S(t).ExpectTrue(IsVersionCompatible("8.0.23", "5.6.9"))
+	test.S(t).ExpectTrue(IsVersionCompatible("5.7.30", "5.5.17"))
+	test.S(t).ExpectTrue(IsVersionCompatible("8.4.0", "8.0.23"))
+	test.S(t).ExpectTrue(IsVersionCompatible("9.0.1", "8.0.23"))
+	test.S(t).ExpectFalse(IsVersionCompatible("8.0.23", "8.4.0"))
+	test.S(t).ExpectTrue(IsVersionCompatible("12.1.0", "11.0.0"))
+
+	test.S(t).ExpectTrue(IsVersionCompatible("10.3.22-MariaDB", "10.3.10-MariaDB-log"))
+	test.S(t).ExpectTrue(IsVersionCompatible("10.6.4-MariaDB", "10.3.22-MariaDB"))
+	test.S(t).ExpectTrue(IsVersionCompatible("11.2.1-MariaDB", "10.3.22-MariaDB"))
+	test.S(t).ExpectFalse(IsVersionCompatible("10.3.22-MariaDB", "10.6.4-MariaDB"))
+
+	test.S(t).ExpectTrue(IsVersionCompatible("10.3.22-MariaDB", "5.7.30"))
+	test.S(t).ExpectTrue(IsVersionCompatible("10.3.22-MariaDB", "5.6.9"))
+	test.S(t).ExpectFalse(IsVersionCompatible("10.6.4-MariaDB", "8.0.23"))
+	test.S(t).ExpectFalse(IsVersionCompatible("10.6.4-MariaDB", "4.1.22"))
+	test.S(t).ExpectFalse(IsVersionCompatible("5.7.30", "10.3.22-MariaDB"))
+	test.S(t).ExpectFalse(IsVersionCompatible("8.0.23", "10.3.22-MariaDB"))
+}
+
+func TestVersionCompatibilityMatrix(t *testing.T) {
+	tests := []struct {
+		replicaVersion string
+		masterVersion  string
+		compatible     bool
+	}{
+		{"5.7.30", "5.6.9", true},
+		{"5.7.30", "5.7.8", true},
+		{"5.7.30", "8.0.23", false},
+		{"5.7.30", "4.1.22", false},
+		{"8.0.23", "5.7.30", true},
+		{"8.0.23", "8.0.11", true},
+		{"8.0.23", "8.1.0", false},
+		{"5.6.9", "5.5.17", true},
+		{"5.6.9", "5.7.30", false},
+		{"10.0.38-MariaDB", "5.5.17", true},
+		{"10.6.4-MariaDB", "5.7.30", true},
+		{"10.6.4-MariaDB", "8.0.23", false},
+		{"11.2.1-MariaDB", "5.7.30", true},
+		{"10.6.4-MariaDB", "5.5.64-MariaDB", true},
+		{"10.6.4-MariaDB", "10.6.2-MariaDB", true},
+		{"10.6.4-MariaDB", "10.11.2-MariaDB", false},
+		{"5.5.64-MariaDB", "5.5.60-MariaDB", true},
+		{"5.7.30", "10.3.22-MariaDB", false},
+		{"8.4.0", "10.3.22-MariaDB", false},
+	}
+	for _, tt := range tests {
+		err := checkVersionCompatibility(tt.replicaVersion, tt.masterVersion)
+		test.S(t).ExpectEquals(err == nil, tt.compatible)
+	}
+	test.S(t).ExpectEquals(checkVersionCompatibility("5.7.30", "8.0.23").Error(), "MySQL 5.7 is older than 8.0")
+	test.S(t).ExpectEquals(checkVersionCompatibility("10.6.4-MariaDB", "8.0.23").Error(), "MariaDB 10.6-MariaDB cannot replicate from MySQL 8.0")
+}
+
+func TestCanReplicateFromVersionCompatibility(t *testing.T) {
+	i57 := Instance{Key: key1, Version: "5.7.30", ServerID: 1, LogBinEnabled: true, LogSlaveUpdatesEnabled: true}
+	i80 := Instance{Key: key2, Version: "8.0.23", ServerID: 2, LogBinEnabled: true, LogSlaveUpdatesEnabled: true}
+	iMariaDB := Instance{Key: key3, Version: "10.3.22-MariaDB", ServerID: 3, LogBinEnabled: true, LogSlaveUpdatesEnabled: true}
+
+	canReplicate, err := i80.CanReplicateFrom(&i57)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(canReplicate)
+	canReplicate, err = i57.CanReplicateFrom(&i80)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectFalse(canReplicate)
+	canReplicate, _ = iMariaDB.CanReplicateFrom(&i57)
+	test.S(t).ExpectTrue(canReplicate)
+	canReplicate, _ = iMariaDB.CanReplicateFrom(&i80)
+	test.S(t).ExpectFalse(canReplicate)
+	canReplicate, _ = i57.CanReplicateFrom(&iMariaDB)
+	test.S(t).ExpectFalse(canReplicate)
+
+	iBinlogServer := Instance{Key: key1, Version: "2.1.5-maxscale", ServerID: 4, LogBinEnabled: true, LogSlaveUpdatesEnabled: true}
+	canReplicate, _ = iMariaDB.CanReplicateFrom(&iBinlogServer)
+	test.S(t).ExpectTrue(canReplicate)
+}
+
+func TestVersionCompatibilityOverride(t *testing.T) {
+	ctx := context.Background()
+	test.S(t).ExpectFalse(isVersionCompatibilityOverridden(ctx))
+	test.S(t).ExpectTrue(isVersionCompatibilityOverridden(WithVersionCompatibilityOverride(ctx)))
+}
+
+func TestGetPriorityMajorVersionForCandidateCompatibility(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	instancesMap[i710Key.StringCode()].Version = "5.7.30"
+	instancesMap[i720Key.StringCode()].Version = "5.7.30"
+	instancesMap[i730Key.StringCode()].Version = "5.7.30"
+	instancesMap[i810Key.StringCode()].Version = "8.0.23"
+	instancesMap[i820Key.StringCode()].Version = "8.0.23"
+	instancesMap[i830Key.StringCode()].Version = "8.0.23"
+	// Equally common; 8.0 replicates from 5.7, but not the other way around
+	priorityMajorVersion, err := getPriorityMajorVersionForCandidate(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(priorityMajorVersion, "5.7")
+
+	for _, instance := range instances {
+		instance.Version = "10.3.22-MariaDB-log"
+	}
+	priorityMajorVersion, err = getPriorityMajorVersionForCandidate(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(priorityMajorVersion, "10.3-MariaDB")
+	test.S(t).ExpectTrue(IsVersionCompatible(priorityMajorVersion, "10.3.10-MariaDB"))
+	test.S(t).ExpectFalse(IsVersionCompatible(priorityMajorVersion, "10.6.4-MariaDB"))
+}
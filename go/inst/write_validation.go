@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/sqlutils"
+)
+
+// readMasterBinlogCoordinates reads the binary log coordinates of an instance, per SHOW MASTER STATUS
+func readMasterBinlogCoordinates(ctx context.Context, sqlDB *sql.DB) (coordinates *BinlogCoordinates, err error) {
+	rows, err := sqlDB.QueryContext(ctx, "show master status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	err = sqlutils.ScanRowsToMaps(rows, func(m sqlutils.RowMap) error {
+		coordinates = &BinlogCoordinates{LogFile: m.GetString("File"), LogPos: m.GetInt64("Position")}
+		return nil
+	})
+	return coordinates, err
+}
+
+// ValidateMasterWrites verifies given master accepts writes: it executes PromotedMasterWriteValidationQuery, which must
+// advance the master's binary log position. A write blocking beyond PromotedMasterWriteTimeoutSeconds, as is the case
+// on a full disk, fails validation. Returns the binary log coordinates following the write.
+func ValidateMasterWrites(instanceKey *InstanceKey) (*BinlogCoordinates, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Config.PromotedMasterWriteTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	sqlDB, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return nil, err
+	}
+	coordinatesBefore, err := readMasterBinlogCoordinates(ctx, sqlDB)
+	if err != nil {
+		return nil, fmt.Errorf("ValidateMasterWrites: cannot read binary log coordinates of %+v: %+v", *instanceKey, err)
+	}
+	if coordinatesBefore == nil {
+		return nil, fmt.Errorf("ValidateMasterWrites: binary logging is disabled on %+v", *instanceKey)
+	}
+	if _, err := sqlDB.ExecContext(ctx, config.Config.PromotedMasterWriteValidationQuery); err != nil {
+		return nil, fmt.Errorf("ValidateMasterWrites: write failed on %+v: %+v", *instanceKey, err)
+	}
+	coordinatesAfter, err := readMasterBinlogCoordinates(ctx, sqlDB)
+	if err != nil {
+		return nil, fmt.Errorf("ValidateMasterWrites: cannot read binary log coordinates of %+v: %+v", *instanceKey, err)
+	}
+	if coordinatesAfter == nil || !coordinatesBefore.SmallerThan(coordinatesAfter) {
+		return coordinatesAfter, fmt.Errorf("ValidateMasterWrites: binary log of %+v did not advance past %+v on write", *instanceKey, *coordinatesBefore)
+	}
+	return coordinatesAfter, nil
+}
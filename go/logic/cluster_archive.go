@@ -0,0 +1,350 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+	"github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+const clusterArchiveFormatVersion = 1
+
+// clusterArchiveTableSpec describes how a backend table participates in a cluster archive
+type clusterArchiveTableSpec struct {
+	tableName string
+	// whereClause filters the table rows to those of a single cluster. It expects the cluster name as only argument.
+	whereClause string
+	// autoIncrementColumn, if non empty, is exported but not imported: ids are assigned anew by the importing deployment
+	autoIncrementColumn string
+}
+
+// instanceOfClusterClause filters rows of a per-instance table (having hostname, port columns) to instances of a cluster
+func instanceOfClusterClause(tableName string) string {
+	return fmt.Sprintf(`exists (
+			select 1 from database_instance
+			where
+				database_instance.hostname = %s.hostname
+				and database_instance.port = %s.port
+				and database_instance.cluster_name = ?
+		)`, tableName, tableName)
+}
+
+// clusterArchiveTables lists the tables exported per cluster. Order matters: it is the import order.
+var clusterArchiveTables = []clusterArchiveTableSpec{
+	{tableName: "database_instance", whereClause: "cluster_name = ?"},
+	{tableName: "cluster_alias", whereClause: "cluster_name = ?"},
+	{tableName: "cluster_alias_override", whereClause: "cluster_name = ?"},
+	{tableName: "cluster_domain_name", whereClause: "cluster_name = ?"},
+	{tableName: "database_instance_tags", whereClause: instanceOfClusterClause("database_instance_tags")},
+	{tableName: "candidate_database_instance", whereClause: instanceOfClusterClause("candidate_database_instance")},
+	{tableName: "database_instance_downtime", whereClause: instanceOfClusterClause("database_instance_downtime")},
+	{tableName: "topology_failure_detection", whereClause: "cluster_name = ?", autoIncrementColumn: "detection_id"},
+	{tableName: "topology_recovery", whereClause: "cluster_name = ?", autoIncrementColumn: "recovery_id"},
+	{tableName: "topology_recovery_steps", whereClause: "recovery_uid in (select uid from topology_recovery where cluster_name = ?)", autoIncrementColumn: "recovery_step_id"},
+	{tableName: "topology_recovery_topology", whereClause: "recovery_uid in (select uid from topology_recovery where cluster_name = ?)"},
+}
+
+// ClusterArchiveTable is the exported content of a single backend table. Cells are either
+// strings or nil (for NULL values).
+type ClusterArchiveTable struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+func (this *ClusterArchiveTable) columnIndex(column string) int {
+	for i, c := range this.Columns {
+		if c == column {
+			return i
+		}
+	}
+	return -1
+}
+
+// ClusterArchive is a portable representation of the full orchestrator state of a single cluster
+type ClusterArchive struct {
+	FormatVersion int
+	ClusterName   string
+	ClusterAlias  string
+	ExportedBy    string
+	ExportedAt    string
+	Tables        map[string]*ClusterArchiveTable
+}
+
+// ClusterArchiveImportSummary reports what an import has written
+type ClusterArchiveImportSummary struct {
+	ClusterName  string
+	RowsImported map[string]int
+	RowsSkipped  map[string]int
+}
+
+// ExportClusterArchive reads all backend state of given cluster into a ClusterArchive
+func ExportClusterArchive(clusterName string) (*ClusterArchive, error) {
+	orcdb, err := db.OpenOrchestrator()
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	archive := &ClusterArchive{
+		FormatVersion: clusterArchiveFormatVersion,
+		ClusterName:   clusterName,
+		ExportedBy:    process.ThisHostname,
+		ExportedAt:    time.Now().Format("2006-01-02 15:04:05"),
+		Tables:        make(map[string]*ClusterArchiveTable),
+	}
+	archive.ClusterAlias, _ = inst.ReadAliasByClusterName(clusterName)
+
+	for _, spec := range clusterArchiveTables {
+		query := fmt.Sprintf(`select * from %s where %s`, spec.tableName, spec.whereClause)
+		data, err := sqlutils.QueryNamedResultData(orcdb, query, clusterName)
+		if err != nil {
+			return nil, log.Errore(err)
+		}
+		table := &ClusterArchiveTable{Columns: data.Columns, Rows: [][]interface{}{}}
+		for _, rowData := range data.Data {
+			row := []interface{}{}
+			for _, cell := range rowData {
+				row = append(row, cellValue(cell))
+			}
+			table.Rows = append(table.Rows, row)
+		}
+		archive.Tables[spec.tableName] = table
+	}
+	if len(archive.Tables["database_instance"].Rows) == 0 {
+		return nil, fmt.Errorf("ExportClusterArchive: no instances found for cluster %s", clusterName)
+	}
+	inst.AuditOperation("export-cluster", nil, fmt.Sprintf("exported cluster %s", clusterName))
+	return archive, nil
+}
+
+func cellValue(cell sqlutils.CellData) interface{} {
+	if !cell.Valid {
+		return nil
+	}
+	return cell.String
+}
+
+// WriteClusterArchive writes the archive as gzipped JSON
+func WriteClusterArchive(archive *ClusterArchive, w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(archive); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadClusterArchive reads a gzipped JSON archive, as produced by WriteClusterArchive
+func ReadClusterArchive(r io.Reader) (*ClusterArchive, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	archive := &ClusterArchive{}
+	if err := json.NewDecoder(zr).Decode(archive); err != nil {
+		return nil, err
+	}
+	if archive.FormatVersion != clusterArchiveFormatVersion {
+		return nil, fmt.Errorf("Unsupported cluster archive format version: %d", archive.FormatVersion)
+	}
+	if archive.ClusterName == "" {
+		return nil, fmt.Errorf("Cluster archive does not indicate a cluster name")
+	}
+	return archive, nil
+}
+
+// ClusterArchiveBytes returns the gzipped JSON archive
+func ClusterArchiveBytes(archive *ClusterArchive) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteClusterArchive(archive, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readTableColumns returns the columns of given backend table
+func readTableColumns(tableName string) (map[string]bool, error) {
+	orcdb, err := db.OpenOrchestrator()
+	if err != nil {
+		return nil, err
+	}
+	data, err := sqlutils.QueryNamedResultData(orcdb, fmt.Sprintf("select * from %s limit 0", tableName))
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]bool)
+	for _, column := range data.Columns {
+		columns[column] = true
+	}
+	return columns, nil
+}
+
+// clusterArchiveImport tracks the state of a single import across tables: detection ids as assigned anew by
+// this deployment, and recoveries skipped for being already known
+type clusterArchiveImport struct {
+	detectionIds        map[string]int64
+	skippedRecoveryUIDs map[string]bool
+	isKnownRecovery     func(uid string) bool
+}
+
+func newClusterArchiveImport(isKnownRecovery func(uid string) bool) *clusterArchiveImport {
+	return &clusterArchiveImport{
+		detectionIds:        make(map[string]int64),
+		skippedRecoveryUIDs: make(map[string]bool),
+		isKnownRecovery:     isKnownRecovery,
+	}
+}
+
+// rowArgs returns the values to write for given archived row, picked by columnIndexes and with detection
+// references rewritten to the ids assigned by this import. It returns false if the row is to be skipped.
+func (this *clusterArchiveImport) rowArgs(spec clusterArchiveTableSpec, table *ClusterArchiveTable, row []interface{}, columnIndexes []int) (args []interface{}, ok bool) {
+	lastDetectionIndex := -1
+	switch spec.tableName {
+	case "topology_recovery":
+		uid := fmt.Sprintf("%v", row[table.columnIndex("uid")])
+		if this.isKnownRecovery(uid) {
+			this.skippedRecoveryUIDs[uid] = true
+			return args, false
+		}
+		lastDetectionIndex = table.columnIndex("last_detection_id")
+	case "topology_recovery_steps", "topology_recovery_topology":
+		if this.skippedRecoveryUIDs[fmt.Sprintf("%v", row[table.columnIndex("recovery_uid")])] {
+			return args, false
+		}
+	}
+	for _, i := range columnIndexes {
+		value := row[i]
+		if i == lastDetectionIndex {
+			if detectionId, ok := this.detectionIds[fmt.Sprintf("%v", value)]; ok {
+				value = fmt.Sprintf("%d", detectionId)
+			}
+		}
+		args = append(args, value)
+	}
+	return args, true
+}
+
+// rowWritten records the id this deployment has assigned to given archived row
+func (this *clusterArchiveImport) rowWritten(spec clusterArchiveTableSpec, table *ClusterArchiveTable, row []interface{}, insertId int64) {
+	if spec.tableName != "topology_failure_detection" {
+		return
+	}
+	if idIndex := table.columnIndex(spec.autoIncrementColumn); idIndex >= 0 {
+		this.detectionIds[fmt.Sprintf("%v", row[idIndex])] = insertId
+	}
+}
+
+// isKnownRecovery returns true when a recovery of given uid exists in the backend
+func isKnownRecovery(uid string) bool {
+	recoveries, _ := ReadRecoveryByUID(uid)
+	return len(recoveries) > 0
+}
+
+// ImportClusterArchive writes the content of given archive into the backend database. Columns unknown
+// to this deployment are ignored. Detection, recovery and step ids are assigned anew, and the references
+// between recoveries and detections rewritten accordingly. Recoveries already known (by uid) are skipped,
+// making the import safe to repeat.
+func ImportClusterArchive(archive *ClusterArchive) (*ClusterArchiveImportSummary, error) {
+	summary := &ClusterArchiveImportSummary{
+		ClusterName:  archive.ClusterName,
+		RowsImported: make(map[string]int),
+		RowsSkipped:  make(map[string]int),
+	}
+	clusterImport := newClusterArchiveImport(isKnownRecovery)
+
+	for _, spec := range clusterArchiveTables {
+		table, ok := archive.Tables[spec.tableName]
+		if !ok || table == nil {
+			continue
+		}
+		targetColumns, err := readTableColumns(spec.tableName)
+		if err != nil {
+			return summary, log.Errore(err)
+		}
+		columnIndexes := []int{}
+		columns := []string{}
+		for i, column := range table.Columns {
+			if column == spec.autoIncrementColumn || !targetColumns[column] {
+				continue
+			}
+			columnIndexes = append(columnIndexes, i)
+			columns = append(columns, column)
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")
+		verb := "replace into"
+		if spec.autoIncrementColumn != "" {
+			verb = "insert ignore into"
+		}
+		query := fmt.Sprintf(`%s %s (%s) values (%s)`, verb, spec.tableName, strings.Join(columns, ","), placeholders)
+
+		for _, row := range table.Rows {
+			if len(row) != len(table.Columns) {
+				return summary, fmt.Errorf("ImportClusterArchive: malformed row in %s", spec.tableName)
+			}
+			args, ok := clusterImport.rowArgs(spec, table, row, columnIndexes)
+			if !ok {
+				summary.RowsSkipped[spec.tableName]++
+				continue
+			}
+			sqlResult, err := db.ExecOrchestrator(query, args...)
+			if err != nil {
+				return summary, log.Errore(err)
+			}
+			if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+				summary.RowsSkipped[spec.tableName]++
+				continue
+			}
+			summary.RowsImported[spec.tableName]++
+			if insertId, err := sqlResult.LastInsertId(); err == nil {
+				clusterImport.rowWritten(spec, table, row, insertId)
+			}
+		}
+	}
+	inst.AuditOperation("import-cluster", nil, fmt.Sprintf("imported cluster %s, exported by %s at %s", archive.ClusterName, archive.ExportedBy, archive.ExportedAt))
+	return summary, nil
+}
+
+// SubmitClusterArchiveImport imports given archive. With raft, each raft member applies the import onto its
+// own backend; the compressed archive is then written to the raft log, and is bounded by RaftMaxClusterArchiveBytes.
+func SubmitClusterArchiveImport(archive *ClusterArchive) (summary *ClusterArchiveImportSummary, err error) {
+	if orcraft.IsRaftEnabled() {
+		archiveBytes, err := ClusterArchiveBytes(archive)
+		if err != nil {
+			return nil, err
+		}
+		if len(archiveBytes) > config.Config.RaftMaxClusterArchiveBytes {
+			return nil, fmt.Errorf("Cluster archive of %s is %d bytes compressed, exceeding RaftMaxClusterArchiveBytes (%d). Import it onto each raft member via `orchestrator -c import-cluster --ignore-raft-setup`", archive.ClusterName, len(archiveBytes), config.Config.RaftMaxClusterArchiveBytes)
+		}
+		_, err = orcraft.PublishCommand("import-cluster-archive", archiveBytes)
+		return &ClusterArchiveImportSummary{ClusterName: archive.ClusterName}, err
+	}
+	return ImportClusterArchive(archive)
+}
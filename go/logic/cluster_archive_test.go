@@ -0,0 +1,176 @@
+package logic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func clusterArchiveTableSpecOf(tableName string) clusterArchiveTableSpec {
+	for _, spec := range clusterArchiveTables {
+		if spec.tableName == tableName {
+			return spec
+		}
+	}
+	return clusterArchiveTableSpec{tableName: tableName}
+}
+
+func newTestClusterArchive() *ClusterArchive {
+	return &ClusterArchive{
+		FormatVersion: clusterArchiveFormatVersion,
+		ClusterName:   "db-0001:3306",
+		ClusterAlias:  "main",
+		ExportedBy:    "orc-0001",
+		ExportedAt:    "2020-02-02 10:00:00",
+		Tables: map[string]*ClusterArchiveTable{
+			"database_instance": {
+				Columns: []string{"hostname", "port", "cluster_name", "data_center"},
+				Rows: [][]interface{}{
+					{"db-0001", "3306", "db-0001:3306", "dc1"},
+					{"db-0002", "3306", "db-0001:3306", nil},
+				},
+			},
+			"topology_failure_detection": {
+				Columns: []string{"detection_id", "hostname", "port", "analysis"},
+				Rows: [][]interface{}{
+					{"5", "db-0001", "3306", "DeadMaster"},
+					{"6", "db-0001", "3306", "DeadMaster"},
+				},
+			},
+			"topology_recovery": {
+				Columns: []string{"recovery_id", "uid", "hostname", "port", "last_detection_id"},
+				Rows: [][]interface{}{
+					{"11", "uid-known", "db-0001", "3306", "5"},
+					{"12", "uid-new", "db-0001", "3306", "6"},
+					{"13", "uid-undetected", "db-0001", "3306", "0"},
+				},
+			},
+			"topology_recovery_steps": {
+				Columns: []string{"recovery_step_id", "recovery_uid", "message"},
+				Rows: [][]interface{}{
+					{"21", "uid-known", "step of known recovery"},
+					{"22", "uid-new", "step of new recovery"},
+				},
+			},
+		},
+	}
+}
+
+func TestClusterArchiveRoundTrip(t *testing.T) {
+	archive := newTestClusterArchive()
+
+	var buf bytes.Buffer
+	err := WriteClusterArchive(archive, &buf)
+	test.S(t).ExpectNil(err)
+
+	readArchive, err := ReadClusterArchive(&buf)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(readArchive.ClusterName, archive.ClusterName)
+	test.S(t).ExpectEquals(readArchive.ClusterAlias, archive.ClusterAlias)
+	test.S(t).ExpectEquals(readArchive.ExportedBy, archive.ExportedBy)
+	test.S(t).ExpectEquals(readArchive.ExportedAt, archive.ExportedAt)
+	test.S(t).ExpectEquals(len(readArchive.Tables), len(archive.Tables))
+
+	instances := readArchive.Tables["database_instance"]
+	test.S(t).ExpectEquals(len(instances.Rows), 2)
+	test.S(t).ExpectEquals(instances.columnIndex("data_center"), 3)
+	test.S(t).ExpectEquals(instances.Rows[0][3], "dc1")
+	test.S(t).ExpectTrue(instances.Rows[1][3] == nil)
+
+	archiveBytes, err := ClusterArchiveBytes(archive)
+	test.S(t).ExpectNil(err)
+	readArchive, err = ReadClusterArchive(bytes.NewReader(archiveBytes))
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(readArchive.Tables["topology_recovery"].Rows[1][1], "uid-new")
+}
+
+func TestReadClusterArchiveValidation(t *testing.T) {
+	writeRaw := func(archive *ClusterArchive) *bytes.Buffer {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		json.NewEncoder(zw).Encode(archive)
+		zw.Close()
+		return &buf
+	}
+	{
+		archive := newTestClusterArchive()
+		archive.FormatVersion = clusterArchiveFormatVersion + 1
+		_, err := ReadClusterArchive(writeRaw(archive))
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		archive := newTestClusterArchive()
+		archive.ClusterName = ""
+		_, err := ReadClusterArchive(writeRaw(archive))
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ReadClusterArchive(bytes.NewBufferString(`{"FormatVersion": 1}`))
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestClusterArchiveImportRemapsDetectionIds(t *testing.T) {
+	archive := newTestClusterArchive()
+	clusterImport := newClusterArchiveImport(func(uid string) bool { return false })
+
+	detections := archive.Tables["topology_failure_detection"]
+	detectionSpec := clusterArchiveTableSpecOf("topology_failure_detection")
+	for i, row := range detections.Rows {
+		args, ok := clusterImport.rowArgs(detectionSpec, detections, row, []int{1, 2, 3})
+		test.S(t).ExpectTrue(ok)
+		test.S(t).ExpectEquals(len(args), 3)
+		clusterImport.rowWritten(detectionSpec, detections, row, int64(100+i))
+	}
+
+	recoveries := archive.Tables["topology_recovery"]
+	recoverySpec := clusterArchiveTableSpecOf("topology_recovery")
+	columnIndexes := []int{1, 2, 3, 4}
+	{
+		args, ok := clusterImport.rowArgs(recoverySpec, recoveries, recoveries.Rows[0], columnIndexes)
+		test.S(t).ExpectTrue(ok)
+		test.S(t).ExpectEquals(args[0], "uid-known")
+		test.S(t).ExpectEquals(args[3], "100")
+	}
+	{
+		args, ok := clusterImport.rowArgs(recoverySpec, recoveries, recoveries.Rows[1], columnIndexes)
+		test.S(t).ExpectTrue(ok)
+		test.S(t).ExpectEquals(args[3], "101")
+	}
+	{
+		// No such detection in the archive: the reference is kept as is
+		args, ok := clusterImport.rowArgs(recoverySpec, recoveries, recoveries.Rows[2], columnIndexes)
+		test.S(t).ExpectTrue(ok)
+		test.S(t).ExpectEquals(args[3], "0")
+	}
+	// The archive itself is not modified
+	test.S(t).ExpectEquals(recoveries.Rows[0][4], "5")
+	test.S(t).ExpectEquals(recoveries.Rows[1][4], "6")
+}
+
+func TestClusterArchiveImportSkipsKnownRecoveries(t *testing.T) {
+	archive := newTestClusterArchive()
+	clusterImport := newClusterArchiveImport(func(uid string) bool { return uid == "uid-known" })
+
+	recoveries := archive.Tables["topology_recovery"]
+	recoverySpec := clusterArchiveTableSpecOf("topology_recovery")
+	_, ok := clusterImport.rowArgs(recoverySpec, recoveries, recoveries.Rows[0], []int{1})
+	test.S(t).ExpectFalse(ok)
+	_, ok = clusterImport.rowArgs(recoverySpec, recoveries, recoveries.Rows[1], []int{1})
+	test.S(t).ExpectTrue(ok)
+
+	steps := archive.Tables["topology_recovery_steps"]
+	stepSpec := clusterArchiveTableSpecOf("topology_recovery_steps")
+	_, ok = clusterImport.rowArgs(stepSpec, steps, steps.Rows[0], []int{1, 2})
+	test.S(t).ExpectFalse(ok)
+	args, ok := clusterImport.rowArgs(stepSpec, steps, steps.Rows[1], []int{1, 2})
+	test.S(t).ExpectTrue(ok)
+	test.S(t).ExpectEquals(args[1], "step of new recovery")
+
+	// Writing a recovery step does not register a detection id
+	clusterImport.rowWritten(stepSpec, steps, steps.Rows[1], 300)
+	test.S(t).ExpectEquals(len(clusterImport.detectionIds), 0)
+}
@@ -0,0 +1,160 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/process"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// clusterSnapshotFormatVersion is incremented upon incompatible changes to ClusterSnapshot
+const clusterSnapshotFormatVersion = 1
+
+// clusterInstanceClause filters rows of a table with hostname and port columns to those of the instances of a cluster
+const clusterInstanceClause = `exists (
+		select 1 from database_instance
+		where database_instance.cluster_name = ?
+			and database_instance.hostname = snapshot_table.hostname
+			and database_instance.port = snapshot_table.port
+	)`
+
+// ClusterSnapshot is a portable export of the backend rows of a single cluster: its instances, their tags, downtime,
+// promotion rules and host attributes, and the cluster's audit, failure detection and recovery history. It is loaded
+// into an orchestrator running in AnalysisMode, for investigation away from the production topology.
+type ClusterSnapshot struct {
+	FormatVersion int
+	AppVersion    string
+	ClusterName   string
+	ExportedBy    string
+	ExportedAt    time.Time
+
+	Instances,
+	InstanceTags,
+	DowntimedInstances,
+	Candidates,
+	HostAttributes,
+	ClusterAlias,
+	ClusterDomainName,
+	Audit,
+	Detections,
+	Recovery,
+	RecoverySteps sqlutils.NamedResultData
+}
+
+// clusterSnapshotTable is a table included in a cluster snapshot, along with the clause selecting the cluster's rows
+type clusterSnapshotTable struct {
+	tableName   string
+	whereClause string
+	data        func(snapshot *ClusterSnapshot) *sqlutils.NamedResultData
+}
+
+// clusterSnapshotTables lists the tables of a cluster snapshot, in the order they are loaded. Each where clause
+// takes the cluster name as single argument.
+var clusterSnapshotTables = []clusterSnapshotTable{
+	{"database_instance", "cluster_name = ?", func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.Instances }},
+	{"database_instance_tags", clusterInstanceClause, func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.InstanceTags }},
+	{"database_instance_downtime", clusterInstanceClause, func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.DowntimedInstances }},
+	{"candidate_database_instance", clusterInstanceClause, func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.Candidates }},
+	{"host_attributes", `exists (
+		select 1 from database_instance
+		where database_instance.cluster_name = ?
+			and database_instance.hostname = snapshot_table.hostname
+	)`, func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.HostAttributes }},
+	{"cluster_alias", "cluster_name = ?", func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.ClusterAlias }},
+	{"cluster_domain_name", "cluster_name = ?", func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.ClusterDomainName }},
+	{"audit", "cluster_name = ?", func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.Audit }},
+	{"topology_failure_detection", "cluster_name = ?", func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.Detections }},
+	{"topology_recovery", "cluster_name = ?", func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.Recovery }},
+	{"topology_recovery_steps", `exists (
+		select 1 from topology_recovery
+		where topology_recovery.cluster_name = ?
+			and topology_recovery.uid = snapshot_table.recovery_uid
+	)`, func(s *ClusterSnapshot) *sqlutils.NamedResultData { return &s.RecoverySteps }},
+}
+
+// ExportClusterSnapshot reads the backend rows of given cluster into a snapshot
+func ExportClusterSnapshot(clusterName string) (*ClusterSnapshot, error) {
+	orcdb, err := db.OpenOrchestrator()
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	snapshot := &ClusterSnapshot{
+		FormatVersion: clusterSnapshotFormatVersion,
+		AppVersion:    config.RuntimeCLIFlags.ConfiguredVersion,
+		ClusterName:   clusterName,
+		ExportedBy:    process.ThisHostname,
+		ExportedAt:    time.Now(),
+	}
+	for _, table := range clusterSnapshotTables {
+		query := fmt.Sprintf("select snapshot_table.* from %s snapshot_table where %s", table.tableName, table.whereClause)
+		data, err := sqlutils.QueryNamedResultData(orcdb, query, clusterName)
+		if err != nil {
+			return nil, log.Errorf("ExportClusterSnapshot: %s: %+v", table.tableName, err)
+		}
+		*table.data(snapshot) = data
+	}
+	if len(snapshot.Instances.Data) == 0 {
+		return nil, fmt.Errorf("ExportClusterSnapshot: no instances found for cluster %s", clusterName)
+	}
+	return snapshot, nil
+}
+
+// ReadClusterSnapshot decodes a snapshot, as written by ExportClusterSnapshot
+func ReadClusterSnapshot(reader io.Reader) (*ClusterSnapshot, error) {
+	snapshot := &ClusterSnapshot{}
+	if err := json.NewDecoder(reader).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("ReadClusterSnapshot: %+v", err)
+	}
+	if snapshot.FormatVersion != clusterSnapshotFormatVersion {
+		return nil, fmt.Errorf("ReadClusterSnapshot: unsupported snapshot format version %d; expected %d", snapshot.FormatVersion, clusterSnapshotFormatVersion)
+	}
+	if snapshot.ClusterName == "" || len(snapshot.Instances.Data) == 0 {
+		return nil, fmt.Errorf("ReadClusterSnapshot: snapshot has no instances")
+	}
+	return snapshot, nil
+}
+
+// LoadClusterSnapshot writes the rows of given snapshot into the backend, replacing existing rows with the same keys.
+// It only runs in AnalysisMode, where loaded instances are neither probed nor recovered.
+func LoadClusterSnapshot(snapshot *ClusterSnapshot) error {
+	if !config.Config.AnalysisMode {
+		return fmt.Errorf("LoadClusterSnapshot: AnalysisMode is not enabled. Loading a snapshot into an orchestrator which probes and recovers its instances is not supported")
+	}
+	if snapshot.AppVersion != config.RuntimeCLIFlags.ConfiguredVersion {
+		log.Warningf("LoadClusterSnapshot: snapshot was exported by orchestrator version %s; this is %s", snapshot.AppVersion, config.RuntimeCLIFlags.ConfiguredVersion)
+	}
+	orcdb, err := db.OpenOrchestrator()
+	if err != nil {
+		return log.Errore(err)
+	}
+	for _, table := range clusterSnapshotTables {
+		if err := sqlutils.WriteTable(orcdb, table.tableName, *table.data(snapshot)); err != nil {
+			return log.Errorf("LoadClusterSnapshot: %s: %+v", table.tableName, err)
+		}
+	}
+	log.Infof("LoadClusterSnapshot: loaded %d instances of %s, exported by %s at %s", len(snapshot.Instances.Data), snapshot.ClusterName, snapshot.ExportedBy, snapshot.ExportedAt.Format(time.RFC3339))
+	return nil
+}
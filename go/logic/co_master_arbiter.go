@@ -0,0 +1,115 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/kv"
+	"github.com/github/orchestrator/go/raft"
+)
+
+const coMasterArbiterWitnessTimeout = 5 * time.Second
+
+// getCoMasterArbiterKVKey returns the key of the arbiter lock of given cluster. Its value is the
+// co-master which is allowed to be writable.
+func getCoMasterArbiterKVKey(clusterAlias string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimRight(config.Config.CoMasterArbiterKVPrefix, "/"), clusterAlias)
+}
+
+// claimCoMasterArbiterKVLock hands the arbiter lock of given cluster over to the promoted co-master. The lock
+// may only be taken from the failed co-master, or claimed when not held at all. Failure to write to any of the
+// KV stores, e.g. when this node is on the minority side of a partition, fails the claim.
+func claimCoMasterArbiterKVLock(clusterAlias string, failedKey *inst.InstanceKey, promotedKey *inst.InstanceKey) error {
+	key := getCoMasterArbiterKVKey(clusterAlias)
+	holder, found, err := kv.GetValue(key)
+	if err != nil {
+		return fmt.Errorf("cannot read co-master arbiter lock %s: %+v", key, err)
+	}
+	if found && holder != "" && holder != failedKey.StringCode() && holder != promotedKey.StringCode() {
+		return fmt.Errorf("co-master arbiter lock %s is held by %s", key, holder)
+	}
+	kvPair := kv.NewKVPair(key, promotedKey.StringCode())
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("put-key-value", kvPair)
+	} else {
+		err = kv.PutKVPair(kvPair)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot claim co-master arbiter lock %s: %+v", key, err)
+	}
+	if err := kv.DistributePairs([](*kv.KVPair){kvPair}); err != nil {
+		return fmt.Errorf("cannot distribute co-master arbiter lock %s: %+v", key, err)
+	}
+	return nil
+}
+
+// consultCoMasterArbiterWitness asks the witness to approve the promotion. Only an HTTP 200 response approves.
+func consultCoMasterArbiterWitness(clusterAlias string, failedKey *inst.InstanceKey, promotedKey *inst.InstanceKey) error {
+	witnessURL, err := url.Parse(config.Config.CoMasterArbiterWitnessURL)
+	if err != nil {
+		return err
+	}
+	query := witnessURL.Query()
+	query.Set("cluster", clusterAlias)
+	query.Set("failed", failedKey.StringCode())
+	query.Set("promoted", promotedKey.StringCode())
+	witnessURL.RawQuery = query.Encode()
+
+	client := &http.Client{Timeout: coMasterArbiterWitnessTimeout}
+	response, err := client.Get(witnessURL.String())
+	if err != nil {
+		return fmt.Errorf("cannot reach co-master arbiter witness: %+v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("co-master arbiter witness declined, status: %d", response.StatusCode)
+	}
+	return nil
+}
+
+// consultCoMasterArbiter is called before changing writability of co-masters during recovery. It returns
+// nil when no arbiter is configured, or when the arbiter approves given co-master to be the writable one.
+func consultCoMasterArbiter(topologyRecovery *TopologyRecovery, promotedKey *inst.InstanceKey) (err error) {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	clusterAlias := analysisEntry.ClusterDetails.ClusterAlias
+	failedKey := &analysisEntry.AnalyzedInstanceKey
+	switch config.Config.CoMasterArbiter {
+	case "":
+		return nil
+	case "kv":
+		err = claimCoMasterArbiterKVLock(clusterAlias, failedKey, promotedKey)
+	case "witness":
+		err = consultCoMasterArbiterWitness(clusterAlias, failedKey, promotedKey)
+	default:
+		err = fmt.Errorf("unsupported CoMasterArbiter: %s", config.Config.CoMasterArbiter)
+	}
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadCoMaster: co-master arbiter (%s) declined %+v: %+v", config.Config.CoMasterArbiter, *promotedKey, err))
+		inst.AuditOperation("co-master-arbiter", promotedKey, fmt.Sprintf("declined: %+v", err))
+		return err
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadCoMaster: co-master arbiter (%s) approved %+v", config.Config.CoMasterArbiter, *promotedKey))
+	inst.AuditOperation("co-master-arbiter", promotedKey, fmt.Sprintf("approved; failed co-master: %+v", *failedKey))
+	return nil
+}
@@ -17,10 +17,13 @@
 package logic
 
 import (
+	"context"
+	"bytes"
 	"encoding/json"
 
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/kv"
+	"github.com/github/orchestrator/go/process"
 	"github.com/github/orchestrator/go/raft"
 
 	"github.com/openark/golib/log"
@@ -69,6 +72,8 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.writeRecovery(value)
 	case "write-recovery-step":
 		return applier.writeRecoveryStep(value)
+	case "write-recovery-topology":
+		return applier.writeRecoveryTopology(value)
 	case "resolve-recovery":
 		return applier.resolveRecovery(value)
 	case "disable-global-recoveries":
@@ -87,6 +92,52 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.healthReport(value)
 	case "set-cluster-alias-manual-override":
 		return applier.setClusterAliasManualOverride(value)
+	case "write-operation-intent":
+		return applier.writeOperationIntent(value)
+	case "claim-operation-intent":
+		return applier.claimOperationIntent(value)
+	case "import-cluster-archive":
+		return applier.importClusterArchive(value)
+	case "write-dr-pair":
+		return applier.writeDRPair(value)
+	case "delete-dr-pair":
+		return applier.deleteDRPair(value)
+	case "write-poll-override":
+		return applier.writePollOverride(value)
+	case "delete-poll-override":
+		return applier.deletePollOverride(value)
+	case "write-replica-concurrency-override":
+		return applier.writeReplicaConcurrencyOverride(value)
+	case "delete-replica-concurrency-override":
+		return applier.deleteReplicaConcurrencyOverride(value)
+	case "write-postponement-relaxation":
+		return applier.writePostponementRelaxation(value)
+	case "delete-postponement-relaxation":
+		return applier.deletePostponementRelaxation(value)
+	case "write-cluster-focus":
+		return applier.writeClusterFocus(value)
+	case "delete-cluster-focus":
+		return applier.deleteClusterFocus(value)
+	case "write-cluster-write-freeze":
+		return applier.writeClusterWriteFreeze(value)
+	case "delete-cluster-write-freeze":
+		return applier.deleteClusterWriteFreeze(value)
+	case "write-recovery-rate-limit":
+		return applier.writeRecoveryRateLimit(value)
+	case "reset-recovery-rate-limit":
+		return applier.resetRecoveryRateLimit(value)
+	case "write-feature-flag":
+		return applier.writeFeatureFlag(value)
+	case "delete-feature-flag":
+		return applier.deleteFeatureFlag(value)
+	case "write-idempotent-response":
+		return applier.writeIdempotentResponse(value)
+	case "delete-idempotent-response":
+		return applier.deleteIdempotentResponse(value)
+	case "write-promotion-plan":
+		return applier.writePromotionPlan(value)
+	case "consume-promotion-plan":
+		return applier.consumePromotionPlan(value)
 	}
 	return log.Errorf("Unknown command op: %s", op)
 }
@@ -230,7 +281,16 @@ func (applier *CommandApplier) writeRecoveryStep(value []byte) interface{} {
 	if err := json.Unmarshal(value, &topologyRecoveryStep); err != nil {
 		return log.Errore(err)
 	}
-	err := writeTopologyRecoveryStep(&topologyRecoveryStep)
+	err := writeTopologyRecoveryStep(context.Background(), &topologyRecoveryStep)
+	return err
+}
+
+func (applier *CommandApplier) writeRecoveryTopology(value []byte) interface{} {
+	topology := []RecoveryTopologyInstance{}
+	if err := json.Unmarshal(value, &topology); err != nil {
+		return log.Errore(err)
+	}
+	err := writeRecoveryTopology(topology)
 	return err
 }
 
@@ -309,3 +369,220 @@ func (applier *CommandApplier) setClusterAliasManualOverride(value []byte) inter
 	err := inst.SetClusterAliasManualOverride(clusterName, alias)
 	return err
 }
+
+func (applier *CommandApplier) writeOperationIntent(value []byte) interface{} {
+	intent := OperationIntent{}
+	if err := json.Unmarshal(value, &intent); err != nil {
+		return log.Errore(err)
+	}
+	err := writeOperationIntent(&intent)
+	return err
+}
+
+func (applier *CommandApplier) claimOperationIntent(value []byte) interface{} {
+	claim := OperationIntentClaim{}
+	if err := json.Unmarshal(value, &claim); err != nil {
+		return log.Errore(err)
+	}
+	claimed, err := claimOperationIntent(&claim)
+	if err != nil {
+		return err
+	}
+	return claimed
+}
+
+func (applier *CommandApplier) importClusterArchive(value []byte) interface{} {
+	var archiveBytes []byte
+	if err := json.Unmarshal(value, &archiveBytes); err != nil {
+		return log.Errore(err)
+	}
+	archive, err := ReadClusterArchive(bytes.NewReader(archiveBytes))
+	if err != nil {
+		return log.Errore(err)
+	}
+	_, err = ImportClusterArchive(archive)
+	return err
+}
+
+func (applier *CommandApplier) writeDRPair(value []byte) interface{} {
+	pair := inst.DRPair{}
+	if err := json.Unmarshal(value, &pair); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WriteDRPair(&pair)
+	return err
+}
+
+func (applier *CommandApplier) deleteDRPair(value []byte) interface{} {
+	var standbyClusterAlias string
+	if err := json.Unmarshal(value, &standbyClusterAlias); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeleteDRPair(standbyClusterAlias)
+	return err
+}
+
+func (applier *CommandApplier) writePollOverride(value []byte) interface{} {
+	override := inst.PollOverride{}
+	if err := json.Unmarshal(value, &override); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WritePollOverride(&override)
+	return err
+}
+
+func (applier *CommandApplier) deletePollOverride(value []byte) interface{} {
+	override := inst.PollOverride{}
+	if err := json.Unmarshal(value, &override); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeletePollOverride(&override)
+	return err
+}
+
+func (applier *CommandApplier) writeReplicaConcurrencyOverride(value []byte) interface{} {
+	override := inst.ReplicaConcurrencyOverride{}
+	if err := json.Unmarshal(value, &override); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WriteReplicaConcurrencyOverride(&override)
+	return err
+}
+
+func (applier *CommandApplier) deleteReplicaConcurrencyOverride(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeleteReplicaConcurrencyOverride(clusterName)
+	return err
+}
+
+func (applier *CommandApplier) writePostponementRelaxation(value []byte) interface{} {
+	relaxation := inst.PostponementRelaxation{}
+	if err := json.Unmarshal(value, &relaxation); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WritePostponementRelaxation(&relaxation)
+	return err
+}
+
+func (applier *CommandApplier) deletePostponementRelaxation(value []byte) interface{} {
+	instanceKey := inst.InstanceKey{}
+	if err := json.Unmarshal(value, &instanceKey); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeletePostponementRelaxation(&instanceKey)
+	return err
+}
+
+func (applier *CommandApplier) writeClusterFocus(value []byte) interface{} {
+	focus := inst.ClusterFocus{}
+	if err := json.Unmarshal(value, &focus); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WriteClusterFocus(&focus)
+	return err
+}
+
+func (applier *CommandApplier) deleteClusterFocus(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeleteClusterFocus(clusterName)
+	return err
+}
+
+func (applier *CommandApplier) writeClusterWriteFreeze(value []byte) interface{} {
+	freeze := inst.ClusterWriteFreeze{}
+	if err := json.Unmarshal(value, &freeze); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WriteClusterWriteFreeze(&freeze)
+	return err
+}
+
+func (applier *CommandApplier) deleteClusterWriteFreeze(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeleteClusterWriteFreeze(clusterName)
+	return err
+}
+
+func (applier *CommandApplier) writeRecoveryRateLimit(value []byte) interface{} {
+	rateLimit := inst.RecoveryRateLimit{}
+	if err := json.Unmarshal(value, &rateLimit); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WriteRecoveryRateLimit(&rateLimit)
+	return err
+}
+
+func (applier *CommandApplier) resetRecoveryRateLimit(value []byte) interface{} {
+	var clusterName string
+	if err := json.Unmarshal(value, &clusterName); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeleteRecoveryRateLimit(clusterName)
+	return err
+}
+
+func (applier *CommandApplier) writeFeatureFlag(value []byte) interface{} {
+	flag := inst.FeatureFlag{}
+	if err := json.Unmarshal(value, &flag); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.WriteFeatureFlag(&flag)
+	return err
+}
+
+func (applier *CommandApplier) deleteFeatureFlag(value []byte) interface{} {
+	flag := inst.FeatureFlag{}
+	if err := json.Unmarshal(value, &flag); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.DeleteFeatureFlag(flag.FeatureName, flag.ClusterName)
+	return err
+}
+
+func (applier *CommandApplier) writeIdempotentResponse(value []byte) interface{} {
+	response := process.IdempotentResponse{}
+	if err := json.Unmarshal(value, &response); err != nil {
+		return log.Errore(err)
+	}
+	err := process.WriteIdempotentResponse(&response)
+	return err
+}
+
+func (applier *CommandApplier) deleteIdempotentResponse(value []byte) interface{} {
+	var requestHash string
+	if err := json.Unmarshal(value, &requestHash); err != nil {
+		return log.Errore(err)
+	}
+	err := process.DeleteIdempotentResponse(requestHash)
+	return err
+}
+
+func (applier *CommandApplier) writePromotionPlan(value []byte) interface{} {
+	plan := PromotionPlan{}
+	if err := json.Unmarshal(value, &plan); err != nil {
+		return log.Errore(err)
+	}
+	err := writePromotionPlan(&plan)
+	return err
+}
+
+func (applier *CommandApplier) consumePromotionPlan(value []byte) interface{} {
+	var token string
+	if err := json.Unmarshal(value, &token); err != nil {
+		return log.Errore(err)
+	}
+	consumed, err := consumePromotionPlan(token)
+	if err != nil {
+		return err
+	}
+	return consumed
+}
@@ -0,0 +1,188 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// publishDRPair persists given DR pair; with raft, this is replicated to all raft members
+func publishDRPair(pair *inst.DRPair) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-dr-pair", pair)
+	} else {
+		err = inst.WriteDRPair(pair)
+	}
+	return err
+}
+
+// RegisterDRPair registers given instance as the main of a standby cluster, which must be replicating
+// directly from the master of its primary cluster.
+func RegisterDRPair(standbyClusterAlias string, standbyMasterKey *inst.InstanceKey) (*inst.DRPair, error) {
+	if standbyClusterAlias == "" {
+		return nil, fmt.Errorf("RegisterDRPair: standby cluster alias must be provided")
+	}
+	standbyMaster, err := inst.ReadTopologyInstance(standbyMasterKey)
+	if err != nil {
+		return nil, err
+	}
+	if standbyMaster.ReplicationDepth != 1 {
+		return nil, fmt.Errorf("RegisterDRPair: %+v must replicate directly from the master of its primary cluster", *standbyMasterKey)
+	}
+	pair := inst.NewDRPair(standbyClusterAlias, standbyMasterKey)
+	if err := publishDRPair(pair); err != nil {
+		return nil, err
+	}
+	inst.AuditOperation("register-dr-pair", standbyMasterKey, fmt.Sprintf("standby cluster %s, replicating from %+v", standbyClusterAlias, standbyMaster.MasterKey))
+	return pair, nil
+}
+
+// RemoveDRPair forgets a DR pair. It does not change replication.
+func RemoveDRPair(standbyClusterAlias string) (err error) {
+	pair, err := inst.ReadDRPair(standbyClusterAlias)
+	if err != nil {
+		return err
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-dr-pair", standbyClusterAlias)
+	} else {
+		err = inst.DeleteDRPair(standbyClusterAlias)
+	}
+	if err != nil {
+		return err
+	}
+	inst.AuditOperation("remove-dr-pair", &pair.StandbyMasterKey, fmt.Sprintf("standby cluster %s", standbyClusterAlias))
+	return nil
+}
+
+// SplitDRPair detaches the standby cluster from its primary and promotes its main: replication from the
+// primary master is detached (reversibly, see reattach-replica-master-host) and the standby main is made
+// writable. The standby then becomes a cluster of its own, aliased by the pair's standby cluster alias.
+func SplitDRPair(standbyClusterAlias string) (*inst.Instance, error) {
+	pair, err := inst.ReadDRPair(standbyClusterAlias)
+	if err != nil {
+		return nil, err
+	}
+	if !pair.IsActive() {
+		return nil, fmt.Errorf("SplitDRPair: DR pair %s is not active", standbyClusterAlias)
+	}
+	standbyMaster, err := inst.DetachReplicaMasterHost(&pair.StandbyMasterKey)
+	if err != nil {
+		return standbyMaster, err
+	}
+	if standbyMaster, err = inst.SetReadOnly(&pair.StandbyMasterKey, false); err != nil {
+		return standbyMaster, err
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("set-cluster-alias-manual-override", []string{standbyMaster.ClusterName, standbyClusterAlias})
+	} else {
+		err = inst.SetClusterAliasManualOverride(standbyMaster.ClusterName, standbyClusterAlias)
+	}
+	if err != nil {
+		log.Errore(err)
+	}
+	pair.State = inst.DRPairSplit
+	if err := publishDRPair(pair); err != nil {
+		return standbyMaster, err
+	}
+	inst.AuditOperation("split-dr-pair", &pair.StandbyMasterKey, fmt.Sprintf("standby cluster %s detached and promoted", standbyClusterAlias))
+	return standbyMaster, nil
+}
+
+// recoverDeadDRStandbyMaster handles the failure of a standby main: one of its replicas is promoted in
+// its place and made to replicate from the primary master, thus keeping the standby cluster intact and
+// the DR edge in place. Siblings of the dead standby main, which belong to the primary cluster, are not
+// considered as replacements.
+func recoverDeadDRStandbyMaster(topologyRecovery *TopologyRecovery, pair *inst.DRPair, skipProcesses bool) (successorInstance *inst.Instance, err error) {
+	topologyRecovery.Type = DRStandbyMasterRecovery
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedInstanceKey := &analysisEntry.AnalyzedInstanceKey
+	primaryMasterKey := &analysisEntry.AnalyzedInstanceMasterKey
+
+	inst.AuditOperation("recover-dead-dr-standby-master", failedInstanceKey, fmt.Sprintf("problem found on main of DR standby cluster %s; will recover", pair.StandbyClusterAlias))
+	if !skipProcesses {
+		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+			return nil, topologyRecovery.AddError(err)
+		}
+	}
+
+	ctx, cancel := recoveryOperationContext(topologyRecovery)
+	defer cancel()
+
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadDRStandbyMaster: %+v is the main of DR standby cluster %s; will promote one of its replicas", *failedInstanceKey, pair.StandbyClusterAlias))
+	lostReplicas, _, _, _, promotedReplica, err := inst.RegroupReplicasContext(ctx, failedInstanceKey, true, nil, nil)
+	if err != nil {
+		topologyRecovery.AddError(err)
+	}
+	if promotedReplica == nil {
+		return nil, topologyRecovery.AddError(log.Errorf("topology_recovery: cannot promote a replica of DR standby main %+v", *failedInstanceKey))
+	}
+	topologyRecovery.ParticipatingInstanceKeys.AddKey(promotedReplica.Key)
+	topologyRecovery.LostReplicas.AddInstances(lostReplicas)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadDRStandbyMaster: regrouped DR standby replicas under %+v, with %d lost replicas", promotedReplica.Key, len(lostReplicas)))
+
+	promotedReplica, err = inst.RelocateBelowContext(ctx, &promotedReplica.Key, primaryMasterKey, true)
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadDRStandbyMaster: DR standby main %+v now replicating from %+v", promotedReplica.Key, *primaryMasterKey))
+
+	pair.StandbyMasterKey = promotedReplica.Key
+	if err := publishDRPair(pair); err != nil {
+		topologyRecovery.AddError(err)
+	}
+	inst.AuditOperation("recover-dead-dr-standby-master", failedInstanceKey, fmt.Sprintf("DR standby cluster %s: promoted %+v", pair.StandbyClusterAlias, promotedReplica.Key))
+	return promotedReplica, nil
+}
+
+// repointDRStandbyMasters is called after a master failover. It makes sure the mains of active DR standby
+// clusters of the failed-over cluster replicate directly from the promoted master.
+func repointDRStandbyMasters(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) {
+	pairs, err := inst.ReadDRPairs()
+	if err != nil {
+		topologyRecovery.AddError(err)
+		return
+	}
+	for _, pair := range pairs {
+		if !pair.IsActive() {
+			continue
+		}
+		standbyMaster, _, err := inst.ReadInstance(&pair.StandbyMasterKey)
+		if err != nil || standbyMaster == nil {
+			continue
+		}
+		if standbyMaster.ClusterName != topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName {
+			continue
+		}
+		if standbyMaster.MasterKey.Equals(&promotedReplica.Key) {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: DR standby main %+v already replicates from %+v", pair.StandbyMasterKey, promotedReplica.Key))
+			continue
+		}
+		if _, err := inst.RelocateBelow(&pair.StandbyMasterKey, &promotedReplica.Key, true); err != nil {
+			topologyRecovery.AddError(err)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: failed relocating DR standby main %+v below %+v: %+v", pair.StandbyMasterKey, promotedReplica.Key, err))
+			continue
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: DR standby main %+v relocated below %+v", pair.StandbyMasterKey, promotedReplica.Key))
+	}
+}
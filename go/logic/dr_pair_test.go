@@ -0,0 +1,41 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/github/orchestrator/go/events"
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestRecoverDeadDRStandbyMasterLabels(t *testing.T) {
+	subscription := events.Subscribe(100)
+	defer subscription.Close()
+
+	analysisEntry := inst.ReplicationAnalysis{
+		AnalyzedInstanceKey:       inst.InstanceKey{Hostname: "standby-main", Port: 3306},
+		AnalyzedInstanceMasterKey: inst.InstanceKey{Hostname: "primary-master", Port: 3306},
+		Analysis:                  inst.DeadIntermediateMaster,
+	}
+	topologyRecovery := NewTopologyRecovery(analysisEntry)
+	topologyRecovery.Type = IntermediateMasterRecovery
+	pair := inst.NewDRPair("standby", &analysisEntry.AnalyzedInstanceKey)
+
+	// There is no backend in this test, hence no replica can be promoted
+	successorInstance, err := recoverDeadDRStandbyMaster(topologyRecovery, pair, true)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectTrue(successorInstance == nil)
+	test.S(t).ExpectEquals(topologyRecovery.Type, RecoveryType(DRStandbyMasterRecovery))
+
+	recoverySteps := 0
+	for len(subscription.Events) > 0 {
+		event := <-subscription.Events
+		if event.Type != "recovery-step" {
+			continue
+		}
+		recoverySteps++
+		test.S(t).ExpectTrue(strings.HasPrefix(event.Message, "- RecoverDeadDRStandbyMaster: "))
+	}
+	test.S(t).ExpectTrue(recoverySteps > 0)
+}
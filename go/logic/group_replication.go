@@ -0,0 +1,253 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/github/orchestrator/go/attributes"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+)
+
+// masterFailureAnalysisCode returns the analysis to assume when forcing a failover of given cluster master
+func masterFailureAnalysisCode(clusterMaster *inst.Instance) inst.AnalysisCode {
+	if clusterMaster.IsReplicationGroupMember() {
+		return inst.DeadReplicationGroupPrimary
+	}
+	return inst.DeadMaster
+}
+
+// waitForReplicationGroupPrimary polls the surviving members of the failed primary's group until one of them
+// is seen as the group's new primary. In multi-primary mode, any online member will do.
+func waitForReplicationGroupPrimary(failedPrimary *inst.Instance) (*inst.Instance, error) {
+	memberKeys := failedPrimary.ReplicationGroupMembers.GetInstanceKeys()
+	sort.Slice(memberKeys, func(i, j int) bool { return memberKeys[i].SmallerThan(&memberKeys[j]) })
+
+	waitDuration := time.Duration(config.Config.ReplicationGroupElectionWaitSeconds) * time.Second
+	startTime := time.Now()
+	for {
+		for _, memberKey := range memberKeys {
+			memberKey := memberKey
+			if memberKey.Equals(&failedPrimary.Key) {
+				continue
+			}
+			member, err := inst.ReadTopologyInstance(&memberKey)
+			if err != nil || member == nil || !member.IsReplicationGroupPeerOf(failedPrimary) {
+				continue
+			}
+			if failedPrimary.ReplicationGroupIsSinglePrimary && member.IsReplicationGroupPrimary() {
+				return member, nil
+			}
+			if !failedPrimary.ReplicationGroupIsSinglePrimary && member.ReplicationGroupMemberState == inst.GroupReplicationMemberStateOnline {
+				return member, nil
+			}
+		}
+		if time.Since(startTime) >= waitDuration {
+			break
+		}
+		time.Sleep(time.Duration(config.RecoveryPollSeconds) * time.Second)
+	}
+	return nil, fmt.Errorf("replication group %s did not elect a new primary within %+v", failedPrimary.ReplicationGroupName, waitDuration)
+}
+
+// recoverDeadReplicationGroupPrimary does not promote a server by itself: a replication group elects its own
+// new primary. When a candidate is given, it is elected by means of group_replication_set_as_primary; otherwise
+// the group's own election is awaited. Asynchronous replicas of the failed primary are then relocated below
+// the new primary. No CHANGE MASTER is issued within the group.
+func recoverDeadReplicationGroupPrimary(topologyRecovery *TopologyRecovery, candidateInstanceKey *inst.InstanceKey, skipProcesses bool) (promotedPrimary *inst.Instance, lostReplicas [](*inst.Instance), err error) {
+	topologyRecovery.Type = MasterRecovery
+	topologyRecovery.RecoveryType = MasterRecoveryGroupReplication
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedInstanceKey := &analysisEntry.AnalyzedInstanceKey
+
+	inst.AuditOperation("recover-dead-replication-group-primary", failedInstanceKey, "problem found; will recover")
+	if !skipProcesses {
+		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+			return nil, lostReplicas, topologyRecovery.AddError(err)
+		}
+	}
+	failedPrimary, _, err := inst.ReadInstance(failedInstanceKey)
+	if err != nil {
+		return nil, lostReplicas, topologyRecovery.AddError(err)
+	}
+	if failedPrimary == nil || !failedPrimary.IsReplicationGroupMember() {
+		return nil, lostReplicas, topologyRecovery.AddError(fmt.Errorf("RecoverDeadReplicationGroupPrimary: %+v is not known to be a replication group member", *failedInstanceKey))
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadReplicationGroupPrimary: will recover %+v, primary of replication group %s", *failedInstanceKey, failedPrimary.ReplicationGroupName))
+
+	if candidateInstanceKey != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadReplicationGroupPrimary: electing %+v as group primary", *candidateInstanceKey))
+		if _, err := inst.SetReplicationGroupPrimary(candidateInstanceKey); err != nil {
+			// The group may yet elect a primary of its own
+			topologyRecovery.AddError(err)
+		}
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadReplicationGroupPrimary: waiting up to %ds for group primary election", config.Config.ReplicationGroupElectionWaitSeconds))
+	promotedPrimary, err = waitForReplicationGroupPrimary(failedPrimary)
+	if err != nil {
+		inst.AuditOperation("recover-dead-replication-group-primary", failedInstanceKey, "Failure: no primary elected.")
+		return nil, lostReplicas, topologyRecovery.AddError(err)
+	}
+	topologyRecovery.ParticipatingInstanceKeys.AddKey(promotedPrimary.Key)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadReplicationGroupPrimary: group elected %+v", promotedPrimary.Key))
+
+	replicas, err := inst.ReadReplicaInstances(failedInstanceKey)
+	if err != nil {
+		topologyRecovery.AddError(err)
+	}
+	for _, replica := range replicas {
+		if _, err := inst.RelocateBelow(&replica.Key, &promotedPrimary.Key, true); err != nil {
+			topologyRecovery.AddError(err)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadReplicationGroupPrimary: - lost replica: %+v: %+v", replica.Key, err))
+			lostReplicas = append(lostReplicas, replica)
+			continue
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadReplicationGroupPrimary: relocated %+v below %+v", replica.Key, promotedPrimary.Key))
+	}
+
+	if isReplicationGroupPrimaryLostInRecovery(analysisEntry) {
+		inst.BeginDowntime(inst.NewDowntime(failedInstanceKey, inst.GetMaintenanceOwner(), inst.DowntimeLostInRecoveryMessage, time.Duration(config.LostInRecoveryDowntimeSeconds)*time.Second))
+		acknowledgeInstanceFailureDetection(failedInstanceKey)
+	}
+	for _, replica := range lostReplicas {
+		inst.BeginDowntime(inst.NewDowntime(&replica.Key, inst.GetMaintenanceOwner(), inst.DowntimeLostInRecoveryMessage, time.Duration(config.LostInRecoveryDowntimeSeconds)*time.Second))
+	}
+	inst.AuditOperation("recover-dead-replication-group-primary", failedInstanceKey, fmt.Sprintf("elected primary: %+v", promotedPrimary.Key))
+	return promotedPrimary, lostReplicas, nil
+}
+
+// isReplicationGroupPrimaryLostInRecovery returns true when the former group primary is to be downtimed and its
+// failure acknowledged. This is not the case on graceful takeover, where the former primary remains a healthy
+// member of the group.
+func isReplicationGroupPrimaryLostInRecovery(analysisEntry *inst.ReplicationAnalysis) bool {
+	return analysisEntry.CommandHint != inst.GracefulMasterTakeoverCommandHint
+}
+
+func checkAndRecoverDeadReplicationGroupPrimary(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
+	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedMasterRecovery) {
+		return false, nil, nil
+	}
+	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
+	if topologyRecovery == nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another RecoverDeadReplicationGroupPrimary.", analysisEntry.AnalyzedInstanceKey))
+		return false, nil, err
+	}
+
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("will handle DeadReplicationGroupPrimary event on %+v", analysisEntry.ClusterDetails.ClusterName))
+	recoverDeadMasterCounter.Inc(1)
+	promotedPrimary, lostReplicas, err := recoverDeadReplicationGroupPrimary(topologyRecovery, candidateInstanceKey, skipProcesses)
+	topologyRecovery.LostReplicas.AddInstances(lostReplicas)
+
+	resolveRecovery(topologyRecovery, promotedPrimary)
+	if promotedPrimary == nil {
+		recoverDeadMasterFailureCounter.Inc(1)
+		return true, topologyRecovery, err
+	}
+	if satisfied, reason := MasterFailoverGeographicConstraintSatisfied(&analysisEntry, promotedPrimary); !satisfied {
+		// The group has already elected its primary; there is nothing to undo
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadReplicationGroupPrimary: NOTE that elected primary %+v does not satisfy constraint: %s", promotedPrimary.Key, reason))
+	}
+	recoverDeadMasterSuccessCounter.Inc(1)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadReplicationGroupPrimary: successfully elected %+v", promotedPrimary.Key))
+
+	writeClusterMasterKVPairs(topologyRecovery, promotedPrimary)
+	repointDRStandbyMasters(topologyRecovery, promotedPrimary)
+	replaceFailedMasterClusterName(topologyRecovery, promotedPrimary)
+	attributes.SetGeneralAttribute(analysisEntry.ClusterDetails.ClusterDomain, promotedPrimary.Key.StringCode())
+
+	if !skipProcesses {
+		executeProcesses(config.Config.PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
+	}
+	return true, topologyRecovery, err
+}
+
+// gracefulReplicationGroupPrimaryTakeover is the replication group flavor of GracefulMasterTakeover: the
+// designated group member is elected as primary. The demoted primary remains in the group as a secondary,
+// and asynchronous replicas of the demoted primary are relocated below the new primary.
+func gracefulReplicationGroupPrimaryTakeover(ctx context.Context, clusterName string, clusterMaster *inst.Instance, designatedKey *inst.InstanceKey) (topologyRecovery *TopologyRecovery, promotedMasterCoordinates *inst.BinlogCoordinates, err error) {
+	members, err := inst.ReadReplicationGroupMembers(clusterMaster)
+	if err != nil {
+		return nil, nil, log.Errore(err)
+	}
+	onlineMembers := [](*inst.Instance){}
+	for _, member := range members {
+		if member.ReplicationGroupMemberState == inst.GroupReplicationMemberStateOnline {
+			onlineMembers = append(onlineMembers, member)
+		}
+	}
+	if designatedKey != nil && !designatedKey.IsValid() {
+		designatedKey = nil
+	}
+	var designatedInstance *inst.Instance
+	if designatedKey == nil {
+		if len(onlineMembers) != 1 {
+			return nil, nil, fmt.Errorf("When no target instance indicated, replication group of %+v should have a single online secondary, but has %+v. Aborting", clusterMaster.Key, len(onlineMembers))
+		}
+		designatedInstance = onlineMembers[0]
+		log.Infof("GracefulMasterTakeover: designated group primary deduced to be %+v", designatedInstance.Key)
+	} else {
+		for _, member := range onlineMembers {
+			if member.Key.Equals(designatedKey) {
+				designatedInstance = member
+			}
+		}
+		if designatedInstance == nil {
+			return nil, nil, fmt.Errorf("GracefulMasterTakeover: indicated designated instance %+v must be an online member of the replication group of %+v", *designatedKey, clusterMaster.Key)
+		}
+	}
+	if inst.IsBannedFromBeingCandidateReplica(designatedInstance) {
+		return nil, nil, fmt.Errorf("GracefulMasterTakeover: designated instance %+v cannot be promoted due to promotion rule or it is explicitly ignored in PromotionIgnoreHostnameFilters configuration", designatedInstance.Key)
+	}
+
+	analysisEntry, err := forceAnalysisEntry(ctx, clusterName, inst.DeadReplicationGroupPrimary, inst.GracefulMasterTakeoverCommandHint, &clusterMaster.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	preGracefulTakeoverTopologyRecovery := &TopologyRecovery{
+		AnalysisEntry: analysisEntry,
+	}
+	if err := executeProcesses(config.Config.PreGracefulTakeoverProcesses, "PreGracefulTakeoverProcesses", preGracefulTakeoverTopologyRecovery, true); err != nil {
+		return nil, nil, fmt.Errorf("Failed running PreGracefulTakeoverProcesses: %+v", err)
+	}
+	log.Infof("GracefulMasterTakeover: Will elect %+v as primary of replication group %s", designatedInstance.Key, clusterMaster.ReplicationGroupName)
+
+	recoveryAttempted, topologyRecovery, err := ForceExecuteRecovery(analysisEntry, &designatedInstance.Key, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !recoveryAttempted {
+		return nil, nil, fmt.Errorf("Unexpected error: recovery not attempted. This should not happen")
+	}
+	if topologyRecovery == nil {
+		return nil, nil, fmt.Errorf("Recovery attempted but with no results. This should not happen")
+	}
+	if topologyRecovery.SuccessorKey == nil {
+		return nil, nil, fmt.Errorf("Recovery attempted yet no group primary elected")
+	}
+	if promotedPrimary, _, _ := inst.ReadInstance(topologyRecovery.SuccessorKey); promotedPrimary != nil {
+		promotedMasterCoordinates = &promotedPrimary.SelfBinlogCoordinates
+	}
+	executeProcesses(config.Config.PostGracefulTakeoverProcesses, "PostGracefulTakeoverProcesses", topologyRecovery, false)
+
+	return topologyRecovery, promotedMasterCoordinates, nil
+}
@@ -0,0 +1,19 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestIsReplicationGroupPrimaryLostInRecovery(t *testing.T) {
+	analysisEntry := &inst.ReplicationAnalysis{Analysis: inst.DeadReplicationGroupPrimary}
+	test.S(t).ExpectTrue(isReplicationGroupPrimaryLostInRecovery(analysisEntry))
+
+	analysisEntry.CommandHint = inst.ForceMasterFailoverCommandHint
+	test.S(t).ExpectTrue(isReplicationGroupPrimaryLostInRecovery(analysisEntry))
+
+	analysisEntry.CommandHint = inst.GracefulMasterTakeoverCommandHint
+	test.S(t).ExpectFalse(isReplicationGroupPrimaryLostInRecovery(analysisEntry))
+}
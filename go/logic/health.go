@@ -0,0 +1,235 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+	"github.com/github/orchestrator/go/raft"
+)
+
+// HealthReportSchemaVersion is the version of the HealthReport schema. It is only incremented upon
+// incompatible changes; fields may be added within a version.
+const HealthReportSchemaVersion = 1
+
+// hookFailureHealthWindow is the time during which a failed recovery hook is reported as a warning
+const hookFailureHealthWindow = 10 * time.Minute
+
+type HealthSeverity string
+
+const (
+	HealthSeverityOK       HealthSeverity = "ok"
+	HealthSeverityWarning                 = "warning"
+	HealthSeverityCritical                = "critical"
+)
+
+var healthSeverityRank = map[HealthSeverity]int{
+	HealthSeverityOK:       0,
+	HealthSeverityWarning:  1,
+	HealthSeverityCritical: 2,
+}
+
+// ComponentHealth is the health of a single component of this node
+type ComponentHealth struct {
+	Component string
+	Severity  HealthSeverity
+	Message   string
+	Details   map[string]interface{}
+}
+
+// HealthReport is the structured health of this node, as served by /api/health/v1
+type HealthReport struct {
+	SchemaVersion int
+	Hostname      string
+	AppVersion    string
+	Severity      HealthSeverity
+	Healthy       bool
+	IsActiveNode  bool
+	Components    []ComponentHealth
+	GeneratedAt   time.Time
+}
+
+var runningHooksCount int64
+var lastHookFailureUnixNano int64
+
+func newComponentHealth(component string) ComponentHealth {
+	return ComponentHealth{Component: component, Severity: HealthSeverityOK, Details: make(map[string]interface{})}
+}
+
+func backendComponentHealth() ComponentHealth {
+	component := newComponentHealth("backend")
+	health, err := process.HealthTest()
+	if err != nil {
+		component.Severity = HealthSeverityCritical
+		component.Message = err.Error()
+		return component
+	}
+	component.Details["DBBackend"] = process.ThisNodeHealth.DBBackend
+	if !health.Healthy {
+		component.Severity = HealthSeverityCritical
+		component.Message = "cannot register node in backend database"
+	}
+	return component
+}
+
+func raftComponentHealth() ComponentHealth {
+	component := newComponentHealth("raft")
+	component.Details["Enabled"] = orcraft.IsRaftEnabled()
+	if !orcraft.IsRaftEnabled() {
+		return component
+	}
+	component.Details["Leader"] = orcraft.GetLeader()
+	component.Details["IsLeader"] = orcraft.IsLeader()
+	component.Details["HealthyMembers"] = orcraft.HealthyMembers()
+	if orcraft.GetLeader() == "" {
+		component.Severity = HealthSeverityCritical
+		component.Message = "no raft leader"
+	} else if !orcraft.IsHealthy() {
+		component.Severity = HealthSeverityCritical
+		component.Message = "node is not part of a healthy raft group"
+	}
+	return component
+}
+
+func discoveryComponentHealth() ComponentHealth {
+	component := newComponentHealth("discovery")
+	if discoveryQueue == nil {
+		component.Details["QueueLength"] = 0
+		return component
+	}
+	queueLength := discoveryQueue.QueueLen()
+	capacity := int(config.Config.DiscoveryQueueCapacity)
+	component.Details["QueueLength"] = queueLength
+	component.Details["QueueCapacity"] = capacity
+	if capacity > 0 && queueLength >= capacity {
+		component.Severity = HealthSeverityCritical
+		component.Message = "discovery queue is full"
+	} else if capacity > 0 && queueLength*2 >= capacity {
+		component.Severity = HealthSeverityWarning
+		component.Message = "discovery queue is over half full"
+	}
+	return component
+}
+
+func hooksComponentHealth() ComponentHealth {
+	component := newComponentHealth("hooks")
+	component.Details["Running"] = atomic.LoadInt64(&runningHooksCount)
+	if lastFailure := atomic.LoadInt64(&lastHookFailureUnixNano); lastFailure > 0 {
+		sinceLastFailure := time.Since(time.Unix(0, lastFailure))
+		component.Details["SecondsSinceLastFailure"] = int64(sinceLastFailure.Seconds())
+		if sinceLastFailure < hookFailureHealthWindow {
+			component.Severity = HealthSeverityWarning
+			component.Message = fmt.Sprintf("a recovery hook failed %s ago", sinceLastFailure.Round(time.Second))
+		}
+	}
+	return component
+}
+
+func versionComponentHealth() ComponentHealth {
+	component := newComponentHealth("version")
+	skew, err := process.ReadVersionSkew()
+	if err != nil {
+		component.Severity = HealthSeverityWarning
+		component.Message = err.Error()
+		return component
+	}
+	component.Details["Versions"] = skew.Versions
+	if skew.Skewed {
+		component.Severity = HealthSeverityWarning
+		component.Message = fmt.Sprintf("orchestrator nodes run different versions: %s", skew.String())
+	}
+	return component
+}
+
+// stalenessPercentile returns the given percentile (nearest rank) of given sorted values
+func stalenessPercentile(sorted []int64, percentile float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func pollingComponentHealth() ComponentHealth {
+	component := newComponentHealth("polling")
+	if !IsLeaderOrActive() {
+		component.Message = "node is not polling instances"
+		return component
+	}
+	staleness, err := inst.ReadInstancesPollStaleness()
+	if err != nil {
+		component.Severity = HealthSeverityCritical
+		component.Message = err.Error()
+		return component
+	}
+	sort.Slice(staleness, func(i, j int) bool { return staleness[i] < staleness[j] })
+	p50 := stalenessPercentile(staleness, 50)
+	p95 := stalenessPercentile(staleness, 95)
+	p99 := stalenessPercentile(staleness, 99)
+	component.Details["Instances"] = len(staleness)
+	component.Details["StalenessSecondsP50"] = p50
+	component.Details["StalenessSecondsP95"] = p95
+	component.Details["StalenessSecondsP99"] = p99
+
+	pollSeconds := int64(config.Config.InstancePollSeconds)
+	if p50 > 5*pollSeconds {
+		component.Severity = HealthSeverityCritical
+		component.Message = fmt.Sprintf("median instance staleness is %ds", p50)
+	} else if p95 > 2*pollSeconds {
+		component.Severity = HealthSeverityWarning
+		component.Message = fmt.Sprintf("95th percentile instance staleness is %ds", p95)
+	}
+	return component
+}
+
+// ReadHealthReport evaluates the health of this node's components. The report's severity is that of its
+// most severe component. The node is healthy unless some component is critical.
+func ReadHealthReport() *HealthReport {
+	report := &HealthReport{
+		SchemaVersion: HealthReportSchemaVersion,
+		Hostname:      process.ThisHostname,
+		AppVersion:    config.RuntimeCLIFlags.ConfiguredVersion,
+		Severity:      HealthSeverityOK,
+		IsActiveNode:  IsLeaderOrActive(),
+		GeneratedAt:   time.Now(),
+	}
+	report.Components = []ComponentHealth{
+		backendComponentHealth(),
+		raftComponentHealth(),
+		discoveryComponentHealth(),
+		hooksComponentHealth(),
+		pollingComponentHealth(),
+		versionComponentHealth(),
+	}
+	for _, component := range report.Components {
+		if healthSeverityRank[component.Severity] > healthSeverityRank[report.Severity] {
+			report.Severity = component.Severity
+		}
+	}
+	report.Healthy = (report.Severity != HealthSeverityCritical)
+	return report
+}
@@ -0,0 +1,189 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	goos "os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/events"
+	"github.com/github/orchestrator/go/os"
+
+	"github.com/openark/golib/log"
+)
+
+const (
+	HookDeliveryKindExec    = "exec"
+	HookDeliveryKindWebhook = "webhook"
+)
+
+// hookDeliveryEnvironmentPrefix marks the environment variables of a recovery hook which are persisted with a
+// queued delivery; the rest of the environment is that of the retrying process
+const hookDeliveryEnvironmentPrefix = "ORC_"
+
+var hookDeliveriesRunning int64
+
+// HookDelivery is a failed delivery of a recovery hook or an event webhook, queued for retry. Once it has
+// failed HookRetryMaxAttempts times it is dead: no longer retried, but listed until expired.
+type HookDelivery struct {
+	DeliveryId            int64
+	Kind                  string
+	Description           string
+	Target                string
+	Payload               string
+	RecoveryUID           string
+	Attempts              uint
+	LastError             string
+	IsDead                bool
+	FirstFailureTimestamp string
+	LastAttemptTimestamp  string
+	NextAttemptTimestamp  string
+}
+
+// hookRetryBackoff returns the delay following given number of failed attempts: the initial backoff, doubling with
+// each further attempt, up to the maximum backoff
+func hookRetryBackoff(attempts uint, initialBackoffSeconds uint, maxBackoffSeconds uint) time.Duration {
+	backoff := time.Duration(initialBackoffSeconds) * time.Second
+	maxBackoff := time.Duration(maxBackoffSeconds) * time.Second
+	for i := uint(1); i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// hookRetryEnabled returns true when failed deliveries are to be queued: a single attempt leaves nothing to retry
+func hookRetryEnabled() bool {
+	return config.Config.HookRetryMaxAttempts > 1
+}
+
+// isHookDeliveryQueueOwner returns true when this node queues and retries failed hook deliveries. Only the leader
+// does: a hook or webhook failing on several nodes is then queued, and retried, once.
+func isHookDeliveryQueueOwner() bool {
+	return hookRetryEnabled() && IsLeader()
+}
+
+// enqueueFailedExecHook queues a failed recovery hook for retry, along with its ORC_* environment
+func enqueueFailedExecHook(description string, command string, env []string, topologyRecovery *TopologyRecovery, cmdErr error) {
+	if !isHookDeliveryQueueOwner() {
+		return
+	}
+	hookEnv := []string{}
+	for _, entry := range env {
+		if strings.HasPrefix(entry, hookDeliveryEnvironmentPrefix) {
+			hookEnv = append(hookEnv, entry)
+		}
+	}
+	payload, err := json.Marshal(hookEnv)
+	if err != nil {
+		log.Errore(err)
+		return
+	}
+	delivery := &HookDelivery{
+		Kind:        HookDeliveryKindExec,
+		Description: description,
+		Target:      command,
+		Payload:     string(payload),
+		RecoveryUID: topologyRecovery.UID,
+		LastError:   cmdErr.Error(),
+	}
+	if err := writeFailedHookDelivery(delivery); err == nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Queued %s for retry", description))
+	}
+}
+
+// enqueueFailedWebhook queues an event which failed posting to a webhook for retry. It is an events.WebhookFailureHandler
+func enqueueFailedWebhook(url string, body []byte, postErr error) {
+	if !isHookDeliveryQueueOwner() {
+		return
+	}
+	delivery := &HookDelivery{
+		Kind:        HookDeliveryKindWebhook,
+		Description: "event webhook",
+		Target:      url,
+		Payload:     string(body),
+		LastError:   postErr.Error(),
+	}
+	writeFailedHookDelivery(delivery)
+}
+
+// deliverHook makes a single attempt at delivering a queued hook
+func deliverHook(delivery *HookDelivery) error {
+	switch delivery.Kind {
+	case HookDeliveryKindExec:
+		hookEnv := []string{}
+		if err := json.Unmarshal([]byte(delivery.Payload), &hookEnv); err != nil {
+			return err
+		}
+		return os.CommandRun(delivery.Target, append(goos.Environ(), hookEnv...))
+	case HookDeliveryKindWebhook:
+		return events.PostWebhook(delivery.Target, []byte(delivery.Payload))
+	}
+	return fmt.Errorf("Unknown hook delivery kind: %s", delivery.Kind)
+}
+
+// attemptHookDelivery makes a single attempt at delivering a queued hook via given deliver function, accounting for
+// it: on failure, the delivery is dead once it has had given number of attempts. Returns true when delivered.
+func attemptHookDelivery(delivery *HookDelivery, deliver func(*HookDelivery) error, maxAttempts uint) (delivered bool) {
+	delivery.Attempts++
+	deliveryErr := deliver(delivery)
+	if deliveryErr == nil {
+		log.Infof("hook delivery: %s %d to %s succeeded on attempt %d", delivery.Description, delivery.DeliveryId, delivery.Target, delivery.Attempts)
+		return true
+	}
+	delivery.LastError = deliveryErr.Error()
+	delivery.IsDead = delivery.Attempts >= maxAttempts
+	if delivery.IsDead {
+		log.Errorf("hook delivery: %s %d to %s failed its last attempt %d and is dead: %+v", delivery.Description, delivery.DeliveryId, delivery.Target, delivery.Attempts, deliveryErr)
+	} else {
+		log.Warningf("hook delivery: %s %d to %s failed attempt %d: %+v", delivery.Description, delivery.DeliveryId, delivery.Target, delivery.Attempts, deliveryErr)
+	}
+	return false
+}
+
+// DeliverDueHooks retries queued hook deliveries whose backoff has elapsed, up to HookRetryMaxDeliveriesPerSecond
+// of them. Delivered hooks are removed from the queue; hooks failing their last attempt are dead-lettered.
+// Outcomes are logged rather than audited, as audit events are themselves posted to webhooks.
+func DeliverDueHooks() {
+	if !isHookDeliveryQueueOwner() {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&hookDeliveriesRunning, 0, 1) {
+		// Previous round still running
+		return
+	}
+	defer atomic.StoreInt64(&hookDeliveriesRunning, 0)
+
+	deliveries, err := readDueHookDeliveries(config.Config.HookRetryMaxDeliveriesPerSecond)
+	if err != nil {
+		return
+	}
+	for _, delivery := range deliveries {
+		if attemptHookDelivery(delivery, deliverHook, config.Config.HookRetryMaxAttempts) {
+			deleteHookDelivery(delivery.DeliveryId)
+		} else {
+			updateFailedHookDelivery(delivery)
+		}
+	}
+}
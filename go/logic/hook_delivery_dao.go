@@ -0,0 +1,162 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// writeFailedHookDelivery queues a hook delivery which failed its first attempt
+func writeFailedHookDelivery(delivery *HookDelivery) error {
+	backoff := hookRetryBackoff(1, config.Config.HookRetryInitialBackoffSeconds, config.Config.HookRetryMaxBackoffSeconds)
+	_, err := db.ExecOrchestrator(`
+			insert into hook_delivery (
+				hook_kind, description, target, payload, recovery_uid, attempts, last_error, is_dead,
+				first_failure_timestamp, last_attempt_timestamp, next_attempt_timestamp
+			) values (
+				?, ?, ?, ?, ?, 1, ?, 0,
+				NOW(), NOW(), NOW() + INTERVAL ? SECOND
+			)
+		`, delivery.Kind, delivery.Description, delivery.Target, delivery.Payload, delivery.RecoveryUID, delivery.LastError, int64(backoff.Seconds()),
+	)
+	return log.Errore(err)
+}
+
+// updateFailedHookDelivery records a failed retry of a queued hook delivery, scheduling its next attempt unless dead
+func updateFailedHookDelivery(delivery *HookDelivery) error {
+	backoff := hookRetryBackoff(delivery.Attempts, config.Config.HookRetryInitialBackoffSeconds, config.Config.HookRetryMaxBackoffSeconds)
+	_, err := db.ExecOrchestrator(`
+			update hook_delivery set
+				attempts = ?,
+				last_error = ?,
+				is_dead = ?,
+				last_attempt_timestamp = NOW(),
+				next_attempt_timestamp = NOW() + INTERVAL ? SECOND
+			where
+				delivery_id = ?
+		`, delivery.Attempts, delivery.LastError, delivery.IsDead, int64(backoff.Seconds()), delivery.DeliveryId,
+	)
+	return log.Errore(err)
+}
+
+// deleteHookDelivery removes a delivered hook from the queue
+func deleteHookDelivery(deliveryId int64) error {
+	_, err := db.ExecOrchestrator(`
+			delete from hook_delivery where delivery_id = ?
+		`, deliveryId,
+	)
+	return log.Errore(err)
+}
+
+// RetryHookDelivery revives a dead, or expedites a pending, hook delivery: it is due at once, with a fresh
+// allowance of attempts
+func RetryHookDelivery(deliveryId int64) error {
+	sqlResult, err := db.ExecOrchestrator(`
+			update hook_delivery set
+				attempts = 0,
+				is_dead = 0,
+				next_attempt_timestamp = NOW()
+			where
+				delivery_id = ?
+		`, deliveryId,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	rows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return log.Errore(err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("RetryHookDelivery: delivery %d not found", deliveryId)
+	}
+	return nil
+}
+
+func readHookDeliveries(whereCondition string, limit string, args []interface{}) ([]*HookDelivery, error) {
+	res := []*HookDelivery{}
+	query := fmt.Sprintf(`
+		select
+			delivery_id,
+			hook_kind,
+			description,
+			target,
+			payload,
+			recovery_uid,
+			attempts,
+			last_error,
+			is_dead,
+			first_failure_timestamp,
+			last_attempt_timestamp,
+			next_attempt_timestamp
+		from
+			hook_delivery
+		%s
+		order by
+			delivery_id
+		%s
+		`, whereCondition, limit)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		res = append(res, &HookDelivery{
+			DeliveryId:            m.GetInt64("delivery_id"),
+			Kind:                  m.GetString("hook_kind"),
+			Description:           m.GetString("description"),
+			Target:                m.GetString("target"),
+			Payload:               m.GetString("payload"),
+			RecoveryUID:           m.GetString("recovery_uid"),
+			Attempts:              m.GetUint("attempts"),
+			LastError:             m.GetString("last_error"),
+			IsDead:                m.GetBool("is_dead"),
+			FirstFailureTimestamp: m.GetString("first_failure_timestamp"),
+			LastAttemptTimestamp:  m.GetString("last_attempt_timestamp"),
+			NextAttemptTimestamp:  m.GetString("next_attempt_timestamp"),
+		})
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// readDueHookDeliveries reads up to given number of pending hook deliveries whose next attempt is due
+func readDueHookDeliveries(limit uint) ([]*HookDelivery, error) {
+	whereCondition := `
+		where
+			is_dead = 0
+			and next_attempt_timestamp <= NOW()
+		`
+	return readHookDeliveries(whereCondition, `limit ?`, sqlutils.Args(limit))
+}
+
+// ReadHookDeliveries reads the queued hook deliveries; only the dead ones when deadOnly
+func ReadHookDeliveries(deadOnly bool) ([]*HookDelivery, error) {
+	whereCondition := ""
+	if deadOnly {
+		whereCondition = `where is_dead = 1`
+	}
+	return readHookDeliveries(whereCondition, "", sqlutils.Args())
+}
+
+// ExpireHookDeliveries removes hook deliveries not attempted in AuditPurgeDays, dead or otherwise
+func ExpireHookDeliveries() error {
+	return inst.ExpireTableData("hook_delivery", "last_attempt_timestamp")
+}
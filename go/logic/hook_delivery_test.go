@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestHookRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name                  string
+		attempts              uint
+		initialBackoffSeconds uint
+		maxBackoffSeconds     uint
+		expected              time.Duration
+	}{
+		{"no attempts yet", 0, 5, 600, 5 * time.Second},
+		{"first attempt", 1, 5, 600, 5 * time.Second},
+		{"second attempt", 2, 5, 600, 10 * time.Second},
+		{"third attempt", 3, 5, 600, 20 * time.Second},
+		{"seventh attempt", 7, 5, 600, 320 * time.Second},
+		{"capped", 8, 5, 600, 600 * time.Second},
+		{"far beyond cap", 1000, 5, 600, 600 * time.Second},
+		{"initial above cap", 1, 10, 5, 5 * time.Second},
+		{"no backoff", 3, 0, 600, 0},
+		{"initial equals cap", 4, 60, 60, 60 * time.Second},
+	}
+	for _, tt := range tests {
+		test.S(t).ExpectEquals(hookRetryBackoff(tt.attempts, tt.initialBackoffSeconds, tt.maxBackoffSeconds), tt.expected)
+	}
+}
+
+func TestAttemptHookDelivery(t *testing.T) {
+	failing := func(delivery *HookDelivery) error { return fmt.Errorf("attempt %d failed", delivery.Attempts) }
+	succeeding := func(delivery *HookDelivery) error { return nil }
+
+	tests := []struct {
+		name              string
+		attempts          uint
+		deliver           func(*HookDelivery) error
+		expectedDelivered bool
+		expectedAttempts  uint
+		expectedDead      bool
+		expectedLastError string
+	}{
+		{"success", 1, succeeding, true, 2, false, "original"},
+		{"failure, attempts left", 1, failing, false, 2, false, "attempt 2 failed"},
+		{"failure, last attempt", 2, failing, false, 3, true, "attempt 3 failed"},
+		{"success on last attempt", 2, succeeding, true, 3, false, "original"},
+		{"revived delivery fails", 0, failing, false, 1, false, "attempt 1 failed"},
+	}
+	for _, tt := range tests {
+		delivery := &HookDelivery{DeliveryId: 1, Description: "test", Attempts: tt.attempts, LastError: "original"}
+		delivered := attemptHookDelivery(delivery, tt.deliver, 3)
+		test.S(t).ExpectEquals(delivered, tt.expectedDelivered)
+		test.S(t).ExpectEquals(delivery.Attempts, tt.expectedAttempts)
+		test.S(t).ExpectEquals(delivery.IsDead, tt.expectedDead)
+		test.S(t).ExpectEquals(delivery.LastError, tt.expectedLastError)
+	}
+}
+
+func TestAttemptHookDeliveryGivesUp(t *testing.T) {
+	// The original attempt failed when queued; retries follow until the allowance of attempts is used up
+	delivery := &HookDelivery{DeliveryId: 1, Description: "test", Attempts: 1}
+	deliveries := 0
+	failing := func(delivery *HookDelivery) error {
+		deliveries++
+		return fmt.Errorf("unavailable")
+	}
+	backoffs := []time.Duration{}
+	for !delivery.IsDead {
+		test.S(t).ExpectFalse(attemptHookDelivery(delivery, failing, 5))
+		backoffs = append(backoffs, hookRetryBackoff(delivery.Attempts, 5, 30))
+	}
+	test.S(t).ExpectEquals(deliveries, 4)
+	test.S(t).ExpectEquals(delivery.Attempts, uint(5))
+	test.S(t).ExpectEquals(len(backoffs), 4)
+	test.S(t).ExpectEquals(backoffs[0], 10*time.Second)
+	test.S(t).ExpectEquals(backoffs[1], 20*time.Second)
+	test.S(t).ExpectEquals(backoffs[2], 30*time.Second)
+	test.S(t).ExpectEquals(backoffs[3], 30*time.Second)
+}
+
+func TestIsHookDeliveryQueueOwner(t *testing.T) {
+	defer func(maxAttempts uint, electedNode int64) {
+		config.Config.HookRetryMaxAttempts = maxAttempts
+		atomic.StoreInt64(&isElectedNode, electedNode)
+	}(config.Config.HookRetryMaxAttempts, atomic.LoadInt64(&isElectedNode))
+
+	tests := []struct {
+		name        string
+		maxAttempts uint
+		electedNode int64
+		expected    bool
+	}{
+		{"disabled", 0, 1, false},
+		{"single attempt", 1, 1, false},
+		{"retries, not the leader", 3, 0, false},
+		{"retries, leader", 3, 1, true},
+	}
+	for _, tt := range tests {
+		config.Config.HookRetryMaxAttempts = tt.maxAttempts
+		atomic.StoreInt64(&isElectedNode, tt.electedNode)
+		test.S(t).ExpectEquals(isHookDeliveryQueueOwner(), tt.expected)
+	}
+}
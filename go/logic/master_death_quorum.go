@@ -0,0 +1,274 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+// masterDeathProbeVotes holds recent tallies of asking DeadMasterDataCenterProbeURLs, such that probes are asked once
+// for both the analysis and the recovery of a dead master, rather than on every analysis
+var masterDeathProbeVotes = cache.New(5*time.Second, time.Second)
+
+// masterDeathVote is the view of a raft node or of a probe on a master analyzed as dead
+type masterDeathVote int
+
+const (
+	masterDeathAbstained masterDeathVote = iota
+	masterDeathConfirmed
+	masterDeathRefuted
+)
+
+// masterDeathVotes tallies the views on a master analyzed as dead
+type masterDeathVotes struct {
+	Confirmed int
+	Refuted   int
+	Abstained int
+}
+
+func (this *masterDeathVotes) add(vote masterDeathVote) {
+	switch vote {
+	case masterDeathConfirmed:
+		this.Confirmed++
+	case masterDeathRefuted:
+		this.Refuted++
+	default:
+		this.Abstained++
+	}
+}
+
+// quorum is the majority of all voters, abstaining ones included
+func (this *masterDeathVotes) quorum() int {
+	return (this.Confirmed+this.Refuted+this.Abstained)/2 + 1
+}
+
+// isRefuted returns true when a quorum of voters sees the master alive
+func (this *masterDeathVotes) isRefuted() bool {
+	return this.Refuted >= this.quorum()
+}
+
+func (this *masterDeathVotes) String() string {
+	return fmt.Sprintf("dead: %d, alive: %d, abstained: %d, quorum: %d", this.Confirmed, this.Refuted, this.Abstained, this.quorum())
+}
+
+// parseMasterDeathProbeResponse parses the response of a DeadMasterDataCenterProbeURLs probe
+func parseMasterDeathProbeResponse(response string) masterDeathVote {
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "dead":
+		return masterDeathConfirmed
+	case "alive":
+		return masterDeathRefuted
+	}
+	return masterDeathAbstained
+}
+
+// masterDeathProbeURL returns given probe URL, with {host} and {port} substituted by given master's
+func masterDeathProbeURL(probeURL string, masterKey *inst.InstanceKey) string {
+	url := strings.Replace(probeURL, "{host}", masterKey.Hostname, -1)
+	return strings.Replace(url, "{port}", fmt.Sprintf("%d", masterKey.Port), -1)
+}
+
+// askRaftMemberMasterDeath has the raft member of given HTTP URI check the master by itself
+func askRaftMemberMasterDeath(memberURI string, masterKey *inst.InstanceKey) masterDeathVote {
+	body, err := orcraft.HttpGetMember(memberURI, fmt.Sprintf("instance-reachability/%s/%d", masterKey.Hostname, masterKey.Port))
+	if err != nil {
+		log.Errorf("askRaftMemberMasterDeath: %s: %+v", memberURI, err)
+		return masterDeathAbstained
+	}
+	reachability := inst.InstanceReachability{}
+	if err := json.Unmarshal(body, &reachability); err != nil {
+		log.Errorf("askRaftMemberMasterDeath: %s: %+v", memberURI, err)
+		return masterDeathAbstained
+	}
+	if reachability.Reachable {
+		return masterDeathRefuted
+	}
+	return masterDeathConfirmed
+}
+
+// askProbeMasterDeath requests given DeadMasterDataCenterProbeURLs probe
+func askProbeMasterDeath(probeURL string, masterKey *inst.InstanceKey, timeout time.Duration) masterDeathVote {
+	client := &http.Client{Timeout: timeout}
+	url := masterDeathProbeURL(probeURL, masterKey)
+	response, err := client.Get(url)
+	if err != nil {
+		log.Errorf("askProbeMasterDeath: %s: %+v", url, err)
+		return masterDeathAbstained
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil || response.StatusCode < 200 || response.StatusCode >= 300 {
+		log.Errorf("askProbeMasterDeath: %s: status %d, %+v", url, response.StatusCode, err)
+		return masterDeathAbstained
+	}
+	return parseMasterDeathProbeResponse(string(body))
+}
+
+// otherDataCenterProbeURLs returns the DeadMasterDataCenterProbeURLs of data centers other than OrchestratorDataCenter
+func otherDataCenterProbeURLs() []string {
+	probeURLs := []string{}
+	for dataCenter, urls := range config.Config.DeadMasterDataCenterProbeURLs {
+		if dataCenter == config.Config.OrchestratorDataCenter {
+			continue
+		}
+		probeURLs = append(probeURLs, urls...)
+	}
+	return probeURLs
+}
+
+// askMasterDeathProbes has the DeadMasterDataCenterProbeURLs probes in other data centers independently check given
+// master, and tallies their views. Probes not responding within MasterDeathConfirmationTimeoutSeconds abstain.
+func askMasterDeathProbes(masterKey *inst.InstanceKey) *masterDeathVotes {
+	if votes, found := masterDeathProbeVotes.Get(masterKey.StringCode()); found {
+		return votes.(*masterDeathVotes)
+	}
+	probeURLs := otherDataCenterProbeURLs()
+	timeout := time.Duration(config.Config.MasterDeathConfirmationTimeoutSeconds) * time.Second
+	results := make(chan masterDeathVote, len(probeURLs))
+	for _, probeURL := range probeURLs {
+		go func(probeURL string) { results <- askProbeMasterDeath(probeURL, masterKey, timeout) }(probeURL)
+	}
+	votes := &masterDeathVotes{}
+	deadline := time.After(timeout)
+collect:
+	for collected := 0; collected < len(probeURLs); collected++ {
+		select {
+		case vote := <-results:
+			votes.add(vote)
+		case <-deadline:
+			votes.Abstained += len(probeURLs) - collected
+			break collect
+		}
+	}
+	masterDeathProbeVotes.Set(masterKey.StringCode(), votes, cache.DefaultExpiration)
+	return votes
+}
+
+// confirmMasterDeath has the other raft nodes and the DeadMasterDataCenterProbeURLs probes in other data centers
+// independently check given master, and tallies their views along with this node's, which analyzed the master as
+// dead. Raft nodes which are not healthy, and those nodes and probes not responding within
+// MasterDeathConfirmationTimeoutSeconds, abstain.
+func confirmMasterDeath(masterKey *inst.InstanceKey) *masterDeathVotes {
+	probeVotes := make(chan *masterDeathVotes, 1)
+	go func() { probeVotes <- askMasterDeathProbes(masterKey) }()
+
+	timeout := time.Duration(config.Config.MasterDeathConfirmationTimeoutSeconds) * time.Second
+	votes := &masterDeathVotes{Confirmed: 1}
+	memberURIs := []string{}
+	if orcraft.IsRaftEnabled() {
+		memberURIs = orcraft.HealthyMemberURIs()
+		if peers, err := orcraft.GetPeers(); err == nil && len(peers) > len(memberURIs)+1 {
+			votes.Abstained += len(peers) - len(memberURIs) - 1
+		}
+	}
+	results := make(chan masterDeathVote, len(memberURIs))
+	for _, memberURI := range memberURIs {
+		go func(memberURI string) { results <- askRaftMemberMasterDeath(memberURI, masterKey) }(memberURI)
+	}
+	deadline := time.After(timeout)
+collect:
+	for collected := 0; collected < len(memberURIs); collected++ {
+		select {
+		case vote := <-results:
+			votes.add(vote)
+		case <-deadline:
+			votes.Abstained += len(memberURIs) - collected
+			break collect
+		}
+	}
+	fromProbes := <-probeVotes
+	votes.Confirmed += fromProbes.Confirmed
+	votes.Refuted += fromProbes.Refuted
+	votes.Abstained += fromProbes.Abstained
+	return votes
+}
+
+// isRecoveryRefusedByMasterDeathQuorum returns true when, with MasterDeathQuorumConfirmation, a quorum of raft nodes
+// and probes sees the master of given registered recovery alive, in which case the automated recovery is refused.
+// The refused recovery is resolved and acknowledged, such that it does not block the master's recovery once dead.
+func isRecoveryRefusedByMasterDeathQuorum(topologyRecovery *TopologyRecovery, forceInstanceRecovery bool) bool {
+	if forceInstanceRecovery || !config.Config.MasterDeathQuorumConfirmation {
+		return false
+	}
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	votes := confirmMasterDeath(&analysisEntry.AnalyzedInstanceKey)
+	if !votes.isRefuted() {
+		inst.AuditOperation("master-death-confirmed", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("%+v on %s: %s", analysisEntry.Analysis, analysisEntry.ClusterDetails.ClusterName, votes))
+		return false
+	}
+	message := fmt.Sprintf("%+v on %s: a quorum sees the master alive (%s); not recovering automatically", analysisEntry.Analysis, analysisEntry.ClusterDetails.ClusterName, votes)
+	inst.AuditOperation("master-death-refuted", &analysisEntry.AnalyzedInstanceKey, message)
+	AuditTopologyRecovery(topologyRecovery, message)
+	resolveRecovery(topologyRecovery, nil)
+	acknowledgement := NewInternalAcknowledgement()
+	acknowledgement.UID = topologyRecovery.UID
+	acknowledgement.Comment = "master death refuted"
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("ack-recovery", acknowledgement)
+	} else {
+		_, err = AcknowledgeRecoveryByUID(acknowledgement.UID, acknowledgement.Owner, acknowledgement.Comment)
+	}
+	log.Errore(err)
+	return true
+}
+
+// isMasterDeathConfirmedFromOtherDataCenters returns true when at least one DeadMasterDataCenterProbeURLs probe, in a
+// data center other than orchestrator's own, sees given master dead. With no such probe configured, there is
+// nothing to confirm by, and the master's death stands.
+func isMasterDeathConfirmedFromOtherDataCenters(masterKey *inst.InstanceKey) bool {
+	if len(otherDataCenterProbeURLs()) == 0 {
+		return true
+	}
+	votes := askMasterDeathProbes(masterKey)
+	if votes.Confirmed > 0 {
+		return true
+	}
+	inst.AuditOperation("master-death-unconfirmed", masterKey, fmt.Sprintf("no probe in another data center sees the master dead (alive: %d, abstained: %d); analyzed as %s", votes.Refuted, votes.Abstained, inst.UnreachableMaster))
+	return false
+}
+
+// analyzeUnconfirmedDeadMaster re-analyzes a dead master as UnreachableMaster, which is not recovered, unless a probe
+// in another data center confirms its death. With raft, only the leader, which would recover the master, asks.
+func analyzeUnconfirmedDeadMaster(analysisEntry *inst.ReplicationAnalysis) {
+	if len(config.Config.DeadMasterDataCenterProbeURLs) == 0 {
+		return
+	}
+	if analysisEntry.Analysis != inst.DeadMaster && analysisEntry.Analysis != inst.DeadMasterAndSomeSlaves {
+		return
+	}
+	if orcraft.IsRaftEnabled() && !orcraft.IsLeader() {
+		return
+	}
+	if isMasterDeathConfirmedFromOtherDataCenters(&analysisEntry.AnalyzedInstanceKey) {
+		return
+	}
+	analysisEntry.Analysis = inst.UnreachableMaster
+	analysisEntry.Description = "Master cannot be reached by orchestrator, but no probe in another data center sees it dead; possibly a network issue on orchestrator's side"
+}
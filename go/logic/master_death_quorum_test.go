@@ -0,0 +1,196 @@
+package logic
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestMasterDeathVotesQuorum(t *testing.T) {
+	tests := []struct {
+		name            string
+		votes           masterDeathVotes
+		expectedQuorum  int
+		expectedRefuted bool
+	}{
+		{"single voter, dead", masterDeathVotes{Confirmed: 1}, 1, false},
+		{"two voters, split", masterDeathVotes{Confirmed: 1, Refuted: 1}, 2, false},
+		{"two voters, both alive", masterDeathVotes{Refuted: 2}, 2, true},
+		{"three voters, majority alive", masterDeathVotes{Confirmed: 1, Refuted: 2}, 2, true},
+		{"three voters, majority dead", masterDeathVotes{Confirmed: 2, Refuted: 1}, 2, false},
+		{"four voters, split", masterDeathVotes{Confirmed: 2, Refuted: 2}, 3, false},
+		{"four voters, three alive", masterDeathVotes{Confirmed: 1, Refuted: 3}, 3, true},
+		{"four voters, alive with abstention", masterDeathVotes{Confirmed: 1, Refuted: 2, Abstained: 1}, 3, false},
+		{"five voters, abstentions count", masterDeathVotes{Confirmed: 1, Refuted: 2, Abstained: 2}, 3, false},
+		{"five voters, alive despite abstentions", masterDeathVotes{Confirmed: 1, Refuted: 3, Abstained: 1}, 3, true},
+		{"six voters, split", masterDeathVotes{Confirmed: 3, Refuted: 3}, 4, false},
+		{"all abstained", masterDeathVotes{Abstained: 3}, 2, false},
+	}
+	for _, tt := range tests {
+		test.S(t).ExpectEquals(tt.votes.quorum(), tt.expectedQuorum)
+		test.S(t).ExpectEquals(tt.votes.isRefuted(), tt.expectedRefuted)
+	}
+}
+
+func TestMasterDeathVotesAdd(t *testing.T) {
+	votes := &masterDeathVotes{}
+	for _, vote := range []masterDeathVote{masterDeathConfirmed, masterDeathRefuted, masterDeathRefuted, masterDeathAbstained, masterDeathVote(7)} {
+		votes.add(vote)
+	}
+	test.S(t).ExpectEquals(*votes, masterDeathVotes{Confirmed: 1, Refuted: 2, Abstained: 2})
+}
+
+func TestParseMasterDeathProbeResponse(t *testing.T) {
+	tests := []struct {
+		response string
+		expected masterDeathVote
+	}{
+		{"dead", masterDeathConfirmed},
+		{"DEAD\n", masterDeathConfirmed},
+		{"  Alive ", masterDeathRefuted},
+		{"alive", masterDeathRefuted},
+		{"", masterDeathAbstained},
+		{"unknown", masterDeathAbstained},
+		{"dead or alive", masterDeathAbstained},
+		{`{"status": "dead"}`, masterDeathAbstained},
+		{"<html>dead</html>", masterDeathAbstained},
+	}
+	for _, tt := range tests {
+		test.S(t).ExpectEquals(parseMasterDeathProbeResponse(tt.response), tt.expected)
+	}
+}
+
+func TestMasterDeathProbeURL(t *testing.T) {
+	masterKey := &inst.InstanceKey{Hostname: "master.example.com", Port: 3306}
+	tests := []struct {
+		probeURL string
+		expected string
+	}{
+		{"http://probe/check/{host}/{port}", "http://probe/check/master.example.com/3306"},
+		{"http://probe/check?host={host}&port={port}", "http://probe/check?host=master.example.com&port=3306"},
+		{"http://probe/{host}/{host}", "http://probe/master.example.com/master.example.com"},
+		{"http://probe/check", "http://probe/check"},
+	}
+	for _, tt := range tests {
+		test.S(t).ExpectEquals(masterDeathProbeURL(tt.probeURL, masterKey), tt.expected)
+	}
+}
+
+func TestAskProbeMasterDeath(t *testing.T) {
+	masterKey := &inst.InstanceKey{Hostname: "master", Port: 3306}
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		delay    time.Duration
+		expected masterDeathVote
+	}{
+		{"dead", http.StatusOK, "dead", 0, masterDeathConfirmed},
+		{"alive", http.StatusOK, "alive\n", 0, masterDeathRefuted},
+		{"malformed body", http.StatusOK, "{not json", 0, masterDeathAbstained},
+		{"empty body", http.StatusOK, "", 0, masterDeathAbstained},
+		{"server error", http.StatusInternalServerError, "dead", 0, masterDeathAbstained},
+		{"timeout", http.StatusOK, "dead", 500 * time.Millisecond, masterDeathAbstained},
+	}
+	for _, tt := range tests {
+		tt := tt
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			test.S(t).ExpectEquals(r.URL.Path, "/master/3306")
+			time.Sleep(tt.delay)
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, tt.body)
+		}))
+		vote := askProbeMasterDeath(server.URL+"/{host}/{port}", masterKey, 100*time.Millisecond)
+		server.Close()
+		test.S(t).ExpectEquals(vote, tt.expected)
+	}
+}
+
+func TestConfirmMasterDeath(t *testing.T) {
+	masterKey := &inst.InstanceKey{Hostname: "master", Port: 3306}
+	newProbe := func(body string, delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			fmt.Fprint(w, body)
+		}))
+	}
+	alive := newProbe("alive", 0)
+	defer alive.Close()
+	dead := newProbe("dead", 0)
+	defer dead.Close()
+	malformed := newProbe("maybe", 0)
+	defer malformed.Close()
+	slow := newProbe("alive", 1500*time.Millisecond)
+	defer slow.Close()
+
+	defer func(probeURLs config.DCProbeURLs, dataCenter string, timeoutSeconds uint) {
+		config.Config.DeadMasterDataCenterProbeURLs = probeURLs
+		config.Config.OrchestratorDataCenter = dataCenter
+		config.Config.MasterDeathConfirmationTimeoutSeconds = timeoutSeconds
+	}(config.Config.DeadMasterDataCenterProbeURLs, config.Config.OrchestratorDataCenter, config.Config.MasterDeathConfirmationTimeoutSeconds)
+	config.Config.OrchestratorDataCenter = "dc1"
+	config.Config.MasterDeathConfirmationTimeoutSeconds = 1
+
+	tests := []struct {
+		name            string
+		probes          []*httptest.Server
+		expectedVotes   masterDeathVotes
+		expectedRefuted bool
+	}{
+		{"no probes", nil, masterDeathVotes{Confirmed: 1}, false},
+		{"even voters, split", []*httptest.Server{alive}, masterDeathVotes{Confirmed: 1, Refuted: 1}, false},
+		{"majority alive", []*httptest.Server{alive, alive}, masterDeathVotes{Confirmed: 1, Refuted: 2}, true},
+		{"majority dead", []*httptest.Server{alive, dead}, masterDeathVotes{Confirmed: 2, Refuted: 1}, false},
+		{"malformed abstains", []*httptest.Server{alive, malformed}, masterDeathVotes{Confirmed: 1, Refuted: 1, Abstained: 1}, false},
+		{"timeout abstains", []*httptest.Server{alive, alive, slow}, masterDeathVotes{Confirmed: 1, Refuted: 2, Abstained: 1}, false},
+		{"alive despite timeout", []*httptest.Server{alive, alive, alive, slow}, masterDeathVotes{Confirmed: 1, Refuted: 3, Abstained: 1}, true},
+	}
+	for _, tt := range tests {
+		// Probes in orchestrator's own data center are not asked
+		config.Config.DeadMasterDataCenterProbeURLs = config.DCProbeURLs{"dc1": []string{alive.URL + "/{host}/{port}"}}
+		for i, probe := range tt.probes {
+			dataCenter := fmt.Sprintf("dc%d", 2+i%2)
+			config.Config.DeadMasterDataCenterProbeURLs[dataCenter] = append(config.Config.DeadMasterDataCenterProbeURLs[dataCenter], probe.URL+"/{host}/{port}")
+		}
+		masterDeathProbeVotes.Flush()
+		votes := confirmMasterDeath(masterKey)
+		test.S(t).ExpectEquals(*votes, tt.expectedVotes)
+		test.S(t).ExpectEquals(votes.isRefuted(), tt.expectedRefuted)
+	}
+}
+
+func TestMasterDeathProbesAskedOnce(t *testing.T) {
+	masterKey := &inst.InstanceKey{Hostname: "master", Port: 3306}
+	requests := 0
+	probe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "alive")
+	}))
+	defer probe.Close()
+
+	defer func(probeURLs config.DCProbeURLs, dataCenter string, timeoutSeconds uint) {
+		config.Config.DeadMasterDataCenterProbeURLs = probeURLs
+		config.Config.OrchestratorDataCenter = dataCenter
+		config.Config.MasterDeathConfirmationTimeoutSeconds = timeoutSeconds
+	}(config.Config.DeadMasterDataCenterProbeURLs, config.Config.OrchestratorDataCenter, config.Config.MasterDeathConfirmationTimeoutSeconds)
+	config.Config.DeadMasterDataCenterProbeURLs = config.DCProbeURLs{"dc2": []string{probe.URL + "/{host}/{port}"}}
+	config.Config.OrchestratorDataCenter = "dc1"
+	config.Config.MasterDeathConfirmationTimeoutSeconds = 1
+	masterDeathProbeVotes.Flush()
+
+	// The analysis and the recovery of a dead master share the probes' views
+	test.S(t).ExpectFalse(isMasterDeathConfirmedFromOtherDataCenters(masterKey))
+	votes := confirmMasterDeath(masterKey)
+	test.S(t).ExpectEquals(*votes, masterDeathVotes{Confirmed: 1, Refuted: 1})
+	test.S(t).ExpectEquals(requests, 1)
+
+	masterDeathProbeVotes.Flush()
+	confirmMasterDeath(masterKey)
+	test.S(t).ExpectEquals(requests, 2)
+}
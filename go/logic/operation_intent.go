@@ -0,0 +1,357 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+	"github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+)
+
+type OperationIntentType string
+
+const (
+	RelocateBelowIntent    OperationIntentType = "relocate"
+	RelocateReplicasIntent                     = "relocate-replicas"
+	RegroupReplicasIntent                      = "regroup-replicas"
+)
+
+type OperationIntentState string
+
+const (
+	OperationIntentPending   OperationIntentState = "pending"
+	OperationIntentRunning                        = "running"
+	OperationIntentCompleted                      = "completed"
+	OperationIntentFailed                         = "failed"
+	OperationIntentAbandoned                      = "abandoned"
+)
+
+// OperationIntent is an operation accepted by the leader, recorded in the (possibly replicated) backend
+// store before being executed. Intents not known to be complete are resumed by a newly elected leader.
+// A running intent is leased to the node running it (ClaimedBy), which renews the lease while it runs.
+type OperationIntent struct {
+	UID                  string
+	Operation            OperationIntentType
+	Key                  inst.InstanceKey
+	TargetKey            inst.InstanceKey
+	Pattern              string
+	AllowWAN             bool
+	Owner                string
+	Reason               string
+	State                OperationIntentState
+	Attempts             int
+	Message              string
+	SubmittedTimestamp   string
+	LastAttemptTimestamp string
+	CompletedTimestamp   string
+	ClaimedBy            string
+	LeaseExpiryTimestamp string
+}
+
+func NewOperationIntent(operation OperationIntentType, key *inst.InstanceKey, targetKey *inst.InstanceKey, owner string, reason string) *OperationIntent {
+	intent := &OperationIntent{
+		UID:       util.PrettyUniqueToken(),
+		Operation: operation,
+		Owner:     owner,
+		Reason:    reason,
+		State:     OperationIntentPending,
+
+		SubmittedTimestamp: intentTimestamp(),
+	}
+	if key != nil {
+		intent.Key = *key
+	}
+	if targetKey != nil {
+		intent.TargetKey = *targetKey
+	}
+	return intent
+}
+
+// intentTimestamp returns the current time in a format suitable for the backend database. Timestamps are
+// computed by the leader so that all raft members persist identical values.
+func intentTimestamp() string {
+	return intentTimestampAt(time.Now())
+}
+
+func intentTimestampAt(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// operationIntentLeaseDuration is the time for which a claim on a running intent holds without being renewed
+func operationIntentLeaseDuration() time.Duration {
+	return time.Duration(config.Config.OperationIntentLeaseSeconds) * time.Second
+}
+
+// IsDone returns true when the intent requires no further attention
+func (this *OperationIntent) IsDone() bool {
+	switch this.State {
+	case OperationIntentCompleted, OperationIntentFailed, OperationIntentAbandoned:
+		return true
+	}
+	return false
+}
+
+// OperationIntentClaim is a conditional transition of an intent onto running state, by given node: it only applies
+// if the intent is still in the state and at the attempt it was read with, and is not running under another node's
+// unexpired lease. Only one attempt can claim a given intent. A renewal extends the lease of the node's own attempt.
+type OperationIntentClaim struct {
+	UID                  string
+	ExpectedState        OperationIntentState
+	ExpectedAttempts     int
+	Timestamp            string
+	ClaimedBy            string
+	LeaseExpiryTimestamp string
+	Renewal              bool
+}
+
+func NewOperationIntentClaim(intent *OperationIntent) *OperationIntentClaim {
+	now := time.Now()
+	return &OperationIntentClaim{
+		UID:                  intent.UID,
+		ExpectedState:        intent.State,
+		ExpectedAttempts:     intent.Attempts,
+		Timestamp:            intentTimestampAt(now),
+		ClaimedBy:            process.ThisHostname,
+		LeaseExpiryTimestamp: intentTimestampAt(now.Add(operationIntentLeaseDuration())),
+	}
+}
+
+// NewOperationIntentLeaseRenewal returns a claim renewing the lease this node holds on given running intent
+func NewOperationIntentLeaseRenewal(intent *OperationIntent) *OperationIntentClaim {
+	renewal := NewOperationIntentClaim(intent)
+	renewal.Renewal = true
+	return renewal
+}
+
+// appliesTo returns true when this claim may transition given intent
+func (this *OperationIntentClaim) appliesTo(intent *OperationIntent) bool {
+	if intent.UID != this.UID || intent.State != this.ExpectedState || intent.Attempts != this.ExpectedAttempts {
+		return false
+	}
+	if this.Renewal {
+		return intent.State == OperationIntentRunning && intent.ClaimedBy == this.ClaimedBy
+	}
+	if intent.State != OperationIntentRunning {
+		return true
+	}
+	// A running intent is only taken over once its lease expires: its node may otherwise still be running it.
+	// Intents claimed before leases were recorded have no lease.
+	return intent.LeaseExpiryTimestamp < this.Timestamp
+}
+
+// apply transitions given intent as per this claim, which is assumed to apply to it
+func (this *OperationIntentClaim) apply(intent *OperationIntent) {
+	if !this.Renewal {
+		intent.Attempts++
+		intent.LastAttemptTimestamp = this.Timestamp
+	}
+	intent.State = OperationIntentRunning
+	intent.ClaimedBy = this.ClaimedBy
+	intent.LeaseExpiryTimestamp = this.LeaseExpiryTimestamp
+}
+
+// publishOperationIntentClaim attempts to claim an intent for running; with raft, the claim is applied by all
+// raft members, and the outcome is that of the leader's backend.
+func publishOperationIntentClaim(claim *OperationIntentClaim) (claimed bool, err error) {
+	if !orcraft.IsRaftEnabled() {
+		return claimOperationIntent(claim)
+	}
+	response, err := orcraft.PublishCommand("claim-operation-intent", claim)
+	if err != nil {
+		return false, err
+	}
+	claimed, _ = response.(bool)
+	return claimed, nil
+}
+
+// publishOperationIntent persists given intent; with raft, this is replicated to all raft members
+func publishOperationIntent(intent *OperationIntent) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-operation-intent", intent)
+	} else {
+		err = writeOperationIntent(intent)
+	}
+	return err
+}
+
+// executeOperationIntent runs the operation described by given intent, bounded by given context
+func executeOperationIntent(ctx context.Context, intent *OperationIntent) (result interface{}, err error) {
+	ctx = inst.WithMasterChangeOrigin(ctx, inst.NewMasterChangeOrigin(inst.MasterChangeCausePlanned, intent.Owner, intent.UID))
+	switch intent.Operation {
+	case RelocateBelowIntent:
+		return inst.RelocateBelowContext(ctx, &intent.Key, &intent.TargetKey, intent.AllowWAN)
+	case RelocateReplicasIntent:
+		replicas, _, err, errs := inst.RelocateReplicasContext(ctx, &intent.Key, &intent.TargetKey, intent.Pattern)
+		if err == nil {
+			intent.Message = fmt.Sprintf("%d errors: %+v", len(errs), errs)
+		}
+		return replicas, err
+	case RegroupReplicasIntent:
+		lostReplicas, equalReplicas, aheadReplicas, cannotReplicateReplicas, promotedReplica, err := inst.RegroupReplicasContext(ctx, &intent.Key, false, nil, nil)
+		if err == nil && promotedReplica != nil {
+			intent.Message = fmt.Sprintf("promoted replica: %s, lost: %d, trivial: %d, pseudo-gtid: %d",
+				promotedReplica.Key.DisplayString(), len(lostReplicas)+len(cannotReplicateReplicas), len(equalReplicas), len(aheadReplicas))
+		}
+		return promotedReplica, err
+	}
+	return nil, fmt.Errorf("Unknown operation intent: %s", intent.Operation)
+}
+
+// renewOperationIntentLease renews this node's lease on given running intent, until given context is done. Should
+// the lease not be renewed, e.g. because this node lost leadership, the intent's context is canceled and leaseLost
+// is closed: another node may take the intent over.
+func renewOperationIntentLease(ctx context.Context, cancel context.CancelFunc, intent OperationIntent, claimIntent func(*OperationIntentClaim) (bool, error), leaseLost chan<- bool) {
+	ticker := time.NewTicker(operationIntentLeaseDuration() / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewal := NewOperationIntentLeaseRenewal(&intent)
+			renewed, err := claimIntent(renewal)
+			if err != nil || !renewed {
+				log.Errorf("Lost lease on %s intent %s: %+v", intent.Operation, intent.UID, err)
+				close(leaseLost)
+				cancel()
+				return
+			}
+			renewal.apply(&intent)
+		}
+	}
+}
+
+// runOperationIntent claims given intent via claimIntent, executes it and records the outcome. The claim is leased,
+// and renewed while the intent runs.
+func runOperationIntent(ctx context.Context, intent *OperationIntent, claimIntent func(*OperationIntentClaim) (bool, error)) (result interface{}, err error) {
+	claim := NewOperationIntentClaim(intent)
+	claimed, err := claimIntent(claim)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("Operation intent %s is already running or done", intent.UID)
+	}
+	claim.apply(intent)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	leaseLost := make(chan bool)
+	go renewOperationIntentLease(ctx, cancel, *intent, claimIntent, leaseLost)
+
+	// An intent submitted by an API request runs as part of the request's in-flight operation
+	correlationID := inst.InFlightOperationFromContext(ctx)
+	if correlationID == "" {
+		clusterName, _ := inst.GetClusterName(&intent.Key)
+		correlationID = inst.BeginInFlightOperation(intent.UID, fmt.Sprintf("operation-intent: %s", intent.Operation), clusterName, intent.Owner, false, intent.Key, intent.TargetKey)
+		defer inst.EndInFlightOperation(correlationID)
+	}
+
+	inst.AuditOperationBy("operation-intent", &intent.Key, fmt.Sprintf("running %s intent %s, attempt %d", intent.Operation, intent.UID, intent.Attempts), intent.Owner, intent.Reason)
+	inst.SetInFlightOperationStep(correlationID, fmt.Sprintf("running %s intent %s, attempt %d", intent.Operation, intent.UID, intent.Attempts))
+
+	result, err = executeOperationIntent(ctx, intent)
+	if err == nil {
+		intent.State = OperationIntentCompleted
+	} else {
+		intent.State = OperationIntentFailed
+		intent.Message = err.Error()
+	}
+	intent.CompletedTimestamp = intentTimestamp()
+	select {
+	case <-leaseLost:
+		// The intent may have been taken over: its outcome is not ours to record
+		return result, err
+	default:
+	}
+	if perr := publishOperationIntent(intent); perr != nil {
+		log.Errore(perr)
+	}
+	return result, err
+}
+
+// SubmitOperationIntent persists an intent for given operation and then executes it, synchronously.
+// Should this node lose leadership before the intent completes, the next leader resumes it.
+// The operation is bounded by given context, e.g. that of the submitting API request.
+func SubmitOperationIntent(ctx context.Context, intent *OperationIntent) (result interface{}, err error) {
+	if err := publishOperationIntent(intent); err != nil {
+		return nil, log.Errore(err)
+	}
+	return runOperationIntent(ctx, intent, publishOperationIntentClaim)
+}
+
+// ResumePendingOperationIntents is called upon becoming leader. It re-runs, in submission order,
+// intents which were accepted but not known to have completed. Intents exceeding their allowed
+// attempts are abandoned.
+func ResumePendingOperationIntents() error {
+	intents, err := readIncompleteOperationIntents()
+	if err != nil {
+		return log.Errore(err)
+	}
+	resumeOperationIntents(intents)
+	return nil
+}
+
+// ResumeExpiredOperationIntents is called periodically by the leader. It takes over running intents whose lease
+// expired, such as those still leased to the former leader upon election.
+func ResumeExpiredOperationIntents() error {
+	intents, err := readLeaseExpiredOperationIntents(intentTimestamp())
+	if err != nil {
+		return log.Errore(err)
+	}
+	resumeOperationIntents(intents)
+	return nil
+}
+
+// resumeOperationIntents runs given intents, unless they are still leased to another node, or abandons them
+// once they exceed their allowed attempts
+func resumeOperationIntents(intents []*OperationIntent) {
+	for _, intent := range intents {
+		if !IsLeader() {
+			return
+		}
+		if !NewOperationIntentClaim(intent).appliesTo(intent) {
+			log.Infof("%s intent %s is leased to %s until %s; not resuming", intent.Operation, intent.UID, intent.ClaimedBy, intent.LeaseExpiryTimestamp)
+			continue
+		}
+		if intent.Attempts >= int(config.Config.OperationIntentMaxAttempts) {
+			intent.State = OperationIntentAbandoned
+			intent.Message = fmt.Sprintf("abandoned after %d attempts", intent.Attempts)
+			intent.CompletedTimestamp = intentTimestamp()
+			inst.AuditOperationBy("operation-intent", &intent.Key, fmt.Sprintf("abandoning %s intent %s: %s", intent.Operation, intent.UID, intent.Message), intent.Owner, intent.Reason)
+			if err := publishOperationIntent(intent); err != nil {
+				log.Errore(err)
+			}
+			continue
+		}
+		log.Infof("Resuming %s intent %s on %+v", intent.Operation, intent.UID, intent.Key)
+		ctx, cancel := inst.NewOperationContext(context.Background(), 0)
+		_, err := runOperationIntent(ctx, intent, publishOperationIntentClaim)
+		cancel()
+		if err != nil {
+			log.Errorf("Resumed %s intent %s failed: %+v", intent.Operation, intent.UID, err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// writeOperationIntent creates or updates an operation intent entry
+func writeOperationIntent(intent *OperationIntent) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into operation_intent (
+				uid, operation, hostname, port, target_hostname, target_port, pattern, allow_wan,
+				owner, reason, state, attempts, message, submitted_timestamp, last_attempt_timestamp, completed_timestamp,
+				claimed_by, lease_expiry_timestamp
+			) values (
+				?, ?, ?, ?, ?, ?, ?, ?,
+				?, ?, ?, ?, ?, ?, ?, ?,
+				?, ?
+			) on duplicate key update
+				state=values(state),
+				attempts=values(attempts),
+				message=values(message),
+				last_attempt_timestamp=values(last_attempt_timestamp),
+				completed_timestamp=values(completed_timestamp),
+				claimed_by=values(claimed_by),
+				lease_expiry_timestamp=values(lease_expiry_timestamp)
+			`, intent.UID, string(intent.Operation), intent.Key.Hostname, intent.Key.Port, intent.TargetKey.Hostname, intent.TargetKey.Port, intent.Pattern, intent.AllowWAN,
+			intent.Owner, intent.Reason, string(intent.State), intent.Attempts, intent.Message,
+			intent.SubmittedTimestamp, nullIfEmpty(intent.LastAttemptTimestamp), nullIfEmpty(intent.CompletedTimestamp),
+			intent.ClaimedBy, nullIfEmpty(intent.LeaseExpiryTimestamp),
+		)
+		return log.Errore(err)
+	}
+	return inst.ExecDBWriteFunc(writeFunc)
+}
+
+// claimOperationIntent transitions an intent onto running state, or renews its lease, as per given claim (see
+// OperationIntentClaim.appliesTo). Returns true when this call made the transition, false when the intent has
+// meanwhile been claimed by another attempt, is running under another node's lease, or completed.
+func claimOperationIntent(claim *OperationIntentClaim) (claimed bool, err error) {
+	intents, err := readOperationIntents(`where uid = ?`, ``, sqlutils.Args(claim.UID))
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	if len(intents) == 0 {
+		return false, nil
+	}
+	intent := intents[0]
+	if !claim.appliesTo(intent) {
+		return false, nil
+	}
+	readIntent := *intent
+	claim.apply(intent)
+	// The intent is only transitioned if unchanged since read
+	sqlResult, err := db.ExecOrchestrator(`
+			update operation_intent set
+				state = ?,
+				attempts = ?,
+				last_attempt_timestamp = ?,
+				claimed_by = ?,
+				lease_expiry_timestamp = ?
+			where
+				uid = ?
+				and state = ?
+				and attempts = ?
+				and claimed_by = ?
+				and ifnull(lease_expiry_timestamp, '') = ?
+		`, string(intent.State), intent.Attempts, nullIfEmpty(intent.LastAttemptTimestamp), intent.ClaimedBy, intent.LeaseExpiryTimestamp,
+		readIntent.UID, string(readIntent.State), readIntent.Attempts, readIntent.ClaimedBy, readIntent.LeaseExpiryTimestamp,
+	)
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	rows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	return rows > 0, nil
+}
+
+// readOperationIntents reads operation intents satisfying given condition
+func readOperationIntents(whereCondition string, limit string, args []interface{}) ([]*OperationIntent, error) {
+	res := []*OperationIntent{}
+	query := fmt.Sprintf(`
+		select
+			uid,
+			operation,
+			hostname,
+			port,
+			target_hostname,
+			target_port,
+			pattern,
+			allow_wan,
+			owner,
+			reason,
+			state,
+			attempts,
+			message,
+			submitted_timestamp,
+			ifnull(last_attempt_timestamp, '') as last_attempt_timestamp,
+			ifnull(completed_timestamp, '') as completed_timestamp,
+			claimed_by,
+			ifnull(lease_expiry_timestamp, '') as lease_expiry_timestamp
+		from
+			operation_intent
+		%s
+		order by
+			submitted_timestamp asc, uid asc
+		%s
+		`, whereCondition, limit)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		intent := &OperationIntent{}
+		intent.UID = m.GetString("uid")
+		intent.Operation = OperationIntentType(m.GetString("operation"))
+		intent.Key.Hostname = m.GetString("hostname")
+		intent.Key.Port = m.GetInt("port")
+		intent.TargetKey.Hostname = m.GetString("target_hostname")
+		intent.TargetKey.Port = m.GetInt("target_port")
+		intent.Pattern = m.GetString("pattern")
+		intent.AllowWAN = m.GetBool("allow_wan")
+		intent.Owner = m.GetString("owner")
+		intent.Reason = m.GetString("reason")
+		intent.State = OperationIntentState(m.GetString("state"))
+		intent.Attempts = m.GetInt("attempts")
+		intent.Message = m.GetString("message")
+		intent.SubmittedTimestamp = m.GetString("submitted_timestamp")
+		intent.LastAttemptTimestamp = m.GetString("last_attempt_timestamp")
+		intent.CompletedTimestamp = m.GetString("completed_timestamp")
+		intent.ClaimedBy = m.GetString("claimed_by")
+		intent.LeaseExpiryTimestamp = m.GetString("lease_expiry_timestamp")
+
+		res = append(res, intent)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// readIncompleteOperationIntents reads intents which were accepted and not known to have completed
+func readIncompleteOperationIntents() ([]*OperationIntent, error) {
+	whereCondition := `
+		where
+			state in (?, ?)
+		`
+	return readOperationIntents(whereCondition, ``, sqlutils.Args(string(OperationIntentPending), string(OperationIntentRunning)))
+}
+
+// readLeaseExpiredOperationIntents reads running intents whose lease expired by given timestamp
+func readLeaseExpiredOperationIntents(timestamp string) ([]*OperationIntent, error) {
+	whereCondition := `
+		where
+			state = ?
+			and ifnull(lease_expiry_timestamp, '') < ?
+		`
+	return readOperationIntents(whereCondition, ``, sqlutils.Args(string(OperationIntentRunning), timestamp))
+}
+
+// ReadRecentOperationIntents reads the latest operation intents, most recently submitted last
+func ReadRecentOperationIntents() ([]*OperationIntent, error) {
+	whereCondition := `
+		where
+			submitted_timestamp > NOW() - INTERVAL 1 DAY
+		`
+	return readOperationIntents(whereCondition, ``, sqlutils.Args())
+}
+
+// ExpireOperationIntentHistory removes old rows from the operation_intent table
+func ExpireOperationIntentHistory() error {
+	return inst.ExpireTableData("operation_intent", "submitted_timestamp")
+}
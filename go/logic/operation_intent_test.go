@@ -0,0 +1,131 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewOperationIntentClaim(t *testing.T) {
+	intent := NewOperationIntent(RelocateBelowIntent, &inst.InstanceKey{Hostname: "replica", Port: 3306}, &inst.InstanceKey{Hostname: "master", Port: 3306}, "someone", "")
+	claim := NewOperationIntentClaim(intent)
+	test.S(t).ExpectEquals(claim.UID, intent.UID)
+	test.S(t).ExpectEquals(claim.ExpectedState, OperationIntentPending)
+	test.S(t).ExpectEquals(claim.ExpectedAttempts, 0)
+
+	intent.State = OperationIntentRunning
+	intent.Attempts = 2
+	claim = NewOperationIntentClaim(intent)
+	test.S(t).ExpectEquals(claim.ExpectedState, OperationIntentState(OperationIntentRunning))
+	test.S(t).ExpectEquals(claim.ExpectedAttempts, 2)
+}
+
+func TestOperationIntentClaimAppliesTo(t *testing.T) {
+	now := time.Now()
+	leased := func(claimedBy string, leaseExpiry time.Time) *OperationIntent {
+		intent := NewOperationIntent(RelocateBelowIntent, &inst.InstanceKey{Hostname: "replica", Port: 3306}, &inst.InstanceKey{Hostname: "master", Port: 3306}, "someone", "")
+		intent.State = OperationIntentRunning
+		intent.Attempts = 1
+		intent.ClaimedBy = claimedBy
+		intent.LeaseExpiryTimestamp = intentTimestampAt(leaseExpiry)
+		return intent
+	}
+	{
+		intent := NewOperationIntent(RelocateBelowIntent, &inst.InstanceKey{Hostname: "replica", Port: 3306}, &inst.InstanceKey{Hostname: "master", Port: 3306}, "someone", "")
+		claim := NewOperationIntentClaim(intent)
+		test.S(t).ExpectTrue(claim.appliesTo(intent))
+		claim.apply(intent)
+		test.S(t).ExpectEquals(intent.State, OperationIntentState(OperationIntentRunning))
+		test.S(t).ExpectEquals(intent.Attempts, 1)
+		test.S(t).ExpectEquals(intent.ClaimedBy, process.ThisHostname)
+		test.S(t).ExpectEquals(intent.LeaseExpiryTimestamp, claim.LeaseExpiryTimestamp)
+		// The intent was meanwhile claimed
+		test.S(t).ExpectFalse(claim.appliesTo(intent))
+	}
+	{
+		// Running under another node's unexpired lease
+		intent := leased("former-leader", now.Add(time.Minute))
+		test.S(t).ExpectFalse(NewOperationIntentClaim(intent).appliesTo(intent))
+		test.S(t).ExpectFalse(NewOperationIntentLeaseRenewal(intent).appliesTo(intent))
+	}
+	{
+		// Running under another node's expired lease
+		intent := leased("former-leader", now.Add(-time.Minute))
+		test.S(t).ExpectTrue(NewOperationIntentClaim(intent).appliesTo(intent))
+		test.S(t).ExpectFalse(NewOperationIntentLeaseRenewal(intent).appliesTo(intent))
+	}
+	{
+		// Running under own lease: renewed, but not claimed anew
+		intent := leased(process.ThisHostname, now.Add(time.Minute))
+		test.S(t).ExpectFalse(NewOperationIntentClaim(intent).appliesTo(intent))
+		renewal := NewOperationIntentLeaseRenewal(intent)
+		test.S(t).ExpectTrue(renewal.appliesTo(intent))
+		renewal.apply(intent)
+		test.S(t).ExpectEquals(intent.Attempts, 1)
+		test.S(t).ExpectEquals(intent.LeaseExpiryTimestamp, renewal.LeaseExpiryTimestamp)
+	}
+	{
+		// Claimed before leases were recorded
+		intent := leased("", now)
+		intent.LeaseExpiryTimestamp = ""
+		test.S(t).ExpectTrue(NewOperationIntentClaim(intent).appliesTo(intent))
+	}
+	{
+		// Completed since read
+		intent := leased("former-leader", now.Add(-time.Minute))
+		claim := NewOperationIntentClaim(intent)
+		intent.State = OperationIntentCompleted
+		test.S(t).ExpectFalse(claim.appliesTo(intent))
+	}
+}
+
+// claimStoredOperationIntent returns a claim function which claims given stored intent, as the backend does
+func claimStoredOperationIntent(stored *OperationIntent) func(*OperationIntentClaim) (bool, error) {
+	return func(claim *OperationIntentClaim) (bool, error) {
+		if !claim.appliesTo(stored) {
+			return false, nil
+		}
+		claim.apply(stored)
+		return true, nil
+	}
+}
+
+func TestRunOperationIntentRequiresClaim(t *testing.T) {
+	intent := NewOperationIntent(RelocateBelowIntent, &inst.InstanceKey{Hostname: "replica", Port: 3306}, &inst.InstanceKey{Hostname: "master", Port: 3306}, "someone", "")
+	intent.State = OperationIntentRunning
+	intent.Attempts = 1
+	intent.ClaimedBy = "former-leader"
+	intent.LeaseExpiryTimestamp = intentTimestampAt(time.Now().Add(time.Minute))
+	stored := *intent
+	{
+		// Still leased to the former leader, which may be running it: the intent must not run
+		_, err := runOperationIntent(context.Background(), intent, claimStoredOperationIntent(&stored))
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(intent.Attempts, 1)
+		test.S(t).ExpectEquals(intent.ClaimedBy, "former-leader")
+		test.S(t).ExpectEquals(stored.Attempts, 1)
+		test.S(t).ExpectEquals(stored.ClaimedBy, "former-leader")
+	}
+	{
+		// Once the lease expires, the intent is taken over. There is no backend in this test, and the relocation fails
+		intent.LeaseExpiryTimestamp = intentTimestampAt(time.Now().Add(-time.Minute))
+		stored = *intent
+		_, err := runOperationIntent(context.Background(), intent, claimStoredOperationIntent(&stored))
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(stored.Attempts, 2)
+		test.S(t).ExpectEquals(stored.ClaimedBy, process.ThisHostname)
+		test.S(t).ExpectEquals(intent.Attempts, 2)
+		test.S(t).ExpectEquals(intent.State, OperationIntentState(OperationIntentFailed))
+	}
+}
+
+func TestApplierClaimOperationIntentMalformed(t *testing.T) {
+	applier := NewCommandApplier()
+	response := applier.ApplyCommand("claim-operation-intent", []byte("{malformed"))
+	_, isError := response.(error)
+	test.S(t).ExpectTrue(isError)
+}
@@ -29,12 +29,15 @@ import (
 	"github.com/github/orchestrator/go/agent"
 	"github.com/github/orchestrator/go/collection"
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
 	"github.com/github/orchestrator/go/discovery"
+	"github.com/github/orchestrator/go/events"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/kv"
 	ometrics "github.com/github/orchestrator/go/metrics"
 	"github.com/github/orchestrator/go/process"
 	"github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/tracing"
 	"github.com/github/orchestrator/go/util"
 	"github.com/openark/golib/log"
 	"github.com/patrickmn/go-cache"
@@ -229,6 +232,9 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 	}
 
 	discoveriesCounter.Inc(1)
+	span := tracing.StartSpan("discover-instance", nil)
+	span.SetAttribute("instance", instanceKey.StringCode())
+	defer span.End()
 
 	// First we've ever heard of this instance. Continue investigation:
 	instance, err = inst.ReadTopologyInstanceBufferable(&instanceKey, config.Config.BufferInstanceWrites, latency)
@@ -237,6 +243,9 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 	totalLatency := latency.Elapsed("total")
 	backendLatency := latency.Elapsed("backend")
 	instanceLatency := latency.Elapsed("instance")
+	span.SetAttribute("backend_latency_ms", backendLatency.Milliseconds())
+	span.SetAttribute("instance_latency_ms", instanceLatency.Milliseconds())
+	span.SetError(err)
 
 	if instance == nil {
 		failedDiscoveriesCounter.Inc(1)
@@ -332,6 +341,7 @@ func onHealthTick() {
 	if !IsLeaderOrActive() {
 		return
 	}
+	go process.CheckVersionSkew()
 	instanceKeys, err := inst.ReadOutdatedInstanceKeys()
 	if err != nil {
 		log.Errore(err)
@@ -341,6 +351,7 @@ func onHealthTick() {
 		// Just turned to be leader!
 		go process.RegisterNode(process.ThisNodeHealth)
 		go inst.ExpireMaintenance()
+		go ResumePendingOperationIntents()
 	}
 
 	func() {
@@ -467,15 +478,21 @@ func ContinuousDiscovery() {
 
 	healthTick := time.Tick(config.HealthPollSeconds * time.Second)
 	instancePollTick := time.Tick(instancePollSecondsDuration())
+	focusModeTick := time.Tick(time.Duration(config.Config.FocusModePollSeconds) * time.Second)
 	caretakingTick := time.Tick(time.Minute)
 	raftCaretakingTick := time.Tick(10 * time.Minute)
 	recoveryTick := time.Tick(time.Duration(config.RecoveryPollSeconds) * time.Second)
+	hookDeliveryTick := time.Tick(time.Second)
 	autoPseudoGTIDTick := time.Tick(time.Duration(config.PseudoGTIDIntervalSeconds) * time.Second)
 	var recoveryEntrance int64
 	var snapshotTopologiesTick <-chan time.Time
 	if config.Config.SnapshotTopologiesIntervalHours > 0 {
 		snapshotTopologiesTick = time.Tick(time.Duration(config.Config.SnapshotTopologiesIntervalHours) * time.Hour)
 	}
+	var inventoryReconciliationTick <-chan time.Time
+	if config.Config.InventoryURL != "" && config.Config.InventoryReconciliationIntervalMinutes > 0 {
+		inventoryReconciliationTick = time.Tick(time.Duration(config.Config.InventoryReconciliationIntervalMinutes) * time.Minute)
+	}
 
 	runCheckAndRecoverOperationsTimeRipe := func() bool {
 		return time.Since(continuousDiscoveryStartTime) >= checkAndRecoverWaitPeriod
@@ -483,6 +500,8 @@ func ContinuousDiscovery() {
 
 	go ometrics.InitMetrics()
 	go ometrics.InitGraphiteMetrics()
+	go tracing.InitTracing()
+	go events.InitWebhooks(enqueueFailedWebhook)
 	go acceptSignals()
 	go kv.InitKVStores()
 	if config.Config.RaftEnabled {
@@ -496,6 +515,8 @@ func ContinuousDiscovery() {
 		process.GrabElection()
 	}
 
+	go rapidRediscoveryOnStartup()
+
 	log.Infof("continuous discovery: starting")
 	for {
 		select {
@@ -511,6 +532,25 @@ func ContinuousDiscovery() {
 				if IsLeaderOrActive() {
 					go inst.UpdateClusterAliases()
 					go inst.ExpireDowntime()
+					go inst.PublishTopologyChanges()
+				}
+			}()
+		case <-focusModeTick:
+			go func() {
+				// Clusters in focus mode have their instances sampled, and topology changes published, more frequently
+				if db.IsBackendOverloaded() {
+					// Shed low priority polling: focus mode sampling
+					return
+				}
+				if IsLeaderOrActive() && inst.HasFocusedClusters() {
+					go inst.RecordClusterFocusSamples()
+					go inst.PublishTopologyChanges()
+				}
+			}()
+		case <-hookDeliveryTick:
+			go func() {
+				if IsLeader() {
+					DeliverDueHooks()
 				}
 			}()
 		case <-autoPseudoGTIDTick:
@@ -523,7 +563,15 @@ func ContinuousDiscovery() {
 			// Various periodic internal maintenance tasks
 			go func() {
 				if IsLeaderOrActive() {
-					go inst.RecordInstanceCoordinatesHistory()
+					if db.IsBackendOverloaded() {
+						// Shed non-essential work: history writes and re-probing of unseen instances
+						log.Warningf("Backend overloaded; skipping coordinates history and reverification of unseen instances")
+					} else {
+						go inst.RecordInstanceCoordinatesHistory()
+						go inst.MonitorGTIDPurge()
+						go inst.MonitorPromotionCandidateQuorums()
+						go inst.ReverifyInstances()
+					}
 					go inst.ReviewUnseenInstances()
 					go inst.InjectUnseenMasters()
 
@@ -537,16 +585,31 @@ func ContinuousDiscovery() {
 					go inst.ExpireHostnameUnresolve()
 					go inst.ExpireClusterDomainName()
 					go inst.ExpireAudit()
+					go inst.ExpireMasterHistory()
+					go inst.ExpireLastRelocations()
+					go inst.ExpireIOThreadReconnects()
+					go inst.ExpireCampaigns()
 					go inst.ExpireMasterPositionEquivalence()
 					go inst.ExpirePoolInstances()
 					go inst.FlushNontrivialResolveCacheToDatabase()
 					go inst.ExpireInjectedPseudoGTID()
+					go inst.ExpirePostponementRelaxations()
+					go inst.ExpireClusterFocuses()
+					go inst.ExpireClusterWriteFreezes()
+					go inst.ExpireRecoveryRateLimits()
+					go inst.EnforceSemiSyncReplicasPerMaster()
 					go process.ExpireNodesHistory()
 					go process.ExpireAccessTokens()
+					go process.ExpireIdempotentResponses()
 					go process.ExpireAvailableNodes()
 					go ExpireFailureDetectionHistory()
 					go ExpireTopologyRecoveryHistory()
 					go ExpireTopologyRecoveryStepsHistory()
+					go ExpireRecoveryTopologyHistory()
+					go ExpireHookDeliveries()
+					go ExpireOperationIntentHistory()
+					go ResumeExpiredOperationIntents()
+					go ExpirePromotionPlans()
 
 					if runCheckAndRecoverOperationsTimeRipe() && IsLeader() {
 						go SubmitMastersToKvStores("", false)
@@ -587,9 +650,19 @@ func ContinuousDiscovery() {
 		case <-snapshotTopologiesTick:
 			go func() {
 				if IsLeaderOrActive() {
+					if db.IsBackendOverloaded() {
+						log.Warningf("Backend overloaded; skipping topology snapshot")
+						return
+					}
 					go inst.SnapshotTopologies()
 				}
 			}()
+		case <-inventoryReconciliationTick:
+			go func() {
+				if IsLeaderOrActive() {
+					inst.ReconcileInventory()
+				}
+			}()
 		}
 	}
 }
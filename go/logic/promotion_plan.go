@@ -0,0 +1,207 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+)
+
+// PromotionPlan is the outcome of candidate selection for a cluster's master, prepared by an operator and
+// executed only once confirmed, by its token, within PromotionPlanTTLSeconds
+type PromotionPlan struct {
+	Token             string
+	ClusterName       string
+	MasterKey         inst.InstanceKey
+	CandidateKey      inst.InstanceKey
+	MovedReplicas     []inst.InstanceKey // replicas expected to move below the candidate
+	LostReplicas      []inst.InstanceKey // replicas ahead of the candidate, or unable to replicate from it
+	Owner             string
+	PreparedTimestamp string
+	ExpiresTimestamp  string
+}
+
+func (this *PromotionPlan) String() string {
+	return fmt.Sprintf("%s: promote %s in place of %s, by %s, expires %s",
+		this.ClusterName, this.CandidateKey.DisplayString(), this.MasterKey.DisplayString(), this.Owner, this.ExpiresTimestamp)
+}
+
+// newPromotionPlan describes the outcome of candidate selection for given master
+func newPromotionPlan(clusterName string, masterKey *inst.InstanceKey, candidateReplica *inst.Instance, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas [](*inst.Instance)) *PromotionPlan {
+	plan := &PromotionPlan{
+		ClusterName:   clusterName,
+		MasterKey:     *masterKey,
+		CandidateKey:  candidateReplica.Key,
+		MovedReplicas: []inst.InstanceKey{},
+		LostReplicas:  []inst.InstanceKey{},
+	}
+	for _, replica := range append(equalReplicas, laterReplicas...) {
+		plan.MovedReplicas = append(plan.MovedReplicas, replica.Key)
+	}
+	for _, replica := range append(aheadReplicas, cannotReplicateReplicas...) {
+		plan.LostReplicas = append(plan.LostReplicas, replica.Key)
+	}
+	return plan
+}
+
+// sameInstanceKeys returns true when both lists hold the same keys, regardless of order
+func sameInstanceKeys(keys []inst.InstanceKey, otherKeys []inst.InstanceKey) bool {
+	keysMap := inst.NewInstanceKeyMap()
+	keysMap.AddKeys(keys)
+	otherKeysMap := inst.NewInstanceKeyMap()
+	otherKeysMap.AddKeys(otherKeys)
+	return len(*keysMap) == len(*otherKeysMap) && len(*keysMap.Intersect(otherKeysMap)) == len(*keysMap)
+}
+
+// validatePromotionPlan compares a prepared plan with the outcome of candidate selection at time of confirmation, and
+// returns an error when the plan is stale: another candidate is now the freshest, or different replicas would be
+// moved or lost than were presented to the operator
+func validatePromotionPlan(plan *PromotionPlan, currentPlan *PromotionPlan) error {
+	if !currentPlan.CandidateKey.Equals(&plan.CandidateKey) {
+		return fmt.Errorf("ConfirmMasterPromotion: %+v is now a better candidate than %+v. Will not promote; prepare again", currentPlan.CandidateKey, plan.CandidateKey)
+	}
+	if !sameInstanceKeys(currentPlan.MovedReplicas, plan.MovedReplicas) {
+		return fmt.Errorf("ConfirmMasterPromotion: replicas to move below %+v have changed since prepared. Will not promote; prepare again", plan.CandidateKey)
+	}
+	if !sameInstanceKeys(currentPlan.LostReplicas, plan.LostReplicas) {
+		return fmt.Errorf("ConfirmMasterPromotion: replicas to be lost by promoting %+v have changed since prepared. Will not promote; prepare again", plan.CandidateKey)
+	}
+	return nil
+}
+
+// publishPromotionPlan persists given plan; with raft, this is replicated to all raft members
+func publishPromotionPlan(plan *PromotionPlan) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-promotion-plan", plan)
+	} else {
+		err = writePromotionPlan(plan)
+	}
+	return err
+}
+
+// publishPromotionPlanConsumption consumes the plan of given token on all raft members, and returns true when this
+// is the call which consumed it
+func publishPromotionPlanConsumption(token string) (consumed bool, err error) {
+	if !orcraft.IsRaftEnabled() {
+		return consumePromotionPlan(token)
+	}
+	response, err := orcraft.PublishCommand("consume-promotion-plan", token)
+	if err != nil {
+		return false, err
+	}
+	consumed, _ = response.(bool)
+	return consumed, nil
+}
+
+// PrepareMasterPromotion runs candidate selection for the master of given cluster without stopping replication or
+// otherwise changing the topology, and records the outcome as a plan. The promotion takes place only once
+// ConfirmMasterPromotion is called with the plan's token, within PromotionPlanTTLSeconds.
+func PrepareMasterPromotion(clusterName string, owner string) (*PromotionPlan, error) {
+	clusterMasters, err := inst.ReadClusterWriteableMaster(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusterMasters) != 1 {
+		return nil, fmt.Errorf("PrepareMasterPromotion: cannot deduce cluster master for %+v", clusterName)
+	}
+	clusterMaster := clusterMasters[0]
+
+	candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := inst.GetCandidateReplica(&clusterMaster.Key, false)
+	if err != nil {
+		return nil, err
+	}
+	if candidateReplica == nil {
+		return nil, fmt.Errorf("PrepareMasterPromotion: no candidate replica found for %+v", clusterMaster.Key)
+	}
+	plan := newPromotionPlan(clusterName, &clusterMaster.Key, candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas)
+	plan.Token = util.NewToken().Hash
+	plan.Owner = owner
+	if err := publishPromotionPlan(plan); err != nil {
+		return nil, err
+	}
+	if prepared, found, err := readPromotionPlan(plan.Token); err == nil && found {
+		plan.PreparedTimestamp = prepared.PreparedTimestamp
+		plan.ExpiresTimestamp = prepared.ExpiresTimestamp
+	}
+	inst.AuditOperation("prepare-master-promotion", &clusterMaster.Key, fmt.Sprintf("prepared promotion of %+v by %s; %d replicas to move, %d to be lost; expires in %d seconds",
+		plan.CandidateKey, owner, len(plan.MovedReplicas), len(plan.LostReplicas), config.Config.PromotionPlanTTLSeconds))
+	return plan, nil
+}
+
+// ConfirmMasterPromotion executes the promotion plan of given token, provided it has not expired, and the cluster's
+// master, the chosen candidate and the replicas to be moved or lost are as they were when prepared. A plan is only
+// ever confirmed once.
+func ConfirmMasterPromotion(token string) (*PromotionPlan, *TopologyRecovery, error) {
+	return ConfirmMasterPromotionContext(context.Background(), token)
+}
+
+// ConfirmMasterPromotionContext is ConfirmMasterPromotion, where the recovery is traced as a child of the span carried by given context
+func ConfirmMasterPromotionContext(ctx context.Context, token string) (*PromotionPlan, *TopologyRecovery, error) {
+	plan, found, err := readPromotionPlan(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("ConfirmMasterPromotion: no promotion plan by token %s, or plan expired", token)
+	}
+	if consumed, err := publishPromotionPlanConsumption(token); err != nil {
+		return plan, nil, err
+	} else if !consumed {
+		return plan, nil, fmt.Errorf("ConfirmMasterPromotion: promotion plan %s already confirmed", token)
+	}
+	clusterMasters, err := inst.ReadClusterWriteableMaster(plan.ClusterName)
+	if err != nil {
+		return plan, nil, err
+	}
+	if len(clusterMasters) != 1 || !clusterMasters[0].Key.Equals(&plan.MasterKey) {
+		return plan, nil, fmt.Errorf("ConfirmMasterPromotion: master of %s is no longer %+v. Will not promote; prepare again", plan.ClusterName, plan.MasterKey)
+	}
+	candidate, found, err := inst.ReadInstance(&plan.CandidateKey)
+	if err != nil {
+		return plan, nil, err
+	}
+	if !found {
+		return plan, nil, fmt.Errorf("ConfirmMasterPromotion: candidate %+v not found", plan.CandidateKey)
+	}
+	if !candidate.MasterKey.Equals(&plan.MasterKey) {
+		return plan, nil, fmt.Errorf("ConfirmMasterPromotion: candidate %+v no longer replicates from %+v. Will not promote; prepare again", plan.CandidateKey, plan.MasterKey)
+	}
+	currentCandidate, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := inst.GetCandidateReplica(&plan.MasterKey, false)
+	if err != nil {
+		return plan, nil, err
+	}
+	if currentCandidate == nil {
+		return plan, nil, fmt.Errorf("ConfirmMasterPromotion: no candidate replica found for %+v. Will not promote; prepare again", plan.MasterKey)
+	}
+	currentPlan := newPromotionPlan(plan.ClusterName, &plan.MasterKey, currentCandidate, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas)
+	if err := validatePromotionPlan(plan, currentPlan); err != nil {
+		return plan, nil, err
+	}
+	inst.AuditOperation("confirm-master-promotion", &plan.MasterKey, fmt.Sprintf("confirmed promotion of %+v prepared by %s", plan.CandidateKey, plan.Owner))
+	topologyRecovery, err := ForceMasterTakeoverContext(ctx, plan.ClusterName, candidate)
+	if err != nil {
+		log.Errorf("ConfirmMasterPromotion: %+v", err)
+	}
+	return plan, topologyRecovery, err
+}
@@ -0,0 +1,121 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+func instanceKeysToCommaDelimitedList(keys []inst.InstanceKey) string {
+	keysMap := inst.NewInstanceKeyMap()
+	keysMap.AddKeys(keys)
+	return keysMap.ToCommaDelimitedList()
+}
+
+func instanceKeysFromCommaDelimitedList(list string) []inst.InstanceKey {
+	keysMap := inst.NewInstanceKeyMap()
+	if list == "" {
+		return keysMap.GetInstanceKeys()
+	}
+	if err := keysMap.ReadCommaDelimitedList(list); err != nil {
+		log.Errore(err)
+	}
+	return keysMap.GetInstanceKeys()
+}
+
+// writePromotionPlan persists a prepared promotion plan, expiring in PromotionPlanTTLSeconds
+func writePromotionPlan(plan *PromotionPlan) error {
+	_, err := db.ExecOrchestrator(`
+			insert into master_promotion_plan (
+				token, cluster_name, master_host, master_port, candidate_host, candidate_port,
+				moved_replicas, lost_replicas, owner, prepared_timestamp, expires_timestamp
+			) values (
+				?, ?, ?, ?, ?, ?,
+				?, ?, ?, NOW(), NOW() + INTERVAL ? SECOND
+			)
+		`, plan.Token, plan.ClusterName, plan.MasterKey.Hostname, plan.MasterKey.Port, plan.CandidateKey.Hostname, plan.CandidateKey.Port,
+		instanceKeysToCommaDelimitedList(plan.MovedReplicas), instanceKeysToCommaDelimitedList(plan.LostReplicas), plan.Owner, config.Config.PromotionPlanTTLSeconds,
+	)
+	return log.Errore(err)
+}
+
+// readPromotionPlan reads the promotion plan of given token, provided it has not expired
+func readPromotionPlan(token string) (plan *PromotionPlan, found bool, err error) {
+	query := `
+		select
+			token,
+			cluster_name,
+			master_host,
+			master_port,
+			candidate_host,
+			candidate_port,
+			moved_replicas,
+			lost_replicas,
+			owner,
+			prepared_timestamp,
+			expires_timestamp
+		from
+			master_promotion_plan
+		where
+			token = ?
+			and expires_timestamp >= NOW()
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(token), func(m sqlutils.RowMap) error {
+		plan = &PromotionPlan{}
+		plan.Token = m.GetString("token")
+		plan.ClusterName = m.GetString("cluster_name")
+		plan.MasterKey.Hostname = m.GetString("master_host")
+		plan.MasterKey.Port = m.GetInt("master_port")
+		plan.CandidateKey.Hostname = m.GetString("candidate_host")
+		plan.CandidateKey.Port = m.GetInt("candidate_port")
+		plan.MovedReplicas = instanceKeysFromCommaDelimitedList(m.GetString("moved_replicas"))
+		plan.LostReplicas = instanceKeysFromCommaDelimitedList(m.GetString("lost_replicas"))
+		plan.Owner = m.GetString("owner")
+		plan.PreparedTimestamp = m.GetString("prepared_timestamp")
+		plan.ExpiresTimestamp = m.GetString("expires_timestamp")
+		return nil
+	})
+	return plan, (plan != nil), log.Errore(err)
+}
+
+// consumePromotionPlan deletes the promotion plan of given token, and returns true when this call is the one which
+// deleted it; this makes sure a plan is confirmed at most once
+func consumePromotionPlan(token string) (consumed bool, err error) {
+	sqlResult, err := db.ExecOrchestrator(`
+			delete from master_promotion_plan where token = ? and expires_timestamp >= NOW()
+		`, token,
+	)
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	rows, err := sqlResult.RowsAffected()
+	return rows > 0, log.Errore(err)
+}
+
+// ExpirePromotionPlans removes promotion plans which were not confirmed in time
+func ExpirePromotionPlans() error {
+	_, err := db.ExecOrchestrator(`
+			delete from master_promotion_plan where expires_timestamp < NOW()
+		`,
+	)
+	return log.Errore(err)
+}
@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewPromotionPlan(t *testing.T) {
+	masterKey := &inst.InstanceKey{Hostname: "master", Port: 3306}
+	candidate := newTestInstance("candidate", 3306)
+	ahead := [](*inst.Instance){newTestInstance("ahead", 3306)}
+	equal := [](*inst.Instance){newTestInstance("equal", 3306)}
+	later := [](*inst.Instance){newTestInstance("later", 3306)}
+	cannotReplicate := [](*inst.Instance){newTestInstance("cannot-replicate", 3306)}
+
+	plan := newPromotionPlan("cluster", masterKey, candidate, ahead, equal, later, cannotReplicate)
+	test.S(t).ExpectEquals(plan.ClusterName, "cluster")
+	test.S(t).ExpectTrue(plan.MasterKey.Equals(masterKey))
+	test.S(t).ExpectTrue(plan.CandidateKey.Equals(&candidate.Key))
+	test.S(t).ExpectTrue(sameInstanceKeys(plan.MovedReplicas, []inst.InstanceKey{equal[0].Key, later[0].Key}))
+	test.S(t).ExpectTrue(sameInstanceKeys(plan.LostReplicas, []inst.InstanceKey{ahead[0].Key, cannotReplicate[0].Key}))
+}
+
+func TestSameInstanceKeys(t *testing.T) {
+	key1 := inst.InstanceKey{Hostname: "host1", Port: 3306}
+	key2 := inst.InstanceKey{Hostname: "host2", Port: 3306}
+	key3 := inst.InstanceKey{Hostname: "host3", Port: 3306}
+
+	tests := []struct {
+		name      string
+		keys      []inst.InstanceKey
+		otherKeys []inst.InstanceKey
+		expected  bool
+	}{
+		{"both empty", []inst.InstanceKey{}, []inst.InstanceKey{}, true},
+		{"same order", []inst.InstanceKey{key1, key2}, []inst.InstanceKey{key1, key2}, true},
+		{"different order", []inst.InstanceKey{key1, key2}, []inst.InstanceKey{key2, key1}, true},
+		{"missing key", []inst.InstanceKey{key1, key2}, []inst.InstanceKey{key1}, false},
+		{"extra key", []inst.InstanceKey{key1}, []inst.InstanceKey{key1, key2}, false},
+		{"different key", []inst.InstanceKey{key1, key2}, []inst.InstanceKey{key1, key3}, false},
+	}
+	for _, tt := range tests {
+		test.S(t).ExpectEquals(sameInstanceKeys(tt.keys, tt.otherKeys), tt.expected)
+	}
+}
+
+func TestValidatePromotionPlan(t *testing.T) {
+	masterKey := inst.InstanceKey{Hostname: "master", Port: 3306}
+	candidateKey := inst.InstanceKey{Hostname: "candidate", Port: 3306}
+	otherCandidateKey := inst.InstanceKey{Hostname: "other-candidate", Port: 3306}
+	replicaKey := inst.InstanceKey{Hostname: "replica", Port: 3306}
+	laggingKey := inst.InstanceKey{Hostname: "lagging", Port: 3306}
+
+	plan := &PromotionPlan{
+		MasterKey:     masterKey,
+		CandidateKey:  candidateKey,
+		MovedReplicas: []inst.InstanceKey{replicaKey, otherCandidateKey},
+		LostReplicas:  []inst.InstanceKey{laggingKey},
+	}
+	tests := []struct {
+		name          string
+		currentPlan   *PromotionPlan
+		expectedError string
+	}{
+		{
+			name: "unchanged",
+			currentPlan: &PromotionPlan{
+				CandidateKey:  candidateKey,
+				MovedReplicas: []inst.InstanceKey{otherCandidateKey, replicaKey},
+				LostReplicas:  []inst.InstanceKey{laggingKey},
+			},
+		},
+		{
+			name: "fresher candidate",
+			currentPlan: &PromotionPlan{
+				CandidateKey:  otherCandidateKey,
+				MovedReplicas: []inst.InstanceKey{candidateKey, replicaKey},
+				LostReplicas:  []inst.InstanceKey{laggingKey},
+			},
+			expectedError: "is now a better candidate",
+		},
+		{
+			name: "replica no longer moved",
+			currentPlan: &PromotionPlan{
+				CandidateKey:  candidateKey,
+				MovedReplicas: []inst.InstanceKey{otherCandidateKey},
+				LostReplicas:  []inst.InstanceKey{laggingKey, replicaKey},
+			},
+			expectedError: "replicas to move",
+		},
+		{
+			name: "lost replica recovered",
+			currentPlan: &PromotionPlan{
+				CandidateKey:  candidateKey,
+				MovedReplicas: []inst.InstanceKey{replicaKey, otherCandidateKey},
+				LostReplicas:  []inst.InstanceKey{},
+			},
+			expectedError: "replicas to be lost",
+		},
+	}
+	for _, tt := range tests {
+		err := validatePromotionPlan(plan, tt.currentPlan)
+		if tt.expectedError == "" {
+			test.S(t).ExpectNil(err)
+		} else {
+			test.S(t).ExpectNotNil(err)
+			test.S(t).ExpectTrue(strings.Contains(err.Error(), tt.expectedError))
+		}
+	}
+}
+
+func TestInstanceKeysCommaDelimitedList(t *testing.T) {
+	keys := []inst.InstanceKey{
+		{Hostname: "host1", Port: 3306},
+		{Hostname: "host2", Port: 3307},
+	}
+	list := instanceKeysToCommaDelimitedList(keys)
+	test.S(t).ExpectTrue(sameInstanceKeys(instanceKeysFromCommaDelimitedList(list), keys))
+	test.S(t).ExpectEquals(instanceKeysToCommaDelimitedList([]inst.InstanceKey{}), "")
+	test.S(t).ExpectEquals(len(instanceKeysFromCommaDelimitedList("")), 0)
+}
+
+func TestCommandApplierPromotionPlanMalformed(t *testing.T) {
+	applier := NewCommandApplier()
+	_, isError := applier.writePromotionPlan([]byte("{")).(error)
+	test.S(t).ExpectTrue(isError)
+	_, isError = applier.consumePromotionPlan([]byte("{")).(error)
+	test.S(t).ExpectTrue(isError)
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+)
+
+// rapidRediscoveryWorker discovers instances off the discovery queue for as long as rapid rediscovery is active
+func rapidRediscoveryWorker() {
+	for inst.IsRapidRediscoveryActive() {
+		instanceKey := discoveryQueue.Consume()
+		if IsLeaderOrActive() {
+			DiscoverInstance(instanceKey)
+		}
+		discoveryQueue.Release(instanceKey)
+	}
+}
+
+// RapidRediscovery crawls the fleet from given seed instances, with RapidRediscoveryConcurrency discovery workers
+// added to the usual ones, and with a minimal probe set for instances not yet known. This rebuilds orchestrator's
+// state quickly after a fresh install or backend loss. Rapid rediscovery ends once the crawl drains the discovery
+// queue, or after RapidRediscoveryMaxSeconds; normal polling then fills in whatever the minimal probe skipped.
+// Should rapid rediscovery already be active, the seeds join the ongoing crawl.
+func RapidRediscovery(seeds []inst.InstanceKey) error {
+	if !IsLeaderOrActive() {
+		return fmt.Errorf("RapidRediscovery: this node is not the active node")
+	}
+	if len(seeds) == 0 {
+		return fmt.Errorf("RapidRediscovery: no seeds given")
+	}
+	if !inst.BeginRapidRediscovery() {
+		for _, seed := range seeds {
+			discoveryQueue.Push(seed)
+		}
+		return nil
+	}
+	log.Infof("RapidRediscovery: crawling the fleet from %d seeds", len(seeds))
+	inst.AuditOperation("rapid-rediscovery", nil, fmt.Sprintf("started from %d seeds", len(seeds)))
+
+	startTime := time.Now()
+	startDiscoveries := discoveriesCounter.Count()
+	for i := uint(0); i < config.Config.RapidRediscoveryConcurrency; i++ {
+		go rapidRediscoveryWorker()
+	}
+	for _, seed := range seeds {
+		discoveryQueue.Push(seed)
+	}
+	go func() {
+		defer inst.EndRapidRediscovery()
+
+		maxDuration := time.Duration(config.Config.RapidRediscoveryMaxSeconds) * time.Second
+		outcome := "fleet crawled"
+		for range time.Tick(time.Second) {
+			if discoveryQueue.QueueLen() == 0 && discoveryQueue.ActiveLen() == 0 {
+				break
+			}
+			if time.Since(startTime) >= maxDuration {
+				outcome = "timed out"
+				break
+			}
+		}
+		summary := fmt.Sprintf("%s: %d discoveries in %s", outcome, discoveriesCounter.Count()-startDiscoveries, time.Since(startTime).Round(time.Second))
+		log.Infof("RapidRediscovery: %s", summary)
+		inst.AuditOperation("rapid-rediscovery", nil, summary)
+	}()
+	return nil
+}
+
+// rapidRediscoveryOnStartup starts rapid rediscovery from RapidRediscoverySeeds once this node is the active node,
+// provided the backend knows no instances at that time
+func rapidRediscoveryOnStartup() {
+	if len(config.Config.RapidRediscoverySeeds) == 0 {
+		return
+	}
+	seeds := []inst.InstanceKey{}
+	for _, seed := range config.Config.RapidRediscoverySeeds {
+		instanceKey, err := inst.ParseResolveInstanceKey(seed)
+		if err != nil {
+			log.Errorf("rapidRediscoveryOnStartup: cannot parse seed %s: %+v", seed, err)
+			continue
+		}
+		seeds = append(seeds, *instanceKey)
+	}
+	for range time.Tick(time.Second) {
+		if IsLeaderOrActive() {
+			break
+		}
+	}
+	instanceKeys, err := inst.ReadAllInstanceKeys()
+	if err != nil {
+		log.Errore(err)
+		return
+	}
+	if len(instanceKeys) > 0 {
+		log.Debugf("rapidRediscoveryOnStartup: backend knows %d instances; not needed", len(instanceKeys))
+		return
+	}
+	if err := RapidRediscovery(seeds); err != nil {
+		log.Errore(err)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// recoveryRateLimitMutex serializes the counting of automated recoveries against cluster rate limits
+var recoveryRateLimitMutex sync.Mutex
+
+// publishRecoveryRateLimit persists given automated recovery counters; with raft, this is replicated to all raft members
+func publishRecoveryRateLimit(rateLimit *inst.RecoveryRateLimit) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-recovery-rate-limit", rateLimit)
+	} else {
+		err = inst.WriteRecoveryRateLimit(rateLimit)
+	}
+	return err
+}
+
+// ResetRecoveryRateLimit resets the automated recovery counters, and any backoff, of given cluster, or of all
+// clusters when clusterName is empty
+func ResetRecoveryRateLimit(clusterName string) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("reset-recovery-rate-limit", clusterName)
+	} else {
+		err = inst.DeleteRecoveryRateLimit(clusterName)
+	}
+	return err
+}
+
+// admitRecoveryByRateLimit counts an automated recovery against its cluster's RecoveryRateLimitPerHour, and returns
+// an error when the recovery is refused: the cluster has reached its limit, or is backing off. Failing to read or
+// write the counters does not refuse the recovery.
+func admitRecoveryByRateLimit(analysisEntry *inst.ReplicationAnalysis) error {
+	clusterName := analysisEntry.ClusterDetails.ClusterName
+	limitPerHour := inst.GetRecoveryRateLimitPerHour(clusterName)
+	if limitPerHour == 0 {
+		return nil
+	}
+	recoveryRateLimitMutex.Lock()
+	defer recoveryRateLimitMutex.Unlock()
+
+	rateLimit, err := inst.ReadRecoveryRateLimit(clusterName)
+	if err != nil {
+		log.Errore(err)
+		return nil
+	}
+	initialBackoff := time.Duration(config.Config.RecoveryRateLimitBackoffMinutes) * time.Minute
+	maxBackoff := time.Duration(config.Config.RecoveryRateLimitMaxBackoffMinutes) * time.Minute
+	admitted, changed, reason := rateLimit.Admit(time.Now(), limitPerHour, initialBackoff, maxBackoff)
+	if changed {
+		if err := publishRecoveryRateLimit(rateLimit); err != nil {
+			log.Errore(err)
+		}
+	}
+	if admitted {
+		return nil
+	}
+	if changed {
+		// Audited as the backoff begins, rather than on each analysis refused while backing off
+		inst.AuditOperation("recovery-rate-limited", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("%+v: %s", analysisEntry.Analysis, reason))
+	}
+	return fmt.Errorf("AttemptRecoveryRegistration: %+v on %+v: %s. You may reset the rate limit (-c reset-recovery-rate-limit) to remove this blockage", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, reason)
+}
@@ -0,0 +1,188 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// RecoveryTopologyInstance is a server's place in its cluster's topology, as recorded prior to a recovery
+type RecoveryTopologyInstance struct {
+	RecoveryUID string
+	Key         inst.InstanceKey
+	MasterKey   inst.InstanceKey
+	ReadOnly    bool
+}
+
+// recordRecoveryTopology records the topology of the recovered cluster as it is prior to the recovery, such that
+// the recovery may be undone via UndoRecovery. Failure to record does not fail the recovery.
+func recordRecoveryTopology(topologyRecovery *TopologyRecovery) {
+	instances, err := inst.ReadClusterInstances(topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName)
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("cannot record pre-recovery topology: %+v", err))
+		return
+	}
+	topology := []RecoveryTopologyInstance{}
+	for _, instance := range instances {
+		topology = append(topology, RecoveryTopologyInstance{
+			RecoveryUID: topologyRecovery.UID,
+			Key:         instance.Key,
+			MasterKey:   instance.MasterKey,
+			ReadOnly:    instance.ReadOnly,
+		})
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-recovery-topology", topology)
+	} else {
+		err = writeRecoveryTopology(topology)
+	}
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("cannot record pre-recovery topology: %+v", err))
+		return
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("recorded pre-recovery topology of %d instances", len(topology)))
+}
+
+// recoveryTopologyRestoreOrder lists the recorded instances below given master, such that each instance follows
+// its own recorded master. Instances not recorded below the master, such as the master itself, are not listed.
+func recoveryTopologyRestoreOrder(topology []RecoveryTopologyInstance, masterKey inst.InstanceKey) []RecoveryTopologyInstance {
+	ordered := []RecoveryTopologyInstance{}
+	listed := map[inst.InstanceKey]bool{masterKey: true}
+	parentKeys := []inst.InstanceKey{masterKey}
+	for len(parentKeys) > 0 {
+		parentKey := parentKeys[0]
+		parentKeys = parentKeys[1:]
+		for _, instance := range topology {
+			if listed[instance.Key] || !instance.MasterKey.Equals(&parentKey) {
+				continue
+			}
+			listed[instance.Key] = true
+			ordered = append(ordered, instance)
+			parentKeys = append(parentKeys, instance.Key)
+		}
+	}
+	return ordered
+}
+
+// UndoRecovery reverts a master recovery which turned out to be unnecessary: given the failed master is back, the
+// promoted replica is set read-only and moved back below it, and all other servers are moved back to their
+// pre-recovery masters. Requires GTID. Refuses when the promoted replica has transactions the failed master does
+// not, as reverting would lose them.
+func UndoRecovery(recoveryUID string) (failedMaster *inst.Instance, err error) {
+	recoveries, err := ReadRecoveryByUID(recoveryUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(recoveries) == 0 {
+		return nil, fmt.Errorf("UndoRecovery: recovery %s not found", recoveryUID)
+	}
+	topologyRecovery := &recoveries[0]
+	if !topologyRecovery.IsSuccessful || topologyRecovery.SuccessorKey == nil {
+		return nil, fmt.Errorf("UndoRecovery: recovery %s did not promote a replica", recoveryUID)
+	}
+	topology, err := ReadRecoveryTopology(recoveryUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(topology) == 0 {
+		return nil, fmt.Errorf("UndoRecovery: no pre-recovery topology is recorded for recovery %s; only master recoveries are recorded", recoveryUID)
+	}
+	failedMasterKey := topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	promotedKey := *topologyRecovery.SuccessorKey
+
+	failedMaster, err = inst.ReadTopologyInstance(&failedMasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("UndoRecovery: failed master %+v is not back: %+v", failedMasterKey, err)
+	}
+	if failedMaster.IsReplica() {
+		return failedMaster, fmt.Errorf("UndoRecovery: failed master %+v replicates from %+v; detach it first, or promote it via graceful-master-takeover", failedMasterKey, failedMaster.MasterKey)
+	}
+	promoted, err := inst.ReadTopologyInstance(&promotedKey)
+	if err != nil {
+		return failedMaster, err
+	}
+	if !failedMaster.SupportsOracleGTID || !promoted.SupportsOracleGTID {
+		return failedMaster, fmt.Errorf("UndoRecovery: requires GTID on both %+v and %+v", failedMasterKey, promotedKey)
+	}
+
+	clusterOperation, err := inst.BeginClusterOperation(promoted.ClusterName, fmt.Sprintf("recover-undo of %s", recoveryUID), inst.GetMaintenanceOwner())
+	if err != nil {
+		return failedMaster, err
+	}
+	defer inst.EndClusterOperation(clusterOperation)
+
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("UndoRecovery: reverting promotion of %+v in favor of %+v", promotedKey, failedMasterKey))
+	if promoted, err = inst.SetReadOnly(&promotedKey, true); err != nil {
+		return failedMaster, err
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- UndoRecovery: applied read-only=1 on promoted master %+v", promotedKey))
+
+	// With the promoted master read-only, its executed GTID set is final
+	missingTransactions, err := inst.GTIDSubtract(&failedMasterKey, promoted.ExecutedGtidSet, failedMaster.ExecutedGtidSet)
+	if err != nil {
+		return failedMaster, err
+	}
+	if missingTransactions != "" {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- UndoRecovery: aborted; failed master %+v lacks transactions of promoted master: %s", failedMasterKey, missingTransactions))
+		if _, err := inst.SetReadOnly(&promotedKey, false); err != nil {
+			log.Errore(err)
+		}
+		return failedMaster, fmt.Errorf("UndoRecovery: failed master %+v lacks transactions of promoted master %+v: %s; reverting would lose them", failedMasterKey, promotedKey, missingTransactions)
+	}
+
+	errs := []string{}
+	for _, recorded := range recoveryTopologyRestoreOrder(topology, failedMasterKey) {
+		instance, err := inst.ReadTopologyInstance(&recorded.Key)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%+v: %+v", recorded.Key, err))
+			continue
+		}
+		if instance.MasterKey.Equals(&recorded.MasterKey) {
+			continue
+		}
+		// Restoring the recorded topology does not create replication edges which were not there before
+		if _, err := inst.MoveBelowGTIDContext(inst.WithWANRelocationAllowed(context.Background()), &recorded.Key, &recorded.MasterKey); err != nil {
+			errs = append(errs, fmt.Sprintf("%+v: %+v", recorded.Key, err))
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- UndoRecovery: failed moving %+v below %+v: %+v", recorded.Key, recorded.MasterKey, err))
+			continue
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- UndoRecovery: moved %+v below %+v", recorded.Key, recorded.MasterKey))
+	}
+	for _, recorded := range topology {
+		if recorded.Key.Equals(&failedMasterKey) && !recorded.ReadOnly {
+			_, err := inst.SetReadOnly(&failedMasterKey, false)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- UndoRecovery: applying read-only=0 on failed master: success=%t", (err == nil)))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%+v: %+v", failedMasterKey, err))
+			}
+		}
+	}
+	writeClusterMasterKVPairs(topologyRecovery, failedMaster)
+
+	inst.AuditOperation("recover-undo", &failedMasterKey, fmt.Sprintf("reverted recovery %s; demoted %+v", recoveryUID, promotedKey))
+	if len(errs) > 0 {
+		return failedMaster, fmt.Errorf("UndoRecovery: reverted recovery %s, though some servers were not restored: %s", recoveryUID, strings.Join(errs, "; "))
+	}
+	return failedMaster, nil
+}
@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// writeRecoveryTopology persists the pre-recovery topology of a recovery
+func writeRecoveryTopology(topology []RecoveryTopologyInstance) error {
+	for _, instance := range topology {
+		_, err := db.ExecOrchestrator(`
+				replace into topology_recovery_topology (
+					recovery_uid, hostname, port, master_host, master_port, read_only, recorded_timestamp
+				) values (
+					?, ?, ?, ?, ?, ?, NOW()
+				)
+			`, instance.RecoveryUID, instance.Key.Hostname, instance.Key.Port, instance.MasterKey.Hostname, instance.MasterKey.Port, instance.ReadOnly,
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+	}
+	return nil
+}
+
+// ReadRecoveryTopology reads the topology of a cluster as recorded prior to given recovery
+func ReadRecoveryTopology(recoveryUID string) ([]RecoveryTopologyInstance, error) {
+	res := []RecoveryTopologyInstance{}
+	query := `
+		select
+			recovery_uid, hostname, port, master_host, master_port, read_only
+		from
+			topology_recovery_topology
+		where
+			recovery_uid = ?
+		order by
+			hostname, port
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(recoveryUID), func(m sqlutils.RowMap) error {
+		res = append(res, RecoveryTopologyInstance{
+			RecoveryUID: m.GetString("recovery_uid"),
+			Key:         inst.InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			MasterKey:   inst.InstanceKey{Hostname: m.GetString("master_host"), Port: m.GetInt("master_port")},
+			ReadOnly:    m.GetBool("read_only"),
+		})
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ExpireRecoveryTopologyHistory removes old rows from the topology_recovery_topology table
+func ExpireRecoveryTopologyHistory() error {
+	return inst.ExpireTableData("topology_recovery_topology", "recorded_timestamp")
+}
@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestRecoveryTopologyRestoreOrder(t *testing.T) {
+	key := func(hostname string) inst.InstanceKey {
+		return inst.InstanceKey{Hostname: hostname, Port: 3306}
+	}
+	entry := func(hostname string, masterHostname string) RecoveryTopologyInstance {
+		return RecoveryTopologyInstance{Key: key(hostname), MasterKey: key(masterHostname)}
+	}
+	masterKey := key("master")
+
+	tests := []struct {
+		name     string
+		topology []RecoveryTopologyInstance
+		expected []string
+	}{
+		{
+			name:     "empty",
+			topology: []RecoveryTopologyInstance{},
+			expected: []string{},
+		},
+		{
+			name:     "master only",
+			topology: []RecoveryTopologyInstance{{Key: masterKey}},
+			expected: []string{},
+		},
+		{
+			name:     "flat, in recorded order",
+			topology: []RecoveryTopologyInstance{{Key: masterKey}, entry("r2", "master"), entry("r1", "master")},
+			expected: []string{"r2", "r1"},
+		},
+		{
+			name:     "chain recorded bottom up",
+			topology: []RecoveryTopologyInstance{entry("r3", "r2"), entry("r2", "r1"), entry("r1", "master"), {Key: masterKey}},
+			expected: []string{"r1", "r2", "r3"},
+		},
+		{
+			name:     "levels before their replicas",
+			topology: []RecoveryTopologyInstance{entry("r1a", "r1"), entry("r1", "master"), entry("r2a", "r2"), entry("r2", "master")},
+			expected: []string{"r1", "r2", "r1a", "r2a"},
+		},
+		{
+			name:     "co-master",
+			topology: []RecoveryTopologyInstance{entry("master", "co-master"), entry("co-master", "master"), entry("r1", "master")},
+			expected: []string{"co-master", "r1"},
+		},
+		{
+			name:     "not below master",
+			topology: []RecoveryTopologyInstance{entry("r1", "master"), entry("other", "other-master"), entry("other-replica", "other")},
+			expected: []string{"r1"},
+		},
+		{
+			name:     "detached cycle",
+			topology: []RecoveryTopologyInstance{entry("r1", "master"), entry("a", "b"), entry("b", "a")},
+			expected: []string{"r1"},
+		},
+	}
+	for _, tt := range tests {
+		ordered := recoveryTopologyRestoreOrder(tt.topology, masterKey)
+		orderedHostnames := []string{}
+		for _, instance := range ordered {
+			orderedHostnames = append(orderedHostnames, instance.Key.Hostname)
+		}
+		test.S(t).ExpectEquals(len(orderedHostnames), len(tt.expected))
+		for i := range tt.expected {
+			if i < len(orderedHostnames) {
+				test.S(t).ExpectEquals(orderedHostnames[i], tt.expected[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+	"github.com/github/orchestrator/go/process"
+	"github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// UpgradeStep is a single node in a rolling upgrade plan
+type UpgradeStep struct {
+	Node       string
+	AppVersion string
+	IsLeader   bool
+	Upgraded   bool
+}
+
+// ReadRollingUpgradePlan lists the orchestrator nodes in the order by which they should be restarted when
+// upgrading to given version. The leader (or active node) comes last, such that leadership only changes once.
+// With raft, the plan is only known to the leader.
+func ReadRollingUpgradePlan(targetVersion string) (steps [](*UpgradeStep), err error) {
+	if orcraft.IsRaftEnabled() {
+		if !orcraft.IsLeader() {
+			return steps, fmt.Errorf("ReadRollingUpgradePlan: rolling upgrade plan is only available on the raft leader")
+		}
+		for node, appVersion := range orcraft.HealthyMemberVersions() {
+			steps = append(steps, &UpgradeStep{Node: node, AppVersion: appVersion, IsLeader: node == config.Config.RaftAdvertise})
+		}
+	} else {
+		nodes, err := process.ReadAvailableNodes(true)
+		if err != nil {
+			return steps, err
+		}
+		electedNode, _, err := process.ElectedNode()
+		if err != nil {
+			return steps, err
+		}
+		for _, node := range nodes {
+			steps = append(steps, &UpgradeStep{Node: node.Hostname, AppVersion: node.AppVersion, IsLeader: node.Hostname == electedNode.Hostname})
+		}
+	}
+	for _, step := range steps {
+		step.Upgraded = (targetVersion != "" && step.AppVersion == targetVersion)
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		if steps[i].IsLeader != steps[j].IsLeader {
+			return steps[j].IsLeader
+		}
+		return steps[i].Node < steps[j].Node
+	})
+	return steps, nil
+}
+
+// RestartForUpgrade prepares this node for a restart: it gives up leadership, if held, and then asynchronously
+// runs RestartForUpgradeCommand, which is expected to replace the binary and restart this service.
+func RestartForUpgrade(targetVersion string) error {
+	if config.Config.RestartForUpgradeCommand == "" {
+		return fmt.Errorf("RestartForUpgrade: RestartForUpgradeCommand is not configured")
+	}
+	if orcraft.IsRaftEnabled() {
+		if orcraft.IsLeader() {
+			if err := orcraft.Yield(); err != nil {
+				return log.Errore(err)
+			}
+		}
+	} else if IsLeader() {
+		if err := process.Reelect(); err != nil {
+			return log.Errore(err)
+		}
+	}
+	inst.AuditOperation("restart-for-upgrade", nil, fmt.Sprintf("%s: upgrading %s to %s", process.ThisHostname, config.RuntimeCLIFlags.ConfiguredVersion, targetVersion))
+
+	env := []string{
+		fmt.Sprintf("ORC_APP_VERSION=%s", config.RuntimeCLIFlags.ConfiguredVersion),
+		fmt.Sprintf("ORC_TARGET_VERSION=%s", targetVersion),
+	}
+	go func() {
+		if err := os.CommandRun(config.Config.RestartForUpgradeCommand, env); err != nil {
+			log.Errorf("RestartForUpgrade: %+v", err)
+		}
+	}()
+	return nil
+}
@@ -50,7 +50,12 @@ type SnapshotData struct {
 	Detections,
 	KVStore,
 	Recovery,
-	RecoverySteps sqlutils.NamedResultData
+	RecoverySteps,
+	RecoveryTopology,
+	OperationIntents,
+	DRPairs,
+	IdempotentResponses,
+	PromotionPlans sqlutils.NamedResultData
 
 	LeaderURI string
 }
@@ -101,7 +106,12 @@ func CreateSnapshotData() *SnapshotData {
 	readTableData("kv_store", &snapshotData.KVStore)
 	readTableData("topology_recovery", &snapshotData.Recovery)
 	readTableData("topology_recovery_steps", &snapshotData.RecoverySteps)
+	readTableData("topology_recovery_topology", &snapshotData.RecoveryTopology)
 	readTableData("cluster_injected_pseudo_gtid", &snapshotData.InjectedPseudoGTIDClusters)
+	readTableData("operation_intent", &snapshotData.OperationIntents)
+	readTableData("dr_pair", &snapshotData.DRPairs)
+	readTableData("api_idempotent_response", &snapshotData.IdempotentResponses)
+	readTableData("master_promotion_plan", &snapshotData.PromotionPlans)
 
 	log.Debugf("raft snapshot data created")
 	return snapshotData
@@ -207,7 +217,12 @@ func (this *SnapshotDataCreatorApplier) Restore(rc io.ReadCloser) error {
 	writeTableData("topology_recovery", &snapshotData.Recovery)
 	writeTableData("topology_failure_detection", &snapshotData.Detections)
 	writeTableData("topology_recovery_steps", &snapshotData.RecoverySteps)
+	writeTableData("topology_recovery_topology", &snapshotData.RecoveryTopology)
 	writeTableData("cluster_injected_pseudo_gtid", &snapshotData.InjectedPseudoGTIDClusters)
+	writeTableData("operation_intent", &snapshotData.OperationIntents)
+	writeTableData("dr_pair", &snapshotData.DRPairs)
+	writeTableData("api_idempotent_response", &snapshotData.IdempotentResponses)
+	writeTableData("master_promotion_plan", &snapshotData.PromotionPlans)
 
 	// recovery disable
 	{
@@ -0,0 +1,64 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"github.com/github/orchestrator/go/inst"
+)
+
+// topologyOperations are the operations a recovery step applies on MySQL instances. Recovery steps take them
+// as a parameter; in production these are instanceTopologyOperations.
+type topologyOperations interface {
+	ReadTopologyInstance(instanceKey *inst.InstanceKey) (*inst.Instance, error)
+	ReadReplicaInstances(masterKey *inst.InstanceKey) ([](*inst.Instance), error)
+	ResetSlave(instanceKey *inst.InstanceKey) (*inst.Instance, error)
+	SetSuperReadOnly(instanceKey *inst.InstanceKey, superReadOnly bool) (*inst.Instance, error)
+	SetSemiSyncMaster(instanceKey *inst.InstanceKey, enableMaster bool) (*inst.Instance, error)
+	SetSemiSyncReplica(instanceKey *inst.InstanceKey, enableReplica bool) (*inst.Instance, error)
+	StartSlave(instanceKey *inst.InstanceKey) (*inst.Instance, error)
+}
+
+// instanceTopologyOperations applies topology operations on the actual instances
+type instanceTopologyOperations struct{}
+
+func (this instanceTopologyOperations) ReadTopologyInstance(instanceKey *inst.InstanceKey) (*inst.Instance, error) {
+	return inst.ReadTopologyInstance(instanceKey)
+}
+
+func (this instanceTopologyOperations) ReadReplicaInstances(masterKey *inst.InstanceKey) ([](*inst.Instance), error) {
+	return inst.ReadReplicaInstances(masterKey)
+}
+
+func (this instanceTopologyOperations) ResetSlave(instanceKey *inst.InstanceKey) (*inst.Instance, error) {
+	return inst.ResetSlaveOperation(instanceKey)
+}
+
+func (this instanceTopologyOperations) SetSuperReadOnly(instanceKey *inst.InstanceKey, superReadOnly bool) (*inst.Instance, error) {
+	return inst.SetSuperReadOnly(instanceKey, superReadOnly)
+}
+
+func (this instanceTopologyOperations) SetSemiSyncMaster(instanceKey *inst.InstanceKey, enableMaster bool) (*inst.Instance, error) {
+	return inst.SetSemiSyncMaster(instanceKey, enableMaster)
+}
+
+func (this instanceTopologyOperations) SetSemiSyncReplica(instanceKey *inst.InstanceKey, enableReplica bool) (*inst.Instance, error) {
+	return inst.SetSemiSyncReplica(instanceKey, enableReplica)
+}
+
+func (this instanceTopologyOperations) StartSlave(instanceKey *inst.InstanceKey) (*inst.Instance, error) {
+	return inst.StartSlave(instanceKey)
+}
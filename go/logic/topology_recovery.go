@@ -17,23 +17,27 @@
 package logic
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
 	goos "os"
 	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/github/orchestrator/go/attributes"
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/events"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/kv"
 	ometrics "github.com/github/orchestrator/go/metrics"
 	"github.com/github/orchestrator/go/os"
 	"github.com/github/orchestrator/go/process"
 	"github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/tracing"
 	"github.com/github/orchestrator/go/util"
 	"github.com/openark/golib/log"
 	"github.com/patrickmn/go-cache"
@@ -48,6 +52,8 @@ const (
 	MasterRecovery             RecoveryType = "MasterRecovery"
 	CoMasterRecovery                        = "CoMasterRecovery"
 	IntermediateMasterRecovery              = "IntermediateMasterRecovery"
+	BinlogServerRecovery                    = "BinlogServerRecovery"
+	DRStandbyMasterRecovery                 = "DRStandbyMasterRecovery"
 )
 
 type RecoveryAcknowledgement struct {
@@ -113,6 +119,7 @@ type TopologyRecovery struct {
 	RelatedRecoveryId         int64
 	Type                      RecoveryType
 	RecoveryType              MasterRecoveryType
+	Span                      *tracing.Span `json:"-"`
 }
 
 func NewTopologyRecovery(replicationAnalysis inst.ReplicationAnalysis) *TopologyRecovery {
@@ -127,6 +134,26 @@ func NewTopologyRecovery(replicationAnalysis inst.ReplicationAnalysis) *Topology
 	return topologyRecovery
 }
 
+// recoveryMasterChangeContext returns an unbounded context by which master changes are attributed to given recovery,
+// and topology operations are traced as children of the recovery's span
+func recoveryMasterChangeContext(topologyRecovery *TopologyRecovery) context.Context {
+	origin := inst.NewMasterChangeOrigin(inst.MasterChangeCauseRecovery, inst.GetMaintenanceOwner(), topologyRecovery.UID)
+	return inst.WithMasterChangeOrigin(tracing.ContextWithSpan(context.Background(), topologyRecovery.Span), origin)
+}
+
+// recoveryTracingContext returns an unbounded context by which backend queries are traced as children of the
+// recovery's span
+func recoveryTracingContext(topologyRecovery *TopologyRecovery) context.Context {
+	return tracing.ContextWithSpan(context.Background(), topologyRecovery.Span)
+}
+
+// recoveryOperationContext returns a context for topology operations run as part of given recovery. These are
+// bounded by TopologyOperationTimeoutSeconds, if configured (postponed functions are not), and master changes
+// are attributed to the recovery.
+func recoveryOperationContext(topologyRecovery *TopologyRecovery) (context.Context, context.CancelFunc) {
+	return inst.NewOperationContext(recoveryMasterChangeContext(topologyRecovery), 0)
+}
+
 func (this *TopologyRecovery) AddError(err error) error {
 	if err != nil {
 		this.AllErrors = append(this.AllErrors, err.Error())
@@ -157,10 +184,11 @@ func NewTopologyRecoveryStep(uid string, message string) *TopologyRecoveryStep {
 type MasterRecoveryType string
 
 const (
-	NotMasterRecovery          MasterRecoveryType = "NotMasterRecovery"
-	MasterRecoveryGTID                            = "MasterRecoveryGTID"
-	MasterRecoveryPseudoGTID                      = "MasterRecoveryPseudoGTID"
-	MasterRecoveryBinlogServer                    = "MasterRecoveryBinlogServer"
+	NotMasterRecovery              MasterRecoveryType = "NotMasterRecovery"
+	MasterRecoveryGTID                                = "MasterRecoveryGTID"
+	MasterRecoveryPseudoGTID                          = "MasterRecoveryPseudoGTID"
+	MasterRecoveryBinlogServer                        = "MasterRecoveryBinlogServer"
+	MasterRecoveryGroupReplication                    = "MasterRecoveryGroupReplication"
 )
 
 var emergencyReadTopologyInstanceMap *cache.Cache
@@ -186,6 +214,9 @@ var recoverDeadMasterFailureCounter = metrics.NewCounter()
 var recoverDeadIntermediateMasterCounter = metrics.NewCounter()
 var recoverDeadIntermediateMasterSuccessCounter = metrics.NewCounter()
 var recoverDeadIntermediateMasterFailureCounter = metrics.NewCounter()
+var recoverDeadBinlogServerCounter = metrics.NewCounter()
+var recoverDeadBinlogServerSuccessCounter = metrics.NewCounter()
+var recoverDeadBinlogServerFailureCounter = metrics.NewCounter()
 var recoverDeadCoMasterCounter = metrics.NewCounter()
 var recoverDeadCoMasterSuccessCounter = metrics.NewCounter()
 var recoverDeadCoMasterFailureCounter = metrics.NewCounter()
@@ -198,6 +229,9 @@ func init() {
 	metrics.Register("recover.dead_intermediate_master.start", recoverDeadIntermediateMasterCounter)
 	metrics.Register("recover.dead_intermediate_master.success", recoverDeadIntermediateMasterSuccessCounter)
 	metrics.Register("recover.dead_intermediate_master.fail", recoverDeadIntermediateMasterFailureCounter)
+	metrics.Register("recover.dead_binlog_server.start", recoverDeadBinlogServerCounter)
+	metrics.Register("recover.dead_binlog_server.success", recoverDeadBinlogServerSuccessCounter)
+	metrics.Register("recover.dead_binlog_server.fail", recoverDeadBinlogServerFailureCounter)
 	metrics.Register("recover.dead_co_master.start", recoverDeadCoMasterCounter)
 	metrics.Register("recover.dead_co_master.success", recoverDeadCoMasterSuccessCounter)
 	metrics.Register("recover.dead_co_master.fail", recoverDeadCoMasterFailureCounter)
@@ -229,13 +263,62 @@ func AuditTopologyRecovery(topologyRecovery *TopologyRecovery, message string) e
 		return nil
 	}
 
+	topologyRecovery.Span.AddEvent(message)
+	inst.SetInFlightOperationStep(topologyRecovery.UID, message)
+	events.Publish(&events.Event{
+		Kind:        events.ProgressEvent,
+		Type:        "recovery-step",
+		Hostname:    topologyRecovery.AnalysisEntry.AnalyzedInstanceKey.Hostname,
+		Port:        topologyRecovery.AnalysisEntry.AnalyzedInstanceKey.Port,
+		ClusterName: topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName,
+		Message:     message,
+	})
 	recoveryStep := NewTopologyRecoveryStep(topologyRecovery.UID, message)
 	if orcraft.IsRaftEnabled() {
 		_, err := orcraft.PublishCommand("write-recovery-step", recoveryStep)
 		return err
 	} else {
-		return writeTopologyRecoveryStep(recoveryStep)
+		return writeTopologyRecoveryStep(recoveryTracingContext(topologyRecovery), recoveryStep)
+	}
+}
+
+// applyPostponementRelaxations exempts the cluster's designated critical replicas from lag postponement
+// in given recovery, such that they are relocated right away
+func applyPostponementRelaxations(topologyRecovery *TopologyRecovery) {
+	relaxations, err := inst.ReadPostponementRelaxations(topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName)
+	if err != nil {
+		log.Errore(err)
+		return
+	}
+	for _, relaxation := range relaxations {
+		topologyRecovery.ExemptFromLagPostponing(relaxation.Key)
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("lag postponement relaxed for %+v; owner: %s, reason: %s", relaxation.Key, relaxation.Owner, relaxation.Reason))
+	}
+}
+
+// focusClusterOnRecovery puts the cluster of given recovery in focus mode for FocusModeOnRecoveryMinutes, unless
+// already in focus for longer
+func focusClusterOnRecovery(topologyRecovery *TopologyRecovery) {
+	if config.Config.FocusModeOnRecoveryMinutes == 0 {
+		return
+	}
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	duration := time.Duration(config.Config.FocusModeOnRecoveryMinutes) * time.Minute
+	if outlasts, err := inst.ClusterFocusOutlasts(analysisEntry.ClusterDetails.ClusterName, duration); err != nil || outlasts {
+		return
+	}
+	focus := inst.NewClusterFocus(analysisEntry.ClusterDetails.ClusterName, "orchestrator", fmt.Sprintf("recovery %s: %s on %+v", topologyRecovery.UID, analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey), duration)
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-cluster-focus", focus)
+	} else {
+		err = inst.WriteClusterFocus(focus)
+	}
+	if err != nil {
+		log.Errore(err)
+		return
 	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("%s in focus mode for %+v", focus.ClusterName, duration))
 }
 
 func resolveRecovery(topologyRecovery *TopologyRecovery, successorInstance *inst.Instance) error {
@@ -244,6 +327,41 @@ func resolveRecovery(topologyRecovery *TopologyRecovery, successorInstance *inst
 		topologyRecovery.SuccessorAlias = successorInstance.InstanceAlias
 		topologyRecovery.IsSuccessful = true
 	}
+	topologyRecovery.Span.SetAttribute("successful", topologyRecovery.IsSuccessful)
+	if successorInstance != nil {
+		topologyRecovery.Span.SetAttribute("successor", successorInstance.Key.StringCode())
+	}
+	if len(topologyRecovery.AllErrors) > 0 {
+		topologyRecovery.Span.SetError(fmt.Errorf("%s", strings.Join(topologyRecovery.AllErrors, "; ")))
+	}
+	topologyRecovery.Span.End()
+	message := fmt.Sprintf("recovery %s finished: %s, successful: %t", topologyRecovery.UID, topologyRecovery.AnalysisEntry.Analysis, topologyRecovery.IsSuccessful)
+	if topologyRecovery.SuccessorKey != nil {
+		message = fmt.Sprintf("%s, successor: %s", message, topologyRecovery.SuccessorKey.DisplayString())
+	}
+	events.Publish(&events.Event{
+		Kind:        events.TopologyEvent,
+		Type:        inst.TopologyRecoveryFinishedEvent,
+		Hostname:    topologyRecovery.AnalysisEntry.AnalyzedInstanceKey.Hostname,
+		Port:        topologyRecovery.AnalysisEntry.AnalyzedInstanceKey.Port,
+		ClusterName: topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName,
+		Message:     message,
+	})
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("resolve-recovery", topologyRecovery)
+		return err
+	} else {
+		return writeResolveRecovery(topologyRecovery)
+	}
+}
+
+// failResolvedRecovery marks a resolved recovery as unsuccessful, as its successor turns out unfit after the fact, such
+// that unsuccessful failover processes, rather than successful ones, are executed
+func failResolvedRecovery(topologyRecovery *TopologyRecovery, err error) error {
+	topologyRecovery.AddError(err)
+	topologyRecovery.IsSuccessful = false
+	topologyRecovery.SuccessorKey = nil
+	topologyRecovery.SuccessorAlias = ""
 	if orcraft.IsRaftEnabled() {
 		_, err := orcraft.PublishCommand("resolve-recovery", topologyRecovery)
 		return err
@@ -324,6 +442,12 @@ func applyEnvironmentVariables(topologyRecovery *TopologyRecovery) []string {
 
 // executeProcesses executes a list of processes
 func executeProcesses(processes []string, description string, topologyRecovery *TopologyRecovery, failOnError bool) error {
+	return executeProcessesContext(context.Background(), processes, description, topologyRecovery, failOnError)
+}
+
+// executeProcessesContext is as executeProcesses, where a hook still running when given context is done is killed,
+// and fails
+func executeProcessesContext(ctx context.Context, processes []string, description string, topologyRecovery *TopologyRecovery, failOnError bool) error {
 	if len(processes) == 0 {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("No %s hooks to run", description))
 		return nil
@@ -339,8 +463,15 @@ func executeProcesses(processes []string, description string, topologyRecovery *
 
 		// Log the command to be run and record how long it takes as this may be useful
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %s: %s", fullDescription, command))
+		hookSpan := tracing.StartSpan("hook", topologyRecovery.Span)
+		hookSpan.SetAttribute("hook", fullDescription)
 		start := time.Now()
-		if cmdErr := os.CommandRun(command, env); cmdErr == nil {
+		atomic.AddInt64(&runningHooksCount, 1)
+		cmdErr := os.CommandRunContext(ctx, command, env)
+		atomic.AddInt64(&runningHooksCount, -1)
+		hookSpan.SetError(cmdErr)
+		hookSpan.End()
+		if cmdErr == nil {
 			info := fmt.Sprintf("Completed %s in %v",
 				fullDescription, time.Since(start))
 			AuditTopologyRecovery(topologyRecovery, info)
@@ -349,6 +480,7 @@ func executeProcesses(processes []string, description string, topologyRecovery *
 				fullDescription, time.Since(start), cmdErr)
 			AuditTopologyRecovery(topologyRecovery, info)
 			log.Errorf(info)
+			atomic.StoreInt64(&lastHookFailureUnixNano, time.Now().UnixNano())
 			// FIXME: It would be good to additionally include command execution output to the auditing
 
 			if err == nil {
@@ -361,6 +493,8 @@ func executeProcesses(processes []string, description string, topologyRecovery *
 					fmt.Sprintf("Not running further %s hooks", description))
 				return err
 			}
+			// Aborting hooks have served their purpose by failing; others are notifications worth retrying
+			enqueueFailedExecHook(fullDescription, command, env, topologyRecovery, cmdErr)
 		}
 	}
 	AuditTopologyRecovery(
@@ -373,8 +507,9 @@ func recoverDeadMasterInBinlogServerTopology(topologyRecovery *TopologyRecovery)
 	failedMasterKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
 
 	var promotedBinlogServer *inst.Instance
+	ctx := recoveryMasterChangeContext(topologyRecovery)
 
-	_, promotedBinlogServer, err = inst.RegroupReplicasBinlogServers(failedMasterKey, true)
+	_, promotedBinlogServer, err = inst.RegroupReplicasBinlogServersContext(ctx, failedMasterKey, true)
 	if err != nil {
 		return nil, log.Errore(err)
 	}
@@ -422,7 +557,7 @@ func recoverDeadMasterInBinlogServerTopology(topologyRecovery *TopologyRecovery)
 	if err != nil {
 		return promotedReplica, log.Errore(err)
 	}
-	promotedBinlogServer, err = inst.Repoint(&promotedBinlogServer.Key, &promotedReplica.Key, inst.GTIDHintDeny)
+	promotedBinlogServer, err = inst.RepointContext(ctx, &promotedBinlogServer.Key, &promotedReplica.Key, inst.GTIDHintDeny)
 	if err != nil {
 		return nil, log.Errore(err)
 	}
@@ -454,7 +589,7 @@ func recoverDeadMasterInBinlogServerTopology(topologyRecovery *TopologyRecovery)
 						return err
 					}
 				}
-				_, err = inst.Repoint(&binlogServerReplica.Key, &promotedReplica.Key, inst.GTIDHintDeny)
+				_, err = inst.RepointContext(ctx, &binlogServerReplica.Key, &promotedReplica.Key, inst.GTIDHintDeny)
 				return err
 			}
 			topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("recoverDeadMasterInBinlogServerTopology, moving binlog server %+v", binlogServerReplica.Key))
@@ -490,6 +625,15 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 	topologyRecovery.RecoveryType = masterRecoveryType
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: masterRecoveryType=%+v", masterRecoveryType))
 
+	var policyCheckedKey *inst.InstanceKey
+	if masterRecoveryType != MasterRecoveryBinlogServer {
+		if policyCheckedKey, err = checkCandidatePromotionPolicy(topologyRecovery, candidateInstanceKey); err != nil {
+			inst.AuditOperation("recover-dead-master", failedInstanceKey, fmt.Sprintf("Failure: %+v", err))
+			return nil, lostReplicas, topologyRecovery.AddError(err)
+		}
+	}
+
+	dataCenterPreference := inst.NewPromotionDataCenterPreference(analysisEntry.AnalyzedInstanceDataCenter, analysisEntry.ClusterDetails.ClusterName, analysisEntry.ClusterDetails.ClusterAlias)
 	promotedReplicaIsIdeal := func(promoted *inst.Instance) bool {
 		if promoted == nil {
 			return false
@@ -500,7 +644,7 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 		}
 		if candidateInstanceKey == nil {
 			if promoted.PromotionRule == inst.MustPromoteRule || promoted.PromotionRule == inst.PreferPromoteRule {
-				if promoted.DataCenter == topologyRecovery.AnalysisEntry.AnalyzedInstanceDataCenter &&
+				if dataCenterPreference.Rank(promoted.DataCenter) == 0 &&
 					promoted.PhysicalEnvironment == topologyRecovery.AnalysisEntry.AnalyzedInstancePhysicalEnvironment {
 					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: found %+v to be ideal candidate; will optimize recovery", promoted.Key))
 					postponedAll = true
@@ -510,16 +654,18 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 		}
 		return false
 	}
+	ctx, cancel := recoveryOperationContext(topologyRecovery)
+	defer cancel()
 	switch masterRecoveryType {
 	case MasterRecoveryGTID:
 		{
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: regrouping replicas via GTID"))
-			lostReplicas, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasGTID(failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, promotedReplicaIsIdeal)
+			lostReplicas, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasGTIDContext(ctx, failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, promotedReplicaIsIdeal)
 		}
 	case MasterRecoveryPseudoGTID:
 		{
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: regrouping replicas via Pseudo-GTID"))
-			lostReplicas, _, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, promotedReplicaIsIdeal)
+			lostReplicas, _, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServersContext(ctx, failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, promotedReplicaIsIdeal)
 		}
 	case MasterRecoveryBinlogServer:
 		{
@@ -534,7 +680,7 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 	}
 
 	if promotedReplica != nil && len(lostReplicas) > 0 && config.Config.DetachLostReplicasAfterMasterFailover {
-		postponedFunction := func() error {
+		postponedFunction := func(ctx context.Context) error {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: lost %+v replicas during recovery process; detaching them", len(lostReplicas)))
 			for _, replica := range lostReplicas {
 				replica := replica
@@ -542,7 +688,8 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 			}
 			return nil
 		}
-		topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("RecoverDeadMaster, detach %+v lost replicas", len(lostReplicas)))
+		// Lost replicas are of no use to the recovered topology; other postponed work comes first
+		topologyRecovery.AddPrioritizedPostponedFunction(context.Background(), postponedFunction, fmt.Sprintf("RecoverDeadMaster, detach %+v lost replicas", len(lostReplicas)), inst.PostponedFunctionPriorityLow, 0)
 	}
 
 	func() error {
@@ -562,6 +709,13 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 		topologyRecovery.AddError(err)
 	}
 
+	if promotedReplica != nil {
+		if err := recheckPromotionPolicy(topologyRecovery, promotedReplica, policyCheckedKey); err != nil {
+			topologyRecovery.AddError(err)
+			promotedReplica = nil
+		}
+	}
+
 	if promotedReplica == nil {
 		message := "Failure: no replica promoted."
 		AuditTopologyRecovery(topologyRecovery, message)
@@ -588,6 +742,57 @@ func MasterFailoverGeographicConstraintSatisfied(analysisEntry *inst.Replication
 	return true, ""
 }
 
+// checkPromotionPolicy evaluates the promotion of given replica, in the recovery of a failed master or co-master,
+// against the operation policy
+func checkPromotionPolicy(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) error {
+	operation := "promote-master"
+	if topologyRecovery.Type == CoMasterRecovery {
+		operation = "promote-co-master"
+	}
+	input := inst.NewOperationPolicyInput(operation, &promotedReplica.Key, &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey)
+	input.Instance = promotedReplica
+	input.Analysis = &topologyRecovery.AnalysisEntry
+	return inst.CheckOperationPolicy(input)
+}
+
+// checkCandidatePromotionPolicy evaluates, before any replica is moved, the promotion of the replica a recovery is
+// about to promote against the operation policy: given candidate, if any, or else the candidate replica of the failed
+// master. Returns the key of the replica evaluated, or nil when there is no policy or no candidate to evaluate.
+func checkCandidatePromotionPolicy(topologyRecovery *TopologyRecovery, candidateInstanceKey *inst.InstanceKey) (checkedKey *inst.InstanceKey, err error) {
+	if config.Config.OperationPolicyURL == "" {
+		return nil, nil
+	}
+	var candidate *inst.Instance
+	if candidateInstanceKey != nil {
+		candidate, _, _ = inst.ReadInstance(candidateInstanceKey)
+	} else {
+		// Does not stop replication; the candidate is chosen the same way upon regrouping
+		candidate, _, _, _, _, _ = inst.GetCandidateReplica(&topologyRecovery.AnalysisEntry.AnalyzedInstanceKey, false)
+	}
+	if candidate == nil {
+		return nil, nil
+	}
+	if err := checkPromotionPolicy(topologyRecovery, candidate); err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("will not promote %+v; %+v", candidate.Key, err))
+		return &candidate.Key, err
+	}
+	return &candidate.Key, nil
+}
+
+// recheckPromotionPolicy evaluates the promotion of the replica a recovery actually promoted against the operation
+// policy, unless it is the one already evaluated before any replica was moved. A denied replica is not to be
+// considered promoted.
+func recheckPromotionPolicy(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance, checkedKey *inst.InstanceKey) error {
+	if config.Config.OperationPolicyURL == "" || promotedReplica.Key.Equals(checkedKey) {
+		return nil
+	}
+	if err := checkPromotionPolicy(topologyRecovery, promotedReplica); err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("failed %+v promotion; %+v", promotedReplica.Key, err))
+		return err
+	}
+	return nil
+}
+
 // SuggestReplacementForPromotedReplica returns a server to take over the already
 // promoted replica, if such server is found and makes an improvement over the promoted replica.
 func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, deadInstanceKey *inst.InstanceKey, promotedReplica *inst.Instance, candidateInstanceKey *inst.InstanceKey) (replacement *inst.Instance, actionRequired bool, err error) {
@@ -597,6 +802,16 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 	if err != nil {
 		deadInstance = nil
 	}
+	failedDataCenter := topologyRecovery.AnalysisEntry.AnalyzedInstanceDataCenter
+	if deadInstance != nil {
+		failedDataCenter = deadInstance.DataCenter
+	}
+	dataCenterPreference := inst.NewPromotionDataCenterPreference(failedDataCenter, promotedReplica.ClusterName, topologyRecovery.AnalysisEntry.ClusterDetails.ClusterAlias)
+	// dataCenterPolicyAllows returns true unless the cluster's promotion data center policy ranks given instance's
+	// data center lower than the promoted replica's
+	dataCenterPolicyAllows := func(instance *inst.Instance) bool {
+		return !dataCenterPreference.HasPolicy() || dataCenterPreference.Rank(instance.DataCenter) <= dataCenterPreference.Rank(promotedReplica.DataCenter)
+	}
 	// So we've already promoted a replica.
 	// However, can we improve on our choice? Are there any replicas marked with "is_candidate"?
 	// Maybe we actually promoted such a replica. Does that mean we should keep it?
@@ -610,10 +825,10 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 		if deadInstance != nil {
 			for _, candidateReplica := range candidateReplicas {
 				if promotedReplica.Key.Equals(&candidateReplica.Key) &&
-					promotedReplica.DataCenter == deadInstance.DataCenter &&
+					dataCenterPreference.Rank(promotedReplica.DataCenter) == 0 &&
 					promotedReplica.PhysicalEnvironment == deadInstance.PhysicalEnvironment {
 					// Seems like we promoted a candidate in the same DC & ENV as dead IM! Ideal! We're happy!
-					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("promoted replica %+v is the ideal candidate", promotedReplica.Key))
+					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("promoted replica %+v is the ideal candidate; %s", promotedReplica.Key, dataCenterPreference.Reason(promotedReplica.DataCenter)))
 					return promotedReplica, false, nil
 				}
 			}
@@ -626,15 +841,36 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 		if deadInstance != nil {
 			for _, candidateReplica := range candidateReplicas {
 				if canTakeOverPromotedServerAsMaster(candidateReplica, promotedReplica) &&
-					candidateReplica.DataCenter == deadInstance.DataCenter &&
+					dataCenterPreference.Rank(candidateReplica.DataCenter) == 0 &&
 					candidateReplica.PhysicalEnvironment == deadInstance.PhysicalEnvironment {
 					// This would make a great candidate
 					candidateInstanceKey = &candidateReplica.Key
-					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("no candidate was offered for %+v but orchestrator picks %+v as candidate replacement, based on being in same DC & env as failed instance", *deadInstanceKey, candidateReplica.Key))
+					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("no candidate was offered for %+v but orchestrator picks %+v as candidate replacement, based on being in ideal DC & same env as failed instance; %s", *deadInstanceKey, candidateReplica.Key, dataCenterPreference.Reason(candidateReplica.DataCenter)))
 				}
 			}
 		}
 	}
+	if candidateInstanceKey == nil && dataCenterPreference.HasPolicy() {
+		// Try the candidate replica in the data center best ranked by the cluster's policy, if better ranked than the promoted replica
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("+ searching for a candidate per promotion data center policy"))
+		var bestCandidate *inst.Instance
+		bestRank := dataCenterPreference.Rank(promotedReplica.DataCenter)
+		for _, candidateReplica := range candidateReplicas {
+			if !canTakeOverPromotedServerAsMaster(candidateReplica, promotedReplica) {
+				continue
+			}
+			if satisfied, _ := MasterFailoverGeographicConstraintSatisfied(&topologyRecovery.AnalysisEntry, candidateReplica); !satisfied {
+				continue
+			}
+			if rank := dataCenterPreference.Rank(candidateReplica.DataCenter); rank < bestRank {
+				bestCandidate, bestRank = candidateReplica, rank
+			}
+		}
+		if bestCandidate != nil {
+			candidateInstanceKey = &bestCandidate.Key
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("no candidate was offered for %+v but orchestrator picks %+v as candidate replacement, based on promotion data center policy: %s, whereas promoted replica's %s", promotedReplica.Key, bestCandidate.Key, dataCenterPreference.Reason(bestCandidate.DataCenter), dataCenterPreference.Reason(promotedReplica.DataCenter)))
+		}
+	}
 	if candidateInstanceKey == nil {
 		// We cannot find a candidate in same DC and ENV as dead master
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("+ checking if promoted replica is an OK candidate"))
@@ -670,7 +906,7 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 		// Try a candidate replica (our promoted replica is not an "is_candidate")
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("+ searching for a candidate"))
 		for _, candidateReplica := range candidateReplicas {
-			if canTakeOverPromotedServerAsMaster(candidateReplica, promotedReplica) {
+			if canTakeOverPromotedServerAsMaster(candidateReplica, promotedReplica) && dataCenterPolicyAllows(candidateReplica) {
 				if satisfied, reason := MasterFailoverGeographicConstraintSatisfied(&topologyRecovery.AnalysisEntry, candidateReplica); satisfied {
 					// OK, better than nothing
 					candidateInstanceKey = &candidateReplica.Key
@@ -687,6 +923,8 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 		keepSearchingHint = fmt.Sprintf("Will keep searching; %s", reason)
 	} else if promotedReplica.PromotionRule == inst.PreferNotPromoteRule {
 		keepSearchingHint = fmt.Sprintf("Will keep searching because we have promoted a server with prefer_not rule: %+v", promotedReplica.Key)
+	} else if inst.IsInPromotionCooldown(promotedReplica) {
+		keepSearchingHint = fmt.Sprintf("Will keep searching because we have promoted a server in promotion cool-down: %+v", promotedReplica.Key)
 	}
 	if keepSearchingHint != "" {
 		AuditTopologyRecovery(topologyRecovery, keepSearchingHint)
@@ -698,7 +936,7 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("+ searching for a neutral server to replace promoted server, in same DC and env as dead master"))
 			for _, neutralReplica := range neutralReplicas {
 				if canTakeOverPromotedServerAsMaster(neutralReplica, promotedReplica) &&
-					deadInstance.DataCenter == neutralReplica.DataCenter &&
+					dataCenterPreference.Rank(neutralReplica.DataCenter) == 0 &&
 					deadInstance.PhysicalEnvironment == neutralReplica.PhysicalEnvironment {
 					candidateInstanceKey = &neutralReplica.Key
 					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("no candidate was offered for %+v but orchestrator picks %+v as candidate replacement, based on being in same DC & env as dead master", promotedReplica.Key, neutralReplica.Key))
@@ -720,7 +958,7 @@ func SuggestReplacementForPromotedReplica(topologyRecovery *TopologyRecovery, de
 		if candidateInstanceKey == nil {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("+ searching for a neutral server to replace a prefer_not"))
 			for _, neutralReplica := range neutralReplicas {
-				if canTakeOverPromotedServerAsMaster(neutralReplica, promotedReplica) {
+				if canTakeOverPromotedServerAsMaster(neutralReplica, promotedReplica) && dataCenterPolicyAllows(neutralReplica) {
 					if satisfied, reason := MasterFailoverGeographicConstraintSatisfied(&topologyRecovery.AnalysisEntry, neutralReplica); satisfied {
 						// OK, better than nothing
 						candidateInstanceKey = &neutralReplica.Key
@@ -766,9 +1004,13 @@ func replacePromotedReplicaWithCandidate(topologyRecovery *TopologyRecovery, dea
 	// Try and promote suggested candidate, if applicable and possible
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("replace-promoted-replica-with-candidate: promoted instance %+v is not the suggested candidate %+v. Will see what can be done", promotedReplica.Key, candidateInstance.Key))
 
+	if err := checkPromotionPolicy(topologyRecovery, candidateInstance); err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("replace-promoted-replica-with-candidate: will not promote suggested candidate %+v; %+v", candidateInstance.Key, err))
+		return promotedReplica, nil
+	}
 	if candidateInstance.MasterKey.Equals(&promotedReplica.Key) {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("replace-promoted-replica-with-candidate: suggested candidate %+v is replica of promoted instance %+v. Will try and take its master", candidateInstance.Key, promotedReplica.Key))
-		candidateInstance, err = inst.TakeMaster(&candidateInstance.Key, topologyRecovery.Type == CoMasterRecovery)
+		candidateInstance, err = inst.TakeMasterContext(recoveryMasterChangeContext(topologyRecovery), &candidateInstance.Key, topologyRecovery.Type == CoMasterRecovery)
 		if err != nil {
 			return promotedReplica, log.Errore(err)
 		}
@@ -778,7 +1020,7 @@ func replacePromotedReplicaWithCandidate(topologyRecovery *TopologyRecovery, dea
 		relocateReplicasFunc := func() error {
 			log.Debugf("replace-promoted-replica-with-candidate: relocating replicas of %+v below %+v", promotedReplica.Key, candidateInstance.Key)
 
-			relocatedReplicas, _, err, _ := inst.RelocateReplicas(&promotedReplica.Key, &candidateInstance.Key, "")
+			relocatedReplicas, _, err, _ := inst.RelocateReplicasContext(recoveryMasterChangeContext(topologyRecovery), &promotedReplica.Key, &candidateInstance.Key, "")
 			log.Debugf("replace-promoted-replica-with-candidate: + relocated %+v replicas of %+v below %+v", len(relocatedReplicas), promotedReplica.Key, candidateInstance.Key)
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("relocated %+v replicas of %+v below %+v", len(relocatedReplicas), promotedReplica.Key, candidateInstance.Key))
 			return log.Errore(err)
@@ -797,20 +1039,66 @@ func replacePromotedReplicaWithCandidate(topologyRecovery *TopologyRecovery, dea
 	return promotedReplica, nil
 }
 
+// writeClusterMasterKVPairs writes and distributes the KV pairs pointing to the newly promoted master
+func writeClusterMasterKVPairs(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) {
+	kvPairs := inst.GetClusterMasterKVPairs(topologyRecovery.AnalysisEntry.ClusterDetails.ClusterAlias, &promotedReplica.Key)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Writing KV %+v", kvPairs))
+	if orcraft.IsRaftEnabled() {
+		for _, kvPair := range kvPairs {
+			_, err := orcraft.PublishCommand("put-key-value", kvPair)
+			log.Errore(err)
+		}
+		// since we'll be affecting 3rd party tools here, we _prefer_ to mitigate re-applying
+		// of the put-key-value event upon startup. We _recommend_ a snapshot in the near future.
+		go orcraft.PublishCommand("async-snapshot", "")
+	} else {
+		for _, kvPair := range kvPairs {
+			err := kv.PutKVPair(kvPair)
+			log.Errore(err)
+		}
+	}
+	{
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Distributing KV %+v", kvPairs))
+		err := kv.DistributePairs(kvPairs)
+		log.Errore(err)
+	}
+}
+
+// replaceFailedMasterClusterName renames the cluster after the newly promoted master, keeping its alias
+func replaceFailedMasterClusterName(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	before := analysisEntry.AnalyzedInstanceKey.StringCode()
+	after := promotedReplica.Key.StringCode()
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: updating cluster_alias: %v -> %v", before, after))
+	inst.ReplaceClusterName(before, after)
+	if alias := analysisEntry.ClusterDetails.ClusterAlias; alias != "" {
+		inst.SetClusterAlias(promotedReplica.Key.StringCode(), alias)
+	} else {
+		inst.ReplaceAliasClusterName(before, after)
+	}
+}
+
 // checkAndRecoverDeadMaster checks a given analysis, decides whether to take action, and possibly takes action
 // Returns true when action was taken.
 func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
 	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedMasterRecovery) {
 		return false, nil, nil
 	}
+	if isRecoveryRefusedByWriteFreeze(&analysisEntry, forceInstanceRecovery) {
+		return false, nil, nil
+	}
 	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
 	if topologyRecovery == nil {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another RecoverDeadMaster.", analysisEntry.AnalyzedInstanceKey))
 		return false, nil, err
 	}
+	if isRecoveryRefusedByMasterDeathQuorum(topologyRecovery, forceInstanceRecovery) {
+		return false, topologyRecovery, nil
+	}
 
 	// That's it! We must do recovery!
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("will handle DeadMaster event on %+v", analysisEntry.ClusterDetails.ClusterName))
+	recordRecoveryTopology(topologyRecovery)
 	recoverDeadMasterCounter.Inc(1)
 	promotedReplica, lostReplicas, err := recoverDeadMaster(topologyRecovery, candidateInstanceKey, skipProcesses)
 	topologyRecovery.LostReplicas.AddInstances(lostReplicas)
@@ -828,6 +1116,13 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 			AuditTopologyRecovery(topologyRecovery, message)
 			return false, nil, log.Error(message)
 		}
+		if analysisEntry.CommandHint == inst.ForceMasterFailoverToCommandHint {
+			if err := reverifyPromotedReplicaTransactions(instanceTopologyOperations{}, topologyRecovery, promotedReplica); err != nil {
+				recoverDeadMasterFailureCounter.Inc(1)
+				failResolvedRecovery(topologyRecovery, err)
+				return true, topologyRecovery, log.Errore(err)
+			}
+		}
 		if config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Waiting to ensure the SQL thread catches up on %+v", promotedReplica.Key))
 			if _, err = inst.WaitForSQLThreadUpToDate(&promotedReplica.Key, 0, 0); err != nil {
@@ -836,8 +1131,6 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SQL thread caught up on %+v", promotedReplica.Key))
 		}
 
-		// Success!
-		recoverDeadMasterSuccessCounter.Inc(1)
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: successfully promoted %+v", promotedReplica.Key))
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promoted server coordinates: %+v", promotedReplica.SelfBinlogCoordinates))
 
@@ -865,28 +1158,20 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying read-only=1 on demoted master: success=%t", (err == nil)))
 			}()
 		}
-
-		kvPairs := inst.GetClusterMasterKVPairs(analysisEntry.ClusterDetails.ClusterAlias, &promotedReplica.Key)
-		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Writing KV %+v", kvPairs))
-		if orcraft.IsRaftEnabled() {
-			for _, kvPair := range kvPairs {
-				_, err := orcraft.PublishCommand("put-key-value", kvPair)
-				log.Errore(err)
+		if config.Config.PromotedMasterWriteValidationQuery != "" {
+			coordinates, err := inst.ValidateMasterWrites(&promotedReplica.Key)
+			if err != nil {
+				recoverDeadMasterFailureCounter.Inc(1)
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: failed %+v promotion; promoted master does not accept writes: %+v", promotedReplica.Key, err))
+				failResolvedRecovery(topologyRecovery, err)
+				return true, topologyRecovery, log.Errore(err)
 			}
-			// since we'll be affecting 3rd party tools here, we _prefer_ to mitigate re-applying
-			// of the put-key-value event upon startup. We _recommend_ a snapshot in the near future.
-			go orcraft.PublishCommand("async-snapshot", "")
-		} else {
-			for _, kvPair := range kvPairs {
-				err := kv.PutKVPair(kvPair)
-				log.Errore(err)
-			}
-		}
-		{
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Distributing KV %+v", kvPairs))
-			err := kv.DistributePairs(kvPairs)
-			log.Errore(err)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promoted master accepts writes; binary log advanced to %+v", *coordinates))
 		}
+
+		// Success!
+		recoverDeadMasterSuccessCounter.Inc(1)
+		writeClusterMasterKVPairs(topologyRecovery, promotedReplica)
 		if config.Config.MasterFailoverDetachReplicaMasterHost {
 			postponedFunction := func() error {
 				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: detaching master host on promoted master"))
@@ -895,18 +1180,36 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 			}
 			topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("RecoverDeadMaster, detaching promoted master host %+v", promotedReplica.Key))
 		}
-		func() error {
-			before := analysisEntry.AnalyzedInstanceKey.StringCode()
-			after := promotedReplica.Key.StringCode()
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: updating cluster_alias: %v -> %v", before, after))
-			inst.ReplaceClusterName(before, after)
-			if alias := analysisEntry.ClusterDetails.ClusterAlias; alias != "" {
-				inst.SetClusterAlias(promotedReplica.Key.StringCode(), alias)
-			} else {
-				inst.ReplaceAliasClusterName(before, after)
+		if config.Config.PromotedMasterResetSlaveAllWhenHealthy && !config.Config.ApplyMySQLPromotionAfterMasterFailover && analysisEntry.CommandHint != inst.GracefulMasterTakeoverCommandHint {
+			postponedFunction := func(ctx context.Context) error {
+				return resetSlaveAllOnPromotedMasterWhenHealthy(ctx, instanceTopologyOperations{}, topologyRecovery, &promotedReplica.Key)
 			}
-			return nil
-		}()
+			// Waits on the relocation of the promoted master's replicas, hence runs after them
+			topologyRecovery.AddPrioritizedPostponedFunction(context.Background(), postponedFunction, fmt.Sprintf("RecoverDeadMaster, RESET SLAVE ALL on promoted master %+v", promotedReplica.Key), inst.PostponedFunctionPriorityLow, 0)
+		}
+		if config.Config.SemiSyncReplicasPerMaster > 0 {
+			postponedFunction := func(ctx context.Context) error {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: enforcing %d semi-sync replicas on promoted master", config.Config.SemiSyncReplicasPerMaster))
+				_, err := inst.EnforceMasterSemiSync(&promotedReplica.Key, config.Config.SemiSyncReplicasPerMaster)
+				return err
+			}
+			// Writes on the promoted master block until semi-sync is enforced
+			topologyRecovery.AddPrioritizedPostponedFunction(context.Background(), postponedFunction, fmt.Sprintf("RecoverDeadMaster, enforcing semi-sync on promoted master %+v", promotedReplica.Key), inst.PostponedFunctionPriorityHigh, 0)
+		}
+		if config.Config.RemediateErrantGTIDAfterMasterFailover {
+			postponedFunction := func() error {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: remediating errant GTID below promoted master"))
+				remediated, countInjectedTransactions, err := inst.RemediateErrantGTIDBelow(&promotedReplica.Key)
+				for _, replica := range remediated {
+					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: remediated errant GTID %s of %+v", replica.GtidErrant, replica.Key))
+				}
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: injected %d empty transactions on promoted master: success=%t", countInjectedTransactions, (err == nil)))
+				return err
+			}
+			topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("RecoverDeadMaster, remediating errant GTID below promoted master %+v", promotedReplica.Key))
+		}
+		repointDRStandbyMasters(topologyRecovery, promotedReplica)
+		replaceFailedMasterClusterName(topologyRecovery, promotedReplica)
 
 		attributes.SetGeneralAttribute(analysisEntry.ClusterDetails.ClusterDomain, promotedReplica.Key.StringCode())
 
@@ -921,6 +1224,53 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 	return true, topologyRecovery, err
 }
 
+// isPromotedMasterTopologyHealthy returns true when the promoted master is reachable and all its replicas, other
+// than downtimed ones, replicate from it; otherwise, why it is not
+func isPromotedMasterTopologyHealthy(operations topologyOperations, promotedMasterKey *inst.InstanceKey) (bool, string) {
+	if _, err := operations.ReadTopologyInstance(promotedMasterKey); err != nil {
+		return false, fmt.Sprintf("%+v is unreachable: %+v", *promotedMasterKey, err)
+	}
+	replicas, err := operations.ReadReplicaInstances(promotedMasterKey)
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, replica := range replicas {
+		if replica.IsDowntimed || replica.ReplicaRunning() {
+			continue
+		}
+		// The backend may not yet reflect a replica just relocated
+		if refreshed, err := operations.ReadTopologyInstance(&replica.Key); err == nil && refreshed.ReplicaRunning() {
+			continue
+		}
+		return false, fmt.Sprintf("replica %+v is not replicating", replica.Key)
+	}
+	return true, ""
+}
+
+// resetSlaveAllOnPromotedMasterWhenHealthy waits for the topology below a promoted master to be healthy, then issues
+// RESET SLAVE ALL on the promoted master, clearing its stale CHANGE MASTER settings pointing at the failed master.
+// It gives up after PromotedMasterResetSlaveAllWaitSeconds, leaving the promoted master's settings as they are.
+func resetSlaveAllOnPromotedMasterWhenHealthy(ctx context.Context, operations topologyOperations, topologyRecovery *TopologyRecovery, promotedMasterKey *inst.InstanceKey) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.Config.PromotedMasterResetSlaveAllWaitSeconds)*time.Second)
+	defer cancel()
+
+	for {
+		healthy, reason := isPromotedMasterTopologyHealthy(operations, promotedMasterKey)
+		if healthy {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: not applying RESET SLAVE ALL on promoted master; topology not healthy: %s", reason))
+			return fmt.Errorf("RESET SLAVE ALL not applied on promoted master %+v: %s", *promotedMasterKey, reason)
+		case <-time.After(time.Second):
+		}
+	}
+	_, err := operations.ResetSlave(promotedMasterKey)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: topology healthy; applying RESET SLAVE ALL on promoted master: success=%t", (err == nil)))
+	return err
+}
+
 // isGeneralyValidAsCandidateSiblingOfIntermediateMaster sees that basic server configuration and state are valid
 func isGeneralyValidAsCandidateSiblingOfIntermediateMaster(sibling *inst.Instance) bool {
 	if !sibling.LogBinEnabled {
@@ -989,6 +1339,9 @@ func canTakeOverPromotedServerAsMaster(wantToTakeOver *inst.Instance, toBeTakenO
 	if !isGenerallyValidAsWouldBeMaster(wantToTakeOver, true) {
 		return false
 	}
+	if inst.IsInPromotionCooldown(wantToTakeOver) {
+		return false
+	}
 	if !wantToTakeOver.MasterKey.Equals(&toBeTakenOver.Key) {
 		return false
 	}
@@ -1057,6 +1410,126 @@ func GetCandidateSiblingOfIntermediateMaster(topologyRecovery *TopologyRecovery,
 	return nil, log.Errorf("topology_recovery: cannot find candidate sibling of %+v", intermediateMasterInstance.Key)
 }
 
+// distributeReplicasAmongWeightedSiblings distributes the replicas of a dead intermediate master among those of its
+// valid candidate siblings tagged with a regroup-weight, in proportion to their weights and within their
+// regroup-capacity. It returns the sibling taking over the most replicas, and whether all replicas were relocated.
+// Replicas left behind are for the next plans to handle.
+func distributeReplicasAmongWeightedSiblings(ctx context.Context, topologyRecovery *TopologyRecovery, intermediateMasterInstance *inst.Instance) (successorInstance *inst.Instance, resolved bool) {
+	siblings, err := inst.ReadReplicaInstances(&intermediateMasterInstance.MasterKey)
+	if err != nil {
+		topologyRecovery.AddError(err)
+		return nil, false
+	}
+	targets := [](*inst.RegroupTarget){}
+	for _, sibling := range siblings {
+		if !isValidAsCandidateSiblingOfIntermediateMaster(intermediateMasterInstance, sibling) {
+			continue
+		}
+		target, err := inst.ReadRegroupTarget(sibling)
+		if err != nil {
+			topologyRecovery.AddError(err)
+			continue
+		}
+		if target.Weight > 0 {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, false
+	}
+	replicas, err := inst.ReadReplicaInstances(&intermediateMasterInstance.Key)
+	if err != nil {
+		topologyRecovery.AddError(err)
+		return nil, false
+	}
+	distribution, unassigned := inst.DistributeReplicas(replicas, targets)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: will distribute %d replicas among %d weighted siblings; %d replicas fit no sibling", len(replicas)-len(unassigned), len(targets), len(unassigned)))
+
+	relocatedCount := 0
+	failedCount := 0
+	successorRelocatedCount := 0
+	for _, target := range targets {
+		targetReplicas := distribution[target.Instance.Key]
+		if len(targetReplicas) == 0 {
+			continue
+		}
+		topologyRecovery.ParticipatingInstanceKeys.AddKey(target.Instance.Key)
+		targetRelocatedCount := 0
+		for _, replica := range targetReplicas {
+			if _, err := inst.RelocateBelowContext(ctx, &replica.Key, &target.Instance.Key, true); err != nil {
+				topologyRecovery.AddError(err)
+				failedCount++
+				continue
+			}
+			targetRelocatedCount++
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: relocated %d/%d replicas below %+v (weight %d, capacity %d)", targetRelocatedCount, len(targetReplicas), target.Instance.Key, target.Weight, target.Capacity))
+		if targetRelocatedCount > successorRelocatedCount {
+			successorInstance = target.Instance
+			successorRelocatedCount = targetRelocatedCount
+		}
+		relocatedCount += targetRelocatedCount
+	}
+	if relocatedCount == 0 {
+		return nil, false
+	}
+	inst.AuditOperation("recover-dead-intermediate-master", &intermediateMasterInstance.Key, fmt.Sprintf("Distributed %d replicas among weighted siblings; %d failed, %d unassigned", relocatedCount, failedCount, len(unassigned)))
+	return successorInstance, (failedCount == 0 && len(unassigned) == 0)
+}
+
+// recoverDeadIntermediateMasterByStrategy recovers the replicas of a dead intermediate master by the configured,
+// explicit strategy alone. Replicas the strategy fails to relocate are left as they are.
+func recoverDeadIntermediateMasterByStrategy(ctx context.Context, topologyRecovery *TopologyRecovery, strategy *inst.IntermediateMasterRecoveryStrategy) (successorInstance *inst.Instance, err error) {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedInstanceKey := &analysisEntry.AnalyzedInstanceKey
+	grandparentKey := &analysisEntry.AnalyzedInstanceMasterKey
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: applying %s strategy", strategy.Type))
+
+	targetKey := grandparentKey
+	switch strategy.Type {
+	case inst.RegroupIntermediateMasterRecovery:
+		lostReplicas, _, _, _, regroupPromotedReplica, regroupError := inst.RegroupReplicasContext(ctx, failedInstanceKey, true, nil, nil)
+		if regroupError != nil {
+			topologyRecovery.AddError(regroupError)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: regroup failed on: %+v", regroupError))
+		}
+		if regroupPromotedReplica != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: regrouped under %+v, with %d lost replicas", regroupPromotedReplica.Key, len(lostReplicas)))
+			topologyRecovery.ParticipatingInstanceKeys.AddKey(regroupPromotedReplica.Key)
+			successorInstance = regroupPromotedReplica
+		}
+		// The regrouped replica, along with any replicas not regrouped, still replicates from the dead intermediate master
+	case inst.DesignatedIntermediateMasterRecovery:
+		targetKey = strategy.DesignatedKey
+		designated, err := inst.ReadTopologyInstance(targetKey)
+		if err != nil {
+			return nil, topologyRecovery.AddError(err)
+		}
+		if designated.MasterKey.Equals(failedInstanceKey) {
+			// The designated server itself replicates from the dead intermediate master; it first moves up
+			if _, err := inst.RelocateBelowContext(ctx, targetKey, grandparentKey, true); err != nil {
+				return nil, topologyRecovery.AddError(err)
+			}
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: relocated designated %+v below %+v", *targetKey, *grandparentKey))
+		}
+	}
+
+	relocatedReplicas, targetInstance, err, errs := inst.RelocateReplicasContext(ctx, failedInstanceKey, targetKey, "")
+	topologyRecovery.AddErrors(errs)
+	topologyRecovery.ParticipatingInstanceKeys.AddKey(*targetKey)
+	if len(relocatedReplicas) == 0 {
+		if err == nil {
+			err = fmt.Errorf("RecoverDeadIntermediateMaster failed to relocate any replica from %+v below %+v", *failedInstanceKey, *targetKey)
+		}
+		return nil, topologyRecovery.AddError(err)
+	}
+	if successorInstance == nil {
+		successorInstance = targetInstance
+	}
+	inst.AuditOperation("recover-dead-intermediate-master", failedInstanceKey, fmt.Sprintf("%s strategy: relocated %d replicas below %+v; successor: %+v; %d errors: %+v", strategy.Type, len(relocatedReplicas), *targetKey, successorInstance.Key, len(errs), errs))
+	return successorInstance, err
+}
+
 // RecoverDeadIntermediateMaster performs intermediate master recovery; complete logic inside
 func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProcesses bool) (successorInstance *inst.Instance, err error) {
 	topologyRecovery.Type = IntermediateMasterRecovery
@@ -1064,6 +1537,12 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 	failedInstanceKey := &analysisEntry.AnalyzedInstanceKey
 	recoveryResolved := false
 
+	if pair, _ := inst.ReadDRPairByStandbyMaster(failedInstanceKey); pair != nil && pair.IsActive() {
+		successorInstance, err = recoverDeadDRStandbyMaster(topologyRecovery, pair, skipProcesses)
+		resolveRecovery(topologyRecovery, successorInstance)
+		return successorInstance, err
+	}
+
 	inst.AuditOperation("recover-dead-intermediate-master", failedInstanceKey, "problem found; will recover")
 	if !skipProcesses {
 		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
@@ -1075,6 +1554,17 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 	if err != nil {
 		return nil, topologyRecovery.AddError(err)
 	}
+	ctx, cancel := recoveryOperationContext(topologyRecovery)
+	defer cancel()
+	strategy, err := inst.GetIntermediateMasterRecoveryStrategy(analysisEntry.ClusterDetails.ClusterName, analysisEntry.ClusterDetails.ClusterAlias)
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	if strategy.Type != inst.AutoIntermediateMasterRecovery {
+		successorInstance, err = recoverDeadIntermediateMasterByStrategy(ctx, topologyRecovery, strategy)
+		resolveRecovery(topologyRecovery, successorInstance)
+		return successorInstance, err
+	}
 	// Find possible candidate
 	candidateSiblingOfIntermediateMaster, _ := GetCandidateSiblingOfIntermediateMaster(topologyRecovery, intermediateMasterInstance)
 	relocateReplicasToCandidateSibling := func() {
@@ -1083,7 +1573,7 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 		}
 		// We have a candidate
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: will attempt a candidate intermediate master: %+v", candidateSiblingOfIntermediateMaster.Key))
-		relocatedReplicas, candidateSibling, err, errs := inst.RelocateReplicas(failedInstanceKey, &candidateSiblingOfIntermediateMaster.Key, "")
+		relocatedReplicas, candidateSibling, err, errs := inst.RelocateReplicasContext(ctx, failedInstanceKey, &candidateSiblingOfIntermediateMaster.Key, "")
 		topologyRecovery.AddErrors(errs)
 		topologyRecovery.ParticipatingInstanceKeys.AddKey(candidateSiblingOfIntermediateMaster.Key)
 
@@ -1102,14 +1592,16 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 			inst.AuditOperation("recover-dead-intermediate-master", failedInstanceKey, fmt.Sprintf("Relocated %d replicas under candidate sibling: %+v; %d errors: %+v", len(relocatedReplicas), candidateSibling.Key, len(errs), errs))
 		}
 	}
+	// Plan 0: distribute replicas among siblings tagged with a regroup-weight, if any
+	successorInstance, recoveryResolved = distributeReplicasAmongWeightedSiblings(ctx, topologyRecovery, intermediateMasterInstance)
 	// Plan A: find a replacement intermediate master in same Data Center
-	if candidateSiblingOfIntermediateMaster != nil && candidateSiblingOfIntermediateMaster.DataCenter == intermediateMasterInstance.DataCenter {
+	if !recoveryResolved && candidateSiblingOfIntermediateMaster != nil && candidateSiblingOfIntermediateMaster.DataCenter == intermediateMasterInstance.DataCenter {
 		relocateReplicasToCandidateSibling()
 	}
 	if !recoveryResolved {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: will next attempt regrouping of replicas"))
 		// Plan B: regroup (we wish to reduce cross-DC replication streams)
-		lostReplicas, _, _, _, regroupPromotedReplica, regroupError := inst.RegroupReplicas(failedInstanceKey, true, nil, nil)
+		lostReplicas, _, _, _, regroupPromotedReplica, regroupError := inst.RegroupReplicasContext(ctx, failedInstanceKey, true, nil, nil)
 		if regroupError != nil {
 			topologyRecovery.AddError(regroupError)
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: regroup failed on: %+v", regroupError))
@@ -1138,7 +1630,7 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 		// So, match up all that's left, plan D
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadIntermediateMaster: will next attempt to relocate up from %+v", *failedInstanceKey))
 
-		relocatedReplicas, masterInstance, err, errs := inst.RelocateReplicas(failedInstanceKey, &analysisEntry.AnalyzedInstanceMasterKey, "")
+		relocatedReplicas, masterInstance, err, errs := inst.RelocateReplicasContext(ctx, failedInstanceKey, &analysisEntry.AnalyzedInstanceMasterKey, "")
 		topologyRecovery.AddErrors(errs)
 		topologyRecovery.ParticipatingInstanceKeys.AddKey(analysisEntry.AnalyzedInstanceMasterKey)
 
@@ -1192,6 +1684,114 @@ func checkAndRecoverDeadIntermediateMaster(analysisEntry inst.ReplicationAnalysi
 	return true, topologyRecovery, err
 }
 
+// RecoverDeadBinlogServer recovers a dead binlog server by relocating its replicas onto the most up-to-date
+// surviving binlog server of same master, or onto the master itself if no such binlog server is found.
+// Binlog servers serve the same binary logs (same file names & positions) as their master, hence replicas
+// are simply repointed, keeping their coordinates.
+func RecoverDeadBinlogServer(topologyRecovery *TopologyRecovery, skipProcesses bool) (successorInstance *inst.Instance, err error) {
+	topologyRecovery.Type = BinlogServerRecovery
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	failedInstanceKey := &analysisEntry.AnalyzedInstanceKey
+	masterKey := &analysisEntry.AnalyzedInstanceMasterKey
+
+	inst.AuditOperation("recover-dead-binlog-server", failedInstanceKey, "problem found; will recover")
+	if !skipProcesses {
+		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+			return nil, topologyRecovery.AddError(err)
+		}
+	}
+
+	replicas, err := inst.ReadReplicaInstances(failedInstanceKey)
+	if err != nil {
+		return nil, topologyRecovery.AddError(err)
+	}
+	var mostAdvancedReplicaCoordinates inst.BinlogCoordinates
+	for _, replica := range replicas {
+		if mostAdvancedReplicaCoordinates.SmallerThan(&replica.ExecBinlogCoordinates) {
+			mostAdvancedReplicaCoordinates = replica.ExecBinlogCoordinates
+		}
+	}
+
+	successorKey := masterKey
+	survivingBinlogServer, _, err := inst.GetMostUpToDateActiveBinlogServer(masterKey)
+	if err != nil {
+		topologyRecovery.AddError(err)
+	}
+	if survivingBinlogServer != nil && !survivingBinlogServer.Key.Equals(failedInstanceKey) {
+		if survivingBinlogServer.ExecBinlogCoordinates.SmallerThan(&mostAdvancedReplicaCoordinates) {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadBinlogServer: binlog server %+v is behind replicas of %+v (%+v < %+v); will not use it", survivingBinlogServer.Key, *failedInstanceKey, survivingBinlogServer.ExecBinlogCoordinates, mostAdvancedReplicaCoordinates))
+		} else {
+			successorKey = &survivingBinlogServer.Key
+		}
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadBinlogServer: will repoint replicas of %+v onto %+v", *failedInstanceKey, *successorKey))
+	ctx := recoveryMasterChangeContext(topologyRecovery)
+	relocatedReplicas, err, errs := inst.RepointToContext(ctx, replicas, successorKey)
+	topologyRecovery.AddErrors(errs)
+	topologyRecovery.ParticipatingInstanceKeys.AddKey(*successorKey)
+
+	if remainingReplicas := binlogServerFallbackReplicas(replicas, relocatedReplicas, successorKey, masterKey); len(remainingReplicas) > 0 {
+		// Some, or all, replicas could not be repointed to the surviving binlog server. We fall back to the master for those
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadBinlogServer: will repoint %d remaining replicas onto master %+v", len(remainingReplicas), *masterKey))
+		moreRelocatedReplicas, _, moreErrs := inst.RepointToContext(ctx, remainingReplicas, masterKey)
+		topologyRecovery.AddErrors(moreErrs)
+		topologyRecovery.ParticipatingInstanceKeys.AddKey(*masterKey)
+		if len(relocatedReplicas) == 0 && len(moreRelocatedReplicas) > 0 {
+			successorKey = masterKey
+		}
+		relocatedReplicas = append(relocatedReplicas, moreRelocatedReplicas...)
+	}
+	if len(relocatedReplicas) == 0 {
+		if err == nil {
+			err = log.Errorf("topology_recovery: RecoverDeadBinlogServer failed to repoint any replica of %+v", *failedInstanceKey)
+		}
+		topologyRecovery.AddError(err)
+		resolveRecovery(topologyRecovery, nil)
+		return nil, err
+	}
+	successorInstance, _, _ = inst.ReadInstance(successorKey)
+	inst.AuditOperation("recover-dead-binlog-server", failedInstanceKey, fmt.Sprintf("Repointed %d/%d replicas under: %+v; %d errors: %+v", len(relocatedReplicas), len(replicas), *successorKey, len(errs), errs))
+	resolveRecovery(topologyRecovery, successorInstance)
+	return successorInstance, nil
+}
+
+// binlogServerFallbackReplicas returns those replicas of a dead binlog server which were not repointed onto given
+// successor, and should therefore be repointed onto the master. There are none if the successor is the master itself.
+func binlogServerFallbackReplicas(replicas, relocatedReplicas [](*inst.Instance), successorKey, masterKey *inst.InstanceKey) [](*inst.Instance) {
+	if successorKey.Equals(masterKey) {
+		return nil
+	}
+	return inst.RemoveInstances(replicas, relocatedReplicas)
+}
+
+// checkAndRecoverDeadBinlogServer checks a given analysis, decides whether to take action, and possibly takes action
+// Returns true when action was taken.
+func checkAndRecoverDeadBinlogServer(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
+	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedIntermediateMasterRecovery) {
+		return false, nil, nil
+	}
+	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
+	if topologyRecovery == nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadBinlogServer: found an active or recent recovery on %+v. Will not issue another RecoverDeadBinlogServer.", analysisEntry.AnalyzedInstanceKey))
+		return false, nil, err
+	}
+
+	recoverDeadBinlogServerCounter.Inc(1)
+	successorInstance, err := RecoverDeadBinlogServer(topologyRecovery, skipProcesses)
+	if successorInstance != nil {
+		recoverDeadBinlogServerSuccessCounter.Inc(1)
+
+		if !skipProcesses {
+			topologyRecovery.SuccessorKey = &successorInstance.Key
+			topologyRecovery.SuccessorAlias = successorInstance.InstanceAlias
+			executeProcesses(config.Config.PostIntermediateMasterFailoverProcesses, "PostIntermediateMasterFailoverProcesses", topologyRecovery, false)
+		}
+	} else {
+		recoverDeadBinlogServerFailureCounter.Inc(1)
+	}
+	return true, topologyRecovery, err
+}
+
 // RecoverDeadCoMaster recovers a dead co-master, complete logic inside
 func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool) (promotedReplica *inst.Instance, lostReplicas [](*inst.Instance), err error) {
 	topologyRecovery.Type = CoMasterRecovery
@@ -1218,27 +1818,39 @@ func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool)
 
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadCoMaster: coMasterRecoveryType=%+v", coMasterRecoveryType))
 
+	mustPromoteOtherCoMaster := config.Config.CoMasterRecoveryMustPromoteOtherCoMaster
+	if !otherCoMaster.ReadOnly {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadCoMaster: other co-master %+v is writeable hence has to be promoted", otherCoMaster.Key))
+		mustPromoteOtherCoMaster = true
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadCoMaster: mustPromoteOtherCoMaster? %+v", mustPromoteOtherCoMaster))
+
+	var candidateInstanceKey *inst.InstanceKey
+	if mustPromoteOtherCoMaster {
+		candidateInstanceKey = otherCoMasterKey
+	}
+	policyCheckedKey, err := checkCandidatePromotionPolicy(topologyRecovery, candidateInstanceKey)
+	if err != nil {
+		inst.AuditOperation("recover-dead-co-master", failedInstanceKey, fmt.Sprintf("Failure: %+v", err))
+		return nil, lostReplicas, topologyRecovery.AddError(err)
+	}
+
+	ctx, cancel := recoveryOperationContext(topologyRecovery)
+	defer cancel()
 	var cannotReplicateReplicas [](*inst.Instance)
 	switch coMasterRecoveryType {
 	case MasterRecoveryGTID:
 		{
-			lostReplicas, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasGTID(failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, nil)
+			lostReplicas, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasGTIDContext(ctx, failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, nil)
 		}
 	case MasterRecoveryPseudoGTID:
 		{
-			lostReplicas, _, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServers(failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, nil)
+			lostReplicas, _, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasPseudoGTIDIncludingSubReplicasOfBinlogServersContext(ctx, failedInstanceKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, nil)
 		}
 	}
 	topologyRecovery.AddError(err)
 	lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
 
-	mustPromoteOtherCoMaster := config.Config.CoMasterRecoveryMustPromoteOtherCoMaster
-	if !otherCoMaster.ReadOnly {
-		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadCoMaster: other co-master %+v is writeable hence has to be promoted", otherCoMaster.Key))
-		mustPromoteOtherCoMaster = true
-	}
-	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadCoMaster: mustPromoteOtherCoMaster? %+v", mustPromoteOtherCoMaster))
-
 	if promotedReplica != nil {
 		topologyRecovery.ParticipatingInstanceKeys.AddKey(promotedReplica.Key)
 		if mustPromoteOtherCoMaster {
@@ -1256,6 +1868,12 @@ func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool)
 			promotedReplica = nil
 		}
 	}
+	if promotedReplica != nil {
+		if err := recheckPromotionPolicy(topologyRecovery, promotedReplica, policyCheckedKey); err != nil {
+			topologyRecovery.AddError(err)
+			promotedReplica = nil
+		}
+	}
 	if promotedReplica != nil {
 		if config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Waiting to ensure the SQL thread catches up on %+v", promotedReplica.Key))
@@ -1287,7 +1905,7 @@ func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool)
 	}
 
 	if promotedReplica != nil && len(lostReplicas) > 0 && config.Config.DetachLostReplicasAfterMasterFailover {
-		postponedFunction := func() error {
+		postponedFunction := func(ctx context.Context) error {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadCoMaster: lost %+v replicas during recovery process; detaching them", len(lostReplicas)))
 			for _, replica := range lostReplicas {
 				replica := replica
@@ -1295,7 +1913,7 @@ func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool)
 			}
 			return nil
 		}
-		topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("RecoverDeadCoMaster, detaching %+v replicas", len(lostReplicas)))
+		topologyRecovery.AddPrioritizedPostponedFunction(context.Background(), postponedFunction, fmt.Sprintf("RecoverDeadCoMaster, detaching %+v replicas", len(lostReplicas)), inst.PostponedFunctionPriorityLow, 0)
 	}
 
 	func() error {
@@ -1318,11 +1936,17 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedMasterRecovery) {
 		return false, nil, nil
 	}
+	if isRecoveryRefusedByWriteFreeze(&analysisEntry, forceInstanceRecovery) {
+		return false, nil, nil
+	}
 	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
 	if topologyRecovery == nil {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another RecoverDeadCoMaster.", analysisEntry.AnalyzedInstanceKey))
 		return false, nil, err
 	}
+	if isRecoveryRefusedByMasterDeathQuorum(topologyRecovery, forceInstanceRecovery) {
+		return false, topologyRecovery, nil
+	}
 
 	// That's it! We must do recovery!
 	recoverDeadCoMasterCounter.Inc(1)
@@ -1341,9 +1965,22 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 		// success
 		recoverDeadCoMasterSuccessCounter.Inc(1)
 
-		if config.Config.ApplyMySQLPromotionAfterMasterFailover {
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: will apply MySQL changes to promoted master"))
-			inst.SetReadOnly(&promotedReplica.Key, false)
+		if err := consultCoMasterArbiter(topologyRecovery, &promotedReplica.Key); err != nil {
+			// Without the arbiter's approval, writability is left untouched on both co-masters
+			topologyRecovery.AddError(err)
+		} else {
+			if config.Config.ApplyMySQLPromotionAfterMasterFailover {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: will apply MySQL changes to promoted master"))
+				inst.SetReadOnly(&promotedReplica.Key, false)
+			}
+			if config.Config.CoMasterArbiter != "" {
+				// The arbiter approved the promoted co-master as the writable one. Let's attempt, though we won't
+				// necessarily succeed, to set the failed co-master as read-only
+				go func() {
+					_, err := inst.SetReadOnly(failedInstanceKey, true)
+					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadCoMaster: applying read-only=1 on failed co-master: success=%t", (err == nil)))
+				}()
+			}
 		}
 		if !skipProcesses {
 			// Execute post intermediate-master-failover processes
@@ -1471,6 +2108,12 @@ func getCheckAndRecoverFunction(analysisCode inst.AnalysisCode, analyzedInstance
 		return checkAndRecoverDeadIntermediateMaster, true
 	case inst.DeadIntermediateMasterAndSlaves:
 		return checkAndRecoverGenericProblem, false
+	// replication group
+	case inst.DeadReplicationGroupPrimary:
+		return checkAndRecoverDeadReplicationGroupPrimary, true
+	// binlog server
+	case inst.DeadBinlogServer:
+		return checkAndRecoverDeadBinlogServer, true
 	// co-master
 	case inst.DeadCoMaster:
 		return checkAndRecoverDeadCoMaster, true
@@ -1520,6 +2163,9 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 	atomic.AddInt64(&countPendingRecoveries, 1)
 	defer atomic.AddInt64(&countPendingRecoveries, -1)
 
+	if !forceInstanceRecovery {
+		analyzeUnconfirmedDeadMaster(&analysisEntry)
+	}
 	checkAndRecoverFunction, isActionableRecovery := getCheckAndRecoverFunction(analysisEntry.Analysis, &analysisEntry.AnalyzedInstanceKey)
 	analysisEntry.IsActionableRecovery = isActionableRecovery
 	runEmergentOperations(&analysisEntry)
@@ -1591,13 +2237,25 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 	if isActionableRecovery || util.ClearToLog("executeCheckAndRecoverFunction: recovery", analysisEntry.AnalyzedInstanceKey.StringCode()) {
 		log.Infof("executeCheckAndRecoverFunction: proceeding with %+v recovery on %+v; isRecoverable?: %+v; skipProcesses: %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, isActionableRecovery, skipProcesses)
 	}
+	if (isActionableRecovery || forceInstanceRecovery) && analysisEntry.CommandHint != inst.GracefulMasterTakeoverCommandHint {
+		// A graceful takeover already runs as a cluster operation of its own
+		clusterOperation, err := inst.BeginClusterOperation(analysisEntry.ClusterDetails.ClusterName, fmt.Sprintf("recovery of %+v on %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey), process.ThisHostname)
+		if err != nil {
+			return false, nil, log.Errore(err)
+		}
+		defer inst.EndClusterOperation(clusterOperation)
+	}
 	recoveryAttempted, topologyRecovery, err = checkAndRecoverFunction(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	if topologyRecovery != nil {
+		defer inst.EndInFlightOperation(topologyRecovery.UID)
+	}
 	if !recoveryAttempted {
 		return recoveryAttempted, topologyRecovery, err
 	}
 	if topologyRecovery == nil {
 		return recoveryAttempted, topologyRecovery, err
 	}
+	countNamespaceRecovery(analysisEntry.ClusterDetails.Namespace, topologyRecovery)
 	if b, err := json.Marshal(topologyRecovery); err == nil {
 		log.Infof("Topology recovery: %+v", string(b))
 	} else {
@@ -1615,13 +2273,28 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 	}
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Waiting for %d postponed functions", topologyRecovery.PostponedFunctionsContainer.Len()))
 	topologyRecovery.Wait()
-	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Executed %d postponed functions", topologyRecovery.PostponedFunctionsContainer.Len()))
-	if topologyRecovery.PostponedFunctionsContainer.Len() > 0 {
-		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Executed postponed functions: %+v", strings.Join(topologyRecovery.PostponedFunctionsContainer.Descriptions(), ", ")))
+	executedDescriptions := topologyRecovery.PostponedFunctionsContainer.ExecutedDescriptions()
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Executed %d of %d postponed functions", len(executedDescriptions), topologyRecovery.PostponedFunctionsContainer.Len()))
+	if len(executedDescriptions) > 0 {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Executed postponed functions, in order: %+v", strings.Join(executedDescriptions, ", ")))
 	}
 	return recoveryAttempted, topologyRecovery, err
 }
 
+// countNamespaceRecovery counts given recovery by the environment namespace of its cluster, as
+// namespace.<namespace>.recover.start/success/fail
+func countNamespaceRecovery(namespace string, topologyRecovery *TopologyRecovery) {
+	if namespace == "" {
+		return
+	}
+	metrics.GetOrRegisterCounter(fmt.Sprintf("namespace.%s.recover.start", namespace), nil).Inc(1)
+	if topologyRecovery.SuccessorKey == nil {
+		metrics.GetOrRegisterCounter(fmt.Sprintf("namespace.%s.recover.fail", namespace), nil).Inc(1)
+	} else {
+		metrics.GetOrRegisterCounter(fmt.Sprintf("namespace.%s.recover.success", namespace), nil).Inc(1)
+	}
+}
+
 // CheckAndRecover is the main entry point for the recovery mechanism
 func CheckAndRecover(specificInstance *inst.InstanceKey, candidateInstanceKey *inst.InstanceKey, skipProcesses bool) (recoveryAttempted bool, promotedReplicaKey *inst.InstanceKey, err error) {
 	// Allow the analysis to run even if we don't want to recover
@@ -1665,7 +2338,7 @@ func CheckAndRecover(specificInstance *inst.InstanceKey, candidateInstanceKey *i
 	return recoveryAttempted, promotedReplicaKey, err
 }
 
-func forceAnalysisEntry(clusterName string, analysisCode inst.AnalysisCode, commandHint string, failedInstanceKey *inst.InstanceKey) (analysisEntry inst.ReplicationAnalysis, err error) {
+func forceAnalysisEntry(ctx context.Context, clusterName string, analysisCode inst.AnalysisCode, commandHint string, failedInstanceKey *inst.InstanceKey) (analysisEntry inst.ReplicationAnalysis, err error) {
 	clusterInfo, err := inst.ReadClusterInfo(clusterName)
 	if err != nil {
 		return analysisEntry, err
@@ -1683,6 +2356,7 @@ func forceAnalysisEntry(clusterName string, analysisCode inst.AnalysisCode, comm
 	}
 	analysisEntry.Analysis = analysisCode // we force this analysis
 	analysisEntry.CommandHint = commandHint
+	analysisEntry.RequestSpan = tracing.SpanFromContext(ctx)
 	analysisEntry.ClusterDetails = *clusterInfo
 	analysisEntry.AnalyzedInstanceKey = *failedInstanceKey
 
@@ -1698,6 +2372,11 @@ func ForceExecuteRecovery(analysisEntry inst.ReplicationAnalysis, candidateInsta
 
 // ForceMasterFailover *trusts* master of given cluster is dead and initiates a failover
 func ForceMasterFailover(clusterName string) (topologyRecovery *TopologyRecovery, err error) {
+	return ForceMasterFailoverContext(context.Background(), clusterName)
+}
+
+// ForceMasterFailoverContext is ForceMasterFailover, where the recovery is traced as a child of the span carried by given context
+func ForceMasterFailoverContext(ctx context.Context, clusterName string) (topologyRecovery *TopologyRecovery, err error) {
 	clusterMasters, err := inst.ReadClusterMaster(clusterName)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
@@ -1707,7 +2386,7 @@ func ForceMasterFailover(clusterName string) (topologyRecovery *TopologyRecovery
 	}
 	clusterMaster := clusterMasters[0]
 
-	analysisEntry, err := forceAnalysisEntry(clusterName, inst.DeadMaster, inst.ForceMasterFailoverCommandHint, &clusterMaster.Key)
+	analysisEntry, err := forceAnalysisEntry(ctx, clusterName, masterFailureAnalysisCode(clusterMaster), inst.ForceMasterFailoverCommandHint, &clusterMaster.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -1727,9 +2406,128 @@ func ForceMasterFailover(clusterName string) (topologyRecovery *TopologyRecovery
 	return topologyRecovery, nil
 }
 
+// verifyNoMissingTransactionsOf reads given replicas afresh and verifies, by GTID sets, that designated is not missing
+// transactions which they executed. Replicas which cannot be read are unverifiable, and fail verification, unless
+// ignoreUnreachable; in which case they are not verified. Returns the keys of the verified replicas.
+func verifyNoMissingTransactionsOf(operations topologyOperations, designated *inst.Instance, replicaKeys []inst.InstanceKey, ignoreUnreachable bool) (verifiedKeys []inst.InstanceKey, missingTransactions [](*inst.MissingTransactions), err error) {
+	replicas := [](*inst.Instance){}
+	unverifiable := [](*inst.MissingTransactions){}
+	for _, replicaKey := range replicaKeys {
+		if replicaKey.Equals(&designated.Key) {
+			continue
+		}
+		replica, err := operations.ReadTopologyInstance(&replicaKey)
+		if err != nil || replica == nil {
+			if err == nil {
+				err = fmt.Errorf("not found")
+			}
+			if ignoreUnreachable {
+				log.Warningf("Not verifying transactions of unreachable %+v: %+v", replicaKey, err)
+				continue
+			}
+			unverifiable = append(unverifiable, inst.NewUnreachableMissingTransactions(replicaKey, err))
+			continue
+		}
+		replicas = append(replicas, replica)
+		verifiedKeys = append(verifiedKeys, replica.Key)
+	}
+	missingTransactions, err = inst.VerifyNoMissingTransactions(designated, replicas, unverifiable)
+	return verifiedKeys, missingTransactions, err
+}
+
+// reverifyPromotedReplicaTransactions verifies, upon force-master-failover-to, that the promoted replica is not
+// missing transactions of the replicas verified before failing over. By now replication is stopped, and the executed
+// GTID sets are final. A replica which is no longer reachable fails verification.
+func reverifyPromotedReplicaTransactions(operations topologyOperations, topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) error {
+	promoted, err := operations.ReadTopologyInstance(&promotedReplica.Key)
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: failed %+v promotion; cannot re-verify transactions: %+v", promotedReplica.Key, err))
+		return err
+	}
+	replicaKeys := topologyRecovery.AnalysisEntry.TransactionVerifiedReplicas.GetInstanceKeys()
+	if _, _, err := verifyNoMissingTransactionsOf(operations, promoted, replicaKeys, false); err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: failed %+v promotion; %+v", promotedReplica.Key, err))
+		return err
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: re-verified %+v is not missing transactions of %d replicas", promotedReplica.Key, len(replicaKeys)))
+	return nil
+}
+
+// ForceMasterFailoverTo *trusts* master of given cluster is dead and fails over to designated instance, which has
+// to be its direct child. Before failing over, it verifies by GTID sets that the designated instance is not missing
+// transactions which its siblings executed; otherwise it fails, reporting the siblings at fault. Unreachable
+// siblings cannot be verified and fail verification, unless ignoreUnreachableReplicas. Verification is repeated
+// upon promotion, once replication is stopped.
+func ForceMasterFailoverTo(clusterName string, designatedKey *inst.InstanceKey, ignoreUnreachableReplicas bool) (topologyRecovery *TopologyRecovery, missingTransactions [](*inst.MissingTransactions), err error) {
+	return ForceMasterFailoverToContext(context.Background(), clusterName, designatedKey, ignoreUnreachableReplicas)
+}
+
+// ForceMasterFailoverToContext is ForceMasterFailoverTo, where the recovery is traced as a child of the span carried by given context
+func ForceMasterFailoverToContext(ctx context.Context, clusterName string, designatedKey *inst.InstanceKey, ignoreUnreachableReplicas bool) (topologyRecovery *TopologyRecovery, missingTransactions [](*inst.MissingTransactions), err error) {
+	clusterMasters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
+	}
+	if len(clusterMasters) != 1 {
+		return nil, nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
+	}
+	clusterMaster := clusterMasters[0]
+
+	designatedInstance, err := inst.ReadTopologyInstance(designatedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !designatedInstance.MasterKey.Equals(&clusterMaster.Key) {
+		return nil, nil, fmt.Errorf("You may only promote a direct child of the master %+v. The master of %+v is %+v.", clusterMaster.Key, designatedInstance.Key, designatedInstance.MasterKey)
+	}
+	siblings, err := inst.ReadReplicaInstances(&clusterMaster.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	siblingKeys := []inst.InstanceKey{}
+	for _, sibling := range siblings {
+		siblingKeys = append(siblingKeys, sibling.Key)
+	}
+	verifiedKeys, missingTransactions, err := verifyNoMissingTransactionsOf(instanceTopologyOperations{}, designatedInstance, siblingKeys, ignoreUnreachableReplicas)
+	if err != nil {
+		inst.AuditOperation("force-master-failover-to", &clusterMaster.Key, fmt.Sprintf("will not promote %+v: %+v", designatedInstance.Key, err))
+		return nil, missingTransactions, err
+	}
+	log.Infof("Will fail over %+v to %+v, verified not to be missing transactions", clusterMaster.Key, designatedInstance.Key)
+
+	analysisEntry, err := forceAnalysisEntry(ctx, clusterName, masterFailureAnalysisCode(clusterMaster), inst.ForceMasterFailoverToCommandHint, &clusterMaster.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	analysisEntry.TransactionVerifiedReplicas = *inst.NewInstanceKeyMap()
+	analysisEntry.TransactionVerifiedReplicas.AddKeys(verifiedKeys)
+	recoveryAttempted, topologyRecovery, err := ForceExecuteRecovery(analysisEntry, &designatedInstance.Key, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !recoveryAttempted {
+		return nil, nil, fmt.Errorf("Unexpected error: recovery not attempted. This should not happen")
+	}
+	if topologyRecovery == nil {
+		return nil, nil, fmt.Errorf("Recovery attempted but with no results. This should not happen")
+	}
+	if topologyRecovery.SuccessorKey == nil {
+		return nil, nil, fmt.Errorf("Recovery attempted yet no replica promoted")
+	}
+	if !topologyRecovery.SuccessorKey.Equals(&designatedInstance.Key) {
+		return topologyRecovery, nil, fmt.Errorf("Recovery promoted %+v rather than designated %+v", *topologyRecovery.SuccessorKey, designatedInstance.Key)
+	}
+	return topologyRecovery, nil, nil
+}
+
 // ForceMasterTakeover *trusts* master of given cluster is dead and fails over to designated instance,
 // which has to be its direct child.
 func ForceMasterTakeover(clusterName string, destination *inst.Instance) (topologyRecovery *TopologyRecovery, err error) {
+	return ForceMasterTakeoverContext(context.Background(), clusterName, destination)
+}
+
+// ForceMasterTakeoverContext is ForceMasterTakeover, where the recovery is traced as a child of the span carried by given context
+func ForceMasterTakeoverContext(ctx context.Context, clusterName string, destination *inst.Instance) (topologyRecovery *TopologyRecovery, err error) {
 	clusterMasters, err := inst.ReadClusterWriteableMaster(clusterName)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot deduce cluster master for %+v", clusterName)
@@ -1739,12 +2537,16 @@ func ForceMasterTakeover(clusterName string, destination *inst.Instance) (topolo
 	}
 	clusterMaster := clusterMasters[0]
 
-	if !destination.MasterKey.Equals(&clusterMaster.Key) {
+	if clusterMaster.IsReplicationGroupMember() {
+		if !destination.IsReplicationGroupPeerOf(clusterMaster) {
+			return nil, fmt.Errorf("You may only promote a member of the replication group of %+v. %+v is not a member of group %s.", clusterMaster.Key, destination.Key, clusterMaster.ReplicationGroupName)
+		}
+	} else if !destination.MasterKey.Equals(&clusterMaster.Key) {
 		return nil, fmt.Errorf("You may only promote a direct child of the master %+v. The master of %+v is %+v.", clusterMaster.Key, destination.Key, destination.MasterKey)
 	}
 	log.Infof("Will demote %+v and promote %+v instead", clusterMaster.Key, destination.Key)
 
-	analysisEntry, err := forceAnalysisEntry(clusterName, inst.DeadMaster, inst.ForceMasterTakeoverCommandHint, &clusterMaster.Key)
+	analysisEntry, err := forceAnalysisEntry(ctx, clusterName, masterFailureAnalysisCode(clusterMaster), inst.ForceMasterTakeoverCommandHint, &clusterMaster.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -1764,13 +2566,129 @@ func ForceMasterTakeover(clusterName string, destination *inst.Instance) (topolo
 	return topologyRecovery, nil
 }
 
+// gracefulTakeoverTrafficContext bounds the run of the traffic pausing, or resuming, hooks of a graceful master takeover
+func gracefulTakeoverTrafficContext() (context.Context, context.CancelFunc) {
+	if config.Config.GracefulTakeoverTrafficHookTimeoutSeconds == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), time.Duration(config.Config.GracefulTakeoverTrafficHookTimeoutSeconds)*time.Second)
+}
+
+// gracefulTakeoverCatchUpContext bounds the wait for the designated replica of a graceful master takeover to catch up
+func gracefulTakeoverCatchUpContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if config.Config.GracefulTakeoverCatchUpTimeoutSeconds == 0 {
+		return context.WithCancel(tracing.DetachedContext(ctx))
+	}
+	return context.WithTimeout(tracing.DetachedContext(ctx), time.Duration(config.Config.GracefulTakeoverCatchUpTimeoutSeconds)*time.Second)
+}
+
+// pauseGracefulTakeoverTraffic runs GracefulTakeoverPauseTrafficProcesses. Should any of them fail, traffic is
+// resumed, as some proxies may have paused already, and the takeover is to be aborted.
+func pauseGracefulTakeoverTraffic(topologyRecovery *TopologyRecovery) error {
+	ctx, cancel := gracefulTakeoverTrafficContext()
+	defer cancel()
+	if err := executeProcessesContext(ctx, config.Config.GracefulTakeoverPauseTrafficProcesses, "GracefulTakeoverPauseTrafficProcesses", topologyRecovery, true); err != nil {
+		resumeGracefulTakeoverTraffic(topologyRecovery)
+		return fmt.Errorf("Failed running GracefulTakeoverPauseTrafficProcesses: %+v", err)
+	}
+	return nil
+}
+
+// resumeGracefulTakeoverTraffic runs GracefulTakeoverResumeTrafficProcesses. Given a successful takeover's
+// topologyRecovery, the hooks learn of the promoted master via the successor placeholders.
+func resumeGracefulTakeoverTraffic(topologyRecovery *TopologyRecovery) error {
+	ctx, cancel := gracefulTakeoverTrafficContext()
+	defer cancel()
+	return executeProcessesContext(ctx, config.Config.GracefulTakeoverResumeTrafficProcesses, "GracefulTakeoverResumeTrafficProcesses", topologyRecovery, false)
+}
+
+// rollbackGracefulMasterTakeover reverts a graceful master takeover aborted before promotion: the master regains
+// its writability and the designated replica resumes replicating from it
+func rollbackGracefulMasterTakeover(masterKey *inst.InstanceKey, masterWasReadOnly bool, designatedKey *inst.InstanceKey, reason string) {
+	if !masterWasReadOnly {
+		log.Infof("GracefulMasterTakeover: rolling back; will set %+v as writable", *masterKey)
+		if _, err := inst.SetReadOnly(masterKey, false); err != nil {
+			log.Errore(err)
+		}
+	}
+	if _, err := inst.StartSlave(designatedKey); err != nil {
+		log.Errore(err)
+	}
+	inst.AuditOperation("graceful-master-takeover-rollback", masterKey, reason)
+}
+
+// demoteGracefulTakeoverMaster completes an automated graceful takeover. The demoted master, already pointed at
+// the promoted master, is set super_read_only and starts replicating; semi-sync master and replica roles, where used,
+// are swapped between the two. The demoted master is configured as a semi-sync replica before it starts replicating,
+// and only then is semi-sync master enabled on the promoted master, such that writes on the promoted master do not
+// wait on a semi-sync replica which is not there. The demotion is audited as a single operation.
+func demoteGracefulTakeoverMaster(operations topologyOperations, topologyRecovery *TopologyRecovery, demotedKey *inst.InstanceKey, promotedKey *inst.InstanceKey, demotedWasSemiSyncMaster bool, promotedWasSemiSyncReplica bool) (err error) {
+	steps := []string{}
+	defer func() {
+		if err != nil {
+			steps = append(steps, fmt.Sprintf("failed: %+v", err))
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- GracefulMasterTakeover: demoting %+v: %s", *demotedKey, strings.Join(steps, "; ")))
+		inst.AuditOperation("graceful-master-takeover-demote", demotedKey, fmt.Sprintf("demoted below %+v: %s", *promotedKey, strings.Join(steps, "; ")))
+	}()
+
+	if _, err := operations.SetSuperReadOnly(demotedKey, true); err != nil {
+		// Not fatal: read_only is already set, and super_read_only may not be supported
+		steps = append(steps, fmt.Sprintf("super_read_only not set: %+v", err))
+	} else {
+		steps = append(steps, "super_read_only set")
+	}
+	if demotedWasSemiSyncMaster {
+		if _, err := operations.SetSemiSyncMaster(demotedKey, false); err != nil {
+			return err
+		}
+		steps = append(steps, "semi-sync master disabled on demoted master")
+	}
+	if promotedWasSemiSyncReplica {
+		if _, err := operations.SetSemiSyncReplica(demotedKey, true); err != nil {
+			return err
+		}
+		steps = append(steps, "semi-sync replica enabled on demoted master")
+	}
+	if _, err := operations.StartSlave(demotedKey); err != nil {
+		return err
+	}
+	steps = append(steps, "replication started")
+	if demotedWasSemiSyncMaster {
+		if _, err := operations.SetSemiSyncMaster(promotedKey, true); err != nil {
+			return err
+		}
+		steps = append(steps, "semi-sync master enabled on promoted master")
+	}
+	if promotedWasSemiSyncReplica {
+		if _, err := operations.SetSemiSyncReplica(promotedKey, false); err != nil {
+			return err
+		}
+		steps = append(steps, "semi-sync replica disabled on promoted master")
+	}
+	return nil
+}
+
 // GracefulMasterTakeover will demote master of existing topology and promote its
 // direct replica instead.
 // It expects that replica to have no siblings.
 // This function is graceful in that it will first lock down the master, then wait
 // for the designated replica to catch up with last position.
-// It will point old master at the newly promoted master at the correct coordinates, but will not start replication.
-func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey) (topologyRecovery *TopologyRecovery, promotedMasterCoordinates *inst.BinlogCoordinates, err error) {
+// It will point old master at the newly promoted master at the correct coordinates. With auto, it will further set
+// the old master super_read_only, swap semi-sync roles and start replication; otherwise replication is not started.
+func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey, auto bool) (topologyRecovery *TopologyRecovery, promotedMasterCoordinates *inst.BinlogCoordinates, err error) {
+	return GracefulMasterTakeoverContext(context.Background(), clusterName, designatedKey, auto)
+}
+
+// GracefulMasterTakeoverContext is GracefulMasterTakeover, where the takeover's operations and recovery are traced as
+// children of the span carried by given context. The takeover is not cancelled along with given context.
+func GracefulMasterTakeoverContext(ctx context.Context, clusterName string, designatedKey *inst.InstanceKey, auto bool) (topologyRecovery *TopologyRecovery, promotedMasterCoordinates *inst.BinlogCoordinates, err error) {
+	clusterOperation, err := inst.BeginClusterOperation(clusterName, fmt.Sprintf("graceful-master-takeover of %s", clusterName), inst.GetMaintenanceOwner())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer inst.EndClusterOperation(clusterOperation)
+
 	clusterMasters, err := inst.ReadClusterMaster(clusterName)
 	if err != nil {
 		return nil, nil, fmt.Errorf("Cannot deduce cluster master for %+v; error: %+v", clusterName, err)
@@ -1780,6 +2698,13 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 	}
 	clusterMaster := clusterMasters[0]
 
+	takeoverCorrelationID := inst.BeginInFlightOperation("", "graceful-master-takeover", clusterName, inst.GetMaintenanceOwner(), false, clusterMaster.Key)
+	defer inst.EndInFlightOperation(takeoverCorrelationID)
+
+	if clusterMaster.IsReplicationGroupMember() {
+		return gracefulReplicationGroupPrimaryTakeover(ctx, clusterName, clusterMaster, designatedKey)
+	}
+
 	clusterMasterDirectReplicas, err := inst.ReadReplicaInstances(&clusterMaster.Key)
 	if err != nil {
 		return nil, nil, log.Errore(err)
@@ -1828,10 +2753,25 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 	if !designatedInstance.HasReasonableMaintenanceReplicationLag() {
 		return nil, nil, fmt.Errorf("Desginated instance %+v seems to be lagging to much for thie operation. Aborting.", designatedInstance.Key)
 	}
+	policyInput := inst.NewOperationPolicyInput("graceful-master-takeover", &designatedInstance.Key, &clusterMaster.Key)
+	policyInput.Instance = designatedInstance
+	policyInput.Other = clusterMaster
+	if err := inst.CheckOperationPolicy(policyInput); err != nil {
+		return nil, nil, fmt.Errorf("GracefulMasterTakeover: %+v", err)
+	}
+	takeoverWait, takeoverWaitFound := inst.GetTakeoverWait(clusterName)
+	var takeoverWaitReplicas [](*inst.Instance)
+	if takeoverWaitFound {
+		if takeoverWaitReplicas, err = inst.ReadTakeoverWaitReplicas(clusterName, takeoverWait, &designatedInstance.Key); err != nil {
+			return nil, nil, fmt.Errorf("GracefulMasterTakeover: %+v", err)
+		}
+	}
 
 	if len(clusterMasterDirectReplicas) > 1 {
 		log.Infof("GracefulMasterTakeover: Will let %+v take over its siblings", designatedInstance.Key)
-		relocatedReplicas, _, err, _ := inst.RelocateReplicas(&clusterMaster.Key, &designatedInstance.Key, "")
+		inst.SetInFlightOperationStep(takeoverCorrelationID, "relocating siblings below designated instance", designatedInstance.Key)
+		// The takeover is requested as a whole: siblings follow the designated instance, be it across a WAN link
+		relocatedReplicas, _, err, _ := inst.RelocateReplicasContext(inst.WithWANRelocationAllowed(tracing.DetachedContext(ctx)), &clusterMaster.Key, &designatedInstance.Key, "")
 		if len(relocatedReplicas) != len(clusterMasterDirectReplicas)-1 {
 			// We are unable to make designated instance master of all its siblings
 			relocatedReplicasKeyMap := inst.NewInstanceKeyMap()
@@ -1856,10 +2796,11 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 		}
 	}
 	log.Infof("GracefulMasterTakeover: Will demote %+v and promote %+v instead", clusterMaster.Key, designatedInstance.Key)
+	inst.SetInFlightOperationStep(takeoverCorrelationID, "running pre graceful takeover processes", designatedInstance.Key)
 
 	replicationUser, replicationPassword, replicationCredentialsError := inst.ReadReplicationCredentials(&designatedInstance.Key)
 
-	analysisEntry, err := forceAnalysisEntry(clusterName, inst.DeadMaster, inst.GracefulMasterTakeoverCommandHint, &clusterMaster.Key)
+	analysisEntry, err := forceAnalysisEntry(ctx, clusterName, inst.DeadMaster, inst.GracefulMasterTakeoverCommandHint, &clusterMaster.Key)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1870,24 +2811,66 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 		return nil, nil, fmt.Errorf("Failed running PreGracefulTakeoverProcesses: %+v", err)
 	}
 
+	// Traffic, once paused, is resumed when the takeover completes or fails, whichever comes first
+	trafficTopologyRecovery := preGracefulTakeoverTopologyRecovery
+	resumeTraffic := func() {}
+	if len(config.Config.GracefulTakeoverPauseTrafficProcesses) > 0 {
+		inst.SetInFlightOperationStep(takeoverCorrelationID, "pausing traffic")
+		if err := pauseGracefulTakeoverTraffic(preGracefulTakeoverTopologyRecovery); err != nil {
+			return nil, nil, err
+		}
+		var resumeTrafficOnce sync.Once
+		resumeTraffic = func() {
+			resumeTrafficOnce.Do(func() { resumeGracefulTakeoverTraffic(trafficTopologyRecovery) })
+		}
+		defer resumeTraffic()
+	}
+
 	if designatedInstance, err = inst.StopSlave(&designatedInstance.Key); err != nil {
 		return nil, nil, err
 	}
+	masterWasReadOnly := clusterMaster.ReadOnly
+	masterWasSemiSyncMaster := clusterMaster.SemiSyncMasterEnabled
+	designatedWasSemiSyncReplica := designatedInstance.SemiSyncReplicaEnabled
 	log.Infof("GracefulMasterTakeover: Will set %+v as read_only", clusterMaster.Key)
+	inst.SetInFlightOperationStep(takeoverCorrelationID, "setting master read_only")
 	if clusterMaster, err = inst.SetReadOnly(&clusterMaster.Key, true); err != nil {
 		return nil, nil, err
 	}
 	demotedMasterSelfBinlogCoordinates := clusterMaster.SelfBinlogCoordinates
 	log.Infof("GracefulMasterTakeover: Will advance %+v to master coordinates %+v", designatedInstance.Key, demotedMasterSelfBinlogCoordinates)
-	if designatedInstance, err = inst.StartSlaveUntilMasterCoordinates(&designatedInstance.Key, &clusterMaster.SelfBinlogCoordinates); err != nil {
+	designatedInstanceKey := designatedInstance.Key
+	inst.SetInFlightOperationStep(takeoverCorrelationID, "designated instance catching up with master")
+	catchUpCtx, cancelCatchUp := gracefulTakeoverCatchUpContext(ctx)
+	designatedInstance, err = inst.StartSlaveUntilMasterCoordinatesContext(catchUpCtx, &designatedInstanceKey, &clusterMaster.SelfBinlogCoordinates)
+	cancelCatchUp()
+	if err != nil {
+		if catchUpCtx.Err() == context.DeadlineExceeded {
+			rollbackGracefulMasterTakeover(&clusterMaster.Key, masterWasReadOnly, &designatedInstanceKey, fmt.Sprintf("designated replica %+v did not catch up", designatedInstanceKey))
+			return nil, nil, fmt.Errorf("GracefulMasterTakeover: %+v did not catch up with %+v within %d seconds; rolled back", designatedInstanceKey, clusterMaster.Key, config.Config.GracefulTakeoverCatchUpTimeoutSeconds)
+		}
 		return nil, nil, err
 	}
 	promotedMasterCoordinates = &designatedInstance.SelfBinlogCoordinates
+	if len(takeoverWaitReplicas) > 0 {
+		log.Infof("GracefulMasterTakeover: waiting for %d must-wait replicas to replicate within %ds", len(takeoverWaitReplicas), takeoverWait.MaxLagSeconds)
+		inst.SetInFlightOperationStep(takeoverCorrelationID, "waiting for must-wait replicas")
+		if err := inst.WaitForTakeoverReplicas(takeoverWaitReplicas, takeoverWait); err != nil {
+			rollbackGracefulMasterTakeover(&clusterMaster.Key, masterWasReadOnly, &designatedInstanceKey, fmt.Sprintf("must-wait replicas not confirmed: %+v", err))
+			return nil, nil, fmt.Errorf("GracefulMasterTakeover: must-wait replicas not confirmed; rolled back: %+v", err)
+		}
+	}
 
+	inst.SetInFlightOperationStep(takeoverCorrelationID, "promoting designated instance")
 	recoveryAttempted, topologyRecovery, err := ForceExecuteRecovery(analysisEntry, &designatedInstance.Key, false)
 	if err != nil {
 		return nil, nil, err
 	}
+	if topologyRecovery != nil && topologyRecovery.SuccessorKey != nil {
+		// The promoted master is writable: no need to wait on the demoted master's repointing
+		trafficTopologyRecovery = topologyRecovery
+		resumeTraffic()
+	}
 	if !recoveryAttempted {
 		return nil, nil, fmt.Errorf("Unexpected error: recovery not attempted. This should not happen")
 	}
@@ -1901,7 +2884,9 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 	if topologyRecovery.RecoveryType == MasterRecoveryGTID {
 		gtidHint = inst.GTIDHintForce
 	}
-	clusterMaster, err = inst.ChangeMasterTo(&clusterMaster.Key, &designatedInstance.Key, promotedMasterCoordinates, false, gtidHint)
+	inst.SetInFlightOperationStep(takeoverCorrelationID, "repointing demoted master below promoted master")
+	repointCtx := inst.WithMasterChangeOrigin(inst.WithWANRelocationAllowed(tracing.DetachedContext(ctx)), inst.NewMasterChangeOrigin(inst.MasterChangeCausePlanned, inst.GetMaintenanceOwner(), topologyRecovery.UID))
+	clusterMaster, err = inst.ChangeMasterToContext(repointCtx, &clusterMaster.Key, &designatedInstance.Key, promotedMasterCoordinates, false, gtidHint)
 	if !clusterMaster.SelfBinlogCoordinates.Equals(&demotedMasterSelfBinlogCoordinates) {
 		log.Errorf("GracefulMasterTakeover: sanity problem. Demoted master's coordinates changed from %+v to %+v while supposed to have been frozen", demotedMasterSelfBinlogCoordinates, clusterMaster.SelfBinlogCoordinates)
 	}
@@ -1918,6 +2903,11 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 			err = enableSSLErr
 		}
 	}
+	if auto && err == nil {
+		inst.SetInFlightOperationStep(takeoverCorrelationID, "demoting old master as replica of promoted master")
+		err = demoteGracefulTakeoverMaster(instanceTopologyOperations{}, topologyRecovery, &clusterMaster.Key, &designatedInstance.Key, masterWasSemiSyncMaster, designatedWasSemiSyncReplica)
+	}
+	inst.SetInFlightOperationStep(takeoverCorrelationID, "running post graceful takeover processes")
 	executeProcesses(config.Config.PostGracefulTakeoverProcesses, "PostGracefulTakeoverProcesses", topologyRecovery, false)
 
 	return topologyRecovery, promotedMasterCoordinates, err
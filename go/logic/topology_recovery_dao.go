@@ -17,14 +17,17 @@
 package logic
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/github/orchestrator/go/config"
 	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/events"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/process"
 	"github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/tracing"
 	"github.com/github/orchestrator/go/util"
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/sqlutils"
@@ -139,7 +142,7 @@ func acknowledgeInstanceFailureDetection(instanceKey *inst.InstanceKey) error {
 
 func writeTopologyRecovery(topologyRecovery *TopologyRecovery) (*TopologyRecovery, error) {
 	analysisEntry := topologyRecovery.AnalysisEntry
-	sqlResult, err := db.ExecOrchestrator(`
+	sqlResult, err := db.ExecOrchestratorContext(recoveryTracingContext(topologyRecovery), `
 			insert ignore
 				into topology_recovery (
 					recovery_id,
@@ -229,6 +232,12 @@ func AttemptRecoveryRegistration(analysisEntry *inst.ReplicationAnalysis, failIf
 			return nil, log.Errorf("AttemptRecoveryRegistration: cluster %+v has recently experienced a failover (of %+v) and is in active period. It will not be failed over again. You may acknowledge the failure on this cluster (-c ack-cluster-recoveries) or on %+v (-c ack-instance-recoveries) to remove this blockage", analysisEntry.ClusterDetails.ClusterName, recoveries[0].AnalysisEntry.AnalyzedInstanceKey, recoveries[0].AnalysisEntry.AnalyzedInstanceKey)
 		}
 	}
+	if failIfClusterInActiveRecovery {
+		// Automated recoveries count against the cluster's rate limit; those forced by a user do not
+		if err := admitRecoveryByRateLimit(analysisEntry); err != nil {
+			return nil, log.Errore(err)
+		}
+	}
 	if !failIfFailedInstanceInActiveRecovery {
 		// Implicitly acknowledge this instance's possibly existing active recovery, provided they are completed.
 		AcknowledgeInstanceCompletedRecoveries(&analysisEntry.AnalyzedInstanceKey, "orchestrator", fmt.Sprintf("implicit acknowledge due to user invocation of recovery on same instance: %+v", analysisEntry.AnalyzedInstanceKey))
@@ -237,16 +246,41 @@ func AttemptRecoveryRegistration(analysisEntry *inst.ReplicationAnalysis, failIf
 	}
 
 	topologyRecovery := NewTopologyRecovery(*analysisEntry)
+	span := tracing.StartSpan(fmt.Sprintf("recovery %s", analysisEntry.Analysis), analysisEntry.RequestSpan)
+	span.SetAttribute("recovery_uid", topologyRecovery.UID)
+	span.SetAttribute("instance", analysisEntry.AnalyzedInstanceKey.StringCode())
+	span.SetAttribute("cluster", analysisEntry.ClusterDetails.ClusterName)
+	topologyRecovery.Span = span
 
 	topologyRecovery, err := writeTopologyRecovery(topologyRecovery)
 	if err != nil {
+		span.SetError(err)
+		span.End()
 		return nil, log.Errore(err)
 	}
+	if topologyRecovery == nil {
+		span.AddEvent("not registered: found an active or recent recovery")
+		span.End()
+		return nil, nil
+	}
 	if orcraft.IsRaftEnabled() {
 		if _, err := orcraft.PublishCommand("write-recovery", topologyRecovery); err != nil {
 			return nil, log.Errore(err)
 		}
 	}
+	events.Publish(&events.Event{
+		Kind:        events.TopologyEvent,
+		Type:        inst.TopologyRecoveryStartedEvent,
+		Hostname:    analysisEntry.AnalyzedInstanceKey.Hostname,
+		Port:        analysisEntry.AnalyzedInstanceKey.Port,
+		ClusterName: analysisEntry.ClusterDetails.ClusterName,
+		Message:     fmt.Sprintf("recovery %s started: %s", topologyRecovery.UID, analysisEntry.Analysis),
+	})
+	// The recovery is in flight through its postponed functions, as ended by executeCheckAndRecoverFunction.
+	// Recoveries which are not forced by a user are automated.
+	inst.BeginInFlightOperation(topologyRecovery.UID, fmt.Sprintf("recovery: %s", analysisEntry.Analysis), analysisEntry.ClusterDetails.ClusterName, inst.GetMaintenanceOwner(), failIfFailedInstanceInActiveRecovery, analysisEntry.AnalyzedInstanceKey)
+	applyPostponementRelaxations(topologyRecovery)
+	focusClusterOnRecovery(topologyRecovery)
 	return topologyRecovery, nil
 }
 
@@ -480,7 +514,7 @@ func writeResolveRecovery(topologyRecovery *TopologyRecovery) error {
 	if topologyRecovery.IsSuccessful {
 		successorKeyToWrite = *topologyRecovery.SuccessorKey
 	}
-	_, err := db.ExecOrchestrator(`
+	_, err := db.ExecOrchestratorContext(recoveryTracingContext(topologyRecovery), `
 			update topology_recovery set
 				is_successful = ?,
 				successor_hostname = ?,
@@ -809,8 +843,8 @@ func ReadBlockedRecoveries(clusterName string) ([]BlockedTopologyRecovery, error
 }
 
 // writeTopologyRecoveryStep writes down a single step in a recovery process
-func writeTopologyRecoveryStep(topologyRecoveryStep *TopologyRecoveryStep) error {
-	sqlResult, err := db.ExecOrchestrator(`
+func writeTopologyRecoveryStep(ctx context.Context, topologyRecoveryStep *TopologyRecoveryStep) error {
+	sqlResult, err := db.ExecOrchestratorContext(ctx, `
 			insert ignore
 				into topology_recovery_steps (
 					recovery_step_id, recovery_uid, audit_at, message
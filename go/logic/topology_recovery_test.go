@@ -0,0 +1,549 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/events"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+	test "github.com/openark/golib/tests"
+)
+
+func init() {
+	config.Config.HostnameResolveMethod = "none"
+	config.MarkConfigurationLoaded()
+	log.SetLevel(log.ERROR)
+	// inst caches are initialized asynchronously once configuration is loaded
+	time.Sleep(100 * time.Millisecond)
+}
+
+func newTestInstance(hostname string, port int) *inst.Instance {
+	instance := inst.NewInstance()
+	instance.Key = inst.InstanceKey{Hostname: hostname, Port: port}
+	return instance
+}
+
+func TestBinlogServerFallbackReplicas(t *testing.T) {
+	masterKey := &inst.InstanceKey{Hostname: "master", Port: 3306}
+	binlogServerKey := &inst.InstanceKey{Hostname: "binlog-server", Port: 3306}
+	replicas := [](*inst.Instance){
+		newTestInstance("replica1", 3306),
+		newTestInstance("replica2", 3306),
+		newTestInstance("replica3", 3306),
+	}
+	{
+		// All repointed onto the binlog server
+		remaining := binlogServerFallbackReplicas(replicas, replicas, binlogServerKey, masterKey)
+		test.S(t).ExpectEquals(len(remaining), 0)
+	}
+	{
+		// Some repointed onto the binlog server
+		remaining := binlogServerFallbackReplicas(replicas, replicas[1:], binlogServerKey, masterKey)
+		test.S(t).ExpectEquals(len(remaining), 1)
+		test.S(t).ExpectEquals(remaining[0].Key.Hostname, "replica1")
+	}
+	{
+		// None repointed onto the binlog server: all fall back to the master
+		remaining := binlogServerFallbackReplicas(replicas, [](*inst.Instance){}, binlogServerKey, masterKey)
+		test.S(t).ExpectEquals(len(remaining), 3)
+	}
+	{
+		// Successor is the master itself: nothing to fall back to
+		remaining := binlogServerFallbackReplicas(replicas, [](*inst.Instance){}, masterKey, masterKey)
+		test.S(t).ExpectEquals(len(remaining), 0)
+	}
+}
+
+func newTestReplica(hostname string, masterKey inst.InstanceKey, running bool) *inst.Instance {
+	replica := newTestInstance(hostname, 3306)
+	replica.MasterKey = masterKey
+	replica.ReadBinlogCoordinates = inst.BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 4}
+	replica.ReplicationSQLThreadState = inst.ReplicationThreadStateStopped
+	replica.ReplicationIOThreadState = inst.ReplicationThreadStateStopped
+	if running {
+		replica.ReplicationSQLThreadState = inst.ReplicationThreadStateRunning
+		replica.ReplicationIOThreadState = inst.ReplicationThreadStateRunning
+	}
+	return replica
+}
+
+// fakeTopologyOperations reads given instances, keyed by hostname, and given replicas; others are unreachable. It
+// records the topology operations applied, each as "<operation> <hostname>"; the operation named by failedOperation
+// fails
+type fakeTopologyOperations struct {
+	instances       map[string]*inst.Instance
+	replicas        func() ([](*inst.Instance), error)
+	operations      []string
+	failedOperation string
+}
+
+func (this *fakeTopologyOperations) ReadTopologyInstance(instanceKey *inst.InstanceKey) (*inst.Instance, error) {
+	if instance, ok := this.instances[instanceKey.Hostname]; ok {
+		return instance, nil
+	}
+	return nil, fmt.Errorf("cannot connect to %+v", *instanceKey)
+}
+
+func (this *fakeTopologyOperations) ReadReplicaInstances(masterKey *inst.InstanceKey) ([](*inst.Instance), error) {
+	return this.replicas()
+}
+
+func (this *fakeTopologyOperations) apply(operation string, instanceKey *inst.InstanceKey) (*inst.Instance, error) {
+	operation = fmt.Sprintf("%s %s", operation, instanceKey.Hostname)
+	this.operations = append(this.operations, operation)
+	if operation == this.failedOperation {
+		return nil, fmt.Errorf("%s failed", operation)
+	}
+	return newTestInstance(instanceKey.Hostname, instanceKey.Port), nil
+}
+
+func (this *fakeTopologyOperations) ResetSlave(instanceKey *inst.InstanceKey) (*inst.Instance, error) {
+	return this.apply("reset-slave-all", instanceKey)
+}
+
+func (this *fakeTopologyOperations) SetSuperReadOnly(instanceKey *inst.InstanceKey, superReadOnly bool) (*inst.Instance, error) {
+	return this.apply(fmt.Sprintf("super-read-only=%t", superReadOnly), instanceKey)
+}
+
+func (this *fakeTopologyOperations) SetSemiSyncMaster(instanceKey *inst.InstanceKey, enableMaster bool) (*inst.Instance, error) {
+	return this.apply(fmt.Sprintf("semi-sync-master=%t", enableMaster), instanceKey)
+}
+
+func (this *fakeTopologyOperations) SetSemiSyncReplica(instanceKey *inst.InstanceKey, enableReplica bool) (*inst.Instance, error) {
+	return this.apply(fmt.Sprintf("semi-sync-replica=%t", enableReplica), instanceKey)
+}
+
+func (this *fakeTopologyOperations) StartSlave(instanceKey *inst.InstanceKey) (*inst.Instance, error) {
+	return this.apply("start-replication", instanceKey)
+}
+
+func TestIsPromotedMasterTopologyHealthy(t *testing.T) {
+	master := newTestInstance("promoted", 3306)
+	running := newTestReplica("running", master.Key, true)
+	stopped := newTestReplica("stopped", master.Key, false)
+	downtimed := newTestReplica("downtimed", master.Key, false)
+	downtimed.IsDowntimed = true
+	stale := newTestReplica("stale", master.Key, false)
+	refreshedStale := newTestReplica("stale", master.Key, true)
+
+	tests := []struct {
+		name            string
+		instances       map[string]*inst.Instance
+		replicas        [](*inst.Instance)
+		replicasErr     error
+		expectedHealthy bool
+		expectedReason  string
+	}{
+		{"master unreachable", map[string]*inst.Instance{}, nil, nil, false, "is unreachable"},
+		{"replicas unreadable", map[string]*inst.Instance{"promoted": master}, nil, fmt.Errorf("backend down"), false, "backend down"},
+		{"no replicas", map[string]*inst.Instance{"promoted": master}, [](*inst.Instance){}, nil, true, ""},
+		{"all replicating", map[string]*inst.Instance{"promoted": master}, [](*inst.Instance){running}, nil, true, ""},
+		{"downtimed replica not replicating", map[string]*inst.Instance{"promoted": master}, [](*inst.Instance){running, downtimed}, nil, true, ""},
+		{"stale in backend, replicating", map[string]*inst.Instance{"promoted": master, "stale": refreshedStale}, [](*inst.Instance){stale}, nil, true, ""},
+		{"replica not replicating", map[string]*inst.Instance{"promoted": master, "stopped": stopped}, [](*inst.Instance){running, stopped}, nil, false, "stopped:3306 is not replicating"},
+		{"replica unreachable", map[string]*inst.Instance{"promoted": master}, [](*inst.Instance){stopped}, nil, false, "stopped:3306 is not replicating"},
+	}
+	for _, tt := range tests {
+		operations := &fakeTopologyOperations{instances: tt.instances, replicas: func() ([](*inst.Instance), error) { return tt.replicas, tt.replicasErr }}
+		healthy, reason := isPromotedMasterTopologyHealthy(operations, &master.Key)
+		test.S(t).ExpectEquals(healthy, tt.expectedHealthy)
+		test.S(t).ExpectTrue(strings.Contains(reason, tt.expectedReason))
+	}
+}
+
+func TestResetSlaveAllOnPromotedMasterWhenHealthy(t *testing.T) {
+	master := newTestInstance("promoted", 3306)
+	running := newTestReplica("running", master.Key, true)
+	stopped := newTestReplica("stopped", master.Key, false)
+
+	defer func(waitSeconds uint) {
+		config.Config.PromotedMasterResetSlaveAllWaitSeconds = waitSeconds
+	}(config.Config.PromotedMasterResetSlaveAllWaitSeconds)
+	config.Config.PromotedMasterResetSlaveAllWaitSeconds = 2
+
+	tests := []struct {
+		name             string
+		healthyAfter     int // number of health checks failing before the topology is healthy; -1 for never
+		resetFails       bool
+		expectedResets   int
+		expectedErr      string
+		expectedDuration time.Duration
+	}{
+		{"healthy at once", 0, false, 1, "", 0},
+		{"healthy at once, reset fails", 0, true, 1, "reset-slave-all promoted failed", 0},
+		{"healthy on second check", 1, false, 1, "", time.Second},
+		{"never healthy", -1, false, 0, "RESET SLAVE ALL not applied", 2 * time.Second},
+	}
+	for _, tt := range tests {
+		checks := 0
+		operations := &fakeTopologyOperations{
+			instances: map[string]*inst.Instance{"promoted": master},
+			replicas: func() ([](*inst.Instance), error) {
+				checks++
+				if tt.healthyAfter >= 0 && checks > tt.healthyAfter {
+					return [](*inst.Instance){running}, nil
+				}
+				return [](*inst.Instance){stopped}, nil
+			},
+		}
+		if tt.resetFails {
+			operations.failedOperation = "reset-slave-all promoted"
+		}
+		subscription := events.Subscribe(100)
+		topologyRecovery := NewTopologyRecovery(inst.ReplicationAnalysis{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "failed", Port: 3306}})
+
+		startTime := time.Now()
+		err := resetSlaveAllOnPromotedMasterWhenHealthy(context.Background(), operations, topologyRecovery, &master.Key)
+		duration := time.Since(startTime)
+		subscription.Close()
+
+		test.S(t).ExpectEquals(len(operations.operations), tt.expectedResets)
+		for _, operation := range operations.operations {
+			test.S(t).ExpectEquals(operation, "reset-slave-all promoted")
+		}
+		if tt.expectedErr == "" {
+			test.S(t).ExpectNil(err)
+		} else {
+			test.S(t).ExpectNotNil(err)
+			test.S(t).ExpectTrue(strings.Contains(err.Error(), tt.expectedErr))
+		}
+		test.S(t).ExpectTrue(duration >= tt.expectedDuration)
+		test.S(t).ExpectTrue(duration < tt.expectedDuration+time.Second)
+
+		messages := []string{}
+		for len(subscription.Events) > 0 {
+			if event := <-subscription.Events; event.Type == "recovery-step" {
+				messages = append(messages, event.Message)
+			}
+		}
+		test.S(t).ExpectEquals(len(messages), 1)
+		if tt.expectedResets > 0 {
+			test.S(t).ExpectTrue(strings.Contains(messages[0], "topology healthy; applying RESET SLAVE ALL"))
+		} else {
+			test.S(t).ExpectTrue(strings.Contains(messages[0], "stopped:3306 is not replicating"))
+		}
+	}
+}
+
+func TestResetSlaveAllOnPromotedMasterWhenHealthyCanceled(t *testing.T) {
+	master := newTestInstance("promoted", 3306)
+	stopped := newTestReplica("stopped", master.Key, false)
+	operations := &fakeTopologyOperations{
+		instances: map[string]*inst.Instance{"promoted": master},
+		replicas: func() ([](*inst.Instance), error) {
+			return [](*inst.Instance){stopped}, nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := resetSlaveAllOnPromotedMasterWhenHealthy(ctx, operations, nil, &master.Key)
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectEquals(len(operations.operations), 0)
+}
+
+func newTestGTIDInstance(hostname string, executedGtidSet string) *inst.Instance {
+	instance := newTestInstance(hostname, 3306)
+	instance.SupportsOracleGTID = true
+	instance.ExecutedGtidSet = executedGtidSet
+	return instance
+}
+
+// verificationOperations has transaction verification read given instances; others are unreachable
+func verificationOperations(instances ...*inst.Instance) *fakeTopologyOperations {
+	operations := &fakeTopologyOperations{instances: map[string]*inst.Instance{}}
+	for _, instance := range instances {
+		operations.instances[instance.Key.Hostname] = instance
+	}
+	return operations
+}
+
+func TestVerifyNoMissingTransactionsOf(t *testing.T) {
+	designated := newTestGTIDInstance("designated", "00020192-1111-1111-1111-111111111111:1-100")
+	behind := newTestGTIDInstance("behind", "00020192-1111-1111-1111-111111111111:1-90")
+	ahead := newTestGTIDInstance("ahead", "00020192-1111-1111-1111-111111111111:1-105")
+	unreachableKey := inst.InstanceKey{Hostname: "unreachable", Port: 3306}
+
+	operations := verificationOperations(designated, behind, ahead)
+
+	tests := []struct {
+		name                string
+		replicaKeys         []inst.InstanceKey
+		ignoreUnreachable   bool
+		expectedVerified    int
+		expectedMissing     []string
+		expectedErrContains string
+	}{
+		{"all verified", []inst.InstanceKey{designated.Key, behind.Key}, false, 1, []string{}, ""},
+		{"sibling ahead", []inst.InstanceKey{behind.Key, ahead.Key}, false, 2, []string{"ahead"}, "executed 00020192-1111-1111-1111-111111111111:101-105"},
+		{"unreachable sibling", []inst.InstanceKey{behind.Key, unreachableKey}, false, 1, []string{"unreachable"}, "unreachable:3306 cannot be verified: unreachable"},
+		{"unreachable sibling, ignored", []inst.InstanceKey{behind.Key, unreachableKey}, true, 1, []string{}, ""},
+		{"unreachable ignored, sibling ahead", []inst.InstanceKey{ahead.Key, unreachableKey}, true, 1, []string{"ahead"}, "ahead:3306 executed"},
+	}
+	for _, tt := range tests {
+		verifiedKeys, missingTransactions, err := verifyNoMissingTransactionsOf(operations, designated, tt.replicaKeys, tt.ignoreUnreachable)
+		test.S(t).ExpectEquals(len(verifiedKeys), tt.expectedVerified)
+		test.S(t).ExpectEquals(len(missingTransactions), len(tt.expectedMissing))
+		for i, hostname := range tt.expectedMissing {
+			if i < len(missingTransactions) {
+				test.S(t).ExpectEquals(missingTransactions[i].Key.Hostname, hostname)
+			}
+		}
+		if tt.expectedErrContains == "" {
+			test.S(t).ExpectNil(err)
+		} else {
+			test.S(t).ExpectNotNil(err)
+			test.S(t).ExpectTrue(strings.Contains(err.Error(), tt.expectedErrContains))
+		}
+	}
+}
+
+func TestReverifyPromotedReplicaTransactions(t *testing.T) {
+	promotedKey := inst.InstanceKey{Hostname: "designated", Port: 3306}
+	siblingKey := inst.InstanceKey{Hostname: "sibling", Port: 3306}
+
+	tests := []struct {
+		name                string
+		instances           [](*inst.Instance)
+		expectedErrContains string
+	}{
+		{
+			name: "still verified",
+			instances: [](*inst.Instance){
+				newTestGTIDInstance("designated", "00020192-1111-1111-1111-111111111111:1-100"),
+				newTestGTIDInstance("sibling", "00020192-1111-1111-1111-111111111111:1-100"),
+			},
+		},
+		{
+			name: "sibling applied more before replication stopped",
+			instances: [](*inst.Instance){
+				newTestGTIDInstance("designated", "00020192-1111-1111-1111-111111111111:1-100"),
+				newTestGTIDInstance("sibling", "00020192-1111-1111-1111-111111111111:1-102"),
+			},
+			expectedErrContains: "executed 00020192-1111-1111-1111-111111111111:101-102",
+		},
+		{
+			name: "sibling no longer reachable",
+			instances: [](*inst.Instance){
+				newTestGTIDInstance("designated", "00020192-1111-1111-1111-111111111111:1-100"),
+			},
+			expectedErrContains: "sibling:3306 cannot be verified",
+		},
+		{
+			name: "promoted replica unreachable",
+			instances: [](*inst.Instance){
+				newTestGTIDInstance("sibling", "00020192-1111-1111-1111-111111111111:1-100"),
+			},
+			expectedErrContains: "cannot connect",
+		},
+	}
+	for _, tt := range tests {
+		analysisEntry := inst.ReplicationAnalysis{
+			AnalyzedInstanceKey:         inst.InstanceKey{Hostname: "master", Port: 3306},
+			CommandHint:                 inst.ForceMasterFailoverToCommandHint,
+			TransactionVerifiedReplicas: *inst.NewInstanceKeyMap(),
+		}
+		analysisEntry.TransactionVerifiedReplicas.AddKeys([]inst.InstanceKey{siblingKey})
+		topologyRecovery := NewTopologyRecovery(analysisEntry)
+		err := reverifyPromotedReplicaTransactions(verificationOperations(tt.instances...), topologyRecovery, newTestInstance(promotedKey.Hostname, promotedKey.Port))
+		if tt.expectedErrContains == "" {
+			test.S(t).ExpectNil(err)
+		} else {
+			test.S(t).ExpectNotNil(err)
+			test.S(t).ExpectTrue(strings.Contains(err.Error(), tt.expectedErrContains))
+		}
+	}
+}
+
+func TestDemoteGracefulTakeoverMaster(t *testing.T) {
+	demotedKey := inst.InstanceKey{Hostname: "demoted", Port: 3306}
+	promotedKey := inst.InstanceKey{Hostname: "promoted", Port: 3306}
+	semiSyncOperations := []string{
+		"super-read-only=true demoted",
+		"semi-sync-master=false demoted",
+		"semi-sync-replica=true demoted",
+		"start-replication demoted",
+		"semi-sync-master=true promoted",
+		"semi-sync-replica=false promoted",
+	}
+
+	tests := []struct {
+		name                       string
+		demotedWasSemiSyncMaster   bool
+		promotedWasSemiSyncReplica bool
+		failedOperation            string
+		expectedOperations         []string
+		expectedErr                bool
+		expectedStep               string
+	}{
+		{
+			name:               "no semi-sync",
+			expectedOperations: []string{"super-read-only=true demoted", "start-replication demoted"},
+			expectedStep:       "replication started",
+		},
+		{
+			name:                       "semi-sync",
+			demotedWasSemiSyncMaster:   true,
+			promotedWasSemiSyncReplica: true,
+			expectedOperations:         semiSyncOperations,
+			expectedStep:               "semi-sync replica disabled on promoted master",
+		},
+		{
+			name:                     "semi-sync master only",
+			demotedWasSemiSyncMaster: true,
+			expectedOperations:       []string{"super-read-only=true demoted", "semi-sync-master=false demoted", "start-replication demoted", "semi-sync-master=true promoted"},
+			expectedStep:             "semi-sync master enabled on promoted master",
+		},
+		{
+			name:                       "semi-sync replica only",
+			promotedWasSemiSyncReplica: true,
+			expectedOperations:         []string{"super-read-only=true demoted", "semi-sync-replica=true demoted", "start-replication demoted", "semi-sync-replica=false promoted"},
+			expectedStep:               "semi-sync replica disabled on promoted master",
+		},
+		{
+			name:                       "super_read_only not supported",
+			demotedWasSemiSyncMaster:   true,
+			promotedWasSemiSyncReplica: true,
+			failedOperation:            "super-read-only=true demoted",
+			expectedOperations:         semiSyncOperations,
+			expectedStep:               "super_read_only not set",
+		},
+		{
+			name:                       "disabling semi-sync master on demoted master fails",
+			demotedWasSemiSyncMaster:   true,
+			promotedWasSemiSyncReplica: true,
+			failedOperation:            "semi-sync-master=false demoted",
+			expectedOperations:         semiSyncOperations[:2],
+			expectedErr:                true,
+		},
+		{
+			name:                       "enabling semi-sync replica on demoted master fails",
+			demotedWasSemiSyncMaster:   true,
+			promotedWasSemiSyncReplica: true,
+			failedOperation:            "semi-sync-replica=true demoted",
+			expectedOperations:         semiSyncOperations[:3],
+			expectedErr:                true,
+		},
+		{
+			name:                       "starting replication fails",
+			demotedWasSemiSyncMaster:   true,
+			promotedWasSemiSyncReplica: true,
+			failedOperation:            "start-replication demoted",
+			expectedOperations:         semiSyncOperations[:4],
+			expectedErr:                true,
+		},
+		{
+			name:                       "enabling semi-sync master on promoted master fails",
+			demotedWasSemiSyncMaster:   true,
+			promotedWasSemiSyncReplica: true,
+			failedOperation:            "semi-sync-master=true promoted",
+			expectedOperations:         semiSyncOperations[:5],
+			expectedErr:                true,
+		},
+		{
+			name:                       "disabling semi-sync replica on promoted master fails",
+			demotedWasSemiSyncMaster:   true,
+			promotedWasSemiSyncReplica: true,
+			failedOperation:            "semi-sync-replica=false promoted",
+			expectedOperations:         semiSyncOperations,
+			expectedErr:                true,
+		},
+	}
+	for _, tt := range tests {
+		fakeOperations := &fakeTopologyOperations{failedOperation: tt.failedOperation}
+		subscription := events.Subscribe(100)
+		topologyRecovery := NewTopologyRecovery(inst.ReplicationAnalysis{AnalyzedInstanceKey: demotedKey})
+
+		err := demoteGracefulTakeoverMaster(fakeOperations, topologyRecovery, &demotedKey, &promotedKey, tt.demotedWasSemiSyncMaster, tt.promotedWasSemiSyncReplica)
+		subscription.Close()
+		operations := fakeOperations.operations
+
+		test.S(t).ExpectEquals(len(operations), len(tt.expectedOperations))
+		for i := range tt.expectedOperations {
+			if i < len(operations) {
+				test.S(t).ExpectEquals(operations[i], tt.expectedOperations[i])
+			}
+		}
+		messages := []string{}
+		for len(subscription.Events) > 0 {
+			if event := <-subscription.Events; event.Type == "recovery-step" {
+				messages = append(messages, event.Message)
+			}
+		}
+		test.S(t).ExpectEquals(len(messages), 1)
+		if tt.expectedErr {
+			test.S(t).ExpectNotNil(err)
+			test.S(t).ExpectTrue(strings.HasSuffix(messages[0], fmt.Sprintf("failed: %s failed", tt.failedOperation)))
+		} else {
+			test.S(t).ExpectNil(err)
+			test.S(t).ExpectTrue(strings.Contains(messages[0], tt.expectedStep))
+		}
+	}
+}
+
+func TestRecheckPromotionPolicy(t *testing.T) {
+	// The policy denies promoting "denied" in any recovery, and promoting anything in a co-master recovery
+	queried := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Input inst.OperationPolicyInput
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		queried = append(queried, fmt.Sprintf("%s %s", request.Input.Operation, request.Input.InstanceKey.Hostname))
+		if request.Input.Operation == "promote-co-master" || request.Input.InstanceKey.Hostname == "denied" {
+			fmt.Fprintf(w, `{"result": {"allow": false, "reason": "%s is protected"}}`, request.Input.InstanceKey.Hostname)
+			return
+		}
+		fmt.Fprintf(w, `{"result": {"allow": true}}`)
+	}))
+	defer server.Close()
+	defer func(url string) { config.Config.OperationPolicyURL = url }(config.Config.OperationPolicyURL)
+
+	checkedKey := inst.InstanceKey{Hostname: "checked", Port: 3306}
+	tests := []struct {
+		name            string
+		policyURL       string
+		recoveryType    RecoveryType
+		promoted        string
+		expectedQueried []string
+		expectedErr     string
+	}{
+		{"no policy", "", MasterRecovery, "denied", []string{}, ""},
+		{"promoted as checked", server.URL, MasterRecovery, "checked", []string{}, ""},
+		{"other promoted, allowed", server.URL, MasterRecovery, "allowed", []string{"promote-master allowed"}, ""},
+		{"other promoted, denied", server.URL, MasterRecovery, "denied", []string{"promote-master denied"}, "denied is protected"},
+		{"co-master promoted as checked", server.URL, CoMasterRecovery, "checked", []string{}, ""},
+		{"other co-master promoted, policy denies co-masters", server.URL, CoMasterRecovery, "allowed", []string{"promote-co-master allowed"}, "allowed is protected"},
+	}
+	for _, tt := range tests {
+		queried = []string{}
+		config.Config.OperationPolicyURL = tt.policyURL
+		topologyRecovery := NewTopologyRecovery(inst.ReplicationAnalysis{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "failed", Port: 3306}})
+		topologyRecovery.Type = tt.recoveryType
+
+		err := recheckPromotionPolicy(topologyRecovery, newTestInstance(tt.promoted, 3306), &checkedKey)
+		test.S(t).ExpectEquals(len(queried), len(tt.expectedQueried))
+		for i := range tt.expectedQueried {
+			if i < len(queried) {
+				test.S(t).ExpectEquals(queried[i], tt.expectedQueried[i])
+			}
+		}
+		if tt.expectedErr == "" {
+			test.S(t).ExpectNil(err)
+		} else {
+			test.S(t).ExpectNotNil(err)
+			test.S(t).ExpectTrue(strings.Contains(err.Error(), tt.expectedErr))
+		}
+	}
+}
@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// publishClusterWriteFreeze persists given write freeze; with raft, this is replicated to all raft members
+func publishClusterWriteFreeze(freeze *inst.ClusterWriteFreeze) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-cluster-write-freeze", freeze)
+	} else {
+		err = inst.WriteClusterWriteFreeze(freeze)
+	}
+	return err
+}
+
+// unpublishClusterWriteFreeze removes the write freeze of given cluster
+func unpublishClusterWriteFreeze(clusterName string) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-cluster-write-freeze", clusterName)
+	} else {
+		err = inst.DeleteClusterWriteFreeze(clusterName)
+	}
+	return err
+}
+
+// FreezeClusterWrites sets the writeable instances of a cluster (normally, its master) read_only, and records the
+// cluster's write freeze, by which automated failovers are refused until the freeze expires. The freeze is recorded
+// before any instance is set read_only, such that no failover restores writability meanwhile. Should any instance
+// fail to be set read_only, those already set are made writeable again and the freeze is removed: either all writes
+// are frozen, or none.
+func FreezeClusterWrites(freeze *inst.ClusterWriteFreeze) (*inst.ClusterWriteFreeze, error) {
+	if err := freeze.Validate(); err != nil {
+		return freeze, err
+	}
+	instances, err := inst.ReadClusterInstances(freeze.ClusterName)
+	if err != nil {
+		return freeze, err
+	}
+	writeable := inst.WriteableInstances(instances)
+	if len(writeable) == 0 {
+		log.Infof("FreezeClusterWrites: no writeable instances in %s", freeze.ClusterName)
+	}
+	freeze.FrozenInstances.AddInstances(writeable)
+	if err := publishClusterWriteFreeze(freeze); err != nil {
+		return freeze, err
+	}
+
+	frozen := [](*inst.Instance){}
+	for _, instance := range writeable {
+		if _, err := inst.SetReadOnly(&instance.Key, true); err != nil {
+			log.Errorf("FreezeClusterWrites: failed setting %+v read_only; reverting write freeze of %s", instance.Key, freeze.ClusterName)
+			for _, frozenInstance := range frozen {
+				if _, rerr := inst.SetReadOnly(&frozenInstance.Key, false); rerr != nil {
+					log.Errore(rerr)
+				}
+			}
+			if uerr := unpublishClusterWriteFreeze(freeze.ClusterName); uerr != nil {
+				log.Errore(uerr)
+			}
+			return freeze, fmt.Errorf("FreezeClusterWrites: cannot set %+v read_only: %+v", instance.Key, err)
+		}
+		frozen = append(frozen, instance)
+	}
+	return freeze, nil
+}
+
+// UnfreezeClusterWrites ends the write freeze of a cluster, allowing automated failovers again. Frozen instances
+// remain read_only; see set-writeable.
+func UnfreezeClusterWrites(clusterName string) error {
+	return unpublishClusterWriteFreeze(clusterName)
+}
+
+// isRecoveryRefusedByWriteFreeze returns true when given automated recovery would restore writability on a cluster
+// whose writes are frozen. Failing to read the freeze does not refuse the recovery.
+func isRecoveryRefusedByWriteFreeze(analysisEntry *inst.ReplicationAnalysis, forceInstanceRecovery bool) bool {
+	if forceInstanceRecovery {
+		return false
+	}
+	frozen, err := inst.IsClusterWriteFrozen(analysisEntry.ClusterDetails.ClusterName)
+	if err != nil {
+		log.Errore(err)
+		return false
+	}
+	if frozen {
+		inst.AuditOperation("write-freeze-refused-recovery", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("%+v on %s: writes are frozen; not recovering automatically", analysisEntry.Analysis, analysisEntry.ClusterDetails.ClusterName))
+	}
+	return frozen
+}
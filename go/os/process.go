@@ -17,6 +17,7 @@
 package os
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -35,10 +36,15 @@ var EmptyEnv = []string{}
 // command to a temporary file and then ask the shell to execute
 // it, after which the temporary file is removed.
 func CommandRun(commandText string, env []string, arguments ...string) error {
+	return CommandRunContext(context.Background(), commandText, env, arguments...)
+}
+
+// CommandRunContext is as CommandRun, and kills the shell running the command when given context is done.
+func CommandRunContext(ctx context.Context, commandText string, env []string, arguments ...string) error {
 	// show the actual command we have been asked to run
 	log.Infof("CommandRun(%v,%+v)", commandText, arguments)
 
-	cmd, shellScript, err := generateShellScript(commandText, env, arguments...)
+	cmd, shellScript, err := generateShellScriptContext(ctx, commandText, env, arguments...)
 	defer os.Remove(shellScript)
 	if err != nil {
 		return log.Errore(err)
@@ -66,11 +72,33 @@ func CommandRun(commandText string, env []string, arguments ...string) error {
 	return nil
 }
 
-// generateShellScript generates a temporary shell script based on
+// CommandOutput executes some text as a command, as CommandRun does, and returns its standard output.
+func CommandOutput(commandText string, env []string, arguments ...string) ([]byte, error) {
+	return CommandOutputContext(context.Background(), commandText, env, arguments...)
+}
+
+// CommandOutputContext is as CommandOutput, and kills the shell running the command when given context is done.
+// Processes the shell spawned are not killed, and may hold on to its output until they complete.
+func CommandOutputContext(ctx context.Context, commandText string, env []string, arguments ...string) ([]byte, error) {
+	cmd, shellScript, err := generateShellScriptContext(ctx, commandText, env, arguments...)
+	defer os.Remove(shellScript)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+
+	log.Debugf("CommandOutput/running: %s", strings.Join(cmd.Args, " "))
+	cmdOutput, err := cmd.Output()
+	if err != nil {
+		return cmdOutput, log.Errore(fmt.Errorf("(%s) %s", err.Error(), cmdOutput))
+	}
+	return cmdOutput, nil
+}
+
+// generateShellScriptContext generates a temporary shell script based on
 // the given command to be executed, writes the command to a temporary
 // file and returns the exec.Command which can be executed together
-// with the script name that was created.
-func generateShellScript(commandText string, env []string, arguments ...string) (*exec.Cmd, string, error) {
+// with the script name that was created. The command is killed when given context is done.
+func generateShellScriptContext(ctx context.Context, commandText string, env []string, arguments ...string) (*exec.Cmd, string, error) {
 	shell := config.Config.ProcessesShellCommand
 
 	commandBytes := []byte(commandText)
@@ -83,7 +111,7 @@ func generateShellScript(commandText string, env []string, arguments ...string)
 	shellArguments := append([]string{}, tmpFile.Name())
 	shellArguments = append(shellArguments, arguments...)
 
-	cmd := exec.Command(shell, shellArguments...)
+	cmd := exec.CommandContext(ctx, shell, shellArguments...)
 	cmd.Env = env
 
 	return cmd, tmpFile.Name(), nil
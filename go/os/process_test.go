@@ -1,8 +1,10 @@
 package os
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestCommandRun(t *testing.T) {
@@ -16,3 +18,21 @@ func TestCommandRun(t *testing.T) {
 		t.Errorf(fmt.Sprintf("Expected CommandRun to return an Error '%s' but got '%s'", expectedMsg, cmdErr.Error()))
 	}
 }
+
+func TestCommandOutput(t *testing.T) {
+	output, err := CommandOutput("echo \"VAR1=$VAR1 $1\"", []string{"VAR1=a"}, "arg1")
+	if err != nil {
+		t.Errorf("Expected CommandOutput to succeed, but got %+v", err)
+	}
+	if string(output) != "VAR1=a arg1\n" {
+		t.Errorf("Expected CommandOutput to return 'VAR1=a arg1', but got '%s'", string(output))
+	}
+}
+
+func TestCommandOutputContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := CommandOutputContext(ctx, "exec sleep 5", []string{}); err == nil {
+		t.Error("Expected CommandOutputContext to fail on timeout, but no error returned")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
 	"github.com/github/orchestrator/go/util"
 
 	"github.com/github/orchestrator/go/raft"
@@ -83,6 +84,7 @@ type HealthStatus struct {
 	RaftLeaderURI      string
 	RaftAdvertise      string
 	RaftHealthyMembers []string
+	BackendHealth      db.BackendHealth
 }
 
 type OrchestratorExecutionMode string
@@ -136,6 +138,7 @@ func HealthTest() (health *HealthStatus, err error) {
 		}
 	}
 	health.AvailableNodes, err = ReadAvailableNodes(true)
+	health.BackendHealth = db.GetBackendHealth()
 
 	return health, nil
 }
@@ -0,0 +1,135 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package process
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// IdempotentResponse is the persisted response to an API request carrying an idempotency key, by which the request
+// is replayed rather than re-executed, be it on another node or after a restart. Only the digest of the response's
+// body is persisted. An entry with InProgress set is a placeholder for a request still being executed.
+type IdempotentResponse struct {
+	RequestHash     string
+	InProgress      bool
+	Status          int
+	Header          map[string][]string
+	BodyDigest      string
+	ExpiresUnixtime int64
+}
+
+// ClaimIdempotentRequest records a placeholder for given request, unless an unexpired entry exists. It returns
+// true when the placeholder was recorded, i.e. the request is to be executed.
+func ClaimIdempotentRequest(requestHash string, expiresUnixtime int64) (claimed bool, err error) {
+	if _, err := db.ExecOrchestrator(`
+			delete from api_idempotent_response where request_hash = ? and expires_unixtime < ?
+		`, requestHash, time.Now().Unix(),
+	); err != nil {
+		return false, log.Errore(err)
+	}
+	sqlResult, err := db.ExecOrchestrator(`
+			insert ignore into api_idempotent_response (
+				request_hash, in_progress, status, header, body, expires_unixtime
+			) values (
+				?, 1, 0, '', '', ?
+			)
+		`, requestHash, expiresUnixtime,
+	)
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	rows, err := sqlResult.RowsAffected()
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	return rows > 0, nil
+}
+
+// WriteIdempotentResponse persists given response, or placeholder
+func WriteIdempotentResponse(response *IdempotentResponse) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return log.Errore(err)
+	}
+	_, err = db.ExecOrchestrator(`
+			replace into api_idempotent_response (
+				request_hash, in_progress, status, header, body, body_digest, expires_unixtime
+			) values (
+				?, ?, ?, ?, '', ?, ?
+			)
+		`, response.RequestHash, response.InProgress, response.Status, string(header), response.BodyDigest, response.ExpiresUnixtime,
+	)
+	return log.Errore(err)
+}
+
+// ReadIdempotentResponse reads the unexpired response, or placeholder, of given request. It returns nil when there
+// is none.
+func ReadIdempotentResponse(requestHash string) (response *IdempotentResponse, err error) {
+	query := `
+		select
+			request_hash,
+			in_progress,
+			status,
+			header,
+			body_digest,
+			expires_unixtime
+		from
+			api_idempotent_response
+		where
+			request_hash = ?
+			and expires_unixtime >= ?
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(requestHash, time.Now().Unix()), func(m sqlutils.RowMap) error {
+		response = &IdempotentResponse{
+			RequestHash:     m.GetString("request_hash"),
+			InProgress:      m.GetBool("in_progress"),
+			Status:          m.GetInt("status"),
+			BodyDigest:      m.GetString("body_digest"),
+			ExpiresUnixtime: m.GetInt64("expires_unixtime"),
+		}
+		if header := m.GetString("header"); header != "" {
+			if err := json.Unmarshal([]byte(header), &response.Header); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return response, log.Errore(err)
+}
+
+// DeleteIdempotentResponse forgets the response, or placeholder, of given request
+func DeleteIdempotentResponse(requestHash string) error {
+	_, err := db.ExecOrchestrator(`
+			delete from api_idempotent_response where request_hash = ?
+		`, requestHash,
+	)
+	return log.Errore(err)
+}
+
+// ExpireIdempotentResponses removes responses past their expiry
+func ExpireIdempotentResponses() error {
+	_, err := db.ExecOrchestrator(`
+			delete from api_idempotent_response where expires_unixtime < ?
+		`, time.Now().Unix(),
+	)
+	return log.Errore(err)
+}
@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package process
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// VersionSkew describes the versions run by the orchestrator nodes sharing this node's backend or raft group
+type VersionSkew struct {
+	ThisVersion string
+	Versions    map[string][]string
+	Skewed      bool
+}
+
+var lastVersionSkew string
+var lastVersionSkewMutex sync.Mutex
+
+// newVersionSkew evaluates given mapping of node to version. Nodes which do not report a version are ignored.
+func newVersionSkew(thisVersion string, nodeVersions map[string]string) *VersionSkew {
+	skew := &VersionSkew{ThisVersion: thisVersion, Versions: make(map[string][]string)}
+	for node, appVersion := range nodeVersions {
+		if appVersion == "" {
+			continue
+		}
+		skew.Versions[appVersion] = append(skew.Versions[appVersion], node)
+	}
+	for _, nodes := range skew.Versions {
+		sort.Strings(nodes)
+	}
+	skew.Skewed = len(skew.Versions) > 1
+	return skew
+}
+
+// String returns a human readable description of the versions, e.g. "3.2.3: [node1 node2], 3.2.4: [node3]"
+func (this *VersionSkew) String() string {
+	appVersions := []string{}
+	for appVersion := range this.Versions {
+		appVersions = append(appVersions, appVersion)
+	}
+	sort.Strings(appVersions)
+	descriptions := []string{}
+	for _, appVersion := range appVersions {
+		descriptions = append(descriptions, appVersion+": ["+strings.Join(this.Versions[appVersion], " ")+"]")
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// ReadVersionSkew reads the versions of all healthy orchestrator nodes. With raft, these are the versions
+// reported by raft members to the leader, and the result is only meaningful on the leader. Otherwise, these
+// are the versions of the nodes registered in the shared backend.
+func ReadVersionSkew() (*VersionSkew, error) {
+	nodeVersions := make(map[string]string)
+	if orcraft.IsRaftEnabled() {
+		nodeVersions = orcraft.HealthyMemberVersions()
+	} else {
+		nodes, err := ReadAvailableNodes(false)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			nodeVersions[node.Hostname] = node.AppVersion
+		}
+	}
+	return newVersionSkew(config.RuntimeCLIFlags.ConfiguredVersion, nodeVersions), nil
+}
+
+// CheckVersionSkew is called periodically. It logs a warning whenever nodes are found to run different
+// versions, and when versions converge.
+func CheckVersionSkew() {
+	skew, err := ReadVersionSkew()
+	if err != nil {
+		log.Errore(err)
+		return
+	}
+	description := ""
+	if skew.Skewed {
+		description = skew.String()
+	}
+	lastVersionSkewMutex.Lock()
+	defer lastVersionSkewMutex.Unlock()
+	if description == lastVersionSkew {
+		return
+	}
+	if skew.Skewed {
+		log.Warningf("Version skew: orchestrator nodes run different versions: %s", description)
+	} else {
+		log.Infof("Version skew resolved: all orchestrator nodes run %s", skew.String())
+	}
+	lastVersionSkew = description
+}
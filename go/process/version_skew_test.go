@@ -0,0 +1,26 @@
+package process
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewVersionSkew(t *testing.T) {
+	{
+		skew := newVersionSkew("3.2.3", map[string]string{"node1": "3.2.3", "node2": "3.2.3", "node3": ""})
+		test.S(t).ExpectFalse(skew.Skewed)
+		test.S(t).ExpectEquals(len(skew.Versions), 1)
+		test.S(t).ExpectEquals(skew.String(), "3.2.3: [node1 node2]")
+	}
+	{
+		skew := newVersionSkew("3.2.3", map[string]string{"node3": "3.2.4", "node1": "3.2.3", "node2": "3.2.4"})
+		test.S(t).ExpectTrue(skew.Skewed)
+		test.S(t).ExpectEquals(len(skew.Versions), 2)
+		test.S(t).ExpectEquals(skew.String(), "3.2.3: [node1], 3.2.4: [node2 node3]")
+	}
+	{
+		skew := newVersionSkew("3.2.3", map[string]string{})
+		test.S(t).ExpectFalse(skew.Skewed)
+	}
+}
@@ -52,13 +52,21 @@ func HttpGetLeader(path string) (response []byte, err error) {
 	if leaderURI == "" {
 		return nil, fmt.Errorf("Raft leader URI unknown")
 	}
-	leaderAPI := leaderURI
+	return HttpGetMember(leaderURI, path)
+}
+
+// HttpGetMember requests given API path of the raft member of given HTTP URI, as reported by HealthyMemberURIs
+func HttpGetMember(memberURI string, path string) (response []byte, err error) {
+	if httpClient == nil {
+		return nil, RaftNotRunning
+	}
+	memberAPI := memberURI
 	if config.Config.URLPrefix != "" {
-		leaderAPI = fmt.Sprintf("%s/%s", leaderAPI, config.Config.URLPrefix)
+		memberAPI = fmt.Sprintf("%s/%s", memberAPI, config.Config.URLPrefix)
 	}
-	leaderAPI = fmt.Sprintf("%s/api", leaderAPI)
+	memberAPI = fmt.Sprintf("%s/api", memberAPI)
 
-	url := fmt.Sprintf("%s/%s", leaderAPI, path)
+	url := fmt.Sprintf("%s/%s", memberAPI, path)
 
 	req, err := http.NewRequest("GET", url, nil)
 	switch strings.ToLower(config.Config.AuthenticationMethod) {
@@ -78,7 +86,7 @@ func HttpGetLeader(path string) (response []byte, err error) {
 	}
 
 	if res.StatusCode != http.StatusOK {
-		return body, log.Errorf("HttpGetLeader: got %d status on %s", res.StatusCode, url)
+		return body, log.Errorf("HttpGetMember: got %d status on %s", res.StatusCode, url)
 	}
 
 	return body, nil
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"net/url"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -40,10 +41,11 @@ const (
 )
 
 const (
-	retainSnapshotCount    = 10
-	snapshotInterval       = 30 * time.Minute
-	asyncSnapshotTimeframe = 1 * time.Minute
-	raftTimeout            = 10 * time.Second
+	retainSnapshotCount      = 10
+	snapshotInterval         = 30 * time.Minute
+	asyncSnapshotTimeframe   = 1 * time.Minute
+	raftTimeout              = 10 * time.Second
+	appliedIndexPollInterval = 10 * time.Millisecond
 )
 
 var RaftNotRunning = fmt.Errorf("raft is not configured/running")
@@ -51,11 +53,17 @@ var store *Store
 var raftSetupComplete int64
 var ThisHostname string
 var healthRequestAuthenticationTokenCache = cache.New(config.RaftHealthPollSeconds*2*time.Second, time.Second)
-var healthReportsCache = cache.New(config.RaftHealthPollSeconds*2*time.Second, time.Second)
+var healthReportsCache = cache.New(config.RaftHealthPollSeconds*2*time.Second, time.Second) // *healthReport by raft advertised address
 var healthRequestReportCache = cache.New(time.Second, time.Second)
 
 var fatalRaftErrorChan = make(chan error)
 
+// healthReport is what a raft member reports of itself to the leader
+type healthReport struct {
+	appVersion string
+	httpURI    string
+}
+
 type leaderURI struct {
 	uri string
 	sync.Mutex
@@ -295,6 +303,27 @@ func IsPeer(peer string) (bool, error) {
 	return (store.raftBind == peer), nil
 }
 
+// AppliedIndex returns the index of the latest raft log entry applied by this node, or 0 when raft is not running
+func AppliedIndex() uint64 {
+	if !IsRaftEnabled() || !isRaftSetupComplete() {
+		return 0
+	}
+	return getRaft().AppliedIndex()
+}
+
+// WaitForAppliedIndex waits up to given timeout for this node to apply the raft log up to given index.
+// It returns true when the node has applied the index.
+func WaitForAppliedIndex(index uint64, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for AppliedIndex() < index {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(appliedIndexPollInterval)
+	}
+	return true
+}
+
 // PublishCommand will distribute a command across the group
 func PublishCommand(op string, value interface{}) (response interface{}, err error) {
 	if !IsRaftEnabled() {
@@ -325,17 +354,18 @@ func ReportToRaftLeader(authenticationToken string) (err error) {
 		// Recently reported
 		return nil
 	}
-	path := fmt.Sprintf("raft-follower-health-report/%s/%s/%s", authenticationToken, config.Config.RaftBind, config.Config.RaftAdvertise)
+	path := fmt.Sprintf("raft-follower-health-report/%s/%s/%s?version=%s&uri=%s", authenticationToken, config.Config.RaftBind, config.Config.RaftAdvertise, url.QueryEscape(config.RuntimeCLIFlags.ConfiguredVersion), url.QueryEscape(thisLeaderURI))
 	_, err = HttpGetLeader(path)
 	return err
 }
 
-// OnHealthReport acts on a raft-member reporting its health
-func OnHealthReport(authenticationToken, raftBind, raftAdvertise string) (err error) {
+// OnHealthReport acts on a raft-member reporting its health. appVersion and httpURI are empty when reported by
+// members which predate version and URI reporting, respectively.
+func OnHealthReport(authenticationToken, raftBind, raftAdvertise, appVersion, httpURI string) (err error) {
 	if _, found := healthRequestAuthenticationTokenCache.Get(authenticationToken); !found {
 		return log.Errorf("Raft health report: unknown token %s", authenticationToken)
 	}
-	healthReportsCache.Set(raftAdvertise, true, cache.DefaultExpiration)
+	healthReportsCache.Set(raftAdvertise, &healthReport{appVersion: appVersion, httpURI: httpURI}, cache.DefaultExpiration)
 	return nil
 }
 
@@ -347,6 +377,27 @@ func HealthyMembers() (advertised []string) {
 	return advertised
 }
 
+// HealthyMemberVersions maps healthy members, by advertised address, to their reported versions.
+// This is only populated on the leader.
+func HealthyMemberVersions() map[string]string {
+	versions := make(map[string]string)
+	for raftAdvertised, item := range healthReportsCache.Items() {
+		versions[raftAdvertised] = item.Object.(*healthReport).appVersion
+	}
+	return versions
+}
+
+// HealthyMemberURIs returns the HTTP URIs of healthy members other than this node, as reported by the members
+// themselves. This is only populated on the leader.
+func HealthyMemberURIs() (uris []string) {
+	for _, item := range healthReportsCache.Items() {
+		if httpURI := item.Object.(*healthReport).httpURI; httpURI != "" && httpURI != thisLeaderURI {
+			uris = append(uris, httpURI)
+		}
+	}
+	return uris
+}
+
 // Monitor is a utility function to routinely observe leadership state.
 // It doesn't actually do much; merely takes notes.
 func Monitor() {
@@ -0,0 +1,236 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+
+	"github.com/openark/golib/log"
+)
+
+const exportQueueCapacity = 4096
+const exportBatchSize = 512
+const exportInterval = 5 * time.Second
+const exportTimeout = 10 * time.Second
+
+// exportQueue holds ended spans pending export. When full, spans are dropped rather than blocking the caller.
+var exportQueue = make(chan *Span, exportQueueCapacity)
+var initTracingOnce sync.Once
+
+func exportSpan(span *Span) {
+	select {
+	case exportQueue <- span:
+	default:
+		log.Debugf("tracing: export queue full; dropping span %s", span.Name)
+	}
+}
+
+// InitTracing is called once in the lifetime of the app, after config has been loaded. It starts the
+// background OTLP exporter when TracingOTLPEndpoint is configured.
+func InitTracing() {
+	if !IsEnabled() {
+		return
+	}
+	initTracingOnce.Do(func() {
+		endpoint := strings.TrimSuffix(config.Config.TracingOTLPEndpoint, "/") + "/v1/traces"
+		log.Debugf("Will export traces via OTLP to %s", endpoint)
+		go exportSpans(endpoint)
+	})
+}
+
+func exportSpans(endpoint string) {
+	client := &http.Client{Timeout: exportTimeout}
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	batch := []*Span{}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := postSpans(client, endpoint, batch); err != nil {
+			log.Errorf("tracing: failed exporting %d spans: %+v", len(batch), err)
+		}
+		batch = []*Span{}
+	}
+	for {
+		select {
+		case span := <-exportQueue:
+			batch = append(batch, span)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func postSpans(client *http.Client, endpoint string, spans []*Span) error {
+	body, err := json.Marshal(toOTLPRequest(spans))
+	if err != nil {
+		return err
+	}
+	response, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("OTLP endpoint %s returned %s", endpoint, response.Status)
+	}
+	return nil
+}
+
+// The following types depict the OTLP/HTTP JSON encoding of an ExportTraceServiceRequest
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string `json:"timeUnixNano"`
+	Name         string `json:"name"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Events            []otlpEvent     `json:"events,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func toOTLPValue(value interface{}) (result otlpValue) {
+	switch value := value.(type) {
+	case string:
+		result.StringValue = &value
+	case bool:
+		result.BoolValue = &value
+	case int:
+		s := strconv.FormatInt(int64(value), 10)
+		result.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		result.IntValue = &s
+	case uint:
+		s := strconv.FormatUint(uint64(value), 10)
+		result.IntValue = &s
+	case float64:
+		result.DoubleValue = &value
+	default:
+		s := fmt.Sprintf("%+v", value)
+		result.StringValue = &s
+	}
+	return result
+}
+
+func toOTLPAttributes(attributes map[string]interface{}) (result []otlpAttribute) {
+	for key, value := range attributes {
+		result = append(result, otlpAttribute{Key: key, Value: toOTLPValue(value)})
+	}
+	return result
+}
+
+func unixNanoString(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func toOTLPSpan(span *Span) otlpSpan {
+	result := otlpSpan{
+		TraceID:           hex.EncodeToString(span.TraceID[:]),
+		SpanID:            hex.EncodeToString(span.SpanID[:]),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: unixNanoString(span.StartTime),
+		EndTimeUnixNano:   unixNanoString(span.EndTime),
+		Attributes:        toOTLPAttributes(span.Attributes),
+	}
+	if span.ParentSpanID != [8]byte{} {
+		result.ParentSpanID = hex.EncodeToString(span.ParentSpanID[:])
+	}
+	for _, event := range span.Events {
+		result.Events = append(result.Events, otlpEvent{TimeUnixNano: unixNanoString(event.Time), Name: event.Name})
+	}
+	if span.ErrorMessage != "" {
+		// STATUS_CODE_ERROR
+		result.Status = otlpStatus{Code: 2, Message: span.ErrorMessage}
+	}
+	return result
+}
+
+func toOTLPRequest(spans []*Span) *otlpRequest {
+	hostname, _ := os.Hostname()
+	scopeSpans := otlpScopeSpans{}
+	scopeSpans.Scope.Name = "github.com/github/orchestrator"
+	for _, span := range spans {
+		scopeSpans.Spans = append(scopeSpans.Spans, toOTLPSpan(span))
+	}
+	resourceSpans := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{scopeSpans}}
+	resourceSpans.Resource.Attributes = toOTLPAttributes(map[string]interface{}{
+		"service.name":        config.Config.TracingServiceName,
+		"service.version":     config.RuntimeCLIFlags.ConfiguredVersion,
+		"service.instance.id": hostname,
+	})
+	return &otlpRequest{ResourceSpans: []otlpResourceSpans{resourceSpans}}
+}
@@ -0,0 +1,288 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// TraceparentHeader is the W3C trace context header by which traces are propagated across HTTP requests
+const TraceparentHeader = "traceparent"
+
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// SpanEvent is a timestamped annotation of a span
+type SpanEvent struct {
+	Name string
+	Time time.Time
+}
+
+// Span is a single timed operation within a trace. Spans of unsampled traces are not recorded nor exported,
+// but still carry trace identifiers such that sampling decisions propagate to child spans and remote services.
+// All methods are safe to call on a nil span, which is what StartSpan returns when tracing is disabled.
+type Span struct {
+	TraceID      [16]byte
+	SpanID       [8]byte
+	ParentSpanID [8]byte
+	Name         string
+	Kind         SpanKind
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]interface{}
+	Events       []SpanEvent
+	ErrorMessage string
+	Sampled      bool
+
+	mutex sync.Mutex
+	ended bool
+}
+
+// IsEnabled returns true when an OTLP endpoint is configured
+func IsEnabled() bool {
+	return config.Config.TracingOTLPEndpoint != ""
+}
+
+func newSpanID() (spanID [8]byte) {
+	rand.Read(spanID[:])
+	return spanID
+}
+
+func newTraceID() (traceID [16]byte) {
+	rand.Read(traceID[:])
+	return traceID
+}
+
+func shouldSample() bool {
+	return mathrand.Float64() < config.Config.TracingSampleRatio
+}
+
+func newSpan(name string, kind SpanKind) *Span {
+	return &Span{
+		SpanID:     newSpanID(),
+		Name:       name,
+		Kind:       kind,
+		StartTime:  time.Now(),
+		Attributes: make(map[string]interface{}),
+	}
+}
+
+// StartSpan starts a span as a child of given parent. With no parent, a new trace is started, and the
+// sampling decision is made. Returns nil when tracing is disabled.
+func StartSpan(name string, parent *Span) *Span {
+	return startSpan(name, SpanKindInternal, parent)
+}
+
+func startSpan(name string, kind SpanKind, parent *Span) *Span {
+	if !IsEnabled() {
+		return nil
+	}
+	span := newSpan(name, kind)
+	if parent == nil {
+		span.TraceID = newTraceID()
+		span.Sampled = shouldSample()
+	} else {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+		span.Sampled = parent.Sampled
+	}
+	return span
+}
+
+// StartServerSpan starts a span for an incoming request, continuing the trace depicted by given
+// traceparent header value, if valid. Otherwise a new trace is started.
+func StartServerSpan(name string, traceparent string) *Span {
+	if !IsEnabled() {
+		return nil
+	}
+	remoteParent, err := ParseTraceparent(traceparent)
+	if err != nil {
+		remoteParent = nil
+	}
+	return startSpan(name, SpanKindServer, remoteParent)
+}
+
+// ParseTraceparent parses a W3C traceparent header value, e.g.
+// `00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01`, into a (remote, non recording) span
+func ParseTraceparent(traceparent string) (*Span, error) {
+	tokens := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(tokens) < 4 || len(tokens[0]) != 2 || tokens[0] == "ff" {
+		return nil, fmt.Errorf("Invalid traceparent: %s", traceparent)
+	}
+	if tokens[0] == "00" && len(tokens) != 4 {
+		return nil, fmt.Errorf("Invalid traceparent: %s", traceparent)
+	}
+	span := &Span{}
+	if n, err := hex.Decode(span.TraceID[:], []byte(tokens[1])); err != nil || n != len(span.TraceID) || len(tokens[1]) != 2*len(span.TraceID) {
+		return nil, fmt.Errorf("Invalid trace id in traceparent: %s", traceparent)
+	}
+	if n, err := hex.Decode(span.SpanID[:], []byte(tokens[2])); err != nil || n != len(span.SpanID) || len(tokens[2]) != 2*len(span.SpanID) {
+		return nil, fmt.Errorf("Invalid parent id in traceparent: %s", traceparent)
+	}
+	if span.TraceID == [16]byte{} || span.SpanID == [8]byte{} {
+		return nil, fmt.Errorf("Invalid all-zero id in traceparent: %s", traceparent)
+	}
+	flags, err := hex.DecodeString(tokens[3])
+	if err != nil || len(flags) != 1 {
+		return nil, fmt.Errorf("Invalid trace flags in traceparent: %s", traceparent)
+	}
+	span.Sampled = (flags[0]&0x01 == 0x01)
+	return span, nil
+}
+
+// Traceparent returns the W3C traceparent header value by which this span is propagated
+func (this *Span) Traceparent() string {
+	if this == nil {
+		return ""
+	}
+	flags := "00"
+	if this.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(this.TraceID[:]), hex.EncodeToString(this.SpanID[:]), flags)
+}
+
+func (this *Span) isRecording() bool {
+	return this != nil && this.Sampled && !this.ended
+}
+
+// SetAttribute sets an attribute on this span. Supported values are strings, bools, integers and floats;
+// other values are formatted as strings.
+func (this *Span) SetAttribute(key string, value interface{}) *Span {
+	if this == nil {
+		return this
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.isRecording() {
+		this.Attributes[key] = value
+	}
+	return this
+}
+
+// AddEvent adds a timestamped event to this span
+func (this *Span) AddEvent(name string) {
+	if this == nil {
+		return
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.isRecording() {
+		this.Events = append(this.Events, SpanEvent{Name: name, Time: time.Now()})
+	}
+}
+
+// SetError marks this span as failed with given error. A nil error is ignored.
+func (this *Span) SetError(err error) {
+	if this == nil || err == nil {
+		return
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	if this.isRecording() {
+		this.ErrorMessage = err.Error()
+	}
+}
+
+// End completes this span and queues it for export. Subsequent calls have no effect.
+func (this *Span) End() {
+	if this == nil {
+		return
+	}
+	this.mutex.Lock()
+	if !this.isRecording() {
+		this.mutex.Unlock()
+		return
+	}
+	this.EndTime = time.Now()
+	this.ended = true
+	this.mutex.Unlock()
+	exportSpan(this)
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan returns a copy of given context carrying given span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span carried by given context, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// StartSpanFromContext starts a span as a child of the span carried by given context, and returns
+// a context carrying the new span
+func StartSpanFromContext(ctx context.Context, name string) (context.Context, *Span) {
+	span := StartSpan(name, SpanFromContext(ctx))
+	if span == nil {
+		return ctx, nil
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// StartClientSpanFromContext starts a client span, e.g. of a query, as a child of the span carried by given context.
+// Unlike StartSpanFromContext, no span is started when the context carries none, such that individual queries
+// do not make for traces of their own.
+func StartClientSpanFromContext(ctx context.Context, name string) *Span {
+	parent := SpanFromContext(ctx)
+	if parent == nil {
+		return nil
+	}
+	return startSpan(name, SpanKindClient, parent)
+}
+
+// StatementOperation returns the operation of given SQL statement, e.g. "change master" or "select", by which
+// statement spans are named. The statement itself is not recorded, as it may include credentials.
+func StatementOperation(statement string) string {
+	tokens := strings.Fields(strings.ToLower(statement))
+	if len(tokens) == 0 {
+		return ""
+	}
+	switch tokens[0] {
+	case "start", "stop", "reset", "change", "show", "set", "flush", "purge":
+		if len(tokens) > 1 {
+			return strings.Join(tokens[0:2], " ")
+		}
+	}
+	return tokens[0]
+}
+
+// DetachedContext returns a background context carrying the span of given context, if any, such that work
+// traced by way of it is a child of that span, yet is not cancelled along with given context
+func DetachedContext(ctx context.Context) context.Context {
+	return ContextWithSpan(context.Background(), SpanFromContext(ctx))
+}
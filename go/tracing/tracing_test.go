@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func init() {
+	config.Config.TracingOTLPEndpoint = "http://localhost:4318"
+	config.Config.TracingSampleRatio = 1
+}
+
+func TestParseTraceparent(t *testing.T) {
+	{
+		span, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(span.Sampled)
+		test.S(t).ExpectEquals(span.Traceparent(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	}
+	{
+		span, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(span.Sampled)
+	}
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+	for _, traceparent := range invalid {
+		_, err := ParseTraceparent(traceparent)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestStartSpan(t *testing.T) {
+	root := StartSpan("root", nil)
+	test.S(t).ExpectTrue(root.Sampled)
+	child := StartSpan("child", root)
+	test.S(t).ExpectEquals(child.TraceID, root.TraceID)
+	test.S(t).ExpectEquals(child.ParentSpanID, root.SpanID)
+	test.S(t).ExpectTrue(child.SpanID != root.SpanID)
+
+	remote := StartServerSpan("server", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	test.S(t).ExpectFalse(remote.Sampled)
+	test.S(t).ExpectTrue(strings.HasPrefix(remote.Traceparent(), "00-4bf92f3577b34da6a3ce929d0e0e4736-"))
+	remote.SetAttribute("key", "value")
+	test.S(t).ExpectEquals(len(remote.Attributes), 0)
+}
+
+func TestNilSpan(t *testing.T) {
+	var span *Span
+	span.SetAttribute("key", "value")
+	span.AddEvent("event")
+	span.End()
+	test.S(t).ExpectEquals(span.Traceparent(), "")
+	test.S(t).ExpectTrue(StartSpan("child", span) != nil)
+}
+
+func TestStartClientSpanFromContext(t *testing.T) {
+	test.S(t).ExpectTrue(StartClientSpanFromContext(context.Background(), "select") == nil)
+
+	ctx, parent := StartSpanFromContext(context.Background(), "operation")
+	span := StartClientSpanFromContext(ctx, "select")
+	test.S(t).ExpectEquals(span.Kind, SpanKindClient)
+	test.S(t).ExpectEquals(span.TraceID, parent.TraceID)
+	test.S(t).ExpectEquals(span.ParentSpanID, parent.SpanID)
+}
+
+func TestStatementOperation(t *testing.T) {
+	test.S(t).ExpectEquals(StatementOperation("select @@global.read_only"), "select")
+	test.S(t).ExpectEquals(StatementOperation("  stop slave io_thread"), "stop slave")
+	test.S(t).ExpectEquals(StatementOperation("change master to master_password='secret'"), "change master")
+	test.S(t).ExpectEquals(StatementOperation("insert ignore into topology_recovery (uid) values (?)"), "insert")
+	test.S(t).ExpectEquals(StatementOperation("start"), "start")
+	test.S(t).ExpectEquals(StatementOperation(""), "")
+}
+
+func TestToOTLPRequest(t *testing.T) {
+	parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	span := StartSpan("recovery", parent)
+	span.SetAttribute("instance", "db-1:3306")
+	span.SetAttribute("successful", false)
+	span.AddEvent("regrouping replicas")
+	span.SetError(json.Unmarshal([]byte("{"), &struct{}{}))
+	span.End()
+
+	body, err := json.Marshal(toOTLPRequest([]*Span{span}))
+	test.S(t).ExpectNil(err)
+	request := &otlpRequest{}
+	test.S(t).ExpectNil(json.Unmarshal(body, request))
+	test.S(t).ExpectEquals(len(request.ResourceSpans), 1)
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	test.S(t).ExpectEquals(len(spans), 1)
+	test.S(t).ExpectEquals(spans[0].TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	test.S(t).ExpectEquals(spans[0].ParentSpanID, "00f067aa0ba902b7")
+	test.S(t).ExpectEquals(spans[0].Name, "recovery")
+	test.S(t).ExpectEquals(len(spans[0].Attributes), 2)
+	test.S(t).ExpectEquals(len(spans[0].Events), 1)
+	test.S(t).ExpectEquals(spans[0].Status.Code, 2)
+}
@@ -0,0 +1,147 @@
+/*
+   Copyright 2020 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// WorkerPool runs functions concurrently, with at most a given number of them running at any time.
+// Errors returned by functions, as well as panics, are collected and returned by Wait().
+// Wait() returns once all functions complete, or once the pool's context is done, whichever comes first.
+// In the latter case, functions still running are abandoned: they are left to complete in the background,
+// their outcome discarded and reported as an error. Functions yet to start do not start at all.
+type WorkerPool struct {
+	ctx         context.Context
+	concurrency chan bool
+	waitGroup   sync.WaitGroup
+	mutex       sync.Mutex
+	pending     map[string]int
+	errs        []error
+	closed      bool
+}
+
+// NewWorkerPool creates a pool bound by given context, running up to maxConcurrency functions at a time.
+// A zero maxConcurrency is treated as 1.
+func NewWorkerPool(ctx context.Context, maxConcurrency uint) *WorkerPool {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if maxConcurrency == 0 {
+		maxConcurrency = 1
+	}
+	return &WorkerPool{
+		ctx:         ctx,
+		concurrency: make(chan bool, maxConcurrency),
+		pending:     make(map[string]int),
+		errs:        []error{},
+	}
+}
+
+// Go schedules given function to run in the pool. name identifies the function in reported errors.
+func (this *WorkerPool) Go(name string, f func() error) {
+	this.mutex.Lock()
+	this.pending[name]++
+	this.mutex.Unlock()
+
+	this.waitGroup.Add(1)
+	go func() {
+		defer this.waitGroup.Done()
+		select {
+		case this.concurrency <- true:
+			defer func() { <-this.concurrency }()
+		case <-this.ctx.Done():
+			this.complete(name, fmt.Errorf("%s: not started: %+v", name, this.ctx.Err()))
+			return
+		}
+		this.complete(name, this.run(name, f))
+	}()
+}
+
+// run invokes given function, converting a panic into an error
+func (this *WorkerPool) run(name string, f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: panic: %+v", name, r)
+		}
+	}()
+	return f()
+}
+
+// complete records the outcome of a function, unless the pool has already been waited upon
+func (this *WorkerPool) complete(name string, err error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.closed {
+		return
+	}
+	this.pending[name]--
+	if err != nil {
+		this.errs = append(this.errs, err)
+	}
+}
+
+// Synchronized runs given function under the pool's lock, unless Wait() has already returned, in which
+// case the function does not run. It returns whether the function ran. Functions running in the pool
+// should use Synchronized to update any state shared with the caller, so that abandoned functions do
+// not modify such state once the caller has moved on.
+func (this *WorkerPool) Synchronized(f func()) bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.closed {
+		return false
+	}
+	f()
+	return true
+}
+
+// Wait blocks until all functions complete or the pool's context is done, and returns the collected errors,
+// including one error per abandoned function.
+func (this *WorkerPool) Wait() []error {
+	done := make(chan struct{})
+	go func() {
+		this.waitGroup.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-this.ctx.Done():
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.closed = true
+	names := []string{}
+	for name, count := range this.pending {
+		if count > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for i := 0; i < this.pending[name]; i++ {
+			this.errs = append(this.errs, fmt.Errorf("%s: abandoned: %+v", name, this.ctx.Err()))
+		}
+	}
+	return this.errs
+}
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestWorkerPoolCollectsErrors(t *testing.T) {
+	pool := NewWorkerPool(context.Background(), 2)
+	var count int32
+	for i := 0; i < 10; i++ {
+		i := i
+		pool.Go(fmt.Sprintf("task-%d", i), func() error {
+			atomic.AddInt32(&count, 1)
+			if i%5 == 0 {
+				return fmt.Errorf("task-%d failed", i)
+			}
+			return nil
+		})
+	}
+	errs := pool.Wait()
+	test.S(t).ExpectEquals(atomic.LoadInt32(&count), int32(10))
+	test.S(t).ExpectEquals(len(errs), 2)
+}
+
+func TestWorkerPoolBoundsConcurrency(t *testing.T) {
+	pool := NewWorkerPool(context.Background(), 3)
+	var running, maxRunning int32
+	for i := 0; i < 20; i++ {
+		pool.Go("task", func() error {
+			current := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return nil
+		})
+	}
+	test.S(t).ExpectEquals(len(pool.Wait()), 0)
+	test.S(t).ExpectTrue(atomic.LoadInt32(&maxRunning) <= 3)
+}
+
+func TestWorkerPoolRecoversPanic(t *testing.T) {
+	pool := NewWorkerPool(context.Background(), 1)
+	pool.Go("panicky", func() error { panic("oops") })
+	pool.Go("fine", func() error { return nil })
+	errs := pool.Wait()
+	test.S(t).ExpectEquals(len(errs), 1)
+	test.S(t).ExpectEquals(errs[0].Error(), "panicky: panic: oops")
+}
+
+func TestWorkerPoolAbandonsHungFunctions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := make(chan bool)
+	hang := make(chan bool)
+	defer close(hang)
+
+	pool := NewWorkerPool(ctx, 1)
+	results := []string{}
+	pool.Go("hung", func() error {
+		started <- true
+		<-hang
+		pool.Synchronized(func() { results = append(results, "hung") })
+		return nil
+	})
+	<-started
+	pool.Go("queued", func() error {
+		pool.Synchronized(func() { results = append(results, "queued") })
+		return nil
+	})
+	errs := pool.Wait()
+	test.S(t).ExpectEquals(len(errs), 2)
+	test.S(t).ExpectEquals(len(results), 0)
+	test.S(t).ExpectFalse(pool.Synchronized(func() {}))
+}
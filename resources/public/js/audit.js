@@ -44,4 +44,26 @@ $(document).ready(function () {
             return false;
         });
     }
+    function followAudit() {
+        if (currentPage() > 0 || typeof(EventSource) === "undefined") {
+            return;
+        }
+        var eventSource = new EventSource(appUrl("/api/events-stream"));
+        eventSource.onmessage = function (message) {
+            var event = JSON.parse(message.data);
+            if (event.Kind != "outcome") {
+                return;
+            }
+            if (auditHostname() && (event.Hostname != auditHostname() || event.Port != auditPort())) {
+                return;
+            }
+            var row = jQuery('<tr/>');
+            jQuery('<td/>', { text: event.Timestamp.replace("T", " ").substring(0, 19) }).appendTo(row);
+            jQuery('<td/>', { text: event.Type }).appendTo(row);
+            jQuery('<td/>', { text: event.Hostname+":"+event.Port }).appendTo(row);
+            jQuery('<td/>', { text: event.Message }).appendTo(row);
+            row.prependTo('#audit tbody');
+        };
+    }
+    followAudit();
 });
@@ -11,6 +11,7 @@ var interestingAnalysis = {
 	"AllMasterSlavesStale" : true,
 	"DeadCoMaster" : true,
 	"DeadCoMasterAndSomeSlaves" : true,
+	"DeadReplicationGroupPrimary" : true,
 	"DeadIntermediateMaster" : true,
 	"DeadIntermediateMasterWithSingleSlaveFailingToConnect" : true,
 	"DeadIntermediateMasterWithSingleSlave" : true,
@@ -46,5 +47,9 @@ var errorMapping = {
 	"errant_gtid": {
 		"badge": "label-errant",
 		"description": "Errant GTID"
+	},
+	"clock_skew": {
+		"badge": "label-warning",
+		"description": "Clock skew"
 	}
 };
@@ -256,6 +256,13 @@ function openNodeModal(node) {
       $('#node_modal button[data-btn=reattach-replica-master-host]').appendTo(hiddenZone);
     }
     $('#node_modal button[data-btn=reset-slave]').appendTo(td.find("div"))
+    var masterTd = td;
+    $('#node_modal button[data-btn=undo-last-relocation]').appendTo(hiddenZone);
+    $.get(appUrl("/api/last-relocation/") + node.Key.Hostname + "/" + node.Key.Port, function(relocationResult) {
+      if (relocationResult.Code == "OK") {
+        $('#node_modal button[data-btn=undo-last-relocation]').attr("title", "Undo: " + relocationResult.Message).appendTo(masterTd.find("div"));
+      }
+    }, "json");
 
     td = addNodeModalDataAttribute("Replication running", booleanString(node.replicationRunning));
     $('#node_modal button[data-btn=start-slave]').appendTo(td.find("div"))
@@ -303,6 +310,7 @@ function openNodeModal(node) {
     }
   } else {
     $('#node_modal button[data-btn=reset-slave]').appendTo(hiddenZone);
+    $('#node_modal button[data-btn=undo-last-relocation]').appendTo(hiddenZone);
     $('#node_modal button[data-btn=reattach-replica-master-host]').appendTo(hiddenZone);
     $('#node_modal button[data-btn=skip-query]').appendTo(hiddenZone);
     $('#node_modal button[data-btn=detach-replica]').appendTo(hiddenZone)
@@ -358,6 +366,8 @@ function openNodeModal(node) {
 
   addNodeModalDataAttribute("Uptime", node.Uptime);
   addNodeModalDataAttribute("Allow TLS", node.AllowTLS);
+  addNodeModalDataAttribute("Binlog encryption", booleanString(node.BinlogEncryption));
+  addNodeModalDataAttribute("Relay log encryption", booleanString(node.RelayLogEncryption));
   addNodeModalDataAttribute("Cluster",
     '<a href="' + appUrl('/web/cluster/' + node.ClusterName) + '">' + node.ClusterName + '</a>');
   addNodeModalDataAttribute("Audit",
@@ -405,6 +415,9 @@ function openNodeModal(node) {
   $('#node_modal button[data-btn=reattach-replica-master-host]').click(function() {
     apiCommand("/api/reattach-replica-master-host/" + node.Key.Hostname + "/" + node.Key.Port);
   });
+  $('#node_modal button[data-btn=undo-last-relocation]').click(function() {
+    apiCommand("/api/undo-last-relocation/" + node.Key.Hostname + "/" + node.Key.Port);
+  });
   $('#node_modal button[data-btn=reset-slave]').click(function() {
     var message = "<p>Are you sure you wish to reset <code><strong>" + node.Key.Hostname + ":" + node.Key.Port +
       "</strong></code>?" +
@@ -860,6 +873,9 @@ function renderInstanceElement(popoverElement, instance, renderType) {
     if (instance.HasReplicationFilters) {
       popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-filter" title="Using replication filters"></span> ');
     }
+    if (instance.BinlogEncryption) {
+      popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-lock" title="Binlog encryption enabled"></span> ');
+    }
     if (instance.SemiSyncMasterEnabled) {
       popoverElement.find("h3 div.pull-right").prepend('<span class="glyphicon glyphicon-check" title="Semi sync enabled (master side)"></span> ');
     }